@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/users"
+	"github.com/NatthawutSK/ri-shop/modules/users/usersRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/users/usersUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+	"github.com/NatthawutSK/ri-shop/pkg/seed"
+	"github.com/jmoiron/sqlx"
+)
+
+func createAdmin(ctx context.Context, cfg config.IConfig, db *sqlx.DB, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	req := new(users.UserRegisterReq)
+	fs.StringVar(&req.Email, "email", "", "email of the admin")
+	fs.StringVar(&req.Username, "username", "", "username of the admin")
+	fs.StringVar(&req.Password, "password", "", "password of the admin")
+	fs.Parse(args)
+
+	if !req.IsEmail() {
+		return fmt.Errorf("email is invalid")
+	}
+	if req.Username == "" || req.Password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+
+	usecase := usersUsecases.UserUsecaseHandler(usersRepositories.UsersRepositoryHandler(db), cfg)
+	passport, err := usecase.InsertAdmin(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("admin %s created, id %s\n", passport.User.Email, passport.User.Id)
+	return nil
+}
+
+func importUsers(ctx context.Context, cfg config.IConfig, db *sqlx.DB, args []string) error {
+	fs := flag.NewFlagSet("import-users", flag.ExitOnError)
+	path := fs.String("file", "", "csv file of users")
+	dryRun := fs.Bool("dry-run", false, "only validate the file")
+	fs.Parse(args)
+
+	if *path == "" {
+		return fmt.Errorf("file is required")
+	}
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	usecase := usersUsecases.UserUsecaseHandler(usersRepositories.UsersRepositoryHandler(db), cfg)
+	report, err := usecase.ImportUsers(ctx, file, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	// report เป็น json เดียวกับ POST /users/import
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func runSeed(ctx context.Context, cfg config.IConfig, db *sqlx.DB, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	fs.Parse(args)
+
+	if cfg.App().IsProduction() {
+		return fmt.Errorf("seed is not allowed in production")
+	}
+	return seed.Run(db)
+}
+
+func retryEmails(ctx context.Context, cfg config.IConfig, db *sqlx.DB, args []string) error {
+	fs := flag.NewFlagSet("retry-emails", flag.ExitOnError)
+	fs.Parse(args)
+
+	usecase := notificationsUsecases.NotificationsUsecase(cfg.Mail(), notificationsRepositories.NotificationsRepository(db))
+	retried, err := usecase.RetryFailedEmail(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d emails queued again\n", retried)
+	return nil
+}
+
+func retryFileDeletions(ctx context.Context, cfg config.IConfig, db *sqlx.DB, args []string) error {
+	fs := flag.NewFlagSet("retry-file-deletions", flag.ExitOnError)
+	fs.Parse(args)
+
+	usecase := filesUsecases.FilesUsecase(cfg, filesRepositories.FilesRepository(db), filesUsecases.FileScanner(cfg.Scan()), filesUsecases.CdnPurger(cfg.Cdn()), filesUsecases.ImageTranscoders(cfg.Image()), locks.DbLocker(db))
+	retried, err := usecase.RetryFileDeletion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d files deleted\n", retried)
+	return nil
+}
@@ -0,0 +1,85 @@
+// rishopctl run back-office operations against the same database and config as the server,
+// e.g. go run ./cmd/rishopctl -env .env create-admin -email admin@ri-shop.com -username admin -password secret
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+// command is one sub command, run get the context of the command and the arguments after the command name
+type command struct {
+	usage string
+	run   func(ctx context.Context, cfg config.IConfig, db *sqlx.DB, args []string) error
+}
+
+var commands = map[string]*command{
+	"create-admin": {
+		usage: "create an admin user: -email -username -password",
+		run:   createAdmin,
+	},
+	"import-users": {
+		usage: "seed users from a csv file like POST /users/import: -file [-dry-run]",
+		run:   importUsers,
+	},
+	"seed": {
+		usage: "upsert the development fixtures, never in production",
+		run:   runSeed,
+	},
+	"retry-emails": {
+		usage: "queue the failed emails again, the running server sends them",
+		run:   retryEmails,
+	},
+	"retry-file-deletions": {
+		usage: "delete again the replaced files which gave up after 10 attempts",
+		run:   retryFileDeletions,
+	},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rishopctl [-env .env] <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-22s %s\n", name, commands[name].usage)
+	}
+}
+
+func main() {
+	envPath := flag.String("env", ".env", "path of the env file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig(*envPath)
+
+	db := databases.DbConnect(cfg.Db())
+	defer db.Close()
+
+	if err := cmd.run(context.Background(), cfg, db, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		db.Close()
+		os.Exit(1)
+	}
+}
@@ -4,21 +4,46 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
+// LoadConfig read the layered sources of readSources, the .env file at path is optional when every
+// setting comes from the environment
 func LoadConfig(path string) IConfig {
-	envMap, err := godotenv.Read(path)
+	envMap, files, err := readSources(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	current, err := newSnapshot(envMap)
 	if err != nil {
-		log.Fatalf("load dotenv failed: %v", err)
+		log.Fatal(err)
 	}
+	current.generation = 1
+	live := new(atomic.Pointer[snapshot])
+	live.Store(current)
 
 	return &config{
+		path:          path,
+		envMap:        envMap,
+		files:         files,
+		modTimes:      modTimes(files),
+		watchInterval: time.Duration(envInt(envMap, "CONFIG_WATCH_SECONDS", 5)) * time.Second,
+		secretsInterval: func() time.Duration {
+			if envMap["SECRETS_PROVIDER"] == "" {
+				return 0
+			}
+			return time.Duration(envInt(envMap, "SECRETS_REFRESH_MINUTES", 15)) * time.Minute
+		}(),
+		snapshot: live,
 		app: &app{
-			host: envMap["APP_HOST"],
+			snapshot: live,
+			host:     envMap["APP_HOST"],
 			port: func() int {
 				p, err := strconv.Atoi(envMap["APP_PORT"])
 				if err != nil {
@@ -49,14 +74,39 @@ func LoadConfig(path string) IConfig {
 				}
 				return b
 			}(),
-			fileLimit: func() int {
-				b, err := strconv.Atoi(envMap["APP_FILE_LIMIT"])
+			gcpbucket: envMap["APP_GCP_BUCKET"],
+			currency: func() string {
+				if envMap["APP_CURRENCY"] == "" {
+					return "THB"
+				}
+				return envMap["APP_CURRENCY"]
+			}(),
+			taxRate: func() float64 {
+				if envMap["APP_TAX_RATE"] == "" {
+					return 0
+				}
+				f, err := strconv.ParseFloat(envMap["APP_TAX_RATE"], 64)
 				if err != nil {
-					log.Fatalf("load file limit failed: %v", err)
+					log.Fatalf("load tax rate failed: %v", err)
 				}
-				return b
+				return f
+			}(),
+			grpcPort: envInt(envMap, "APP_GRPC_PORT", 0),
+			publicUrl: func() string {
+				if envMap["APP_PUBLIC_URL"] == "" {
+					return fmt.Sprintf("http://%s:%s", envMap["APP_HOST"], envMap["APP_PORT"])
+				}
+				return strings.TrimSuffix(envMap["APP_PUBLIC_URL"], "/")
+			}(),
+			compressLevel: envInt(envMap, "APP_COMPRESS_LEVEL", 0),
+			staticMaxAge:  envInt(envMap, "APP_STATIC_MAX_AGE", 7*24*60*60),
+			storeDomain:   strings.ToLower(strings.TrimPrefix(envMap["APP_STORE_DOMAIN"], ".")),
+			env: func() string {
+				if envMap["APP_ENV"] == "" {
+					return "development"
+				}
+				return strings.ToLower(envMap["APP_ENV"])
 			}(),
-			gcpbucket: envMap["APP_GCP_BUCKET"],
 		},
 		db: &db{
 			host: envMap["DB_HOST"],
@@ -69,7 +119,7 @@ func LoadConfig(path string) IConfig {
 			}(),
 			protocol: envMap["DB_PROTOCOL"],
 			username: envMap["DB_USERNAME"],
-			password: envMap["DB_PASSWORD"],
+			snapshot: live,
 			database: envMap["DB_DATABASE"],
 			sslMode:  envMap["DB_SSL_MODE"],
 			maxConnections: func() int {
@@ -79,11 +129,13 @@ func LoadConfig(path string) IConfig {
 				}
 				return m
 			}(),
+			maxIdleConnections: envInt(envMap, "DB_MAX_IDLE_CONNECTIONS", 10),
+			connMaxLifetime:    time.Duration(envInt(envMap, "DB_CONN_MAX_LIFETIME_SECONDS", 30*60)) * time.Second,
+			connMaxIdleTime:    time.Duration(envInt(envMap, "DB_CONN_MAX_IDLE_SECONDS", 5*60)) * time.Second,
+			slowQuery:          time.Duration(envInt(envMap, "DB_SLOW_QUERY_MS", 500)) * time.Millisecond,
 		},
 		jwt: &jwt{
-			adminKey:  envMap["JWT_ADMIN_KEY"],
-			secertKey: envMap["JWT_SECRET_KEY"],
-			apiKey:    envMap["JWT_API_KEY"],
+			snapshot: live,
 			accessExpiresAt: func() int {
 				t, err := strconv.Atoi(envMap["JWT_ACCESS_EXPIRES"])
 				if err != nil {
@@ -99,19 +151,386 @@ func LoadConfig(path string) IConfig {
 				return t
 			}(),
 		},
+		shipping: &shipping{
+			flatRate: func() float64 {
+				if envMap["SHIPPING_FLAT_RATE"] == "" {
+					return 50
+				}
+				f, err := strconv.ParseFloat(envMap["SHIPPING_FLAT_RATE"], 64)
+				if err != nil {
+					log.Fatalf("load shipping flat rate failed: %v", err)
+				}
+				return f
+			}(),
+			freeOver: func() float64 {
+				if envMap["SHIPPING_FREE_OVER"] == "" {
+					return 0
+				}
+				f, err := strconv.ParseFloat(envMap["SHIPPING_FREE_OVER"], 64)
+				if err != nil {
+					log.Fatalf("load shipping free over failed: %v", err)
+				}
+				return f
+			}(),
+			originCountry:    envMap["SHIPPING_ORIGIN_COUNTRY"],
+			originPostalCode: envMap["SHIPPING_ORIGIN_POSTAL_CODE"],
+			carrierUrl:       envMap["SHIPPING_CARRIER_URL"],
+			snapshot:         live,
+		},
+		chaos: &chaos{
+			enabled: envMap["CHAOS_ENABLED"] == "true",
+			latency: func() time.Duration {
+				if envMap["CHAOS_LATENCY_MS"] == "" {
+					return 0
+				}
+				t, err := strconv.Atoi(envMap["CHAOS_LATENCY_MS"])
+				if err != nil {
+					log.Fatalf("load chaos latency failed: %v", err)
+				}
+				return time.Duration(t) * time.Millisecond
+			}(),
+			errorRate: func() float64 {
+				if envMap["CHAOS_ERROR_RATE"] == "" {
+					return 0
+				}
+				f, err := strconv.ParseFloat(envMap["CHAOS_ERROR_RATE"], 64)
+				if err != nil {
+					log.Fatalf("load chaos error rate failed: %v", err)
+				}
+				return f
+			}(),
+			dependencyFailureRate: func() float64 {
+				if envMap["CHAOS_DEPENDENCY_FAILURE_RATE"] == "" {
+					return 0
+				}
+				f, err := strconv.ParseFloat(envMap["CHAOS_DEPENDENCY_FAILURE_RATE"], 64)
+				if err != nil {
+					log.Fatalf("load chaos dependency failure rate failed: %v", err)
+				}
+				return f
+			}(),
+			routes: func() []string {
+				routes := make([]string, 0)
+				for _, route := range strings.Split(envMap["CHAOS_ROUTES"], ",") {
+					if route = strings.TrimSpace(route); route != "" {
+						routes = append(routes, route)
+					}
+				}
+				return routes
+			}(),
+		},
+		mail: &mail{
+			snapshot:     live,
+			from:         envMap["MAIL_FROM"],
+			smtpHost:     envMap["MAIL_SMTP_HOST"],
+			smtpPort:     envInt(envMap, "MAIL_SMTP_PORT", 587),
+			smtpUsername: envMap["MAIL_SMTP_USERNAME"],
+			apiUrl:       envMap["MAIL_API_URL"],
+		},
+		webhook: &webhook{
+			snapshot:        live,
+			tolerance:       time.Duration(envInt(envMap, "WEBHOOK_TOLERANCE_SECONDS", 300)) * time.Second,
+			duplicateWindow: time.Duration(envInt(envMap, "WEBHOOK_DUPLICATE_WINDOW_MINUTES", 10)) * time.Minute,
+			autoRefund:      envMap["WEBHOOK_DUPLICATE_AUTO_REFUND"] != "false",
+		},
+		cache: &cache{
+			controls: func() map[string]string {
+				controls := make(map[string]string)
+				for key, value := range envMap {
+					if group, ok := strings.CutPrefix(key, "CACHE_CONTROL_"); ok && value != "" {
+						controls[strings.ToLower(group)] = value
+					}
+				}
+				return controls
+			}(),
+			productTtl:   time.Duration(envInt(envMap, "CACHE_PRODUCT_TTL_SECONDS", 60)) * time.Second,
+			warmProducts: envInt(envMap, "CACHE_WARM_PRODUCTS", 100),
+			summaryTtl:   time.Duration(envInt(envMap, "CACHE_SUMMARY_TTL_SECONDS", 300)) * time.Second,
+		},
+		inventory: &inventory{
+			lowStockThreshold: envInt(envMap, "INVENTORY_LOW_STOCK_THRESHOLD", 5),
+			alertEmails: func() []string {
+				emails := make([]string, 0)
+				for _, email := range strings.Split(envMap["INVENTORY_ALERT_EMAILS"], ",") {
+					if email = strings.TrimSpace(email); email != "" {
+						emails = append(emails, email)
+					}
+				}
+				return emails
+			}(),
+			alertWebhookUrl:    envMap["INVENTORY_ALERT_WEBHOOK_URL"],
+			alertWebhookSecret: envMap["INVENTORY_ALERT_WEBHOOK_SECRET"],
+		},
+		scan: &scan{
+			provider: func() string {
+				provider := strings.ToLower(envMap["FILE_SCAN_PROVIDER"])
+				if provider != "" && provider != "clamav" && provider != "icap" {
+					log.Fatalf("load file_scan_provider failed: %s is not supported", provider)
+				}
+				return provider
+			}(),
+			addr: envMap["FILE_SCAN_ADDR"],
+			icapService: func() string {
+				if envMap["FILE_SCAN_ICAP_SERVICE"] == "" {
+					return "avscan"
+				}
+				return envMap["FILE_SCAN_ICAP_SERVICE"]
+			}(),
+			timeout:    time.Duration(envInt(envMap, "FILE_SCAN_TIMEOUT_SECONDS", 30)) * time.Second,
+			failOpen:   envMap["FILE_SCAN_FAIL_OPEN"] == "true",
+			quarantine: envMap["FILE_SCAN_QUARANTINE"] == "true",
+		},
+		image: &image{
+			variants: func() []string {
+				variants := make([]string, 0)
+				for _, format := range strings.Split(envMap["IMAGE_VARIANTS"], ",") {
+					format = strings.ToLower(strings.TrimSpace(format))
+					if format == "" {
+						continue
+					}
+					if format != "webp" && format != "avif" {
+						log.Fatalf("load image_variants failed: %s is not supported", format)
+					}
+					variants = append(variants, format)
+				}
+				return variants
+			}(),
+			cwebpBin:    envString(envMap, "IMAGE_CWEBP_BIN", "cwebp"),
+			avifencBin:  envString(envMap, "IMAGE_AVIFENC_BIN", "avifenc"),
+			webpQuality: envInt(envMap, "IMAGE_WEBP_QUALITY", 80),
+			avifQuality: envInt(envMap, "IMAGE_AVIF_QUALITY", 60),
+			workers:     envInt(envMap, "IMAGE_TRANSCODE_WORKERS", 2),
+			timeout:     time.Duration(envInt(envMap, "IMAGE_TRANSCODE_TIMEOUT_SECONDS", 60)) * time.Second,
+		},
+		cdn: &cdn{
+			baseUrl: strings.TrimRight(envMap["CDN_BASE_URL"], "/"),
+			provider: func() string {
+				provider := strings.ToLower(envMap["CDN_PROVIDER"])
+				if provider != "" && provider != "cloudcdn" && provider != "cloudflare" {
+					log.Fatalf("load cdn_provider failed: %s is not supported", provider)
+				}
+				if provider != "" && envMap["CDN_BASE_URL"] == "" {
+					log.Fatalf("load cdn_provider failed: cdn_base_url is required")
+				}
+				return provider
+			}(),
+			gcpProject:      envMap["CDN_GCP_PROJECT"],
+			urlMap:          envMap["CDN_URL_MAP"],
+			cloudflareZone:  envMap["CDN_CLOUDFLARE_ZONE_ID"],
+			cloudflareToken: envMap["CDN_CLOUDFLARE_TOKEN"],
+			timeout:         time.Duration(envInt(envMap, "CDN_TIMEOUT_SECONDS", 10)) * time.Second,
+		},
+		upload: &upload{
+			defaultLimit: envInt(envMap, "APP_BODY_LIMIT", 0),
+			bodyLimits: func() map[string]int {
+				limits := make(map[string]int)
+				for key, value := range envMap {
+					if group, ok := strings.CutPrefix(key, "BODY_LIMIT_"); ok && value != "" {
+						limit, err := strconv.Atoi(value)
+						if err != nil || limit <= 0 {
+							log.Fatalf("load %s failed: %s is not a positive number of bytes", strings.ToLower(key), value)
+						}
+						limits[strings.ToLower(group)] = limit
+					}
+				}
+				return limits
+			}(),
+			dailyQuota: int64(envInt(envMap, "UPLOAD_DAILY_QUOTA_BYTES", 0)),
+			// ลบ EXIF เป็นค่าเริ่มต้น ปิดได้ด้วย false
+			stripMetadata: envMap["UPLOAD_STRIP_METADATA"] != "false",
+		},
+		security: func() *security {
+			s := &security{
+				cors: &CorsPolicy{
+					Origins:     envString(envMap, "CORS_ORIGINS", "*"),
+					Methods:     envString(envMap, "CORS_METHODS", "GET,POST,HEAD,PUT,DELETE,PATCH"),
+					Credentials: envMap["CORS_CREDENTIALS"] == "true",
+					MaxAge:      envInt(envMap, "CORS_MAX_AGE", 0),
+				},
+				groupCors:         make(map[string]*CorsPolicy),
+				hstsMaxAge:        envInt(envMap, "SECURITY_HSTS_MAX_AGE", 0),
+				hstsSubdomains:    envMap["SECURITY_HSTS_SUBDOMAINS"] == "true",
+				nosniff:           envMap["SECURITY_NOSNIFF"] != "false",
+				staticCsp:         envString(envMap, "SECURITY_STATIC_CSP", "default-src 'none'; img-src 'self'; style-src 'unsafe-inline'; sandbox"),
+				loginLockAfter:    envInt(envMap, "LOGIN_LOCK_AFTER", 5),
+				loginLockBase:     time.Duration(envInt(envMap, "LOGIN_LOCK_BASE_SECONDS", 60)) * time.Second,
+				loginLockMax:      time.Duration(envInt(envMap, "LOGIN_LOCK_MAX_SECONDS", 60*60)) * time.Second,
+				loginCaptchaAfter: envInt(envMap, "LOGIN_CAPTCHA_AFTER", 3),
+			}
+			// a group start from the default policy and override what is set, e.g. CORS_ORIGINS_PRODUCTS
+			group := func(key, prefix string) *CorsPolicy {
+				name := strings.ToLower(strings.TrimPrefix(key, prefix))
+				if _, ok := s.groupCors[name]; !ok {
+					policy := *s.cors
+					s.groupCors[name] = &policy
+				}
+				return s.groupCors[name]
+			}
+			for key, value := range envMap {
+				if value == "" {
+					continue
+				}
+				switch {
+				case strings.HasPrefix(key, "CORS_ORIGINS_"):
+					group(key, "CORS_ORIGINS_").Origins = value
+				case strings.HasPrefix(key, "CORS_METHODS_"):
+					group(key, "CORS_METHODS_").Methods = value
+				case strings.HasPrefix(key, "CORS_CREDENTIALS_"):
+					group(key, "CORS_CREDENTIALS_").Credentials = value == "true"
+				}
+			}
+			// browsers refuse credentials from a wildcard origin
+			if s.cors.Credentials && s.cors.Origins == "*" {
+				log.Fatal("load cors failed: credentials need a list of origins, not *")
+			}
+			for name, policy := range s.groupCors {
+				if policy.Credentials && policy.Origins == "*" {
+					log.Fatalf("load cors of %s failed: credentials need a list of origins, not *", name)
+				}
+			}
+			return s
+		}(),
+		accessLog: &accessLog{
+			file:     envMap["ACCESS_LOG_FILE"],
+			maxBytes: int64(envInt(envMap, "ACCESS_LOG_MAX_MB", 100)) * 1024 * 1024,
+			every:    time.Duration(envInt(envMap, "ACCESS_LOG_ROTATE_HOURS", 24)) * time.Hour,
+			maxFiles: envInt(envMap, "ACCESS_LOG_MAX_FILES", 7),
+			buffer:   envInt(envMap, "ACCESS_LOG_BUFFER", 4096),
+		},
+		timeout: func() *timeout {
+			t := &timeout{
+				defaultBudget: time.Duration(envInt(envMap, "TIMEOUT_MS", 15000)) * time.Millisecond,
+				// product reads are served from the cache or one query, uploads write to the bucket. The
+				// exports stream a file and a cpu profile of the monitor take as long as it is asked. A feed
+				// read every product when it is not in memory
+				budgets: map[string]time.Duration{
+					"products_read":  2 * time.Second,
+					"products_write": 30 * time.Second,
+					"files":          30 * time.Second,
+					"reports":        30 * time.Second,
+					"feeds":          30 * time.Second,
+					"exports":        0,
+					"monitor":        0,
+				},
+			}
+			// e.g. TIMEOUT_REPORTS_MS, or TIMEOUT_PRODUCTS_READ_MS for the GET and HEAD of a group
+			for key := range envMap {
+				name, ok := strings.CutPrefix(key, "TIMEOUT_")
+				if !ok || !strings.HasSuffix(name, "_MS") || envMap[key] == "" {
+					continue
+				}
+				t.budgets[strings.ToLower(strings.TrimSuffix(name, "_MS"))] = time.Duration(envInt(envMap, key, 0)) * time.Millisecond
+			}
+			return t
+		}(),
+		resilience: &resilience{
+			attempts:  envInt(envMap, "RETRY_ATTEMPTS", 3),
+			baseDelay: time.Duration(envInt(envMap, "RETRY_BASE_MS", 100)) * time.Millisecond,
+			maxDelay:  time.Duration(envInt(envMap, "RETRY_MAX_MS", 2000)) * time.Millisecond,
+			failures:  envInt(envMap, "BREAKER_FAILURES", 5),
+			openFor:   time.Duration(envInt(envMap, "BREAKER_OPEN_SECONDS", 30)) * time.Second,
+		},
+	}
+}
+
+// envInt parse an optional int env, a malformed value stop the app like the other settings
+func envInt(envMap map[string]string, key string, fallback int) int {
+	i, err := parseInt(envMap, key, fallback)
+	if err != nil {
+		log.Fatal(err)
 	}
+	return i
+}
+
+// envString return the env or fallback when it is empty
+func envString(envMap map[string]string, key, fallback string) string {
+	if envMap[key] == "" {
+		return fallback
+	}
+	return envMap[key]
+}
+
+func envFloat(envMap map[string]string, key string, fallback float64) float64 {
+	f, err := parseFloat(envMap, key, fallback)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return f
+}
+
+func parseInt(envMap map[string]string, key string, fallback int) (int, error) {
+	if envMap[key] == "" {
+		return fallback, nil
+	}
+	i, err := strconv.Atoi(envMap[key])
+	if err != nil {
+		return 0, fmt.Errorf("load %s failed: %v", strings.ToLower(key), err)
+	}
+	return i, nil
+}
+
+func parseFloat(envMap map[string]string, key string, fallback float64) (float64, error) {
+	if envMap[key] == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(envMap[key], 64)
+	if err != nil {
+		return 0, fmt.Errorf("load %s failed: %v", strings.ToLower(key), err)
+	}
+	return f, nil
 }
 
 type IConfig interface {
 	App() IAppConfig
 	Db() IDbConfig
 	Jwt() IJwtConfig
+	Shipping() IShippingConfig
+	Chaos() IChaosConfig
+	Mail() IMailConfig
+	Webhook() IWebhookConfig
+	Cache() ICacheConfig
+	Inventory() IInventoryConfig
+	Scan() IScanConfig
+	Cdn() ICdnConfig
+	Image() IImageConfig
+	Upload() IUploadConfig
+	Security() ISecurityConfig
+	AccessLog() IAccessLogConfig
+	Resilience() IResilienceConfig
+	Timeout() ITimeoutConfig
+	Snapshot() ISnapshot // the hot reloadable settings, safe to read while a reload happens
+	Reload() error
+	StartWatcher()
 }
 
 type config struct {
-	app *app
-	db  *db
-	jwt *jwt
+	path          string
+	mu            sync.RWMutex
+	envMap        map[string]string
+	files         []string
+	modTimes      map[string]time.Time
+	watchInterval time.Duration
+	// rotated secrets are fetched again, 0 without a secrets provider
+	secretsInterval time.Duration
+	snapshot        *atomic.Pointer[snapshot]
+
+	app        *app
+	db         *db
+	jwt        *jwt
+	shipping   *shipping
+	chaos      *chaos
+	mail       *mail
+	webhook    *webhook
+	cache      *cache
+	inventory  *inventory
+	scan       *scan
+	cdn        *cdn
+	image      *image
+	upload     *upload
+	security   *security
+	accessLog  *accessLog
+	resilience *resilience
+	timeout    *timeout
 }
 
 type IAppConfig interface {
@@ -121,22 +540,40 @@ type IAppConfig interface {
 	ReadTimeout() time.Duration
 	WriteTimeout() time.Duration
 	BodyLimit() int
-	FileLimit() int
+	FileLimit() int // hot reloadable
 	GCPBucket() string
+	Currency() string // base currency of product prices
+	TaxRate() float64 // e.g. 0.07, added on top of the cart total
+	Env() string      // development, staging or production
+	IsProduction() bool
 	Host() string
 	Port() int
+	GrpcUrl() string     // host:grpc port
+	GrpcPort() int       // port of the internal grpc api, disabled when 0
+	PublicUrl() string   // base url clients use to reach the app, e.g. https://shop.example.com
+	CompressLevel() int  // -1 disabled, 0 default, 1 best speed, 2 best compression
+	StaticMaxAge() int   // seconds static files are cached by clients
+	StoreDomain() string // a request to <store>.StoreDomain is for the store, subdomains are not used when empty
 }
 
 type app struct {
-	host         string
-	port         int
-	name         string
-	version      string
-	readTimeout  time.Duration
-	writeTimeout time.Duration
-	bodyLimit    int //bytes
-	fileLimit    int //bytes
-	gcpbucket    string
+	host          string
+	port          int
+	name          string
+	version       string
+	readTimeout   time.Duration
+	writeTimeout  time.Duration
+	bodyLimit     int //bytes
+	snapshot      *atomic.Pointer[snapshot]
+	gcpbucket     string
+	currency      string
+	taxRate       float64
+	grpcPort      int
+	publicUrl     string
+	compressLevel int
+	staticMaxAge  int
+	storeDomain   string
+	env           string
 }
 
 func (c *config) App() IAppConfig {
@@ -148,25 +585,44 @@ func (a *app) Version() string             { return a.version }
 func (a *app) ReadTimeout() time.Duration  { return a.readTimeout }
 func (a *app) WriteTimeout() time.Duration { return a.writeTimeout }
 func (a *app) BodyLimit() int              { return a.bodyLimit }
-func (a *app) FileLimit() int              { return a.fileLimit }
+func (a *app) FileLimit() int              { return a.snapshot.Load().fileLimit }
 func (a *app) GCPBucket() string           { return a.gcpbucket }
+func (a *app) Currency() string            { return a.currency }
+func (a *app) TaxRate() float64            { return a.taxRate }
+func (a *app) Env() string                 { return a.env }
+func (a *app) IsProduction() bool          { return a.env == "production" }
 func (a *app) Host() string                { return a.host }
 func (a *app) Port() int                   { return a.port }
+func (a *app) GrpcUrl() string             { return fmt.Sprintf("%s:%d", a.host, a.grpcPort) }
+func (a *app) GrpcPort() int               { return a.grpcPort }
+func (a *app) PublicUrl() string           { return a.publicUrl }
+func (a *app) CompressLevel() int          { return a.compressLevel }
+func (a *app) StaticMaxAge() int           { return a.staticMaxAge }
+func (a *app) StoreDomain() string         { return a.storeDomain }
 
 type IDbConfig interface {
 	Url() string
 	MaxOpenConns() int
+	MaxIdleConns() int
+	ConnMaxLifetime() time.Duration // 0 keeps a connection forever
+	ConnMaxIdleTime() time.Duration // 0 keeps an idle connection forever
+	SlowQuery() time.Duration       // queries which take longer are logged, 0 disables it
+	Password() string               // rotating, new connections use the latest password
 }
 
 type db struct {
-	host           string
-	port           int
-	protocol       string
-	username       string
-	password       string
-	database       string
-	sslMode        string
-	maxConnections int
+	host               string
+	port               int
+	protocol           string
+	username           string
+	database           string
+	sslMode            string
+	maxConnections     int
+	maxIdleConnections int
+	connMaxLifetime    time.Duration
+	connMaxIdleTime    time.Duration
+	slowQuery          time.Duration
+	snapshot           *atomic.Pointer[snapshot]
 }
 
 func (c *config) Db() IDbConfig {
@@ -178,12 +634,17 @@ func (d *db) Url() string {
 		d.host,
 		d.port,
 		d.username,
-		d.password,
+		d.Password(),
 		d.database,
 		d.sslMode,
 	)
 }
-func (d *db) MaxOpenConns() int { return d.maxConnections }
+func (d *db) MaxOpenConns() int              { return d.maxConnections }
+func (d *db) MaxIdleConns() int              { return d.maxIdleConnections }
+func (d *db) ConnMaxLifetime() time.Duration { return d.connMaxLifetime }
+func (d *db) ConnMaxIdleTime() time.Duration { return d.connMaxIdleTime }
+func (d *db) SlowQuery() time.Duration       { return d.slowQuery }
+func (d *db) Password() string               { return d.snapshot.Load().secret("DB_PASSWORD") }
 
 type IJwtConfig interface {
 	SecretKey() []byte
@@ -196,9 +657,7 @@ type IJwtConfig interface {
 }
 
 type jwt struct {
-	secertKey        string
-	adminKey         string
-	apiKey           string
+	snapshot         *atomic.Pointer[snapshot]
 	accessExpiresAt  int //sec
 	refreshExpiresAt int //sec
 }
@@ -206,10 +665,449 @@ type jwt struct {
 func (c *config) Jwt() IJwtConfig {
 	return c.jwt
 }
-func (j *jwt) SecretKey() []byte          { return []byte(j.secertKey) }
-func (j *jwt) AdminKey() []byte           { return []byte(j.adminKey) }
-func (j *jwt) ApiKey() []byte             { return []byte(j.apiKey) }
+func (j *jwt) SecretKey() []byte          { return []byte(j.snapshot.Load().secret("JWT_SECRET_KEY")) }
+func (j *jwt) AdminKey() []byte           { return []byte(j.snapshot.Load().secret("JWT_ADMIN_KEY")) }
+func (j *jwt) ApiKey() []byte             { return []byte(j.snapshot.Load().secret("JWT_API_KEY")) }
 func (j *jwt) AccessExpiresAt() int       { return j.accessExpiresAt }
 func (j *jwt) RefreshExpiresAt() int      { return j.refreshExpiresAt }
 func (j *jwt) SetJwtAccessExpires(t int)  { j.accessExpiresAt = t }
-func (j *jwt) SetJwtRefreshExpires(t int) { j.refreshExpiresAt = t }
\ No newline at end of file
+func (j *jwt) SetJwtRefreshExpires(t int) { j.refreshExpiresAt = t }
+
+type IShippingConfig interface {
+	FlatRate() float64 // fee of the flat rate provider
+	FreeOver() float64 // order value with free flat rate shipping, 0 is never free
+	OriginCountry() string
+	OriginPostalCode() string
+	CarrierUrl() string // rate api of the carrier, the carrier provider is disabled when empty
+	CarrierKey() string
+}
+
+type shipping struct {
+	flatRate         float64
+	freeOver         float64
+	originCountry    string
+	originPostalCode string
+	carrierUrl       string
+	snapshot         *atomic.Pointer[snapshot]
+}
+
+func (c *config) Shipping() IShippingConfig {
+	return c.shipping
+}
+func (s *shipping) FlatRate() float64        { return s.flatRate }
+func (s *shipping) FreeOver() float64        { return s.freeOver }
+func (s *shipping) OriginCountry() string    { return s.originCountry }
+func (s *shipping) OriginPostalCode() string { return s.originPostalCode }
+func (s *shipping) CarrierUrl() string       { return s.carrierUrl }
+func (s *shipping) CarrierKey() string       { return s.snapshot.Load().secret("SHIPPING_CARRIER_KEY") }
+
+// IChaosConfig is the fault injection of staging, it is never used in production
+type IChaosConfig interface {
+	Enabled() bool
+	Latency() time.Duration         // max latency added to a request
+	ErrorRate() float64             // 0-1, chance of a random 5xx
+	DependencyFailureRate() float64 // 0-1, chance of a dependency (database, storage) unavailable response
+	Routes() []string               // path prefixes to inject faults, every route when empty
+}
+
+type chaos struct {
+	enabled               bool
+	latency               time.Duration
+	errorRate             float64
+	dependencyFailureRate float64
+	routes                []string
+}
+
+func (c *config) Chaos() IChaosConfig {
+	return c.chaos
+}
+func (c *chaos) Enabled() bool                  { return c.enabled }
+func (c *chaos) Latency() time.Duration         { return c.latency }
+func (c *chaos) ErrorRate() float64             { return c.errorRate }
+func (c *chaos) DependencyFailureRate() float64 { return c.dependencyFailureRate }
+func (c *chaos) Routes() []string               { return c.routes }
+
+// IMailConfig is the email providers in failover order, smtp first then the http api,
+// a provider is disabled when its host or url is empty
+type IMailConfig interface {
+	From() string
+	SmtpHost() string
+	SmtpPort() int
+	SmtpUsername() string
+	SmtpPassword() string
+	SmtpRate() float64 // emails per second, hot reloadable like ApiRate and MaxAttempts
+	ApiUrl() string
+	ApiKey() string
+	ApiRate() float64 // emails per second
+	MaxAttempts() int // attempts before an email is failed
+}
+
+type mail struct {
+	from         string
+	smtpHost     string
+	smtpPort     int
+	smtpUsername string
+	apiUrl       string
+	snapshot     *atomic.Pointer[snapshot]
+}
+
+func (c *config) Mail() IMailConfig {
+	return c.mail
+}
+func (m *mail) From() string         { return m.from }
+func (m *mail) SmtpHost() string     { return m.smtpHost }
+func (m *mail) SmtpPort() int        { return m.smtpPort }
+func (m *mail) SmtpUsername() string { return m.smtpUsername }
+func (m *mail) SmtpPassword() string { return m.snapshot.Load().secret("MAIL_SMTP_PASSWORD") }
+func (m *mail) SmtpRate() float64    { return m.snapshot.Load().smtpRate }
+func (m *mail) ApiUrl() string       { return m.apiUrl }
+func (m *mail) ApiKey() string       { return m.snapshot.Load().secret("MAIL_API_KEY") }
+func (m *mail) ApiRate() float64     { return m.snapshot.Load().apiRate }
+func (m *mail) MaxAttempts() int     { return m.snapshot.Load().maxAttempts }
+
+// IWebhookConfig is the signing secret of each inbound webhook source, e.g. WEBHOOK_SECRET_PAYMENTS
+type IWebhookConfig interface {
+	Secret(source string) string    // empty when the source is not configured, its webhooks are rejected
+	Tolerance() time.Duration       // max age of the timestamp, nonces are kept twice as long
+	DuplicateWindow() time.Duration // payments of the same amount and customer within the window are flagged
+	DuplicateAutoRefund() bool      // refund a second payment of the same order right away, otherwise flag it
+}
+
+type webhook struct {
+	snapshot        *atomic.Pointer[snapshot]
+	tolerance       time.Duration
+	duplicateWindow time.Duration
+	autoRefund      bool
+}
+
+func (c *config) Webhook() IWebhookConfig {
+	return c.webhook
+}
+func (w *webhook) Secret(source string) string {
+	return w.snapshot.Load().secret("WEBHOOK_SECRET_" + strings.ToUpper(source))
+}
+func (w *webhook) Tolerance() time.Duration       { return w.tolerance }
+func (w *webhook) DuplicateWindow() time.Duration { return w.duplicateWindow }
+func (w *webhook) DuplicateAutoRefund() bool      { return w.autoRefund }
+
+// ICacheConfig is the Cache-Control header of each route group, e.g. CACHE_CONTROL_PRODUCTS=public, max-age=60
+type ICacheConfig interface {
+	Control(group string) string // no-cache when the group is not set, clients revalidate with the ETag
+	ProductTtl() time.Duration   // products and the category tree kept in memory, 0 disables the cache
+	WarmProducts() int           // best selling products loaded on startup before the server is ready
+	SummaryTtl() time.Duration   // order summary of a customer kept in memory, 0 disables the cache
+}
+
+type cache struct {
+	controls     map[string]string
+	productTtl   time.Duration
+	warmProducts int
+	summaryTtl   time.Duration
+}
+
+func (c *config) Cache() ICacheConfig {
+	return c.cache
+}
+func (c *cache) Control(group string) string {
+	if control, ok := c.controls[strings.ToLower(group)]; ok {
+		return control
+	}
+	return "no-cache"
+}
+func (c *cache) ProductTtl() time.Duration { return c.productTtl }
+func (c *cache) WarmProducts() int         { return c.warmProducts }
+func (c *cache) SummaryTtl() time.Duration { return c.summaryTtl }
+
+type IInventoryConfig interface {
+	LowStockThreshold() int     // default of the products without their own threshold
+	AlertEmails() []string      // low stock alerts are emailed to them, none when empty
+	AlertWebhookUrl() string    // low stock alerts are posted to it, none when empty
+	AlertWebhookSecret() string // signs the posted alerts like the inbound webhooks
+}
+
+type inventory struct {
+	lowStockThreshold  int
+	alertEmails        []string
+	alertWebhookUrl    string
+	alertWebhookSecret string
+}
+
+func (c *config) Inventory() IInventoryConfig {
+	return c.inventory
+}
+func (i *inventory) LowStockThreshold() int     { return i.lowStockThreshold }
+func (i *inventory) AlertEmails() []string      { return i.alertEmails }
+func (i *inventory) AlertWebhookUrl() string    { return i.alertWebhookUrl }
+func (i *inventory) AlertWebhookSecret() string { return i.alertWebhookSecret }
+
+// IScanConfig is the antivirus which scan the uploaded files before they are public, no scanning when Provider is empty
+type IScanConfig interface {
+	Provider() string // clamav or icap
+	Addr() string     // host:port of clamd or the icap server
+	IcapService() string
+	Timeout() time.Duration
+	FailOpen() bool   // accept the file unscanned when the scanner is down, rejected otherwise
+	Quarantine() bool // keep infected files in the quarantine, not public, instead of dropping them
+}
+
+type scan struct {
+	provider    string
+	addr        string
+	icapService string
+	timeout     time.Duration
+	failOpen    bool
+	quarantine  bool
+}
+
+func (c *config) Scan() IScanConfig {
+	return c.scan
+}
+func (s *scan) Provider() string       { return s.provider }
+func (s *scan) Addr() string           { return s.addr }
+func (s *scan) IcapService() string    { return s.icapService }
+func (s *scan) Timeout() time.Duration { return s.timeout }
+func (s *scan) FailOpen() bool         { return s.failOpen }
+func (s *scan) Quarantine() bool       { return s.quarantine }
+
+// ICdnConfig is the CDN in front of the public files, the storage urls are used when BaseUrl is empty
+type ICdnConfig interface {
+	BaseUrl() string  // e.g. https://cdn.example.com, serve the root of the bucket or of /static/images
+	Provider() string // cloudcdn or cloudflare, the cache is not purged when empty
+	GcpProject() string
+	UrlMap() string // url map of the Cloud CDN load balancer
+	CloudflareZone() string
+	CloudflareToken() string
+	Timeout() time.Duration
+}
+
+type cdn struct {
+	baseUrl         string
+	provider        string
+	gcpProject      string
+	urlMap          string
+	cloudflareZone  string
+	cloudflareToken string
+	timeout         time.Duration
+}
+
+func (c *config) Cdn() ICdnConfig {
+	return c.cdn
+}
+func (c *cdn) BaseUrl() string         { return c.baseUrl }
+func (c *cdn) Provider() string        { return c.provider }
+func (c *cdn) GcpProject() string      { return c.gcpProject }
+func (c *cdn) UrlMap() string          { return c.urlMap }
+func (c *cdn) CloudflareZone() string  { return c.cloudflareZone }
+func (c *cdn) CloudflareToken() string { return c.cloudflareToken }
+func (c *cdn) Timeout() time.Duration  { return c.timeout }
+
+// IImageConfig is the smaller formats made of every uploaded image, with cwebp and avifenc of the server
+type IImageConfig interface {
+	Variants() []string // webp and or avif, no variants when empty
+	CwebpBin() string
+	AvifencBin() string
+	WebpQuality() int // 0 to 100
+	AvifQuality() int // 0 to 100
+	Workers() int     // images transcoded at the same time, it is heavy on the cpu
+	Timeout() time.Duration
+}
+
+type image struct {
+	variants    []string
+	cwebpBin    string
+	avifencBin  string
+	webpQuality int
+	avifQuality int
+	workers     int
+	timeout     time.Duration
+}
+
+func (c *config) Image() IImageConfig {
+	return c.image
+}
+func (i *image) Variants() []string     { return i.variants }
+func (i *image) CwebpBin() string       { return i.cwebpBin }
+func (i *image) AvifencBin() string     { return i.avifencBin }
+func (i *image) WebpQuality() int       { return i.webpQuality }
+func (i *image) AvifQuality() int       { return i.avifQuality }
+func (i *image) Workers() int           { return i.workers }
+func (i *image) Timeout() time.Duration { return i.timeout }
+
+// IUploadConfig is the body limit of each route group and the upload quota of a user, e.g. BODY_LIMIT_FILES=20971520
+type IUploadConfig interface {
+	BodyLimit(group string) int // APP_BODY_LIMIT when the group is not set
+	MaxBodyLimit() int          // the largest limit, a larger body is rejected before it reach any route
+	DailyQuota() int64          // bytes a user can upload per day, 0 disables the quota
+	StripMetadata() bool        // remove the EXIF of uploaded images and turn them upright
+}
+
+type upload struct {
+	defaultLimit  int
+	bodyLimits    map[string]int
+	dailyQuota    int64
+	stripMetadata bool
+}
+
+func (c *config) Upload() IUploadConfig {
+	return c.upload
+}
+func (u *upload) BodyLimit(group string) int {
+	if limit, ok := u.bodyLimits[strings.ToLower(group)]; ok {
+		return limit
+	}
+	return u.defaultLimit
+}
+func (u *upload) MaxBodyLimit() int {
+	max := u.defaultLimit
+	for _, limit := range u.bodyLimits {
+		if limit > max {
+			max = limit
+		}
+	}
+	return max
+}
+func (u *upload) DailyQuota() int64   { return u.dailyQuota }
+func (u *upload) StripMetadata() bool { return u.stripMetadata }
+
+// CorsPolicy is the CORS of a route group, Origins and Methods are comma separated
+type CorsPolicy struct {
+	Origins     string
+	Methods     string
+	Credentials bool
+	MaxAge      int // seconds a preflight is cached
+}
+
+// ISecurityConfig is the CORS of each route group, the security headers and the lockout of failed sign ins
+type ISecurityConfig interface {
+	Cors(group string) *CorsPolicy // the default policy when the group is not set
+	CorsGroups() []string          // groups with their own policy
+	HstsMaxAge() int               // seconds, no Strict-Transport-Security when 0
+	HstsSubdomains() bool
+	Nosniff() bool                // X-Content-Type-Options: nosniff
+	StaticCsp() string            // Content-Security-Policy of the static files, none when empty
+	LoginLockAfter() int          // failed sign ins before the account is locked, 0 never locks
+	LoginLockBase() time.Duration // the first lock, doubled for every further failure
+	LoginLockMax() time.Duration  // the longest lock
+	LoginCaptchaAfter() int       // failed sign ins before a captcha is asked, 0 never asks
+}
+
+type security struct {
+	cors              *CorsPolicy
+	groupCors         map[string]*CorsPolicy
+	hstsMaxAge        int
+	hstsSubdomains    bool
+	nosniff           bool
+	staticCsp         string
+	loginLockAfter    int
+	loginLockBase     time.Duration
+	loginLockMax      time.Duration
+	loginCaptchaAfter int
+}
+
+func (c *config) Security() ISecurityConfig {
+	return c.security
+}
+func (s *security) Cors(group string) *CorsPolicy {
+	if policy, ok := s.groupCors[strings.ToLower(group)]; ok {
+		return policy
+	}
+	return s.cors
+}
+func (s *security) CorsGroups() []string {
+	groups := make([]string, 0, len(s.groupCors))
+	for group := range s.groupCors {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+func (s *security) HstsMaxAge() int              { return s.hstsMaxAge }
+func (s *security) HstsSubdomains() bool         { return s.hstsSubdomains }
+func (s *security) Nosniff() bool                { return s.nosniff }
+func (s *security) StaticCsp() string            { return s.staticCsp }
+func (s *security) LoginLockAfter() int          { return s.loginLockAfter }
+func (s *security) LoginLockBase() time.Duration { return s.loginLockBase }
+func (s *security) LoginLockMax() time.Duration  { return s.loginLockMax }
+func (s *security) LoginCaptchaAfter() int       { return s.loginCaptchaAfter }
+
+// IAccessLogConfig is where the access log is written, the requests are logged to stdout when File is empty
+type IAccessLogConfig interface {
+	File() string
+	MaxBytes() int64      // the file is rotated when it is larger, 0 never by size
+	Every() time.Duration // the file is rotated every period in UTC, 0 never by time
+	MaxFiles() int        // rotated files which are kept, 0 keep every file
+	Buffer() int          // lines waiting for the disk, a line is dropped when it is full
+}
+
+type accessLog struct {
+	file     string
+	maxBytes int64
+	every    time.Duration
+	maxFiles int
+	buffer   int
+}
+
+func (c *config) AccessLog() IAccessLogConfig {
+	return c.accessLog
+}
+func (a *accessLog) File() string         { return a.file }
+func (a *accessLog) MaxBytes() int64      { return a.maxBytes }
+func (a *accessLog) Every() time.Duration { return a.every }
+func (a *accessLog) MaxFiles() int        { return a.maxFiles }
+func (a *accessLog) Buffer() int          { return a.buffer }
+
+// IResilienceConfig is the retries and the circuit breaker of the calls to the bucket and the email providers
+type IResilienceConfig interface {
+	Attempts() int            // tries of a call, 1 does not retry
+	BaseDelay() time.Duration // the largest delay before the first retry, doubled for each further retry
+	MaxDelay() time.Duration
+	Failures() int          // failed calls in a row which open the breaker, 0 never opens
+	OpenFor() time.Duration // an open breaker fail the calls at once for this long
+}
+
+type resilience struct {
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	failures  int
+	openFor   time.Duration
+}
+
+func (c *config) Resilience() IResilienceConfig {
+	return c.resilience
+}
+func (r *resilience) Attempts() int            { return r.attempts }
+func (r *resilience) BaseDelay() time.Duration { return r.baseDelay }
+func (r *resilience) MaxDelay() time.Duration  { return r.maxDelay }
+func (r *resilience) Failures() int            { return r.failures }
+func (r *resilience) OpenFor() time.Duration   { return r.openFor }
+
+// ITimeoutConfig is the deadline of the requests of each route group
+type ITimeoutConfig interface {
+	Budget(group string, read bool) time.Duration // 0 has no deadline
+}
+
+type timeout struct {
+	defaultBudget time.Duration
+	budgets       map[string]time.Duration
+}
+
+func (c *config) Timeout() ITimeoutConfig {
+	return c.timeout
+}
+
+// Budget look for the budget of the reads or writes of the group, then of the group, then TIMEOUT_MS
+func (t *timeout) Budget(group string, read bool) time.Duration {
+	group = strings.ToLower(group)
+	kind := group + "_write"
+	if read {
+		kind = group + "_read"
+	}
+	if budget, ok := t.budgets[kind]; ok {
+		return budget
+	}
+	if budget, ok := t.budgets[group]; ok {
+		return budget
+	}
+	return t.defaultBudget
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+)
+
+// secretKeys are fetched from the secrets provider when SECRETS_KEYS is not set
+var secretKeys = []string{
+	"DB_PASSWORD",
+	"JWT_SECRET_KEY",
+	"JWT_ADMIN_KEY",
+	"JWT_API_KEY",
+	"MAIL_SMTP_PASSWORD",
+	"MAIL_API_KEY",
+	"SHIPPING_CARRIER_KEY",
+	"WEBHOOK_SECRET_PAYMENTS",
+	"WEBHOOK_SECRET_CARRIERS",
+}
+
+// rotating is whether a credential is read from the snapshot, so a new value apply without a restart
+func rotating(key string) bool {
+	for _, secret := range secretKeys {
+		if key == secret {
+			return true
+		}
+	}
+	return strings.HasPrefix(key, "WEBHOOK_SECRET_")
+}
+
+// ISecretsProvider read credentials from a secrets manager instead of the .env file on disk,
+// a key which the manager does not have is left out of the result
+type ISecretsProvider interface {
+	Name() string
+	Fetch(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+func newSecretsProvider(envMap map[string]string) (ISecretsProvider, error) {
+	switch strings.ToLower(envMap["SECRETS_PROVIDER"]) {
+	case "":
+		return nil, nil
+	case "vault":
+		if envMap["VAULT_ADDR"] == "" || envMap["VAULT_TOKEN"] == "" || envMap["VAULT_SECRET_PATH"] == "" {
+			return nil, fmt.Errorf("vault needs VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+		}
+		return &vaultProvider{
+			addr:  strings.TrimSuffix(envMap["VAULT_ADDR"], "/"),
+			token: envMap["VAULT_TOKEN"],
+			path:  strings.Trim(envMap["VAULT_SECRET_PATH"], "/"),
+		}, nil
+	case "gcp":
+		if envMap["SECRETS_GCP_PROJECT"] == "" {
+			return nil, fmt.Errorf("gcp secret manager needs SECRETS_GCP_PROJECT")
+		}
+		return &gcpProvider{project: envMap["SECRETS_GCP_PROJECT"]}, nil
+	default:
+		return nil, fmt.Errorf("secrets provider %s is not supported, must be vault or gcp", envMap["SECRETS_PROVIDER"])
+	}
+}
+
+// applySecrets override the settings with the values of the secrets provider, a provider which
+// cannot be reached is an error so the app never start with the placeholder values of the .env file
+func applySecrets(envMap map[string]string) error {
+	provider, err := newSecretsProvider(envMap)
+	if err != nil || provider == nil {
+		return err
+	}
+
+	keys := secretKeys
+	if envMap["SECRETS_KEYS"] != "" {
+		keys = make([]string, 0)
+		for _, key := range strings.Split(envMap["SECRETS_KEYS"], ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	secrets, err := provider.Fetch(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("fetch secrets from %s failed: %v", provider.Name(), err)
+	}
+	for key, value := range secrets {
+		envMap[key] = value
+	}
+	return nil
+}
+
+// vaultProvider read one kv v2 secret which has every key, e.g. VAULT_SECRET_PATH=secret/data/ri-shop
+type vaultProvider struct {
+	addr  string
+	token string
+	path  string
+}
+
+func (p *vaultProvider) Name() string { return "vault" }
+
+func (p *vaultProvider) Fetch(ctx context.Context, keys []string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s", p.addr, p.path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault responded %d", res.StatusCode)
+	}
+
+	body := &struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(body); err != nil {
+		return nil, fmt.Errorf("decode vault secret failed: %v", err)
+	}
+
+	secrets := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := body.Data.Data[key]; ok {
+			secrets[key] = fmt.Sprint(value)
+		}
+	}
+	return secrets, nil
+}
+
+// gcpProvider read the latest version of a secret named like the key, e.g. DB_PASSWORD,
+// with the default credentials of the instance like the storage client
+type gcpProvider struct {
+	project string
+}
+
+func (p *gcpProvider) Name() string { return "gcp" }
+
+func (p *gcpProvider) Fetch(ctx context.Context, keys []string) (map[string]string, error) {
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string)
+	for _, key := range keys {
+		endpoint := fmt.Sprintf(
+			"https://secretmanager.googleapis.com/v1/projects/%s/secrets/%s/versions/latest:access",
+			url.PathEscape(p.project),
+			url.PathEscape(key),
+		)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body := &struct {
+			Payload struct {
+				Data string `json:"data"`
+			} `json:"payload"`
+		}{}
+		err = func() error {
+			defer res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				return fmt.Errorf("secret %s responded %d", key, res.StatusCode)
+			}
+			return json.NewDecoder(res.Body).Decode(body)
+		}()
+		if res.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := base64.StdEncoding.DecodeString(body.Payload.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decode secret %s failed: %v", key, err)
+		}
+		secrets[key] = string(value)
+	}
+	return secrets, nil
+}
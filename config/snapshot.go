@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// reloadable is the settings a reload apply, the others are read once and need a restart
+var reloadable = map[string]bool{
+	"APP_FILE_LIMIT":    true,
+	"APP_FILE_WORKERS":  true,
+	"MAIL_SMTP_RATE":    true,
+	"MAIL_API_RATE":     true,
+	"MAIL_MAX_ATTEMPTS": true,
+}
+
+// ISnapshot is the hot reloadable settings at one point in time, it never changes after it is taken.
+// Read several values from the same snapshot when they must agree with each other
+type ISnapshot interface {
+	FileLimit() int    // bytes
+	FileWorkers() int  // uploads and deletions of one request in parallel
+	SmtpRate() float64 // emails per second
+	ApiRate() float64  // emails per second
+	MaxAttempts() int  // attempts before an email is failed
+	Generation() int   // 1 on startup, +1 on each reload
+	LoadedAt() time.Time
+}
+
+type snapshot struct {
+	fileLimit   int
+	fileWorkers int
+	smtpRate    float64
+	apiRate     float64
+	maxAttempts int
+	generation  int
+	loadedAt    time.Time
+	secrets     map[string]string // credentials which may rotate, see rotating
+}
+
+func (s *snapshot) FileLimit() int      { return s.fileLimit }
+func (s *snapshot) FileWorkers() int    { return s.fileWorkers }
+func (s *snapshot) SmtpRate() float64   { return s.smtpRate }
+func (s *snapshot) ApiRate() float64    { return s.apiRate }
+func (s *snapshot) MaxAttempts() int    { return s.maxAttempts }
+func (s *snapshot) Generation() int     { return s.generation }
+func (s *snapshot) LoadedAt() time.Time { return s.loadedAt }
+
+func (s *snapshot) secret(key string) string { return s.secrets[key] }
+
+// newSnapshot return an error instead of stopping the app, a bad reload keep the previous snapshot
+func newSnapshot(envMap map[string]string) (*snapshot, error) {
+	s := &snapshot{
+		loadedAt: time.Now(),
+		secrets:  make(map[string]string),
+	}
+	for key, value := range envMap {
+		if rotating(key) {
+			s.secrets[key] = value
+		}
+	}
+
+	var err error
+	if s.fileLimit, err = strconv.Atoi(envMap["APP_FILE_LIMIT"]); err != nil {
+		return nil, fmt.Errorf("load file limit failed: %v", err)
+	}
+	if s.fileWorkers, err = parseInt(envMap, "APP_FILE_WORKERS", 5); err != nil {
+		return nil, err
+	}
+	if s.smtpRate, err = parseFloat(envMap, "MAIL_SMTP_RATE", 5); err != nil {
+		return nil, err
+	}
+	if s.apiRate, err = parseFloat(envMap, "MAIL_API_RATE", 10); err != nil {
+		return nil, err
+	}
+	if s.maxAttempts, err = parseInt(envMap, "MAIL_MAX_ATTEMPTS", 5); err != nil {
+		return nil, err
+	}
+
+	if s.fileLimit <= 0 || s.fileWorkers <= 0 || s.smtpRate <= 0 || s.apiRate <= 0 || s.maxAttempts <= 0 {
+		return nil, fmt.Errorf("file limit, file workers, mail rates and max attempts must be more than 0")
+	}
+	return s, nil
+}
+
+func (c *config) Snapshot() ISnapshot {
+	return c.snapshot.Load()
+}
+
+// Reload read every source again and swap the snapshot. Changes of the other settings are only logged,
+// the values are not printed because they may be secrets
+func (c *config) Reload() error {
+	envMap, files, err := readSources(c.path)
+	if err != nil {
+		return err
+	}
+	next, err := newSnapshot(envMap)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range mergeKeys(c.envMap, envMap) {
+		if !reloadable[key] && !rotating(key) && c.envMap[key] != envMap[key] {
+			log.Printf("config %s changed, restart the server to apply it", key)
+		}
+	}
+	c.envMap = envMap
+	c.files = files
+	c.modTimes = modTimes(files)
+
+	next.generation = c.snapshot.Load().generation + 1
+	c.snapshot.Store(next)
+	log.Printf("config reloaded, generation %d", next.generation)
+	return nil
+}
+
+// StartWatcher reload when a config file changes or on SIGHUP, files are polled every CONFIG_WATCH_SECONDS
+// because there is no file notify library in go.mod. Rotated secrets are fetched every SECRETS_REFRESH_MINUTES
+// when a secrets provider is set. Must be called in a goroutine
+func (c *config) StartWatcher() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var tick, refresh <-chan time.Time
+	if c.watchInterval > 0 {
+		ticker := time.NewTicker(c.watchInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	if c.secretsInterval > 0 {
+		ticker := time.NewTicker(c.secretsInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		select {
+		case <-hup:
+		case <-refresh:
+		case <-tick:
+			if !c.changed() {
+				continue
+			}
+		}
+		if err := c.Reload(); err != nil {
+			log.Printf("reload config failed, the previous config is kept: %v", err)
+		}
+	}
+}
+
+// changed record the new times, so a file which fails to reload is not reloaded again until it changes
+func (c *config) changed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := modTimes(c.files)
+	for file, modTime := range current {
+		if !modTime.Equal(c.modTimes[file]) {
+			c.modTimes = current
+			return true
+		}
+	}
+	return false
+}
+
+func modTimes(files []string) map[string]time.Time {
+	times := make(map[string]time.Time)
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			times[file] = info.ModTime()
+		}
+	}
+	return times
+}
+
+func mergeKeys(a, b map[string]string) map[string]bool {
+	keys := make(map[string]bool)
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
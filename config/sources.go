@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// readSources merge the settings from lowest to highest priority: the CONFIG_FILE (json or yaml),
+// the .env file at path, the environment variables of the process, then the SECRETS_PROVIDER.
+// The files which were read are returned so they can be watched
+func readSources(path string) (map[string]string, []string, error) {
+	envMap := make(map[string]string)
+	files := make([]string, 0)
+
+	dotenv, err := godotenv.Read(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("load dotenv failed: %v", err)
+	}
+
+	configFile := os.Getenv("CONFIG_FILE")
+	if configFile == "" {
+		configFile = dotenv["CONFIG_FILE"]
+	}
+	if configFile != "" {
+		values, err := readConfigFile(configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, value := range values {
+			envMap[key] = value
+		}
+		files = append(files, configFile)
+	}
+
+	if dotenv != nil {
+		for key, value := range dotenv {
+			envMap[key] = value
+		}
+		files = append(files, path)
+	}
+
+	for _, env := range os.Environ() {
+		if key, value, ok := strings.Cut(env, "="); ok {
+			envMap[key] = value
+		}
+	}
+
+	if err := applySecrets(envMap); err != nil {
+		return nil, nil, err
+	}
+	return envMap, files, nil
+}
+
+// readConfigFile read a flat object of the same keys as the .env file, e.g. {"APP_FILE_LIMIT": 2097152}
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load config file failed: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return parseJson(data)
+	case ".yaml", ".yml":
+		return parseYaml(data)
+	default:
+		return nil, fmt.Errorf("config file %s must be .json, .yaml or .yml", path)
+	}
+}
+
+func parseJson(data []byte) (map[string]string, error) {
+	raw := make(map[string]any)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse json config failed: %v", err)
+	}
+
+	values := make(map[string]string)
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		case nil:
+			values[key] = ""
+		default:
+			return nil, fmt.Errorf("json config %s must be a string, number or bool", key)
+		}
+	}
+	return values, nil
+}
+
+// parseYaml only read flat KEY: value lines, there is no yaml library in go.mod and the settings are flat anyway
+func parseYaml(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "- ") {
+			return nil, fmt.Errorf("yaml config line %d: nested values are not supported", n)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("yaml config line %d: must be KEY: value", n)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parse yaml config failed: %v", err)
+	}
+	return values, nil
+}
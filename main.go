@@ -1,19 +1,33 @@
 package main
 
 import (
+	"log"
 	"os"
+	"strings"
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/servers"
 	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/seed"
 )
 
+// envPath is the first argument which is not a flag, e.g. go run . .env.dev --seed
 func envPath() string {
-	if len(os.Args) == 1 {
-		return ".env"
-	} else {
-		return os.Args[1]
+	for _, arg := range os.Args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
 	}
+	return ".env"
+}
+
+func hasFlag(name string) bool {
+	for _, arg := range os.Args[1:] {
+		if arg == "-"+name || arg == "--"+name {
+			return true
+		}
+	}
+	return false
 }
 
 func main() {
@@ -24,5 +38,14 @@ func main() {
 
 	// fmt.Println(db)
 
+	if hasFlag("seed") {
+		if cfg.App().IsProduction() {
+			log.Fatal("seed is not allowed in production")
+		}
+		if err := seed.Run(db); err != nil {
+			log.Fatalf("seed failed: %v", err)
+		}
+	}
+
 	servers.NewSever(cfg, db).Start()
 }
@@ -0,0 +1,117 @@
+package addresses
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+)
+
+type Address struct {
+	Id         string `json:"id" db:"id"`
+	UserId     string `json:"user_id" db:"user_id"`
+	Recipient  string `json:"recipient" db:"recipient"`
+	Phone      string `json:"phone" db:"phone"`
+	Line1      string `json:"line1" db:"line1"`
+	Line2      string `json:"line2" db:"line2"`
+	City       string `json:"city" db:"city"`
+	State      string `json:"state" db:"state"`
+	PostalCode string `json:"postal_code" db:"postal_code"`
+	Country    string `json:"country" db:"country"` // ISO 3166-1 alpha-2
+	IsDefault  bool   `json:"is_default" db:"is_default"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+	UpdatedAt  string `json:"updated_at" db:"updated_at"`
+}
+
+type countryRule struct {
+	postalCode    *regexp.Regexp
+	stateRequired bool
+}
+
+// countryRules is the countries we ship to
+var countryRules = map[string]*countryRule{
+	"TH": {postalCode: regexp.MustCompile(`^\d{5}$`)},
+	"US": {postalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`), stateRequired: true},
+	"GB": {postalCode: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`)},
+	"JP": {postalCode: regexp.MustCompile(`^\d{3}-?\d{4}$`), stateRequired: true},
+	"SG": {postalCode: regexp.MustCompile(`^\d{6}$`)},
+}
+
+// SupportedCountry report whether we ship to the country
+func SupportedCountry(country string) bool {
+	_, ok := countryRules[strings.ToUpper(strings.TrimSpace(country))]
+	return ok
+}
+
+var phoneRegexp = regexp.MustCompile(`^\+?[\d\- ]{6,20}$`)
+
+// Normalize trim every field and upper case country and postal code
+func (a *Address) Normalize() {
+	a.Recipient = strings.TrimSpace(a.Recipient)
+	a.Phone = strings.TrimSpace(a.Phone)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.State = strings.TrimSpace(a.State)
+	a.PostalCode = strings.ToUpper(strings.TrimSpace(a.PostalCode))
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+}
+
+// Validate check required fields and the postal code format of the country, every invalid field is returned
+func (a *Address) Validate() error {
+	a.Normalize()
+
+	errs := make(entities.ValidationErrors, 0)
+	required := map[string]string{
+		"recipient":   a.Recipient,
+		"phone":       a.Phone,
+		"line1":       a.Line1,
+		"city":        a.City,
+		"postal_code": a.PostalCode,
+		"country":     a.Country,
+	}
+	for _, field := range []string{"recipient", "phone", "line1", "city", "postal_code", "country"} {
+		if required[field] == "" {
+			errs = append(errs, &entities.FieldError{Field: field, Msg: "is required"})
+		}
+	}
+
+	if a.Phone != "" && !phoneRegexp.MatchString(a.Phone) {
+		errs = append(errs, &entities.FieldError{Field: "phone", Msg: "is invalid"})
+	}
+
+	if rule, ok := countryRules[a.Country]; ok {
+		if a.PostalCode != "" && !rule.postalCode.MatchString(a.PostalCode) {
+			errs = append(errs, &entities.FieldError{Field: "postal_code", Msg: "is invalid"})
+		}
+		if rule.stateRequired && a.State == "" {
+			errs = append(errs, &entities.FieldError{Field: "state", Msg: "is required"})
+		}
+	} else if a.Country != "" {
+		errs = append(errs, &entities.FieldError{Field: "country", Msg: "is not supported"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Region is the state and country, e.g. "Chiang Mai, TH"
+func (a *Address) Region() string {
+	if a.State == "" {
+		return a.Country
+	}
+	return a.State + ", " + a.Country
+}
+
+// Format return the address in one line, used for orders.address
+func (a *Address) Format() string {
+	parts := make([]string, 0)
+	for _, part := range []string{a.Recipient, a.Line1, a.Line2, a.City, a.State, a.PostalCode, a.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,163 @@
+package addressesHandlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/addresses/addressesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type addressesHandlerErrCode string
+
+const (
+	findAddressErr   addressesHandlerErrCode = "addresses-001"
+	insertAddressErr addressesHandlerErrCode = "addresses-002"
+	updateAddressErr addressesHandlerErrCode = "addresses-003"
+	deleteAddressErr addressesHandlerErrCode = "addresses-004"
+)
+
+type IAddressesHandler interface {
+	FindAddress(c *fiber.Ctx) error
+	AddAddress(c *fiber.Ctx) error
+	UpdateAddress(c *fiber.Ctx) error
+	DeleteAddress(c *fiber.Ctx) error
+}
+
+type addressesHandler struct {
+	cfg              config.IConfig
+	addressesUsecase addressesUsecases.IAddressesUsecase
+}
+
+func AddressesHandler(cfg config.IConfig, addressesUsecase addressesUsecases.IAddressesUsecase) IAddressesHandler {
+	return &addressesHandler{
+		cfg:              cfg,
+		addressesUsecase: addressesUsecase,
+	}
+}
+
+func (h *addressesHandler) FindAddress(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+
+	addressesData, err := h.addressesUsecase.FindAddress(c.UserContext(), userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findAddressErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, addressesData).Res()
+}
+
+func (h *addressesHandler) AddAddress(c *fiber.Ctx) error {
+	req := new(addresses.Address)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+
+	address, err := h.addressesUsecase.AddAddress(c.UserContext(), req)
+	if err != nil {
+		var fields entities.ValidationErrors
+		if errors.As(err, &fields) {
+			return entities.NewResponse(c).ValidationError(
+				fiber.ErrBadRequest.Code,
+				string(insertAddressErr),
+				fields,
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, address).Res()
+}
+
+func (h *addressesHandler) UpdateAddress(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	addressId := strings.Trim(c.Params("address_id"), " ")
+
+	// อ่าน address เดิมก่อนแล้วค่อย parse body ทับ จะได้ส่งมาแค่ field ที่ต้องการแก้
+	req, err := h.addressesUsecase.FindOneAddress(c.UserContext(), userId, addressId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(updateAddressErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = addressId
+	req.UserId = userId
+
+	address, err := h.addressesUsecase.UpdateAddress(c.UserContext(), req)
+	if err != nil {
+		var fields entities.ValidationErrors
+		if errors.As(err, &fields) {
+			return entities.NewResponse(c).ValidationError(
+				fiber.ErrBadRequest.Code,
+				string(updateAddressErr),
+				fields,
+			).Res()
+		}
+		switch err.Error() {
+		case "address not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateAddressErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateAddressErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, address).Res()
+}
+
+func (h *addressesHandler) DeleteAddress(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	addressId := strings.Trim(c.Params("address_id"), " ")
+
+	if err := h.addressesUsecase.DeleteAddress(c.UserContext(), userId, addressId); err != nil {
+		switch err.Error() {
+		case "address not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteAddressErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteAddressErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
+}
@@ -0,0 +1,239 @@
+package addressesRepositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IAddressesRepository interface {
+	FindAddress(ctx context.Context, userId string) ([]*addresses.Address, error)
+	FindOneAddress(ctx context.Context, userId, addressId string) (*addresses.Address, error)
+	FindDefaultAddress(ctx context.Context, userId string) (*addresses.Address, error)
+	InsertAddress(ctx context.Context, req *addresses.Address) (string, error)
+	UpdateAddress(ctx context.Context, req *addresses.Address) error
+	DeleteAddress(ctx context.Context, userId, addressId string) error
+}
+
+type addressesRepository struct {
+	db *sqlx.DB
+}
+
+func AddressesRepository(db *sqlx.DB) IAddressesRepository {
+	return &addressesRepository{
+		db: db,
+	}
+}
+
+const selectAddress = `
+	SELECT
+		"id",
+		"user_id",
+		"recipient",
+		"phone",
+		"line1",
+		"line2",
+		"city",
+		"state",
+		"postal_code",
+		"country",
+		"is_default",
+		"created_at",
+		"updated_at"
+	FROM "addresses"`
+
+func (r *addressesRepository) FindAddress(ctx context.Context, userId string) ([]*addresses.Address, error) {
+	query := selectAddress + `
+	WHERE "user_id" = $1
+	ORDER BY "is_default" DESC, "created_at" ASC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	addressesData := make([]*addresses.Address, 0)
+	if err := r.db.SelectContext(ctx, &addressesData, query, userId); err != nil {
+		return nil, fmt.Errorf("select addresses failed: %v", err)
+	}
+	return addressesData, nil
+}
+
+func (r *addressesRepository) FindOneAddress(ctx context.Context, userId, addressId string) (*addresses.Address, error) {
+	query := selectAddress + `
+	WHERE "user_id" = $1
+	AND "id"::TEXT = $2;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	address := new(addresses.Address)
+	if err := r.db.GetContext(ctx, address, query, userId, addressId); err != nil {
+		return nil, fmt.Errorf("address not found")
+	}
+	return address, nil
+}
+
+func (r *addressesRepository) FindDefaultAddress(ctx context.Context, userId string) (*addresses.Address, error) {
+	query := selectAddress + `
+	WHERE "user_id" = $1
+	AND "is_default" = TRUE;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	address := new(addresses.Address)
+	if err := r.db.GetContext(ctx, address, query, userId); err != nil {
+		return nil, fmt.Errorf("default address not found")
+	}
+	return address, nil
+}
+
+// unsetDefault ต้องเรียกก่อน set default ใหม่ ไม่งั้นจะติด unique index
+func unsetDefault(ctx context.Context, tx *sqlx.Tx, userId string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE "addresses" SET "is_default" = FALSE WHERE "user_id" = $1 AND "is_default" = TRUE;`, userId); err != nil {
+		return fmt.Errorf("unset default address failed: %v", err)
+	}
+	return nil
+}
+
+func (r *addressesRepository) InsertAddress(ctx context.Context, req *addresses.Address) (string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	if req.IsDefault {
+		if err := unsetDefault(ctx, tx, req.UserId); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	query := `
+	INSERT INTO "addresses" (
+		"user_id",
+		"recipient",
+		"phone",
+		"line1",
+		"line2",
+		"city",
+		"state",
+		"postal_code",
+		"country",
+		"is_default"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING "id";`
+
+	var addressId string
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		req.UserId,
+		req.Recipient,
+		req.Phone,
+		req.Line1,
+		req.Line2,
+		req.City,
+		req.State,
+		req.PostalCode,
+		req.Country,
+		req.IsDefault,
+	).Scan(&addressId); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert address failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit failed: %v", err)
+	}
+	return addressId, nil
+}
+
+func (r *addressesRepository) UpdateAddress(ctx context.Context, req *addresses.Address) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	if req.IsDefault {
+		if err := unsetDefault(ctx, tx, req.UserId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	query := `
+	UPDATE "addresses" SET
+		"recipient" = $1,
+		"phone" = $2,
+		"line1" = $3,
+		"line2" = $4,
+		"city" = $5,
+		"state" = $6,
+		"postal_code" = $7,
+		"country" = $8,
+		"is_default" = $9
+	WHERE "user_id" = $10
+	AND "id"::TEXT = $11;`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		req.Recipient,
+		req.Phone,
+		req.Line1,
+		req.Line2,
+		req.City,
+		req.State,
+		req.PostalCode,
+		req.Country,
+		req.IsDefault,
+		req.UserId,
+		req.Id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update address failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("address not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+func (r *addressesRepository) DeleteAddress(ctx context.Context, userId, addressId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM "addresses" WHERE "user_id" = $1 AND "id"::TEXT = $2;`, userId, addressId)
+	if err != nil {
+		return fmt.Errorf("delete address failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("address not found")
+	}
+	return nil
+}
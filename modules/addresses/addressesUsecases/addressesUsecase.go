@@ -0,0 +1,98 @@
+package addressesUsecases
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/addresses/addressesRepositories"
+)
+
+type IAddressesUsecase interface {
+	FindAddress(ctx context.Context, userId string) ([]*addresses.Address, error)
+	FindOneAddress(ctx context.Context, userId, addressId string) (*addresses.Address, error)
+	FindCheckoutAddress(ctx context.Context, userId, addressId string) (*addresses.Address, error)
+	AddAddress(ctx context.Context, req *addresses.Address) (*addresses.Address, error)
+	UpdateAddress(ctx context.Context, req *addresses.Address) (*addresses.Address, error)
+	DeleteAddress(ctx context.Context, userId, addressId string) error
+}
+
+type addressesUsecase struct {
+	addressesRepository addressesRepositories.IAddressesRepository
+}
+
+func AddressesUsecase(addressesRepository addressesRepositories.IAddressesRepository) IAddressesUsecase {
+	return &addressesUsecase{
+		addressesRepository: addressesRepository,
+	}
+}
+
+func (u *addressesUsecase) FindAddress(ctx context.Context, userId string) ([]*addresses.Address, error) {
+	addressesData, err := u.addressesRepository.FindAddress(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return addressesData, nil
+}
+
+func (u *addressesUsecase) FindOneAddress(ctx context.Context, userId, addressId string) (*addresses.Address, error) {
+	address, err := u.addressesRepository.FindOneAddress(ctx, userId, addressId)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// FindCheckoutAddress return the chosen address, or the default address when addressId is empty
+func (u *addressesUsecase) FindCheckoutAddress(ctx context.Context, userId, addressId string) (*addresses.Address, error) {
+	if addressId == "" {
+		return u.addressesRepository.FindDefaultAddress(ctx, userId)
+	}
+	return u.addressesRepository.FindOneAddress(ctx, userId, addressId)
+}
+
+func (u *addressesUsecase) AddAddress(ctx context.Context, req *addresses.Address) (*addresses.Address, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// the first address is always the default
+	if !req.IsDefault {
+		if _, err := u.addressesRepository.FindDefaultAddress(ctx, req.UserId); err != nil {
+			req.IsDefault = true
+		}
+	}
+
+	addressId, err := u.addressesRepository.InsertAddress(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := u.addressesRepository.FindOneAddress(ctx, req.UserId, addressId)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (u *addressesUsecase) UpdateAddress(ctx context.Context, req *addresses.Address) (*addresses.Address, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := u.addressesRepository.UpdateAddress(ctx, req); err != nil {
+		return nil, err
+	}
+
+	address, err := u.addressesRepository.FindOneAddress(ctx, req.UserId, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (u *addressesUsecase) DeleteAddress(ctx context.Context, userId, addressId string) error {
+	if err := u.addressesRepository.DeleteAddress(ctx, userId, addressId); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,10 +1,39 @@
 package appinfo
 
 type CategoryFilter struct {
-	Title string `query:"title"`
+	Title   string `query:"title"`
+	StoreId string `query:"-"` // set from the store of the request
 }
 
 type Category struct {
 	Id    int    `json:"id" db:"id"`
 	Title string `json:"title" db:"title"`
 }
+
+const (
+	PreviewTokenTtl    = 24 * 60 * 60
+	PreviewTokenMaxTtl = 7 * 24 * 60 * 60
+)
+
+type PreviewTokenReq struct {
+	At         string `json:"at"` // RFC3339, show promotions running at this time, default now
+	TtlSeconds int    `json:"ttl_seconds"`
+}
+
+type PreviewToken struct {
+	Token     string `json:"token"`
+	At        string `json:"at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// TestClockReq move the test clock, only one of AdvanceSeconds, At or Reset is used in that order
+type TestClockReq struct {
+	AdvanceSeconds int    `json:"advance_seconds"`
+	At             string `json:"at"` // RFC3339
+	Reset          bool   `json:"reset"`
+}
+
+type TestClock struct {
+	Now           string `json:"now"`
+	OffsetSeconds int    `json:"offset_seconds"` // from the system clock
+}
@@ -1,13 +1,16 @@
 package appinfoHandlers
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/appinfo"
 	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/pkg/clock"
 	riAuth "github.com/NatthawutSK/ri-shop/pkg/riauth"
 	"github.com/gofiber/fiber/v2"
 )
@@ -20,6 +23,8 @@ const (
 	FindCategoryErr appinfoHandlersErrCode = "appinfo-002"
 	InsertCategoryErr appinfoHandlersErrCode = "appinfo-003"
 	DeleteCategoryErr appinfoHandlersErrCode = "appinfo-004"
+	generatePreviewTokenErr appinfoHandlersErrCode = "appinfo-005"
+	testClockErr appinfoHandlersErrCode = "appinfo-006"
 )
 
 type IAppinfoHandler interface {
@@ -27,17 +32,22 @@ type IAppinfoHandler interface {
 	FindCategory(c *fiber.Ctx) error
 	InsertCategory(c *fiber.Ctx) error
 	DeleteCategory(c *fiber.Ctx) error
+	GeneratePreviewToken(c *fiber.Ctx) error
+	FindTestClock(c *fiber.Ctx) error
+	UpdateTestClock(c *fiber.Ctx) error
 }
 
 type appinfoHandler struct {
 	cfg config.IConfig
 	appinfoUsecase appinfoUsecases.IAppinfoUsecase
+	clock clock.Clock
 }
 
-func AppinfoHandler(appinfoUsecase appinfoUsecases.IAppinfoUsecase, cfg config.IConfig) IAppinfoHandler {
+func AppinfoHandler(appinfoUsecase appinfoUsecases.IAppinfoUsecase, cfg config.IConfig, clock clock.Clock) IAppinfoHandler {
 	return &appinfoHandler{
 		appinfoUsecase: appinfoUsecase,
 		cfg: cfg,
+		clock: clock,
 	}
 }
 
@@ -69,6 +79,49 @@ func (h *appinfoHandler) GenerateApiKey(c *fiber.Ctx) error {
 }
 
 
+func (h *appinfoHandler) GeneratePreviewToken(c *fiber.Ctx) error {
+	req := new(appinfo.PreviewTokenReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(generatePreviewTokenErr),
+			err.Error(),
+		).Res()
+	}
+
+	at := h.clock.Now()
+	if req.At != "" {
+		t, err := time.Parse(time.RFC3339, req.At)
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(generatePreviewTokenErr),
+				"at must be RFC3339",
+			).Res()
+		}
+		at = t
+	}
+	if req.TtlSeconds <= 0 {
+		req.TtlSeconds = appinfo.PreviewTokenTtl
+	}
+	if req.TtlSeconds > appinfo.PreviewTokenMaxTtl {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(generatePreviewTokenErr),
+			fmt.Sprintf("ttl must be at most %d seconds", appinfo.PreviewTokenMaxTtl),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(
+		fiber.StatusOK,
+		&appinfo.PreviewToken{
+			Token:     riAuth.NewPreviewToken(h.cfg.Jwt(), at, req.TtlSeconds),
+			At:        at.Format(time.RFC3339),
+			ExpiresAt: h.clock.Now().Add(time.Duration(req.TtlSeconds) * time.Second).Format(time.RFC3339),
+		},
+	).Res()
+}
+
 func (h *appinfoHandler) FindCategory(c *fiber.Ctx) error {
 	req := new(appinfo.CategoryFilter)
 	//if only one parameter
@@ -82,8 +135,9 @@ func (h *appinfoHandler) FindCategory(c *fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
+	req.StoreId = c.Locals("storeId").(string)
 
-	category, err := h.appinfoUsecase.FindCategory(req)
+	category, err := h.appinfoUsecase.FindCategory(c.UserContext(), req)
 	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
@@ -118,7 +172,7 @@ func (h *appinfoHandler) InsertCategory(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if err := h.appinfoUsecase.InsertCategory(req); err != nil {
+	if err := h.appinfoUsecase.InsertCategory(c.UserContext(), c.Locals("storeId").(string), req); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(InsertCategoryErr),
@@ -147,7 +201,7 @@ func (h *appinfoHandler) DeleteCategory(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if err := h.appinfoUsecase.DeleteCategory(categoryIdInt); err != nil {
+	if err := h.appinfoUsecase.DeleteCategory(c.UserContext(), c.Locals("storeId").(string), categoryIdInt); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(DeleteCategoryErr),
@@ -162,4 +216,68 @@ func (h *appinfoHandler) DeleteCategory(c *fiber.Ctx) error {
 			CategoryId: categoryIdInt,
 		},
 	).Res()
-}
\ No newline at end of file
+}
+// FindTestClock and UpdateTestClock are only routed outside production, where the server runs on a test clock
+func (h *appinfoHandler) FindTestClock(c *fiber.Ctx) error {
+	testClock, ok := h.clock.(*clock.TestClock)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(testClockErr),
+			"test clock is not enabled",
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(
+		fiber.StatusOK,
+		&appinfo.TestClock{
+			Now:           testClock.Now().Format(time.RFC3339),
+			OffsetSeconds: int(testClock.Offset().Seconds()),
+		},
+	).Res()
+}
+
+func (h *appinfoHandler) UpdateTestClock(c *fiber.Ctx) error {
+	testClock, ok := h.clock.(*clock.TestClock)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(testClockErr),
+			"test clock is not enabled",
+		).Res()
+	}
+
+	req := new(appinfo.TestClockReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(testClockErr),
+			err.Error(),
+		).Res()
+	}
+
+	switch {
+	case req.AdvanceSeconds != 0:
+		testClock.Advance(time.Duration(req.AdvanceSeconds) * time.Second)
+	case req.At != "":
+		at, err := time.Parse(time.RFC3339, req.At)
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(testClockErr),
+				"at must be RFC3339",
+			).Res()
+		}
+		testClock.Set(at)
+	case req.Reset:
+		testClock.Reset()
+	default:
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(testClockErr),
+			"advance_seconds, at or reset is required",
+		).Res()
+	}
+
+	return h.FindTestClock(c)
+}
@@ -6,13 +6,14 @@ import (
 	"strings"
 
 	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
 	"github.com/jmoiron/sqlx"
 )
 
 type IAppinfoRepository interface {
-	FindCategory(req *appinfo.CategoryFilter) ([]*appinfo.Category, error)
-	InsertCategory(req []*appinfo.Category)  error
-	DeleteCategory(categoryId int) error
+	FindCategory(ctx context.Context, req *appinfo.CategoryFilter) ([]*appinfo.Category, error)
+	InsertCategory(ctx context.Context, storeId string, req []*appinfo.Category)  error
+	DeleteCategory(ctx context.Context, storeId string, categoryId int) error
 }
 
 type appinfoRepository struct {
@@ -26,25 +27,29 @@ func AppinfoRepository(db *sqlx.DB) IAppinfoRepository {
 }
 
 
-func (r *appinfoRepository) FindCategory(req *appinfo.CategoryFilter) ([]*appinfo.Category, error)  {
+func (r *appinfoRepository) FindCategory(ctx context.Context, req *appinfo.CategoryFilter) ([]*appinfo.Category, error)  {
 	query := `
 	SELECT
 		"id",
 		"title"
-	FROM "categories"`
+	FROM "categories"
+	WHERE COALESCE("store_id", '') = $1`
 
-	filterValues := make([]any, 0)
+	filterValues := []any{req.StoreId}
 	if req.Title != "" {
 		query += `
-		WHERE (LOWER("title") LIKE $1)`
+		AND (LOWER("title") LIKE $2)`
 
 		filterValues = append(filterValues, "%"+strings.ToLower(req.Title)+"%")
 	}
 	query += ";"
 
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
 	category := make([]*appinfo.Category, 0)
 
-	if err := r.db.Select(&category, query, filterValues...); err != nil {
+	if err := r.db.SelectContext(ctx, &category, query, filterValues...); err != nil {
 		return nil, fmt.Errorf("select categories failed: %v", err)
 	}
 	return category, nil
@@ -52,13 +57,15 @@ func (r *appinfoRepository) FindCategory(req *appinfo.CategoryFilter) ([]*appinf
 
 
 // InsertCategory insert multiple rows
-func (r *appinfoRepository) InsertCategory(req []*appinfo.Category)  error {
-	ctx := context.Background()
+func (r *appinfoRepository) InsertCategory(ctx context.Context, storeId string, req []*appinfo.Category)  error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
 
 
 	query := `
 	INSERT INTO "categories" (
-		"title"
+		"title",
+		"store_id"
 	) VALUES `
 
 
@@ -68,7 +75,8 @@ func (r *appinfoRepository) InsertCategory(req []*appinfo.Category)  error {
 		return fmt.Errorf("begin transaction failed: %v", err)
 	}
 
-	valuesStack := make([]any, 0)
+	// $1 is the store of every row, empty is the default store
+	valuesStack := []any{storeId}
 
 	// loop for insert multiple rows
 	for i,cat := range req {
@@ -76,9 +84,9 @@ func (r *appinfoRepository) InsertCategory(req []*appinfo.Category)  error {
 
 		// if last loop no need to add comma
 		if i == len(req)-1 {
-			query += fmt.Sprintf("($%d)", i+1)
+			query += fmt.Sprintf("($%d, NULLIF($1, ''))", i+2)
 		} else {
-			query += fmt.Sprintf("($%d),", i+1)
+			query += fmt.Sprintf("($%d, NULLIF($1, '')),", i+2)
 		}
 
 	}
@@ -115,14 +123,16 @@ func (r *appinfoRepository) InsertCategory(req []*appinfo.Category)  error {
 	return nil
 }
 
-func (r *appinfoRepository) DeleteCategory(categoryId int) error {
-	ctx := context.Background()
+func (r *appinfoRepository) DeleteCategory(ctx context.Context, storeId string, categoryId int) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
 
 	query := `
 	DELETE FROM "categories"
-	WHERE "id" = $1;`
+	WHERE "id" = $1
+	AND COALESCE("store_id", '') = $2;`
 
-	result, err := r.db.ExecContext(ctx, query, categoryId)
+	result, err := r.db.ExecContext(ctx, query, categoryId, storeId)
 	if err != nil {
 		return fmt.Errorf("delete category failed: %v", err)
 	}
@@ -1,45 +1,62 @@
 package appinfoUsecases
 
 import (
+	"context"
+
 	"github.com/NatthawutSK/ri-shop/modules/appinfo"
 	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
 )
 
 type IAppinfoUsecase interface{
-	FindCategory(req *appinfo.CategoryFilter) ([]*appinfo.Category, error)
-	InsertCategory(req []*appinfo.Category)  error
-	DeleteCategory(categoryId int) error
+	FindCategory(ctx context.Context, req *appinfo.CategoryFilter) ([]*appinfo.Category, error)
+	InsertCategory(ctx context.Context, storeId string, req []*appinfo.Category)  error
+	DeleteCategory(ctx context.Context, storeId string, categoryId int) error
 }
 
 type appinfoUsecase struct {
 	appinfoRepository appinfoRepositories.IAppinfoRepository
+	categoryCache     *cache.Cache[[]*appinfo.Category]
 }
 
-func AppinfoUsecase(appinfoRepository appinfoRepositories.IAppinfoRepository) IAppinfoUsecase {
+func AppinfoUsecase(appinfoRepository appinfoRepositories.IAppinfoRepository, categoryCache *cache.Cache[[]*appinfo.Category]) IAppinfoUsecase {
 	return &appinfoUsecase{
 		appinfoRepository: appinfoRepository,
+		categoryCache:     categoryCache,
 	}
 }
 
 
-func (u *appinfoUsecase) FindCategory(req *appinfo.CategoryFilter) ([]*appinfo.Category, error)  {
-	category, err := u.appinfoRepository.FindCategory(req)
+// FindCategory cache only the whole tree of each store, a search by title always read the database
+func (u *appinfoUsecase) FindCategory(ctx context.Context, req *appinfo.CategoryFilter) ([]*appinfo.Category, error)  {
+	if req.Title == "" {
+		if category, ok := u.categoryCache.Get(req.StoreId); ok {
+			return category, nil
+		}
+	}
+
+	category, err := u.appinfoRepository.FindCategory(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	if req.Title == "" {
+		u.categoryCache.Set(req.StoreId, category)
+	}
 	return category, nil
 }
 
-func (u *appinfoUsecase) InsertCategory(req []*appinfo.Category)  error {
-	if err := u.appinfoRepository.InsertCategory(req); err != nil {
+func (u *appinfoUsecase) InsertCategory(ctx context.Context, storeId string, req []*appinfo.Category)  error {
+	if err := u.appinfoRepository.InsertCategory(ctx, storeId, req); err != nil {
 		return  err
 	}
+	u.categoryCache.Delete(storeId)
 	return nil
 }
 
-func (u *appinfoUsecase) DeleteCategory(categoryId int) error {
-	if err := u.appinfoRepository.DeleteCategory(categoryId); err != nil {
+func (u *appinfoUsecase) DeleteCategory(ctx context.Context, storeId string, categoryId int) error {
+	if err := u.appinfoRepository.DeleteCategory(ctx, storeId, categoryId); err != nil {
 		return  err
 	}
+	u.categoryCache.Delete(storeId)
 	return nil
 }
\ No newline at end of file
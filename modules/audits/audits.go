@@ -0,0 +1,42 @@
+package audits
+
+import "github.com/NatthawutSK/ri-shop/modules/entities"
+
+type Action string
+
+const (
+	ProductCreate      Action = "product.create"
+	ProductUpdate      Action = "product.update"
+	ProductPriceChange Action = "product.price_change"
+	ProductDelete      Action = "product.delete"
+	UserCreateAdmin    Action = "user.create_admin"
+	FileDelete         Action = "file.delete"
+	UserImport         Action = "user.import"
+	RefundIssue        Action = "refund.issue"
+	CancellationReview Action = "cancellation.review"
+	UserUnlock         Action = "user.unlock"
+	TaskPause          Action = "task.pause"
+	TaskResume         Action = "task.resume"
+	UserDelete         Action = "user.delete"
+)
+
+type AuditLog struct {
+	Id        string `json:"id" db:"id"`
+	ActorId   string `json:"actor_id" db:"actor_id"`
+	Action    Action `json:"action" db:"action"`
+	Entity    string `json:"entity" db:"entity"`
+	EntityId  string `json:"entity_id" db:"entity_id"`
+	Before    any    `json:"before" db:"before"`
+	After     any    `json:"after" db:"after"`
+	Diff      any    `json:"diff" db:"diff"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
+
+type AuditFilter struct {
+	ActorId   string `query:"actor_id"`
+	Entity    string `query:"entity"`
+	EntityId  string `query:"entity_id"`
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+	*entities.PaginationReq
+}
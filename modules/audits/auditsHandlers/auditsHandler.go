@@ -0,0 +1,76 @@
+package auditsHandlers
+
+import (
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type auditsHandlerErrCode string
+
+const (
+	findAuditErr auditsHandlerErrCode = "audits-001"
+)
+
+type IAuditsHandler interface {
+	FindAudit(c *fiber.Ctx) error
+}
+
+type auditsHandler struct {
+	cfg           config.IConfig
+	auditsUsecase auditsUsecases.IAuditsUsecase
+}
+
+func AuditsHandler(cfg config.IConfig, auditsUsecase auditsUsecases.IAuditsUsecase) IAuditsHandler {
+	return &auditsHandler{
+		cfg:           cfg,
+		auditsUsecase: auditsUsecase,
+	}
+}
+
+func (h *auditsHandler) FindAudit(c *fiber.Ctx) error {
+	req := &audits.AuditFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findAuditErr),
+			err.Error(),
+		).Res()
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 3 {
+		req.Limit = 3
+	}
+
+	// Date	YYYY-MM-DD
+	if req.StartDate != "" {
+		if _, err := time.Parse("2006-01-02", req.StartDate); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findAuditErr),
+				"start date is invalid",
+			).Res()
+		}
+	}
+	if req.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", req.EndDate); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findAuditErr),
+				"end date is invalid",
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, h.auditsUsecase.FindAudit(c.UserContext(), req)).Res()
+}
@@ -0,0 +1,151 @@
+package auditsRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IAuditsRepository interface {
+	InsertAudit(ctx context.Context, req *audits.AuditLog) error
+	FindAudit(ctx context.Context, req *audits.AuditFilter) ([]*audits.AuditLog, int)
+}
+
+type auditsRepository struct {
+	db *sqlx.DB
+}
+
+func AuditsRepository(db *sqlx.DB) IAuditsRepository {
+	return &auditsRepository{
+		db: db,
+	}
+}
+
+// jsonbValue marshal data for a jsonb column, nil is stored as NULL
+func jsonbValue(data any) (any, error) {
+	if data == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (r *auditsRepository) InsertAudit(ctx context.Context, req *audits.AuditLog) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "audit_logs" (
+		"actor_id",
+		"action",
+		"entity",
+		"entity_id",
+		"before",
+		"after",
+		"diff"
+	)
+	VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, $7::jsonb)
+		RETURNING "id", "created_at";`
+
+	values := []any{req.ActorId, req.Action, req.Entity, req.EntityId}
+	for _, data := range []any{req.Before, req.After, req.Diff} {
+		v, err := jsonbValue(data)
+		if err != nil {
+			return fmt.Errorf("marshal audit data failed: %v", err)
+		}
+		values = append(values, v)
+	}
+
+	if err := r.db.QueryRowContext(ctx, query, values...).Scan(&req.Id, &req.CreatedAt); err != nil {
+		return fmt.Errorf("insert audit log failed: %v", err)
+	}
+	return nil
+}
+
+func (r *auditsRepository) FindAudit(ctx context.Context, req *audits.AuditFilter) ([]*audits.AuditLog, int) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var queryWhere string
+	values := make([]any, 0)
+
+	if req.ActorId != "" {
+		values = append(values, req.ActorId)
+		queryWhere += fmt.Sprintf(`
+		AND "a"."actor_id" = $%d`, len(values))
+	}
+	if req.Entity != "" {
+		values = append(values, req.Entity)
+		queryWhere += fmt.Sprintf(`
+		AND "a"."entity" = $%d`, len(values))
+	}
+	if req.EntityId != "" {
+		values = append(values, req.EntityId)
+		queryWhere += fmt.Sprintf(`
+		AND "a"."entity_id" = $%d`, len(values))
+	}
+	// แต่ละขอบของช่วงวันที่ใช้ได้โดยไม่ต้องมีอีกข้าง
+	if req.StartDate != "" {
+		values = append(values, req.StartDate)
+		queryWhere += fmt.Sprintf(`
+		AND "a"."created_at" >= ($%d)::DATE`, len(values))
+	}
+	if req.EndDate != "" {
+		values = append(values, req.EndDate)
+		queryWhere += fmt.Sprintf(`
+		AND "a"."created_at" < ($%d)::DATE + 1`, len(values))
+	}
+
+	countQuery := `
+	SELECT
+		COUNT(*) AS "count"
+	FROM "audit_logs" "a"
+	WHERE 1 = 1` + queryWhere
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, countQuery, values...); err != nil {
+		log.Printf("count audit logs failed: %v\n", err)
+		return make([]*audits.AuditLog, 0), 0
+	}
+
+	values = append(values, (req.Page-1)*req.Limit, req.Limit)
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"a"."id",
+			"a"."actor_id",
+			"a"."action",
+			"a"."entity",
+			"a"."entity_id",
+			"a"."before",
+			"a"."after",
+			"a"."diff",
+			"a"."created_at"
+		FROM "audit_logs" "a"
+		WHERE 1 = 1` + queryWhere + fmt.Sprintf(`
+		ORDER BY "a"."created_at" DESC
+		OFFSET $%d LIMIT $%d
+	) AS "t";`, len(values)-1, len(values))
+
+	bytes := make([]byte, 0)
+	auditsData := make([]*audits.AuditLog, 0)
+	if err := r.db.GetContext(ctx, &bytes, query, values...); err != nil {
+		log.Printf("find audit logs failed: %v\n", err)
+		return make([]*audits.AuditLog, 0), 0
+	}
+	if err := json.Unmarshal(bytes, &auditsData); err != nil {
+		log.Printf("unmarshal audit logs failed: %v\n", err)
+		return make([]*audits.AuditLog, 0), 0
+	}
+	return auditsData, count
+}
@@ -0,0 +1,45 @@
+package auditsUsecases
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+)
+
+type IAuditsUsecase interface {
+	Record(ctx context.Context, req *audits.AuditLog)
+	FindAudit(ctx context.Context, req *audits.AuditFilter) *entities.PaginateRes
+}
+
+type auditsUsecase struct {
+	auditsRepository auditsRepositories.IAuditsRepository
+}
+
+func AuditsUsecase(auditsRepository auditsRepositories.IAuditsRepository) IAuditsUsecase {
+	return &auditsUsecase{
+		auditsRepository: auditsRepository,
+	}
+}
+
+// Record save audit log, a failed audit must not fail the mutation that already happened so the error is only logged
+func (u *auditsUsecase) Record(ctx context.Context, req *audits.AuditLog) {
+	if err := u.auditsRepository.InsertAudit(ctx, req); err != nil {
+		log.Printf("record audit %s %s/%s failed: %v\n", req.Action, req.Entity, req.EntityId, err)
+	}
+}
+
+func (u *auditsUsecase) FindAudit(ctx context.Context, req *audits.AuditFilter) *entities.PaginateRes {
+	auditsData, count := u.auditsRepository.FindAudit(ctx, req)
+
+	return &entities.PaginateRes{
+		Data:      auditsData,
+		Page:      req.Page,
+		Limit:     req.Limit,
+		TotalPage: int(math.Ceil(float64(count) / float64(req.Limit))),
+		TotalItem: count,
+	}
+}
@@ -0,0 +1,27 @@
+package badges
+
+const (
+	RuleBestSeller = "best_seller" // top rule_value products by sales velocity
+	RuleNewArrival = "new_arrival" // created within rule_value days
+)
+
+// RuleDefaults is rule_value when it is not given
+var RuleDefaults = map[string]int{
+	RuleBestSeller: 10,
+	RuleNewArrival: 30,
+}
+
+// Badge without Rule is only given to products it is assigned to
+type Badge struct {
+	Id        int     `json:"id" db:"id"`
+	Code      string  `json:"code" db:"code"` // e.g. best-seller, used by the badge filter of products
+	Title     string  `json:"title" db:"title"`
+	Color     string  `json:"color" db:"color"`
+	Rule      *string `json:"rule,omitempty" db:"rule"`
+	RuleValue int     `json:"rule_value,omitempty" db:"rule_value"`
+}
+
+type ProductBadgeReq struct {
+	BadgeId   int    `json:"-"`
+	ProductId string `json:"product_id"`
+}
@@ -0,0 +1,181 @@
+package badgesHandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/badges"
+	"github.com/NatthawutSK/ri-shop/modules/badges/badgesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type badgesHandlerErrCode string
+
+const (
+	findBadgeErr     badgesHandlerErrCode = "badges-001"
+	insertBadgeErr   badgesHandlerErrCode = "badges-002"
+	deleteBadgeErr   badgesHandlerErrCode = "badges-003"
+	assignBadgeErr   badgesHandlerErrCode = "badges-004"
+	unassignBadgeErr badgesHandlerErrCode = "badges-005"
+)
+
+type IBadgesHandler interface {
+	FindBadge(c *fiber.Ctx) error
+	AddBadge(c *fiber.Ctx) error
+	DeleteBadge(c *fiber.Ctx) error
+	AssignBadge(c *fiber.Ctx) error
+	UnassignBadge(c *fiber.Ctx) error
+}
+
+type badgesHandler struct {
+	cfg           config.IConfig
+	badgesUsecase badgesUsecases.IBadgesUsecase
+}
+
+func BadgesHandler(cfg config.IConfig, badgesUsecase badgesUsecases.IBadgesUsecase) IBadgesHandler {
+	return &badgesHandler{
+		cfg:           cfg,
+		badgesUsecase: badgesUsecase,
+	}
+}
+
+func (h *badgesHandler) FindBadge(c *fiber.Ctx) error {
+	badgesList, err := h.badgesUsecase.FindBadge(c.UserContext())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findBadgeErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, badgesList).Res()
+}
+
+func (h *badgesHandler) AddBadge(c *fiber.Ctx) error {
+	req := new(badges.Badge)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertBadgeErr),
+			err.Error(),
+		).Res()
+	}
+
+	badge, err := h.badgesUsecase.AddBadge(c.UserContext(), req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "insert badge failed") {
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertBadgeErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertBadgeErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, badge).Res()
+}
+
+func (h *badgesHandler) DeleteBadge(c *fiber.Ctx) error {
+	badgeId, err := strconv.Atoi(strings.Trim(c.Params("badgeId"), " "))
+	if err != nil || badgeId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteBadgeErr),
+			"badge id is invalid",
+		).Res()
+	}
+
+	if err := h.badgesUsecase.DeleteBadge(c.UserContext(), badgeId); err != nil {
+		switch err.Error() {
+		case "badge not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteBadgeErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteBadgeErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
+}
+
+func (h *badgesHandler) AssignBadge(c *fiber.Ctx) error {
+	badgeId, err := strconv.Atoi(strings.Trim(c.Params("badgeId"), " "))
+	if err != nil || badgeId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(assignBadgeErr),
+			"badge id is invalid",
+		).Res()
+	}
+
+	req := &badges.ProductBadgeReq{
+		BadgeId: badgeId,
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(assignBadgeErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.TrimSpace(req.ProductId)
+
+	if err := h.badgesUsecase.AssignBadge(c.UserContext(), req); err != nil {
+		switch err.Error() {
+		case "badge not found", "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(assignBadgeErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(assignBadgeErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, req).Res()
+}
+
+func (h *badgesHandler) UnassignBadge(c *fiber.Ctx) error {
+	badgeId, err := strconv.Atoi(strings.Trim(c.Params("badgeId"), " "))
+	if err != nil || badgeId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(unassignBadgeErr),
+			"badge id is invalid",
+		).Res()
+	}
+
+	req := &badges.ProductBadgeReq{
+		BadgeId:   badgeId,
+		ProductId: strings.TrimSpace(c.Params("productId")),
+	}
+	if err := h.badgesUsecase.UnassignBadge(c.UserContext(), req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(unassignBadgeErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
+}
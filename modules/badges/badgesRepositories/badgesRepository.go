@@ -0,0 +1,132 @@
+package badgesRepositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/badges"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IBadgesRepository interface {
+	FindBadge(ctx context.Context) ([]*badges.Badge, error)
+	InsertBadge(ctx context.Context, req *badges.Badge) error
+	DeleteBadge(ctx context.Context, badgeId int) error
+	AssignBadge(ctx context.Context, req *badges.ProductBadgeReq) error
+	UnassignBadge(ctx context.Context, req *badges.ProductBadgeReq) error
+}
+
+type badgesRepository struct {
+	db *sqlx.DB
+}
+
+func BadgesRepository(db *sqlx.DB) IBadgesRepository {
+	return &badgesRepository{
+		db: db,
+	}
+}
+
+func (r *badgesRepository) FindBadge(ctx context.Context) ([]*badges.Badge, error) {
+	query := `
+	SELECT
+		"id",
+		"code",
+		"title",
+		"color",
+		"rule",
+		"rule_value"
+	FROM "badges"
+	ORDER BY "id" ASC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	badgesList := make([]*badges.Badge, 0)
+	if err := r.db.SelectContext(ctx, &badgesList, query); err != nil {
+		return nil, fmt.Errorf("find badges failed: %v", err)
+	}
+	return badgesList, nil
+}
+
+func (r *badgesRepository) InsertBadge(ctx context.Context, req *badges.Badge) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "badges" (
+		"code",
+		"title",
+		"color",
+		"rule",
+		"rule_value"
+	)
+	VALUES ($1, $2, $3, $4, $5)
+		RETURNING "id";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.Code, req.Title, req.Color, req.Rule, req.RuleValue).Scan(&req.Id); err != nil {
+		switch err.Error() {
+		case `ERROR: duplicate key value violates unique constraint "badges_code_key" (SQLSTATE 23505)`:
+			return fmt.Errorf("badge code has been used")
+		default:
+			return fmt.Errorf("insert badge failed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (r *badgesRepository) DeleteBadge(ctx context.Context, badgeId int) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM "badges" WHERE "id" = $1;`, badgeId)
+	if err != nil {
+		return fmt.Errorf("delete badge failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("badge not found")
+	}
+	return nil
+}
+
+func (r *badgesRepository) AssignBadge(ctx context.Context, req *badges.ProductBadgeReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "products_badges" (
+		"product_id",
+		"badge_id"
+	)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;`
+
+	if _, err := r.db.ExecContext(ctx, query, req.ProductId, req.BadgeId); err != nil {
+		switch err.Error() {
+		case `ERROR: insert or update on table "products_badges" violates foreign key constraint "products_badges_product_id_fkey" (SQLSTATE 23503)`:
+			return fmt.Errorf("product not found")
+		case `ERROR: insert or update on table "products_badges" violates foreign key constraint "products_badges_badge_id_fkey" (SQLSTATE 23503)`:
+			return fmt.Errorf("badge not found")
+		default:
+			return fmt.Errorf("assign badge failed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (r *badgesRepository) UnassignBadge(ctx context.Context, req *badges.ProductBadgeReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `
+	DELETE FROM "products_badges"
+	WHERE "product_id" = $1
+	AND "badge_id" = $2;`, req.ProductId, req.BadgeId); err != nil {
+		return fmt.Errorf("unassign badge failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package badgesUsecases
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/badges"
+	"github.com/NatthawutSK/ri-shop/modules/badges/badgesRepositories"
+)
+
+var codePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type IBadgesUsecase interface {
+	FindBadge(ctx context.Context) ([]*badges.Badge, error)
+	AddBadge(ctx context.Context, req *badges.Badge) (*badges.Badge, error)
+	DeleteBadge(ctx context.Context, badgeId int) error
+	AssignBadge(ctx context.Context, req *badges.ProductBadgeReq) error
+	UnassignBadge(ctx context.Context, req *badges.ProductBadgeReq) error
+}
+
+type badgesUsecase struct {
+	badgesRepository badgesRepositories.IBadgesRepository
+}
+
+func BadgesUsecase(badgesRepository badgesRepositories.IBadgesRepository) IBadgesUsecase {
+	return &badgesUsecase{
+		badgesRepository: badgesRepository,
+	}
+}
+
+func (u *badgesUsecase) FindBadge(ctx context.Context) ([]*badges.Badge, error) {
+	return u.badgesRepository.FindBadge(ctx)
+}
+
+func (u *badgesUsecase) AddBadge(ctx context.Context, req *badges.Badge) (*badges.Badge, error) {
+	req.Code = strings.ToLower(strings.TrimSpace(req.Code))
+	if !codePattern.MatchString(req.Code) {
+		return nil, fmt.Errorf("code must be lowercase letters, digits and dashes")
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	if req.Rule != nil && *req.Rule == "" {
+		req.Rule = nil
+	}
+	if req.Rule == nil {
+		req.RuleValue = 0
+	} else {
+		fallback, ok := badges.RuleDefaults[*req.Rule]
+		if !ok {
+			return nil, fmt.Errorf("rule %s is invalid", *req.Rule)
+		}
+		if req.RuleValue < 0 {
+			return nil, fmt.Errorf("rule value is invalid")
+		}
+		if req.RuleValue == 0 {
+			req.RuleValue = fallback
+		}
+	}
+
+	if err := u.badgesRepository.InsertBadge(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *badgesUsecase) DeleteBadge(ctx context.Context, badgeId int) error {
+	return u.badgesRepository.DeleteBadge(ctx, badgeId)
+}
+
+func (u *badgesUsecase) AssignBadge(ctx context.Context, req *badges.ProductBadgeReq) error {
+	return u.badgesRepository.AssignBadge(ctx, req)
+}
+
+func (u *badgesUsecase) UnassignBadge(ctx context.Context, req *badges.ProductBadgeReq) error {
+	return u.badgesRepository.UnassignBadge(ctx, req)
+}
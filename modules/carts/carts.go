@@ -0,0 +1,65 @@
+package carts
+
+import (
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+)
+
+const (
+	KindPercent = "percent"
+	KindFixed   = "fixed"
+)
+
+type QuoteReq struct {
+	Items          []*shipping.QuoteItem `json:"items"`
+	CouponCode     string                `json:"coupon_code"`
+	Destination    *addresses.Address    `json:"destination"`     // optional, shipping is quoted only with a destination
+	ShippingMethod string                `json:"shipping_method"` // one of shipping_rates, no shipping fee when empty
+	PreviewAt      *time.Time            `json:"-"`               // set by a preview token, drafts and the promotions of that time are used
+	StoreId        string                `json:"-"`               // only products of the store of the request are priced
+}
+
+// Promotion without Code is applied to every cart, with Code it is a coupon
+type Promotion struct {
+	Id          int     `db:"id"`
+	Code        *string `db:"code"`
+	Name        string  `db:"name"`
+	Kind        string  `db:"kind"`
+	Value       float64 `db:"value"` // percent of the subtotal or fixed amount
+	MinSubtotal float64 `db:"min_subtotal"`
+}
+
+type QuoteLine struct {
+	ProductId  string  `json:"product_id" db:"id"`
+	Title      string  `json:"title" db:"title"`
+	Qty        int     `json:"qty"`
+	UnitPrice  float64 `json:"unit_price" db:"price"`
+	Total      float64 `json:"total"`
+	CategoryId int     `json:"-" db:"category_id"` // picks the tax rate of the line
+}
+
+type Discount struct {
+	Code   string  `json:"code,omitempty"`
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// Quote is a preview, nothing is reserved or created and the order is priced again when placed
+type Quote struct {
+	Lines          []*QuoteLine     `json:"lines"`
+	Subtotal       float64          `json:"subtotal"`
+	Discounts      []*Discount      `json:"discounts"`
+	DiscountTotal  float64          `json:"discount_total"`
+	CouponError    string           `json:"coupon_error,omitempty"` // why coupon_code was not applied
+	ShippingRates  []*shipping.Rate `json:"shipping_rates"`
+	ShippingMethod string           `json:"shipping_method"`
+	ShippingFee    float64          `json:"shipping_fee"`
+	TaxRate        float64          `json:"tax_rate"` // general rate of the destination, lines may have their own
+	Tax            float64          `json:"tax"`
+	TaxBreakdown   *taxes.Breakdown `json:"tax_breakdown"`
+	Total          float64          `json:"total"`
+	Currency       string           `json:"currency"`
+}
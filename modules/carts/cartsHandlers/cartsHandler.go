@@ -0,0 +1,74 @@
+package cartsHandlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/carts"
+	"github.com/NatthawutSK/ri-shop/modules/carts/cartsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/gofiber/fiber/v2"
+)
+
+type cartsHandlerErrCode string
+
+const (
+	quoteErr cartsHandlerErrCode = "carts-001"
+)
+
+type ICartsHandler interface {
+	Quote(c *fiber.Ctx) error
+}
+
+type cartsHandler struct {
+	cfg          config.IConfig
+	cartsUsecase cartsUsecases.ICartsUsecase
+}
+
+func CartsHandler(cfg config.IConfig, cartsUsecase cartsUsecases.ICartsUsecase) ICartsHandler {
+	return &cartsHandler{
+		cfg:          cfg,
+		cartsUsecase: cartsUsecase,
+	}
+}
+
+func (h *cartsHandler) Quote(c *fiber.Ctx) error {
+	req := &carts.QuoteReq{
+		Items: make([]*shipping.QuoteItem, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(quoteErr),
+			err.Error(),
+		).Res()
+	}
+
+	for _, item := range req.Items {
+		if item.Qty < 1 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(quoteErr),
+				"qty is invalid",
+			).Res()
+		}
+	}
+	req.CouponCode = strings.TrimSpace(req.CouponCode)
+	req.StoreId = c.Locals("storeId").(string)
+	if at, ok := c.Locals("previewAt").(time.Time); ok {
+		req.PreviewAt = &at
+	}
+
+	quote, err := h.cartsUsecase.Quote(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(quoteErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, quote).Res()
+}
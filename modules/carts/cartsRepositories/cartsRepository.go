@@ -0,0 +1,127 @@
+package cartsRepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/carts"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type ICartsRepository interface {
+	FindQuoteLines(ctx context.Context, storeId string, items []*shipping.QuoteItem, preview bool) ([]*carts.QuoteLine, string, error)
+	FindAutoPromotion(ctx context.Context, at time.Time) ([]*carts.Promotion, error)
+	FindCoupon(ctx context.Context, code string, at time.Time) (*carts.Promotion, error)
+}
+
+type cartsRepository struct {
+	db *sqlx.DB
+}
+
+func CartsRepository(db *sqlx.DB) ICartsRepository {
+	return &cartsRepository{
+		db: db,
+	}
+}
+
+// FindQuoteLines price the items with the current product prices, return the lines and their currency.
+// Products of other stores are not found, draft products are only found in a preview
+func (r *cartsRepository) FindQuoteLines(ctx context.Context, storeId string, items []*shipping.QuoteItem, preview bool) ([]*carts.QuoteLine, string, error) {
+	ids := make([]string, 0)
+	for _, item := range items {
+		ids = append(ids, item.ProductId)
+	}
+
+	query, args, err := sqlx.In(`
+	SELECT
+		"id",
+		"title",
+		minor_to_major("price_minor", "currency") AS "price",
+		"currency",
+		COALESCE((
+			SELECT
+				"pc"."category_id"
+			FROM "products_categories" "pc"
+			WHERE "pc"."product_id" = "products"."id"
+			LIMIT 1
+		), 0) AS "category_id"
+	FROM "products"
+	WHERE "id" IN (?)
+	AND COALESCE("store_id", '') = ?
+	AND ("status" = 'published' OR ("status" = 'draft' AND ?));`, ids, storeId, preview)
+	if err != nil {
+		return nil, "", fmt.Errorf("build find quote lines query failed: %v", err)
+	}
+
+	products := make([]*struct {
+		carts.QuoteLine
+		Currency string `db:"currency"`
+	}, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if err := r.db.SelectContext(ctx, &products, r.db.Rebind(query), args...); err != nil {
+		return nil, "", fmt.Errorf("find quote lines failed: %v", err)
+	}
+
+	productsMap := make(map[string]int)
+	for i, product := range products {
+		productsMap[product.ProductId] = i
+	}
+
+	currency := ""
+	lines := make([]*carts.QuoteLine, 0)
+	for _, item := range items {
+		i, ok := productsMap[item.ProductId]
+		if !ok {
+			return nil, "", fmt.Errorf("product %s not found", item.ProductId)
+		}
+		line := products[i].QuoteLine
+		line.Qty = item.Qty
+		lines = append(lines, &line)
+		currency = products[i].Currency
+	}
+	return lines, currency, nil
+}
+
+const findPromotionQuery = `
+	SELECT
+		"id",
+		"code",
+		"name",
+		"kind",
+		"value",
+		"min_subtotal"
+	FROM "promotions"
+	WHERE "active" = TRUE
+	AND ("starts_at" IS NULL OR "starts_at" <= $1)
+	AND ("ends_at" IS NULL OR "ends_at" > $1)`
+
+// FindAutoPromotion find promotions running at, it is now except in a preview
+func (r *cartsRepository) FindAutoPromotion(ctx context.Context, at time.Time) ([]*carts.Promotion, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	promotions := make([]*carts.Promotion, 0)
+	if err := r.db.SelectContext(ctx, &promotions, findPromotionQuery+`
+	AND "code" IS NULL
+	ORDER BY "id" ASC;`, at); err != nil {
+		return nil, fmt.Errorf("find promotions failed: %v", err)
+	}
+	return promotions, nil
+}
+
+func (r *cartsRepository) FindCoupon(ctx context.Context, code string, at time.Time) (*carts.Promotion, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	coupon := new(carts.Promotion)
+	if err := r.db.GetContext(ctx, coupon, findPromotionQuery+`
+	AND UPPER("code") = UPPER($2);`, at, code); err != nil {
+		return nil, fmt.Errorf("coupon is invalid or expired")
+	}
+	return coupon, nil
+}
@@ -0,0 +1,174 @@
+package cartsUsecases
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/carts"
+	"github.com/NatthawutSK/ri-shop/modules/carts/cartsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/modules/shipping/shippingUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/clock"
+)
+
+type ICartsUsecase interface {
+	Quote(ctx context.Context, req *carts.QuoteReq) (*carts.Quote, error)
+}
+
+type cartsUsecase struct {
+	cfg             config.IConfig
+	cartsRepository cartsRepositories.ICartsRepository
+	shippingUsecase shippingUsecases.IShippingUsecase
+	taxesUsecase    taxesUsecases.ITaxesUsecase
+	clock           clock.Clock
+}
+
+func CartsUsecase(cfg config.IConfig, cartsRepository cartsRepositories.ICartsRepository, shippingUsecase shippingUsecases.IShippingUsecase, taxesUsecase taxesUsecases.ITaxesUsecase, clock clock.Clock) ICartsUsecase {
+	return &cartsUsecase{
+		cfg:             cfg,
+		cartsRepository: cartsRepository,
+		shippingUsecase: shippingUsecase,
+		taxesUsecase:    taxesUsecase,
+		clock:           clock,
+	}
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Quote price the cart as checkout would: subtotal - discounts + shipping fee, then tax on top
+func (u *cartsUsecase) Quote(ctx context.Context, req *carts.QuoteReq) (*carts.Quote, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items are empty")
+	}
+
+	at := u.clock.Now()
+	if req.PreviewAt != nil {
+		at = *req.PreviewAt
+	}
+
+	lines, currency, err := u.cartsRepository.FindQuoteLines(ctx, req.StoreId, req.Items, req.PreviewAt != nil)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		currency = u.cfg.App().Currency()
+	}
+
+	quote := &carts.Quote{
+		Lines:         lines,
+		Discounts:     make([]*carts.Discount, 0),
+		ShippingRates: make([]*shipping.Rate, 0),
+		TaxRate:       u.cfg.App().TaxRate(),
+		Currency:      currency,
+	}
+	for _, line := range lines {
+		line.Total = round(line.UnitPrice * float64(line.Qty))
+		quote.Subtotal += line.Total
+	}
+	quote.Subtotal = round(quote.Subtotal)
+
+	// Discounts
+	promotions, err := u.cartsRepository.FindAutoPromotion(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	if req.CouponCode != "" {
+		coupon, err := u.cartsRepository.FindCoupon(ctx, req.CouponCode, at)
+		if err != nil {
+			quote.CouponError = err.Error()
+		} else if quote.Subtotal < coupon.MinSubtotal {
+			quote.CouponError = fmt.Sprintf("subtotal must be at least %.2f", coupon.MinSubtotal)
+		} else {
+			promotions = append(promotions, coupon)
+		}
+	}
+
+	for _, promotion := range promotions {
+		if quote.Subtotal < promotion.MinSubtotal {
+			continue
+		}
+
+		amount := promotion.Value
+		if promotion.Kind == carts.KindPercent {
+			amount = quote.Subtotal * promotion.Value / 100
+		}
+		// ส่วนลดรวมต้องไม่เกิน subtotal
+		amount = round(math.Min(amount, quote.Subtotal-quote.DiscountTotal))
+		if amount <= 0 {
+			continue
+		}
+
+		discount := &carts.Discount{
+			Name:   promotion.Name,
+			Amount: amount,
+		}
+		if promotion.Code != nil {
+			discount.Code = *promotion.Code
+		}
+		quote.Discounts = append(quote.Discounts, discount)
+		quote.DiscountTotal = round(quote.DiscountTotal + amount)
+	}
+
+	// Shipping
+	if req.Destination != nil {
+		shippingQuote, err := u.shippingUsecase.Quote(ctx, &shipping.QuoteReq{
+			StoreId:     req.StoreId,
+			Items:       req.Items,
+			Destination: req.Destination,
+		})
+		if err != nil {
+			return nil, err
+		}
+		quote.ShippingRates = shippingQuote.Rates
+	}
+	if req.ShippingMethod != "" {
+		var rate *shipping.Rate
+		for _, r := range quote.ShippingRates {
+			if strings.EqualFold(r.Method, req.ShippingMethod) {
+				rate = r
+				break
+			}
+		}
+		if rate == nil {
+			return nil, fmt.Errorf("shipping method %s is not available", req.ShippingMethod)
+		}
+		quote.ShippingMethod = rate.Method
+		quote.ShippingFee = rate.Fee
+	}
+
+	// Tax, ส่วนลดถูกกระจายไปตามสัดส่วนของแต่ละ line ก่อนคิดภาษี
+	taxReq := &taxes.CalculateReq{
+		Currency:    quote.Currency,
+		Lines:       make([]*taxes.CalculateLine, 0, len(lines)),
+		ShippingFee: quote.ShippingFee,
+	}
+	if req.Destination != nil {
+		taxReq.Country = req.Destination.Country
+		taxReq.State = req.Destination.State
+	}
+	for _, line := range lines {
+		taxReq.Lines = append(taxReq.Lines, &taxes.CalculateLine{
+			ProductId:  line.ProductId,
+			CategoryId: line.CategoryId,
+			Amount:     line.Total,
+		})
+	}
+	taxReq.AllocateDiscount(quote.DiscountTotal)
+	breakdown, err := u.taxesUsecase.Calculate(ctx, taxReq)
+	if err != nil {
+		return nil, err
+	}
+	quote.TaxBreakdown = breakdown
+	quote.TaxRate = breakdown.ShippingRate
+	quote.Tax = breakdown.Total
+	quote.Total = round(quote.Subtotal - quote.DiscountTotal + quote.ShippingFee + quote.Tax)
+
+	return quote, nil
+}
@@ -0,0 +1,164 @@
+package catalogHandlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/catalog/catalogpb"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// max products of one GetPrices call
+const maxPriceIds = 100
+
+type ICatalogHandler interface {
+	catalogpb.CatalogServiceServer
+}
+
+type catalogHandler struct {
+	catalogpb.UnimplementedCatalogServiceServer
+	cfg              config.IConfig
+	productsUsecase  productsUsecases.IProductsUsecase
+	inventoryUsecase inventoryUsecases.IInventoryUsecase
+}
+
+func CatalogHandler(cfg config.IConfig, productsUsecase productsUsecases.IProductsUsecase, inventoryUsecase inventoryUsecases.IInventoryUsecase) ICatalogHandler {
+	return &catalogHandler{
+		cfg:              cfg,
+		productsUsecase:  productsUsecase,
+		inventoryUsecase: inventoryUsecase,
+	}
+}
+
+func (h *catalogHandler) GetProduct(ctx context.Context, req *catalogpb.GetProductRequest) (*catalogpb.Product, error) {
+	productId := strings.TrimSpace(req.GetId())
+	if productId == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	product, err := h.productsUsecase.FindOneProduct(ctx, productId)
+	if err != nil {
+		return nil, productErr(err)
+	}
+	if err := h.productsUsecase.ConvertCurrency(ctx, []*products.Products{product}, req.GetCurrency()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	res := &catalogpb.Product{
+		Id:          product.Id,
+		Title:       product.Title,
+		Description: product.Description,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Prices:      make([]*catalogpb.ProductPrice, 0),
+		Stock:       int32(product.Stock),
+		ImageUrls:   make([]string, 0),
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+	}
+	if product.Category != nil {
+		res.Category = product.Category.Title
+	}
+	for _, price := range product.Prices {
+		res.Prices = append(res.Prices, &catalogpb.ProductPrice{Currency: price.Currency, Price: price.Price})
+	}
+	for _, image := range product.Images {
+		res.ImageUrls = append(res.ImageUrls, image.Url)
+	}
+	return res, nil
+}
+
+func (h *catalogHandler) GetPrices(ctx context.Context, req *catalogpb.GetPricesRequest) (*catalogpb.GetPricesResponse, error) {
+	if len(req.GetProductIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "product_ids is required")
+	}
+	if len(req.GetProductIds()) > maxPriceIds {
+		return nil, status.Errorf(codes.InvalidArgument, "product_ids must be at most %d", maxPriceIds)
+	}
+
+	res := &catalogpb.GetPricesResponse{
+		Prices: make([]*catalogpb.PriceQuote, 0),
+	}
+	for _, productId := range req.GetProductIds() {
+		// grpc อ่านได้ทุกร้าน availability จึงใช้ร้านของ product เอง
+		product, err := h.productsUsecase.FindOneProduct(ctx, strings.TrimSpace(productId))
+		if err != nil {
+			return nil, productErr(err)
+		}
+		// ใช้ availability ตัวเดียวกับหน้า product ราคาจึงตรงกับ http api
+		availability, err := h.productsUsecase.FindAvailability(ctx, product.StoreId, product.Id, req.GetCurrency())
+		if err != nil {
+			return nil, productErr(err)
+		}
+		res.Prices = append(res.Prices, &catalogpb.PriceQuote{
+			ProductId: availability.ProductId,
+			Price:     availability.Price,
+			Currency:  availability.Currency,
+			Stock:     int32(availability.Stock),
+			InStock:   availability.InStock,
+		})
+	}
+	return res, nil
+}
+
+func (h *catalogHandler) ReserveStock(ctx context.Context, req *catalogpb.ReserveStockRequest) (*catalogpb.ReserveStockResponse, error) {
+	reservation := &inventory.Reservation{
+		Reference:  req.GetReference(),
+		Items:      make([]*inventory.StockItem, 0),
+		TtlSeconds: int(req.GetTtlSeconds()),
+	}
+	for _, item := range req.GetItems() {
+		reservation.Items = append(reservation.Items, &inventory.StockItem{
+			ProductId: strings.TrimSpace(item.GetProductId()),
+			Qty:       int(item.GetQty()),
+		})
+	}
+
+	reservation, err := h.inventoryUsecase.ReserveStock(ctx, reservation)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "insufficient stock"):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case strings.HasSuffix(err.Error(), "not found"):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case strings.Contains(err.Error(), "failed"):
+			return nil, status.Error(codes.Internal, err.Error())
+		default:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	res := &catalogpb.ReserveStockResponse{
+		Reference: reservation.Reference,
+		Items:     make([]*catalogpb.StockItem, 0),
+		ExpiresAt: reservation.ExpiresAt,
+	}
+	for _, item := range reservation.Items {
+		res.Items = append(res.Items, &catalogpb.StockItem{ProductId: item.ProductId, Qty: int32(item.Qty)})
+	}
+	return res, nil
+}
+
+func (h *catalogHandler) ReleaseStock(ctx context.Context, req *catalogpb.ReleaseStockRequest) (*catalogpb.ReleaseStockResponse, error) {
+	released, err := h.inventoryUsecase.ReleaseStock(ctx, req.GetReference())
+	if err != nil {
+		if err.Error() == "reference is required" {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &catalogpb.ReleaseStockResponse{Released: int32(released)}, nil
+}
+
+func productErr(err error) error {
+	if strings.Contains(err.Error(), "no rows in result set") {
+		return status.Error(codes.NotFound, "product not found")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
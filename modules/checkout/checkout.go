@@ -0,0 +1,58 @@
+package checkout
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/carts"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+)
+
+// CheckoutReq is the cart of the customer with how it is delivered and paid, the prices are read from the products
+type CheckoutReq struct {
+	UserId         string                `json:"-"`
+	StoreId        string                `json:"-"`
+	Items          []*shipping.QuoteItem `json:"items"`
+	CouponCode     string                `json:"coupon_code"`
+	Fulfillment    string                `json:"fulfillment"`     // delivery (default) or pickup
+	AddressId      string                `json:"address_id"`      // address book entry, the default address when empty
+	PickupSlotId   int                   `json:"pickup_slot_id"`  // required for pickup
+	Contact        string                `json:"contact"`         // phone of the address when empty
+	ShippingMethod string                `json:"shipping_method"` // one of the shipping_rates of a quote
+	Gift           *orders.Gift          `json:"gift"`
+	PaymentMethod  string                `json:"payment_method"` // the gateway of the server when empty
+}
+
+// Checkout is the placed order, its price and how to pay it
+type Checkout struct {
+	Order     *orders.Order `json:"order"`
+	Breakdown *Breakdown    `json:"breakdown"`
+	Payment   *Payment      `json:"payment"`
+	HeldUntil string        `json:"held_until"` // the stock of the order is held until then, after that it is checked again when paid
+}
+
+// Breakdown is the price of the order, Total is the total_paid of the order
+type Breakdown struct {
+	Lines          []*carts.QuoteLine `json:"lines"`
+	Subtotal       float64            `json:"subtotal"`
+	Discounts      []*carts.Discount  `json:"discounts"`
+	DiscountTotal  float64            `json:"discount_total"`
+	ShippingMethod string             `json:"shipping_method"`
+	ShippingFee    float64            `json:"shipping_fee"`
+	Tax            float64            `json:"tax"`
+	TaxBreakdown   *taxes.Breakdown   `json:"tax_breakdown"`
+	Total          float64            `json:"total"`
+	Currency       string             `json:"currency"`
+}
+
+const PaymentPending = "pending"
+
+// Payment is a payment started by the gateway, the order becomes paid when the payments webhook confirm it
+type Payment struct {
+	Method       string  `json:"method"`
+	Reference    string  `json:"reference"`
+	Status       string  `json:"status"`
+	Amount       float64 `json:"amount"`
+	Currency     string  `json:"currency"`
+	RedirectUrl  string  `json:"redirect_url,omitempty"` // page of the gateway where the customer pay
+	Instructions string  `json:"instructions,omitempty"`
+}
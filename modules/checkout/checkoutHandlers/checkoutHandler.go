@@ -0,0 +1,114 @@
+package checkoutHandlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/checkout"
+	"github.com/NatthawutSK/ri-shop/modules/checkout/checkoutUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+	"github.com/gofiber/fiber/v2"
+)
+
+type checkoutHandlerErrCode string
+
+const (
+	checkoutErr checkoutHandlerErrCode = "checkout-001"
+)
+
+type ICheckoutHandler interface {
+	Checkout(c *fiber.Ctx) error
+}
+
+type checkoutHandler struct {
+	cfg             config.IConfig
+	checkoutUsecase checkoutUsecases.ICheckoutUsecase
+}
+
+func CheckoutHandler(cfg config.IConfig, checkoutUsecase checkoutUsecases.ICheckoutUsecase) ICheckoutHandler {
+	return &checkoutHandler{
+		cfg:             cfg,
+		checkoutUsecase: checkoutUsecase,
+	}
+}
+
+func (h *checkoutHandler) Checkout(c *fiber.Ctx) error {
+	req := &checkout.CheckoutReq{
+		Items: make([]*shipping.QuoteItem, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(checkoutErr),
+			err.Error(),
+		).Res()
+	}
+	// ลูกค้า checkout ได้เฉพาะของตัวเอง
+	req.UserId = c.Locals("userId").(string)
+	req.StoreId = c.Locals("storeId").(string)
+
+	res, err := h.checkoutUsecase.Checkout(c.UserContext(), req)
+	if err != nil {
+		var fieldErrs entities.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			return entities.NewResponse(c).ValidationError(
+				fiber.ErrBadRequest.Code,
+				string(checkoutErr),
+				fieldErrs,
+			).Res()
+		}
+		var alert *inventory.StockAlert
+		if errors.As(err, &alert) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(checkoutErr),
+				err.Error(),
+			).Res()
+		}
+		var openErr *resilience.OpenError
+		if errors.As(err, &openErr) {
+			// payment gateway ล่มอยู่ order ถูกยกเลิกไปแล้ว ลองใหม่ได้หลัง Retry-After
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(openErr.RetryAfter.Seconds()))))
+			return entities.NewResponse(c).Error(
+				fiber.ErrServiceUnavailable.Code,
+				string(checkoutErr),
+				openErr.Error(),
+			).Res()
+		}
+		switch {
+		case err.Error() == "reservation has expired",
+			err.Error() == "prices changed during checkout",
+			err.Error() == "pickup slot is full":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(checkoutErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "items are empty",
+			err.Error() == "gift order cannot be picked up",
+			err.Error() == "pickup_slot_id is required",
+			err.Error() == "pickup location is closed",
+			strings.HasPrefix(err.Error(), "shipping method"),
+			strings.HasSuffix(err.Error(), "not found"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(checkoutErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(checkoutErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, res).Res()
+}
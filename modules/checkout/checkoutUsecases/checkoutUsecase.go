@@ -0,0 +1,288 @@
+package checkoutUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/addresses/addressesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/carts"
+	"github.com/NatthawutSK/ri-shop/modules/carts/cartsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/checkout"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+)
+
+type ICheckoutUsecase interface {
+	Checkout(ctx context.Context, req *checkout.CheckoutReq) (*checkout.Checkout, error)
+}
+
+type checkoutUsecase struct {
+	cartsUsecase     cartsUsecases.ICartsUsecase
+	inventoryUsecase inventoryUsecases.IInventoryUsecase
+	ordersUsecase    ordersUsecases.IOrdersUsecase
+	addressesUsecase addressesUsecases.IAddressesUsecase
+	gateway          IPaymentGateway
+}
+
+func CheckoutUsecase(cartsUsecase cartsUsecases.ICartsUsecase, inventoryUsecase inventoryUsecases.IInventoryUsecase, ordersUsecase ordersUsecases.IOrdersUsecase, addressesUsecase addressesUsecases.IAddressesUsecase, gateway IPaymentGateway) ICheckoutUsecase {
+	return &checkoutUsecase{
+		cartsUsecase:     cartsUsecase,
+		inventoryUsecase: inventoryUsecase,
+		ordersUsecase:    ordersUsecase,
+		addressesUsecase: addressesUsecase,
+		gateway:          gateway,
+	}
+}
+
+// paymentBreaker guard the calls to the payment gateway, during an outage they fail at once with a resilience.OpenError
+var paymentBreaker = resilience.Get("payment")
+
+const paymentTimeout = 15 * time.Second
+
+// Checkout place the order of the cart in one call: the cart is priced with the coupon, the stock is held, the
+// order is inserted with the hold in one transaction and the payment is started. When a step fails the steps
+// which are done are undone, so no hold or waiting order is left behind
+func (u *checkoutUsecase) Checkout(ctx context.Context, req *checkout.CheckoutReq) (*checkout.Checkout, error) {
+	if err := u.prepare(req); err != nil {
+		return nil, err
+	}
+
+	// Price
+	quoteReq := &carts.QuoteReq{
+		Items:          req.Items,
+		CouponCode:     req.CouponCode,
+		ShippingMethod: req.ShippingMethod,
+		StoreId:        req.StoreId,
+	}
+	if req.Fulfillment == orders.FulfillmentDelivery {
+		destination, err := u.destination(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		quoteReq.Destination = destination
+	}
+	quote, err := u.cartsUsecase.Quote(ctx, quoteReq)
+	if err != nil {
+		return nil, err
+	}
+	// quote ข้าม coupon ที่ใช้ไม่ได้ แต่ checkout ต้องไม่คิดราคาต่างจากที่ลูกค้าขอ
+	if quote.CouponError != "" {
+		return nil, entities.ValidationErrors{{Field: "coupon_code", Msg: quote.CouponError}}
+	}
+
+	s := new(saga)
+
+	// Stock
+	stockItems := make([]*inventory.StockItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		stockItems = append(stockItems, &inventory.StockItem{ProductId: item.ProductId, Qty: item.Qty})
+	}
+	reservation, err := u.inventoryUsecase.StartCheckout(ctx, &inventory.CheckoutReq{
+		StoreId: req.StoreId,
+		UserId:  req.UserId,
+		Items:   stockItems,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.done("release stock hold", func(ctx context.Context) error {
+		_, err := u.inventoryUsecase.ReleaseStock(ctx, reservation.Reference)
+		return err
+	})
+
+	// Order, the order, its items and the hold are written in one transaction of the orders repository
+	order, err := u.ordersUsecase.InsertOrder(ctx, u.newOrder(req, quote, reservation.Reference))
+	if err != nil {
+		s.rollback(req.UserId)
+		return nil, err
+	}
+	// hold ย้ายไปอยู่กับ order แล้ว ยกเลิก order ก็คืน stock ให้เอง
+	s = new(saga)
+	s.done("cancel order "+order.Id, func(ctx context.Context) error {
+		_, err := u.ordersUsecase.UpdateOrder(ctx, &orders.OrderUpdate{
+			Id:          order.Id,
+			Status:      orders.StatusCanceled,
+			ActorId:     req.UserId,
+			ActorRoleId: 1,
+		})
+		return err
+	})
+
+	breakdown := newBreakdown(quote, order)
+	// order คิดราคาใหม่จาก product ถ้าราคาเปลี่ยนระหว่าง checkout ลูกค้าต้องเห็นราคาใหม่ก่อนจ่าย
+	if math.Abs(breakdown.Total-round(quote.Total)) >= 0.01 {
+		s.rollback(req.UserId)
+		return nil, fmt.Errorf("prices changed during checkout")
+	}
+
+	// Payment
+	var payment *checkout.Payment
+	paymentCtx, cancel := context.WithTimeout(ctx, paymentTimeout)
+	defer cancel()
+	if err := paymentBreaker.Do(paymentCtx, func(ctx context.Context) error {
+		payment, err = u.gateway.Initiate(ctx, order, breakdown.Total, breakdown.Currency)
+		return err
+	}); err != nil {
+		s.rollback(req.UserId)
+		return nil, err
+	}
+
+	return &checkout.Checkout{
+		Order:     order,
+		Breakdown: breakdown,
+		Payment:   payment,
+		HeldUntil: reservation.ExpiresAt,
+	}, nil
+}
+
+// prepare check the request and merge the items of the same product
+func (u *checkoutUsecase) prepare(req *checkout.CheckoutReq) error {
+	if len(req.Items) == 0 {
+		return fmt.Errorf("items are empty")
+	}
+
+	errs := make(entities.ValidationErrors, 0)
+	qty := make(map[string]int)
+	items := make([]*shipping.QuoteItem, 0, len(req.Items))
+	for i, item := range req.Items {
+		item.ProductId = strings.TrimSpace(item.ProductId)
+		if item.ProductId == "" {
+			errs = append(errs, &entities.FieldError{Field: fmt.Sprintf("items[%d].product_id", i), Msg: "is required"})
+			continue
+		}
+		if item.Qty <= 0 {
+			errs = append(errs, &entities.FieldError{Field: fmt.Sprintf("items[%d].qty", i), Msg: "must be more than 0"})
+			continue
+		}
+		if _, ok := qty[item.ProductId]; !ok {
+			items = append(items, &shipping.QuoteItem{ProductId: item.ProductId})
+		}
+		qty[item.ProductId] += item.Qty
+	}
+	for _, item := range items {
+		item.Qty = qty[item.ProductId]
+	}
+	req.Items = items
+
+	req.CouponCode = strings.TrimSpace(req.CouponCode)
+	switch req.Fulfillment {
+	case "", orders.FulfillmentDelivery:
+		req.Fulfillment = orders.FulfillmentDelivery
+		req.PickupSlotId = 0
+		if req.Gift != nil && req.Gift.Recipient == nil {
+			errs = append(errs, &entities.FieldError{Field: "gift.recipient", Msg: "is required"})
+		}
+	case orders.FulfillmentPickup:
+		// รับที่ร้านไม่มีค่าส่ง
+		req.AddressId = ""
+		req.ShippingMethod = ""
+	default:
+		errs = append(errs, &entities.FieldError{Field: "fulfillment", Msg: "must be delivery or pickup"})
+	}
+	if req.PaymentMethod != "" && req.PaymentMethod != u.gateway.Code() {
+		errs = append(errs, &entities.FieldError{Field: "payment_method", Msg: "is not supported"})
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// destination is where a delivery is shipped, the recipient of a gift or the chosen address of the customer
+func (u *checkoutUsecase) destination(ctx context.Context, req *checkout.CheckoutReq) (*addresses.Address, error) {
+	if req.Gift != nil {
+		return req.Gift.Recipient, nil
+	}
+	return u.addressesUsecase.FindCheckoutAddress(ctx, req.UserId, req.AddressId)
+}
+
+func (u *checkoutUsecase) newOrder(req *checkout.CheckoutReq, quote *carts.Quote, reservation string) *orders.Order {
+	order := &orders.Order{
+		UserId:         req.UserId,
+		StoreId:        req.StoreId,
+		Products:       make([]*orders.ProductsOrder, 0, len(req.Items)),
+		AddressId:      req.AddressId,
+		Contact:        req.Contact,
+		Status:         orders.StatusWaiting,
+		ShippingMethod: quote.ShippingMethod,
+		Gift:           req.Gift,
+		Fulfillment:    req.Fulfillment,
+		PickupSlotId:   req.PickupSlotId,
+		Reservation:    reservation,
+		Discount:       quote.DiscountTotal,
+	}
+	for _, item := range req.Items {
+		order.Products = append(order.Products, &orders.ProductsOrder{
+			Qty:     item.Qty,
+			Product: &products.Products{Id: item.ProductId},
+		})
+	}
+	for _, discount := range quote.Discounts {
+		if discount.Code != "" {
+			order.CouponCode = discount.Code
+		}
+	}
+	return order
+}
+
+// newBreakdown is the lines and the discounts of the quote with the shipping fee and the tax kept on the order
+func newBreakdown(quote *carts.Quote, order *orders.Order) *checkout.Breakdown {
+	breakdown := &checkout.Breakdown{
+		Lines:          quote.Lines,
+		Subtotal:       quote.Subtotal,
+		Discounts:      quote.Discounts,
+		DiscountTotal:  order.Discount,
+		ShippingMethod: order.ShippingMethod,
+		ShippingFee:    order.ShippingFee,
+		TaxBreakdown:   order.Tax,
+		Total:          round(order.TotalPaid),
+		Currency:       quote.Currency,
+	}
+	if order.Tax != nil {
+		breakdown.Tax = order.Tax.Total
+	}
+	return breakdown
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// saga is the compensations of the steps which are done, when a later step fails they are run in the reverse order
+type saga struct {
+	steps []*sagaStep
+}
+
+type sagaStep struct {
+	name       string
+	compensate func(ctx context.Context) error
+}
+
+func (s *saga) done(name string, compensate func(ctx context.Context) error) {
+	s.steps = append(s.steps, &sagaStep{name: name, compensate: compensate})
+}
+
+// rollback undo every step, a compensation which fails is only logged so the error of the checkout is returned.
+// An order which could not be canceled is left waiting, a hold which could not be released expires by itself.
+// The checkout may have failed on the deadline of the request, so the compensations have their own context
+func (s *saga) rollback(userId string) {
+	ctx := context.Background()
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		if err := s.steps[i].compensate(ctx); err != nil {
+			log.Printf("checkout of user %s: %s failed: %v\n", userId, s.steps[i].name, err)
+		}
+	}
+	s.steps = nil
+}
@@ -0,0 +1,38 @@
+package checkoutUsecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/checkout"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/google/uuid"
+)
+
+type IPaymentGateway interface {
+	Code() string
+	// Initiate start the payment of amount for the order, it must not charge the customer twice when it is
+	// retried with the same order
+	Initiate(ctx context.Context, order *orders.Order, amount float64, currency string) (*checkout.Payment, error)
+}
+
+// manualTransferGateway is a bank transfer, the customer upload the transfer slip to the order and an admin
+// mark it paid, so nothing is called
+type manualTransferGateway struct{}
+
+func ManualTransferGateway() IPaymentGateway {
+	return &manualTransferGateway{}
+}
+
+func (g *manualTransferGateway) Code() string { return "manual" }
+
+func (g *manualTransferGateway) Initiate(ctx context.Context, order *orders.Order, amount float64, currency string) (*checkout.Payment, error) {
+	return &checkout.Payment{
+		Method:       g.Code(),
+		Reference:    g.Code() + ":" + uuid.NewString(),
+		Status:       checkout.PaymentPending,
+		Amount:       amount,
+		Currency:     currency,
+		Instructions: fmt.Sprintf("transfer %.2f %s and upload the transfer slip to order %s", amount, currency, order.Id),
+	}, nil
+}
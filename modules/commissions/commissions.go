@@ -0,0 +1,25 @@
+package commissions
+
+// Rule is evaluated when an order is completed. The most specific active rule of an item wins, category
+// and seller tier before category only, before seller tier only, before a rule for everything.
+// Commission of an item is price * qty * Percentage + Flat * qty
+type Rule struct {
+	Id         int     `json:"id" db:"id"`
+	Title      string  `json:"title" db:"title"`
+	CategoryId *int    `json:"category_id" db:"category_id"` // nil matches every category
+	SellerTier *string `json:"seller_tier" db:"seller_tier"` // nil matches every tier
+	Percentage float64 `json:"percentage" db:"percentage"`   // 0.05 is 5% of the item
+	Flat       float64 `json:"flat" db:"flat"`               // fee per unit sold
+	Active     bool    `json:"active" db:"active"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+	UpdatedAt  string  `json:"updated_at" db:"updated_at"`
+}
+
+// RuleUpdateReq change only the given fields, category and tier of a rule are fixed, add a new rule instead
+type RuleUpdateReq struct {
+	Id         int      `json:"-"`
+	Title      *string  `json:"title"`
+	Percentage *float64 `json:"percentage"`
+	Flat       *float64 `json:"flat"`
+	Active     *bool    `json:"active"`
+}
@@ -0,0 +1,174 @@
+package commissionsHandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/commissions"
+	"github.com/NatthawutSK/ri-shop/modules/commissions/commissionsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type commissionsHandlerErrCode string
+
+const (
+	findRuleErr   commissionsHandlerErrCode = "commissions-001"
+	insertRuleErr commissionsHandlerErrCode = "commissions-002"
+	updateRuleErr commissionsHandlerErrCode = "commissions-003"
+	deleteRuleErr commissionsHandlerErrCode = "commissions-004"
+)
+
+type ICommissionsHandler interface {
+	FindRule(c *fiber.Ctx) error
+	AddRule(c *fiber.Ctx) error
+	UpdateRule(c *fiber.Ctx) error
+	DeleteRule(c *fiber.Ctx) error
+}
+
+type commissionsHandler struct {
+	cfg                config.IConfig
+	commissionsUsecase commissionsUsecases.ICommissionsUsecase
+}
+
+func CommissionsHandler(cfg config.IConfig, commissionsUsecase commissionsUsecases.ICommissionsUsecase) ICommissionsHandler {
+	return &commissionsHandler{
+		cfg:                cfg,
+		commissionsUsecase: commissionsUsecase,
+	}
+}
+
+// isBadRequest is true for the validation errors of the usecase
+func isBadRequest(err error) bool {
+	switch err.Error() {
+	case "title is required",
+		"category not found",
+		"seller tier must be lowercase letters, digits and dashes",
+		"percentage must be between 0 and 1",
+		"flat must not be negative":
+		return true
+	}
+	return false
+}
+
+func (h *commissionsHandler) FindRule(c *fiber.Ctx) error {
+	rules, err := h.commissionsUsecase.FindRule(c.UserContext())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findRuleErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, rules).Res()
+}
+
+func (h *commissionsHandler) AddRule(c *fiber.Ctx) error {
+	req := &commissions.Rule{
+		Active: true,
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertRuleErr),
+			err.Error(),
+		).Res()
+	}
+
+	rule, err := h.commissionsUsecase.AddRule(c.UserContext(), req)
+	if err != nil {
+		if isBadRequest(err) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertRuleErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(insertRuleErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, rule).Res()
+}
+
+func (h *commissionsHandler) UpdateRule(c *fiber.Ctx) error {
+	ruleId, err := strconv.Atoi(strings.Trim(c.Params("rule_id"), " "))
+	if err != nil || ruleId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateRuleErr),
+			"rule id is invalid",
+		).Res()
+	}
+
+	req := new(commissions.RuleUpdateReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateRuleErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = ruleId
+
+	rule, err := h.commissionsUsecase.UpdateRule(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "commission rule not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateRuleErr),
+				err.Error(),
+			).Res()
+		case isBadRequest(err):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateRuleErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateRuleErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, rule).Res()
+}
+
+func (h *commissionsHandler) DeleteRule(c *fiber.Ctx) error {
+	ruleId, err := strconv.Atoi(strings.Trim(c.Params("rule_id"), " "))
+	if err != nil || ruleId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteRuleErr),
+			"rule id is invalid",
+		).Res()
+	}
+
+	if err := h.commissionsUsecase.DeleteRule(c.UserContext(), ruleId); err != nil {
+		switch err.Error() {
+		case "commission rule not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteRuleErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteRuleErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
+}
@@ -0,0 +1,154 @@
+package commissionsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/commissions"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type ICommissionsRepository interface {
+	FindRule(ctx context.Context) ([]*commissions.Rule, error)
+	FindOneRule(ctx context.Context, ruleId int) (*commissions.Rule, error)
+	InsertRule(ctx context.Context, req *commissions.Rule) error
+	UpdateRule(ctx context.Context, req *commissions.RuleUpdateReq) error
+	DeleteRule(ctx context.Context, ruleId int) error
+}
+
+type commissionsRepository struct {
+	db *sqlx.DB
+}
+
+func CommissionsRepository(db *sqlx.DB) ICommissionsRepository {
+	return &commissionsRepository{
+		db: db,
+	}
+}
+
+func (r *commissionsRepository) FindRule(ctx context.Context) ([]*commissions.Rule, error) {
+	query := `
+	SELECT
+		"id",
+		"title",
+		"category_id",
+		"seller_tier",
+		"percentage",
+		"flat",
+		"active",
+		"created_at",
+		"updated_at"
+	FROM "commission_rules"
+	ORDER BY "id" ASC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rules := make([]*commissions.Rule, 0)
+	if err := r.db.SelectContext(ctx, &rules, query); err != nil {
+		return nil, fmt.Errorf("find commission rules failed: %v", err)
+	}
+	return rules, nil
+}
+
+func (r *commissionsRepository) FindOneRule(ctx context.Context, ruleId int) (*commissions.Rule, error) {
+	query := `
+	SELECT
+		"id",
+		"title",
+		"category_id",
+		"seller_tier",
+		"percentage",
+		"flat",
+		"active",
+		"created_at",
+		"updated_at"
+	FROM "commission_rules"
+	WHERE "id" = $1;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rule := new(commissions.Rule)
+	if err := r.db.GetContext(ctx, rule, query, ruleId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("commission rule not found")
+		}
+		return nil, fmt.Errorf("get commission rule failed: %v", err)
+	}
+	return rule, nil
+}
+
+func (r *commissionsRepository) InsertRule(ctx context.Context, req *commissions.Rule) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "commission_rules" (
+		"title",
+		"category_id",
+		"seller_tier",
+		"percentage",
+		"flat",
+		"active"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING "id";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.Title, req.CategoryId, req.SellerTier, req.Percentage, req.Flat, req.Active).Scan(&req.Id); err != nil {
+		switch err.Error() {
+		case `ERROR: insert or update on table "commission_rules" violates foreign key constraint "commission_rules_category_id_fkey" (SQLSTATE 23503)`:
+			return fmt.Errorf("category not found")
+		default:
+			return fmt.Errorf("insert commission rule failed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (r *commissionsRepository) UpdateRule(ctx context.Context, req *commissions.RuleUpdateReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "commission_rules" SET
+		"title" = COALESCE($2, "title"),
+		"percentage" = COALESCE($3, "percentage"),
+		"flat" = COALESCE($4, "flat"),
+		"active" = COALESCE($5, "active")
+	WHERE "id" = $1;`
+
+	result, err := r.db.ExecContext(ctx, query, req.Id, req.Title, req.Percentage, req.Flat, req.Active)
+	if err != nil {
+		return fmt.Errorf("update commission rule failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("commission rule not found")
+	}
+	return nil
+}
+
+// DeleteRule keep the commission of completed orders, only their link to the rule is removed
+func (r *commissionsRepository) DeleteRule(ctx context.Context, ruleId int) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM "commission_rules" WHERE "id" = $1;`, ruleId)
+	if err != nil {
+		return fmt.Errorf("delete commission rule failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("commission rule not found")
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package commissionsUsecases
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/commissions"
+	"github.com/NatthawutSK/ri-shop/modules/commissions/commissionsRepositories"
+)
+
+// tierPattern is the same as the tier of sellers
+var tierPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type ICommissionsUsecase interface {
+	FindRule(ctx context.Context) ([]*commissions.Rule, error)
+	AddRule(ctx context.Context, req *commissions.Rule) (*commissions.Rule, error)
+	UpdateRule(ctx context.Context, req *commissions.RuleUpdateReq) (*commissions.Rule, error)
+	DeleteRule(ctx context.Context, ruleId int) error
+}
+
+type commissionsUsecase struct {
+	commissionsRepository commissionsRepositories.ICommissionsRepository
+}
+
+func CommissionsUsecase(commissionsRepository commissionsRepositories.ICommissionsRepository) ICommissionsUsecase {
+	return &commissionsUsecase{
+		commissionsRepository: commissionsRepository,
+	}
+}
+
+func validateAmount(percentage, flat float64) error {
+	if percentage < 0 || percentage > 1 {
+		return fmt.Errorf("percentage must be between 0 and 1")
+	}
+	if flat < 0 {
+		return fmt.Errorf("flat must not be negative")
+	}
+	return nil
+}
+
+func (u *commissionsUsecase) FindRule(ctx context.Context) ([]*commissions.Rule, error) {
+	return u.commissionsRepository.FindRule(ctx)
+}
+
+func (u *commissionsUsecase) AddRule(ctx context.Context, req *commissions.Rule) (*commissions.Rule, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if req.CategoryId != nil && *req.CategoryId <= 0 {
+		req.CategoryId = nil
+	}
+	if req.SellerTier != nil {
+		tier := strings.ToLower(strings.TrimSpace(*req.SellerTier))
+		if tier == "" {
+			req.SellerTier = nil
+		} else if !tierPattern.MatchString(tier) {
+			return nil, fmt.Errorf("seller tier must be lowercase letters, digits and dashes")
+		} else {
+			req.SellerTier = &tier
+		}
+	}
+	if err := validateAmount(req.Percentage, req.Flat); err != nil {
+		return nil, err
+	}
+
+	if err := u.commissionsRepository.InsertRule(ctx, req); err != nil {
+		return nil, err
+	}
+	return u.commissionsRepository.FindOneRule(ctx, req.Id)
+}
+
+func (u *commissionsUsecase) UpdateRule(ctx context.Context, req *commissions.RuleUpdateReq) (*commissions.Rule, error) {
+	rule, err := u.commissionsRepository.FindOneRule(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return nil, fmt.Errorf("title is required")
+		}
+		req.Title = &title
+	}
+	percentage, flat := rule.Percentage, rule.Flat
+	if req.Percentage != nil {
+		percentage = *req.Percentage
+	}
+	if req.Flat != nil {
+		flat = *req.Flat
+	}
+	if err := validateAmount(percentage, flat); err != nil {
+		return nil, err
+	}
+
+	if err := u.commissionsRepository.UpdateRule(ctx, req); err != nil {
+		return nil, err
+	}
+	return u.commissionsRepository.FindOneRule(ctx, req.Id)
+}
+
+func (u *commissionsUsecase) DeleteRule(ctx context.Context, ruleId int) error {
+	return u.commissionsRepository.DeleteRule(ctx, ruleId)
+}
@@ -0,0 +1,21 @@
+package currencies
+
+import "math"
+
+type Currency struct {
+	Code      string `json:"code" db:"code"`
+	MinorUnit int    `json:"minor_unit" db:"minor_unit"`
+}
+
+type ExchangeRate struct {
+	Base      string  `json:"base" db:"base"`
+	Quote     string  `json:"quote" db:"quote"`
+	Rate      float64 `json:"rate" db:"rate"` // 1 base = rate quote
+	UpdatedAt string  `json:"updated_at" db:"updated_at"`
+}
+
+// Round round a major unit amount to the number of decimals of the currency
+func (c *Currency) Round(amount float64) float64 {
+	factor := math.Pow10(c.MinorUnit)
+	return math.Round(amount*factor) / factor
+}
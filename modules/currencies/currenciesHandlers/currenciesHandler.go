@@ -0,0 +1,76 @@
+package currenciesHandlers
+
+import (
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/currencies"
+	"github.com/NatthawutSK/ri-shop/modules/currencies/currenciesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type currenciesHandlerErrCode string
+
+const (
+	findCurrencyErr       currenciesHandlerErrCode = "currencies-001"
+	upsertExchangeRateErr currenciesHandlerErrCode = "currencies-002"
+)
+
+type ICurrenciesHandler interface {
+	FindCurrency(c *fiber.Ctx) error
+	UpsertExchangeRate(c *fiber.Ctx) error
+}
+
+type currenciesHandler struct {
+	cfg               config.IConfig
+	currenciesUsecase currenciesUsecases.ICurrenciesUsecase
+}
+
+func CurrenciesHandler(cfg config.IConfig, currenciesUsecase currenciesUsecases.ICurrenciesUsecase) ICurrenciesHandler {
+	return &currenciesHandler{
+		cfg:               cfg,
+		currenciesUsecase: currenciesUsecase,
+	}
+}
+
+func (h *currenciesHandler) FindCurrency(c *fiber.Ctx) error {
+	currenciesData, err := h.currenciesUsecase.FindCurrency(c.UserContext())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCurrencyErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, currenciesData).Res()
+}
+
+func (h *currenciesHandler) UpsertExchangeRate(c *fiber.Ctx) error {
+	req := new(currencies.ExchangeRate)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertExchangeRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	if req.Base == "" || req.Quote == "" || req.Rate <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertExchangeRateErr),
+			"base, quote and a positive rate are required",
+		).Res()
+	}
+
+	rate, err := h.currenciesUsecase.UpsertExchangeRate(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(upsertExchangeRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, rate).Res()
+}
@@ -0,0 +1,86 @@
+package currenciesRepositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/currencies"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type ICurrenciesRepository interface {
+	FindCurrency(ctx context.Context) ([]*currencies.Currency, error)
+	FindOneExchangeRate(ctx context.Context, base, quote string) (*currencies.ExchangeRate, error)
+	UpsertExchangeRate(ctx context.Context, req *currencies.ExchangeRate) error
+}
+
+type currenciesRepository struct {
+	db *sqlx.DB
+}
+
+func CurrenciesRepository(db *sqlx.DB) ICurrenciesRepository {
+	return &currenciesRepository{
+		db: db,
+	}
+}
+
+func (r *currenciesRepository) FindCurrency(ctx context.Context) ([]*currencies.Currency, error) {
+	query := `
+	SELECT
+		"code",
+		"minor_unit"
+	FROM "currencies"
+	ORDER BY "code";`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	currenciesData := make([]*currencies.Currency, 0)
+	if err := r.db.SelectContext(ctx, &currenciesData, query); err != nil {
+		return nil, fmt.Errorf("select currencies failed: %v", err)
+	}
+	return currenciesData, nil
+}
+
+func (r *currenciesRepository) FindOneExchangeRate(ctx context.Context, base, quote string) (*currencies.ExchangeRate, error) {
+	query := `
+	SELECT
+		"base",
+		"quote",
+		"rate",
+		"updated_at"
+	FROM "exchange_rates"
+	WHERE "base" = $1
+	AND "quote" = $2;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rate := new(currencies.ExchangeRate)
+	if err := r.db.GetContext(ctx, rate, query, base, quote); err != nil {
+		return nil, fmt.Errorf("exchange rate %s/%s not found", base, quote)
+	}
+	return rate, nil
+}
+
+func (r *currenciesRepository) UpsertExchangeRate(ctx context.Context, req *currencies.ExchangeRate) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "exchange_rates" (
+		"base",
+		"quote",
+		"rate"
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ("base", "quote") DO UPDATE SET
+		"rate" = EXCLUDED."rate"
+	RETURNING "updated_at";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.Base, req.Quote, req.Rate).Scan(&req.UpdatedAt); err != nil {
+		return fmt.Errorf("upsert exchange rate failed: %v", err)
+	}
+	return nil
+}
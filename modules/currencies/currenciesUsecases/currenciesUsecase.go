@@ -0,0 +1,81 @@
+package currenciesUsecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/currencies"
+	"github.com/NatthawutSK/ri-shop/modules/currencies/currenciesRepositories"
+)
+
+type ICurrenciesUsecase interface {
+	FindCurrency(ctx context.Context) ([]*currencies.Currency, error)
+	FindOneCurrency(ctx context.Context, code string) (*currencies.Currency, error)
+	Convert(ctx context.Context, amount float64, from, to string) (float64, error)
+	UpsertExchangeRate(ctx context.Context, req *currencies.ExchangeRate) (*currencies.ExchangeRate, error)
+}
+
+type currenciesUsecase struct {
+	currenciesRepository currenciesRepositories.ICurrenciesRepository
+	rateProvider         IExchangeRateProvider
+}
+
+func CurrenciesUsecase(currenciesRepository currenciesRepositories.ICurrenciesRepository, rateProvider IExchangeRateProvider) ICurrenciesUsecase {
+	return &currenciesUsecase{
+		currenciesRepository: currenciesRepository,
+		rateProvider:         rateProvider,
+	}
+}
+
+func (u *currenciesUsecase) FindCurrency(ctx context.Context) ([]*currencies.Currency, error) {
+	currenciesData, err := u.currenciesRepository.FindCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return currenciesData, nil
+}
+
+func (u *currenciesUsecase) FindOneCurrency(ctx context.Context, code string) (*currencies.Currency, error) {
+	currenciesData, err := u.currenciesRepository.FindCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range currenciesData {
+		if c.Code == strings.ToUpper(code) {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("currency %s is not supported", code)
+}
+
+// Convert convert a major unit amount and round it to the decimals of the target currency
+func (u *currenciesUsecase) Convert(ctx context.Context, amount float64, from, to string) (float64, error) {
+	target, err := u.FindOneCurrency(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+	if strings.EqualFold(from, to) {
+		return target.Round(amount), nil
+	}
+
+	rate, err := u.rateProvider.Rate(ctx, strings.ToUpper(from), target.Code)
+	if err != nil {
+		return 0, err
+	}
+	return target.Round(amount * rate), nil
+}
+
+func (u *currenciesUsecase) UpsertExchangeRate(ctx context.Context, req *currencies.ExchangeRate) (*currencies.ExchangeRate, error) {
+	req.Base = strings.ToUpper(req.Base)
+	req.Quote = strings.ToUpper(req.Quote)
+
+	if err := u.currenciesRepository.UpsertExchangeRate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if cached, ok := u.rateProvider.(interface{ Invalidate() }); ok {
+		cached.Invalidate()
+	}
+	return req, nil
+}
@@ -0,0 +1,85 @@
+package currenciesUsecases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/currencies/currenciesRepositories"
+)
+
+// IExchangeRateProvider is where exchange rates come from, an external rate api can be plugged in by implementing it
+type IExchangeRateProvider interface {
+	Rate(ctx context.Context, base, quote string) (float64, error)
+}
+
+// dbRateProvider read rates maintained by admins in the exchange_rates table
+type dbRateProvider struct {
+	currenciesRepository currenciesRepositories.ICurrenciesRepository
+}
+
+func DbRateProvider(currenciesRepository currenciesRepositories.ICurrenciesRepository) IExchangeRateProvider {
+	return &dbRateProvider{
+		currenciesRepository: currenciesRepository,
+	}
+}
+
+func (p *dbRateProvider) Rate(ctx context.Context, base, quote string) (float64, error) {
+	rate, err := p.currenciesRepository.FindOneExchangeRate(ctx, base, quote)
+	if err != nil {
+		return 0, err
+	}
+	return rate.Rate, nil
+}
+
+type cachedRate struct {
+	rate      float64
+	expiresAt time.Time
+}
+
+// cachedRateProvider keep rates of another provider in memory for ttl
+type cachedRateProvider struct {
+	provider IExchangeRateProvider
+	ttl      time.Duration
+	mu       sync.RWMutex
+	rates    map[string]*cachedRate
+}
+
+func CachedRateProvider(provider IExchangeRateProvider, ttl time.Duration) IExchangeRateProvider {
+	return &cachedRateProvider{
+		provider: provider,
+		ttl:      ttl,
+		rates:    make(map[string]*cachedRate),
+	}
+}
+
+func (p *cachedRateProvider) Rate(ctx context.Context, base, quote string) (float64, error) {
+	key := base + "/" + quote
+
+	p.mu.RLock()
+	cached, ok := p.rates[key]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.rate, nil
+	}
+
+	rate, err := p.provider.Rate(ctx, base, quote)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	p.rates[key] = &cachedRate{
+		rate:      rate,
+		expiresAt: time.Now().Add(p.ttl),
+	}
+	p.mu.Unlock()
+	return rate, nil
+}
+
+// Invalidate drop every cached rate, called after admins change a rate
+func (p *cachedRateProvider) Invalidate() {
+	p.mu.Lock()
+	p.rates = make(map[string]*cachedRate)
+	p.mu.Unlock()
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotModified set ETag from data and Last-Modified, then report whether the copy of the client is still fresh
+// so the handler can reply 304. The ETag hash the whole data because prices and images are not in updated_at
+func NotModified(c *fiber.Ctx, data any, lastModified time.Time) bool {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write(bytes)
+	etag := fmt.Sprintf(`W/"%x"`, h.Sum64())
+
+	c.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-Modified-Since is ignored when If-None-Match is sent (RFC 7232)
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, tag := range strings.Split(noneMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if modifiedSince := c.Get(fiber.HeaderIfModifiedSince); modifiedSince != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(modifiedSince)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
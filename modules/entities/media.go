@@ -1,7 +1,10 @@
 package entities
 
+// Image of a product, they are listed with the primary (cover) image first and then by position
 type Image struct {
-	Id       string `json:"id" db:"id"`
-	FileName string `json:"filename" db:"filename"`
-	Url      string `json:"url" db:"url"`
+	Id        string `json:"id" db:"id"`
+	FileName  string `json:"filename" db:"filename"`
+	Url       string `json:"url" db:"url"`
+	Position  int    `json:"position" db:"position"`
+	IsPrimary bool   `json:"is_primary" db:"is_primary"`
 }
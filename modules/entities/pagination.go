@@ -0,0 +1,58 @@
+package entities
+
+import "math"
+
+// PageRes is the standard shape of a list, the items are in data and the metadata in pagination. A list
+// endpoint return it with SuccessPage, PaginateRes is the shape of the lists of v1
+type PageRes struct {
+	Data       any         `json:"data"`
+	Pagination *Pagination `json:"pagination"`
+	Facets     any         `json:"facets,omitempty"` // filter sidebar of the list, only some lists have it
+}
+
+// Pagination of a list by page has page and total_pages, a list by cursor has next_cursor instead
+type Pagination struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// NewPagination is the metadata of a page of a list by page
+func NewPagination(page, limit, total int) *Pagination {
+	pagination := &Pagination{
+		Total: total,
+		Limit: limit,
+		Page:  page,
+	}
+	if limit > 0 {
+		pagination.TotalPages = int(math.Ceil(float64(total) / float64(limit)))
+	}
+	return pagination
+}
+
+// NewCursorPagination is the metadata of a list by cursor, nextCursor is empty on the last page
+func NewCursorPagination(limit, total int, nextCursor string) *Pagination {
+	return &Pagination{
+		Total:      total,
+		Limit:      limit,
+		NextCursor: nextCursor,
+	}
+}
+
+func NewPage(data any, pagination *Pagination) *PageRes {
+	return &PageRes{
+		Data:       data,
+		Pagination: pagination,
+	}
+}
+
+// ToPage convert a list of v1 to the standard shape
+func (r *PaginateRes) ToPage() *PageRes {
+	return &PageRes{
+		Data:       r.Data,
+		Pagination: NewPagination(r.Page, r.Limit, r.TotalItem),
+		Facets:     r.Facets,
+	}
+}
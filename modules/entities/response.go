@@ -1,13 +1,18 @@
 package entities
 
 import (
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/pkg/i18n"
 	"github.com/NatthawutSK/ri-shop/pkg/rilogger"
 	"github.com/gofiber/fiber/v2"
 )
 
 type IResponse interface {
 	Success(code int, data any) IResponse
+	SuccessPage(code int, page *PageRes) IResponse
 	Error(code int, traceId, msg string) IResponse
+	ValidationError(code int, traceId string, fields ValidationErrors) IResponse
 	Res() error
 }
 
@@ -20,8 +25,25 @@ type Response struct {
 }
 
 type ErrorResponse struct {
-	TraceId string `json:"trace_id"`
-	Msg     string `json:"message"`
+	TraceId string        `json:"trace_id"`
+	Msg     string        `json:"message"`
+	Fields  []*FieldError `json:"fields,omitempty"`
+}
+
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"message"`
+}
+
+// ValidationErrors is returned by validations which check every field instead of stopping at the first error
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, field := range e {
+		msgs = append(msgs, field.Field+" "+field.Msg)
+	}
+	return strings.Join(msgs, ", ")
 }
 
 func NewResponse(c *fiber.Ctx) IResponse {
@@ -35,13 +57,43 @@ func (r *Response) Error(code int, traceId string, msg string) IResponse {
 	r.StatusCode = code
 	r.ErrorRes = &ErrorResponse{
 		TraceId: traceId,
-		Msg:     msg,
+		Msg:     r.translate(msg),
+	}
+	r.IsError = true
+	rilogger.InitRiLogger(r.Context, &r.ErrorRes).Print()
+	return r
+}
+
+// ValidationError implements IResponse.
+func (r *Response) ValidationError(code int, traceId string, fields ValidationErrors) IResponse {
+	r.StatusCode = code
+	r.ErrorRes = &ErrorResponse{
+		TraceId: traceId,
+		Msg:     r.translate("validation failed"),
+		Fields:  make([]*FieldError, 0, len(fields)),
+	}
+	for _, field := range fields {
+		r.ErrorRes.Fields = append(r.ErrorRes.Fields, &FieldError{
+			Field: field.Field,
+			Msg:   r.translate(field.Msg),
+		})
 	}
 	r.IsError = true
 	rilogger.InitRiLogger(r.Context, &r.ErrorRes).Print()
 	return r
 }
 
+// translate only localize messages of requests which have "locale" set by the Authorize middleware,
+// the preferred locale comes first then the Accept-Language header
+func (r *Response) translate(msg string) string {
+	locale, ok := r.Context.Locals("locale").(string)
+	if !ok {
+		return msg
+	}
+	locales := append([]string{locale}, i18n.ParseAcceptLanguage(r.Context.Get("Accept-Language"))...)
+	return i18n.Translate(locales, msg)
+}
+
 // Success implements IResponse.
 func (r *Response) Success(code int, data any) IResponse {
 	r.StatusCode = code
@@ -50,6 +102,11 @@ func (r *Response) Success(code int, data any) IResponse {
 	return r
 }
 
+// SuccessPage implements IResponse, every list of v2 has this shape.
+func (r *Response) SuccessPage(code int, page *PageRes) IResponse {
+	return r.Success(code, page)
+}
+
 // Res implements IResponse.
 func (r *Response) Res() error {
 
@@ -67,4 +124,5 @@ type PaginateRes struct {
 	Limit     int `json:"limit"`
 	TotalPage int `json:"total_page"`
 	TotalItem int `json:"total_item"`
+	Facets    any `json:"facets,omitempty"` // filter sidebar of the list, only some lists have it
 }
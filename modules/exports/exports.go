@@ -0,0 +1,138 @@
+package exports
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCsv  = "csv"
+	FormatJson = "json"
+)
+
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Schema is the schema of the json export, it is increased when a field is changed or removed
+const Schema = "ri-shop.orders/v1"
+
+// MaxDays is the longest date range of one export
+const MaxDays = 366
+
+// ExportReq is the orders of an export, the dates are of the creation of the order
+type ExportReq struct {
+	StoreId   string   `json:"-"`
+	UserId    string   `json:"-"`                                 // admin who asked for the export
+	StartDate string   `json:"start_date" query:"start_date"`     // YYYY-MM-DD
+	EndDate   string   `json:"end_date" query:"end_date"`         // YYYY-MM-DD
+	Status    string   `json:"status" query:"status"`             // every status when empty
+	Format    string   `json:"format" query:"format"`             // csv or json, csv when empty
+	Columns   []string `json:"columns,omitempty" query:"columns"` // columns of the csv, CsvColumns when empty
+}
+
+// Export is an export made in the background, Url is a signed url of the file once it is done
+type Export struct {
+	Id          string     `json:"id" db:"id"`
+	Status      string     `json:"status" db:"status"`
+	Filter      *ExportReq `json:"filter" db:"-"`
+	Rows        int        `json:"rows" db:"rows"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	Destination string     `json:"-" db:"destination"`
+	Url         string     `json:"url,omitempty"`
+	ExpiresAt   string     `json:"expires_at,omitempty"`
+	CreatedAt   string     `json:"created_at" db:"created_at"`
+	FinishedAt  string     `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// OrderRow is an order as the accounting system reads it, amounts are in Currency and Total include
+// the shipping fee and the tax, less the discount
+type OrderRow struct {
+	OrderId     string       `json:"order_id"`
+	CreatedAt   string       `json:"created_at"`
+	Status      string       `json:"status"`
+	CustomerId  string       `json:"customer_id"`
+	Contact     string       `json:"contact"`
+	Currency    string       `json:"currency"`
+	Subtotal    float64      `json:"subtotal"`
+	Discount    float64      `json:"discount"`
+	ShippingFee float64      `json:"shipping_fee"`
+	Tax         float64      `json:"tax"`
+	Total       float64      `json:"total"`
+	Lines       []*OrderLine `json:"lines"`
+}
+
+type OrderLine struct {
+	ProductId string  `json:"product_id"`
+	Title     string  `json:"title"`
+	Qty       int     `json:"qty"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// CsvColumns is every column of the csv in its default order, a line is one order
+var CsvColumns = []string{"order_id", "created_at", "status", "customer_id", "contact", "currency", "items", "subtotal", "discount", "shipping_fee", "tax", "total"}
+
+// ValidColumn report whether column is one of CsvColumns
+func ValidColumn(column string) bool {
+	for _, c := range CsvColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// CsvRow return the values of the columns of the order
+func (o *OrderRow) CsvRow(columns []string) []string {
+	row := make([]string, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+		case "order_id":
+			row = append(row, o.OrderId)
+		case "created_at":
+			row = append(row, o.CreatedAt)
+		case "status":
+			row = append(row, o.Status)
+		case "customer_id":
+			row = append(row, o.CustomerId)
+		case "contact":
+			row = append(row, o.Contact)
+		case "currency":
+			row = append(row, o.Currency)
+		case "items":
+			items := 0
+			for _, line := range o.Lines {
+				items += line.Qty
+			}
+			row = append(row, strconv.Itoa(items))
+		case "subtotal":
+			row = append(row, money(o.Subtotal))
+		case "discount":
+			row = append(row, money(o.Discount))
+		case "shipping_fee":
+			row = append(row, money(o.ShippingFee))
+		case "tax":
+			row = append(row, money(o.Tax))
+		case "total":
+			row = append(row, money(o.Total))
+		default:
+			row = append(row, "")
+		}
+	}
+	return row
+}
+
+// FileName is the name of the file of an export
+func (r *ExportReq) FileName() string {
+	name := "orders_" + r.StartDate + "_" + r.EndDate
+	if r.Status != "" {
+		name += "_" + r.Status
+	}
+	return name + "." + strings.ToLower(r.Format)
+}
@@ -0,0 +1,145 @@
+package exportsHandlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/exports"
+	"github.com/NatthawutSK/ri-shop/modules/exports/exportsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type exportsHandlerErrCode string
+
+const (
+	downloadOrdersErr exportsHandlerErrCode = "exports-001"
+	startExportErr    exportsHandlerErrCode = "exports-002"
+	findExportErr     exportsHandlerErrCode = "exports-003"
+)
+
+// downloadTimeout is how long a streamed download can read the orders, the stream is written after the
+// handler has returned so it cannot use the context of the request
+const downloadTimeout = 10 * time.Minute
+
+type IExportsHandler interface {
+	DownloadOrders(c *fiber.Ctx) error
+	StartExport(c *fiber.Ctx) error
+	FindExport(c *fiber.Ctx) error
+}
+
+type exportsHandler struct {
+	cfg            config.IConfig
+	exportsUsecase exportsUsecases.IExportsUsecase
+}
+
+func ExportsHandler(cfg config.IConfig, exportsUsecase exportsUsecases.IExportsUsecase) IExportsHandler {
+	return &exportsHandler{
+		cfg:            cfg,
+		exportsUsecase: exportsUsecase,
+	}
+}
+
+// splitColumns รองรับทั้ง ?columns=a,b และ ?columns=a&columns=b
+func splitColumns(columns []string) []string {
+	result := make([]string, 0, len(columns))
+	for _, column := range columns {
+		result = append(result, strings.Split(column, ",")...)
+	}
+	return result
+}
+
+// DownloadOrders stream the file while the orders are read, for a range which is too big use StartExport
+func (h *exportsHandler) DownloadOrders(c *fiber.Ctx) error {
+	req := new(exports.ExportReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(downloadOrdersErr),
+			err.Error(),
+		).Res()
+	}
+	req.Columns = splitColumns(req.Columns)
+	req.StoreId = c.Locals("storeId").(string)
+	req.UserId = c.Locals("userId").(string)
+
+	if err := h.exportsUsecase.Validate(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(downloadOrdersErr),
+			err.Error(),
+		).Res()
+	}
+
+	c.Set(fiber.HeaderContentType, exportsUsecases.ContentType(req.Format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", req.FileName()))
+	// the writer must not touch c, the Ctx is released to the pool before it runs
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		// status 200 was sent already, an error can only be logged and the file is cut
+		if _, err := h.exportsUsecase.WriteOrders(ctx, req, w); err != nil {
+			log.Printf("export orders of store %s failed: %v\n", req.StoreId, err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func (h *exportsHandler) StartExport(c *fiber.Ctx) error {
+	req := new(exports.ExportReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(startExportErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+	req.UserId = c.Locals("userId").(string)
+
+	if err := h.exportsUsecase.Validate(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(startExportErr),
+			err.Error(),
+		).Res()
+	}
+
+	export, err := h.exportsUsecase.StartExport(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(startExportErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusAccepted, export).Res()
+}
+
+func (h *exportsHandler) FindExport(c *fiber.Ctx) error {
+	exportId := strings.Trim(c.Params("export_id"), " ")
+
+	export, err := h.exportsUsecase.FindExport(c.UserContext(), c.Locals("storeId").(string), exportId)
+	if err != nil {
+		if err.Error() == "export not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findExportErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findExportErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, export).Res()
+}
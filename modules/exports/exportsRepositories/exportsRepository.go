@@ -0,0 +1,218 @@
+package exportsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/exports"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IExportsRepository interface {
+	FindOrderRows(ctx context.Context, req *exports.ExportReq, fn func(row *exports.OrderRow) error) (int, error)
+	InsertExport(ctx context.Context, req *exports.ExportReq) (*exports.Export, error)
+	UpdateExport(ctx context.Context, storeId string, req *exports.Export) error
+	FindExport(ctx context.Context, storeId, exportId string) (*exports.Export, error)
+	FailPendingExports(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+type exportsRepository struct {
+	db *sqlx.DB
+}
+
+func ExportsRepository(db *sqlx.DB) IExportsRepository {
+	return &exportsRepository{
+		db: db,
+	}
+}
+
+// FindOrderRows read the orders of the export one by one and pass each to fn, so a large export is never
+// held in memory. It return the number of orders read
+func (r *exportsRepository) FindOrderRows(ctx context.Context, req *exports.ExportReq, fn func(row *exports.OrderRow) error) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*10)
+	defer cancel()
+
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT
+			"o"."id" AS "order_id",
+			to_char("o"."created_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "created_at",
+			"o"."status",
+			"o"."user_id" AS "customer_id",
+			"o"."contact",
+			COALESCE((
+				SELECT "po"."product"->>'currency'
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "o"."id"
+				LIMIT 1
+			), '') AS "currency",
+			COALESCE("l"."subtotal", 0) AS "subtotal",
+			"o"."discount",
+			"o"."shipping_fee",
+			COALESCE(("o"."tax"->>'total')::FLOAT, 0) AS "tax",
+			COALESCE("l"."subtotal", 0) - "o"."discount" + "o"."shipping_fee" + COALESCE(("o"."tax"->>'total')::FLOAT, 0) AS "total",
+			COALESCE("l"."lines", '[]'::JSONB) AS "lines"
+		FROM "orders" "o"
+		LEFT JOIN LATERAL (
+			SELECT
+				SUM(COALESCE(("po"."product"->>'price')::FLOAT * "po"."qty", 0)) AS "subtotal",
+				jsonb_agg(jsonb_build_object(
+					'product_id', "po"."product"->>'id',
+					'title', "po"."product"->>'title',
+					'qty', "po"."qty",
+					'unit_price', COALESCE(("po"."product"->>'price')::FLOAT, 0)
+				)) AS "lines"
+			FROM "products_orders" "po"
+			WHERE "po"."order_id" = "o"."id"
+		) "l" ON true
+		WHERE COALESCE("o"."store_id", '') = $1
+		AND ($2 = '' OR "o"."status" = $2)
+		AND "o"."created_at" BETWEEN DATE($3) AND ($4)::DATE + 1
+		ORDER BY "o"."created_at", "o"."id"
+	) AS "t";`
+
+	rows, err := r.db.QueryxContext(ctx, query, req.StoreId, req.Status, req.StartDate, req.EndDate)
+	if err != nil {
+		return 0, fmt.Errorf("find export orders failed: %v", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		raw := make([]byte, 0)
+		if err := rows.Scan(&raw); err != nil {
+			return count, fmt.Errorf("scan export order failed: %v", err)
+		}
+		row := new(exports.OrderRow)
+		if err := json.Unmarshal(raw, row); err != nil {
+			return count, fmt.Errorf("unmarshal export order failed: %v", err)
+		}
+		if err := fn(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("read export orders failed: %v", err)
+	}
+	return count, nil
+}
+
+const exportColumns = `
+		"id",
+		"status",
+		"filter",
+		"rows",
+		"error",
+		"destination",
+		to_char("created_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "created_at",
+		COALESCE(to_char("finished_at", 'YYYY-MM-DD"T"HH24:MI:SS'), '') AS "finished_at"`
+
+// exportRow is an order_exports row, the filter is decoded after the scan
+type exportRow struct {
+	exports.Export
+	FilterJson []byte `db:"filter"`
+}
+
+func (e *exportRow) export() (*exports.Export, error) {
+	export := e.Export
+	export.Filter = new(exports.ExportReq)
+	if err := json.Unmarshal(e.FilterJson, export.Filter); err != nil {
+		return nil, fmt.Errorf("unmarshal export filter failed: %v", err)
+	}
+	return &export, nil
+}
+
+func (r *exportsRepository) InsertExport(ctx context.Context, req *exports.ExportReq) (*exports.Export, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	filter, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal export filter failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "order_exports" (
+		"store_id",
+		"user_id",
+		"filter"
+	)
+	VALUES ($1, $2, $3)
+	RETURNING` + exportColumns + `;`
+
+	row := new(exportRow)
+	if err := r.db.GetContext(ctx, row, query, req.StoreId, req.UserId, string(filter)); err != nil {
+		return nil, fmt.Errorf("insert export failed: %v", err)
+	}
+	return row.export()
+}
+
+// UpdateExport keep the result of an export, it is finished when it is not pending anymore
+func (r *exportsRepository) UpdateExport(ctx context.Context, storeId string, req *exports.Export) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "order_exports" SET
+		"status" = $3,
+		"rows" = $4,
+		"error" = $5,
+		"destination" = $6,
+		"finished_at" = CASE WHEN $3 = 'pending' THEN NULL ELSE now() END
+	WHERE "id" = $1
+	AND "store_id" = $2;`
+
+	if _, err := r.db.ExecContext(ctx, query, req.Id, storeId, req.Status, req.Rows, req.Error, req.Destination); err != nil {
+		return fmt.Errorf("update export failed: %v", err)
+	}
+	return nil
+}
+
+func (r *exportsRepository) FindExport(ctx context.Context, storeId, exportId string) (*exports.Export, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT` + exportColumns + `
+	FROM "order_exports"
+	WHERE "id"::TEXT = $1
+	AND "store_id" = $2;`
+
+	row := new(exportRow)
+	if err := r.db.GetContext(ctx, row, query, exportId, storeId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("export not found")
+		}
+		return nil, fmt.Errorf("find export failed: %v", err)
+	}
+	return row.export()
+}
+
+// FailPendingExports mark the exports pending for longer than olderThan as failed, they were running when
+// the server stopped. A newer one may still run on another instance
+func (r *exportsRepository) FailPendingExports(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "order_exports" SET
+		"status" = 'failed',
+		"error" = 'the server stopped before the export was done',
+		"finished_at" = now()
+	WHERE "status" = 'pending'
+	AND "created_at" < now() - make_interval(secs => $1);`
+
+	result, err := r.db.ExecContext(ctx, query, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("fail pending exports failed: %v", err)
+	}
+	return result.RowsAffected()
+}
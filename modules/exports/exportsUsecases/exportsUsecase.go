@@ -0,0 +1,240 @@
+package exportsUsecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/exports"
+	"github.com/NatthawutSK/ri-shop/modules/exports/exportsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+)
+
+// exportUrlTtl is how long the signed url of an export file can be used
+const exportUrlTtl = 15 * time.Minute
+
+// pendingTimeout is how long an export can be pending, an older one was stopped with the server
+const pendingTimeout = 30 * time.Minute
+
+type IExportsUsecase interface {
+	Validate(req *exports.ExportReq) error
+	WriteOrders(ctx context.Context, req *exports.ExportReq, w io.Writer) (int, error)
+	StartExport(ctx context.Context, req *exports.ExportReq) (*exports.Export, error)
+	FindExport(ctx context.Context, storeId, exportId string) (*exports.Export, error)
+	FailStoppedExports(ctx context.Context)
+}
+
+type exportsUsecase struct {
+	exportsRepository exportsRepositories.IExportsRepository
+	filesUsecase      filesUsecases.IFilesUsecase
+}
+
+func ExportsUsecase(exportsRepository exportsRepositories.IExportsRepository, filesUsecase filesUsecases.IFilesUsecase) IExportsUsecase {
+	return &exportsUsecase{
+		exportsRepository: exportsRepository,
+		filesUsecase:      filesUsecase,
+	}
+}
+
+// Validate check the filter and fill the defaults, the last 30 days as csv with every column
+func (u *exportsUsecase) Validate(req *exports.ExportReq) error {
+	if req.EndDate == "" {
+		req.EndDate = time.Now().Format("2006-01-02")
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return fmt.Errorf("end date is invalid")
+	}
+	if req.StartDate == "" {
+		req.StartDate = end.AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return fmt.Errorf("start date is invalid")
+	}
+	if start.After(end) {
+		return fmt.Errorf("start date is after end date")
+	}
+	if end.Sub(start) > exports.MaxDays*24*time.Hour {
+		return fmt.Errorf("an export has at most %d days", exports.MaxDays)
+	}
+
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	switch req.Status {
+	case "", orders.StatusWaiting, orders.StatusPaid, orders.StatusShipping, orders.StatusReadyForPickup, orders.StatusCompleted, orders.StatusCanceled:
+	default:
+		return fmt.Errorf("status is invalid")
+	}
+
+	req.Format = strings.ToLower(strings.TrimSpace(req.Format))
+	if req.Format == "" {
+		req.Format = exports.FormatCsv
+	}
+	if req.Format != exports.FormatCsv && req.Format != exports.FormatJson {
+		return fmt.Errorf("format must be csv or json")
+	}
+
+	columns := make([]string, 0, len(req.Columns))
+	for _, column := range req.Columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "" {
+			continue
+		}
+		if !exports.ValidColumn(column) {
+			return fmt.Errorf("column %s is invalid", column)
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		columns = exports.CsvColumns
+	}
+	req.Columns = columns
+	return nil
+}
+
+// ContentType is the content type of the file of the format
+func ContentType(format string) string {
+	if format == exports.FormatJson {
+		return "application/json"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// WriteOrders write the orders of a validated export to w as they are read, it return the number of orders
+func (u *exportsUsecase) WriteOrders(ctx context.Context, req *exports.ExportReq, w io.Writer) (int, error) {
+	if req.Format == exports.FormatJson {
+		return u.writeJson(ctx, req, w)
+	}
+	return u.writeCsv(ctx, req, w)
+}
+
+func (u *exportsUsecase) writeCsv(ctx context.Context, req *exports.ExportReq, w io.Writer) (int, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(req.Columns); err != nil {
+		return 0, err
+	}
+	count, err := u.exportsRepository.FindOrderRows(ctx, req, func(row *exports.OrderRow) error {
+		return writer.Write(row.CsvRow(req.Columns))
+	})
+	writer.Flush()
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Error()
+}
+
+// writeJson write {"schema": ..., "filter": ..., "orders": [...]}, an order is one line so the file can be
+// read line by line too
+func (u *exportsUsecase) writeJson(ctx context.Context, req *exports.ExportReq, w io.Writer) (int, error) {
+	filter, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := fmt.Fprintf(w, "{\"schema\":%q,\"filter\":%s,\"orders\":[", exports.Schema, filter); err != nil {
+		return 0, err
+	}
+
+	first := true
+	count, err := u.exportsRepository.FindOrderRows(ctx, req, func(row *exports.OrderRow) error {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if first {
+			sep, first = "\n", false
+		}
+		_, err = fmt.Fprintf(w, "%s%s", sep, data)
+		return err
+	})
+	if err != nil {
+		return count, err
+	}
+	_, err = fmt.Fprintf(w, "\n],\"count\":%d}\n", count)
+	return count, err
+}
+
+// StartExport make the file of the export in the background, FindExport return it once it is done
+func (u *exportsUsecase) StartExport(ctx context.Context, req *exports.ExportReq) (*exports.Export, error) {
+	if err := u.Validate(req); err != nil {
+		return nil, err
+	}
+	export, err := u.exportsRepository.InsertExport(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	go u.runExport(export.Id, req)
+	return export, nil
+}
+
+// runExport outlive the request which started the export, so it has its own context
+func (u *exportsUsecase) runExport(exportId string, req *exports.ExportReq) {
+	ctx := context.Background()
+	result := &exports.Export{Id: exportId, Status: exports.StatusFailed}
+	defer func() {
+		if err := u.exportsRepository.UpdateExport(ctx, req.StoreId, result); err != nil {
+			log.Printf("update export %s failed: %v\n", exportId, err)
+		}
+	}()
+
+	buf := new(bytes.Buffer)
+	rows, err := u.WriteOrders(ctx, req, buf)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	destination, err := u.filesUsecase.UploadPrivate(ctx, &files.PrivateFileReq{
+		StoreId:     req.StoreId,
+		Destination: fmt.Sprintf("exports/%s/%s", exportId, req.FileName()),
+		ContentType: ContentType(req.Format),
+		Data:        buf.Bytes(),
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	result.Status = exports.StatusDone
+	result.Rows = rows
+	result.Destination = destination
+}
+
+// FindExport return the export of the store, with a signed url of the file once it is done
+func (u *exportsUsecase) FindExport(ctx context.Context, storeId, exportId string) (*exports.Export, error) {
+	export, err := u.exportsRepository.FindExport(ctx, storeId, exportId)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != exports.StatusDone {
+		return export, nil
+	}
+
+	expiresAt := time.Now().Add(exportUrlTtl)
+	url, err := u.filesUsecase.SignDownload(ctx, export.Destination, exportUrlTtl)
+	if err != nil {
+		return nil, err
+	}
+	export.Url = url
+	export.ExpiresAt = expiresAt.Format(time.RFC3339)
+	return export, nil
+}
+
+// FailStoppedExports mark the exports which were stopped with the server as failed, called at startup
+func (u *exportsUsecase) FailStoppedExports(ctx context.Context) {
+	failed, err := u.exportsRepository.FailPendingExports(ctx, pendingTimeout)
+	if err != nil {
+		log.Printf("fail stopped exports failed: %v\n", err)
+		return
+	}
+	if failed > 0 {
+		log.Printf("%d exports were stopped with the server and marked as failed\n", failed)
+	}
+}
@@ -0,0 +1,198 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+const (
+	KindSitemap  = "sitemap"
+	KindGoogle   = "google"
+	KindFacebook = "facebook"
+)
+
+// MaxItems is the most urls of a sitemap file, more products need a sitemap index
+const MaxItems = 50000
+
+// MaxAge is how old a feed can be before a request generate it again, the task refresh it before that
+const MaxAge = 2 * time.Hour
+
+// ContentType of each kind of feed
+func ContentType(kind string) string {
+	if kind == KindFacebook {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/xml; charset=utf-8"
+}
+
+// Item is a published product which is inside its availability window
+type Item struct {
+	Id          string    `db:"id"`
+	Title       string    `db:"title"`
+	Description string    `db:"description"`
+	Price       float64   `db:"price"` // major unit
+	Currency    string    `db:"currency"`
+	MinorUnit   int       `db:"minor_unit"`
+	Stock       int       `db:"stock"`
+	Brand       string    `db:"brand"`    // the brand attribute, empty when the product has none
+	Category    string    `db:"category"` // title of the category
+	ImageUrl    string    `db:"image_url"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// Link is the page of the product on the storefront at baseUrl
+func (i *Item) Link(baseUrl string) string {
+	return baseUrl + "/products/" + i.Id
+}
+
+// PriceText is the price with the decimals of its currency, e.g. 150.50 THB
+func (i *Item) PriceText() string {
+	return strconv.FormatFloat(i.Price, 'f', i.MinorUnit, 64) + " " + i.Currency
+}
+
+// Availability use the words that both google and facebook accept
+func (i *Item) Availability() string {
+	if i.Stock > 0 {
+		return "in stock"
+	}
+	return "out of stock"
+}
+
+// Feed is a generated file kept in memory
+type Feed struct {
+	Kind        string
+	Body        []byte
+	Items       int
+	GeneratedAt time.Time
+}
+
+// limit cut s to n runes, google reject a title longer than 150 and a description longer than 5000
+func limit(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type sitemap struct {
+	XMLName xml.Name      `xml:"urlset"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Urls    []*sitemapUrl `xml:"url"`
+}
+
+// Sitemap is the sitemap.xml of the product pages, see sitemaps.org
+func Sitemap(baseUrl string, items []*Item) ([]byte, error) {
+	doc := &sitemap{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		Urls:  make([]*sitemapUrl, 0, len(items)),
+	}
+	for _, item := range items {
+		doc.Urls = append(doc.Urls, &sitemapUrl{
+			Loc:     item.Link(baseUrl),
+			LastMod: item.UpdatedAt.UTC().Format("2006-01-02"),
+		})
+	}
+	return marshal(doc)
+}
+
+type googleItem struct {
+	Id           string `xml:"g:id"`
+	Title        string `xml:"g:title"`
+	Description  string `xml:"g:description"`
+	Link         string `xml:"g:link"`
+	ImageLink    string `xml:"g:image_link,omitempty"`
+	Availability string `xml:"g:availability"`
+	Price        string `xml:"g:price"`
+	Brand        string `xml:"g:brand,omitempty"`
+	ProductType  string `xml:"g:product_type,omitempty"`
+	Condition    string `xml:"g:condition"`
+}
+
+type googleFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	XmlnsG  string   `xml:"xmlns:g,attr"`
+	Channel struct {
+		Title string        `xml:"title"`
+		Link  string        `xml:"link"`
+		Items []*googleItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// Google is the rss feed of google merchant center
+func Google(title, baseUrl string, items []*Item) ([]byte, error) {
+	doc := &googleFeed{
+		Version: "2.0",
+		XmlnsG:  "http://base.google.com/ns/1.0",
+	}
+	doc.Channel.Title = title
+	doc.Channel.Link = baseUrl
+	doc.Channel.Items = make([]*googleItem, 0, len(items))
+	for _, item := range items {
+		doc.Channel.Items = append(doc.Channel.Items, &googleItem{
+			Id:           item.Id,
+			Title:        limit(item.Title, 150),
+			Description:  limit(item.Description, 5000),
+			Link:         item.Link(baseUrl),
+			ImageLink:    item.ImageUrl,
+			Availability: item.Availability(),
+			Price:        item.PriceText(),
+			Brand:        item.Brand,
+			ProductType:  item.Category,
+			Condition:    "new",
+		})
+	}
+	return marshal(doc)
+}
+
+// FacebookColumns is the header of the csv of a facebook catalog
+var FacebookColumns = []string{"id", "title", "description", "availability", "condition", "price", "link", "image_link", "brand", "product_type"}
+
+// Facebook is the csv of a facebook catalog data feed
+func Facebook(title, baseUrl string, items []*Item) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.Write(FacebookColumns); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		// facebook require a brand, the shop itself is the brand of a product without one
+		brand := item.Brand
+		if brand == "" {
+			brand = title
+		}
+		if err := w.Write([]string{
+			item.Id,
+			limit(item.Title, 150),
+			limit(item.Description, 5000),
+			item.Availability(),
+			"new",
+			item.PriceText(),
+			item.Link(baseUrl),
+			item.ImageUrl,
+			brand,
+			item.Category,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func marshal(doc any) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
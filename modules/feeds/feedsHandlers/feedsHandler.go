@@ -0,0 +1,67 @@
+package feedsHandlers
+
+import (
+	"net/http"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/feeds"
+	"github.com/NatthawutSK/ri-shop/modules/feeds/feedsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type feedsHandlerErrCode string
+
+const (
+	findFeedErr feedsHandlerErrCode = "feeds-001"
+)
+
+type IFeedsHandler interface {
+	Sitemap(c *fiber.Ctx) error
+	GoogleFeed(c *fiber.Ctx) error
+	FacebookFeed(c *fiber.Ctx) error
+}
+
+type feedsHandler struct {
+	cfg          config.IConfig
+	feedsUsecase feedsUsecases.IFeedsUsecase
+}
+
+func FeedsHandler(cfg config.IConfig, feedsUsecase feedsUsecases.IFeedsUsecase) IFeedsHandler {
+	return &feedsHandler{
+		cfg:          cfg,
+		feedsUsecase: feedsUsecase,
+	}
+}
+
+func (h *feedsHandler) Sitemap(c *fiber.Ctx) error {
+	return h.send(c, feeds.KindSitemap)
+}
+
+func (h *feedsHandler) GoogleFeed(c *fiber.Ctx) error {
+	return h.send(c, feeds.KindGoogle)
+}
+
+func (h *feedsHandler) FacebookFeed(c *fiber.Ctx) error {
+	return h.send(c, feeds.KindFacebook)
+}
+
+// send answer 304 when the crawler has the same generation already
+func (h *feedsHandler) send(c *fiber.Ctx, kind string) error {
+	feed, err := h.feedsUsecase.FindFeed(c.UserContext(), c.Locals("storeId").(string), kind)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findFeedErr),
+			err.Error(),
+		).Res()
+	}
+
+	c.Set(fiber.HeaderLastModified, feed.GeneratedAt.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "public, max-age=600")
+	if c.Fresh() {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, feeds.ContentType(kind))
+	return c.Send(feed.Body)
+}
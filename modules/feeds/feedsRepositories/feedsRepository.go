@@ -0,0 +1,88 @@
+package feedsRepositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/feeds"
+	"github.com/jmoiron/sqlx"
+)
+
+type IFeedsRepository interface {
+	FindItems(ctx context.Context, storeId string, limit int) ([]*feeds.Item, error)
+	FindStoreTitle(ctx context.Context, storeId string) (string, error)
+}
+
+type feedsRepository struct {
+	db *sqlx.DB
+}
+
+func FeedsRepository(db *sqlx.DB) IFeedsRepository {
+	return &feedsRepository{
+		db: db,
+	}
+}
+
+// FindItems return the products a customer can buy now, the same as the public listing, newest change first
+func (r *feedsRepository) FindItems(ctx context.Context, storeId string, limit int) ([]*feeds.Item, error) {
+	query := `
+	SELECT
+		"p"."id",
+		"p"."title",
+		"p"."description",
+		minor_to_major("p"."price_minor", "p"."currency") AS "price",
+		"p"."currency",
+		"cu"."minor_unit",
+		"p"."stock",
+		COALESCE((
+			SELECT "pa"."value"
+			FROM "product_attributes" "pa"
+			WHERE "pa"."product_id" = "p"."id"
+			AND "pa"."key" = 'brand'
+		), '') AS "brand",
+		COALESCE((
+			SELECT "c"."title"
+			FROM "categories" "c"
+				JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
+			WHERE "pc"."product_id" = "p"."id"
+			LIMIT 1
+		), '') AS "category",
+		COALESCE((
+			SELECT "i"."url"
+			FROM "images" "i"
+			WHERE "i"."product_id" = "p"."id"
+			ORDER BY "i"."is_primary" DESC, "i"."position" ASC
+			LIMIT 1
+		), '') AS "image_url",
+		"p"."updated_at"
+	FROM "products" "p"
+		JOIN "currencies" "cu" ON "cu"."code" = "p"."currency"
+	WHERE "p"."status" = 'published'
+	AND ("p"."available_from" IS NULL OR "p"."available_from" <= (now() AT TIME ZONE 'UTC'))
+	AND ("p"."available_until" IS NULL OR "p"."available_until" > (now() AT TIME ZONE 'UTC'))
+	AND COALESCE("p"."store_id", '') = $1
+	AND ("p"."seller_id" IS NULL OR EXISTS (SELECT 1 FROM "sellers" "s" WHERE "s"."user_id" = "p"."seller_id" AND "s"."status" = 'approved'))
+	ORDER BY "p"."updated_at" DESC
+	LIMIT $2;`
+
+	items := make([]*feeds.Item, 0)
+	if err := r.db.SelectContext(ctx, &items, query, storeId, limit); err != nil {
+		return nil, fmt.Errorf("get feed items failed: %v", err)
+	}
+	return items, nil
+}
+
+// FindStoreTitle return the title of a store, the default store has no row
+func (r *feedsRepository) FindStoreTitle(ctx context.Context, storeId string) (string, error) {
+	query := `
+	SELECT
+		"title"
+	FROM "stores"
+	WHERE "id" = $1;`
+
+	var title string
+	if err := r.db.GetContext(ctx, &title, query, storeId); err != nil {
+		return "", fmt.Errorf("get store failed: %v", err)
+	}
+	return title, nil
+}
@@ -0,0 +1,144 @@
+package feedsUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/feeds"
+	"github.com/NatthawutSK/ri-shop/modules/feeds/feedsRepositories"
+)
+
+type IFeedsUsecase interface {
+	FindFeed(ctx context.Context, storeId, kind string) (*feeds.Feed, error)
+	RefreshFeeds(ctx context.Context) error
+}
+
+type feedsUsecase struct {
+	cfg             config.IConfig
+	feedsRepository feedsRepositories.IFeedsRepository
+
+	mu    sync.Mutex
+	feeds map[string]map[string]*feeds.Feed // store id, kind
+}
+
+func FeedsUsecase(cfg config.IConfig, feedsRepository feedsRepositories.IFeedsRepository) IFeedsUsecase {
+	return &feedsUsecase{
+		cfg:             cfg,
+		feedsRepository: feedsRepository,
+		feeds:           make(map[string]map[string]*feeds.Feed),
+	}
+}
+
+// FindFeed return the feed from memory, it is generated when the store has none yet or the task did not
+// refresh it within feeds.MaxAge
+func (u *feedsUsecase) FindFeed(ctx context.Context, storeId, kind string) (*feeds.Feed, error) {
+	switch kind {
+	case feeds.KindSitemap, feeds.KindGoogle, feeds.KindFacebook:
+	default:
+		return nil, fmt.Errorf("feed not found")
+	}
+
+	u.mu.Lock()
+	feed, ok := u.feeds[storeId][kind]
+	u.mu.Unlock()
+	if ok && time.Since(feed.GeneratedAt) < feeds.MaxAge {
+		return feed, nil
+	}
+
+	generated, err := u.generate(ctx, storeId)
+	if err != nil {
+		return nil, err
+	}
+	return generated[kind], nil
+}
+
+// RefreshFeeds generate the feeds of the default store and of every store which was asked for one
+func (u *feedsUsecase) RefreshFeeds(ctx context.Context) error {
+	u.mu.Lock()
+	storeIds := []string{""}
+	for storeId := range u.feeds {
+		if storeId != "" {
+			storeIds = append(storeIds, storeId)
+		}
+	}
+	u.mu.Unlock()
+
+	var failed []string
+	for _, storeId := range storeIds {
+		generated, err := u.generate(ctx, storeId)
+		if err != nil {
+			log.Printf("refresh feeds of store %q failed: %v\n", storeId, err)
+			failed = append(failed, storeId)
+			continue
+		}
+		log.Printf("refreshed feeds of store %q with %d products\n", storeId, generated[feeds.KindSitemap].Items)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("refresh feeds failed for %d of %d stores", len(failed), len(storeIds))
+	}
+	return nil
+}
+
+// generate build the three feeds from one read of the products, the old feeds are kept when it fails
+func (u *feedsUsecase) generate(ctx context.Context, storeId string) (map[string]*feeds.Feed, error) {
+	title := u.cfg.App().Name()
+	if storeId != "" {
+		storeTitle, err := u.feedsRepository.FindStoreTitle(ctx, storeId)
+		if err != nil {
+			return nil, err
+		}
+		title = storeTitle
+	}
+
+	items, err := u.feedsRepository.FindItems(ctx, storeId, feeds.MaxItems)
+	if err != nil {
+		return nil, err
+	}
+
+	baseUrl := u.baseUrl(storeId)
+	sitemap, err := feeds.Sitemap(baseUrl, items)
+	if err != nil {
+		return nil, fmt.Errorf("generate sitemap failed: %v", err)
+	}
+	google, err := feeds.Google(title, baseUrl, items)
+	if err != nil {
+		return nil, fmt.Errorf("generate google feed failed: %v", err)
+	}
+	facebook, err := feeds.Facebook(title, baseUrl, items)
+	if err != nil {
+		return nil, fmt.Errorf("generate facebook feed failed: %v", err)
+	}
+
+	now := time.Now()
+	generated := map[string]*feeds.Feed{
+		feeds.KindSitemap:  {Kind: feeds.KindSitemap, Body: sitemap, Items: len(items), GeneratedAt: now},
+		feeds.KindGoogle:   {Kind: feeds.KindGoogle, Body: google, Items: len(items), GeneratedAt: now},
+		feeds.KindFacebook: {Kind: feeds.KindFacebook, Body: facebook, Items: len(items), GeneratedAt: now},
+	}
+
+	u.mu.Lock()
+	u.feeds[storeId] = generated
+	u.mu.Unlock()
+	return generated, nil
+}
+
+// baseUrl is the storefront of the store, <store>.StoreDomain when stores have subdomains
+func (u *feedsUsecase) baseUrl(storeId string) string {
+	base := strings.TrimRight(u.cfg.App().PublicUrl(), "/")
+	domain := u.cfg.App().StoreDomain()
+	if storeId == "" || domain == "" {
+		return base
+	}
+
+	scheme := "https"
+	if parsed, err := url.Parse(base); err == nil && parsed.Scheme != "" {
+		scheme = parsed.Scheme
+	}
+	return fmt.Sprintf("%s://%s.%s", scheme, storeId, domain)
+}
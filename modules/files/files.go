@@ -1,6 +1,56 @@
 package files
 
-import "mime/multipart"
+import (
+	"fmt"
+	"mime/multipart"
+	"path"
+	"strings"
+)
+
+// local storage, files in LocalStorageDir are served at LocalStoragePath
+const (
+	LocalStorageDir  = "./assets/images"
+	LocalStoragePath = "/static/images"
+	// infected files are kept here when FILE_SCAN_QUARANTINE is on, it is not served
+	LocalQuarantineDir = "./assets/quarantine"
+)
+
+// StoresPrefix start the objects of every store, the files of the default store are outside of it
+const StoresPrefix = "stores/"
+
+// StoreDestination put the destination under the prefix of the store, a destination which leave the
+// prefix of the store is rejected
+func StoreDestination(storeId, destination string) (string, error) {
+	dest := strings.TrimPrefix(path.Clean("/"+destination), "/")
+	if storeId != "" {
+		dest = StoresPrefix + storeId + "/" + dest
+	}
+	if dest == "" || !OwnedBy(storeId, dest) {
+		return "", fmt.Errorf("destination %s is invalid", destination)
+	}
+	return dest, nil
+}
+
+// StoreOf return the store of the object at destination, empty for the default store
+func StoreOf(destination string) string {
+	rest, ok := strings.CutPrefix(destination, StoresPrefix)
+	if !ok {
+		return ""
+	}
+	storeId, _, _ := strings.Cut(rest, "/")
+	return storeId
+}
+
+// OwnedBy report whether the object at destination belong to the store
+func OwnedBy(storeId, destination string) bool {
+	if destination != strings.TrimPrefix(path.Clean("/"+destination), "/") || strings.HasPrefix(destination, QuarantinePrefix) {
+		return false
+	}
+	if storeId == "" {
+		return !strings.HasPrefix(destination, StoresPrefix)
+	}
+	return strings.HasPrefix(destination, StoresPrefix+storeId+"/")
+}
 
 type FileReq struct {
 	File        *multipart.FileHeader `form:"file"`
@@ -10,10 +60,165 @@ type FileReq struct {
 }
 
 type FileRes struct {
-	FileName string `json:"filename"`
-	Url      string `json:"url"`
+	FileName    string `json:"filename"`
+	Url         string `json:"url"`
+	Destination string `json:"-"`
+	Embedding   string `json:"-"` // perceptual hash as pgvector literal, empty if the file is not a decodable image
+	ScanStatus  string `json:"scan_status"`
+	ScanEngine  string `json:"-"`
+	// ScanSignature is the name of the virus found, empty for a clean file
+	ScanSignature string `json:"-"`
+	Sha256        string `json:"-"`
+	Size          int64  `json:"-"` // bytes of the upload, counted in the upload quota
+	// Duplicate is true when the same content was already uploaded to the store, the existing file is returned
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// antivirus result of a file
+const (
+	ScanClean      = "clean"
+	ScanInfected   = "infected"
+	ScanNotScanned = "not_scanned" // scanning is disabled, or the scanner was down and FILE_SCAN_FAIL_OPEN is on
+)
+
+// QuarantinePrefix start the destination of an infected file which is kept, the file is never made public
+const QuarantinePrefix = "quarantine/"
+
+// QuotaError is returned when an upload would pass the daily upload quota of the user
+type QuotaError struct {
+	Quota int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("daily upload quota of %d bytes is used up", e.Quota)
+}
+
+type ScanResult struct {
+	Status    string
+	Engine    string
+	Signature string
+}
+
+// InfectedError is returned when a file does not pass the antivirus, it is never made public
+type InfectedError struct {
+	FileName  string
+	Signature string
+}
+
+func (e *InfectedError) Error() string {
+	return fmt.Sprintf("file %s is infected with %s", e.FileName, e.Signature)
+}
+
+// InvalidImageError is returned when an image cannot be read to remove its metadata
+type InvalidImageError struct {
+	FileName string
+	Reason   string
+}
+
+func (e *InvalidImageError) Error() string {
+	return fmt.Sprintf("image %s is invalid: %s", e.FileName, e.Reason)
+}
+
+// VariantFormats is every format an image can be transcoded to, the smallest first
+var VariantFormats = []string{"avif", "webp"}
+
+// VariantContentTypes is the content type of each variant format
+var VariantContentTypes = map[string]string{
+	"avif": "image/avif",
+	"webp": "image/webp",
+}
+
+// VariantDestination is where the variant of the image at destination is stored, e.g. products/a.jpg.webp
+func VariantDestination(destination, format string) string {
+	return destination + "." + format
+}
+
+// NegotiateFormat return the smallest variant the client accept, empty for the original image. A type with
+// q=0 is refused, e.g. "image/avif;q=0, image/webp"
+func NegotiateFormat(accept string, variants []string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		refused := false
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") && strings.Trim(value, "0.") == "" {
+				refused = true
+			}
+		}
+		accepted[mediaType] = !refused
+	}
+
+	for _, format := range VariantFormats {
+		if !accepted[VariantContentTypes[format]] {
+			continue
+		}
+		for _, variant := range variants {
+			if variant == format {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+// ImageRes is an uploaded image with the variants which were made of it
+type ImageRes struct {
+	Url      string
+	Variants []string
+}
+
+// ImageContentTypes is the content type of every image extension which can be uploaded
+var ImageContentTypes = map[string]string{
+	"png":  "image/png",
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+}
+
+// SignedUploadReq ask for a policy to upload one file from the browser straight to the bucket
+type SignedUploadReq struct {
+	StoreId     string `json:"-"`
+	Destination string `json:"destination"`
+	Extension   string `json:"extension"`
+}
+
+// SignedUploadRes is posted by the browser as a multipart form to Url with Fields and the file as the last field
+type SignedUploadRes struct {
+	Url         string            `json:"url"`
+	Fields      map[string]string `json:"fields"`
+	FileName    string            `json:"filename"`
+	Destination string            `json:"destination"`
+	ContentType string            `json:"content_type"`
+	ExpiresAt   string            `json:"expires_at"`
+}
+
+// ConfirmUploadReq register an object uploaded with a signed policy
+type ConfirmUploadReq struct {
+	StoreId     string `json:"-"`
+	UserId      string `json:"-"` // the size is counted in the upload quota of the user
+	Destination string `json:"destination"`
+}
+
+// PrivateFileReq is a file made by the server, e.g. an invoice. It is never public, it is read with a signed url
+type PrivateFileReq struct {
+	StoreId     string
+	Destination string
+	ContentType string
+	Data        []byte
 }
 
 type DeleteFileReq struct {
 	Destination string `json:"destination"`
 }
+
+// a file deletion is retried with backoff until it succeeds or reaches MaxDeletionAttempts
+const MaxDeletionAttempts = 10
+
+// FileDeletion is a storage delete queued by a committed transaction
+type FileDeletion struct {
+	Id          int    `db:"id" json:"id"`
+	Destination string `db:"destination" json:"destination"`
+	Attempts    int    `db:"attempts" json:"attempts"`
+	LastError   string `db:"last_error" json:"last_error"`
+}
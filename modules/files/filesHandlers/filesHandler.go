@@ -1,15 +1,22 @@
 package filesHandlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/files"
 	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
 	"github.com/NatthawutSK/ri-shop/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,23 +26,31 @@ type FileHandlerErrCode string
 const (
 	uploadFilesErr FileHandlerErrCode = "files-001"
 	deleteFileErr FileHandlerErrCode = "files-002"
+	signUploadErr FileHandlerErrCode = "files-003"
+	confirmUploadErr FileHandlerErrCode = "files-004"
+	serveImageErr FileHandlerErrCode = "files-005"
 
 )
 
 type IFileHandler interface{
 	UploadFiles(c *fiber.Ctx) error
 	DeleteFile(c *fiber.Ctx) error
+	SignUpload(c *fiber.Ctx) error
+	ConfirmUpload(c *fiber.Ctx) error
+	ServeImage(c *fiber.Ctx) error
 }
 
 type fileHandler struct {
 	cfg config.IConfig
 	fileUsecase filesUsecases.IFilesUsecase
+	auditsUsecase auditsUsecases.IAuditsUsecase
 }
 
-func FileHandler(cfg config.IConfig, fileUsecase filesUsecases.IFilesUsecase) IFileHandler {
+func FileHandler(cfg config.IConfig, fileUsecase filesUsecases.IFilesUsecase, auditsUsecase auditsUsecases.IAuditsUsecase) IFileHandler {
 	return &fileHandler{
 		cfg: cfg,
 		fileUsecase: fileUsecase,
+		auditsUsecase: auditsUsecase,
 	}
 }
 
@@ -79,16 +94,74 @@ func (h *fileHandler) UploadFiles(c *fiber.Ctx) error {
 		}
 
 		filename := utils.RandFileName(ext)
+		dest, err := files.StoreDestination(c.Locals("storeId").(string), fmt.Sprintf("%s/%s", destination, filename))
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(uploadFilesErr),
+				err.Error(),
+			).Res()
+		}
 		req = append(req, &files.FileReq{
 			File:        file,
-			Destination: fmt.Sprintf("%s/%s", destination, filename),
+			Destination: dest,
 			FileName:   filename,
 			Extension: ext,
 		})
 	}
 
-	res, err := h.fileUsecase.UploadToGCP(req)
+	// นับ quota ก่อน upload ถ้า upload ไม่สำเร็จคืน quota ให้
+	userId := c.Locals("userId").(string)
+	var size int64
+	for _, file := range filesReq {
+		size += file.Size
+	}
+	if err := h.fileUsecase.ReserveUpload(c.UserContext(), userId, size); err != nil {
+		var quotaErr *files.QuotaError
+		if errors.As(err, &quotaErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrTooManyRequests.Code,
+				string(uploadFilesErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(uploadFilesErr),
+			err.Error(),
+		).Res()
+	}
+
+	res, err := h.fileUsecase.UploadToGCP(c.UserContext(), req)
 	if err != nil {
+		// the upload may have failed on the deadline of the request, the quota is given back anyway
+		h.fileUsecase.ReleaseUpload(context.Background(), userId, size)
+		var infectedErr *files.InfectedError
+		var invalidErr *files.InvalidImageError
+		var openErr *resilience.OpenError
+		if errors.As(err, &openErr) {
+			// ไม่ให้ client รอ timeout ระหว่างที่ bucket ล่ม
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(openErr.RetryAfter.Seconds()))))
+			return entities.NewResponse(c).Error(
+				fiber.ErrServiceUnavailable.Code,
+				string(uploadFilesErr),
+				openErr.Error(),
+			).Res()
+		}
+		if errors.As(err, &infectedErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnprocessableEntity.Code,
+				string(uploadFilesErr),
+				infectedErr.Error(),
+			).Res()
+		}
+		if errors.As(err, &invalidErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnprocessableEntity.Code,
+				string(uploadFilesErr),
+				invalidErr.Error(),
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(uploadFilesErr),
@@ -96,9 +169,14 @@ func (h *fileHandler) UploadFiles(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	// ไฟล์ซ้ำไม่ได้เก็บเพิ่ม คืน quota ให้
+	if size := filesUsecases.DuplicateSize(res); size > 0 {
+		h.fileUsecase.ReleaseUpload(c.UserContext(), userId, size)
+	}
+
 	// If you want to upload files to your computer please use this function below instead
 
-	// res, err := h.fileUsecase.UploadToStorage(req)
+	// res, err := h.fileUsecase.UploadToStorage(c.UserContext(), req)
 	// if err != nil {
 	// 	return entities.NewResponse(c).Error(
 	// 		fiber.ErrInternalServerError.Code,
@@ -122,7 +200,18 @@ func (h *fileHandler) DeleteFile(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if err := h.fileUsecase.DeleteFileOnGCP(req); err != nil {
+	// objects of other stores are rejected before any of the files is deleted
+	for _, file := range req {
+		if !files.OwnedBy(c.Locals("storeId").(string), file.Destination) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(deleteFileErr),
+				"no permission to access the file",
+			).Res()
+		}
+	}
+
+	if err := h.fileUsecase.DeleteFileOnGCP(c.UserContext(), req); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(deleteFileErr),
@@ -130,6 +219,16 @@ func (h *fileHandler) DeleteFile(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	for _, file := range req {
+		h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+			ActorId:  c.Locals("userId").(string),
+			Action:   audits.FileDelete,
+			Entity:   "file",
+			EntityId: file.Destination,
+			Before:   file,
+		})
+	}
+
 	// If you want to delete files in your computer please use this function below instead
 
 	// if err := h.fileUsecase.DeleteFileOnStorage(req); err != nil {
@@ -143,4 +242,135 @@ func (h *fileHandler) DeleteFile(c *fiber.Ctx) error {
 	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
 }
 
+func (h *fileHandler) SignUpload(c *fiber.Ctx) error {
+	req := new(files.SignedUploadReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(signUploadErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
 
+	res, err := h.fileUsecase.SignUpload(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "invalid file extension", strings.HasPrefix(err.Error(), "destination"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(signUploadErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(signUploadErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, res).Res()
+}
+
+func (h *fileHandler) ConfirmUpload(c *fiber.Ctx) error {
+	req := new(files.ConfirmUploadReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(confirmUploadErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+	req.UserId = c.Locals("userId").(string)
+
+	res, err := h.fileUsecase.ConfirmUpload(c.UserContext(), req)
+	if err != nil {
+		var infectedErr *files.InfectedError
+		var invalidErr *files.InvalidImageError
+		var quotaErr *files.QuotaError
+		var openErr *resilience.OpenError
+		switch {
+		case errors.As(err, &openErr):
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(openErr.RetryAfter.Seconds()))))
+			return entities.NewResponse(c).Error(
+				fiber.ErrServiceUnavailable.Code,
+				string(confirmUploadErr),
+				openErr.Error(),
+			).Res()
+		case errors.As(err, &quotaErr):
+			return entities.NewResponse(c).Error(
+				fiber.ErrTooManyRequests.Code,
+				string(confirmUploadErr),
+				err.Error(),
+			).Res()
+		case errors.As(err, &infectedErr), errors.As(err, &invalidErr):
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnprocessableEntity.Code,
+				string(confirmUploadErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "no permission to access the file":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(confirmUploadErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "file not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(confirmUploadErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "invalid file extension",
+			err.Error() == "file is larger than the file limit",
+			strings.HasPrefix(err.Error(), "content type"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(confirmUploadErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(confirmUploadErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, res).Res()
+}
+
+// ServeImage redirect to the webp or avif variant of a public image when the browser accept it, the
+// response vary on Accept so a cache keep one redirect per format
+func (h *fileHandler) ServeImage(c *fiber.Ctx) error {
+	destination := c.Params("*")
+	if destination == "" || destination != strings.TrimPrefix(path.Clean("/"+destination), "/") || strings.HasPrefix(destination, files.QuarantinePrefix) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(serveImageErr),
+			"destination is invalid",
+		).Res()
+	}
+
+	url, err := h.fileUsecase.ImageUrl(c.UserContext(), destination, c.Get(fiber.HeaderAccept))
+	if err != nil {
+		if err.Error() == "file not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(serveImageErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(serveImageErr),
+			err.Error(),
+		).Res()
+	}
+
+	c.Vary(fiber.HeaderAccept)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Redirect(url, fiber.StatusFound)
+}
@@ -0,0 +1,429 @@
+package filesRepositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IFilesRepository interface {
+	InsertFiles(ctx context.Context, req []*files.FileRes) error
+	FindFileByHash(ctx context.Context, storeId, sha256 string) (*files.FileRes, error)
+	CountFileReferences(ctx context.Context, destinations []string) (map[string]int, error)
+	DeleteFiles(ctx context.Context, req []*files.DeleteFileReq) error
+	InsertFileVariant(ctx context.Context, destination, format string, bytes int) error
+	FindImage(ctx context.Context, destination string) (*files.ImageRes, error)
+	FindFileDeletion(ctx context.Context, ids []int) ([]*files.FileDeletion, error)
+	UpdateFileDeletion(ctx context.Context, id int, deleteErr error) error
+	RetryFileDeletion(ctx context.Context) ([]int, error)
+	AddUploadUsage(ctx context.Context, userId string, bytes, quota int64) (bool, error)
+	ReleaseUploadUsage(ctx context.Context, userId string, bytes int64) error
+}
+
+type filesRepository struct {
+	db *sqlx.DB
+}
+
+func FilesRepository(db *sqlx.DB) IFilesRepository {
+	return &filesRepository{
+		db: db,
+	}
+}
+
+// InsertFiles save metadata of uploaded files
+func (r *filesRepository) InsertFiles(ctx context.Context, req []*files.FileRes) error {
+	if len(req) == 0 {
+		return nil
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "files" (
+		"filename",
+		"destination",
+		"url",
+		"embedding",
+		"scan_status",
+		"scan_engine",
+		"scan_signature",
+		"sha256",
+		"scanned_at"
+	)
+	VALUES`
+
+	valueStack := make([]any, 0)
+	var index int
+	for i, file := range req {
+		var embedding any
+		if file.Embedding != "" {
+			embedding = file.Embedding
+		}
+		var sha256 any
+		if file.Sha256 != "" {
+			sha256 = file.Sha256
+		}
+		scanStatus := file.ScanStatus
+		if scanStatus == "" {
+			scanStatus = files.ScanNotScanned
+		}
+		valueStack = append(valueStack,
+			file.FileName,
+			file.Destination,
+			file.Url,
+			embedding,
+			scanStatus,
+			file.ScanEngine,
+			file.ScanSignature,
+			sha256,
+		)
+
+		// scanned_at is null for the files which were not scanned
+		if i != len(req)-1 {
+			query += fmt.Sprintf(`
+			($%d, $%d, $%d, $%d::vector, $%d, $%d, $%d, $%d, CASE WHEN $%d = '%s' THEN NULL ELSE now() END),`, index+1, index+2, index+3, index+4, index+5, index+6, index+7, index+8, index+5, files.ScanNotScanned)
+		} else {
+			query += fmt.Sprintf(`
+			($%d, $%d, $%d, $%d::vector, $%d, $%d, $%d, $%d, CASE WHEN $%d = '%s' THEN NULL ELSE now() END)`, index+1, index+2, index+3, index+4, index+5, index+6, index+7, index+8, index+5, files.ScanNotScanned)
+		}
+		index += 8
+	}
+	query += `
+	ON CONFLICT ("url") DO NOTHING;`
+
+	if _, err := r.db.ExecContext(ctx, query, valueStack...); err != nil {
+		return fmt.Errorf("insert files failed: %v", err)
+	}
+	return nil
+}
+
+// FindFileByHash return the oldest file of the store with the content, nil when there is none.
+// Infected files are never returned
+func (r *filesRepository) FindFileByHash(ctx context.Context, storeId, sha256 string) (*files.FileRes, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// destination ของ store อื่นขึ้นต้นด้วย stores/<store_id>/, store หลักไม่มี prefix
+	query := `
+	SELECT
+		"filename",
+		"destination",
+		"url",
+		"scan_status",
+		"scan_engine",
+		"sha256"
+	FROM "files"
+	WHERE "sha256" = $1
+	AND "scan_status" <> $2`
+	args := []any{sha256, files.ScanInfected}
+	if storeId == "" {
+		query += `
+	AND NOT starts_with("destination", $3)`
+		args = append(args, files.StoresPrefix)
+	} else {
+		query += `
+	AND starts_with("destination", $3)`
+		args = append(args, files.StoresPrefix+storeId+"/")
+	}
+	query += `
+	ORDER BY "created_at" ASC
+	LIMIT 1;`
+
+	file := make([]*struct {
+		FileName    string `db:"filename"`
+		Destination string `db:"destination"`
+		Url         string `db:"url"`
+		ScanStatus  string `db:"scan_status"`
+		ScanEngine  string `db:"scan_engine"`
+		Sha256      string `db:"sha256"`
+	}, 0)
+	if err := r.db.SelectContext(ctx, &file, query, args...); err != nil {
+		return nil, fmt.Errorf("find file by hash failed: %v", err)
+	}
+	if len(file) == 0 {
+		return nil, nil
+	}
+	return &files.FileRes{
+		FileName:    file[0].FileName,
+		Url:         file[0].Url,
+		Destination: file[0].Destination,
+		ScanStatus:  file[0].ScanStatus,
+		ScanEngine:  file[0].ScanEngine,
+		Sha256:      file[0].Sha256,
+	}, nil
+}
+
+// CountFileReferences return the number of images which use the file at each destination, a destination
+// without images is not in the map. The url of an image may be on the storage or on the CDN
+func (r *filesRepository) CountFileReferences(ctx context.Context, destinations []string) (map[string]int, error) {
+	references := make(map[string]int)
+	if len(destinations) == 0 {
+		return references, nil
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query, args, err := sqlx.In(`
+	SELECT
+		"d"."destination",
+		COUNT(*) AS "count"
+	FROM unnest(ARRAY[?]::VARCHAR[]) AS "d" ("destination")
+		JOIN "images" "i" ON right("i"."url", length("d"."destination") + 1) = '/' || "d"."destination"
+	GROUP BY "d"."destination";`, destinations)
+	if err != nil {
+		return nil, fmt.Errorf("build count file references query failed: %v", err)
+	}
+
+	rows := make([]*struct {
+		Destination string `db:"destination"`
+		Count       int    `db:"count"`
+	}, 0)
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("count file references failed: %v", err)
+	}
+	for _, row := range rows {
+		references[row.Destination] = row.Count
+	}
+	return references, nil
+}
+
+func (r *filesRepository) DeleteFiles(ctx context.Context, req []*files.DeleteFileReq) error {
+	if len(req) == 0 {
+		return nil
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	destinations := make([]string, 0)
+	for _, file := range req {
+		destinations = append(destinations, file.Destination)
+	}
+
+	query, args, err := sqlx.In(`
+	DELETE FROM "files"
+	WHERE "destination" IN (?);`, destinations)
+	if err != nil {
+		return fmt.Errorf("build delete files query failed: %v", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("delete files failed: %v", err)
+	}
+
+	query, args, err = sqlx.In(`
+	DELETE FROM "file_variants"
+	WHERE "destination" IN (?);`, destinations)
+	if err != nil {
+		return fmt.Errorf("build delete file variants query failed: %v", err)
+	}
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("delete file variants failed: %v", err)
+	}
+	return nil
+}
+
+// InsertFileVariant record a variant which was stored, a variant made again replace the old one
+func (r *filesRepository) InsertFileVariant(ctx context.Context, destination, format string, bytes int) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "file_variants" (
+		"destination",
+		"format",
+		"bytes"
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ("destination", "format") DO UPDATE
+	SET "bytes" = EXCLUDED."bytes",
+		"created_at" = now();`
+
+	if _, err := r.db.ExecContext(ctx, query, destination, format, bytes); err != nil {
+		return fmt.Errorf("insert file variant failed: %v", err)
+	}
+	return nil
+}
+
+// FindImage return the url of the image at destination and the formats of its variants
+func (r *filesRepository) FindImage(ctx context.Context, destination string) (*files.ImageRes, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"f"."url",
+		COALESCE((
+			SELECT
+				string_agg("v"."format", ',')
+			FROM "file_variants" "v"
+			WHERE "v"."destination" = "f"."destination"
+		), '') AS "variants"
+	FROM "files" "f"
+	WHERE "f"."destination" = $1
+	AND "f"."scan_status" <> $2
+	LIMIT 1;`
+
+	image := struct {
+		Url      string `db:"url"`
+		Variants string `db:"variants"`
+	}{}
+	if err := r.db.GetContext(ctx, &image, query, destination, files.ScanInfected); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("file not found")
+		}
+		return nil, fmt.Errorf("find image failed: %v", err)
+	}
+
+	variants := make([]string, 0)
+	if image.Variants != "" {
+		variants = strings.Split(image.Variants, ",")
+	}
+	return &files.ImageRes{
+		Url:      image.Url,
+		Variants: variants,
+	}, nil
+}
+
+// FindFileDeletion return the queued deletions in ids, or every deletion which is due when ids is empty
+func (r *filesRepository) FindFileDeletion(ctx context.Context, ids []int) ([]*files.FileDeletion, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"id",
+		"destination",
+		"attempts",
+		"last_error"
+	FROM "file_deletions"
+	WHERE "done_at" IS NULL
+	AND "attempts" < ?`
+	args := []any{files.MaxDeletionAttempts}
+
+	if len(ids) > 0 {
+		query += `
+	AND "id" IN (?)`
+		args = append(args, ids)
+	} else {
+		query += `
+	AND "next_attempt_at" <= now()`
+	}
+	query += `
+	ORDER BY "id" ASC
+	LIMIT 100;`
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("build find file deletions query failed: %v", err)
+	}
+
+	deletions := make([]*files.FileDeletion, 0)
+	if err := r.db.SelectContext(ctx, &deletions, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("find file deletions failed: %v", err)
+	}
+	return deletions, nil
+}
+
+// UpdateFileDeletion mark the deletion as done, or record the error and wait before the next attempt
+// (1 minute, doubled after every attempt and at most 1 day)
+func (r *filesRepository) UpdateFileDeletion(ctx context.Context, id int, deleteErr error) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if deleteErr == nil {
+		if _, err := r.db.ExecContext(ctx, `
+		UPDATE "file_deletions" SET
+			"done_at" = now()
+		WHERE "id" = $1;`, id); err != nil {
+			return fmt.Errorf("update file deletion failed: %v", err)
+		}
+		return nil
+	}
+
+	query := `
+	UPDATE "file_deletions" SET
+		"attempts" = "attempts" + 1,
+		"last_error" = $2,
+		"next_attempt_at" = now() + LEAST(interval '1 minute' * power(2, "attempts"), interval '1 day')
+	WHERE "id" = $1;`
+
+	if _, err := r.db.ExecContext(ctx, query, id, deleteErr.Error()); err != nil {
+		return fmt.Errorf("update file deletion failed: %v", err)
+	}
+	return nil
+}
+
+// RetryFileDeletion reset the attempts of the deletions which gave up, return their ids
+func (r *filesRepository) RetryFileDeletion(ctx context.Context) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	ids := make([]int, 0)
+	if err := r.db.SelectContext(ctx, &ids, `
+	UPDATE "file_deletions" SET
+		"attempts" = 0,
+		"next_attempt_at" = now()
+	WHERE "done_at" IS NULL
+	AND "attempts" >= $1
+		RETURNING "id";`, files.MaxDeletionAttempts); err != nil {
+		return nil, fmt.Errorf("retry file deletions failed: %v", err)
+	}
+	return ids, nil
+}
+
+// AddUploadUsage add bytes to the usage of the user today unless it would pass the quota,
+// false when the quota is used up
+func (r *filesRepository) AddUploadUsage(ctx context.Context, userId string, bytes, quota int64) (bool, error) {
+	if bytes > quota {
+		return false, nil
+	}
+
+	query := `
+	INSERT INTO "upload_usage" (
+		"user_id",
+		"bytes"
+	)
+	VALUES ($1, $2)
+	ON CONFLICT ("user_id", "day") DO UPDATE
+	SET "bytes" = "upload_usage"."bytes" + EXCLUDED."bytes"
+	WHERE "upload_usage"."bytes" + EXCLUDED."bytes" <= $3
+	RETURNING "bytes";`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryxContext(ctx, query, userId, bytes, quota)
+	if err != nil {
+		return false, fmt.Errorf("add upload usage failed: %v", err)
+	}
+	defer rows.Close()
+
+	// ไม่มี row กลับมาแปลว่าเงื่อนไข quota ไม่ผ่าน
+	return rows.Next(), rows.Err()
+}
+
+// ReleaseUploadUsage give back bytes of an upload which failed
+func (r *filesRepository) ReleaseUploadUsage(ctx context.Context, userId string, bytes int64) error {
+	query := `
+	UPDATE "upload_usage" SET
+		"bytes" = GREATEST("bytes" - $2, 0)
+	WHERE "user_id" = $1
+	AND "day" = CURRENT_DATE;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, userId, bytes); err != nil {
+		return fmt.Errorf("release upload usage failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package filesUsecases
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+)
+
+// storageOrigins are the url prefixes of the public files without the CDN, on GCP and on the local storage
+func (u *filesUsecase) storageOrigins() []string {
+	return []string{
+		fmt.Sprintf("https://storage.googleapis.com/%s", u.cfg.App().GCPBucket()),
+		u.cfg.App().PublicUrl() + files.LocalStoragePath,
+	}
+}
+
+// CdnUrl rewrite the storage url of a public file to the CDN, a url of somewhere else is kept.
+// Urls saved before the CDN was set are rewritten when they are read
+func (u *filesUsecase) CdnUrl(fileUrl string) string {
+	base := u.cfg.Cdn().BaseUrl()
+	if base == "" {
+		return fileUrl
+	}
+	for _, origin := range u.storageOrigins() {
+		if dest, ok := strings.CutPrefix(fileUrl, origin+"/"); ok {
+			return base + "/" + dest
+		}
+	}
+	return fileUrl
+}
+
+// Destination return the destination of a public file from its storage or CDN url
+func (u *filesUsecase) Destination(fileUrl string) string {
+	origins := u.storageOrigins()
+	if base := u.cfg.Cdn().BaseUrl(); base != "" {
+		origins = append(origins, base)
+	}
+	for _, origin := range origins {
+		if dest, ok := strings.CutPrefix(fileUrl, origin+"/"); ok {
+			return dest
+		}
+	}
+
+	// url อื่นที่ไม่รู้จัก ใช้ path แบบเดิม
+	parsed, err := url.Parse(fileUrl)
+	if err != nil {
+		log.Printf("parse file url %s failed: %v\n", fileUrl, err)
+		return ""
+	}
+	return strings.TrimPrefix(parsed.Path, fmt.Sprintf("/%s/", u.cfg.App().GCPBucket()))
+}
+
+// purgeCdn drop the cached copies of the files which were replaced or deleted, a failure is only logged
+// as the cache still expire by itself
+func (u *filesUsecase) purgeCdn(destinations ...string) {
+	if u.purger == nil || len(destinations) == 0 {
+		return
+	}
+	// the variants of a file are cached with their own url
+	destinations = append(destinations, variantDestinations(destinations...)...)
+	urls := make([]string, 0, len(destinations))
+	for _, dest := range destinations {
+		urls = append(urls, u.cfg.Cdn().BaseUrl()+"/"+dest)
+	}
+	if err := u.purger.Purge(urls); err != nil {
+		log.Printf("purge %d files on %s failed: %v\n", len(urls), u.purger.Name(), err)
+	}
+}
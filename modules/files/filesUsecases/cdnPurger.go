@@ -0,0 +1,129 @@
+package filesUsecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"golang.org/x/oauth2/google"
+)
+
+type ICdnPurger interface {
+	Name() string
+	// Purge drop the cached copies of the urls, the CDN fetch them again from the storage
+	Purge(urls []string) error
+}
+
+// CdnPurger return the purger of CDN_PROVIDER, nil when the cache is not purged
+func CdnPurger(cfg config.ICdnConfig) ICdnPurger {
+	switch cfg.Provider() {
+	case "cloudcdn":
+		return CloudCdnPurger(cfg)
+	case "cloudflare":
+		return CloudflarePurger(cfg)
+	default:
+		return nil
+	}
+}
+
+// cloudCdnPurger invalidate the path of each url on the url map of the load balancer, with the default
+// credentials of the server like the storage client
+type cloudCdnPurger struct {
+	cfg config.ICdnConfig
+}
+
+func CloudCdnPurger(cfg config.ICdnConfig) ICdnPurger {
+	return &cloudCdnPurger{
+		cfg: cfg,
+	}
+}
+
+func (p *cloudCdnPurger) Name() string { return "cloudcdn" }
+
+func (p *cloudCdnPurger) Purge(urls []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.Timeout())
+	defer cancel()
+
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/compute")
+	if err != nil {
+		return fmt.Errorf("google.DefaultClient: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("https://compute.googleapis.com/compute/v1/projects/%s/global/urlMaps/%s/invalidateCache", p.cfg.GcpProject(), p.cfg.UrlMap())
+	// invalidateCache รับได้ทีละ path
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("parse url %s failed: %v", u, err)
+		}
+		body, err := json.Marshal(map[string]string{"host": parsed.Host, "path": parsed.EscapedPath()})
+		if err != nil {
+			return fmt.Errorf("marshal invalidation failed: %v", err)
+		}
+		if err := postPurge(ctx, client, endpoint, "", body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloudflarePurger purge the urls of the zone, at most cloudflarePurgeSize per call
+type cloudflarePurger struct {
+	cfg    config.ICdnConfig
+	client *http.Client
+}
+
+func CloudflarePurger(cfg config.ICdnConfig) ICdnPurger {
+	return &cloudflarePurger{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout()},
+	}
+}
+
+const cloudflarePurgeSize = 30
+
+func (p *cloudflarePurger) Name() string { return "cloudflare" }
+
+func (p *cloudflarePurger) Purge(urls []string) error {
+	endpoint := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/purge_cache", p.cfg.CloudflareZone())
+	for start := 0; start < len(urls); start += cloudflarePurgeSize {
+		end := start + cloudflarePurgeSize
+		if end > len(urls) {
+			end = len(urls)
+		}
+		body, err := json.Marshal(map[string][]string{"files": urls[start:end]})
+		if err != nil {
+			return fmt.Errorf("marshal purge failed: %v", err)
+		}
+		if err := postPurge(context.Background(), p.client, endpoint, p.cfg.CloudflareToken(), body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func postPurge(ctx context.Context, client *http.Client, endpoint, token string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create purge request failed: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("call cdn purge failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("cdn purge responded %d", res.StatusCode)
+	}
+	return nil
+}
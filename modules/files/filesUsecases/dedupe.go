@@ -0,0 +1,73 @@
+package filesUsecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+)
+
+func fileHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// findDuplicate return the file of the same store with the same content, nil when it has to be stored.
+// A file which was not scanned is only reused while scanning is disabled, so it is scanned once enabled
+func (u *filesUsecase) findDuplicate(ctx context.Context, destination, hash string, size int64) (*files.FileRes, error) {
+	existing, err := u.filesRepository.FindFileByHash(ctx, files.StoreOf(destination), hash)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if existing.ScanStatus != files.ScanClean && u.scanner != nil {
+		return nil, nil
+	}
+	existing.Url = u.CdnUrl(existing.Url)
+	existing.Size = size
+	existing.Duplicate = true
+	return existing, nil
+}
+
+// newFiles is the files which were stored, a duplicate is already in files
+func newFiles(res []*files.FileRes) []*files.FileRes {
+	stored := make([]*files.FileRes, 0, len(res))
+	for _, file := range res {
+		if !file.Duplicate {
+			stored = append(stored, file)
+		}
+	}
+	return stored
+}
+
+// unsharedDeletions drop the files which are still used by more than keep images, they are deleted with
+// the last image. Product deletion keep 1 as the image of the product is deleted afterward
+func (u *filesUsecase) unsharedDeletions(ctx context.Context, req []*files.DeleteFileReq, keep int) ([]*files.DeleteFileReq, error) {
+	destinations := make([]string, 0, len(req))
+	for _, r := range req {
+		destinations = append(destinations, r.Destination)
+	}
+	references, err := u.filesRepository.CountFileReferences(ctx, destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	unshared := make([]*files.DeleteFileReq, 0, len(req))
+	for _, r := range req {
+		if references[r.Destination] <= keep {
+			unshared = append(unshared, r)
+		}
+	}
+	return unshared, nil
+}
+
+// DuplicateSize is the bytes of the uploads which reused a file, they are given back to the upload quota
+func DuplicateSize(res []*files.FileRes) int64 {
+	var size int64
+	for _, file := range res {
+		if file.Duplicate {
+			size += file.Size
+		}
+	}
+	return size
+}
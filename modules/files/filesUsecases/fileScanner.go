@@ -0,0 +1,249 @@
+package filesUsecases
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/google/uuid"
+)
+
+type IFileScanner interface {
+	Name() string
+	// Scan return the result of the file, an error means the file could not be scanned
+	Scan(b []byte) (*files.ScanResult, error)
+}
+
+// FileScanner return the scanner of FILE_SCAN_PROVIDER, nil when scanning is disabled
+func FileScanner(cfg config.IScanConfig) IFileScanner {
+	switch cfg.Provider() {
+	case "clamav":
+		return ClamavScanner(cfg)
+	case "icap":
+		return IcapScanner(cfg)
+	default:
+		return nil
+	}
+}
+
+// clamavScanner stream the file to clamd with the INSTREAM command
+type clamavScanner struct {
+	cfg config.IScanConfig
+}
+
+func ClamavScanner(cfg config.IScanConfig) IFileScanner {
+	return &clamavScanner{
+		cfg: cfg,
+	}
+}
+
+// clamd reject a chunk larger than StreamMaxLength, 64 KiB is far below the default
+const clamavChunkSize = 64 * 1024
+
+func (s *clamavScanner) Name() string { return "clamav" }
+
+func (s *clamavScanner) Scan(b []byte) (*files.ScanResult, error) {
+	conn, err := net.DialTimeout("tcp", s.cfg.Addr(), s.cfg.Timeout())
+	if err != nil {
+		return nil, fmt.Errorf("connect clamd failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.cfg.Timeout()))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("write clamd command failed: %v", err)
+	}
+	size := make([]byte, 4)
+	for start := 0; start < len(b); start += clamavChunkSize {
+		end := start + clamavChunkSize
+		if end > len(b) {
+			end = len(b)
+		}
+		binary.BigEndian.PutUint32(size, uint32(end-start))
+		if _, err := conn.Write(append(size, b[start:end]...)); err != nil {
+			return nil, fmt.Errorf("write clamd stream failed: %v", err)
+		}
+	}
+	// a zero length chunk end the stream
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return nil, fmt.Errorf("write clamd stream failed: %v", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read clamd reply failed: %v", err)
+	}
+	// "stream: OK", "stream: Eicar-Test-Signature FOUND" or "... ERROR"
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case reply == "OK":
+		return &files.ScanResult{Status: files.ScanClean, Engine: s.Name()}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &files.ScanResult{
+			Status:    files.ScanInfected,
+			Engine:    s.Name(),
+			Signature: strings.TrimSuffix(reply, " FOUND"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("clamd scan failed: %s", reply)
+	}
+}
+
+// icapScanner send the file as the body of an http response with RESPMOD, 204 means the file is not changed so clean
+type icapScanner struct {
+	cfg config.IScanConfig
+}
+
+func IcapScanner(cfg config.IScanConfig) IFileScanner {
+	return &icapScanner{
+		cfg: cfg,
+	}
+}
+
+func (s *icapScanner) Name() string { return "icap" }
+
+func (s *icapScanner) Scan(b []byte) (*files.ScanResult, error) {
+	conn, err := net.DialTimeout("tcp", s.cfg.Addr(), s.cfg.Timeout())
+	if err != nil {
+		return nil, fmt.Errorf("connect icap server failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.cfg.Timeout()))
+
+	resHeader := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\nContent-Length: %d\r\n\r\n", len(b))
+
+	req := new(bytes.Buffer)
+	fmt.Fprintf(req, "RESPMOD icap://%s/%s ICAP/1.0\r\n", s.cfg.Addr(), s.cfg.IcapService())
+	fmt.Fprintf(req, "Host: %s\r\n", s.cfg.Addr())
+	req.WriteString("Allow: 204\r\n")
+	fmt.Fprintf(req, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(resHeader))
+	req.WriteString(resHeader)
+	if len(b) > 0 {
+		fmt.Fprintf(req, "%x\r\n", len(b))
+		req.Write(b)
+		req.WriteString("\r\n")
+	}
+	req.WriteString("0\r\n\r\n")
+
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return nil, fmt.Errorf("write icap request failed: %v", err)
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	status, err := reader.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("read icap response failed: %v", err)
+	}
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read icap headers failed: %v", err)
+	}
+
+	fields := strings.Fields(status)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("icap response %q is invalid", status)
+	}
+	switch fields[1] {
+	case "204":
+		return &files.ScanResult{Status: files.ScanClean, Engine: s.Name()}, nil
+	case "200":
+		// the server replaced the file with a block page, the threat is in one of these headers
+		signature := header.Get("X-Virus-ID")
+		if found := header.Get("X-Infection-Found"); signature == "" && found != "" {
+			signature = found
+			for _, part := range strings.Split(found, ";") {
+				if threat, ok := strings.CutPrefix(strings.TrimSpace(part), "Threat="); ok {
+					signature = threat
+				}
+			}
+		}
+		if signature == "" {
+			signature = "unknown"
+		}
+		return &files.ScanResult{
+			Status:    files.ScanInfected,
+			Engine:    s.Name(),
+			Signature: signature,
+		}, nil
+	default:
+		return nil, fmt.Errorf("icap scan failed: %s", status)
+	}
+}
+
+// scanFile check a file before it is public, the scanner being down reject the file unless FILE_SCAN_FAIL_OPEN is on
+func (u *filesUsecase) scanFile(b []byte) (*files.ScanResult, error) {
+	if u.scanner == nil {
+		return &files.ScanResult{Status: files.ScanNotScanned}, nil
+	}
+
+	result, err := u.scanner.Scan(b)
+	if err != nil {
+		if u.cfg.Scan().FailOpen() {
+			log.Printf("scan file with %s failed, the file is accepted unscanned: %v\n", u.scanner.Name(), err)
+			return &files.ScanResult{Status: files.ScanNotScanned, Engine: u.scanner.Name()}, nil
+		}
+		return nil, fmt.Errorf("scan file failed: %v", err)
+	}
+	return result, nil
+}
+
+// quarantine record an infected file which was kept under the quarantine, it is not public so only the metadata is saved
+func (u *filesUsecase) quarantine(ctx context.Context, fileName, destination, url string, result *files.ScanResult) error {
+	log.Printf("file %s is infected with %s, quarantined at %s\n", fileName, result.Signature, destination)
+	return u.filesRepository.InsertFiles(ctx, []*files.FileRes{
+		{
+			FileName:      fileName,
+			Url:           url,
+			Destination:   destination,
+			ScanStatus:    result.Status,
+			ScanEngine:    result.Engine,
+			ScanSignature: result.Signature,
+		},
+	})
+}
+
+// quarantineOnGCP keep the infected file in the bucket under the quarantine without making it public
+func (u *filesUsecase) quarantineOnGCP(ctx context.Context, client *storage.Client, fileName string, b []byte, result *files.ScanResult) error {
+	destination := files.QuarantinePrefix + uuid.NewString() + "/" + fileName
+	if err := u.writeObject(ctx, client, destination, "", b); err != nil {
+		return fmt.Errorf("write quarantine file failed: %w", err)
+	}
+	return u.quarantine(ctx, fileName, destination, fmt.Sprintf("gs://%s/%s", u.cfg.App().GCPBucket(), destination), result)
+}
+
+// quarantineOnStorage keep the infected file out of the local storage which is served
+func (u *filesUsecase) quarantineOnStorage(ctx context.Context, fileName string, b []byte, result *files.ScanResult) error {
+	destination := files.QuarantinePrefix + uuid.NewString() + "/" + fileName
+	dest := filepath.Join(files.LocalQuarantineDir, filepath.Clean("/"+destination))
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return fmt.Errorf("mkdir \"%s\" failed: %v", filepath.Dir(dest), err)
+	}
+	if err := os.WriteFile(dest, b, 0600); err != nil {
+		return fmt.Errorf("write quarantine file failed: %v", err)
+	}
+	return u.quarantine(ctx, fileName, destination, "file://"+dest, result)
+}
+
+// rejectInfected quarantine the file when FILE_SCAN_QUARANTINE is on and return the error of the upload
+func (u *filesUsecase) rejectInfected(keep func() error, fileName string, result *files.ScanResult) error {
+	if u.cfg.Scan().Quarantine() {
+		if err := keep(); err != nil {
+			log.Printf("quarantine file %s failed: %v\n", fileName, err)
+		}
+	}
+	return &files.InfectedError{FileName: fileName, Signature: result.Signature}
+}
@@ -3,32 +3,80 @@ package filesUsecases
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/imagehash"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+	"github.com/NatthawutSK/ri-shop/pkg/workerpool"
 )
 
 type IFilesUsecase interface{
-	UploadToGCP(req []*files.FileReq) ([]*files.FileRes, error)
-	DeleteFileOnGCP(req []*files.DeleteFileReq) error
-	UploadToStorage(req []*files.FileReq) ([]*files.FileRes, error)
-	DeleteFileOnStorage(req []*files.DeleteFileReq) error
+	UploadToGCP(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error)
+	DeleteFileOnGCP(ctx context.Context, req []*files.DeleteFileReq) error
+	UploadToStorage(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error)
+	DeleteFileOnStorage(ctx context.Context, req []*files.DeleteFileReq) error
+	ProcessFileDeletion(ctx context.Context, ids []int) error
+	RetryFileDeletion(ctx context.Context) (int, error)
+	SignUpload(ctx context.Context, req *files.SignedUploadReq) (*files.SignedUploadRes, error)
+	ConfirmUpload(ctx context.Context, req *files.ConfirmUploadReq) (*files.FileRes, error)
+	UploadPrivate(ctx context.Context, req *files.PrivateFileReq) (string, error)
+	SignDownload(ctx context.Context, destination string, ttl time.Duration) (string, error)
+	ReserveUpload(ctx context.Context, userId string, bytes int64) error
+	ReleaseUpload(ctx context.Context, userId string, bytes int64)
+	CdnUrl(fileUrl string) string
+	Destination(fileUrl string) string
+	ImageUrl(ctx context.Context, destination, accept string) (string, error)
 }
 
+// FileDeletionTask is the task which delete the queued files, it is also the lock of the deletions
+const FileDeletionTask = "files.deletion"
+
 type filesUsecase struct {
 	cfg config.IConfig
+	filesRepository filesRepositories.IFilesRepository
+	scanner IFileScanner // nil when scanning is disabled
+	purger ICdnPurger // nil when the CDN cache is not purged
+	transcoders []IImageTranscoder // empty when no variants are made
+	transcodeSem chan struct{}
+	locker locks.ILocker
 }
 
-func FilesUsecase(cfg config.IConfig) IFilesUsecase {
+func FilesUsecase(cfg config.IConfig, filesRepository filesRepositories.IFilesRepository, scanner IFileScanner, purger ICdnPurger, transcoders []IImageTranscoder, locker locks.ILocker) IFilesUsecase {
+	workers := 1
+	if len(transcoders) > 0 && cfg.Image().Workers() > 1 {
+		workers = cfg.Image().Workers()
+	}
 	return &filesUsecase{
 		cfg: cfg,
+		filesRepository: filesRepository,
+		scanner: scanner,
+		purger: purger,
+		transcoders: transcoders,
+		transcodeSem: make(chan struct{}, workers),
+		locker: locker,
+	}
+}
+
+// imageEmbedding return the perceptual hash of an image as pgvector literal, used by search by image
+func imageEmbedding(b []byte) string {
+	hash, err := imagehash.DHash(bytes.NewReader(b))
+	if err != nil {
+		log.Printf("compute image hash failed: %v", err)
+		return ""
 	}
+	return imagehash.ToVector(hash)
 }
 
 type filesPub struct {
@@ -40,73 +88,146 @@ type filesPub struct {
 
 func (f *filesPub) makePublic(ctx context.Context, client *storage.Client) error {
 	acl := client.Bucket(f.bucket).Object(f.destination).ACL()
-	if err := acl.Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
+	err := storageBreaker.Do(ctx, func(ctx context.Context) error {
+		return acl.Set(ctx, storage.AllUsers, storage.RoleReader)
+	})
+	if err != nil {
 			return fmt.Errorf("ACLHandle.Set: %w", err)
 	}
 	fmt.Printf("Blob %v is now publicly accessible.\n", f.destination)
 	return nil
 }
 
+// storageTimeout is the deadline of the calls to the bucket whose context has none, the calls of a request
+// stop at the budget of its route group instead
+const storageTimeout = 60 * time.Second
+
+func storageContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, storageTimeout)
+}
 
-func (u *filesUsecase) uploadWorkers(ctx context.Context, client *storage.Client, jobs <-chan *files.FileReq, result chan<- *files.FileRes, errs chan<- error) {
-	//jobs <-chan คือการรับค่าจาก channel แบบ receive only
-	//result chan<- คือการส่งค่าไปที่ channel แบบ send only
-	//errs chan<- คือการส่งค่าไปที่ channel แบบ send only
+// storageBreaker guard the calls to the bucket, during an outage they fail at once with a resilience.OpenError
+var storageBreaker = resilience.Get("storage")
 
-	for job := range jobs {
-		container, err := job.File.Open()
-		if err != nil {
-			errs <- fmt.Errorf("open file failed: %v", err)
-			return
+// writeObject write b to the bucket, a failed write is tried again from the start
+func (u *filesUsecase) writeObject(ctx context.Context, client *storage.Client, destination, contentType string, b []byte) error {
+	return storageBreaker.Do(ctx, func(ctx context.Context) error {
+		wc := client.Bucket(u.cfg.App().GCPBucket()).Object(destination).NewWriter(ctx)
+		if contentType != "" {
+			wc.ContentType = contentType
 		}
-		b, err := io.ReadAll(container)
-		if err != nil {
-			errs <- fmt.Errorf("read file failed: %v", err)
-			return
+		if _, err := io.Copy(wc, bytes.NewReader(b)); err != nil {
+			wc.Close()
+			return fmt.Errorf("io.Copy: %w", err)
 		}
-		buf := bytes.NewBuffer(b)
+		if err := wc.Close(); err != nil {
+			return fmt.Errorf("Writer.Close: %w", err)
+		}
+		return nil
+	})
+}
 
 
-		// Upload an object with storage.Writer.
-		wc := client.Bucket(u.cfg.App().GCPBucket()).Object(job.Destination).NewWriter(ctx)
+// uploadFileToGCP upload one file of UploadToGCP
+func (u *filesUsecase) uploadFileToGCP(ctx context.Context, client *storage.Client, job *files.FileReq) (*files.FileRes, error) {
+	container, err := job.File.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open file failed: %v", err)
+	}
+	defer container.Close()
+	b, err := io.ReadAll(container)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %v", err)
+	}
+	if b, err = u.sanitizeImage(job.FileName, b); err != nil {
+		return nil, err
+	}
+	// the same content in the same store reuse the file, e.g. a catalog which is imported again
+	hash := fileHash(b)
+	duplicate, err := u.findDuplicate(ctx, job.Destination, hash, int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	if duplicate != nil {
+		return duplicate, nil
+	}
 
-		if _, err = io.Copy(wc, buf); err != nil {
-			errs <- fmt.Errorf("io.Copy: %w", err)
-			return
-		}
-		// Data can continue to be added to the file until the writer is closed.
-		if err := wc.Close(); err != nil {
-			errs <- fmt.Errorf("Writer.Close: %w", err)
-			return
-		}
-		fmt.Printf("%v uploaded to %v.\n", job.FileName, job.Destination)
-
-		newFile := &filesPub{
-			file: &files.FileRes{
-				FileName: job.FileName,
-				Url: fmt.Sprintf("https://storage.googleapis.com/%s/%s", u.cfg.App().GCPBucket(), job.Destination),
-			},
-			bucket: u.cfg.App().GCPBucket(),
-			destination: job.Destination,
-		}
+	// scan before the file is written to the bucket, an infected file never become public
+	scan, err := u.scanFile(b)
+	if err != nil {
+		return nil, err
+	}
+	if scan.Status == files.ScanInfected {
+		return nil, u.rejectInfected(func() error {
+			return u.quarantineOnGCP(ctx, client, job.FileName, b, scan)
+		}, job.FileName, scan)
+	}
 
-		if err := newFile.makePublic(ctx, client); err != nil {
-			errs <- fmt.Errorf("make file public failed: %v", err)
-			return
+	// an object which is replaced may still be cached by the CDN
+	replaced := false
+	attrsErr := storageBreaker.Do(ctx, func(ctx context.Context) error {
+		_, err := client.Bucket(u.cfg.App().GCPBucket()).Object(job.Destination).Attrs(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return resilience.Permanent(err)
 		}
+		replaced = err == nil
+		return err
+	})
+	if attrsErr != nil && !errors.Is(attrsErr, storage.ErrObjectNotExist) {
+		return nil, fmt.Errorf("object.Attrs: %w", attrsErr)
+	}
 
-		errs <- nil
-		result <- newFile.file
+	// Upload an object with storage.Writer.
+	if err := u.writeObject(ctx, client, job.Destination, "", b); err != nil {
+		return nil, err
+	}
+	fmt.Printf("%v uploaded to %v.\n", job.FileName, job.Destination)
+	if replaced {
+		go u.purgeCdn(job.Destination)
 	}
 
-	
+	newFile := &filesPub{
+		file: &files.FileRes{
+			FileName: job.FileName,
+			Url: u.CdnUrl(fmt.Sprintf("https://storage.googleapis.com/%s/%s", u.cfg.App().GCPBucket(), job.Destination)),
+			Destination: job.Destination,
+			Embedding: imageEmbedding(b),
+			ScanStatus: scan.Status,
+			ScanEngine: scan.Engine,
+			ScanSignature: scan.Signature,
+			Sha256: hash,
+			Size: int64(len(b)),
+		},
+		bucket: u.cfg.App().GCPBucket(),
+		destination: job.Destination,
+	}
 
+	if err := newFile.makePublic(ctx, client); err != nil {
+		return nil, fmt.Errorf("make file public failed: %v", err)
+	}
+	u.makeVariants(job.Destination, b, u.storeVariantOnGCP)
+	return newFile.file, nil
 }
 
+// the batches of the files, see GET /v1/metrics/workerpools
+var (
+	uploadPool = workerpool.Get("files.upload")
+	deletePool = workerpool.Get("files.delete")
+)
 
+// fileWorkers is the options of a batch of files, a failed file stop the rest of the batch
+func (u *filesUsecase) fileWorkers() workerpool.Options {
+	return workerpool.Options{
+		Workers:  u.cfg.Snapshot().FileWorkers(),
+		FailFast: true,
+	}
+}
 
-func (u *filesUsecase) UploadToGCP(req []*files.FileReq) ([]*files.FileRes, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+func (u *filesUsecase) UploadToGCP(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error) {
+	ctx, cancel := storageContext(ctx)
 	defer cancel()
 
 	client, err := storage.NewClient(ctx)
@@ -115,63 +236,60 @@ func (u *filesUsecase) UploadToGCP(req []*files.FileReq) ([]*files.FileRes, erro
 	}
 	defer client.Close()
 
-	jobsCh := make(chan *files.FileReq, len(req))
-	resultsCh := make(chan *files.FileRes, len(req))
-	errorsCh := make(chan error, len(req))
-
-	res := make([]*files.FileRes, 0)
-
-	for _, r := range req {
-		jobsCh <- r
-	}
-	close(jobsCh)
-
-	numWorkers := 5
-	for i := 0; i < numWorkers; i++ {
-		go u.uploadWorkers(ctx, client, jobsCh, resultsCh, errorsCh)
+	res, err := workerpool.Map(ctx, uploadPool, u.fileWorkers(), req, func(ctx context.Context, job *files.FileReq) (*files.FileRes, error) {
+		return u.uploadFileToGCP(ctx, client, job)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload file failed: %w", err)
 	}
 
-	for a := 0; a < len(req); a++ {
-		err := <-errorsCh
-		if err != nil {
-			return nil, fmt.Errorf("upload file failed: %v", err)
-		}
-		result := <-resultsCh
-		res = append(res, result)
+	if err := u.filesRepository.InsertFiles(ctx, newFiles(res)); err != nil {
+		return nil, err
 	}
 
 	return res, nil
 }
 
 
-func (u *filesUsecase) deleteFileWorkers(ctx context.Context, client *storage.Client, jobs <-chan *files.DeleteFileReq, errs chan<- error){
-	
-	for job := range jobs {
-		o := client.Bucket(u.cfg.App().GCPBucket()).Object(job.Destination)
+// deleteFileOnGCP delete one file of DeleteFileOnGCP
+func (u *filesUsecase) deleteFileOnGCP(ctx context.Context, client *storage.Client, job *files.DeleteFileReq) error {
+	o := client.Bucket(u.cfg.App().GCPBucket()).Object(job.Destination)
 
-		// Optional: set a generation-match precondition to avoid potential race
-		// conditions and data corruptions. The request to delete the file is aborted
-		// if the object's generation number does not match your precondition.
-		attrs, err := o.Attrs(ctx)
-		if err != nil {
-			errs <- fmt.Errorf("object.Attrs: %v", err)
-			return
-		}
-		o = o.If(storage.Conditions{GenerationMatch: attrs.Generation})
+	// Optional: set a generation-match precondition to avoid potential race
+	// conditions and data corruptions. The request to delete the file is aborted
+	// if the object's generation number does not match your precondition.
+	var attrs *storage.ObjectAttrs
+	err := storageBreaker.Do(ctx, func(ctx context.Context) (err error) {
+		attrs, err = o.Attrs(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("object.Attrs: %w", err)
+	}
+	o = o.If(storage.Conditions{GenerationMatch: attrs.Generation})
 
-		if err := o.Delete(ctx); err != nil {
-			errs <- fmt.Errorf("Object(%q).Delete: %w", job.Destination, err)
-			return
-		}
-		fmt.Printf("Blob %v deleted.\n", job.Destination)
-		errs <- nil
+	err = storageBreaker.Do(ctx, func(ctx context.Context) error {
+		return o.Delete(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("Object(%q).Delete: %w", job.Destination, err)
 	}
-	
+	fmt.Printf("Blob %v deleted.\n", job.Destination)
+	return nil
 }
 
 
-func (u *filesUsecase) DeleteFileOnGCP(req []*files.DeleteFileReq) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+func (u *filesUsecase) DeleteFileOnGCP(ctx context.Context, req []*files.DeleteFileReq) error {
+	// a file which is shared with other images is kept, see unsharedDeletions
+	req, err := u.unsharedDeletions(ctx, req, 1)
+	if err != nil {
+		return err
+	}
+	if len(req) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
 	defer cancel()
 
 	client, err := storage.NewClient(ctx)
@@ -180,144 +298,287 @@ func (u *filesUsecase) DeleteFileOnGCP(req []*files.DeleteFileReq) error {
 	}
 	defer client.Close()
 
-
-	jobsCh := make(chan *files.DeleteFileReq, len(req))
-	errsCh := make(chan error, len(req))
-
-
-	for _, r := range req {
-		jobsCh <- r
+	if err := workerpool.Run(ctx, deletePool, u.fileWorkers(), req, func(ctx context.Context, job *files.DeleteFileReq) error {
+		return u.deleteFileOnGCP(ctx, client, job)
+	}); err != nil {
+		return fmt.Errorf("delete file failed: %v", err)
 	}
-	close(jobsCh)
 
-	numWorkers := 5
-	for i := 0; i < numWorkers; i++ {
-		go u.deleteFileWorkers(ctx, client, jobsCh, errsCh)
-	}
-
-	for range req {
-		err := <-errsCh
-		if err != nil {
-			return fmt.Errorf("delete file failed: %v", err)
-		}
+	if err := u.filesRepository.DeleteFiles(ctx, req); err != nil {
+		return err
 	}
+	go u.deleteVariantsOnGCP(deleteDestinations(req))
+	go u.purgeCdn(deleteDestinations(req)...)
 	return nil
 }
 
+func deleteDestinations(req []*files.DeleteFileReq) []string {
+	destinations := make([]string, 0, len(req))
+	for _, r := range req {
+		destinations = append(destinations, r.Destination)
+	}
+	return destinations
+}
 
 
 
 // upload to storage local
 
-func (u *filesUsecase) uploadToStorageWorker(ctx context.Context, jobs <-chan *files.FileReq, results chan<- *files.FileRes, errs chan<- error) {
-	for job := range jobs {
-		cotainer, err := job.File.Open()
-		if err != nil {
-			errs <- err
-			return
-		}
-		b, err := io.ReadAll(cotainer)
-		if err != nil {
-			errs <- err
-			return
-		}
+// localStoragePath reject a destination outside of the local storage, e.g. "../../config/.env"
+func localStoragePath(destination string) (string, error) {
+	dest := filepath.Join(files.LocalStorageDir, filepath.Clean("/"+destination))
+	if dest == filepath.Clean(files.LocalStorageDir) {
+		return "", fmt.Errorf("destination %s is invalid", destination)
+	}
+	return dest, nil
+}
 
-		// Upload an object to storage
-		dest := fmt.Sprintf("./assets/images/%s", job.Destination)
-		if err := os.WriteFile(dest, b, 0777); err != nil {
-			if err := os.MkdirAll("./assets/images/"+strings.Replace(job.Destination, job.FileName, "", 1), 0777); err != nil {
-				errs <- fmt.Errorf("mkdir \"./assets/images/%s\" failed: %v", err, job.Destination)
-				return
-			}
-			if err := os.WriteFile(dest, b, 0777); err != nil {
-				errs <- fmt.Errorf("write file failed: %v", err)
-				return
-			}
-		}
+// uploadFileToStorage upload one file of UploadToStorage
+func (u *filesUsecase) uploadFileToStorage(ctx context.Context, job *files.FileReq) (*files.FileRes, error) {
+	cotainer, err := job.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer cotainer.Close()
+	b, err := io.ReadAll(cotainer)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = u.sanitizeImage(job.FileName, b); err != nil {
+		return nil, err
+	}
 
-		newFile := &filesPub{
-			file: &files.FileRes{
-				FileName: job.FileName,
-				Url:      fmt.Sprintf("http://%s:%d/%s", u.cfg.App().Host(), u.cfg.App().Port(), job.Destination),
-			},
-			destination: job.Destination,
-		}
+	hash := fileHash(b)
+	duplicate, err := u.findDuplicate(ctx, job.Destination, hash, int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	if duplicate != nil {
+		return duplicate, nil
+	}
 
-		errs <- nil
-		results <- newFile.file
+	scan, err := u.scanFile(b)
+	if err != nil {
+		return nil, err
+	}
+	if scan.Status == files.ScanInfected {
+		return nil, u.rejectInfected(func() error {
+			return u.quarantineOnStorage(ctx, job.FileName, b, scan)
+		}, job.FileName, scan)
 	}
-}
 
+	// Upload an object to storage
+	dest, err := localStoragePath(job.Destination)
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Stat(dest)
+	replaced := statErr == nil
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return nil, fmt.Errorf("mkdir \"%s\" failed: %v", filepath.Dir(dest), err)
+	}
+	if err := os.WriteFile(dest, b, 0644); err != nil {
+		return nil, fmt.Errorf("write file failed: %v", err)
+	}
+	if replaced {
+		go u.purgeCdn(job.Destination)
+	}
 
-func (u *filesUsecase) UploadToStorage(req []*files.FileReq) ([]*files.FileRes, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
-	defer cancel()
+	newFile := &filesPub{
+		file: &files.FileRes{
+			FileName:    job.FileName,
+			Url:         u.CdnUrl(fmt.Sprintf("%s%s/%s", u.cfg.App().PublicUrl(), files.LocalStoragePath, job.Destination)),
+			Destination: job.Destination,
+			Embedding:   imageEmbedding(b),
+			ScanStatus:  scan.Status,
+			ScanEngine:  scan.Engine,
+			Sha256:      hash,
+			Size:        int64(len(b)),
+		},
+		destination: job.Destination,
+	}
+	u.makeVariants(job.Destination, b, u.storeVariantOnStorage)
+	return newFile.file, nil
+}
 
-	jobsCh := make(chan *files.FileReq, len(req))
-	resultsCh := make(chan *files.FileRes, len(req))
-	errsCh := make(chan error, len(req))
 
-	res := make([]*files.FileRes, 0)
+func (u *filesUsecase) UploadToStorage(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
 
-	for _, r := range req {
-		jobsCh <- r
+	res, err := workerpool.Map(ctx, uploadPool, u.fileWorkers(), req, func(ctx context.Context, job *files.FileReq) (*files.FileRes, error) {
+		return u.uploadFileToStorage(ctx, job)
+	})
+	if err != nil {
+		return nil, err
 	}
-	close(jobsCh)
 
-	numWorkers := 5
-	for i := 0; i < numWorkers; i++ {
-		go u.uploadToStorageWorker(ctx, jobsCh, resultsCh, errsCh)
+	if err := u.filesRepository.InsertFiles(ctx, newFiles(res)); err != nil {
+		return nil, err
 	}
+	return res, nil
+}
+
 
-	for a := 0; a < len(req); a++ {
-		err := <-errsCh
-		if err != nil {
-			return nil, err
-		}
 
-		result := <-resultsCh
-		res = append(res, result)
+
+// delete file in storage local
+
+// deleteFileOnStorage delete one file of DeleteFileOnStorage
+func deleteFileOnStorage(job *files.DeleteFileReq) error {
+	dest, err := localStoragePath(job.Destination)
+	if err != nil {
+		return err
 	}
-	return res, nil
+	if err := os.Remove(dest); err != nil {
+		return fmt.Errorf("remove file: %s failed: %v", job.Destination, err)
+	}
+	return nil
 }
 
+func (u *filesUsecase) DeleteFileOnStorage(ctx context.Context, req []*files.DeleteFileReq) error {
+	req, err := u.unsharedDeletions(ctx, req, 1)
+	if err != nil {
+		return err
+	}
+	if len(req) == 0 {
+		return nil
+	}
 
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
 
+	if err := workerpool.Run(ctx, deletePool, u.fileWorkers(), req, func(ctx context.Context, job *files.DeleteFileReq) error {
+		return deleteFileOnStorage(job)
+	}); err != nil {
+		return err
+	}
 
-// delete file in storage local
+	if err := u.filesRepository.DeleteFiles(ctx, req); err != nil {
+		return err
+	}
+	go u.deleteVariantsOnStorage(deleteDestinations(req))
+	go u.purgeCdn(deleteDestinations(req)...)
+	return nil
+}
+
+// queued file deletion
 
-func (u *filesUsecase) deleteFromStorageFileWorkers(ctx context.Context, jobs <-chan *files.DeleteFileReq, errs chan<- error) {
-	for job := range jobs {
-		if err := os.Remove("./assets/images/" + job.Destination); err != nil {
-			errs <- fmt.Errorf("remove file: %s failed: %v", job.Destination, err)
-			return
+// deleteObject delete one object on GCP, an object which is already gone counts as deleted
+func (u *filesUsecase) deleteObject(ctx context.Context, client *storage.Client, destination string) error {
+	err := storageBreaker.Do(ctx, func(ctx context.Context) error {
+		err := client.Bucket(u.cfg.App().GCPBucket()).Object(destination).Delete(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return resilience.Permanent(err)
 		}
-		errs <- nil
+		return err
+	})
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("Object(%q).Delete: %w", destination, err)
 	}
+	fmt.Printf("Blob %v deleted.\n", destination)
+	return nil
 }
 
-func (u *filesUsecase) DeleteFileOnStorage(req []*files.DeleteFileReq) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
-	defer cancel()
+// ProcessFileDeletion delete the queued files in ids, or every deletion which is due when ids is empty.
+// A failed deletion is recorded and retried by the FileDeletionTask
+func (u *filesUsecase) ProcessFileDeletion(ctx context.Context, ids []int) error {
+	deletions, err := u.filesRepository.FindFileDeletion(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(deletions) == 0 {
+		return nil
+	}
 
-	jobsCh := make(chan *files.DeleteFileReq, len(req))
-	errsCh := make(chan error, len(req))
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
 
-	for _, r := range req {
-		jobsCh <- r
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %v", err)
 	}
-	close(jobsCh)
+	defer client.Close()
+
+	failed := 0
+	deleted := make([]string, 0, len(deletions))
+	defer func() {
+		if len(deleted) > 0 {
+			go u.deleteVariantsOnGCP(deleted)
+		}
+		go u.purgeCdn(deleted...)
+	}()
 
-	numWorkers := 5
-	for i := 0; i < numWorkers; i++ {
-		go u.deleteFromStorageFileWorkers(ctx, jobsCh, errsCh)
+	// the image was removed already, another image which still use the file keep it
+	destinations := make([]string, 0, len(deletions))
+	for _, deletion := range deletions {
+		destinations = append(destinations, deletion.Destination)
+	}
+	references, err := u.filesRepository.CountFileReferences(ctx, destinations)
+	if err != nil {
+		return err
 	}
 
-	for range req {
-		err := <-errsCh
-		if err != nil {
+	for _, deletion := range deletions {
+		if references[deletion.Destination] > 0 {
+			if err := u.filesRepository.UpdateFileDeletion(ctx, deletion.Id, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		deleteErr := u.deleteObject(ctx, client, deletion.Destination)
+		if deleteErr == nil {
+			deleted = append(deleted, deletion.Destination)
+			deleteErr = u.filesRepository.DeleteFiles(ctx, []*files.DeleteFileReq{{Destination: deletion.Destination}})
+		}
+		if deleteErr != nil {
+			failed++
+		}
+		if err := u.filesRepository.UpdateFileDeletion(ctx, deletion.Id, deleteErr); err != nil {
 			return err
 		}
 	}
+
+	if failed > 0 {
+		return fmt.Errorf("delete %d of %d files failed", failed, len(deletions))
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// RetryFileDeletion delete again the files which gave up after MaxDeletionAttempts, return the number of retried files
+func (u *filesUsecase) RetryFileDeletion(ctx context.Context) (int, error) {
+	// the task of a running server may be deleting the same files, wait for it
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	retried := 0
+	err := u.locker.Run(ctx, FileDeletionTask, func() error {
+		var err error
+		retried, err = u.retryFileDeletion(ctx)
+		return err
+	})
+	return retried, err
+}
+
+func (u *filesUsecase) retryFileDeletion(ctx context.Context) (int, error) {
+	ids, err := u.filesRepository.RetryFileDeletion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// FindFileDeletion อ่านได้ครั้งละ 100, batch ที่ล้มเหลวไม่หยุด batch ถัดไป
+	errs := make([]string, 0)
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := u.ProcessFileDeletion(ctx, ids[start:end]); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return len(ids), fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,84 @@
+package filesUsecases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+)
+
+type IImageTranscoder interface {
+	Format() string
+	// Transcode return the image in the format, b is a JPEG or PNG
+	Transcode(b []byte) ([]byte, error)
+}
+
+// ImageTranscoders return a transcoder of each format of IMAGE_VARIANTS, empty when no variants are made
+func ImageTranscoders(cfg config.IImageConfig) []IImageTranscoder {
+	transcoders := make([]IImageTranscoder, 0)
+	for _, format := range cfg.Variants() {
+		switch format {
+		case "webp":
+			transcoders = append(transcoders, &execTranscoder{
+				cfg:    cfg,
+				format: "webp",
+				args: func(in, out string) []string {
+					return []string{cfg.CwebpBin(), "-quiet", "-metadata", "none", "-q", strconv.Itoa(cfg.WebpQuality()), in, "-o", out}
+				},
+			})
+		case "avif":
+			transcoders = append(transcoders, &execTranscoder{
+				cfg:    cfg,
+				format: "avif",
+				args: func(in, out string) []string {
+					return []string{cfg.AvifencBin(), "--ignore-exif", "--ignore-xmp", "-q", strconv.Itoa(cfg.AvifQuality()), in, out}
+				},
+			})
+		}
+	}
+	return transcoders
+}
+
+// execTranscoder run the encoder of the server on temporary files, cwebp and avifenc only read and write files
+type execTranscoder struct {
+	cfg    config.IImageConfig
+	format string
+	args   func(in, out string) []string
+}
+
+func (t *execTranscoder) Format() string { return t.format }
+
+func (t *execTranscoder) Transcode(b []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "ri-shop-transcode-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the encoders read the format from the extension
+	ext := ".jpg"
+	if bytes.HasPrefix(b, []byte("\x89PNG")) {
+		ext = ".png"
+	}
+	in := filepath.Join(dir, "in"+ext)
+	out := filepath.Join(dir, "out."+t.format)
+	if err := os.WriteFile(in, b, 0600); err != nil {
+		return nil, fmt.Errorf("write temp file failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.cfg.Timeout())
+	defer cancel()
+
+	args := t.args(in, out)
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", filepath.Base(args[0]), err, strings.TrimSpace(string(output)))
+	}
+	return os.ReadFile(out)
+}
@@ -0,0 +1,55 @@
+package filesUsecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+)
+
+// UploadPrivate write a file made by the server to the bucket without making it public and return its
+// destination. It is not scanned, it does not come from a user
+func (u *filesUsecase) UploadPrivate(ctx context.Context, req *files.PrivateFileReq) (string, error) {
+	destination, err := files.StoreDestination(req.StoreId, req.Destination)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	if err := u.writeObject(ctx, client, destination, req.ContentType, req.Data); err != nil {
+		return "", fmt.Errorf("write %s failed: %w", destination, err)
+	}
+	return destination, nil
+}
+
+// SignDownload return a url which can read a private object until ttl has passed
+func (u *filesUsecase) SignDownload(ctx context.Context, destination string, ttl time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	url, err := client.Bucket(u.cfg.App().GCPBucket()).SignedURL(destination, &storage.SignedURLOptions{
+		Method:  "GET",
+		Expires: time.Now().Add(ttl),
+		Scheme:  storage.SigningSchemeV4,
+	})
+	if err != nil {
+		return "", fmt.Errorf("sign download url failed: %v", err)
+	}
+	return url, nil
+}
@@ -0,0 +1,38 @@
+package filesUsecases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/pkg/imageclean"
+)
+
+// sanitizeImage remove the metadata of an image and turn it upright before it is hashed, scanned and stored.
+// An image which cannot be read is rejected, its metadata could not be removed
+func (u *filesUsecase) sanitizeImage(fileName string, b []byte) ([]byte, error) {
+	if !u.cfg.Upload().StripMetadata() {
+		return b, nil
+	}
+	clean, err := imageclean.Sanitize(b)
+	if err != nil {
+		return nil, &files.InvalidImageError{FileName: fileName, Reason: err.Error()}
+	}
+	return clean, nil
+}
+
+// replaceObject write the sanitized image over an object which was uploaded straight to the bucket
+func (u *filesUsecase) replaceObject(ctx context.Context, client *storage.Client, destination, contentType string, b []byte) error {
+	wc := client.Bucket(u.cfg.App().GCPBucket()).Object(destination).NewWriter(ctx)
+	wc.ContentType = contentType
+	if _, err := bytes.NewReader(b).WriteTo(wc); err != nil {
+		wc.Close()
+		return fmt.Errorf("write sanitized image failed: %v", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
+	}
+	return nil
+}
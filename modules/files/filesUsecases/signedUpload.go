@@ -0,0 +1,222 @@
+package filesUsecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+	"github.com/NatthawutSK/ri-shop/pkg/utils"
+)
+
+// signedUploadTtl is how long the browser has to upload the file with a signed policy
+const signedUploadTtl = 15 * time.Minute
+
+// SignUpload return a policy which let the browser upload one image straight to the bucket,
+// the bucket reject a file of another content type or larger than the file limit
+func (u *filesUsecase) SignUpload(ctx context.Context, req *files.SignedUploadReq) (*files.SignedUploadRes, error) {
+	ext := strings.ToLower(strings.TrimPrefix(req.Extension, "."))
+	contentType, ok := files.ImageContentTypes[ext]
+	if !ok {
+		return nil, fmt.Errorf("invalid file extension")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	filename := utils.RandFileName(ext)
+	destination, err := files.StoreDestination(req.StoreId, fmt.Sprintf("%s/%s", req.Destination, filename))
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(signedUploadTtl)
+
+	policy, err := client.Bucket(u.cfg.App().GCPBucket()).GenerateSignedPostPolicyV4(destination, &storage.PostPolicyV4Options{
+		Expires: expiresAt,
+		Fields: &storage.PolicyV4Fields{
+			ContentType: contentType,
+		},
+		Conditions: []storage.PostPolicyV4Condition{
+			storage.ConditionContentLengthRange(1, uint64(u.cfg.App().FileLimit())),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sign upload policy failed: %v", err)
+	}
+
+	return &files.SignedUploadRes{
+		Url:         policy.URL,
+		Fields:      policy.Fields,
+		FileName:    filename,
+		Destination: destination,
+		ContentType: contentType,
+		ExpiresAt:   expiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// ConfirmUpload check and scan the object uploaded with a signed policy, make it public and save it in files.
+// An object which does not pass the checks is deleted
+func (u *filesUsecase) ConfirmUpload(ctx context.Context, req *files.ConfirmUploadReq) (*files.FileRes, error) {
+	destination := strings.Trim(req.Destination, "/")
+	// a store can only confirm the objects under its own prefix
+	if !files.OwnedBy(req.StoreId, destination) {
+		return nil, fmt.Errorf("no permission to access the file")
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(destination), "."))
+	contentType, ok := files.ImageContentTypes[ext]
+	if !ok {
+		return nil, fmt.Errorf("invalid file extension")
+	}
+
+	ctx, cancel := storageContext(ctx)
+	defer cancel()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	object := client.Bucket(u.cfg.App().GCPBucket()).Object(destination)
+	var attrs *storage.ObjectAttrs
+	err = storageBreaker.Do(ctx, func(ctx context.Context) (err error) {
+		attrs, err = object.Attrs(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return resilience.Permanent(err)
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, fmt.Errorf("file not found")
+		}
+		return nil, fmt.Errorf("Object(%q).Attrs: %w", destination, err)
+	}
+
+	var invalid error
+	switch {
+	case attrs.Size > int64(u.cfg.App().FileLimit()):
+		invalid = fmt.Errorf("file is larger than the file limit")
+	case attrs.ContentType != contentType:
+		invalid = fmt.Errorf("content type %s does not match the file extension", attrs.ContentType)
+	}
+	if invalid != nil {
+		if err := u.deleteObject(ctx, client, destination); err != nil {
+			return nil, err
+		}
+		return nil, invalid
+	}
+
+	// the size is only known now, an object over the quota of the user is deleted like an invalid one
+	if quotaErr := u.ReserveUpload(ctx, req.UserId, attrs.Size); quotaErr != nil {
+		if err := u.deleteObject(ctx, client, destination); err != nil {
+			return nil, err
+		}
+		return nil, quotaErr
+	}
+	confirmed := false
+	defer func() {
+		if !confirmed {
+			// not with ctx, the confirmation may have failed on its deadline
+			u.ReleaseUpload(context.Background(), req.UserId, attrs.Size)
+		}
+	}()
+
+	// only read the generation which was checked, the object may be replaced while the policy is valid
+	var b []byte
+	err = storageBreaker.Do(ctx, func(ctx context.Context) error {
+		reader, err := object.Generation(attrs.Generation).NewReader(ctx)
+		if err != nil {
+			return fmt.Errorf("Object(%q).NewReader: %w", destination, err)
+		}
+		defer reader.Close()
+		if b, err = io.ReadAll(reader); err != nil {
+			return fmt.Errorf("read file failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	original := b
+	if b, err = u.sanitizeImage(filepath.Base(destination), b); err != nil {
+		if err := u.deleteObject(ctx, client, destination); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	// the same content is already in the store, the new object is deleted and its size is not counted
+	hash := fileHash(b)
+	duplicate, err := u.findDuplicate(ctx, destination, hash, attrs.Size)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate != nil {
+		if duplicate.Destination != destination {
+			if err := u.deleteObject(ctx, client, destination); err != nil {
+				return nil, err
+			}
+		}
+		return duplicate, nil
+	}
+
+	scan, err := u.scanFile(b)
+	if err != nil {
+		return nil, err
+	}
+	if scan.Status == files.ScanInfected {
+		infected := u.rejectInfected(func() error {
+			return u.quarantineOnGCP(ctx, client, filepath.Base(destination), b, scan)
+		}, filepath.Base(destination), scan)
+		if err := u.deleteObject(ctx, client, destination); err != nil {
+			return nil, err
+		}
+		return nil, infected
+	}
+
+	// the object still has the metadata, the sanitized image is written over it before it is public
+	if !bytes.Equal(original, b) {
+		if err := u.replaceObject(ctx, client, destination, contentType, b); err != nil {
+			return nil, err
+		}
+	}
+
+	newFile := &filesPub{
+		file: &files.FileRes{
+			FileName:    filepath.Base(destination),
+			Url:         u.CdnUrl(fmt.Sprintf("https://storage.googleapis.com/%s/%s", u.cfg.App().GCPBucket(), destination)),
+			Destination: destination,
+			Embedding:   imageEmbedding(b),
+			ScanStatus:  scan.Status,
+			ScanEngine:  scan.Engine,
+			Sha256:      hash,
+			Size:        attrs.Size,
+		},
+		bucket:      u.cfg.App().GCPBucket(),
+		destination: destination,
+	}
+	if err := newFile.makePublic(ctx, client); err != nil {
+		return nil, fmt.Errorf("make file public failed: %v", err)
+	}
+
+	if err := u.filesRepository.InsertFiles(ctx, []*files.FileRes{newFile.file}); err != nil {
+		return nil, err
+	}
+	confirmed = true
+	u.makeVariants(destination, b, u.storeVariantOnGCP)
+	return newFile.file, nil
+}
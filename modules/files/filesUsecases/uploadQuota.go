@@ -0,0 +1,36 @@
+package filesUsecases
+
+import (
+	"context"
+	"log"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+)
+
+// ReserveUpload count bytes in the upload quota of the user today before they are uploaded,
+// a *files.QuotaError is returned when the quota is used up. No quota when it is 0
+func (u *filesUsecase) ReserveUpload(ctx context.Context, userId string, bytes int64) error {
+	quota := u.cfg.Upload().DailyQuota()
+	if quota <= 0 || userId == "" {
+		return nil
+	}
+
+	ok, err := u.filesRepository.AddUploadUsage(ctx, userId, bytes, quota)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &files.QuotaError{Quota: quota}
+	}
+	return nil
+}
+
+// ReleaseUpload give back bytes reserved for an upload which failed
+func (u *filesUsecase) ReleaseUpload(ctx context.Context, userId string, bytes int64) {
+	if u.cfg.Upload().DailyQuota() <= 0 || userId == "" {
+		return
+	}
+	if err := u.filesRepository.ReleaseUploadUsage(ctx, userId, bytes); err != nil {
+		log.Printf("release upload usage of user %s failed: %v\n", userId, err)
+	}
+}
@@ -0,0 +1,138 @@
+package filesUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+)
+
+// storeVariant write the bytes of a variant at its destination, on GCP or on the local storage
+type storeVariant func(ctx context.Context, destination string, b []byte) error
+
+// makeVariants transcode an uploaded image to every format of IMAGE_VARIANTS in the background, the upload
+// does not wait for the encoders. A failed variant is only logged, the original is served instead
+func (u *filesUsecase) makeVariants(destination string, b []byte, store storeVariant) {
+	if len(u.transcoders) == 0 {
+		return
+	}
+	go func() {
+		// จำกัดจำนวน encoder ที่รันพร้อมกัน เพราะใช้ CPU เยอะ
+		u.transcodeSem <- struct{}{}
+		defer func() { <-u.transcodeSem }()
+
+		for _, transcoder := range u.transcoders {
+			variant, err := transcoder.Transcode(b)
+			if err != nil {
+				log.Printf("transcode %s to %s failed: %v\n", destination, transcoder.Format(), err)
+				continue
+			}
+			// a variant which is not smaller is not worth serving
+			if len(variant) >= len(b) {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+			dest := files.VariantDestination(destination, transcoder.Format())
+			if err := store(ctx, dest, variant); err != nil {
+				cancel()
+				log.Printf("store variant %s failed: %v\n", dest, err)
+				continue
+			}
+			if err := u.filesRepository.InsertFileVariant(ctx, destination, transcoder.Format(), len(variant)); err != nil {
+				log.Printf("insert variant %s failed: %v\n", dest, err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// storeVariantOnGCP upload a public variant, the client of the upload is closed when the variant is made
+func (u *filesUsecase) storeVariantOnGCP(ctx context.Context, destination string, b []byte) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	format := strings.TrimPrefix(filepath.Ext(destination), ".")
+	if err := u.writeObject(ctx, client, destination, files.VariantContentTypes[format], b); err != nil {
+		return err
+	}
+
+	variant := &filesPub{bucket: u.cfg.App().GCPBucket(), destination: destination}
+	return variant.makePublic(ctx, client)
+}
+
+func (u *filesUsecase) storeVariantOnStorage(ctx context.Context, destination string, b []byte) error {
+	dest, err := localStoragePath(destination)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest, b, 0644); err != nil {
+		return fmt.Errorf("write file failed: %v", err)
+	}
+	return nil
+}
+
+// variantDestinations return the destinations of every variant format of the files, whether they were made or not
+func variantDestinations(destinations ...string) []string {
+	variants := make([]string, 0, len(destinations)*len(files.VariantFormats))
+	for _, dest := range destinations {
+		for _, format := range files.VariantFormats {
+			variants = append(variants, files.VariantDestination(dest, format))
+		}
+	}
+	return variants
+}
+
+// deleteVariantsOnGCP delete the variants of the deleted files, a variant which was never made counts as deleted
+func (u *filesUsecase) deleteVariantsOnGCP(destinations []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		log.Printf("storage.NewClient: %v\n", err)
+		return
+	}
+	defer client.Close()
+
+	for _, dest := range variantDestinations(destinations...) {
+		if err := u.deleteObject(ctx, client, dest); err != nil {
+			log.Printf("delete variant %s failed: %v\n", dest, err)
+		}
+	}
+}
+
+func (u *filesUsecase) deleteVariantsOnStorage(destinations []string) {
+	for _, dest := range variantDestinations(destinations...) {
+		path, err := localStoragePath(dest)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("delete variant %s failed: %v\n", dest, err)
+		}
+	}
+}
+
+// ImageUrl return the url of the best format of a public image for the Accept header of the browser,
+// the original when no variant is accepted
+func (u *filesUsecase) ImageUrl(ctx context.Context, destination, accept string) (string, error) {
+	image, err := u.filesRepository.FindImage(ctx, destination)
+	if err != nil {
+		return "", err
+	}
+	url := u.CdnUrl(image.Url)
+	if format := files.NegotiateFormat(accept, image.Variants); format != "" {
+		return files.VariantDestination(url, format), nil
+	}
+	return url, nil
+}
@@ -0,0 +1,28 @@
+package graphql
+
+import "time"
+
+// Request is the body of POST /graphql, or the query string of GET /graphql
+type Request struct {
+	Query         string         `json:"query" query:"query"`
+	OperationName string         `json:"operationName" query:"operationName"`
+	Variables     map[string]any `json:"variables" query:"-"`
+}
+
+// Viewer is who run the query, it is set from the locals of the request
+type Viewer struct {
+	StoreId   string
+	UserId    string // empty without an access token
+	RoleId    int
+	PreviewAt time.Time // zero without a preview token
+}
+
+// SignedIn report whether the request has an access token
+func (v *Viewer) SignedIn() bool {
+	return v.UserId != ""
+}
+
+// Preview report whether draft products are shown
+func (v *Viewer) Preview() bool {
+	return !v.PreviewAt.IsZero()
+}
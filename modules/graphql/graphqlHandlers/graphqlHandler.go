@@ -0,0 +1,84 @@
+package graphqlHandlers
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/graphql"
+	"github.com/NatthawutSK/ri-shop/modules/graphql/graphqlResolvers"
+	"github.com/gofiber/fiber/v2"
+)
+
+type graphqlHandlerErrCode string
+
+const (
+	queryErr graphqlHandlerErrCode = "graphql-001"
+)
+
+type IGraphqlHandler interface {
+	Query(c *fiber.Ctx) error
+}
+
+type graphqlHandler struct {
+	cfg             config.IConfig
+	graphqlResolver graphqlResolvers.IGraphqlResolver
+}
+
+func GraphqlHandler(cfg config.IConfig, graphqlResolver graphqlResolvers.IGraphqlResolver) IGraphqlHandler {
+	return &graphqlHandler{
+		cfg:             cfg,
+		graphqlResolver: graphqlResolver,
+	}
+}
+
+// Query run a GraphQL query from the body of a POST, or ?query=&variables= of a GET.
+// The errors of the query are in the errors of the result like every GraphQL server, only a request
+// which cannot be read is answered with an error response
+func (h *graphqlHandler) Query(c *fiber.Ctx) error {
+	req := new(graphql.Request)
+	if c.Method() == fiber.MethodGet {
+		if err := c.QueryParser(req); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(queryErr),
+				err.Error(),
+			).Res()
+		}
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(queryErr),
+					"variables must be a json object",
+				).Res()
+			}
+		}
+	} else if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(queryErr),
+			err.Error(),
+		).Res()
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(queryErr),
+			"query is required",
+		).Res()
+	}
+
+	viewer := &graphql.Viewer{
+		StoreId: c.Locals("storeId").(string),
+	}
+	viewer.UserId, _ = c.Locals("userId").(string)
+	viewer.RoleId, _ = c.Locals("userRoleId").(int)
+	viewer.PreviewAt, _ = c.Locals("previewAt").(time.Time)
+
+	result := h.graphqlResolver.Execute(c.UserContext(), viewer, req)
+	return c.Status(fiber.StatusOK).JSON(result)
+}
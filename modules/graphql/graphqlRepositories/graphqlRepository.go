@@ -0,0 +1,101 @@
+package graphqlRepositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+// IGraphqlRepository read the fields of many products in one query, they are called by the loaders of a request
+type IGraphqlRepository interface {
+	FindCategoryByProductId(ctx context.Context, productIds []string) (map[string]*appinfo.Category, error)
+	FindImageByProductId(ctx context.Context, productIds []string) (map[string][]*entities.Image, error)
+}
+
+type graphqlRepository struct {
+	db          *sqlx.DB
+	fileUsecase filesUsecases.IFilesUsecase
+}
+
+func GraphqlRepository(db *sqlx.DB, fileUsecase filesUsecases.IFilesUsecase) IGraphqlRepository {
+	return &graphqlRepository{
+		db:          db,
+		fileUsecase: fileUsecase,
+	}
+}
+
+// FindCategoryByProductId return the category of each product, a product without a category is not in the map
+func (r *graphqlRepository) FindCategoryByProductId(ctx context.Context, productIds []string) (map[string]*appinfo.Category, error) {
+	query, args, err := sqlx.In(`
+	SELECT DISTINCT ON ("pc"."product_id")
+		"pc"."product_id",
+		"c"."id",
+		"c"."title"
+	FROM "products_categories" "pc"
+		JOIN "categories" "c" ON "c"."id" = "pc"."category_id"
+	WHERE "pc"."product_id" IN (?)
+	ORDER BY "pc"."product_id", "c"."id";`, productIds)
+	if err != nil {
+		return nil, fmt.Errorf("build find categories query failed: %v", err)
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows := make([]*struct {
+		ProductId string `db:"product_id"`
+		appinfo.Category
+	}, 0)
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("find categories of products failed: %v", err)
+	}
+
+	categories := make(map[string]*appinfo.Category)
+	for _, row := range rows {
+		category := row.Category
+		categories[row.ProductId] = &category
+	}
+	return categories, nil
+}
+
+// FindImageByProductId return the images of each product in the order of the product page, the primary image first
+func (r *graphqlRepository) FindImageByProductId(ctx context.Context, productIds []string) (map[string][]*entities.Image, error) {
+	query, args, err := sqlx.In(`
+	SELECT
+		"i"."product_id",
+		"i"."id",
+		"i"."filename",
+		"i"."url",
+		"i"."position",
+		"i"."is_primary"
+	FROM "images" "i"
+	WHERE "i"."product_id" IN (?)
+	ORDER BY "i"."product_id", "i"."is_primary" DESC, "i"."position" ASC;`, productIds)
+	if err != nil {
+		return nil, fmt.Errorf("build find images query failed: %v", err)
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows := make([]*struct {
+		ProductId string `db:"product_id"`
+		entities.Image
+	}, 0)
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("find images of products failed: %v", err)
+	}
+
+	images := make(map[string][]*entities.Image)
+	for _, row := range rows {
+		image := row.Image
+		image.Url = r.fileUsecase.CdnUrl(image.Url)
+		images[row.ProductId] = append(images[row.ProductId], &image)
+	}
+	return images, nil
+}
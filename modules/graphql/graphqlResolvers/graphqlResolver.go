@@ -0,0 +1,253 @@
+package graphqlResolvers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/graphql"
+	"github.com/NatthawutSK/ri-shop/modules/graphql/graphqlRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/dataloader"
+	gql "github.com/graphql-go/graphql"
+)
+
+// IGraphqlResolver run the queries of the storefront GraphQL API on the usecases of the REST modules
+type IGraphqlResolver interface {
+	Execute(ctx context.Context, viewer *graphql.Viewer, req *graphql.Request) *gql.Result
+}
+
+type graphqlResolver struct {
+	schema            gql.Schema
+	graphqlRepository graphqlRepositories.IGraphqlRepository
+	productsUsecase   productsUsecases.IProductsUsecase
+	appinfoUsecase    appinfoUsecases.IAppinfoUsecase
+	ordersUsecase     ordersUsecases.IOrdersUsecase
+}
+
+func GraphqlResolver(
+	graphqlRepository graphqlRepositories.IGraphqlRepository,
+	productsUsecase productsUsecases.IProductsUsecase,
+	appinfoUsecase appinfoUsecases.IAppinfoUsecase,
+	ordersUsecase ordersUsecases.IOrdersUsecase,
+) IGraphqlResolver {
+	r := &graphqlResolver{
+		graphqlRepository: graphqlRepository,
+		productsUsecase:   productsUsecase,
+		appinfoUsecase:    appinfoUsecase,
+		ordersUsecase:     ordersUsecase,
+	}
+
+	schema, err := r.newSchema()
+	if err != nil {
+		panic(fmt.Sprintf("build graphql schema failed: %v", err))
+	}
+	r.schema = schema
+	return r
+}
+
+// loaders batch the fields of the products of one request, a list of products cost one query per field
+type loaders struct {
+	category *dataloader.Loader[string, *appinfo.Category]
+	images   *dataloader.Loader[string, []*entities.Image]
+}
+
+type contextKey int
+
+const (
+	viewerKey contextKey = iota
+	loadersKey
+)
+
+func (r *graphqlResolver) Execute(ctx context.Context, viewer *graphql.Viewer, req *graphql.Request) *gql.Result {
+	ctx = context.WithValue(ctx, viewerKey, viewer)
+	ctx = context.WithValue(ctx, loadersKey, &loaders{
+		category: dataloader.New(ctx, r.graphqlRepository.FindCategoryByProductId),
+		images:   dataloader.New(ctx, r.graphqlRepository.FindImageByProductId),
+	})
+
+	return gql.Do(gql.Params{
+		Schema:         r.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+}
+
+func viewerOf(ctx context.Context) *graphql.Viewer {
+	return ctx.Value(viewerKey).(*graphql.Viewer)
+}
+
+func loadersOf(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey).(*loaders)
+}
+
+// prime keep the category and the images which the product query already read, the loaders
+// only read the products which do not have them, e.g. the snapshots of an order
+func prime(ctx context.Context, productsData ...*products.Products) {
+	l := loadersOf(ctx)
+	for _, product := range productsData {
+		l.category.Prime(product.Id, product.Category)
+		if product.Images != nil {
+			l.images.Prime(product.Id, product.Images)
+		}
+	}
+}
+
+// product is nil when it is not found, of another store or not shown on the storefront like GET /products/:productId
+func (r *graphqlResolver) product(p gql.ResolveParams) (any, error) {
+	viewer := viewerOf(p.Context)
+	product, err := r.productsUsecase.FindOneProduct(p.Context, p.Args["id"].(string))
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if product.StoreId != viewer.StoreId {
+		return nil, nil
+	}
+	if product.Status == products.StatusArchived || (product.Status == products.StatusDraft && !viewer.Preview()) {
+		return nil, nil
+	}
+
+	currency, _ := p.Args["currency"].(string)
+	if err := r.productsUsecase.ConvertCurrency(p.Context, []*products.Products{product}, currency); err != nil {
+		return nil, err
+	}
+	prime(p.Context, product)
+	return product, nil
+}
+
+// products is the list of GET /products with the filters of the schema
+func (r *graphqlResolver) products(p gql.ResolveParams) (any, error) {
+	viewer := viewerOf(p.Context)
+	filter, _ := p.Args["filter"].(map[string]any)
+	arg := func(name string) string {
+		value, _ := filter[name].(string)
+		return strings.TrimSpace(value)
+	}
+
+	req := &products.ProductFilter{
+		Search:   arg("search"),
+		Badge:    arg("badge"),
+		Currency: arg("currency"),
+		StoreId:  viewer.StoreId,
+		PaginationReq: &entities.PaginationReq{
+			Page:  1,
+			Limit: 10,
+		},
+		SortReq: &entities.SortReq{
+			OrderBy: "title",
+			Sort:    "ASC",
+		},
+	}
+	if page, ok := filter["page"].(int); ok && page > 1 {
+		req.Page = page
+	}
+	if limit, ok := filter["limit"].(int); ok {
+		req.Limit = limit
+	}
+	if req.Limit < 3 {
+		req.Limit = 3
+	}
+	if req.Search != "" {
+		req.SortKeys = []string{products.SortRelevance}
+	}
+	req.At, req.Preview = viewer.PreviewAt, viewer.Preview()
+	if !req.Preview {
+		req.At = time.Now()
+	}
+
+	page := r.productsUsecase.FindProduct(p.Context, req)
+	if productsData, ok := page.Data.([]*products.Products); ok {
+		prime(p.Context, productsData...)
+	}
+	return page, nil
+}
+
+func (r *graphqlResolver) categories(p gql.ResolveParams) (any, error) {
+	return r.appinfoUsecase.FindCategory(p.Context, &appinfo.CategoryFilter{
+		StoreId: viewerOf(p.Context).StoreId,
+	})
+}
+
+// order is one of the orders of the signed in user, an admin can read every order of the store
+func (r *graphqlResolver) order(p gql.ResolveParams) (any, error) {
+	viewer := viewerOf(p.Context)
+	if !viewer.SignedIn() {
+		return nil, fmt.Errorf("sign in to read orders")
+	}
+
+	order, err := r.ordersUsecase.FindOneOrder(p.Context, p.Args["id"].(string))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if order.StoreId != viewer.StoreId || (order.UserId != viewer.UserId && viewer.RoleId != 2) {
+		return nil, nil
+	}
+	return order, nil
+}
+
+// category and images of a product are read through the loaders, the thunk is called after
+// every product of the response has queued its id
+func productCategory(p gql.ResolveParams) (any, error) {
+	thunk := loadersOf(p.Context).category.Load(p.Source.(*products.Products).Id)
+	return func() (any, error) {
+		category, err := thunk()
+		if err != nil || category == nil {
+			return nil, err
+		}
+		return category, nil
+	}, nil
+}
+
+func productImages(p gql.ResolveParams) (any, error) {
+	thunk := loadersOf(p.Context).images.Load(p.Source.(*products.Products).Id)
+	return func() (any, error) {
+		images, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		return nonNil(images), nil
+	}, nil
+}
+
+func productPrices(p gql.ResolveParams) (any, error) {
+	return nonNil(p.Source.(*products.Products).Prices), nil
+}
+
+func productBadges(p gql.ResolveParams) (any, error) {
+	return nonNil(p.Source.(*products.Products).Badges), nil
+}
+
+func pageData(p gql.ResolveParams) (any, error) {
+	productsData, _ := p.Source.(*entities.PaginateRes).Data.([]*products.Products)
+	return nonNil(productsData), nil
+}
+
+// orderProducts keep the order of the lines, the product of a line is its snapshot at checkout
+func orderProducts(p gql.ResolveParams) (any, error) {
+	return nonNil(p.Source.(*orders.Order).Products), nil
+}
+
+// nonNil return an empty list for nil, the lists of the schema are non null
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return make([]T, 0)
+	}
+	return list
+}
@@ -0,0 +1,148 @@
+package graphqlResolvers
+
+import (
+	gql "github.com/graphql-go/graphql"
+)
+
+// newSchema build the types of modules/graphql/schema.graphqls, the fields without a resolver
+// are read from the json tags of the REST entities
+func (r *graphqlResolver) newSchema() (gql.Schema, error) {
+	category := gql.NewObject(gql.ObjectConfig{
+		Name: "Category",
+		Fields: gql.Fields{
+			"id":    &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"title": &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	image := gql.NewObject(gql.ObjectConfig{
+		Name: "Image",
+		Fields: gql.Fields{
+			"id":       &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"filename": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"url":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	badge := gql.NewObject(gql.ObjectConfig{
+		Name: "Badge",
+		Fields: gql.Fields{
+			"id":    &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"code":  &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"title": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"color": &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	productPrice := gql.NewObject(gql.ObjectConfig{
+		Name: "ProductPrice",
+		Fields: gql.Fields{
+			"currency": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"price":    &gql.Field{Type: gql.NewNonNull(gql.Float)},
+		},
+	})
+
+	product := gql.NewObject(gql.ObjectConfig{
+		Name: "Product",
+		Fields: gql.Fields{
+			"id":          &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"title":       &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"description": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"price":       &gql.Field{Type: gql.NewNonNull(gql.Float)},
+			"currency":    &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"prices":      &gql.Field{Type: nonNullList(productPrice), Resolve: productPrices},
+			"stock":       &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"category":    &gql.Field{Type: category, Resolve: productCategory},
+			"images":      &gql.Field{Type: nonNullList(image), Resolve: productImages},
+			"badges":      &gql.Field{Type: nonNullList(badge), Resolve: productBadges},
+			"created_at":  &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"updated_at":  &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	productsOrder := gql.NewObject(gql.ObjectConfig{
+		Name: "ProductsOrder",
+		Fields: gql.Fields{
+			"id":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"qty":     &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"product": &gql.Field{Type: gql.NewNonNull(product)},
+		},
+	})
+
+	order := gql.NewObject(gql.ObjectConfig{
+		Name: "Order",
+		Fields: gql.Fields{
+			"id":              &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"user_id":         &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"status":          &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"products":        &gql.Field{Type: nonNullList(productsOrder), Resolve: orderProducts},
+			"total_paid":      &gql.Field{Type: gql.NewNonNull(gql.Float)},
+			"shipping_method": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"shipping_fee":    &gql.Field{Type: gql.NewNonNull(gql.Float)},
+			"tracking_number": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"created_at":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"updated_at":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	productPage := gql.NewObject(gql.ObjectConfig{
+		Name: "ProductPage",
+		Fields: gql.Fields{
+			"data":       &gql.Field{Type: nonNullList(product), Resolve: pageData},
+			"page":       &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"limit":      &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"total_page": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"total_item": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+		},
+	})
+
+	productFilter := gql.NewInputObject(gql.InputObjectConfig{
+		Name: "ProductFilter",
+		Fields: gql.InputObjectConfigFieldMap{
+			"search":   &gql.InputObjectFieldConfig{Type: gql.String},
+			"badge":    &gql.InputObjectFieldConfig{Type: gql.String},
+			"currency": &gql.InputObjectFieldConfig{Type: gql.String},
+			"page":     &gql.InputObjectFieldConfig{Type: gql.Int, DefaultValue: 1},
+			"limit":    &gql.InputObjectFieldConfig{Type: gql.Int, DefaultValue: 10},
+		},
+	})
+
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"product": &gql.Field{
+				Type: product,
+				Args: gql.FieldConfigArgument{
+					"id":       &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+					"currency": &gql.ArgumentConfig{Type: gql.String},
+				},
+				Resolve: r.product,
+			},
+			"products": &gql.Field{
+				Type: gql.NewNonNull(productPage),
+				Args: gql.FieldConfigArgument{
+					"filter": &gql.ArgumentConfig{Type: productFilter},
+				},
+				Resolve: r.products,
+			},
+			"categories": &gql.Field{
+				Type:    nonNullList(category),
+				Resolve: r.categories,
+			},
+			"order": &gql.Field{
+				Type: order,
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: r.order,
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: query})
+}
+
+// nonNullList is [T!]!
+func nonNullList(t gql.Type) gql.Type {
+	return gql.NewNonNull(gql.NewList(gql.NewNonNull(t)))
+}
@@ -0,0 +1,169 @@
+package inventory
+
+import (
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+)
+
+// EventStockAlert is published on pkg/events with a *StockAlert payload
+const EventStockAlert = "inventory.stock_alert"
+
+// EventLowStock is published on pkg/events with a *LowStock payload, once per drop below the threshold
+const EventLowStock = "inventory.low_stock"
+
+// CheckoutPrefix start the reference of a checkout hold, the hold is moved to the order id when the order is placed
+const CheckoutPrefix = "checkout:"
+
+type Supplier struct {
+	StoreId string `json:"-" db:"-"`
+	Id      int    `json:"id" db:"id"`
+	Title   string `json:"title" db:"title"`
+	Contact string `json:"contact" db:"contact"`
+}
+
+type ReorderSuggestion struct {
+	Sku               string    `json:"sku"`
+	Title             string    `json:"title"`
+	Stock             int       `json:"stock"`
+	SalesVelocity     float64   `json:"sales_velocity"` // units sold per day
+	DaysOfStock       *float64  `json:"days_of_stock"`  // null when the product has no sales
+	SuggestedQty      int       `json:"suggested_qty"`
+	PreferredSupplier *Supplier `json:"preferred_supplier"`
+	ComputedAt        string    `json:"computed_at"`
+}
+
+type ReorderFilter struct {
+	StoreId      string `query:"-"`
+	LeadTimeDays int    `query:"lead_time_days"` // days until a purchase order arrives
+	CoverageDays int    `query:"coverage_days"`  // days of stock a purchase order should cover
+}
+
+type ProductSupplierReq struct {
+	StoreId    string `json:"-"`
+	ProductId  string `json:"product_id"`
+	SupplierId int    `json:"supplier_id"`
+}
+
+type StockItem struct {
+	ProductId string `json:"product_id"`
+	Qty       int    `json:"qty"`
+}
+
+// Reservation hold stock of the items until ExpiresAt, the held qty is not available to anyone else
+type Reservation struct {
+	Reference  string       `json:"reference"`
+	Items      []*StockItem `json:"items"`
+	TtlSeconds int          `json:"ttl_seconds"`
+	ExpiresAt  string       `json:"expires_at"`
+	UserId     string       `json:"-"` // owner of a checkout hold, empty for holds of other services
+	StoreId    *string      `json:"-"` // only products of the store are held, nil for holds of other services
+}
+
+// CheckoutReq start a checkout of the customer, the items are held until the order is placed and paid
+type CheckoutReq struct {
+	StoreId string       `json:"-"`
+	UserId  string       `json:"-"`
+	Items   []*StockItem `json:"items"`
+}
+
+// StockAlert is returned and published when a product does not have enough stock for a hold
+type StockAlert struct {
+	ProductId string `json:"product_id"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+func (a *StockAlert) Error() string {
+	return fmt.Sprintf("insufficient stock for product %s", a.ProductId)
+}
+
+// LowStock is a product which available stock is at or below its threshold
+type LowStock struct {
+	ProductId string  `json:"product_id" db:"product_id"`
+	Title     string  `json:"title" db:"title"`
+	Available int     `json:"available" db:"available"` // stock minus the holds of checkouts and placed orders
+	Threshold int     `json:"threshold" db:"threshold"`
+	AlertedAt *string `json:"alerted_at" db:"alerted_at"` // null when the drop has not been alerted yet
+}
+
+type LowStockThresholdReq struct {
+	StoreId   string `json:"-"`
+	ProductId string `json:"product_id"`
+	Threshold *int   `json:"threshold"` // null use the default threshold
+}
+
+const (
+	MovementSale        = "sale"
+	MovementRestock     = "restock"
+	MovementAdjustment  = "adjustment"
+	MovementReservation = "reservation" // a hold or its release, the stock itself does not change
+	MovementRefund      = "refund"
+)
+
+// ValidMovementKind report whether kind is one of the movement kinds
+func ValidMovementKind(kind string) bool {
+	switch kind {
+	case MovementSale, MovementRestock, MovementAdjustment, MovementReservation, MovementRefund:
+		return true
+	}
+	return false
+}
+
+// Movement is a change of the stock of a product recorded by the trigger of the products table, Qty is negative
+// when the stock goes out. StockAfter is null for a reservation
+type Movement struct {
+	Id         int64  `json:"id" db:"id"`
+	ProductId  string `json:"product_id" db:"product_id"`
+	Kind       string `json:"kind" db:"kind"`
+	Qty        int    `json:"qty" db:"qty"`
+	StockAfter *int   `json:"stock_after" db:"stock_after"`
+	Reason     string `json:"reason" db:"reason"`
+	ActorId    string `json:"actor_id" db:"actor_id"`
+	OrderId    string `json:"order_id,omitempty" db:"order_id"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+}
+
+// MovementContext is what the next stock changes of a transaction are recorded as, see MarkMovement
+type MovementContext struct {
+	Kind    string
+	Reason  string
+	ActorId string
+	OrderId string
+}
+
+type MovementFilter struct {
+	StoreId   string `query:"-"`
+	ProductId string `query:"product_id"`
+	OrderId   string `query:"order_id"`
+	Kind      string `query:"kind"`
+	StartDate string `query:"start_date"` // YYYY-MM-DD
+	EndDate   string `query:"end_date"`   // YYYY-MM-DD, included
+	*entities.PaginationReq
+}
+
+// MovementReq is a restock or an adjustment made by an admin, Qty is added to the stock
+type MovementReq struct {
+	StoreId   string `json:"-"`
+	ActorId   string `json:"-"`
+	ProductId string `json:"product_id"`
+	Kind      string `json:"kind"`
+	Qty       int    `json:"qty"`
+	Reason    string `json:"reason"`
+}
+
+// Reconcile is the stock of a product against the sum of its movements. Difference is stock minus ledger, it is
+// not 0 when the stock was changed with the trigger disabled
+type Reconcile struct {
+	ProductId  string `json:"product_id" db:"product_id"`
+	Title      string `json:"title" db:"title"`
+	Stock      int    `json:"stock" db:"stock"`
+	Ledger     int    `json:"ledger" db:"ledger"`
+	Difference int    `json:"difference" db:"difference"`
+}
+
+type ReconcileReq struct {
+	StoreId    string `query:"-"`
+	ProductId  string `query:"product_id"`
+	Mismatched bool   `query:"mismatched"` // only the products whose difference is not 0
+}
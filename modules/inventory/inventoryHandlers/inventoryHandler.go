@@ -0,0 +1,287 @@
+package inventoryHandlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+type inventoryHandlerErrCode string
+
+const (
+	findReorderSuggestionErr inventoryHandlerErrCode = "inventory-001"
+	findSupplierErr          inventoryHandlerErrCode = "inventory-002"
+	insertSupplierErr        inventoryHandlerErrCode = "inventory-003"
+	updateProductSupplierErr inventoryHandlerErrCode = "inventory-004"
+	startCheckoutErr         inventoryHandlerErrCode = "inventory-005"
+	findLowStockErr          inventoryHandlerErrCode = "inventory-006"
+	updateLowStockErr        inventoryHandlerErrCode = "inventory-007"
+	findMovementErr          inventoryHandlerErrCode = "inventory-008"
+	insertMovementErr        inventoryHandlerErrCode = "inventory-009"
+	findReconcileErr         inventoryHandlerErrCode = "inventory-010"
+)
+
+type IInventoryHandler interface {
+	FindReorderSuggestion(c *fiber.Ctx) error
+	FindSupplier(c *fiber.Ctx) error
+	AddSupplier(c *fiber.Ctx) error
+	UpdateProductSupplier(c *fiber.Ctx) error
+	StartCheckout(c *fiber.Ctx) error
+	FindLowStock(c *fiber.Ctx) error
+	UpdateLowStockThreshold(c *fiber.Ctx) error
+	FindMovement(c *fiber.Ctx) error
+	AddMovement(c *fiber.Ctx) error
+	FindReconcile(c *fiber.Ctx) error
+	CheckLowStock(ctx context.Context, e *events.Event)
+}
+
+type inventoryHandler struct {
+	cfg              config.IConfig
+	inventoryUsecase inventoryUsecases.IInventoryUsecase
+}
+
+func InventoryHandler(cfg config.IConfig, inventoryUsecase inventoryUsecases.IInventoryUsecase) IInventoryHandler {
+	return &inventoryHandler{
+		cfg:              cfg,
+		inventoryUsecase: inventoryUsecase,
+	}
+}
+
+func (h *inventoryHandler) FindReorderSuggestion(c *fiber.Ctx) error {
+	req := new(inventory.ReorderFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findReorderSuggestionErr),
+			err.Error(),
+		).Res()
+	}
+
+	if req.LeadTimeDays < 1 {
+		req.LeadTimeDays = 7
+	}
+	if req.CoverageDays < 1 {
+		req.CoverageDays = 14
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	suggestions, err := h.inventoryUsecase.FindReorderSuggestion(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findReorderSuggestionErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, suggestions).Res()
+}
+
+func (h *inventoryHandler) FindSupplier(c *fiber.Ctx) error {
+	suppliers, err := h.inventoryUsecase.FindSupplier(c.UserContext(), c.Locals("storeId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findSupplierErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, suppliers).Res()
+}
+
+func (h *inventoryHandler) AddSupplier(c *fiber.Ctx) error {
+	req := new(inventory.Supplier)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSupplierErr),
+			err.Error(),
+		).Res()
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSupplierErr),
+			"supplier title is required",
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	supplier, err := h.inventoryUsecase.AddSupplier(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "supplier title has been used":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertSupplierErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertSupplierErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, supplier).Res()
+}
+
+func (h *inventoryHandler) UpdateProductSupplier(c *fiber.Ctx) error {
+	req := new(inventory.ProductSupplierReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductSupplierErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("productId"), " ")
+	req.StoreId = c.Locals("storeId").(string)
+
+	if req.SupplierId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductSupplierErr),
+			"supplier id is invalid",
+		).Res()
+	}
+
+	if err := h.inventoryUsecase.UpdateProductSupplier(c.UserContext(), req); err != nil {
+		switch err.Error() {
+		case "product not found", "supplier not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateProductSupplierErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateProductSupplierErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, req).Res()
+}
+
+func (h *inventoryHandler) StartCheckout(c *fiber.Ctx) error {
+	req := &inventory.CheckoutReq{
+		Items: make([]*inventory.StockItem, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(startCheckoutErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+	req.StoreId = c.Locals("storeId").(string)
+
+	reservation, err := h.inventoryUsecase.StartCheckout(c.UserContext(), req)
+	if err != nil {
+		var alert *inventory.StockAlert
+		if errors.As(err, &alert) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(startCheckoutErr),
+				err.Error(),
+			).Res()
+		}
+		switch {
+		case err.Error() == "items are empty",
+			strings.HasSuffix(err.Error(), "must be more than 0"),
+			strings.HasSuffix(err.Error(), "not found"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(startCheckoutErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(startCheckoutErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, reservation).Res()
+}
+
+func (h *inventoryHandler) FindLowStock(c *fiber.Ctx) error {
+	items, err := h.inventoryUsecase.FindLowStock(c.UserContext(), c.Locals("storeId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findLowStockErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, items).Res()
+}
+
+func (h *inventoryHandler) UpdateLowStockThreshold(c *fiber.Ctx) error {
+	req := new(inventory.LowStockThresholdReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateLowStockErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("productId"), " ")
+	req.StoreId = c.Locals("storeId").(string)
+
+	if err := h.inventoryUsecase.UpdateLowStockThreshold(c.UserContext(), req); err != nil {
+		switch err.Error() {
+		case "threshold must not be negative":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateLowStockErr),
+				err.Error(),
+			).Res()
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateLowStockErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateLowStockErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, req).Res()
+}
+
+// CheckLowStock is the event subscriber of placed orders, the holds of the order already count
+// against the available stock
+func (h *inventoryHandler) CheckLowStock(ctx context.Context, e *events.Event) {
+	payload, ok := e.Payload.(*orders.OrderEvent)
+	if !ok {
+		return
+	}
+	if _, err := h.inventoryUsecase.CheckLowStock(ctx, payload.OrderId); err != nil {
+		log.Printf("check low stock of order %s failed: %v\n", payload.OrderId, err)
+	}
+}
@@ -0,0 +1,119 @@
+package inventoryHandlers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *inventoryHandler) FindMovement(c *fiber.Ctx) error {
+	req := &inventory.MovementFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findMovementErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 50
+	}
+
+	page, err := h.inventoryUsecase.FindMovement(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "kind is invalid", "date must be YYYY-MM-DD", "end date is before start date":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findMovementErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findMovementErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, page).Res()
+}
+
+// AddMovement is a restock or an adjustment counted by hand, e.g. a delivery of a supplier or a damaged item
+func (h *inventoryHandler) AddMovement(c *fiber.Ctx) error {
+	req := new(inventory.MovementReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertMovementErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+	req.ActorId = c.Locals("userId").(string)
+
+	movement, err := h.inventoryUsecase.AddMovement(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "product id is required",
+			"kind must be restock or adjustment",
+			"qty must not be 0",
+			"qty of a restock must be more than 0",
+			"reason is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertMovementErr),
+				err.Error(),
+			).Res()
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(insertMovementErr),
+				err.Error(),
+			).Res()
+		case "stock can not be negative":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(insertMovementErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertMovementErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, movement).Res()
+}
+
+// FindReconcile compare the stock with the ledger, a difference means the stock was changed outside of it
+func (h *inventoryHandler) FindReconcile(c *fiber.Ctx) error {
+	req := new(inventory.ReconcileReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findReconcileErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	items, err := h.inventoryUsecase.FindReconcile(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findReconcileErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, items).Res()
+}
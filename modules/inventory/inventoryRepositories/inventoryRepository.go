@@ -0,0 +1,462 @@
+package inventoryRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+	"github.com/jmoiron/sqlx"
+)
+
+type IInventoryRepository interface {
+	RefreshForecast(ctx context.Context, windowDays int) error
+	FindReorderSuggestion(ctx context.Context, req *inventory.ReorderFilter) ([]*inventory.ReorderSuggestion, error)
+	FindSupplier(ctx context.Context, storeId string) ([]*inventory.Supplier, error)
+	InsertSupplier(ctx context.Context, req *inventory.Supplier) error
+	UpdateProductSupplier(ctx context.Context, req *inventory.ProductSupplierReq) error
+	ReserveStock(ctx context.Context, req *inventory.Reservation) error
+	ReleaseStock(ctx context.Context, reference string) (int, error)
+	ReleaseCheckout(ctx context.Context, userId string) error
+	DeleteExpiredReservation(ctx context.Context) (int, error)
+	MarkLowStock(ctx context.Context, orderId string, threshold int) ([]*inventory.LowStock, error)
+	FindLowStock(ctx context.Context, storeId string, threshold int) ([]*inventory.LowStock, error)
+	UpdateLowStockThreshold(ctx context.Context, req *inventory.LowStockThresholdReq) error
+	FindMovement(ctx context.Context, req *inventory.MovementFilter) ([]*inventory.Movement, int, error)
+	InsertMovement(ctx context.Context, req *inventory.MovementReq) (*inventory.Movement, error)
+	FindReconcile(ctx context.Context, req *inventory.ReconcileReq) ([]*inventory.Reconcile, error)
+}
+
+type inventoryRepository struct {
+	db *sqlx.DB
+}
+
+func InventoryRepository(db *sqlx.DB) IInventoryRepository {
+	return &inventoryRepository{
+		db: db,
+	}
+}
+
+// RefreshForecast recompute sales velocity and days of stock of every product from orders of its own store in the
+// last windowDays
+func (r *inventoryRepository) RefreshForecast(ctx context.Context, windowDays int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	query := `
+	INSERT INTO "inventory_forecasts" (
+		"product_id",
+		"sales_velocity",
+		"days_of_stock",
+		"computed_at"
+	)
+	SELECT
+		"p"."id",
+		COALESCE("s"."qty", 0)::FLOAT / $1::FLOAT,
+		(CASE WHEN COALESCE("s"."qty", 0) = 0 THEN NULL ELSE "p"."stock"::FLOAT / ("s"."qty"::FLOAT / $1::FLOAT) END),
+		now()
+	FROM "products" "p"
+		LEFT JOIN LATERAL (
+			SELECT
+				SUM("po"."qty") AS "qty"
+			FROM "products_orders" "po"
+				JOIN "orders" "o" ON "o"."id" = "po"."order_id"
+			WHERE "po"."product"->>'id' = "p"."id"
+			AND COALESCE("o"."store_id", '') = COALESCE("p"."store_id", '')
+			AND "o"."status" <> 'canceled'
+			AND "o"."created_at" >= now() - make_interval(days => $1::INT)
+		) AS "s" ON TRUE
+	ON CONFLICT ("product_id") DO UPDATE SET
+		"sales_velocity" = EXCLUDED."sales_velocity",
+		"days_of_stock" = EXCLUDED."days_of_stock",
+		"computed_at" = EXCLUDED."computed_at";`
+
+	// "po"."product"->>'id' คือ id ของ product ที่ snapshot ไว้ตอนสั่ง order
+	// days_of_stock = stock / (ยอดขายต่อวัน)
+
+	if _, err := r.db.ExecContext(ctx, query, windowDays); err != nil {
+		return fmt.Errorf("refresh inventory forecast failed: %v", err)
+	}
+	return nil
+}
+
+func (r *inventoryRepository) FindReorderSuggestion(ctx context.Context, req *inventory.ReorderFilter) ([]*inventory.ReorderSuggestion, error) {
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"p"."id" AS "sku",
+			"p"."title",
+			"p"."stock",
+			"f"."sales_velocity",
+			"f"."days_of_stock",
+			GREATEST(CEIL("f"."sales_velocity" * ($1 + $2)) - "p"."stock", 0)::INT AS "suggested_qty",
+			(
+				SELECT
+					to_jsonb("st")
+				FROM (
+					SELECT
+						"s"."id",
+						"s"."title",
+						"s"."contact"
+					FROM "suppliers" "s"
+					WHERE "s"."id" = "p"."supplier_id"
+				) AS "st"
+			) AS "preferred_supplier",
+			"f"."computed_at"
+		FROM "inventory_forecasts" "f"
+			LEFT JOIN "products" "p" ON "p"."id" = "f"."product_id"
+		WHERE CEIL("f"."sales_velocity" * ($1 + $2)) > "p"."stock"
+		AND COALESCE("p"."store_id", '') = $3
+		ORDER BY "f"."days_of_stock" ASC NULLS LAST
+	) AS "t";`
+
+	// suggested_qty = ยอดขายต่อวัน * (วันที่รอของ + วันที่ต้องการให้ของพอขาย) - stock ที่มีอยู่
+
+	bytes := make([]byte, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	suggestions := make([]*inventory.ReorderSuggestion, 0)
+	if err := r.db.GetContext(ctx, &bytes, query, req.LeadTimeDays, req.CoverageDays, req.StoreId); err != nil {
+		return nil, fmt.Errorf("get reorder suggestions failed: %v", err)
+	}
+	if err := json.Unmarshal(bytes, &suggestions); err != nil {
+		return nil, fmt.Errorf("unmarshal reorder suggestions failed: %v", err)
+	}
+	return suggestions, nil
+}
+
+func (r *inventoryRepository) FindSupplier(ctx context.Context, storeId string) ([]*inventory.Supplier, error) {
+	query := `
+	SELECT
+		"id",
+		"title",
+		"contact"
+	FROM "suppliers"
+	WHERE COALESCE("store_id", '') = $1
+	ORDER BY "id";`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	suppliers := make([]*inventory.Supplier, 0)
+	if err := r.db.SelectContext(ctx, &suppliers, query, storeId); err != nil {
+		return nil, fmt.Errorf("select suppliers failed: %v", err)
+	}
+	return suppliers, nil
+}
+
+func (r *inventoryRepository) InsertSupplier(ctx context.Context, req *inventory.Supplier) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "suppliers" (
+		"store_id",
+		"title",
+		"contact"
+	)
+	VALUES (NULLIF($1, ''), $2, $3)
+		RETURNING "id";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.StoreId, req.Title, req.Contact).Scan(&req.Id); err != nil {
+		switch err.Error() {
+		case "ERROR: duplicate key value violates unique constraint \"suppliers_store_id_title_idx\" (SQLSTATE 23505)":
+			return fmt.Errorf("supplier title has been used")
+		default:
+			return fmt.Errorf("insert supplier failed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (r *inventoryRepository) UpdateProductSupplier(ctx context.Context, req *inventory.ProductSupplierReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// supplier ของร้านอื่นเหมือนไม่มีอยู่
+	supplierQuery := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "suppliers"
+		WHERE "id" = $1
+		AND COALESCE("store_id", '') = $2
+	);`
+
+	var found bool
+	if err := r.db.QueryRowContext(ctx, supplierQuery, req.SupplierId, req.StoreId).Scan(&found); err != nil {
+		return fmt.Errorf("find supplier failed: %v", err)
+	}
+	if !found {
+		return fmt.Errorf("supplier not found")
+	}
+
+	query := `
+	UPDATE "products" SET
+		"supplier_id" = $1
+	WHERE "id" = $2
+	AND COALESCE("store_id", '') = $3;`
+
+	// product ของร้านอื่นเหมือนไม่มีอยู่
+	result, err := r.db.ExecContext(ctx, query, req.SupplierId, req.ProductId, req.StoreId)
+	if err != nil {
+		return fmt.Errorf("update product supplier failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
+
+// ReserveStock hold every item or none of them, products are locked in order so concurrent holds do not deadlock
+func (r *inventoryRepository) ReserveStock(ctx context.Context, req *inventory.Reservation) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	items := make([]*inventory.StockItem, len(req.Items))
+	copy(items, req.Items)
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductId < items[j].ProductId })
+
+	productIds := make([]string, 0, len(items))
+	for _, item := range items {
+		productIds = append(productIds, item.ProductId)
+	}
+	if err := locks.LockStock(ctx, tx, productIds...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// stock ที่ยังว่าง = stock - ที่ถูก hold อยู่และยังไม่หมดอายุ
+	availableQuery := `
+	SELECT
+		"p"."stock" - COALESCE((
+			SELECT
+				SUM("r"."qty")
+			FROM "stock_reservations" "r"
+			WHERE "r"."product_id" = "p"."id"
+			AND "r"."expires_at" > now()
+		), 0) AS "available"
+	FROM "products" "p"
+	WHERE "p"."id" = $1
+	AND ($2::VARCHAR IS NULL OR COALESCE("p"."store_id", '') = $2)
+	FOR UPDATE;`
+
+	insertQuery := `
+	INSERT INTO "stock_reservations" (
+		"reference",
+		"product_id",
+		"qty",
+		"expires_at",
+		"user_id"
+	)
+	VALUES ($1, $2, $3, now() + make_interval(secs => $4), NULLIF($5, ''))
+		RETURNING "expires_at";`
+
+	for _, item := range items {
+		var available int
+		if err := tx.GetContext(ctx, &available, availableQuery, item.ProductId, req.StoreId); err != nil {
+			tx.Rollback()
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("product %s not found", item.ProductId)
+			}
+			return fmt.Errorf("get available stock failed: %v", err)
+		}
+		if available < item.Qty {
+			tx.Rollback()
+			return &inventory.StockAlert{
+				ProductId: item.ProductId,
+				Requested: item.Qty,
+				Available: available,
+			}
+		}
+
+		if err := tx.QueryRowContext(ctx, insertQuery, req.Reference, item.ProductId, item.Qty, req.TtlSeconds, req.UserId).Scan(&req.ExpiresAt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert stock reservation failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit stock reservation failed: %v", err)
+	}
+	return nil
+}
+
+// ReleaseStock remove the holds of the reference, expired holds are removed too
+func (r *inventoryRepository) ReleaseStock(ctx context.Context, reference string) (int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	DELETE FROM "stock_reservations"
+	WHERE "reference" = $1;`, reference)
+	if err != nil {
+		return 0, fmt.Errorf("release stock failed: %v", err)
+	}
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("release stock failed: %v", err)
+	}
+	return int(released), nil
+}
+
+// ReleaseCheckout remove the checkout holds of the customer which are not placed as an order yet
+func (r *inventoryRepository) ReleaseCheckout(ctx context.Context, userId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `
+	DELETE FROM "stock_reservations"
+	WHERE "user_id" = $1
+	AND "reference" LIKE $2 || '%';`, userId, inventory.CheckoutPrefix); err != nil {
+		return fmt.Errorf("release checkout failed: %v", err)
+	}
+	return nil
+}
+
+func (r *inventoryRepository) DeleteExpiredReservation(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	DELETE FROM "stock_reservations"
+	WHERE "expires_at" <= now();`)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired reservation failed: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete expired reservation failed: %v", err)
+	}
+	return int(deleted), nil
+}
+
+// MarkLowStock record the products of the order which fell to their threshold and return them,
+// a product which is already recorded is not returned again. Products which are back above
+// their threshold are cleared first so their next drop is alerted
+func (r *inventoryRepository) MarkLowStock(ctx context.Context, orderId string, threshold int) ([]*inventory.LowStock, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `
+	DELETE FROM "low_stock_alerts" "a"
+	USING "products" "p"
+	WHERE "p"."id" = "a"."product_id"
+	AND available_stock("p"."id") > COALESCE("p"."low_stock_threshold", $1);`, threshold); err != nil {
+		return nil, fmt.Errorf("clear low stock alerts failed: %v", err)
+	}
+
+	query := `
+	WITH "inserted" AS (
+		INSERT INTO "low_stock_alerts" (
+			"product_id",
+			"available",
+			"threshold"
+		)
+		SELECT
+			"s"."id",
+			"s"."available",
+			"s"."threshold"
+		FROM (
+			SELECT
+				"p"."id",
+				available_stock("p"."id") AS "available",
+				COALESCE("p"."low_stock_threshold", $2) AS "threshold"
+			FROM "products" "p"
+			WHERE "p"."id" IN (
+				SELECT
+					"po"."product"->>'id'
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = $1
+			)
+		) AS "s"
+		WHERE "s"."available" <= "s"."threshold"
+		ON CONFLICT ("product_id") DO NOTHING
+			RETURNING *
+	)
+	SELECT
+		"i"."product_id",
+		"p"."title",
+		"i"."available",
+		"i"."threshold",
+		to_char("i"."created_at", 'YYYY-MM-DD"T"HH24:MI:SS"Z"') AS "alerted_at"
+	FROM "inserted" "i"
+		JOIN "products" "p" ON "p"."id" = "i"."product_id"
+	ORDER BY "i"."product_id";`
+
+	alerts := make([]*inventory.LowStock, 0)
+	if err := r.db.SelectContext(ctx, &alerts, query, orderId, threshold); err != nil {
+		return nil, fmt.Errorf("mark low stock failed: %v", err)
+	}
+	return alerts, nil
+}
+
+// FindLowStock list every product of the store at or below its threshold, lowest available first
+func (r *inventoryRepository) FindLowStock(ctx context.Context, storeId string, threshold int) ([]*inventory.LowStock, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"s"."product_id",
+		"s"."title",
+		"s"."available",
+		"s"."threshold",
+		to_char("a"."created_at", 'YYYY-MM-DD"T"HH24:MI:SS"Z"') AS "alerted_at"
+	FROM (
+		SELECT
+			"p"."id" AS "product_id",
+			"p"."title",
+			available_stock("p"."id") AS "available",
+			COALESCE("p"."low_stock_threshold", $1) AS "threshold"
+		FROM "products" "p"
+		WHERE COALESCE("p"."store_id", '') = $2
+	) AS "s"
+		LEFT JOIN "low_stock_alerts" "a" ON "a"."product_id" = "s"."product_id"
+	WHERE "s"."available" <= "s"."threshold"
+	ORDER BY "s"."available" ASC, "s"."product_id" ASC;`
+
+	items := make([]*inventory.LowStock, 0)
+	if err := r.db.SelectContext(ctx, &items, query, threshold, storeId); err != nil {
+		return nil, fmt.Errorf("find low stock failed: %v", err)
+	}
+	return items, nil
+}
+
+func (r *inventoryRepository) UpdateLowStockThreshold(ctx context.Context, req *inventory.LowStockThresholdReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "products" SET
+		"low_stock_threshold" = $1
+	WHERE "id" = $2
+	AND COALESCE("store_id", '') = $3;`, req.Threshold, req.ProductId, req.StoreId)
+	if err != nil {
+		return fmt.Errorf("update low stock threshold failed: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update low stock threshold failed: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package inventoryRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+	"github.com/jmoiron/sqlx"
+)
+
+// MarkMovement tell the trigger of the products table what the next stock changes of the transaction are, it is
+// called by every repository which change the stock before its update
+func MarkMovement(ctx context.Context, tx *sqlx.Tx, m *inventory.MovementContext) error {
+	if _, err := tx.ExecContext(ctx, `
+	SELECT
+		set_config('ri.movement_kind', $1, true),
+		set_config('ri.movement_reason', $2, true),
+		set_config('ri.movement_actor', $3, true),
+		set_config('ri.movement_order', $4, true);`, m.Kind, m.Reason, m.ActorId, m.OrderId); err != nil {
+		return fmt.Errorf("mark stock movement failed: %v", err)
+	}
+	return nil
+}
+
+const movementColumns = `
+		"m"."id",
+		"m"."product_id",
+		"m"."kind",
+		"m"."qty",
+		"m"."stock_after",
+		"m"."reason",
+		"m"."actor_id",
+		COALESCE("m"."order_id", '') AS "order_id",
+		to_char("m"."created_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "created_at"`
+
+// FindMovement list the movements of the products of the store, newest first
+func (r *inventoryRepository) FindMovement(ctx context.Context, req *inventory.MovementFilter) ([]*inventory.Movement, int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	where := `
+	WHERE COALESCE("p"."store_id", '') = $1
+	AND ($2 = '' OR "m"."product_id" = $2)
+	AND ($3 = '' OR "m"."order_id" = $3)
+	AND ($4 = '' OR "m"."kind"::TEXT = $4)
+	AND ($5 = '' OR "m"."created_at" >= $5::DATE)
+	AND ($6 = '' OR "m"."created_at" < $6::DATE + 1)`
+	args := []any{req.StoreId, req.ProductId, req.OrderId, req.Kind, req.StartDate, req.EndDate}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `
+	SELECT
+		COUNT(*)
+	FROM "inventory_movements" "m"
+		JOIN "products" "p" ON "p"."id" = "m"."product_id"`+where+`;`, args...); err != nil {
+		return nil, 0, fmt.Errorf("count movements failed: %v", err)
+	}
+
+	list := make([]*inventory.Movement, 0)
+	if err := r.db.SelectContext(ctx, &list, `
+	SELECT`+movementColumns+`
+	FROM "inventory_movements" "m"
+		JOIN "products" "p" ON "p"."id" = "m"."product_id"`+where+`
+	ORDER BY "m"."id" DESC
+	OFFSET $7 LIMIT $8;`, append(args, (req.Page-1)*req.Limit, req.Limit)...); err != nil {
+		return nil, 0, fmt.Errorf("get movements failed: %v", err)
+	}
+	return list, total, nil
+}
+
+// InsertMovement add qty to the stock of the product, the movement is recorded by the trigger with the kind and
+// the reason of the request
+func (r *inventoryRepository) InsertMovement(ctx context.Context, req *inventory.MovementReq) (*inventory.Movement, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := locks.LockStock(ctx, tx, req.ProductId); err != nil {
+		return nil, err
+	}
+
+	var stock int
+	if err := tx.GetContext(ctx, &stock, `
+	SELECT
+		"stock"
+	FROM "products"
+	WHERE "id" = $1
+	AND COALESCE("store_id", '') = $2
+	FOR UPDATE;`, req.ProductId, req.StoreId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("get stock failed: %v", err)
+	}
+	if stock+req.Qty < 0 {
+		return nil, fmt.Errorf("stock can not be negative")
+	}
+
+	if err := MarkMovement(ctx, tx, &inventory.MovementContext{
+		Kind:    req.Kind,
+		Reason:  req.Reason,
+		ActorId: req.ActorId,
+	}); err != nil {
+		return nil, err
+	}
+	// version เพิ่มด้วยเพื่อให้ PATCH ที่อ่าน stock เก่าไว้ถูกปฏิเสธ
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "products" SET
+		"stock" = "stock" + $2,
+		"version" = "version" + 1
+	WHERE "id" = $1;`, req.ProductId, req.Qty); err != nil {
+		return nil, fmt.Errorf("update stock failed: %v", err)
+	}
+
+	movement := new(inventory.Movement)
+	if err := tx.GetContext(ctx, movement, `
+	SELECT`+movementColumns+`
+	FROM "inventory_movements" "m"
+	WHERE "m"."product_id" = $1
+	ORDER BY "m"."id" DESC
+	LIMIT 1;`, req.ProductId); err != nil {
+		return nil, fmt.Errorf("get movement failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit stock movement failed: %v", err)
+	}
+	return movement, nil
+}
+
+// FindReconcile compare the stock of the products of the store with the sum of their movements, the reservations
+// are not counted because they do not change the stock
+func (r *inventoryRepository) FindReconcile(ctx context.Context, req *inventory.ReconcileReq) ([]*inventory.Reconcile, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	SELECT
+		"s"."product_id",
+		"s"."title",
+		"s"."stock",
+		"s"."ledger",
+		"s"."stock" - "s"."ledger" AS "difference"
+	FROM (
+		SELECT
+			"p"."id" AS "product_id",
+			"p"."title",
+			"p"."stock",
+			COALESCE((
+				SELECT
+					SUM("m"."qty")
+				FROM "inventory_movements" "m"
+				WHERE "m"."product_id" = "p"."id"
+				AND "m"."kind" <> 'reservation'
+			), 0)::INT AS "ledger"
+		FROM "products" "p"
+		WHERE COALESCE("p"."store_id", '') = $1
+		AND ($2 = '' OR "p"."id" = $2)
+	) AS "s"
+	WHERE ($3 = FALSE OR "s"."stock" <> "s"."ledger")
+	ORDER BY ABS("s"."stock" - "s"."ledger") DESC, "s"."product_id" ASC;`
+
+	items := make([]*inventory.Reconcile, 0)
+	if err := r.db.SelectContext(ctx, &items, query, req.StoreId, req.ProductId, req.Mismatched); err != nil {
+		return nil, fmt.Errorf("find reconcile failed: %v", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,171 @@
+package inventoryUsecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/google/uuid"
+)
+
+const (
+	// number of days of order history used to compute sales velocity
+	forecastWindowDays = 30
+	// hold of a stock reservation when the caller does not give one
+	reservationTtl    = 15 * time.Minute
+	reservationMaxTtl = 24 * time.Hour
+	// hold of a checkout, the customer has to place the order before it expires
+	checkoutTtl = 10 * time.Minute
+)
+
+type IInventoryUsecase interface {
+	RefreshForecast(ctx context.Context) error
+	FindReorderSuggestion(ctx context.Context, req *inventory.ReorderFilter) ([]*inventory.ReorderSuggestion, error)
+	FindSupplier(ctx context.Context, storeId string) ([]*inventory.Supplier, error)
+	AddSupplier(ctx context.Context, req *inventory.Supplier) (*inventory.Supplier, error)
+	UpdateProductSupplier(ctx context.Context, req *inventory.ProductSupplierReq) error
+	ReserveStock(ctx context.Context, req *inventory.Reservation) (*inventory.Reservation, error)
+	ReleaseStock(ctx context.Context, reference string) (int, error)
+	StartCheckout(ctx context.Context, req *inventory.CheckoutReq) (*inventory.Reservation, error)
+	DeleteExpiredReservation(ctx context.Context) error
+	CheckLowStock(ctx context.Context, orderId string) ([]*inventory.LowStock, error)
+	FindLowStock(ctx context.Context, storeId string) ([]*inventory.LowStock, error)
+	UpdateLowStockThreshold(ctx context.Context, req *inventory.LowStockThresholdReq) error
+	FindMovement(ctx context.Context, req *inventory.MovementFilter) (*entities.PageRes, error)
+	AddMovement(ctx context.Context, req *inventory.MovementReq) (*inventory.Movement, error)
+	FindReconcile(ctx context.Context, req *inventory.ReconcileReq) ([]*inventory.Reconcile, error)
+}
+
+type inventoryUsecase struct {
+	cfg                  config.IInventoryConfig
+	inventoryRepository  inventoryRepositories.IInventoryRepository
+	notificationsUsecase notificationsUsecases.INotificationsUsecase
+}
+
+func InventoryUsecase(cfg config.IInventoryConfig, inventoryRepository inventoryRepositories.IInventoryRepository, notificationsUsecase notificationsUsecases.INotificationsUsecase) IInventoryUsecase {
+	return &inventoryUsecase{
+		cfg:                  cfg,
+		inventoryRepository:  inventoryRepository,
+		notificationsUsecase: notificationsUsecase,
+	}
+}
+
+func (u *inventoryUsecase) RefreshForecast(ctx context.Context) error {
+	if err := u.inventoryRepository.RefreshForecast(ctx, forecastWindowDays); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *inventoryUsecase) FindReorderSuggestion(ctx context.Context, req *inventory.ReorderFilter) ([]*inventory.ReorderSuggestion, error) {
+	suggestions, err := u.inventoryRepository.FindReorderSuggestion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+func (u *inventoryUsecase) FindSupplier(ctx context.Context, storeId string) ([]*inventory.Supplier, error) {
+	suppliers, err := u.inventoryRepository.FindSupplier(ctx, storeId)
+	if err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+func (u *inventoryUsecase) AddSupplier(ctx context.Context, req *inventory.Supplier) (*inventory.Supplier, error) {
+	if err := u.inventoryRepository.InsertSupplier(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *inventoryUsecase) UpdateProductSupplier(ctx context.Context, req *inventory.ProductSupplierReq) error {
+	if err := u.inventoryRepository.UpdateProductSupplier(ctx, req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *inventoryUsecase) ReserveStock(ctx context.Context, req *inventory.Reservation) (*inventory.Reservation, error) {
+	req.Reference = strings.TrimSpace(req.Reference)
+	if req.Reference == "" {
+		return nil, fmt.Errorf("reference is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items are empty")
+	}
+
+	// รวม product เดียวกันเป็นรายการเดียว
+	qty := make(map[string]int)
+	items := make([]*inventory.StockItem, 0)
+	for _, item := range req.Items {
+		if item.Qty <= 0 {
+			return nil, fmt.Errorf("qty of product %s must be more than 0", item.ProductId)
+		}
+		if _, ok := qty[item.ProductId]; !ok {
+			items = append(items, &inventory.StockItem{ProductId: item.ProductId})
+		}
+		qty[item.ProductId] += item.Qty
+	}
+	for _, item := range items {
+		item.Qty = qty[item.ProductId]
+	}
+	req.Items = items
+
+	if req.TtlSeconds <= 0 {
+		req.TtlSeconds = int(reservationTtl.Seconds())
+	}
+	if req.TtlSeconds > int(reservationMaxTtl.Seconds()) {
+		return nil, fmt.Errorf("ttl must be at most %d seconds", int(reservationMaxTtl.Seconds()))
+	}
+
+	if err := u.inventoryRepository.ReserveStock(ctx, req); err != nil {
+		var alert *inventory.StockAlert
+		if errors.As(err, &alert) {
+			events.Publish(inventory.EventStockAlert, alert)
+		}
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *inventoryUsecase) ReleaseStock(ctx context.Context, reference string) (int, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return 0, fmt.Errorf("reference is required")
+	}
+	return u.inventoryRepository.ReleaseStock(ctx, reference)
+}
+
+// StartCheckout hold the items for checkoutTtl, an earlier checkout of the customer is released first
+// so abandoned carts do not keep stock
+func (u *inventoryUsecase) StartCheckout(ctx context.Context, req *inventory.CheckoutReq) (*inventory.Reservation, error) {
+	if err := u.inventoryRepository.ReleaseCheckout(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
+	return u.ReserveStock(ctx, &inventory.Reservation{
+		Reference:  inventory.CheckoutPrefix + uuid.NewString(),
+		Items:      req.Items,
+		TtlSeconds: int(checkoutTtl.Seconds()),
+		UserId:     req.UserId,
+		StoreId:    &req.StoreId,
+	})
+}
+
+// DeleteExpiredReservation remove the holds of abandoned checkouts once they expire
+func (u *inventoryUsecase) DeleteExpiredReservation(ctx context.Context) error {
+	if _, err := u.inventoryRepository.DeleteExpiredReservation(ctx); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package inventoryUsecases
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/google/uuid"
+)
+
+const alertWebhookTimeout = 10 * time.Second
+
+// CheckLowStock alert the products of a placed order which fell to their threshold, a product is
+// alerted once until its stock is back above the threshold. Alerts go to the admin feed, the
+// INVENTORY_ALERT_EMAILS and the INVENTORY_ALERT_WEBHOOK_URL
+func (u *inventoryUsecase) CheckLowStock(ctx context.Context, orderId string) ([]*inventory.LowStock, error) {
+	alerts, err := u.inventoryRepository.MarkLowStock(ctx, orderId, u.cfg.LowStockThreshold())
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return alerts, nil
+	}
+
+	for _, alert := range alerts {
+		events.Publish(inventory.EventLowStock, alert)
+	}
+	u.emailLowStock(ctx, alerts)
+	if err := u.postLowStock(ctx, alerts); err != nil {
+		log.Printf("post low stock alert of order %s failed: %v\n", orderId, err)
+	}
+	return alerts, nil
+}
+
+func (u *inventoryUsecase) FindLowStock(ctx context.Context, storeId string) ([]*inventory.LowStock, error) {
+	return u.inventoryRepository.FindLowStock(ctx, storeId, u.cfg.LowStockThreshold())
+}
+
+func (u *inventoryUsecase) UpdateLowStockThreshold(ctx context.Context, req *inventory.LowStockThresholdReq) error {
+	if req.Threshold != nil && *req.Threshold < 0 {
+		return fmt.Errorf("threshold must not be negative")
+	}
+	return u.inventoryRepository.UpdateLowStockThreshold(ctx, req)
+}
+
+// emailLowStock queue one email per recipient with every product of the order
+func (u *inventoryUsecase) emailLowStock(ctx context.Context, alerts []*inventory.LowStock) {
+	if len(u.cfg.AlertEmails()) == 0 {
+		return
+	}
+
+	rows := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		rows = append(rows, fmt.Sprintf(
+			"<tr><td>%s</td><td>%s</td><td>%d</td><td>%d</td></tr>",
+			html.EscapeString(alert.ProductId),
+			html.EscapeString(alert.Title),
+			alert.Available,
+			alert.Threshold,
+		))
+	}
+	body := fmt.Sprintf(
+		"<p>These products are low on stock.</p><table><tr><th>Product</th><th>Title</th><th>Available</th><th>Threshold</th></tr>%s</table>",
+		strings.Join(rows, ""),
+	)
+
+	for _, to := range u.cfg.AlertEmails() {
+		if _, err := u.notificationsUsecase.SendEmail(ctx, &notifications.Email{
+			To:      to,
+			Subject: fmt.Sprintf("Low stock: %d products", len(alerts)),
+			Body:    body,
+		}); err != nil {
+			log.Printf("email low stock alert to %s failed: %v\n", to, err)
+		}
+	}
+}
+
+// postLowStock sign the body like the inbound webhooks: hex HMAC-SHA256 of <timestamp>.<nonce>.<body>.
+// It is sent once, a failed post is only logged and the admin endpoint still list the products
+func (u *inventoryUsecase) postLowStock(ctx context.Context, alerts []*inventory.LowStock) error {
+	if u.cfg.AlertWebhookUrl() == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(&struct {
+		Event string                `json:"event"`
+		Items []*inventory.LowStock `json:"items"`
+	}{
+		Event: inventory.EventLowStock,
+		Items: alerts,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, alertWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.cfg.AlertWebhookUrl(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := uuid.NewString()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Nonce", nonce)
+	if secret := u.cfg.AlertWebhookSecret(); secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + nonce + "."))
+		mac.Write(body)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package inventoryUsecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+)
+
+func (u *inventoryUsecase) FindMovement(ctx context.Context, req *inventory.MovementFilter) (*entities.PageRes, error) {
+	req.Kind = strings.TrimSpace(req.Kind)
+	if req.Kind != "" && !inventory.ValidMovementKind(req.Kind) {
+		return nil, fmt.Errorf("kind is invalid")
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("date must be YYYY-MM-DD")
+		}
+	}
+	if req.StartDate != "" && req.EndDate != "" && req.EndDate < req.StartDate {
+		return nil, fmt.Errorf("end date is before start date")
+	}
+
+	list, total, err := u.inventoryRepository.FindMovement(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return entities.NewPage(list, entities.NewPagination(req.Page, req.Limit, total)), nil
+}
+
+// AddMovement change the stock by hand, a sale, a reservation or a refund is only recorded by the order which
+// made it
+func (u *inventoryUsecase) AddMovement(ctx context.Context, req *inventory.MovementReq) (*inventory.Movement, error) {
+	req.ProductId = strings.TrimSpace(req.ProductId)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.ProductId == "" {
+		return nil, fmt.Errorf("product id is required")
+	}
+	if req.Kind != inventory.MovementRestock && req.Kind != inventory.MovementAdjustment {
+		return nil, fmt.Errorf("kind must be restock or adjustment")
+	}
+	if req.Qty == 0 {
+		return nil, fmt.Errorf("qty must not be 0")
+	}
+	if req.Kind == inventory.MovementRestock && req.Qty < 0 {
+		return nil, fmt.Errorf("qty of a restock must be more than 0")
+	}
+	if req.Reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	return u.inventoryRepository.InsertMovement(ctx, req)
+}
+
+func (u *inventoryUsecase) FindReconcile(ctx context.Context, req *inventory.ReconcileReq) ([]*inventory.Reconcile, error) {
+	req.ProductId = strings.TrimSpace(req.ProductId)
+	return u.inventoryRepository.FindReconcile(ctx, req)
+}
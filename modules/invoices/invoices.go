@@ -0,0 +1,17 @@
+package invoices
+
+// Invoice is the PDF of a paid order, the file is private and Url is a signed url which expire at ExpiresAt
+type Invoice struct {
+	OrderId     string `json:"order_id" db:"order_id"`
+	Number      string `json:"number" db:"number"`
+	Destination string `json:"-" db:"destination"`
+	Url         string `json:"url"`
+	ExpiresAt   string `json:"expires_at"`
+	CreatedAt   string `json:"created_at" db:"created_at"`
+}
+
+type InvoiceReq struct {
+	StoreId string
+	UserId  string
+	OrderId string
+}
@@ -0,0 +1,78 @@
+package invoicesHandlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/invoices"
+	"github.com/NatthawutSK/ri-shop/modules/invoices/invoicesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+type invoicesHandlerErrCode string
+
+const (
+	findInvoiceErr invoicesHandlerErrCode = "invoices-001"
+)
+
+type IInvoicesHandler interface {
+	FindInvoice(c *fiber.Ctx) error
+	GenerateOnPaid(ctx context.Context, e *events.Event)
+}
+
+type invoicesHandler struct {
+	invoicesUsecase invoicesUsecases.IInvoicesUsecase
+}
+
+func InvoicesHandler(invoicesUsecase invoicesUsecases.IInvoicesUsecase) IInvoicesHandler {
+	return &invoicesHandler{
+		invoicesUsecase: invoicesUsecase,
+	}
+}
+
+func (h *invoicesHandler) FindInvoice(c *fiber.Ctx) error {
+	invoice, err := h.invoicesUsecase.FindInvoice(c.UserContext(), &invoices.InvoiceReq{
+		StoreId: c.Locals("storeId").(string),
+		UserId:  strings.Trim(c.Params("user_id"), " "),
+		OrderId: strings.Trim(c.Params("order_id"), " "),
+	})
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findInvoiceErr),
+				err.Error(),
+			).Res()
+		case "order is not paid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findInvoiceErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findInvoiceErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, invoice).Res()
+}
+
+// GenerateOnPaid make the invoice when an order is paid, an invoice which fail here is made when it is
+// first asked for
+func (h *invoicesHandler) GenerateOnPaid(ctx context.Context, e *events.Event) {
+	payload, ok := e.Payload.(*orders.OrderEvent)
+	if !ok || payload.Status != orders.StatusPaid {
+		return
+	}
+	if _, err := h.invoicesUsecase.GenerateInvoice(ctx, payload.OrderId); err != nil {
+		log.Printf("generate invoice of order %s failed: %v\n", payload.OrderId, err)
+	}
+}
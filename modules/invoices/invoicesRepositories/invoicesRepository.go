@@ -0,0 +1,84 @@
+package invoicesRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/invoices"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IInvoicesRepository interface {
+	FindInvoice(ctx context.Context, orderId string) (*invoices.Invoice, error)
+	NextNumber(ctx context.Context) (string, error)
+	InsertInvoice(ctx context.Context, req *invoices.Invoice) (*invoices.Invoice, error)
+}
+
+type invoicesRepository struct {
+	db *sqlx.DB
+}
+
+func InvoicesRepository(db *sqlx.DB) IInvoicesRepository {
+	return &invoicesRepository{
+		db: db,
+	}
+}
+
+func (r *invoicesRepository) FindInvoice(ctx context.Context, orderId string) (*invoices.Invoice, error) {
+	query := `
+	SELECT
+		"order_id",
+		"number",
+		"destination",
+		"created_at"
+	FROM "invoices"
+	WHERE "order_id" = $1;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	invoice := new(invoices.Invoice)
+	if err := r.db.GetContext(ctx, invoice, query, orderId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("invoice not found")
+		}
+		return nil, fmt.Errorf("find invoice failed: %v", err)
+	}
+	return invoice, nil
+}
+
+// NextNumber reserve an invoice number, a number which is not used leave a gap
+func (r *invoicesRepository) NextNumber(ctx context.Context) (string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var number string
+	if err := r.db.GetContext(ctx, &number, `SELECT CONCAT('INV', LPAD(NEXTVAL('invoices_number_seq')::TEXT, 6, '0'));`); err != nil {
+		return "", fmt.Errorf("reserve invoice number failed: %v", err)
+	}
+	return number, nil
+}
+
+// InsertInvoice keep the first invoice of the order, the invoice which is already there is returned
+// when two are made at the same time
+func (r *invoicesRepository) InsertInvoice(ctx context.Context, req *invoices.Invoice) (*invoices.Invoice, error) {
+	query := `
+	INSERT INTO "invoices" (
+		"order_id",
+		"number",
+		"destination"
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ("order_id") DO NOTHING;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, req.OrderId, req.Number, req.Destination); err != nil {
+		return nil, fmt.Errorf("insert invoice failed: %v", err)
+	}
+	return r.FindInvoice(ctx, req.OrderId)
+}
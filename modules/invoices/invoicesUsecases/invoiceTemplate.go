@@ -0,0 +1,153 @@
+package invoicesUsecases
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+)
+
+// invoiceTemplate is printed with Courier, pad and lpad keep the columns aligned
+const invoiceTemplate = `{{.Seller}}
+INVOICE {{.Number}}
+
+Order     {{.OrderId}}
+Date      {{.Date}}
+Bill to   {{.Contact}}
+{{range .Address}}          {{.}}
+{{end}}
+{{line}}
+{{pad "ITEM" 38}} {{lpad "QTY" 5}} {{lpad "UNIT PRICE" 12}} {{lpad "TAX" 6}} {{lpad "AMOUNT" 12}}
+{{line}}
+{{range .Lines}}{{pad .Title 38}} {{lpad .Qty 5}} {{lpad (money .UnitPrice) 12}} {{lpad (percent .TaxRate) 6}} {{lpad (money .Amount) 12}}
+{{end}}{{line}}
+{{lpad "Subtotal" 64}} {{lpad (money .Subtotal) 12}}
+{{if .Discount}}{{lpad "Discount" 64}} {{lpad (printf "-%s" (money .Discount)) 12}}
+{{end}}{{lpad "Shipping" 64}} {{lpad (money .ShippingFee) 12}}
+{{range .Taxes}}{{lpad .Name 64}} {{lpad (money .Amount) 12}}
+{{end}}{{lpad (printf "Total (%s)" .Currency) 64}} {{lpad (money .Total) 12}}
+`
+
+var invoiceTmpl = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"line": func() string { return strings.Repeat("-", 77) },
+	"pad": func(s string, n int) string {
+		if r := []rune(s); len(r) > n {
+			s = strings.TrimRight(string(r[:n-1]), " ") + "~"
+		}
+		return fmt.Sprintf("%-*s", n, s)
+	},
+	"lpad": func(v any, n int) string {
+		return fmt.Sprintf("%*v", n, v)
+	},
+	"money":   func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"percent": func(v float64) string { return strconv.FormatFloat(math.Round(v*10000)/100, 'f', -1, 64) + "%" },
+}).Parse(invoiceTemplate))
+
+type invoiceView struct {
+	Seller      string
+	Number      string
+	OrderId     string
+	Date        string
+	Contact     string
+	Address     []string
+	Currency    string
+	Lines       []*invoiceLine
+	Subtotal    float64
+	Discount    float64
+	ShippingFee float64
+	Taxes       []*invoiceTax
+	Total       float64
+}
+
+type invoiceLine struct {
+	Title     string
+	Qty       int
+	UnitPrice float64
+	TaxRate   float64
+	Amount    float64
+}
+
+type invoiceTax struct {
+	Name   string
+	Amount float64
+}
+
+// InvoiceText render the invoice of the order as the text of the PDF, prices are the prices kept on the order
+func InvoiceText(seller, number string, order *orders.Order, issuedAt time.Time) (string, error) {
+	view := &invoiceView{
+		Seller:      seller,
+		Number:      number,
+		OrderId:     order.Id,
+		Date:        issuedAt.Format("2006-01-02"),
+		Contact:     order.Contact,
+		Address:     strings.Split(order.Address, "\n"),
+		Lines:       make([]*invoiceLine, 0, len(order.Products)),
+		Discount:    order.Discount,
+		ShippingFee: order.ShippingFee,
+		Taxes:       make([]*invoiceTax, 0),
+	}
+	if a := order.ShippingAddress; a != nil {
+		view.Address = []string{a.Recipient, a.Line1}
+		if a.Line2 != "" {
+			view.Address = append(view.Address, a.Line2)
+		}
+		view.Address = append(view.Address, strings.TrimSpace(fmt.Sprintf("%s %s %s", a.City, a.State, a.PostalCode)), a.Country)
+	}
+
+	// tax ของแต่ละ line เรียงตาม products ตอนสั่ง, order เก่าไม่มี tax
+	taxRates := make(map[string]float64)
+	taxes := make(map[string]float64)
+	names := make([]string, 0)
+	if order.Tax != nil {
+		for _, line := range order.Tax.Lines {
+			taxRates[line.ProductId] = line.Rate
+			name := line.Name
+			if name == "" {
+				name = "Tax"
+			}
+			if _, ok := taxes[name]; !ok {
+				names = append(names, name)
+			}
+			taxes[name] += line.Tax
+		}
+		if order.Tax.ShippingTax > 0 {
+			if _, ok := taxes["Shipping tax"]; !ok {
+				names = append(names, "Shipping tax")
+			}
+			taxes["Shipping tax"] += order.Tax.ShippingTax
+		}
+	}
+
+	for _, item := range order.Products {
+		if item.Product == nil {
+			continue
+		}
+		if view.Currency == "" {
+			view.Currency = item.Product.Currency
+		}
+		line := &invoiceLine{
+			Title:     item.Product.Title,
+			Qty:       item.Qty,
+			UnitPrice: item.Product.Price,
+			TaxRate:   taxRates[item.Product.Id],
+			Amount:    item.Product.Price * float64(item.Qty),
+		}
+		view.Lines = append(view.Lines, line)
+		view.Subtotal += line.Amount
+	}
+	for _, name := range names {
+		view.Taxes = append(view.Taxes, &invoiceTax{Name: name, Amount: taxes[name]})
+	}
+	view.Total = order.TotalPaid
+
+	out := new(bytes.Buffer)
+	if err := invoiceTmpl.Execute(out, view); err != nil {
+		return "", fmt.Errorf("render invoice failed: %v", err)
+	}
+	return out.String(), nil
+}
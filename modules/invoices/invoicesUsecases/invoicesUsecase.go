@@ -0,0 +1,134 @@
+package invoicesUsecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/invoices"
+	"github.com/NatthawutSK/ri-shop/modules/invoices/invoicesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/pdf"
+)
+
+// invoiceUrlTtl is how long the signed url of an invoice can be used
+const invoiceUrlTtl = 15 * time.Minute
+
+type IInvoicesUsecase interface {
+	GenerateInvoice(ctx context.Context, orderId string) (*invoices.Invoice, error)
+	FindInvoice(ctx context.Context, req *invoices.InvoiceReq) (*invoices.Invoice, error)
+}
+
+type invoicesUsecase struct {
+	cfg                config.IConfig
+	invoicesRepository invoicesRepositories.IInvoicesRepository
+	ordersRepository   ordersRepositories.IOrdersRepository
+	filesUsecase       filesUsecases.IFilesUsecase
+}
+
+func InvoicesUsecase(cfg config.IConfig, invoicesRepository invoicesRepositories.IInvoicesRepository, ordersRepository ordersRepositories.IOrdersRepository, filesUsecase filesUsecases.IFilesUsecase) IInvoicesUsecase {
+	return &invoicesUsecase{
+		cfg:                cfg,
+		invoicesRepository: invoicesRepository,
+		ordersRepository:   ordersRepository,
+		filesUsecase:       filesUsecase,
+	}
+}
+
+// isPaid report whether the order has been paid, a canceled order has no invoice
+func isPaid(status string) bool {
+	switch status {
+	case orders.StatusPaid, orders.StatusShipping, orders.StatusReadyForPickup, orders.StatusCompleted:
+		return true
+	}
+	return false
+}
+
+func (u *invoicesUsecase) findOrder(ctx context.Context, orderId string) (*orders.Order, error) {
+	order, err := u.ordersRepository.FindOneOrder(ctx, orderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// GenerateInvoice make the PDF of a paid order and keep it, an order has only one invoice
+func (u *invoicesUsecase) GenerateInvoice(ctx context.Context, orderId string) (*invoices.Invoice, error) {
+	order, err := u.findOrder(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	return u.generateInvoice(ctx, order)
+}
+
+func (u *invoicesUsecase) generateInvoice(ctx context.Context, order *orders.Order) (*invoices.Invoice, error) {
+	if invoice, err := u.invoicesRepository.FindInvoice(ctx, order.Id); err == nil {
+		return invoice, nil
+	} else if err.Error() != "invoice not found" {
+		return nil, err
+	}
+	if !isPaid(order.Status) {
+		return nil, fmt.Errorf("order is not paid")
+	}
+
+	number, err := u.invoicesRepository.NextNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	text, err := InvoiceText(u.cfg.App().Name(), number, order, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := u.filesUsecase.UploadPrivate(ctx, &files.PrivateFileReq{
+		StoreId:     order.StoreId,
+		Destination: fmt.Sprintf("invoices/%s.pdf", number),
+		ContentType: "application/pdf",
+		Data:        pdf.FromText(text, 9).Bytes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.invoicesRepository.InsertInvoice(ctx, &invoices.Invoice{
+		OrderId:     order.Id,
+		Number:      number,
+		Destination: destination,
+	})
+}
+
+// FindInvoice return the invoice of an order of the user with a signed url, the invoice is made now when
+// making it after the payment failed
+func (u *invoicesUsecase) FindInvoice(ctx context.Context, req *invoices.InvoiceReq) (*invoices.Invoice, error) {
+	order, err := u.findOrder(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	// order ของ user อื่นหรือ store อื่นตอบเหมือนไม่มี order
+	if order.UserId != req.UserId || order.StoreId != req.StoreId {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	invoice, err := u.generateInvoice(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(invoiceUrlTtl)
+	url, err := u.filesUsecase.SignDownload(ctx, invoice.Destination, invoiceUrlTtl)
+	if err != nil {
+		return nil, err
+	}
+	invoice.Url = url
+	invoice.ExpiresAt = expiresAt.Format(time.RFC3339)
+	return invoice, nil
+}
@@ -4,3 +4,13 @@ type Role struct {
 	Id    int    `json:"id" db:"id"`
 	Title string `json:"title" db:"title"`
 }
+
+// reasons a webhook is rejected by WebhookAuth, kept in webhook_rejections
+const (
+	WebhookNotConfigured    = "not_configured"
+	WebhookInvalidTimestamp = "invalid_timestamp"
+	WebhookExpired          = "expired"
+	WebhookInvalidNonce     = "invalid_nonce"
+	WebhookInvalidSignature = "invalid_signature"
+	WebhookReplayed         = "replayed"
+)
@@ -1,19 +1,36 @@
 package middlewaresHandlers
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/middlewares"
 	"github.com/NatthawutSK/ri-shop/modules/middlewares/middlewaresUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/logfile"
 	riAuth "github.com/NatthawutSK/ri-shop/pkg/riauth"
 	"github.com/NatthawutSK/ri-shop/pkg/utils"
+	"github.com/NatthawutSK/ri-shop/pkg/websocket"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type middlewareHandlersErrCode string
@@ -24,49 +41,135 @@ const (
 	paramsCheckErr middlewareHandlersErrCode = "middleware-003"
 	authorizeErr   middlewareHandlersErrCode = "middleware-004"
 	apiKeyErr      middlewareHandlersErrCode = "middleware-005"
+	chaosErr       middlewareHandlersErrCode = "middleware-006"
+	previewErr     middlewareHandlersErrCode = "middleware-007"
+	webhookErr     middlewareHandlersErrCode = "middleware-008"
+	storeErr       middlewareHandlersErrCode = "middleware-009"
+	bodyLimitErr   middlewareHandlersErrCode = "middleware-010"
+	timeoutErr     middlewareHandlersErrCode = "middleware-011"
 )
 
 type IMiddlewaresHandler interface {
 	Cors() fiber.Handler
+	SecurityHeaders() fiber.Handler
+	StaticHeaders() fiber.Handler
 	RouterCheck() fiber.Handler
 	Logger() fiber.Handler
+	Store() fiber.Handler
+	BodyLimit() fiber.Handler
+	Timeout() fiber.Handler
 	JwtAuth() fiber.Handler
+	QueryToken() fiber.Handler
+	Preview() fiber.Handler
+	CacheControl(group string) fiber.Handler
 	ParamsCheck() fiber.Handler
 	Authorize(expectRoleId ...int) fiber.Handler
 	ApiKeyAuth() fiber.Handler
-	StreamingFile() fiber.Handler
+	Compress() fiber.Handler
+	Chaos() fiber.Handler
+	WebhookAuth(source string) fiber.Handler
+	GrpcApiKeyAuth() grpc.UnaryServerInterceptor
 }
 
 type middlewaresHandler struct {
 	cfg                config.IConfig
 	middlewaresUsecase middlewaresUsecases.IMiddlewaresUsecase
+	accessLog          *logfile.Writer
 }
 
-func MiddlewaresHandler(cfg config.IConfig, usecase middlewaresUsecases.IMiddlewaresUsecase) IMiddlewaresHandler {
+// MiddlewaresHandler log the requests to accessLog as json lines, or to stdout when it is nil
+func MiddlewaresHandler(cfg config.IConfig, usecase middlewaresUsecases.IMiddlewaresUsecase, accessLog *logfile.Writer) IMiddlewaresHandler {
 	return &middlewaresHandler{
 		cfg:                cfg,
 		middlewaresUsecase: usecase,
+		accessLog:          accessLog,
 	}
 }
 
-func (h *middlewaresHandler) StreamingFile() fiber.Handler {
-	return filesystem.New(filesystem.Config{
-		Root: http.Dir("./assets/images"),
+// Compress use brotli, gzip or deflate from Accept-Encoding. Streams (sse, websocket) are not compressed
+// because the compressor hold the events until its buffer is full, range requests because Content-Range
+// is the position in the uncompressed file
+func (h *middlewaresHandler) Compress() fiber.Handler {
+	return compress.New(compress.Config{
+		Next: func(c *fiber.Ctx) bool {
+			return strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream") ||
+				websocket.IsUpgrade(c) ||
+				c.Get(fiber.HeaderRange) != ""
+		},
+		Level: compress.Level(h.cfg.App().CompressLevel()),
 	})
 }
 
-func (h *middlewaresHandler) Cors() fiber.Handler {
+// apiVersion is the prefix of a versioned route, e.g. /v2/
+var apiVersion = regexp.MustCompile(`^/v[0-9]+/`)
+
+// routeGroup is the first segment after the version, the group of the per group settings is the same on
+// every version
+func routeGroup(c *fiber.Ctx) string {
+	return strings.SplitN(apiVersion.ReplaceAllString(c.Path(), ""), "/", 2)[0]
+}
+
+func newCors(policy *config.CorsPolicy) fiber.Handler {
 	return cors.New(cors.Config{
 		Next:             cors.ConfigDefault.Next,
-		AllowOrigins:     "*",
-		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH",
+		AllowOrigins:     policy.Origins,
+		AllowMethods:     policy.Methods,
 		AllowHeaders:     "",
-		AllowCredentials: false,
-		ExposeHeaders:    "",
-		MaxAge:           0,
+		AllowCredentials: policy.Credentials,
+		ExposeHeaders:    "Deprecation, Sunset, Link", // of the deprecated api versions
+		MaxAge:           policy.MaxAge,
 	})
 }
 
+// Cors use the policy of the route group, e.g. CORS_ORIGINS_USERS, or the default policy
+func (h *middlewaresHandler) Cors() fiber.Handler {
+	fallback := newCors(h.cfg.Security().Cors(""))
+	groups := make(map[string]fiber.Handler)
+	for _, group := range h.cfg.Security().CorsGroups() {
+		groups[group] = newCors(h.cfg.Security().Cors(group))
+	}
+
+	return func(c *fiber.Ctx) error {
+		if handler, ok := groups[strings.ToLower(routeGroup(c))]; ok {
+			return handler(c)
+		}
+		return fallback(c)
+	}
+}
+
+// SecurityHeaders set HSTS and nosniff on every response
+func (h *middlewaresHandler) SecurityHeaders() fiber.Handler {
+	hsts := ""
+	if maxAge := h.cfg.Security().HstsMaxAge(); maxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", maxAge)
+		if h.cfg.Security().HstsSubdomains() {
+			hsts += "; includeSubDomains"
+		}
+	}
+	nosniff := h.cfg.Security().Nosniff()
+
+	return func(c *fiber.Ctx) error {
+		if hsts != "" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		if nosniff {
+			c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		}
+		return c.Next()
+	}
+}
+
+// StaticHeaders set the Content-Security-Policy of the static files, an uploaded file can not run script
+func (h *middlewaresHandler) StaticHeaders() fiber.Handler {
+	csp := h.cfg.Security().StaticCsp()
+	return func(c *fiber.Ctx) error {
+		if csp != "" {
+			c.Set(fiber.HeaderContentSecurityPolicy, csp)
+		}
+		return c.Next()
+	}
+}
+
 func (h *middlewaresHandler) RouterCheck() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		return entities.NewResponse(c).Error(
@@ -78,6 +181,9 @@ func (h *middlewaresHandler) RouterCheck() fiber.Handler {
 }
 
 func (h *middlewaresHandler) Logger() fiber.Handler {
+	if h.accessLog != nil {
+		return h.accessLogger()
+	}
 	return logger.New(logger.Config{
 		Format:     "${time} [${ip}] ${status} - ${method} ${path}\n",
 		TimeFormat: "02/01/2006",
@@ -85,6 +191,110 @@ func (h *middlewaresHandler) Logger() fiber.Handler {
 	})
 }
 
+// accessLine is a line of the access log, one json object per request for filebeat
+type accessLine struct {
+	Time      string  `json:"time"`
+	Ip        string  `json:"ip"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Route     string  `json:"route"` // e.g. /v1/products/:productId, to group the requests of a route
+	Status    int     `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	BytesIn   int     `json:"bytes_in"`
+	BytesOut  int     `json:"bytes_out"`
+	UserAgent string  `json:"user_agent,omitempty"`
+	UserId    string  `json:"user_id,omitempty"`
+	StoreId   string  `json:"store_id,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// accessLogger write a json line per request to the access log, the query is not logged as it may carry a token
+func (h *middlewaresHandler) accessLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		chainErr := c.Next()
+		line := &accessLine{Time: start.UTC().Format(time.RFC3339Nano)}
+		// ให้ error handler เขียน response ก่อนเพื่อให้ได้ status จริง เหมือน logger ของ fiber
+		if chainErr != nil {
+			line.Error = chainErr.Error()
+			if err := c.App().ErrorHandler(c, chainErr); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		line.Ip = c.IP()
+		line.Method = c.Method()
+		line.Path = c.Path()
+		line.Route = c.Route().Path
+		line.Status = c.Response().StatusCode()
+		line.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		line.BytesIn = len(c.Request().Body())
+		line.BytesOut = len(c.Response().Body())
+		line.UserAgent = c.Get(fiber.HeaderUserAgent)
+		line.UserId, _ = c.Locals("userId").(string)
+		line.StoreId, _ = c.Locals("storeId").(string)
+
+		bytes, err := json.Marshal(line)
+		if err == nil {
+			_, _ = h.accessLog.Write(append(bytes, '\n'))
+		}
+		return nil
+	}
+}
+
+// Timeout give the request the deadline of its route group in c.UserContext(), the queries and the calls
+// to the bucket which get it stop at the deadline. A read which took longer, or a write which failed after
+// it, is answered 504. A write which succeeded late is kept as its change is already made
+func (h *middlewaresHandler) Timeout() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		read := c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead
+		budget := h.cfg.Timeout().Budget(routeGroup(c), read)
+		if budget <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), budget)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+		if !read && err == nil && c.Response().StatusCode() < fiber.StatusInternalServerError {
+			return nil
+		}
+		c.Response().ResetBody()
+		return entities.NewResponse(c).Error(
+			fiber.StatusGatewayTimeout,
+			string(timeoutErr),
+			fmt.Sprintf("request took longer than %s", budget),
+		).Res()
+	}
+}
+
+// BodyLimit reject a body larger than the limit of the route group, the group is the first segment after /v1,
+// e.g. BODY_LIMIT_FILES for /v1/files/upload
+func (h *middlewaresHandler) BodyLimit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := h.cfg.Upload().BodyLimit(routeGroup(c))
+
+		// chunked body ไม่มี Content-Length ต้องดูจาก body ที่อ่านมาแล้ว
+		size := c.Request().Header.ContentLength()
+		if size < 0 {
+			size = len(c.Request().Body())
+		}
+		if size > limit {
+			return entities.NewResponse(c).Error(
+				fiber.StatusRequestEntityTooLarge,
+				string(bodyLimitErr),
+				fmt.Sprintf("request body must be at most %d bytes", limit),
+			).Res()
+		}
+		return c.Next()
+	}
+}
+
 func (h *middlewaresHandler) JwtAuth() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		token := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
@@ -99,7 +309,7 @@ func (h *middlewaresHandler) JwtAuth() fiber.Handler {
 		fmt.Println(result.Claims)
 
 		claims := result.Claims
-		if !h.middlewaresUsecase.FindAccessToken(claims.Id, token) {
+		if !h.middlewaresUsecase.FindAccessToken(c.UserContext(), claims.Id, token) {
 			return entities.NewResponse(c).Error(
 				fiber.ErrUnauthorized.Code,
 				string(jwtAuthErr),
@@ -107,6 +317,15 @@ func (h *middlewaresHandler) JwtAuth() fiber.Handler {
 			).Res()
 		}
 
+		// a user only act in their own store
+		if storeId, _ := c.Locals("storeId").(string); claims.StoreId != storeId {
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(jwtAuthErr),
+				"no permission to access this store",
+			).Res()
+		}
+
 		// set UserId
 		c.Locals("userId", claims.Id)
 		c.Locals("userRoleId", claims.RoleId)
@@ -114,11 +333,121 @@ func (h *middlewaresHandler) JwtAuth() fiber.Handler {
 	}
 }
 
+// Store resolve the store of the request from the X-Store-Id header, or the subdomain of APP_STORE_DOMAIN.
+// It is kept in the storeId local, empty for the default store
+func (h *middlewaresHandler) Store() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		storeId := strings.ToLower(strings.TrimSpace(c.Get("X-Store-Id")))
+		if domain := h.cfg.App().StoreDomain(); storeId == "" && domain != "" {
+			if sub, ok := strings.CutSuffix(strings.ToLower(c.Hostname()), "."+domain); ok && sub != "www" {
+				storeId = sub
+			}
+		}
+
+		if storeId != "" {
+			found, err := h.middlewaresUsecase.FindStore(c.UserContext(), storeId)
+			if err != nil {
+				return entities.NewResponse(c).Error(
+					fiber.ErrInternalServerError.Code,
+					string(storeErr),
+					err.Error(),
+				).Res()
+			}
+			if !found {
+				return entities.NewResponse(c).Error(
+					fiber.ErrNotFound.Code,
+					string(storeErr),
+					"store not found",
+				).Res()
+			}
+		}
+
+		c.Locals("storeId", storeId)
+		return c.Next()
+	}
+}
+
+// QueryToken let a client which cannot set headers, e.g. the websocket of browsers, send the access token
+// in ?access_token, it must come before JwtAuth
+func (h *middlewaresHandler) QueryToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("access_token")
+		if token != "" && c.Get(fiber.HeaderAuthorization) == "" {
+			c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		// ไม่ให้ token ไปอยู่ใน log
+		c.Request().URI().QueryArgs().Del("access_token")
+		return c.Next()
+	}
+}
+
+// Preview accept a preview token in X-Preview-Token or ?preview_token, it is optional but an invalid token is
+// rejected so the reviewer knows the page is not a preview. The time of the preview is in the previewAt local
+func (h *middlewaresHandler) Preview() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get("X-Preview-Token")
+		if token == "" {
+			token = c.Query("preview_token")
+		}
+		c.Request().URI().QueryArgs().Del("preview_token")
+		if token == "" {
+			return c.Next()
+		}
+
+		claims, err := riAuth.ParsePreviewToken(h.cfg.Jwt(), token)
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(previewErr),
+				err.Error(),
+			).Res()
+		}
+
+		c.Locals("previewAt", claims.At.Time)
+		return c.Next()
+	}
+}
+
+// CacheControl set the Cache-Control of the route group on successful responses which did not set their own
+func (h *middlewaresHandler) CacheControl(group string) fiber.Handler {
+	control := h.cfg.Cache().Control(group)
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		// preview เห็น draft ห้ามเก็บใน cache
+		if _, preview := c.Locals("previewAt").(time.Time); preview {
+			c.Set(fiber.HeaderCacheControl, "private, no-store")
+			return err
+		}
+		status := c.Response().StatusCode()
+		if (status == fiber.StatusOK || status == fiber.StatusNotModified) && len(c.Response().Header.Peek(fiber.HeaderCacheControl)) == 0 {
+			c.Set(fiber.HeaderCacheControl, control)
+		}
+		return err
+	}
+}
+
 // ป้องกันการเข้าถึงข้อมูลของคนอื่น ต้องมาคู่กับ JwtAuth
 func (h *middlewaresHandler) ParamsCheck() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userId := c.Locals("userId")
+		// admin เข้าถึงได้เฉพาะ user ของร้านตัวเอง
 		if c.Locals("userRoleId").(int) == 2 {
+			found, err := h.middlewaresUsecase.FindUserInStore(c.UserContext(), c.Params("user_id"), c.Locals("storeId").(string))
+			if err != nil {
+				return entities.NewResponse(c).Error(
+					fiber.ErrInternalServerError.Code,
+					string(paramsCheckErr),
+					err.Error(),
+				).Res()
+			}
+			if !found {
+				return entities.NewResponse(c).Error(
+					fiber.ErrNotFound.Code,
+					string(paramsCheckErr),
+					"user not found",
+				).Res()
+			}
 			return c.Next()
 		}
 		if c.Params("user_id") != userId {
@@ -144,7 +473,7 @@ func (h *middlewaresHandler) Authorize(expectRoleId ...int) fiber.Handler {
 			).Res()
 		}
 
-		roles, err := h.middlewaresUsecase.FindRole()
+		roles, err := h.middlewaresUsecase.FindRole(c.UserContext())
 		if err != nil {
 			return entities.NewResponse(c).Error(
 				fiber.ErrInternalServerError.Code,
@@ -173,6 +502,10 @@ func (h *middlewaresHandler) Authorize(expectRoleId ...int) fiber.Handler {
 
 		for i := range userValueBinary {
 			if userValueBinary[i] == 1 && expectedValueBinary[i] == 1 {
+				// error message ของ endpoint ที่ต้อง authorize จะแปลเป็นภาษาที่ user ตั้งไว้
+				if userId, ok := c.Locals("userId").(string); ok {
+					c.Locals("locale", h.middlewaresUsecase.FindUserLocale(c.UserContext(), userId))
+				}
 				return c.Next()
 			}
 		}
@@ -198,3 +531,142 @@ func (h *middlewaresHandler) ApiKeyAuth() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// GrpcApiKeyAuth is ApiKeyAuth of the grpc server, the key is sent in the x-api-key metadata
+func (h *middlewaresHandler) GrpcApiKeyAuth() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-api-key")) > 0 {
+			key = md.Get("x-api-key")[0]
+		}
+		if _, err := riAuth.ParseApiKey(h.cfg.Jwt(), key); err != nil {
+			return nil, status.Error(codes.Unauthenticated, "api key is invalid")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Chaos inject latency, random 5xx and dependency failures on the routes of CHAOS_ROUTES,
+// a fault can also be forced with the X-Chaos header (latency, error or dependency)
+func (h *middlewaresHandler) Chaos() fiber.Handler {
+	cfg := h.cfg.Chaos()
+	errorCodes := []int{
+		fiber.StatusInternalServerError,
+		fiber.StatusBadGateway,
+		fiber.StatusServiceUnavailable,
+		fiber.StatusGatewayTimeout,
+	}
+	dependencies := []string{"database", "storage", "payment gateway"}
+
+	return func(c *fiber.Ctx) error {
+		// ไม่ว่า config จะเป็นยังไง production ต้องไม่โดน
+		if !cfg.Enabled() || h.cfg.App().IsProduction() {
+			return c.Next()
+		}
+
+		if len(cfg.Routes()) > 0 {
+			matched := false
+			for _, route := range cfg.Routes() {
+				if strings.HasPrefix(c.Path(), route) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return c.Next()
+			}
+		}
+
+		forced := strings.ToLower(c.Get("X-Chaos"))
+
+		if cfg.Latency() > 0 && (forced == "" || forced == "latency") {
+			delay := time.Duration(rand.Int63n(int64(cfg.Latency())))
+			if forced == "latency" {
+				delay = cfg.Latency()
+			}
+			time.Sleep(delay)
+		}
+
+		if forced == "dependency" || (forced == "" && rand.Float64() < cfg.DependencyFailureRate()) {
+			c.Set("X-Chaos-Injected", "dependency")
+			return entities.NewResponse(c).Error(
+				fiber.ErrServiceUnavailable.Code,
+				string(chaosErr),
+				fmt.Sprintf("%s is unavailable", dependencies[rand.Intn(len(dependencies))]),
+			).Res()
+		}
+
+		if forced == "error" || (forced == "" && rand.Float64() < cfg.ErrorRate()) {
+			c.Set("X-Chaos-Injected", "error")
+			code := errorCodes[rand.Intn(len(errorCodes))]
+			return entities.NewResponse(c).Error(
+				code,
+				string(chaosErr),
+				http.StatusText(code),
+			).Res()
+		}
+
+		return c.Next()
+	}
+}
+
+// WebhookAuth protect an inbound webhook of source (payments, carriers) from forged and replayed requests.
+// The sender sign "<timestamp>.<nonce>.<body>" with HMAC-SHA256 and the secret of the source, and sends
+// X-Webhook-Timestamp (unix seconds), X-Webhook-Nonce and X-Webhook-Signature (hex)
+func (h *middlewaresHandler) WebhookAuth(source string) fiber.Handler {
+	cfg := h.cfg.Webhook()
+
+	return func(c *fiber.Ctx) error {
+		reject := func(reason string) error {
+			h.middlewaresUsecase.RejectWebhook(c.UserContext(), source, reason, c.IP())
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(webhookErr),
+				fmt.Sprintf("webhook is rejected: %s", reason),
+			).Res()
+		}
+
+		secret := cfg.Secret(source)
+		if secret == "" {
+			return reject(middlewares.WebhookNotConfigured)
+		}
+
+		timestamp := c.Get("X-Webhook-Timestamp")
+		sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return reject(middlewares.WebhookInvalidTimestamp)
+		}
+		// นาฬิกาของผู้ส่งอาจเร็วกว่าเล็กน้อย จึงเช็คทั้งสองทาง
+		if age := time.Since(time.Unix(sentAt, 0)); age > cfg.Tolerance() || age < -cfg.Tolerance() {
+			return reject(middlewares.WebhookExpired)
+		}
+
+		nonce := c.Get("X-Webhook-Nonce")
+		if nonce == "" || len(nonce) > 128 {
+			return reject(middlewares.WebhookInvalidNonce)
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + nonce + "."))
+		mac.Write(c.Body())
+		signature, err := hex.DecodeString(strings.TrimPrefix(c.Get("X-Webhook-Signature"), "sha256="))
+		if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+			return reject(middlewares.WebhookInvalidSignature)
+		}
+
+		// nonce ถูกบันทึกหลังเช็ค signature แล้วเท่านั้น คนอื่นจึงจอง nonce ล่วงหน้าไม่ได้
+		fresh, err := h.middlewaresUsecase.UseWebhookNonce(c.UserContext(), source, nonce)
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(webhookErr),
+				err.Error(),
+			).Res()
+		}
+		if !fresh {
+			return reject(middlewares.WebhookReplayed)
+		}
+
+		return c.Next()
+	}
+}
@@ -1,15 +1,22 @@
 package middlewaresRepositories
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/NatthawutSK/ri-shop/modules/middlewares"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
 	"github.com/jmoiron/sqlx"
 )
 
 type IMiddlewaresRepository interface {
-	FindAccessToken(userId, accessToken string) bool
-	FindRole() ([]*middlewares.Role, error)
+	FindAccessToken(ctx context.Context, userId, accessToken string) bool
+	FindRole(ctx context.Context) ([]*middlewares.Role, error)
+	FindUserLocale(ctx context.Context, userId string) string
+	InsertWebhookNonce(ctx context.Context, source, nonce string) (bool, error)
+	InsertWebhookRejection(ctx context.Context, source, reason, ip string) error
+	FindStore(ctx context.Context, storeId string) (bool, error)
+	FindUserInStore(ctx context.Context, userId, storeId string) (bool, error)
 }
 
 type middlewaresRepository struct {
@@ -24,7 +31,7 @@ func MiddlewaresRepository(db *sqlx.DB) IMiddlewaresRepository {
 }
 
 
-func (r *middlewaresRepository) FindAccessToken(userId, accessToken string) bool {
+func (r *middlewaresRepository) FindAccessToken(ctx context.Context, userId, accessToken string) bool {
 	query := `
 	SELECT
 		(CASE WHEN COUNT(*) = 1 THEN TRUE ELSE FALSE END)
@@ -32,15 +39,18 @@ func (r *middlewaresRepository) FindAccessToken(userId, accessToken string) bool
 	WHERE "user_id" = $1
 	AND "access_token" = $2;`
 
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
 	var check bool
-	if err := r.db.Get(&check, query, userId, accessToken); err != nil {
+	if err := r.db.GetContext(ctx, &check, query, userId, accessToken); err != nil {
 		return false
 	}
 	return true
 }
 
 
-func (r *middlewaresRepository) FindRole() ([]*middlewares.Role, error) {
+func (r *middlewaresRepository) FindRole(ctx context.Context) ([]*middlewares.Role, error) {
 	query := `
 	SELECT
 		"id",
@@ -48,9 +58,101 @@ func (r *middlewaresRepository) FindRole() ([]*middlewares.Role, error) {
 	FROM "roles"
 	ORDER BY "id" DESC;`
 
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
 	roles := make([]*middlewares.Role, 0)
-	if err := r.db.Select(&roles, query); err != nil {
+	if err := r.db.SelectContext(ctx, &roles, query); err != nil {
 		return nil, fmt.Errorf("role are empty")
 	}
 	return roles, nil
-}
\ No newline at end of file
+}
+
+func (r *middlewaresRepository) FindUserLocale(ctx context.Context, userId string) string {
+	query := `
+	SELECT
+		"locale"
+	FROM "users"
+	WHERE "id" = $1;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var locale string
+	if err := r.db.GetContext(ctx, &locale, query, userId); err != nil {
+		return ""
+	}
+	return locale
+}
+
+// InsertWebhookNonce return false when the nonce of the source has been used
+func (r *middlewaresRepository) InsertWebhookNonce(ctx context.Context, source, nonce string) (bool, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "webhook_nonces" (
+		"source",
+		"nonce"
+	)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;`
+
+	result, err := r.db.ExecContext(ctx, query, source, nonce)
+	if err != nil {
+		return false, fmt.Errorf("insert webhook nonce failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected failed: %v", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+func (r *middlewaresRepository) InsertWebhookRejection(ctx context.Context, source, reason, ip string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "webhook_rejections" (
+		"source",
+		"reason",
+		"ip"
+	)
+	VALUES ($1, $2, $3);`
+
+	if _, err := r.db.ExecContext(ctx, query, source, reason, ip); err != nil {
+		return fmt.Errorf("insert webhook rejection failed: %v", err)
+	}
+	return nil
+}
+
+// FindStore return false when there is no store of the id
+func (r *middlewaresRepository) FindStore(ctx context.Context, storeId string) (bool, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var found bool
+	if err := r.db.GetContext(ctx, &found, `SELECT EXISTS (SELECT 1 FROM "stores" WHERE "id" = $1);`, storeId); err != nil {
+		return false, fmt.Errorf("find store failed: %v", err)
+	}
+	return found, nil
+}
+
+// FindUserInStore return false when the user does not exist or belongs to another store
+func (r *middlewaresRepository) FindUserInStore(ctx context.Context, userId, storeId string) (bool, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var found bool
+	if err := r.db.GetContext(ctx, &found, `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "users"
+		WHERE "id" = $1
+		AND COALESCE("store_id", '') = $2
+	);`, userId, storeId); err != nil {
+		return false, fmt.Errorf("find user of store failed: %v", err)
+	}
+	return found, nil
+}
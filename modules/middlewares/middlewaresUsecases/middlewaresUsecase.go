@@ -1,33 +1,82 @@
 package middlewaresUsecases
 
 import (
+	"context"
+	"log"
+	"time"
+
 	"github.com/NatthawutSK/ri-shop/modules/middlewares"
 	"github.com/NatthawutSK/ri-shop/modules/middlewares/middlewaresRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
 )
 
 type IMiddlewaresUsecase interface {
-	FindAccessToken(userId, accessToken string) bool
-	FindRole() ([]*middlewares.Role, error)
+	FindAccessToken(ctx context.Context, userId, accessToken string) bool
+	FindRole(ctx context.Context) ([]*middlewares.Role, error)
+	FindUserLocale(ctx context.Context, userId string) string
+	UseWebhookNonce(ctx context.Context, source, nonce string) (bool, error)
+	RejectWebhook(ctx context.Context, source, reason, ip string)
+	FindStore(ctx context.Context, storeId string) (bool, error)
+	FindUserInStore(ctx context.Context, userId, storeId string) (bool, error)
 }
 
+// every request resolve its store, a store is looked up again after storeTtl
+const storeTtl = time.Minute
+
 type middlewaresUsecase struct {
 	middlewareRepository middlewaresRepositories.IMiddlewaresRepository
+	stores               *cache.Cache[bool]
 }
 
 func MiddlewaresUsecase(middlewareRepository middlewaresRepositories.IMiddlewaresRepository) IMiddlewaresUsecase {
 	return &middlewaresUsecase{
 		middlewareRepository: middlewareRepository,
+		stores:               cache.New[bool](storeTtl),
 	}
 }
 
-func (u *middlewaresUsecase) FindAccessToken(userId, accessToken string) bool {
-	return u.middlewareRepository.FindAccessToken(userId, accessToken)
+func (u *middlewaresUsecase) FindAccessToken(ctx context.Context, userId, accessToken string) bool {
+	return u.middlewareRepository.FindAccessToken(ctx, userId, accessToken)
 }
 
-func (u *middlewaresUsecase) FindRole() ([]*middlewares.Role, error) {
-	role, err := u.middlewareRepository.FindRole()
+func (u *middlewaresUsecase) FindRole(ctx context.Context) ([]*middlewares.Role, error) {
+	role, err := u.middlewareRepository.FindRole(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return role, nil
-}
\ No newline at end of file
+}
+
+func (u *middlewaresUsecase) FindUserLocale(ctx context.Context, userId string) string {
+	return u.middlewareRepository.FindUserLocale(ctx, userId)
+}
+
+// UseWebhookNonce return false when the webhook has been received before
+func (u *middlewaresUsecase) UseWebhookNonce(ctx context.Context, source, nonce string) (bool, error) {
+	return u.middlewareRepository.InsertWebhookNonce(ctx, source, nonce)
+}
+
+// RejectWebhook log the rejected webhook, it is counted by the webhook metrics
+func (u *middlewaresUsecase) RejectWebhook(ctx context.Context, source, reason, ip string) {
+	log.Printf("webhook %s from %s rejected: %s\n", source, ip, reason)
+	if err := u.middlewareRepository.InsertWebhookRejection(ctx, source, reason, ip); err != nil {
+		log.Printf("%v\n", err)
+	}
+}
+
+func (u *middlewaresUsecase) FindStore(ctx context.Context, storeId string) (bool, error) {
+	if found, ok := u.stores.Get(storeId); ok {
+		return found, nil
+	}
+
+	found, err := u.middlewareRepository.FindStore(ctx, storeId)
+	if err != nil {
+		return false, err
+	}
+	u.stores.Set(storeId, found)
+	return found, nil
+}
+
+func (u *middlewaresUsecase) FindUserInStore(ctx context.Context, userId, storeId string) (bool, error) {
+	return u.middlewareRepository.FindUserInStore(ctx, userId, storeId)
+}
@@ -3,4 +3,43 @@ package monitor
 type Monitor struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
-}
\ No newline at end of file
+}
+// DbMetrics is the connection pool at the time of the request, wait_count and wait_ms grow when
+// the pool is too small for the traffic
+type DbMetrics struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitMs             int64 `json:"wait_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+	SlowQueries        int64 `json:"slow_queries"`
+}
+
+// RuntimeStats is the memory and the goroutines of the process, heap_inuse_bytes which keeps growing
+// after gc is a leak
+type RuntimeStats struct {
+	GoVersion       string  `json:"go_version"`
+	NumCpu          int     `json:"num_cpu"`
+	UptimeSeconds   int64   `json:"uptime_seconds"`
+	Goroutines      int     `json:"goroutines"`
+	HeapAllocBytes  uint64  `json:"heap_alloc_bytes"`
+	HeapInuseBytes  uint64  `json:"heap_inuse_bytes"`
+	HeapIdleBytes   uint64  `json:"heap_idle_bytes"`
+	HeapObjects     uint64  `json:"heap_objects"`
+	SysBytes        uint64  `json:"sys_bytes"`
+	TotalAllocBytes uint64  `json:"total_alloc_bytes"`
+	NumGc           uint32  `json:"num_gc"`
+	GcPauseTotalMs  float64 `json:"gc_pause_total_ms"`
+	LastGcAt        string  `json:"last_gc_at,omitempty"`
+}
+
+// DebugDump is where the profiles of a dump are written on the server
+type DebugDump struct {
+	At        string `json:"at"`
+	Heap      string `json:"heap"`
+	Goroutine string `json:"goroutine"`
+}
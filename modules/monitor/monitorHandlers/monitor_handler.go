@@ -1,24 +1,61 @@
 package monitorHandlers
 
 import (
+	"fmt"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/monitor"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+	"github.com/NatthawutSK/ri-shop/pkg/workerpool"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/jmoiron/sqlx"
+)
+
+type monitorHandlersErrCode string
+
+const (
+	readinessCheckErr monitorHandlersErrCode = "monitor-001"
+	debugDumpErr      monitorHandlersErrCode = "monitor-002"
 )
 
+// the dumps stay on the server, the pod is not restarted when it is copied out with kubectl cp
+var dumpDir = filepath.Join(os.TempDir(), "ri-shop-dumps")
+
+var startedAt = time.Now()
+
 type IMonitorHandlers interface {
 	HealthCheck(c *fiber.Ctx) error
+	ReadinessCheck(c *fiber.Ctx) error
+	DbMetrics(c *fiber.Ctx) error
+	BreakerMetrics(c *fiber.Ctx) error
+	WorkerPoolMetrics(c *fiber.Ctx) error
+	Pprof(c *fiber.Ctx) error
+	RuntimeStats(c *fiber.Ctx) error
+	DebugDump(c *fiber.Ctx) error
 }
 
 type monitorHandlers struct {
-	cfg config.IConfig
+	cfg   config.IConfig
+	ready func() bool
+	db    *sqlx.DB
 }
 
 
-func MonitorHandler(cfg config.IConfig) IMonitorHandlers {
+func MonitorHandler(cfg config.IConfig, ready func() bool, db *sqlx.DB) IMonitorHandlers {
 	return &monitorHandlers{
 		cfg: cfg,
+		ready: ready,
+		db: db,
 	}
 }
 
@@ -28,4 +65,141 @@ func (h *monitorHandlers) HealthCheck(c *fiber.Ctx) error {
 		Version: h.cfg.App().Version(),
 	}
 	return entities.NewResponse(c).Success(fiber.StatusOK, res).Res()
-}
\ No newline at end of file
+}
+
+// ReadinessCheck is 503 until the caches are warmed, the load balancer only send traffic after it is 200
+func (h *monitorHandlers) ReadinessCheck(c *fiber.Ctx) error {
+	if !h.ready() {
+		return entities.NewResponse(c).Error(
+			fiber.ErrServiceUnavailable.Code,
+			string(readinessCheckErr),
+			"server is warming up",
+		).Res()
+	}
+	return h.HealthCheck(c)
+}
+
+func (h *monitorHandlers) DbMetrics(c *fiber.Ctx) error {
+	stats := h.db.Stats()
+	res := &monitor.DbMetrics{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitMs:             stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		SlowQueries:        databases.SlowQueries(),
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, res).Res()
+}
+
+// BreakerMetrics is the circuit breaker of every external dependency which was called since startup
+func (h *monitorHandlers) BreakerMetrics(c *fiber.Ctx) error {
+	return entities.NewResponse(c).Success(fiber.StatusOK, resilience.Statuses()).Res()
+}
+
+// WorkerPoolMetrics is the batches of every worker pool which ran since startup, running is the jobs in progress
+func (h *monitorHandlers) WorkerPoolMetrics(c *fiber.Ctx) error {
+	return entities.NewResponse(c).Success(fiber.StatusOK, workerpool.Statuses()).Res()
+}
+
+// the profiles of net/http/pprof which are not a runtime/pprof profile
+var pprofHandlers = map[string]fiber.Handler{
+	"cmdline": adaptor.HTTPHandlerFunc(pprof.Cmdline),
+	"profile": adaptor.HTTPHandlerFunc(pprof.Profile),
+	"symbol":  adaptor.HTTPHandlerFunc(pprof.Symbol),
+	"trace":   adaptor.HTTPHandlerFunc(pprof.Trace),
+}
+
+var pprofIndex = adaptor.HTTPHandlerFunc(pprof.Index)
+
+// Pprof serve net/http/pprof under /v1/monitor/debug/pprof, pprof.Index only find a profile under
+// /debug/pprof/ so the profile is taken from the route
+func (h *monitorHandlers) Pprof(c *fiber.Ctx) error {
+	name := c.Params("profile")
+	if name == "" {
+		// ลิงก์ใน index เป็น relative path ต้องจบด้วย /
+		if !strings.HasSuffix(c.Path(), "/") {
+			return c.Redirect(c.Path()+"/", fiber.StatusMovedPermanently)
+		}
+		return pprofIndex(c)
+	}
+	if handler, ok := pprofHandlers[name]; ok {
+		return handler(c)
+	}
+	return adaptor.HTTPHandler(pprof.Handler(name))(c)
+}
+
+func (h *monitorHandlers) RuntimeStats(c *fiber.Ctx) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	res := &monitor.RuntimeStats{
+		GoVersion:       runtime.Version(),
+		NumCpu:          runtime.NumCPU(),
+		UptimeSeconds:   int64(time.Since(startedAt).Seconds()),
+		Goroutines:      runtime.NumGoroutine(),
+		HeapAllocBytes:  mem.HeapAlloc,
+		HeapInuseBytes:  mem.HeapInuse,
+		HeapIdleBytes:   mem.HeapIdle,
+		HeapObjects:     mem.HeapObjects,
+		SysBytes:        mem.Sys,
+		TotalAllocBytes: mem.TotalAlloc,
+		NumGc:           mem.NumGC,
+		GcPauseTotalMs:  float64(mem.PauseTotalNs) / float64(time.Millisecond),
+	}
+	if mem.LastGC > 0 {
+		res.LastGcAt = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, res).Res()
+}
+
+// DebugDump write a heap profile and the stacks of every goroutine to files on the server, ?gc=true run a
+// gc first so the heap only has what is still referenced
+func (h *monitorHandlers) DebugDump(c *fiber.Ctx) error {
+	if c.QueryBool("gc") {
+		runtime.GC()
+	}
+
+	now := time.Now().UTC()
+	res := &monitor.DebugDump{
+		At:        now.Format(time.RFC3339),
+		Heap:      filepath.Join(dumpDir, fmt.Sprintf("heap-%s.pprof", now.Format("20060102T150405"))),
+		Goroutine: filepath.Join(dumpDir, fmt.Sprintf("goroutine-%s.txt", now.Format("20060102T150405"))),
+	}
+	if err := writeProfile("heap", res.Heap, 0); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(debugDumpErr),
+			err.Error(),
+		).Res()
+	}
+	// debug=2 เป็น stack เต็มของทุก goroutine อ่านได้โดยไม่ต้องใช้ go tool pprof
+	if err := writeProfile("goroutine", res.Goroutine, 2); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(debugDumpErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, res).Res()
+}
+
+func writeProfile(name, path string, debug int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create dump dir failed: %v", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s dump failed: %v", name, err)
+	}
+	defer file.Close()
+
+	if err := rpprof.Lookup(name).WriteTo(file, debug); err != nil {
+		return fmt.Errorf("write %s dump failed: %v", name, err)
+	}
+	return nil
+}
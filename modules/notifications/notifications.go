@@ -0,0 +1,106 @@
+package notifications
+
+import "github.com/NatthawutSK/ri-shop/modules/entities"
+
+const (
+	EmailQueued  = "queued"
+	EmailSending = "sending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+)
+
+type Email struct {
+	Id            string  `json:"id" db:"id"`
+	To            string  `json:"to" db:"to_address"`
+	Subject       string  `json:"subject" db:"subject"`
+	Body          string  `json:"body" db:"body"` // html
+	Status        string  `json:"status" db:"status"`
+	Attempts      int     `json:"attempts" db:"attempts"`
+	Provider      *string `json:"provider" db:"provider"` // provider which sent the email
+	LastError     *string `json:"last_error" db:"last_error"`
+	NextAttemptAt string  `json:"next_attempt_at" db:"next_attempt_at"`
+	SentAt        *string `json:"sent_at" db:"sent_at"`
+	CreatedAt     string  `json:"created_at" db:"created_at"`
+}
+
+// ProviderMetrics is counted since the server started
+type ProviderMetrics struct {
+	Provider    string `json:"provider"`
+	Sent        int    `json:"sent"`
+	Failed      int    `json:"failed"`
+	RateLimited int    `json:"rate_limited"` // skipped to the next provider because of the rate limit
+	BreakerOpen int    `json:"breaker_open"` // skipped because the provider failed too many times in a row
+	Breaker     string `json:"breaker"`      // closed, open or half_open
+	LastError   string `json:"last_error,omitempty"`
+	LastSentAt  string `json:"last_sent_at,omitempty"`
+}
+
+type EmailMetrics struct {
+	Providers []*ProviderMetrics `json:"providers"`
+	Queue     map[string]int     `json:"queue"` // emails per status
+}
+
+// Types of the notifications of a customer, each is the name of the event it comes from
+const (
+	TypeOrderCreated  = "order.created"
+	TypeOrderStatus   = "order.status_changed"
+	TypeOrderTracking = "order.tracking_updated"
+	TypeRefundIssued  = "refund.issued"
+	TypeBackInStock   = "product.back_in_stock" // a watched product, see modules/watches
+	TypePriceDrop     = "product.price_drop"
+)
+
+// Types is every type in the order of the preferences
+var Types = []string{TypeOrderCreated, TypeOrderStatus, TypeOrderTracking, TypeRefundIssued, TypeBackInStock, TypePriceDrop}
+
+// ValidType report whether t is one of the types
+func ValidType(t string) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Preference is the channels of a type, the in-app inbox always get the notification
+type Preference struct {
+	Type  string `json:"type" db:"event_type"`
+	Email bool   `json:"email" db:"email"`
+	Sms   bool   `json:"sms" db:"sms"`
+	Push  bool   `json:"push" db:"push"`
+}
+
+// DefaultPreference is the preference of a type the customer did not change, sms is opt in
+func DefaultPreference(t string) *Preference {
+	return &Preference{Type: t, Email: true, Sms: false, Push: true}
+}
+
+type PreferencesReq struct {
+	UserId      string        `json:"-"`
+	Preferences []*Preference `json:"preferences"`
+}
+
+// Notification is an entry of the in-app inbox, Link is the page of the storefront it is about
+type Notification struct {
+	Id        string  `json:"id" db:"id"`
+	UserId    string  `json:"-" db:"user_id"`
+	Type      string  `json:"type" db:"event_type"`
+	Title     string  `json:"title" db:"title"`
+	Body      string  `json:"body" db:"body"`
+	Link      string  `json:"link" db:"link"`
+	ReadAt    *string `json:"read_at" db:"read_at"`
+	CreatedAt string  `json:"created_at" db:"created_at"`
+}
+
+type InboxReq struct {
+	UserId string `json:"-"`
+	Unread bool   `query:"unread"` // only the unread notifications
+	*entities.PaginationReq
+}
+
+// Inbox is a page of the notifications with the unread count of the whole inbox, for the badge of the bell
+type Inbox struct {
+	*entities.PageRes
+	Unread int `json:"unread"`
+}
@@ -0,0 +1,145 @@
+package notificationsHandlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *notificationsHandler) FindInbox(c *fiber.Ctx) error {
+	req := &notifications.InboxReq{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findInboxErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 50 {
+		req.Limit = 20
+	}
+
+	inbox, err := h.notificationsUsecase.FindInbox(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findInboxErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, inbox).Res()
+}
+
+func (h *notificationsHandler) MarkRead(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	notificationId := strings.Trim(c.Params("notification_id"), " ")
+
+	if err := h.notificationsUsecase.MarkRead(c.UserContext(), userId, notificationId); err != nil {
+		if err.Error() == "notification not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(markReadErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(markReadErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+func (h *notificationsHandler) MarkAllRead(c *fiber.Ctx) error {
+	read, err := h.notificationsUsecase.MarkAllRead(c.UserContext(), strings.Trim(c.Params("user_id"), " "))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(markAllReadErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, &struct {
+		Read int `json:"read"`
+	}{Read: read}).Res()
+}
+
+func (h *notificationsHandler) FindPreference(c *fiber.Ctx) error {
+	preferences, err := h.notificationsUsecase.FindPreference(c.UserContext(), strings.Trim(c.Params("user_id"), " "))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findPreferenceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, preferences).Res()
+}
+
+func (h *notificationsHandler) UpdatePreference(c *fiber.Ctx) error {
+	req := new(notifications.PreferencesReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updatePreferenceErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+
+	preferences, err := h.notificationsUsecase.UpdatePreference(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "preferences are required", "notification type is invalid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updatePreferenceErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updatePreferenceErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, preferences).Res()
+}
+
+// NotifyOrder is the event subscriber of order events
+func (h *notificationsHandler) NotifyOrder(ctx context.Context, e *events.Event) {
+	payload, ok := e.Payload.(*orders.OrderEvent)
+	if !ok {
+		return
+	}
+	if err := h.notificationsUsecase.NotifyOrder(ctx, e.Name, payload); err != nil {
+		log.Printf("notify %s of order %s failed: %v\n", e.Name, payload.OrderId, err)
+	}
+}
+
+// NotifyRefund is the event subscriber of issued refunds
+func (h *notificationsHandler) NotifyRefund(ctx context.Context, e *events.Event) {
+	payload, ok := e.Payload.(*refunds.Refund)
+	if !ok {
+		return
+	}
+	if err := h.notificationsUsecase.NotifyRefund(ctx, payload); err != nil {
+		log.Printf("notify refund %s of order %s failed: %v\n", payload.Id, payload.OrderId, err)
+	}
+}
@@ -0,0 +1,94 @@
+package notificationsHandlers
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+type notificationsHandlerErrCode string
+
+const (
+	sendEmailErr        notificationsHandlerErrCode = "notifications-001"
+	findEmailMetricsErr notificationsHandlerErrCode = "notifications-002"
+	findInboxErr        notificationsHandlerErrCode = "notifications-003"
+	markReadErr         notificationsHandlerErrCode = "notifications-004"
+	markAllReadErr      notificationsHandlerErrCode = "notifications-005"
+	findPreferenceErr   notificationsHandlerErrCode = "notifications-006"
+	updatePreferenceErr notificationsHandlerErrCode = "notifications-007"
+)
+
+type INotificationsHandler interface {
+	SendEmail(c *fiber.Ctx) error
+	FindEmailMetrics(c *fiber.Ctx) error
+
+	// inbox.go
+	FindInbox(c *fiber.Ctx) error
+	MarkRead(c *fiber.Ctx) error
+	MarkAllRead(c *fiber.Ctx) error
+	FindPreference(c *fiber.Ctx) error
+	UpdatePreference(c *fiber.Ctx) error
+	NotifyOrder(ctx context.Context, e *events.Event)
+	NotifyRefund(ctx context.Context, e *events.Event)
+}
+
+type notificationsHandler struct {
+	cfg                  config.IConfig
+	notificationsUsecase notificationsUsecases.INotificationsUsecase
+}
+
+func NotificationsHandler(cfg config.IConfig, notificationsUsecase notificationsUsecases.INotificationsUsecase) INotificationsHandler {
+	return &notificationsHandler{
+		cfg:                  cfg,
+		notificationsUsecase: notificationsUsecase,
+	}
+}
+
+func (h *notificationsHandler) SendEmail(c *fiber.Ctx) error {
+	req := new(notifications.Email)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(sendEmailErr),
+			err.Error(),
+		).Res()
+	}
+
+	email, err := h.notificationsUsecase.SendEmail(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "email address is invalid", "subject is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(sendEmailErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(sendEmailErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusAccepted, email).Res()
+}
+
+func (h *notificationsHandler) FindEmailMetrics(c *fiber.Ctx) error {
+	metrics, err := h.notificationsUsecase.FindEmailMetrics(c.UserContext())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findEmailMetricsErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, metrics).Res()
+}
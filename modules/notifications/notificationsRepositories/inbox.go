@@ -0,0 +1,205 @@
+package notificationsRepositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+)
+
+func (r *notificationsRepository) InsertNotification(ctx context.Context, req *notifications.Notification) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "user_notifications" (
+		"user_id",
+		"event_type",
+		"title",
+		"body",
+		"link"
+	)
+	VALUES ($1, $2, $3, $4, $5)
+		RETURNING "id", "created_at";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.UserId, req.Type, req.Title, req.Body, req.Link).Scan(
+		&req.Id,
+		&req.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert notification failed: %v", err)
+	}
+	return nil
+}
+
+// FindNotification return a page of the inbox, newest first, with the total of the filter
+func (r *notificationsRepository) FindNotification(ctx context.Context, req *notifications.InboxReq) ([]*notifications.Notification, int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	where := `
+	WHERE "user_id" = $1
+	AND ($2 = FALSE OR "read_at" IS NULL)`
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `
+	SELECT
+		COUNT(*)
+	FROM "user_notifications"`+where+`;`, req.UserId, req.Unread); err != nil {
+		return nil, 0, fmt.Errorf("count notifications failed: %v", err)
+	}
+
+	list := make([]*notifications.Notification, 0)
+	if err := r.db.SelectContext(ctx, &list, `
+	SELECT
+		"id",
+		"user_id",
+		"event_type",
+		"title",
+		"body",
+		"link",
+		"read_at",
+		"created_at"
+	FROM "user_notifications"`+where+`
+	ORDER BY "created_at" DESC
+	OFFSET $3 LIMIT $4;`, req.UserId, req.Unread, (req.Page-1)*req.Limit, req.Limit); err != nil {
+		return nil, 0, fmt.Errorf("get notifications failed: %v", err)
+	}
+	return list, total, nil
+}
+
+func (r *notificationsRepository) CountUnread(ctx context.Context, userId string) (int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var unread int
+	if err := r.db.GetContext(ctx, &unread, `
+	SELECT
+		COUNT(*)
+	FROM "user_notifications"
+	WHERE "user_id" = $1
+	AND "read_at" IS NULL;`, userId); err != nil {
+		return 0, fmt.Errorf("count unread notifications failed: %v", err)
+	}
+	return unread, nil
+}
+
+// MarkRead keep the time it was first read, reading it again does nothing
+func (r *notificationsRepository) MarkRead(ctx context.Context, userId, notificationId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "user_notifications" SET
+		"read_at" = COALESCE("read_at", now())
+	WHERE "id"::TEXT = $1
+	AND "user_id" = $2;`, notificationId, userId)
+	if err != nil {
+		return fmt.Errorf("mark notification read failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
+
+func (r *notificationsRepository) MarkAllRead(ctx context.Context, userId string) (int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "user_notifications" SET
+		"read_at" = now()
+	WHERE "user_id" = $1
+	AND "read_at" IS NULL;`, userId)
+	if err != nil {
+		return 0, fmt.Errorf("mark notifications read failed: %v", err)
+	}
+	read, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("mark notifications read failed: %v", err)
+	}
+	return int(read), nil
+}
+
+// FindPreference return only the types the user changed
+func (r *notificationsRepository) FindPreference(ctx context.Context, userId string) ([]*notifications.Preference, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	preferences := make([]*notifications.Preference, 0)
+	if err := r.db.SelectContext(ctx, &preferences, `
+	SELECT
+		"event_type",
+		"email",
+		"sms",
+		"push"
+	FROM "notification_preferences"
+	WHERE "user_id" = $1;`, userId); err != nil {
+		return nil, fmt.Errorf("get notification preferences failed: %v", err)
+	}
+	return preferences, nil
+}
+
+func (r *notificationsRepository) UpsertPreference(ctx context.Context, userId string, preferences []*notifications.Preference) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO "notification_preferences" (
+		"user_id",
+		"event_type",
+		"email",
+		"sms",
+		"push"
+	)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT ("user_id", "event_type") DO UPDATE SET
+		"email" = EXCLUDED."email",
+		"sms" = EXCLUDED."sms",
+		"push" = EXCLUDED."push",
+		"updated_at" = now();`
+
+	for _, preference := range preferences {
+		if _, err := tx.ExecContext(ctx, query, userId, preference.Type, preference.Email, preference.Sms, preference.Push); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("update notification preference failed: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *notificationsRepository) FindUserEmail(ctx context.Context, userId string) (string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var email string
+	if err := r.db.GetContext(ctx, &email, `
+	SELECT
+		"email"
+	FROM "users"
+	WHERE "id" = $1;`, userId); err != nil {
+		return "", fmt.Errorf("get user email failed: %v", err)
+	}
+	return email, nil
+}
+
+func (r *notificationsRepository) FindOrderUserId(ctx context.Context, orderId string) (string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var userId string
+	if err := r.db.GetContext(ctx, &userId, `
+	SELECT
+		"user_id"
+	FROM "orders"
+	WHERE "id" = $1;`, orderId); err != nil {
+		return "", fmt.Errorf("get order failed: %v", err)
+	}
+	return userId, nil
+}
@@ -0,0 +1,208 @@
+package notificationsRepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type INotificationsRepository interface {
+	InsertEmail(ctx context.Context, req *notifications.Email) error
+	ClaimEmail(ctx context.Context, limit int) ([]*notifications.Email, error)
+	MarkEmailSent(ctx context.Context, emailId, provider string) error
+	MarkEmailRetry(ctx context.Context, emailId, lastError string, nextAttemptAt time.Time, failed bool) error
+	RequeueEmail(ctx context.Context, emailId string, nextAttemptAt time.Time) error
+	CountEmailByStatus(ctx context.Context) (map[string]int, error)
+	RetryFailedEmail(ctx context.Context) (int, error)
+
+	// inbox.go
+	InsertNotification(ctx context.Context, req *notifications.Notification) error
+	FindNotification(ctx context.Context, req *notifications.InboxReq) ([]*notifications.Notification, int, error)
+	CountUnread(ctx context.Context, userId string) (int, error)
+	MarkRead(ctx context.Context, userId, notificationId string) error
+	MarkAllRead(ctx context.Context, userId string) (int, error)
+	FindPreference(ctx context.Context, userId string) ([]*notifications.Preference, error)
+	UpsertPreference(ctx context.Context, userId string, preferences []*notifications.Preference) error
+	FindUserEmail(ctx context.Context, userId string) (string, error)
+	FindOrderUserId(ctx context.Context, orderId string) (string, error)
+}
+
+type notificationsRepository struct {
+	db *sqlx.DB
+}
+
+func NotificationsRepository(db *sqlx.DB) INotificationsRepository {
+	return &notificationsRepository{
+		db: db,
+	}
+}
+
+func (r *notificationsRepository) InsertEmail(ctx context.Context, req *notifications.Email) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "email_queue" (
+		"to_address",
+		"subject",
+		"body"
+	)
+	VALUES ($1, $2, $3)
+		RETURNING "id", "status", "next_attempt_at", "created_at";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.To, req.Subject, req.Body).Scan(
+		&req.Id,
+		&req.Status,
+		&req.NextAttemptAt,
+		&req.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert email failed: %v", err)
+	}
+	return nil
+}
+
+// ClaimEmail mark due emails as sending so another instance does not send them too, an email stuck in sending
+// (the server stopped while sending it) is claimed again after 5 minutes
+func (r *notificationsRepository) ClaimEmail(ctx context.Context, limit int) ([]*notifications.Email, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "email_queue" SET
+		"status" = 'sending'
+	WHERE "id" IN (
+		SELECT
+			"id"
+		FROM "email_queue"
+		WHERE ("status" = 'queued' AND "next_attempt_at" <= now())
+		OR ("status" = 'sending' AND "updated_at" < now() - INTERVAL '5 minutes')
+		ORDER BY "next_attempt_at" ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING
+		"id",
+		"to_address",
+		"subject",
+		"body",
+		"status",
+		"attempts",
+		"provider",
+		"last_error",
+		"next_attempt_at",
+		"sent_at",
+		"created_at";`
+
+	emails := make([]*notifications.Email, 0)
+	if err := r.db.SelectContext(ctx, &emails, query, limit); err != nil {
+		return nil, fmt.Errorf("claim emails failed: %v", err)
+	}
+	return emails, nil
+}
+
+func (r *notificationsRepository) MarkEmailSent(ctx context.Context, emailId, provider string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `
+	UPDATE "email_queue" SET
+		"status" = 'sent',
+		"attempts" = "attempts" + 1,
+		"provider" = $1,
+		"sent_at" = now()
+	WHERE "id" = $2;`, provider, emailId); err != nil {
+		return fmt.Errorf("mark email sent failed: %v", err)
+	}
+	return nil
+}
+
+// MarkEmailRetry count a failed attempt, the email is queued again at nextAttemptAt or failed for good
+func (r *notificationsRepository) MarkEmailRetry(ctx context.Context, emailId, lastError string, nextAttemptAt time.Time, failed bool) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	status := notifications.EmailQueued
+	if failed {
+		status = notifications.EmailFailed
+	}
+
+	if _, err := r.db.ExecContext(ctx, `
+	UPDATE "email_queue" SET
+		"status" = $1,
+		"attempts" = "attempts" + 1,
+		"last_error" = $2,
+		"next_attempt_at" = $3
+	WHERE "id" = $4;`, status, lastError, nextAttemptAt, emailId); err != nil {
+		return fmt.Errorf("mark email retry failed: %v", err)
+	}
+	return nil
+}
+
+// RequeueEmail put the email back without counting an attempt, used when every provider is rate limited
+func (r *notificationsRepository) RequeueEmail(ctx context.Context, emailId string, nextAttemptAt time.Time) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `
+	UPDATE "email_queue" SET
+		"status" = 'queued',
+		"next_attempt_at" = $1
+	WHERE "id" = $2;`, nextAttemptAt, emailId); err != nil {
+		return fmt.Errorf("requeue email failed: %v", err)
+	}
+	return nil
+}
+
+func (r *notificationsRepository) CountEmailByStatus(ctx context.Context) (map[string]int, error) {
+	rows := make([]*struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if err := r.db.SelectContext(ctx, &rows, `
+	SELECT
+		"status",
+		COUNT(*) AS "count"
+	FROM "email_queue"
+	GROUP BY "status";`); err != nil {
+		return nil, fmt.Errorf("count emails failed: %v", err)
+	}
+
+	counts := map[string]int{
+		notifications.EmailQueued:  0,
+		notifications.EmailSending: 0,
+		notifications.EmailSent:    0,
+		notifications.EmailFailed:  0,
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// RetryFailedEmail queue the failed emails again with their attempts reset
+func (r *notificationsRepository) RetryFailedEmail(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "email_queue" SET
+		"status" = 'queued',
+		"attempts" = 0,
+		"next_attempt_at" = now()
+	WHERE "status" = 'failed';`)
+	if err != nil {
+		return 0, fmt.Errorf("retry failed emails failed: %v", err)
+	}
+	retried, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("retry failed emails failed: %v", err)
+	}
+	return int(retried), nil
+}
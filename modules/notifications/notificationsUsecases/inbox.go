@@ -0,0 +1,160 @@
+package notificationsUsecases
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+)
+
+// statusTitles is the title of a status change, another status is "Order updated"
+var statusTitles = map[string]string{
+	orders.StatusPaid:           "Payment received",
+	orders.StatusShipping:       "Order shipped",
+	orders.StatusReadyForPickup: "Order ready for pickup",
+	orders.StatusCompleted:      "Order completed",
+	orders.StatusCanceled:       "Order canceled",
+}
+
+func (u *notificationsUsecase) FindInbox(ctx context.Context, req *notifications.InboxReq) (*notifications.Inbox, error) {
+	list, total, err := u.notificationsRepository.FindNotification(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := u.notificationsRepository.CountUnread(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notifications.Inbox{
+		PageRes: entities.NewPage(list, entities.NewPagination(req.Page, req.Limit, total)),
+		Unread:  unread,
+	}, nil
+}
+
+func (u *notificationsUsecase) MarkRead(ctx context.Context, userId, notificationId string) error {
+	return u.notificationsRepository.MarkRead(ctx, userId, strings.TrimSpace(notificationId))
+}
+
+func (u *notificationsUsecase) MarkAllRead(ctx context.Context, userId string) (int, error) {
+	return u.notificationsRepository.MarkAllRead(ctx, userId)
+}
+
+// FindPreference return every type, the types the user did not change have their defaults
+func (u *notificationsUsecase) FindPreference(ctx context.Context, userId string) ([]*notifications.Preference, error) {
+	saved, err := u.notificationsRepository.FindPreference(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[string]*notifications.Preference)
+	for _, preference := range saved {
+		byType[preference.Type] = preference
+	}
+
+	preferences := make([]*notifications.Preference, 0, len(notifications.Types))
+	for _, t := range notifications.Types {
+		if preference, ok := byType[t]; ok {
+			preferences = append(preferences, preference)
+			continue
+		}
+		preferences = append(preferences, notifications.DefaultPreference(t))
+	}
+	return preferences, nil
+}
+
+// UpdatePreference save the given types only, the others keep what they are
+func (u *notificationsUsecase) UpdatePreference(ctx context.Context, req *notifications.PreferencesReq) ([]*notifications.Preference, error) {
+	if len(req.Preferences) == 0 {
+		return nil, fmt.Errorf("preferences are required")
+	}
+	for _, preference := range req.Preferences {
+		if preference == nil || !notifications.ValidType(preference.Type) {
+			return nil, fmt.Errorf("notification type is invalid")
+		}
+	}
+
+	if err := u.notificationsRepository.UpsertPreference(ctx, req.UserId, req.Preferences); err != nil {
+		return nil, err
+	}
+	return u.FindPreference(ctx, req.UserId)
+}
+
+// NotifyOrder turn an event of the orders usecase into a notification of the customer
+func (u *notificationsUsecase) NotifyOrder(ctx context.Context, name string, event *orders.OrderEvent) error {
+	n := &notifications.Notification{
+		UserId: event.UserId,
+		Type:   name,
+		Link:   "/orders/" + event.OrderId,
+	}
+	switch name {
+	case orders.EventOrderCreated:
+		n.Title = "Order received"
+		n.Body = fmt.Sprintf("We received your order %s.", event.OrderId)
+	case orders.EventOrderStatusChanged:
+		n.Title = "Order updated"
+		if title, ok := statusTitles[event.Status]; ok {
+			n.Title = title
+		}
+		n.Body = fmt.Sprintf("Your order %s is now %s.", event.OrderId, strings.ReplaceAll(event.Status, "_", " "))
+	case orders.EventOrderTrackingUpdated:
+		n.Title = "Tracking number updated"
+		n.Body = fmt.Sprintf("Your order %s can be tracked with %s.", event.OrderId, event.TrackingNumber)
+	default:
+		return nil
+	}
+	return u.Notify(ctx, n)
+}
+
+// NotifyRefund tell the customer of the order that the refund is on its way
+func (u *notificationsUsecase) NotifyRefund(ctx context.Context, refund *refunds.Refund) error {
+	userId, err := u.notificationsRepository.FindOrderUserId(ctx, refund.OrderId)
+	if err != nil {
+		return err
+	}
+	return u.Notify(ctx, &notifications.Notification{
+		UserId: userId,
+		Type:   notifications.TypeRefundIssued,
+		Title:  "Refund issued",
+		Body:   fmt.Sprintf("A refund of %.2f was issued for your order %s.", refund.Amount, refund.OrderId),
+		Link:   "/orders/" + refund.OrderId,
+	})
+}
+
+// Notify put n in the inbox and queue the email when the customer want emails of its type. Sms and push
+// are only saved in the preferences, there is no provider to send them yet
+func (u *notificationsUsecase) Notify(ctx context.Context, n *notifications.Notification) error {
+	if n.UserId == "" {
+		return nil
+	}
+	if err := u.notificationsRepository.InsertNotification(ctx, n); err != nil {
+		return err
+	}
+
+	preferences, err := u.FindPreference(ctx, n.UserId)
+	if err != nil {
+		return err
+	}
+	for _, preference := range preferences {
+		if preference.Type != n.Type || !preference.Email {
+			continue
+		}
+		email, err := u.notificationsRepository.FindUserEmail(ctx, n.UserId)
+		if err != nil {
+			return err
+		}
+		if _, err := u.SendEmail(ctx, &notifications.Email{
+			To:      email,
+			Subject: n.Title,
+			Body:    fmt.Sprintf("<p>%s</p>", html.EscapeString(n.Body)),
+		}); err != nil {
+			log.Printf("email notification %s to user %s failed: %v\n", n.Id, n.UserId, err)
+		}
+	}
+	return nil
+}
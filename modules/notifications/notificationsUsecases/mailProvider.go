@@ -0,0 +1,107 @@
+package notificationsUsecases
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+)
+
+type IMailProvider interface {
+	Name() string
+	Rate() float64 // emails per second
+	Send(email *notifications.Email) error
+}
+
+type smtpProvider struct {
+	cfg config.IMailConfig
+}
+
+func SmtpProvider(cfg config.IMailConfig) IMailProvider {
+	return &smtpProvider{
+		cfg: cfg,
+	}
+}
+
+func (p *smtpProvider) Name() string  { return "smtp" }
+func (p *smtpProvider) Rate() float64 { return p.cfg.SmtpRate() }
+
+func (p *smtpProvider) Send(email *notifications.Email) error {
+	var auth smtp.Auth
+	if p.cfg.SmtpUsername() != "" {
+		auth = smtp.PlainAuth("", p.cfg.SmtpUsername(), p.cfg.SmtpPassword(), p.cfg.SmtpHost())
+	}
+
+	msg := strings.Join([]string{
+		"From: " + p.cfg.From(),
+		"To: " + email.To,
+		"Subject: " + email.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/html; charset=\"UTF-8\"",
+		"",
+		email.Body,
+	}, "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", p.cfg.SmtpHost(), p.cfg.SmtpPort())
+	if err := smtp.SendMail(addr, auth, p.cfg.From(), []string{email.To}, []byte(msg)); err != nil {
+		return fmt.Errorf("smtp send failed: %v", err)
+	}
+	return nil
+}
+
+// apiProvider send through an http email api, {from, to, subject, html} is posted with the key as bearer token
+type apiProvider struct {
+	cfg    config.IMailConfig
+	client *http.Client
+}
+
+func ApiProvider(cfg config.IMailConfig) IMailProvider {
+	return &apiProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *apiProvider) Name() string  { return "api" }
+func (p *apiProvider) Rate() float64 { return p.cfg.ApiRate() }
+
+func (p *apiProvider) Send(email *notifications.Email) error {
+	body, err := json.Marshal(map[string]string{
+		"from":    p.cfg.From(),
+		"to":      email.To,
+		"subject": email.Subject,
+		"html":    email.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal email failed: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.cfg.ApiUrl(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create email api request failed: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.cfg.ApiKey())
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("call email api failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	// the api rejected the email, sending it again would be rejected too
+	if res.StatusCode >= 400 && res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+		return resilience.Permanent(fmt.Errorf("email api responded %d", res.StatusCode))
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("email api responded %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package notificationsUsecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+	"golang.org/x/time/rate"
+)
+
+const (
+	emailPollInterval = 2 * time.Second
+	emailBatchSize    = 20
+	emailRetryBase    = 30 * time.Second
+	emailRetryMax     = time.Hour
+)
+
+type INotificationsUsecase interface {
+	SendEmail(ctx context.Context, req *notifications.Email) (*notifications.Email, error)
+	StartEmailWorker(ctx context.Context)
+	FindEmailMetrics(ctx context.Context) (*notifications.EmailMetrics, error)
+	RetryFailedEmail(ctx context.Context) (int, error)
+
+	// inbox.go
+	FindInbox(ctx context.Context, req *notifications.InboxReq) (*notifications.Inbox, error)
+	MarkRead(ctx context.Context, userId, notificationId string) error
+	MarkAllRead(ctx context.Context, userId string) (int, error)
+	FindPreference(ctx context.Context, userId string) ([]*notifications.Preference, error)
+	UpdatePreference(ctx context.Context, req *notifications.PreferencesReq) ([]*notifications.Preference, error)
+	NotifyOrder(ctx context.Context, name string, event *orders.OrderEvent) error
+	NotifyRefund(ctx context.Context, refund *refunds.Refund) error
+	Notify(ctx context.Context, n *notifications.Notification) error
+}
+
+// mailSender is a provider with its own rate limit, circuit breaker and delivery metrics
+type mailSender struct {
+	provider IMailProvider
+	limiter  *rate.Limiter
+	breaker  *resilience.Breaker
+	metrics  *notifications.ProviderMetrics
+}
+
+type notificationsUsecase struct {
+	cfg                     config.IMailConfig
+	notificationsRepository notificationsRepositories.INotificationsRepository
+	senders                 []*mailSender
+	mu                      sync.Mutex
+}
+
+// NotificationsUsecase send through providers in the given order, the next provider is the failover of the previous one
+func NotificationsUsecase(cfg config.IMailConfig, notificationsRepository notificationsRepositories.INotificationsRepository, providers ...IMailProvider) INotificationsUsecase {
+	senders := make([]*mailSender, 0)
+	for _, provider := range providers {
+		senders = append(senders, &mailSender{
+			provider: provider,
+			limiter:  rate.NewLimiter(rate.Limit(provider.Rate()), int(math.Max(1, provider.Rate()))),
+			breaker:  resilience.Get("email." + provider.Name()),
+			metrics:  &notifications.ProviderMetrics{Provider: provider.Name()},
+		})
+	}
+
+	return &notificationsUsecase{
+		cfg:                     cfg,
+		notificationsRepository: notificationsRepository,
+		senders:                 senders,
+	}
+}
+
+// SendEmail only queue the email, it is sent by the email worker
+func (u *notificationsUsecase) SendEmail(ctx context.Context, req *notifications.Email) (*notifications.Email, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(req.To))
+	if err != nil {
+		return nil, fmt.Errorf("email address is invalid")
+	}
+	req.To = address.Address
+	if strings.TrimSpace(req.Subject) == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+
+	if err := u.notificationsRepository.InsertEmail(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// StartEmailWorker send due emails every emailPollInterval, must be called in a goroutine
+func (u *notificationsUsecase) StartEmailWorker(ctx context.Context) {
+	if len(u.senders) == 0 {
+		log.Println("no email provider is configured, emails stay in the queue")
+		return
+	}
+
+	ticker := time.NewTicker(emailPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		emails, err := u.notificationsRepository.ClaimEmail(ctx, emailBatchSize)
+		if err != nil {
+			log.Printf("email worker: %v\n", err)
+			continue
+		}
+		for _, email := range emails {
+			u.deliver(ctx, email)
+		}
+	}
+}
+
+func (u *notificationsUsecase) deliver(ctx context.Context, email *notifications.Email) {
+	errs := make([]string, 0)
+	// เลื่อนไปเมื่อทุก provider ถูกข้าม
+	requeueAfter := time.Duration(0)
+	for _, sender := range u.senders {
+		// rate อาจเปลี่ยนจาก config reload
+		if limit := rate.Limit(sender.provider.Rate()); sender.limiter.Limit() != limit {
+			sender.limiter.SetLimit(limit)
+			sender.limiter.SetBurst(int(math.Max(1, sender.provider.Rate())))
+		}
+		if !sender.limiter.Allow() {
+			u.record(sender, func(m *notifications.ProviderMetrics) { m.RateLimited++ })
+			requeueAfter = minDuration(requeueAfter, time.Second)
+			continue
+		}
+
+		err := sender.breaker.Do(ctx, func(ctx context.Context) error {
+			return sender.provider.Send(email)
+		})
+		// provider ล่มอยู่ ข้ามไป provider ถัดไปโดยไม่นับเป็น attempt
+		var openErr *resilience.OpenError
+		if errors.As(err, &openErr) {
+			u.record(sender, func(m *notifications.ProviderMetrics) { m.BreakerOpen++ })
+			requeueAfter = minDuration(requeueAfter, openErr.RetryAfter)
+			continue
+		}
+		if err != nil {
+			u.record(sender, func(m *notifications.ProviderMetrics) {
+				m.Failed++
+				m.LastError = err.Error()
+			})
+			errs = append(errs, fmt.Sprintf("%s: %v", sender.provider.Name(), err))
+			continue
+		}
+
+		u.record(sender, func(m *notifications.ProviderMetrics) {
+			m.Sent++
+			m.LastSentAt = time.Now().Format(time.RFC3339)
+		})
+		if err := u.notificationsRepository.MarkEmailSent(ctx, email.Id, sender.provider.Name()); err != nil {
+			log.Printf("email worker: %v\n", err)
+		}
+		return
+	}
+
+	// ทุก provider ติด rate limit หรือ breaker เปิดอยู่ ไม่นับเป็น attempt
+	if len(errs) == 0 {
+		if requeueAfter < time.Second {
+			requeueAfter = time.Second
+		}
+		if err := u.notificationsRepository.RequeueEmail(ctx, email.Id, time.Now().Add(requeueAfter)); err != nil {
+			log.Printf("email worker: %v\n", err)
+		}
+		return
+	}
+
+	attempts := email.Attempts + 1
+	backoff := time.Duration(math.Min(
+		float64(emailRetryBase)*math.Pow(2, float64(attempts-1)),
+		float64(emailRetryMax),
+	))
+	failed := attempts >= u.cfg.MaxAttempts()
+	if failed {
+		log.Printf("email %s to %s failed after %d attempts: %s\n", email.Id, email.To, attempts, strings.Join(errs, "; "))
+	}
+	if err := u.notificationsRepository.MarkEmailRetry(ctx, email.Id, strings.Join(errs, "; "), time.Now().Add(backoff), failed); err != nil {
+		log.Printf("email worker: %v\n", err)
+	}
+}
+
+// minDuration is the shorter wait, 0 is no wait yet
+func minDuration(a, b time.Duration) time.Duration {
+	if a == 0 || b < a {
+		return b
+	}
+	return a
+}
+
+func (u *notificationsUsecase) record(sender *mailSender, fn func(m *notifications.ProviderMetrics)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	fn(sender.metrics)
+}
+
+func (u *notificationsUsecase) FindEmailMetrics(ctx context.Context) (*notifications.EmailMetrics, error) {
+	queue, err := u.notificationsRepository.CountEmailByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	providers := make([]*notifications.ProviderMetrics, 0)
+	for _, sender := range u.senders {
+		metrics := *sender.metrics
+		metrics.Breaker = string(sender.breaker.Status().State)
+		providers = append(providers, &metrics)
+	}
+
+	return &notifications.EmailMetrics{
+		Providers: providers,
+		Queue:     queue,
+	}, nil
+}
+
+// RetryFailedEmail queue the failed emails again, they are sent by the email worker of the running server
+func (u *notificationsUsecase) RetryFailedEmail(ctx context.Context) (int, error) {
+	return u.notificationsRepository.RetryFailedEmail(ctx)
+}
@@ -1,21 +1,43 @@
 package orders
 
 import (
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
 )
 
 type Order struct {
-	Id           string           `json:"id" db:"id"`
-	UserId       string           `json:"user_id" db:"user_id"`
-	TransferSlip *TransferSlip    `json:"transfer_slip" db:"transfer_slip"`
-	Products     []*ProductsOrder `json:"products"`
-	Address      string           `json:"address" db:"address"`
-	Contact      string           `json:"contact" db:"contact"`
-	Status       string           `json:"status" db:"status"`
-	TotalPaid    float64          `json:"total_paid" db:"total_paid"`
-	CreatedAt    string           `json:"created_at" db:"created_at"`
-	UpdatedAt    string           `json:"updated_at" db:"updated_at"`
+	Id              string              `json:"id" db:"id"`
+	UserId          string              `json:"user_id" db:"user_id"`
+	StoreId         string              `json:"store_id,omitempty" db:"store_id"` // empty is an order of the default store
+	TransferSlip    *TransferSlip       `json:"transfer_slip" db:"transfer_slip"`
+	Products        []*ProductsOrder    `json:"products"`
+	Address         string              `json:"address" db:"address"`
+	AddressId       string              `json:"address_id,omitempty"`                   // address book entry chosen at checkout, default address when empty
+	ShippingAddress *addresses.Address  `json:"shipping_address" db:"shipping_address"` // snapshot of the address at checkout
+	Contact         string              `json:"contact" db:"contact"`
+	Status          string              `json:"status" db:"status"`
+	TotalPaid       float64             `json:"total_paid" db:"total_paid"`             // products - discount + shipping fee and tax
+	Discount        float64             `json:"discount" db:"discount"`                 // promotions and coupon, only applied by checkout
+	CouponCode      string              `json:"coupon_code,omitempty" db:"coupon_code"` // the coupon of the discount, empty without a coupon
+	ShippingMethod  string              `json:"shipping_method" db:"shipping_method"`   // method of a shipping quote, e.g. flat:standard
+	ShippingFee     float64             `json:"shipping_fee" db:"shipping_fee"`
+	Tax             *taxes.Breakdown    `json:"tax" db:"tax"` // calculated when the order is placed, null on older orders
+	TrackingNumber  string              `json:"tracking_number" db:"tracking_number"`
+	Tags            []string            `json:"tags" db:"tags"`       // added by order hooks
+	OnHold          bool                `json:"on_hold" db:"on_hold"` // held for review by order hooks
+	Gift            *Gift               `json:"gift" db:"gift"`       // null when the order is not a gift
+	GiftToken       string              `json:"gift_token,omitempty" db:"gift_token"`
+	Fulfillment     string              `json:"fulfillment" db:"fulfillment"` // delivery or pickup
+	PickupSlotId    int                 `json:"pickup_slot_id,omitempty"`     // slot chosen at checkout for pickup
+	Reservation     string              `json:"reservation,omitempty"`        // reference of the checkout hold, moved to the order when placed
+	Pickup          *Pickup             `json:"pickup" db:"pickup"`           // null when the order is delivered
+	CreatedAt       string              `json:"created_at" db:"created_at"`
+	UpdatedAt       string              `json:"updated_at" db:"updated_at"`
+	History         []*StatusTransition `json:"history,omitempty"` // only on find one order
 }
 
 type TransferSlip struct {
@@ -25,6 +47,32 @@ type TransferSlip struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// Gift is shipped to Recipient instead of the buyer
+type Gift struct {
+	Recipient  *addresses.Address `json:"recipient"`
+	Email      string             `json:"email"`       // recipient email, the tracking token is sent here when shipped
+	Message    string             `json:"message"`     // printed on the packing slip
+	HidePrices bool               `json:"hide_prices"` // the invoice and the gift tracking page show no prices
+}
+
+// Pickup is the store and slot of a pickup order, Code is given when the order is ready for pickup
+type Pickup struct {
+	SlotId     int    `json:"slot_id"`
+	LocationId int    `json:"location_id"`
+	Location   string `json:"location"`
+	Address    string `json:"address"`
+	Phone      string `json:"phone"`
+	StartsAt   string `json:"starts_at"`
+	EndsAt     string `json:"ends_at"`
+	Code       string `json:"code,omitempty"`
+}
+
+type PickupConfirmReq struct {
+	OrderId string `json:"-"`
+	Code    string `json:"code"`
+	ActorId string `json:"-"`
+}
+
 type ProductsOrder struct {
 	Id      string             `json:"id" db:"id"`
 	Qty     int                `json:"qty" db:"qty"`
@@ -36,12 +84,100 @@ type OrderFilter struct {
 	Status    string `query:"status"`
 	StartDate string `query:"start_date"`
 	EndDate   string `query:"end_date"`
+	StoreId   string `query:"-"` // set from the store of the request
 	*entities.PaginationReq
 	*entities.SortReq
 }
 
 type OrderUpdate struct {
-	Id           string        `json:"id" db:"id"`
-	TransferSlip *TransferSlip `json:"transfer_slip" db:"transfer_slip"`
-	Status       string        `json:"status" db:"status"`
+	Id             string        `json:"id" db:"id"`
+	TransferSlip   *TransferSlip `json:"transfer_slip" db:"transfer_slip"`
+	Status         string        `json:"status" db:"status"`
+	TrackingNumber string        `json:"tracking_number" db:"tracking_number"` // admin only
+	ActorId        string        `json:"-"`
+	ActorRoleId    int           `json:"-"`
+	PickupCode     string        `json:"-"` // checked when a pickup order is handed over
+}
+
+const (
+	StatusWaiting   = "waiting"
+	StatusPaid      = "paid"
+	StatusShipping  = "shipping"
+	StatusCompleted = "completed"
+	StatusCanceled  = "canceled"
+
+	StatusReadyForPickup = "ready_for_pickup"
+)
+
+// Events published by the orders usecase with an *OrderEvent payload
+const (
+	EventOrderCreated         = "order.created"
+	EventOrderStatusChanged   = "order.status_changed"
+	EventOrderTrackingUpdated = "order.tracking_updated"
+)
+
+type OrderEvent struct {
+	OrderId        string  `json:"order_id"`
+	UserId         string  `json:"user_id"`
+	StoreId        string  `json:"store_id,omitempty"`
+	FromStatus     string  `json:"from_status,omitempty"`
+	Status         string  `json:"status"`
+	TrackingNumber string  `json:"tracking_number,omitempty"`
+	TotalPaid      float64 `json:"total_paid,omitempty"`
+}
+
+const (
+	FulfillmentDelivery = "delivery"
+	FulfillmentPickup   = "pickup"
+)
+
+type StatusTransition struct {
+	Id             int    `json:"id"`
+	OrderId        string `json:"order_id"`
+	FromStatus     string `json:"from_status"`
+	ToStatus       string `json:"to_status"`
+	ActorId        string `json:"actor_id"`
+	RefundRequired bool   `json:"refund_required"` // canceled after payment
+	CreatedAt      string `json:"created_at"`
+}
+
+// TransitionError is returned when the order state machine does not allow a status change
+type TransitionError struct {
+	From   string
+	To     string
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s: %s", e.From, e.To, e.Reason)
+}
+
+type PackingSlip struct {
+	OrderId     string             `json:"order_id"`
+	ShipTo      *addresses.Address `json:"ship_to"`
+	Address     string             `json:"address"`
+	Items       []*PackingSlipItem `json:"items"`
+	GiftMessage string             `json:"gift_message,omitempty"`
+}
+
+type PackingSlipItem struct {
+	ProductId string `json:"product_id"`
+	Title     string `json:"title"`
+	Qty       int    `json:"qty"`
+}
+
+// GiftTracking is what the gift recipient can see with the gift token
+type GiftTracking struct {
+	OrderId        string              `json:"order_id"`
+	Status         string              `json:"status"`
+	ShippingMethod string              `json:"shipping_method"`
+	TrackingNumber string              `json:"tracking_number"`
+	Message        string              `json:"message"`
+	Items          []*GiftTrackingItem `json:"items"`
+}
+
+type GiftTrackingItem struct {
+	Title string   `json:"title"`
+	Qty   int      `json:"qty"`
+	Price *float64 `json:"price,omitempty"` // null when the buyer hide prices
 }
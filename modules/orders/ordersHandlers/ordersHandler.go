@@ -1,6 +1,9 @@
 package ordersHandlers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"strings"
 	"time"
 
@@ -8,6 +11,7 @@ import (
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/orders"
 	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -15,10 +19,13 @@ import (
 type ordersHandlerErrCode string
 
 const (
-	findOneOrderErr ordersHandlerErrCode = "orders-001"
-	findOrderErr    ordersHandlerErrCode = "orders-002"
-	insertOrderErr  ordersHandlerErrCode = "orders-003"
-	updateOrderErr  ordersHandlerErrCode = "orders-004"
+	findOneOrderErr  ordersHandlerErrCode = "orders-001"
+	findOrderErr     ordersHandlerErrCode = "orders-002"
+	insertOrderErr   ordersHandlerErrCode = "orders-003"
+	updateOrderErr   ordersHandlerErrCode = "orders-004"
+	packingSlipErr   ordersHandlerErrCode = "orders-005"
+	giftTrackingErr  ordersHandlerErrCode = "orders-006"
+	confirmPickupErr ordersHandlerErrCode = "orders-007"
 )
 
 type IOrdersHandler interface {
@@ -26,24 +33,43 @@ type IOrdersHandler interface {
 	FindOrder(c *fiber.Ctx) error
 	InsertOrder(c *fiber.Ctx) error
 	UpdateOrder(c *fiber.Ctx) error
+	FindPackingSlip(c *fiber.Ctx) error
+	FindGiftTracking(c *fiber.Ctx) error
+	ConfirmPickup(c *fiber.Ctx) error
+	OrderSocket() fiber.Handler
+	PushOrderEvent(ctx context.Context, e *events.Event)
 }
 
 type ordersHandler struct {
 	orderUsecase ordersUsecases.IOrdersUsecase
 	cfg          config.IConfig
+	socket       *orderSocket
 }
 
 func OrdersHandler(orderUsecase ordersUsecases.IOrdersUsecase, cfg config.IConfig) IOrdersHandler {
 	return &ordersHandler{
 		orderUsecase: orderUsecase,
 		cfg:          cfg,
+		socket:       newOrderSocket(),
 	}
 }
 
+// inStore report whether the order belongs to the store of the request, an order of another store is treated as not found
+func (h *ordersHandler) inStore(c *fiber.Ctx, orderId string) (bool, error) {
+	order, err := h.orderUsecase.FindOneOrder(c.UserContext(), orderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return order.StoreId == c.Locals("storeId").(string), nil
+}
+
 func (h *ordersHandler) FindOneOrder(c *fiber.Ctx) error {
 
 	orderId := strings.Trim(c.Params("order_id"), " ")
-	order, err := h.orderUsecase.FindOneOrder(orderId)
+	order, err := h.orderUsecase.FindOneOrder(c.UserContext(), orderId)
 	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
@@ -51,6 +77,13 @@ func (h *ordersHandler) FindOneOrder(c *fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
+	if order.StoreId != c.Locals("storeId").(string) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(findOneOrderErr),
+			"order not found",
+		).Res()
+	}
 
 	return entities.NewResponse(c).Success(
 		fiber.StatusOK,
@@ -73,6 +106,8 @@ func (h *ordersHandler) FindOrder(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	req.StoreId = c.Locals("storeId").(string)
+
 	// pagination
 	if req.Page < 1 {
 		req.Page = 1
@@ -125,7 +160,7 @@ func (h *ordersHandler) FindOrder(c *fiber.Ctx) error {
 		req.EndDate = end.Format("2006-01-02")
 	}
 
-	orders := h.orderUsecase.FindOrder(req)
+	orders := h.orderUsecase.FindOrder(c.UserContext(), req)
 
 	return entities.NewResponse(c).Success(
 		fiber.StatusOK,
@@ -161,11 +196,32 @@ func (h *ordersHandler) InsertOrder(c *fiber.Ctx) error {
 		req.UserId = userId
 	}
 
+	req.StoreId = c.Locals("storeId").(string)
 	req.Status = "waiting"
 	req.TotalPaid = 0
+	// ส่วนลดและ coupon ใช้ได้ผ่าน checkout เท่านั้น
+	req.Discount = 0
+	req.CouponCode = ""
+	// token สร้างจาก usecase เท่านั้น
+	req.GiftToken = ""
 
-	order, err := h.orderUsecase.InsertOrder(req)
+	order, err := h.orderUsecase.InsertOrder(c.UserContext(), req)
 	if err != nil {
+		var fieldErrs entities.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			return entities.NewResponse(c).ValidationError(
+				fiber.ErrBadRequest.Code,
+				string(insertOrderErr),
+				fieldErrs,
+			).Res()
+		}
+		if err.Error() == "reservation has expired" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(insertOrderErr),
+				err.Error(),
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(insertOrderErr),
@@ -192,20 +248,41 @@ func (h *ordersHandler) UpdateOrder(c *fiber.Ctx) error {
 	}
 
 	req.Id = orderId
+	found, err := h.inStore(c, orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(updateOrderErr),
+			err.Error(),
+		).Res()
+	}
+	if !found {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(updateOrderErr),
+			"order not found",
+		).Res()
+	}
 
 	statusMap := map[string]string{
-		"waiting":   "waiting",
-		"shipping":  "shipping",
-		"completed": "completed",
-		"canceled":  "canceled",
+		"waiting":          orders.StatusWaiting,
+		"paid":             orders.StatusPaid,
+		"shipping":         orders.StatusShipping,
+		"ready_for_pickup": orders.StatusReadyForPickup,
+		"completed":        orders.StatusCompleted,
+		"canceled":         orders.StatusCanceled,
 	}
 
-	// ถ้าเป็น admin จะสามารถเปลี่ยนสถานะได้ทั้งหมด แต่ถ้าเป็น user จะสามารถเปลี่ยนเป็น canceled ได้เท่านั้น
-	if c.Locals("userRoleId").(int) == 2 {
-		req.Status = statusMap[strings.ToLower(req.Status)]
-	} else if strings.ToLower(req.Status) != statusMap["canceled"] {
-		req.Status = statusMap["canceled"]
+	// status ที่เปลี่ยนได้ถูกตรวจโดย state machine ใน usecase, user เปลี่ยนได้แค่ canceled
+	req.Status = statusMap[strings.ToLower(req.Status)]
+	req.ActorId = c.Locals("userId").(string)
+	req.ActorRoleId = c.Locals("userRoleId").(int)
+
+	// tracking number มาจาก admin เท่านั้น
+	if c.Locals("userRoleId").(int) != 2 {
+		req.TrackingNumber = ""
 	}
+	req.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
 
 	if req.TransferSlip != nil {
 		if req.TransferSlip.Id == "" {
@@ -229,8 +306,16 @@ func (h *ordersHandler) UpdateOrder(c *fiber.Ctx) error {
 		}
 	}
 
-	order, err := h.orderUsecase.UpdateOrder(req)
+	order, err := h.orderUsecase.UpdateOrder(c.UserContext(), req)
 	if err != nil {
+		var transitionErr *orders.TransitionError
+		if errors.As(err, &transitionErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(updateOrderErr),
+				err.Error(),
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(updateOrderErr),
@@ -243,3 +328,112 @@ func (h *ordersHandler) UpdateOrder(c *fiber.Ctx) error {
 		order,
 	).Res()
 }
+
+func (h *ordersHandler) FindPackingSlip(c *fiber.Ctx) error {
+	orderId := strings.Trim(c.Params("order_id"), " ")
+	found, err := h.inStore(c, orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(packingSlipErr),
+			err.Error(),
+		).Res()
+	}
+	if !found {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(packingSlipErr),
+			"order not found",
+		).Res()
+	}
+
+	slip, err := h.orderUsecase.FindPackingSlip(c.UserContext(), orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(packingSlipErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, slip).Res()
+}
+
+func (h *ordersHandler) FindGiftTracking(c *fiber.Ctx) error {
+	token := strings.Trim(c.Params("token"), " ")
+
+	tracking, err := h.orderUsecase.FindGiftTracking(c.UserContext(), token)
+	if err != nil {
+		switch err.Error() {
+		case "gift not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(giftTrackingErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(giftTrackingErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, tracking).Res()
+}
+
+func (h *ordersHandler) ConfirmPickup(c *fiber.Ctx) error {
+	req := new(orders.PickupConfirmReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(confirmPickupErr),
+			err.Error(),
+		).Res()
+	}
+	req.OrderId = strings.Trim(c.Params("order_id"), " ")
+	req.ActorId = c.Locals("userId").(string)
+	found, err := h.inStore(c, req.OrderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(confirmPickupErr),
+			err.Error(),
+		).Res()
+	}
+	if !found {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(confirmPickupErr),
+			"order not found",
+		).Res()
+	}
+
+	order, err := h.orderUsecase.ConfirmPickup(c.UserContext(), req)
+	if err != nil {
+		var transitionErr *orders.TransitionError
+		switch {
+		case errors.As(err, &transitionErr):
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(confirmPickupErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "pickup code is invalid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(confirmPickupErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(confirmPickupErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, order).Res()
+}
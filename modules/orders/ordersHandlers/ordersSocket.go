@@ -0,0 +1,161 @@
+package ordersHandlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/NatthawutSK/ri-shop/pkg/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// max orders one connection can subscribe to
+const maxSocketOrders = 50
+
+// socketReq is sent by the client, e.g. {"action":"subscribe","order_ids":["O000001"]}
+type socketReq struct {
+	Action   string   `json:"action"` // subscribe or unsubscribe
+	OrderIds []string `json:"order_ids"`
+}
+
+type socketRes struct {
+	Event string `json:"event"`
+	Data  any    `json:"data"`
+}
+
+type socketClient struct {
+	conn   *websocket.Conn
+	userId string
+	admin  bool
+	orders map[string]bool
+}
+
+// orderSocket push order events to the websocket clients subscribed to the order
+type orderSocket struct {
+	mu      sync.RWMutex
+	clients map[*socketClient]bool
+}
+
+func newOrderSocket() *orderSocket {
+	return &orderSocket{
+		clients: make(map[*socketClient]bool),
+	}
+}
+
+func (h *ordersHandler) OrderSocket() fiber.Handler {
+	return websocket.New(h.serveSocket)
+}
+
+func (h *ordersHandler) serveSocket(conn *websocket.Conn) {
+	client := &socketClient{
+		conn:   conn,
+		userId: conn.Locals("userId").(string),
+		admin:  conn.Locals("userRoleId").(int) == 2,
+		orders: make(map[string]bool),
+	}
+
+	h.socket.mu.Lock()
+	h.socket.clients[client] = true
+	h.socket.mu.Unlock()
+
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		h.socket.mu.Lock()
+		delete(h.socket.clients, client)
+		h.socket.mu.Unlock()
+	}()
+	go conn.KeepAlive(done)
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		req := new(socketReq)
+		if err := json.Unmarshal(message, req); err != nil {
+			h.writeSocket(client, "error", fiber.Map{"message": "message must be json"})
+			continue
+		}
+
+		switch strings.ToLower(req.Action) {
+		case "subscribe":
+			// the socket outlives the request which upgraded it, its queries get the default timeout
+			h.subscribeSocket(context.Background(), client, req.OrderIds)
+		case "unsubscribe":
+			h.socket.mu.Lock()
+			for _, orderId := range req.OrderIds {
+				delete(client.orders, orderId)
+			}
+			h.socket.mu.Unlock()
+			h.writeSocket(client, "unsubscribed", fiber.Map{"order_ids": req.OrderIds})
+		default:
+			h.writeSocket(client, "error", fiber.Map{"message": "action must be subscribe or unsubscribe"})
+		}
+	}
+}
+
+// subscribeSocket only subscribe to orders of the user, admin can subscribe to any order
+func (h *ordersHandler) subscribeSocket(ctx context.Context, client *socketClient, orderIds []string) {
+	subscribed := make([]string, 0)
+	for _, orderId := range orderIds {
+		orderId = strings.TrimSpace(orderId)
+
+		h.socket.mu.RLock()
+		count := len(client.orders)
+		h.socket.mu.RUnlock()
+		if count >= maxSocketOrders {
+			h.writeSocket(client, "error", fiber.Map{"message": "too many subscribed orders"})
+			break
+		}
+
+		order, err := h.orderUsecase.FindOneOrder(ctx, orderId)
+		if err != nil || (!client.admin && order.UserId != client.userId) {
+			h.writeSocket(client, "error", fiber.Map{"message": "order not found", "order_id": orderId})
+			continue
+		}
+
+		h.socket.mu.Lock()
+		client.orders[order.Id] = true
+		h.socket.mu.Unlock()
+		subscribed = append(subscribed, order.Id)
+	}
+	h.writeSocket(client, "subscribed", fiber.Map{"order_ids": subscribed})
+}
+
+func (h *ordersHandler) writeSocket(client *socketClient, event string, data any) {
+	message, err := json.Marshal(&socketRes{Event: event, Data: data})
+	if err != nil {
+		log.Printf("marshal socket message failed: %v\n", err)
+		return
+	}
+	if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+		log.Printf("write socket message failed: %v\n", err)
+	}
+}
+
+// PushOrderEvent is the event subscriber of order events
+func (h *ordersHandler) PushOrderEvent(ctx context.Context, e *events.Event) {
+	payload, ok := e.Payload.(*orders.OrderEvent)
+	if !ok {
+		return
+	}
+
+	h.socket.mu.RLock()
+	receivers := make([]*socketClient, 0)
+	for client := range h.socket.clients {
+		if client.orders[payload.OrderId] {
+			receivers = append(receivers, client)
+		}
+	}
+	h.socket.mu.RUnlock()
+
+	for _, client := range receivers {
+		h.writeSocket(client, e.Name, payload)
+	}
+}
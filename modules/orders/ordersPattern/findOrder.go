@@ -6,15 +6,16 @@ import (
 	"fmt"
 	"log"
 	"strings"
-	"time"
 
 	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
 	"github.com/jmoiron/sqlx"
 )
 
 type IFindOrderBuilder interface{
 	initQuery()
 	initCountQuery()
+	buildWhereStore()
 	buildWhereSearch()
 	buildWhereStatus()
 	buildWhereDate()
@@ -27,10 +28,12 @@ type IFindOrderBuilder interface{
 	setValues(data []any)
 	setLastIndex(index int)
 	getDb() *sqlx.DB
+	getContext() context.Context
 	resetQuery()
 }
 
 type findOrderBuilder struct{
+	ctx context.Context
 	db *sqlx.DB
 	req *orders.OrderFilter
 	query string
@@ -38,8 +41,9 @@ type findOrderBuilder struct{
 	lastIndex int
 }
 
-func FindOrderBuilder(db *sqlx.DB, req *orders.OrderFilter) IFindOrderBuilder {
+func FindOrderBuilder(ctx context.Context, db *sqlx.DB, req *orders.OrderFilter) IFindOrderBuilder {
 	return &findOrderBuilder{
+		ctx: ctx,
 		db: db,
 		req: req,
 		values: make([]any, 0),
@@ -65,6 +69,7 @@ func (b *findOrderBuilder) initQuery() {
 		SELECT
 			"o"."id",
 			"o"."user_id",
+			COALESCE("o"."store_id", '') AS "store_id",
 			"o"."transfer_slip",
 			"o"."status",
 			(
@@ -80,13 +85,43 @@ func (b *findOrderBuilder) initQuery() {
 				) AS "pt"
 			) AS "products",
 			"o"."address",
+			"o"."shipping_address",
 			"o"."contact",
 			(
 				SELECT
 					SUM(COALESCE(("po"."product"->>'price')::FLOAT*("po"."qty")::FLOAT, 0))
 				FROM "products_orders" "po"
 				WHERE "po"."order_id" = "o"."id"
-			) AS "total_paid",
+			) - "o"."discount" + "o"."shipping_fee" + COALESCE(("o"."tax"->>'total')::FLOAT, 0) AS "total_paid",
+			"o"."discount",
+			COALESCE("o"."coupon_code", '') AS "coupon_code",
+			COALESCE("o"."shipping_method", '') AS "shipping_method",
+			"o"."shipping_fee",
+			"o"."tax",
+			COALESCE("o"."tracking_number", '') AS "tracking_number",
+			"o"."tags",
+			"o"."on_hold",
+			"o"."gift",
+			COALESCE("o"."gift_token", '') AS "gift_token",
+			"o"."fulfillment",
+			(
+				SELECT
+					to_jsonb("pkt")
+				FROM (
+					SELECT
+						"ps"."id" AS "slot_id",
+						"pl"."id" AS "location_id",
+						"pl"."title" AS "location",
+						"pl"."address",
+						"pl"."phone",
+						"ps"."starts_at",
+						"ps"."ends_at",
+						COALESCE("o"."pickup_code", '') AS "code"
+					FROM "pickup_slots" "ps"
+						LEFT JOIN "pickup_locations" "pl" ON "pl"."id" = "ps"."location_id"
+					WHERE "ps"."id" = "o"."pickup_slot_id"
+				) AS "pkt"
+			) AS "pickup",
 			"o"."created_at",
 			"o"."updated_at"
 		FROM "orders" "o"
@@ -108,6 +143,20 @@ func (b *findOrderBuilder) initCountQuery() {
 
 
 
+// buildWhereStore always filter, an empty store is the default store
+func (b *findOrderBuilder) buildWhereStore() {
+	b.values = append(
+		b.values,
+		b.req.StoreId,
+	)
+
+	b.query += fmt.Sprintf(`
+		AND COALESCE("o"."store_id", '') = $%d`,
+		b.lastIndex+1,
+	)
+	b.lastIndex = len(b.values)
+}
+
 func (b *findOrderBuilder) buildWhereSearch() {
 	if b.req.Search != "" {
 		b.values = append(
@@ -241,6 +290,10 @@ func (b *findOrderBuilder) getDb() *sqlx.DB {
 	return b.db
 }
 
+func (b *findOrderBuilder) getContext() context.Context {
+	return b.ctx
+}
+
 func (b *findOrderBuilder) resetQuery() {
 	b.query = ""
 	b.values = make([]any, 0)
@@ -250,11 +303,12 @@ func (b *findOrderBuilder) resetQuery() {
 
 // Engineer
 func (en *findOrderEngineer) FindOrder() []*orders.Order {
-	_, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	ctx, cancel := databases.WithQueryTimeout(en.builder.getContext())
 	defer cancel()
 
 
 	en.builder.initQuery()
+	en.builder.buildWhereStore()
 	en.builder.buildWhereSearch()
 	en.builder.buildWhereStatus()
 	en.builder.buildWhereDate()
@@ -265,7 +319,7 @@ func (en *findOrderEngineer) FindOrder() []*orders.Order {
 
 	bytes := make([]byte, 0)
 	ordersData := make([]*orders.Order, 0)
-	if err := en.builder.getDb().Get(&bytes, en.builder.getQuery(), en.builder.getValues()...); err != nil {
+	if err := en.builder.getDb().GetContext(ctx, &bytes, en.builder.getQuery(), en.builder.getValues()...); err != nil {
 		log.Printf("find orders failed: %v\n", err)
 		return make([]*orders.Order, 0)
 	}
@@ -281,17 +335,18 @@ func (en *findOrderEngineer) FindOrder() []*orders.Order {
 }
 
 func (en *findOrderEngineer) CountOrder() int {
-	_, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	ctx, cancel := databases.WithQueryTimeout(en.builder.getContext())
 	defer cancel()
 
 
 	en.builder.initCountQuery()
+	en.builder.buildWhereStore()
 	en.builder.buildWhereSearch()
 	en.builder.buildWhereStatus()
 	en.builder.buildWhereDate()
 
 	var count int
-	if err := en.builder.getDb().Get(&count, en.builder.getQuery(), en.builder.getValues()...); err != nil {
+	if err := en.builder.getDb().GetContext(ctx, &count, en.builder.getQuery(), en.builder.getValues()...); err != nil {
 		log.Printf("count orders failed: %v\n", err)
 		return 0
 	}
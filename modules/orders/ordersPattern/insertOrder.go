@@ -2,22 +2,28 @@ package ordersPattern
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"time"
 
+	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
 	"github.com/jmoiron/sqlx"
 )
 
 type IInsertOrderBuilder interface {
 	initTransaction() error
+	checkPickupSlot() error
+	checkSellers() error
 	insertOrder() error
 	insertProductsOrder() error
+	claimReservation() error
 	getOrderId() string
 	commit() error
 }
 
 type insertOrderBuilder struct {
+	ctx context.Context
 	req *orders.Order
 	db  *sqlx.DB
 	tx  *sqlx.Tx
@@ -25,15 +31,17 @@ type insertOrderBuilder struct {
 
 
 
-func InsertOrderBuilder(req *orders.Order, db *sqlx.DB) IInsertOrderBuilder {
+// InsertOrderBuilder begin the transaction on ctx, it is rolled back when ctx is canceled before the commit
+func InsertOrderBuilder(ctx context.Context, req *orders.Order, db *sqlx.DB) IInsertOrderBuilder {
 	return &insertOrderBuilder{
+		ctx: ctx,
 		req: req,
 		db:  db,
 	}
 }
 
 func (b *insertOrderBuilder) initTransaction() error {
-	tx, err := b.db.BeginTxx(context.Background(), nil)
+	tx, err := b.db.BeginTxx(b.ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -51,13 +59,105 @@ func (b *insertOrderBuilder) commit() error {
 }
 
 
+// checkPickupSlot lock the slot until commit, so two orders cannot take the last place of the slot
+func (b *insertOrderBuilder) checkPickupSlot() error {
+	if b.req.PickupSlotId == 0 {
+		return nil
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"s"."capacity" - (
+			SELECT
+				COUNT(*)
+			FROM "orders" "o"
+			WHERE "o"."pickup_slot_id" = "s"."id"
+			AND "o"."status" <> 'canceled'
+		) AS "available"
+	FROM "pickup_slots" "s"
+	WHERE "s"."id" = $1
+	AND "s"."starts_at" > now()
+	FOR UPDATE;`
+
+	var available int
+	if err := b.tx.GetContext(ctx, &available, query, b.req.PickupSlotId); err != nil {
+		b.tx.Rollback()
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("pickup slot not found")
+		}
+		return fmt.Errorf("check pickup slot: %w", err)
+	}
+	if available <= 0 {
+		b.tx.Rollback()
+		return fmt.Errorf("pickup slot is full")
+	}
+	return nil
+}
+
+// checkSellers reject items of sellers who are not approved any more, the sellers are locked until commit
+// so a seller cannot be suspended in the middle of the order
+func (b *insertOrderBuilder) checkSellers() error {
+	sellerIds := make([]string, 0)
+	for _, item := range b.req.Products {
+		if item.Product.SellerId != "" {
+			sellerIds = append(sellerIds, item.Product.SellerId)
+		}
+	}
+	if len(sellerIds) == 0 {
+		return nil
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
+	query, args, err := sqlx.In(`
+	SELECT
+		"user_id"
+	FROM "sellers"
+	WHERE "user_id" IN (?)
+	AND "status" = 'approved'
+	FOR SHARE;`, sellerIds)
+	if err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("build check sellers query: %w", err)
+	}
+
+	approved := make([]string, 0)
+	if err := b.tx.SelectContext(ctx, &approved, b.tx.Rebind(query), args...); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("check sellers: %w", err)
+	}
+	approvedMap := make(map[string]bool)
+	for _, id := range approved {
+		approvedMap[id] = true
+	}
+
+	errs := make(entities.ValidationErrors, 0)
+	for i, item := range b.req.Products {
+		if item.Product.SellerId != "" && !approvedMap[item.Product.SellerId] {
+			errs = append(errs, &entities.FieldError{
+				Field: fmt.Sprintf("products[%d]", i),
+				Msg:   fmt.Sprintf("%s is not available", item.Product.Title),
+			})
+		}
+	}
+	if len(errs) != 0 {
+		b.tx.Rollback()
+		return errs
+	}
+	return nil
+}
+
 func (b *insertOrderBuilder) getOrderId() string {
 	return b.req.Id
 }
 
 
 func (b *insertOrderBuilder) insertOrder() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
 	defer cancel()
 	
 	query := `
@@ -65,11 +165,22 @@ func (b *insertOrderBuilder) insertOrder() error {
 		"user_id",
 		"contact",
 		"address",
+		"shipping_address",
+		"shipping_method",
+		"shipping_fee",
 		"transfer_slip",
-		"status"
+		"status",
+		"gift",
+		"gift_token",
+		"fulfillment",
+		"pickup_slot_id",
+		"store_id",
+		"tax",
+		"discount",
+		"coupon_code"
 	)
 	VALUES
-	($1, $2, $3, $4, $5)
+	($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, NULLIF($10, ''), $11, NULLIF($12, 0), NULLIF($13, ''), $14, $15, NULLIF($16, ''))
 		RETURNING "id";`
 
 	if err := b.tx.QueryRowxContext(
@@ -78,8 +189,19 @@ func (b *insertOrderBuilder) insertOrder() error {
 		b.req.UserId,
 		b.req.Contact,
 		b.req.Address,
+		b.req.ShippingAddress,
+		b.req.ShippingMethod,
+		b.req.ShippingFee,
 		b.req.TransferSlip,
 		b.req.Status,
+		b.req.Gift,
+		b.req.GiftToken,
+		b.req.Fulfillment,
+		b.req.PickupSlotId,
+		b.req.StoreId,
+		b.req.Tax,
+		b.req.Discount,
+		b.req.CouponCode,
 	).Scan(&b.req.Id); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert order: %w", err)
@@ -90,29 +212,33 @@ func (b *insertOrderBuilder) insertOrder() error {
 
 
 func (b *insertOrderBuilder) insertProductsOrder() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
 	defer cancel()
 
+	// commission rate ของ seller ตอนขาย เก็บไว้กับ item
 	query := `
 	INSERT INTO "products_orders" (
 		"order_id",
 		"qty",
-		"product"
+		"product",
+		"seller_id",
+		"commission_rate"
 	)
 	VALUES`
 
 	lastIndex := 0
 	valueStack := make([]any, 0)
 	for i := range b.req.Products {
-		valueStack = append(valueStack, b.req.Id, b.req.Products[i].Qty, b.req.Products[i].Product)
+		valueStack = append(valueStack, b.req.Id, b.req.Products[i].Qty, b.req.Products[i].Product, b.req.Products[i].Product.SellerId)
 
+		value := fmt.Sprintf(`($%d, $%d, $%d, NULLIF($%d, ''), COALESCE((SELECT "commission_rate" FROM "sellers" WHERE "user_id" = $%d), 0))`, lastIndex+1, lastIndex+2, lastIndex+3, lastIndex+4, lastIndex+4)
 		if i != len(b.req.Products)-1 {
-			query += fmt.Sprintf(`($%d, $%d, $%d),`, lastIndex+1, lastIndex+2, lastIndex+3)
+			query += value + `,`
 		} else {
-			query += fmt.Sprintf(`($%d, $%d, $%d);`, lastIndex+1, lastIndex+2, lastIndex+3)
+			query += value + `;`
 
 		}
-		lastIndex += 3
+		lastIndex += 4
 	}
 
 	if _, err := b.tx.ExecContext(ctx, query, valueStack...); err != nil {
@@ -124,6 +250,60 @@ func (b *insertOrderBuilder) insertProductsOrder() error {
 }
 
 
+// claimReservation move the checkout hold of the customer to the order, the held stock is decremented when the order is paid.
+// The hold of an order does not expire, it is kept until the order is paid or canceled.
+// Orders without a checkout are still accepted, their stock is checked when paid
+func (b *insertOrderBuilder) claimReservation() error {
+	if b.req.Reservation == "" {
+		return nil
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
+	held := make([]*struct {
+		ProductId string `db:"product_id"`
+		Qty       int    `db:"qty"`
+	}, 0)
+	if err := b.tx.SelectContext(ctx, &held, `
+	UPDATE "stock_reservations" SET
+		"reference" = $1,
+		"expires_at" = 'infinity'
+	WHERE "reference" = $2
+	AND "user_id" = $3
+	AND "expires_at" > now()
+		RETURNING "product_id", "qty";`, b.req.Id, b.req.Reservation, b.req.UserId); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("claim reservation: %w", err)
+	}
+	if len(held) == 0 {
+		b.tx.Rollback()
+		return fmt.Errorf("reservation has expired")
+	}
+
+	heldQty := make(map[string]int)
+	for _, item := range held {
+		heldQty[item.ProductId] += item.Qty
+	}
+
+	errs := make(entities.ValidationErrors, 0)
+	for i, item := range b.req.Products {
+		heldQty[item.Product.Id] -= item.Qty
+		if heldQty[item.Product.Id] < 0 {
+			errs = append(errs, &entities.FieldError{
+				Field: fmt.Sprintf("products[%d]", i),
+				Msg:   fmt.Sprintf("%s is not reserved by the checkout", item.Product.Title),
+			})
+		}
+	}
+	if len(errs) != 0 {
+		b.tx.Rollback()
+		return errs
+	}
+	return nil
+}
+
+
 // engineer
 type insertOrderEngineer struct {
 	builder IInsertOrderBuilder
@@ -140,6 +320,14 @@ func (en *insertOrderEngineer) InsertOrder() (string, error) {
 		return "", err
 	}
 
+	if err := en.builder.checkPickupSlot() ; err != nil {
+		return "", err
+	}
+
+	if err := en.builder.checkSellers() ; err != nil {
+		return "", err
+	}
+
 	if err := en.builder.insertOrder() ; err != nil {
 		return "", err
 	}
@@ -148,6 +336,10 @@ func (en *insertOrderEngineer) InsertOrder() (string, error) {
 		return "", err
 	}
 
+	if err := en.builder.claimReservation() ; err != nil {
+		return "", err
+	}
+
 	if err := en.builder.commit() ; err != nil {
 		return "", err
 	}
@@ -2,20 +2,27 @@ package ordersRepositories
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/NatthawutSK/ri-shop/modules/orders"
 	"github.com/NatthawutSK/ri-shop/modules/orders/ordersPattern"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
 	"github.com/jmoiron/sqlx"
 )
 
 type IOrdersRepository interface {
-	FindOneOrder(orderId string) (*orders.Order, error)
-	FindOrder(req *orders.OrderFilter) ([]*orders.Order, int)
-	InsertOrder(req *orders.Order) (string, error)
-	UpdateOrder(req *orders.OrderUpdate) error
+	FindOneOrder(ctx context.Context, orderId string) (*orders.Order, error)
+	FindOrder(ctx context.Context, req *orders.OrderFilter) ([]*orders.Order, int)
+	InsertOrder(ctx context.Context, req *orders.Order) (string, error)
+	UpdateOrder(ctx context.Context, req *orders.OrderUpdate) error
+	TransitionOrder(ctx context.Context, req *orders.StatusTransition) error
+	FindOrderIdByGiftToken(ctx context.Context, token string) (string, error)
+	UpdatePickupCode(ctx context.Context, orderId, code string) error
+	FindUserEmail(ctx context.Context, userId string) (string, error)
 }
 
 type ordersRepository struct {
@@ -28,7 +35,7 @@ func OrdersRepository(db *sqlx.DB) IOrdersRepository {
 	}
 }
 
-func (r *ordersRepository) FindOneOrder(orderId string) (*orders.Order, error) {
+func (r *ordersRepository) FindOneOrder(ctx context.Context, orderId string) (*orders.Order, error) {
 	query := `
 	SELECT
 		to_jsonb("t")
@@ -36,6 +43,7 @@ func (r *ordersRepository) FindOneOrder(orderId string) (*orders.Order, error) {
 		SELECT
 			"o"."id",
 			"o"."user_id",
+			COALESCE("o"."store_id", '') AS "store_id",
 			"o"."transfer_slip",
 			"o"."status",
 			(
@@ -51,15 +59,62 @@ func (r *ordersRepository) FindOneOrder(orderId string) (*orders.Order, error) {
 				) AS "pt"
 			) AS "products",
 			"o"."address",
+			"o"."shipping_address",
 			"o"."contact",
 			(
 				SELECT
 					SUM(COALESCE(("po"."product"->>'price')::FLOAT*("po"."qty")::FLOAT, 0))
 				FROM "products_orders" "po"
 				WHERE "po"."order_id" = "o"."id"
-			) AS "total_paid",
+			) - "o"."discount" + "o"."shipping_fee" + COALESCE(("o"."tax"->>'total')::FLOAT, 0) AS "total_paid",
+			"o"."discount",
+			COALESCE("o"."coupon_code", '') AS "coupon_code",
+			COALESCE("o"."shipping_method", '') AS "shipping_method",
+			"o"."shipping_fee",
+			"o"."tax",
+			COALESCE("o"."tracking_number", '') AS "tracking_number",
+			"o"."tags",
+			"o"."on_hold",
+			"o"."gift",
+			COALESCE("o"."gift_token", '') AS "gift_token",
+			"o"."fulfillment",
+			(
+				SELECT
+					to_jsonb("pkt")
+				FROM (
+					SELECT
+						"ps"."id" AS "slot_id",
+						"pl"."id" AS "location_id",
+						"pl"."title" AS "location",
+						"pl"."address",
+						"pl"."phone",
+						"ps"."starts_at",
+						"ps"."ends_at",
+						COALESCE("o"."pickup_code", '') AS "code"
+					FROM "pickup_slots" "ps"
+						LEFT JOIN "pickup_locations" "pl" ON "pl"."id" = "ps"."location_id"
+					WHERE "ps"."id" = "o"."pickup_slot_id"
+				) AS "pkt"
+			) AS "pickup",
 			"o"."created_at",
-			"o"."updated_at"
+			"o"."updated_at",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("ht")), '[]'::json)
+				FROM (
+					SELECT
+						"h"."id",
+						"h"."order_id",
+						"h"."from_status",
+						"h"."to_status",
+						"h"."actor_id",
+						"h"."refund_required",
+						"h"."created_at"
+					FROM "order_status_history" "h"
+					WHERE "h"."order_id" = "o"."id"
+					ORDER BY "h"."id" ASC
+				) AS "ht"
+			) AS "history"
 		FROM "orders" "o"
 		WHERE "o"."id" = $1
 	) AS "t";`
@@ -74,7 +129,10 @@ func (r *ordersRepository) FindOneOrder(orderId string) (*orders.Order, error) {
 		Products: make([]*orders.ProductsOrder, 0),
 	}
 
-	if err := r.db.Get(&bytes, query, orderId); err != nil {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if err := r.db.GetContext(ctx, &bytes, query, orderId); err != nil {
 		return nil, fmt.Errorf("cannot get order: %w", err)
 	}
 
@@ -85,15 +143,15 @@ func (r *ordersRepository) FindOneOrder(orderId string) (*orders.Order, error) {
 	return order, nil
 }
 
-func (r *ordersRepository) FindOrder(req *orders.OrderFilter) ([]*orders.Order, int) {
-	builder := ordersPattern.FindOrderBuilder(r.db, req)
+func (r *ordersRepository) FindOrder(ctx context.Context, req *orders.OrderFilter) ([]*orders.Order, int) {
+	builder := ordersPattern.FindOrderBuilder(ctx, r.db, req)
 	engineer := ordersPattern.FindOrderEngineer(builder)
 
 	return engineer.FindOrder(), engineer.CountOrder()
 }
 
-func (r *ordersRepository) InsertOrder(req *orders.Order) (string, error) {
-	builder := ordersPattern.InsertOrderBuilder(req, r.db)
+func (r *ordersRepository) InsertOrder(ctx context.Context, req *orders.Order) (string, error) {
+	builder := ordersPattern.InsertOrderBuilder(ctx, req, r.db)
 	orderId, err := ordersPattern.InsertOrderEngineer(builder).InsertOrder()
 	if err != nil {
 		return "", err
@@ -148,7 +206,7 @@ func (r *ordersRepository) InsertOrder(req *orders.Order) (string, error) {
 // 	return nil
 // }
 
-func (r *ordersRepository) UpdateOrder(req *orders.OrderUpdate) error {
+func (r *ordersRepository) UpdateOrder(ctx context.Context, req *orders.OrderUpdate) error {
 	query := `
 	UPDATE "orders" SET`
 
@@ -174,6 +232,15 @@ func (r *ordersRepository) UpdateOrder(req *orders.OrderUpdate) error {
 		lastIndex++
 	}
 
+	if req.TrackingNumber != "" {
+		values = append(values, req.TrackingNumber)
+
+		queryWhereStack = append(queryWhereStack, fmt.Sprintf(`
+		"tracking_number" = $%d?`, lastIndex))
+
+		lastIndex++
+	}
+
 	values = append(values, req.Id)
 
 	queryClose := fmt.Sprintf(`
@@ -188,8 +255,131 @@ func (r *ordersRepository) UpdateOrder(req *orders.OrderUpdate) error {
 	}
 	query += queryClose
 
-	if _, err := r.db.ExecContext(context.Background(), query, values...); err != nil {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, values...); err != nil {
 		return fmt.Errorf("update order failed: %v", err)
 	}
 	return nil
 }
+
+// TransitionOrder change the status only when it is still FromStatus and record the transition,
+// so two concurrent updates cannot both pass the state machine
+func (r *ordersRepository) TransitionOrder(ctx context.Context, req *orders.StatusTransition) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	UPDATE "orders" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "status" = $3;`, req.ToStatus, req.OrderId, req.FromStatus)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update order status failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return &orders.TransitionError{From: req.FromStatus, To: req.ToStatus, Reason: "order status has been changed"}
+	}
+
+	query := `
+	INSERT INTO "order_status_history" (
+		"order_id",
+		"from_status",
+		"to_status",
+		"actor_id",
+		"refund_required"
+	)
+	VALUES ($1, $2, $3, $4, $5)
+		RETURNING "id", "created_at";`
+
+	if err := tx.QueryRowContext(ctx, query, req.OrderId, req.FromStatus, req.ToStatus, req.ActorId, req.RefundRequired).Scan(&req.Id, &req.CreatedAt); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert order status history failed: %v", err)
+	}
+
+	// stock ถูกตัดตอนจ่ายเงิน hold ของ order ถูกแปลงเป็นการตัด stock, ยกเลิกก่อนจ่ายคืน hold
+	switch req.ToStatus {
+	case orders.StatusPaid:
+		productIds := make([]string, 0)
+		if err := tx.SelectContext(ctx, &productIds, `SELECT DISTINCT "product_id" FROM "stock_reservations" WHERE "reference" = $1;`, req.OrderId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("find reservations failed: %v", err)
+		}
+		if err := locks.LockStock(ctx, tx, productIds...); err != nil {
+			tx.Rollback()
+			return err
+		}
+		var shortProductId sql.NullString
+		if err := tx.GetContext(ctx, &shortProductId, `SELECT convert_reservations($1);`, req.OrderId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("convert reservations failed: %v", err)
+		}
+		if shortProductId.Valid {
+			tx.Rollback()
+			return &orders.TransitionError{From: req.FromStatus, To: req.ToStatus, Reason: fmt.Sprintf("insufficient stock for product %s", shortProductId.String)}
+		}
+	case orders.StatusCanceled:
+		if _, err := tx.ExecContext(ctx, `DELETE FROM "stock_reservations" WHERE "reference" = $1;`, req.OrderId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("release reservations failed: %v", err)
+		}
+	}
+
+	// commission ของสินค้า seller คิดตอน completed ด้วย rule ที่ใช้อยู่ตอนนั้น แล้วเก็บไว้กับ item
+	if req.ToStatus == orders.StatusCompleted {
+		if _, err := tx.ExecContext(ctx, `SELECT evaluate_commission($1);`, req.OrderId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("evaluate commission failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+func (r *ordersRepository) FindOrderIdByGiftToken(ctx context.Context, token string) (string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var orderId string
+	if err := r.db.GetContext(ctx, &orderId, `SELECT "id" FROM "orders" WHERE "gift_token" = $1;`, token); err != nil {
+		return "", fmt.Errorf("gift not found")
+	}
+	return orderId, nil
+}
+
+func (r *ordersRepository) UpdatePickupCode(ctx context.Context, orderId, code string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE "orders" SET "pickup_code" = $1 WHERE "id" = $2;`, code, orderId); err != nil {
+		return fmt.Errorf("update pickup code failed: %v", err)
+	}
+	return nil
+}
+
+func (r *ordersRepository) FindUserEmail(ctx context.Context, userId string) (string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var email string
+	if err := r.db.GetContext(ctx, &email, `SELECT "email" FROM "users" WHERE "id" = $1;`, userId); err != nil {
+		return "", fmt.Errorf("find user email failed: %v", err)
+	}
+	return email, nil
+}
@@ -0,0 +1,156 @@
+package ordersUsecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"log"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+)
+
+const giftMessageMaxLength = 500
+
+// prepareGift validate the gift and ship the order to the recipient, every invalid field is returned
+func (u *ordersUsecase) prepareGift(req *orders.Order) error {
+	gift := req.Gift
+	errs := make(entities.ValidationErrors, 0)
+
+	if gift.Recipient == nil {
+		errs = append(errs, &entities.FieldError{Field: "gift.recipient", Msg: "is required"})
+	} else if err := gift.Recipient.Validate(); err != nil {
+		fields, ok := err.(entities.ValidationErrors)
+		if !ok {
+			return err
+		}
+		for _, field := range fields {
+			errs = append(errs, &entities.FieldError{Field: "gift.recipient." + field.Field, Msg: field.Msg})
+		}
+	}
+
+	if gift.Email = strings.TrimSpace(gift.Email); gift.Email != "" {
+		address, err := mail.ParseAddress(gift.Email)
+		if err != nil {
+			errs = append(errs, &entities.FieldError{Field: "gift.email", Msg: "is invalid"})
+		} else {
+			gift.Email = address.Address
+		}
+	}
+
+	gift.Message = strings.TrimSpace(gift.Message)
+	if utf8.RuneCountInString(gift.Message) > giftMessageMaxLength {
+		errs = append(errs, &entities.FieldError{Field: "gift.message", Msg: fmt.Sprintf("must be at most %d characters", giftMessageMaxLength)})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("generate gift token failed: %v", err)
+	}
+	req.GiftToken = hex.EncodeToString(token)
+
+	// id ของสมุดที่อยู่ไม่เกี่ยวกับ recipient
+	gift.Recipient.Id = ""
+	gift.Recipient.UserId = ""
+	req.AddressId = ""
+	req.ShippingAddress = gift.Recipient
+	req.Address = gift.Recipient.Format()
+	if req.Contact == "" {
+		req.Contact = gift.Recipient.Phone
+	}
+	return nil
+}
+
+// notifyGiftRecipient queue the email with the gift token, a failure is only logged like the order hooks
+func (u *ordersUsecase) notifyGiftRecipient(ctx context.Context, order *orders.Order) {
+	if order.Gift == nil || order.Gift.Email == "" {
+		return
+	}
+
+	body := fmt.Sprintf(
+		"<p>A gift is on its way to you.</p><p>Gift tracking code: <b>%s</b></p>",
+		html.EscapeString(order.GiftToken),
+	)
+	if order.Gift.Message != "" {
+		body += fmt.Sprintf("<blockquote>%s</blockquote>", html.EscapeString(order.Gift.Message))
+	}
+
+	if _, err := u.notificationsUsecase.SendEmail(ctx, &notifications.Email{
+		To:      order.Gift.Email,
+		Subject: "A gift is on its way",
+		Body:    body,
+	}); err != nil {
+		log.Printf("notify gift recipient of order %s failed: %v\n", order.Id, err)
+	}
+}
+
+func (u *ordersUsecase) FindPackingSlip(ctx context.Context, orderId string) (*orders.PackingSlip, error) {
+	order, err := u.ordersRepository.FindOneOrder(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	slip := &orders.PackingSlip{
+		OrderId: order.Id,
+		ShipTo:  order.ShippingAddress,
+		Address: order.Address,
+		Items:   make([]*orders.PackingSlipItem, 0),
+	}
+	if order.Gift != nil {
+		slip.GiftMessage = order.Gift.Message
+	}
+	for _, p := range order.Products {
+		slip.Items = append(slip.Items, &orders.PackingSlipItem{
+			ProductId: p.Product.Id,
+			Title:     p.Product.Title,
+			Qty:       p.Qty,
+		})
+	}
+	return slip, nil
+}
+
+func (u *ordersUsecase) FindGiftTracking(ctx context.Context, token string) (*orders.GiftTracking, error) {
+	orderId, err := u.ordersRepository.FindOrderIdByGiftToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	order, err := u.ordersRepository.FindOneOrder(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	tracking := &orders.GiftTracking{
+		OrderId:        order.Id,
+		Status:         order.Status,
+		ShippingMethod: order.ShippingMethod,
+		TrackingNumber: order.TrackingNumber,
+		Items:          make([]*orders.GiftTrackingItem, 0),
+	}
+	hidePrices := true
+	if order.Gift != nil {
+		tracking.Message = order.Gift.Message
+		hidePrices = order.Gift.HidePrices
+	}
+	for _, p := range order.Products {
+		item := &orders.GiftTrackingItem{
+			Title: p.Product.Title,
+			Qty:   p.Qty,
+		}
+		if !hidePrices {
+			price := p.Product.Price
+			item.Price = &price
+		}
+		tracking.Items = append(tracking.Items, item)
+	}
+	return tracking, nil
+}
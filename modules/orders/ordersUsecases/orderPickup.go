@@ -0,0 +1,86 @@
+package ordersUsecases
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"html"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+)
+
+// preparePickup check the slot and set the store as the address of the order, capacity is checked again when inserting
+func (u *ordersUsecase) preparePickup(ctx context.Context, req *orders.Order) error {
+	if req.Gift != nil {
+		return fmt.Errorf("gift order cannot be picked up")
+	}
+	if req.PickupSlotId <= 0 {
+		return fmt.Errorf("pickup_slot_id is required")
+	}
+
+	slot, err := u.pickupsRepository.FindOneSlot(ctx, req.StoreId, req.PickupSlotId)
+	if err != nil {
+		return err
+	}
+	if slot.Location == nil || !slot.Location.Active {
+		return fmt.Errorf("pickup location is closed")
+	}
+
+	req.AddressId = ""
+	req.ShippingAddress = nil
+	req.ShippingMethod = ""
+	req.Address = fmt.Sprintf("Pickup at %s, %s", slot.Location.Title, slot.Location.Address)
+	return nil
+}
+
+func newPickupCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("generate pickup code failed: %v", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// notifyPickupReady email the code to the customer, a failure is only logged like the order hooks
+func (u *ordersUsecase) notifyPickupReady(ctx context.Context, order *orders.Order, code string) {
+	email, err := u.ordersRepository.FindUserEmail(ctx, order.UserId)
+	if err != nil {
+		log.Printf("notify pickup of order %s failed: %v\n", order.Id, err)
+		return
+	}
+
+	body := fmt.Sprintf("<p>Your order %s is ready for pickup.</p>", html.EscapeString(order.Id))
+	if order.Pickup != nil {
+		body += fmt.Sprintf(
+			"<p>%s<br>%s<br>%s - %s</p>",
+			html.EscapeString(order.Pickup.Location),
+			html.EscapeString(order.Pickup.Address),
+			html.EscapeString(order.Pickup.StartsAt),
+			html.EscapeString(order.Pickup.EndsAt),
+		)
+	}
+	body += fmt.Sprintf("<p>Show this code to our staff: <b>%s</b></p>", code)
+
+	if _, err := u.notificationsUsecase.SendEmail(ctx, &notifications.Email{
+		To:      email,
+		Subject: "Your order is ready for pickup",
+		Body:    body,
+	}); err != nil {
+		log.Printf("notify pickup of order %s failed: %v\n", order.Id, err)
+	}
+}
+
+// ConfirmPickup complete a pickup order when the code shown by the customer is correct
+func (u *ordersUsecase) ConfirmPickup(ctx context.Context, req *orders.PickupConfirmReq) (*orders.Order, error) {
+	return u.UpdateOrder(ctx, &orders.OrderUpdate{
+		Id:          req.OrderId,
+		Status:      orders.StatusCompleted,
+		ActorId:     req.ActorId,
+		ActorRoleId: 2,
+		PickupCode:  strings.TrimSpace(req.Code),
+	})
+}
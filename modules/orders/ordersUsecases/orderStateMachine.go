@@ -0,0 +1,61 @@
+package ordersUsecases
+
+import "github.com/NatthawutSK/ri-shop/modules/orders"
+
+type transitionRule struct {
+	adminOnly      bool
+	refundRequired bool   // the payment has to be refunded
+	fulfillment    string // only for orders of this fulfillment, any when empty
+	pickupCode     bool   // the pickup code of the order is required
+}
+
+// orderTransitions is every allowed status change, completed and canceled are final
+//
+//	waiting -> paid -> shipping ---------> completed
+//	   |        |  \-> ready_for_pickup -/
+//	   |        |         |        |
+//	   +--------+---------+--------+-----> canceled
+var orderTransitions = map[string]map[string]*transitionRule{
+	orders.StatusWaiting: {
+		orders.StatusPaid:     {adminOnly: true},
+		orders.StatusCanceled: {},
+	},
+	orders.StatusPaid: {
+		orders.StatusShipping:       {adminOnly: true, fulfillment: orders.FulfillmentDelivery},
+		orders.StatusReadyForPickup: {adminOnly: true, fulfillment: orders.FulfillmentPickup},
+		orders.StatusCanceled:       {adminOnly: true, refundRequired: true},
+	},
+	orders.StatusShipping: {
+		orders.StatusCompleted: {adminOnly: true},
+		orders.StatusCanceled:  {adminOnly: true, refundRequired: true},
+	},
+	orders.StatusReadyForPickup: {
+		orders.StatusCompleted: {adminOnly: true, pickupCode: true},
+		orders.StatusCanceled:  {adminOnly: true, refundRequired: true},
+	},
+	orders.StatusCompleted: {},
+	orders.StatusCanceled:  {},
+}
+
+// checkTransition return the rule of the status change or an *orders.TransitionError
+func checkTransition(from, to string, isAdmin bool, fulfillment string) (*transitionRule, error) {
+	rules, ok := orderTransitions[from]
+	if !ok {
+		return nil, &orders.TransitionError{From: from, To: to, Reason: "unknown status"}
+	}
+	if len(rules) == 0 {
+		return nil, &orders.TransitionError{From: from, To: to, Reason: "order is " + from}
+	}
+
+	rule, ok := rules[to]
+	if !ok {
+		return nil, &orders.TransitionError{From: from, To: to, Reason: "transition is not allowed"}
+	}
+	if rule.adminOnly && !isAdmin {
+		return nil, &orders.TransitionError{From: from, To: to, Reason: "only admin can make this transition"}
+	}
+	if rule.fulfillment != "" && rule.fulfillment != fulfillment {
+		return nil, &orders.TransitionError{From: from, To: to, Reason: "only for " + rule.fulfillment + " orders"}
+	}
+	return rule, nil
+}
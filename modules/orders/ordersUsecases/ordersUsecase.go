@@ -1,36 +1,65 @@
 package ordersUsecases
 
 import (
+	"context"
+	"crypto/subtle"
 	"fmt"
 	"math"
+	"time"
 
+	"github.com/NatthawutSK/ri-shop/modules/addresses/addressesUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/orders"
 	"github.com/NatthawutSK/ri-shop/modules/orders/ordersRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/pickups/pickupsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/products"
 	"github.com/NatthawutSK/ri-shop/modules/products/productsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/modules/shipping/shippingUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/workflows"
+	"github.com/NatthawutSK/ri-shop/modules/workflows/workflowsUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
 )
 
 type IOrdersUsecase interface {
-	FindOneOrder(orderId string) (*orders.Order, error)
-	FindOrder(req *orders.OrderFilter) *entities.PaginateRes
-	InsertOrder(req *orders.Order) (*orders.Order, error)
-	UpdateOrder(req *orders.OrderUpdate) (*orders.Order, error)
+	FindOneOrder(ctx context.Context, orderId string) (*orders.Order, error)
+	FindOrder(ctx context.Context, req *orders.OrderFilter) *entities.PaginateRes
+	InsertOrder(ctx context.Context, req *orders.Order) (*orders.Order, error)
+	UpdateOrder(ctx context.Context, req *orders.OrderUpdate) (*orders.Order, error)
+	FindPackingSlip(ctx context.Context, orderId string) (*orders.PackingSlip, error)
+	FindGiftTracking(ctx context.Context, token string) (*orders.GiftTracking, error)
+	ConfirmPickup(ctx context.Context, req *orders.PickupConfirmReq) (*orders.Order, error)
 }
 
 type ordersUsecase struct {
-	ordersRepository   ordersRepositories.IOrdersRepository
-	productsRepository productsRepositories.IProductsRepository
+	ordersRepository     ordersRepositories.IOrdersRepository
+	productsRepository   productsRepositories.IProductsRepository
+	workflowsUsecase     workflowsUsecases.IWorkflowsUsecase
+	addressesUsecase     addressesUsecases.IAddressesUsecase
+	shippingUsecase      shippingUsecases.IShippingUsecase
+	notificationsUsecase notificationsUsecases.INotificationsUsecase
+	pickupsRepository    pickupsRepositories.IPickupsRepository
+	taxesUsecase         taxesUsecases.ITaxesUsecase
 }
 
-func OrdersUsecase(ordersRepo ordersRepositories.IOrdersRepository, productsRepo productsRepositories.IProductsRepository) IOrdersUsecase {
+func OrdersUsecase(ordersRepo ordersRepositories.IOrdersRepository, productsRepo productsRepositories.IProductsRepository, workflowsUsecase workflowsUsecases.IWorkflowsUsecase, addressesUsecase addressesUsecases.IAddressesUsecase, shippingUsecase shippingUsecases.IShippingUsecase, notificationsUsecase notificationsUsecases.INotificationsUsecase, pickupsRepo pickupsRepositories.IPickupsRepository, taxesUsecase taxesUsecases.ITaxesUsecase) IOrdersUsecase {
 	return &ordersUsecase{
-		ordersRepository:   ordersRepo,
-		productsRepository: productsRepo,
+		ordersRepository:     ordersRepo,
+		productsRepository:   productsRepo,
+		workflowsUsecase:     workflowsUsecase,
+		addressesUsecase:     addressesUsecase,
+		shippingUsecase:      shippingUsecase,
+		notificationsUsecase: notificationsUsecase,
+		pickupsRepository:    pickupsRepo,
+		taxesUsecase:         taxesUsecase,
 	}
 }
 
-func (u *ordersUsecase) FindOneOrder(orderId string) (*orders.Order, error) {
-	order, err := u.ordersRepository.FindOneOrder(orderId)
+func (u *ordersUsecase) FindOneOrder(ctx context.Context, orderId string) (*orders.Order, error) {
+	order, err := u.ordersRepository.FindOneOrder(ctx, orderId)
 	if err != nil {
 		return nil, err
 	}
@@ -38,8 +67,8 @@ func (u *ordersUsecase) FindOneOrder(orderId string) (*orders.Order, error) {
 	return order, nil
 }
 
-func (u *ordersUsecase) FindOrder(req *orders.OrderFilter) *entities.PaginateRes {
-	orders, count := u.ordersRepository.FindOrder(req)
+func (u *ordersUsecase) FindOrder(ctx context.Context, req *orders.OrderFilter) *entities.PaginateRes {
+	orders, count := u.ordersRepository.FindOrder(ctx, req)
 
 	return &entities.PaginateRes{
 		Data:      orders,
@@ -50,7 +79,39 @@ func (u *ordersUsecase) FindOrder(req *orders.OrderFilter) *entities.PaginateRes
 	}
 }
 
-func (u *ordersUsecase) InsertOrder(req *orders.Order) (*orders.Order, error) {
+func (u *ordersUsecase) InsertOrder(ctx context.Context, req *orders.Order) (*orders.Order, error) {
+	switch req.Fulfillment {
+	case "", orders.FulfillmentDelivery:
+		req.Fulfillment = orders.FulfillmentDelivery
+		req.PickupSlotId = 0
+	case orders.FulfillmentPickup:
+	default:
+		return nil, fmt.Errorf("fulfillment must be delivery or pickup")
+	}
+
+	if req.Fulfillment == orders.FulfillmentPickup {
+		// รับที่ร้าน ไม่มีค่าส่งและไม่ใช้ที่อยู่
+		if err := u.preparePickup(ctx, req); err != nil {
+			return nil, err
+		}
+	} else if req.Gift != nil {
+		// ของขวัญส่งไปที่ผู้รับ ไม่ใช้สมุดที่อยู่ของคนซื้อ
+		if err := u.prepareGift(req); err != nil {
+			return nil, err
+		}
+	} else if req.AddressId != "" || req.Address == "" {
+		// ใช้ address จากสมุดที่อยู่ ถ้าเลือกมาหรือไม่ได้พิมพ์ address มาเอง
+		address, err := u.addressesUsecase.FindCheckoutAddress(ctx, req.UserId, req.AddressId)
+		if err != nil {
+			return nil, err
+		}
+		req.ShippingAddress = address
+		req.Address = address.Format()
+		if req.Contact == "" {
+			req.Contact = address.Phone
+		}
+	}
+
 	// Check product is exist and correct price
 	for i := range req.Products {
 		if req.Products[i].Product == nil {
@@ -58,38 +119,209 @@ func (u *ordersUsecase) InsertOrder(req *orders.Order) (*orders.Order, error) {
 
 		}
 
-		prod, err := u.productsRepository.FindOneProduct(req.Products[i].Product.Id)
+		prod, err := u.productsRepository.FindOneProduct(ctx, req.Products[i].Product.Id)
 		if err != nil {
 			return nil, fmt.Errorf("find one product failed : %v", err)
 		}
+		// สั่งได้เฉพาะสินค้าของร้านเดียวกับ order
+		if prod.StoreId != req.StoreId {
+			return nil, entities.ValidationErrors{{
+				Field: fmt.Sprintf("products[%d]", i),
+				Msg:   "product not found",
+			}}
+		}
+		// draft ดูได้ด้วย preview token แต่สั่งซื้อไม่ได้, สินค้าที่ขายช่วงเวลาจำกัดสั่งได้เฉพาะในช่วงนั้น
+		if prod.Status != products.StatusPublished || !prod.AvailableAt(time.Now()) {
+			return nil, entities.ValidationErrors{{
+				Field: fmt.Sprintf("products[%d]", i),
+				Msg:   fmt.Sprintf("%s is not available", prod.Title),
+			}}
+		}
 
 		// set price from product
 		req.TotalPaid += req.Products[i].Product.Price * float64(req.Products[i].Qty)
 		req.Products[i].Product = prod
 	}
 
-	orderId, err := u.ordersRepository.InsertOrder(req)
+	// สินค้าบางอย่างส่งได้บางภูมิภาค, address ที่พิมพ์มาเองไม่มี country ตรวจไม่ได้
+	if req.ShippingAddress != nil {
+		errs := make(entities.ValidationErrors, 0)
+		for i := range req.Products {
+			if !req.Products[i].Product.ShipsTo(req.ShippingAddress.Country, req.ShippingAddress.State) {
+				errs = append(errs, &entities.FieldError{
+					Field: fmt.Sprintf("products[%d]", i),
+					Msg:   fmt.Sprintf("%s can not be shipped to %s", req.Products[i].Product.Title, req.ShippingAddress.Region()),
+				})
+			}
+		}
+		if len(errs) != 0 {
+			return nil, errs
+		}
+	}
+
+	// ค่าส่งคิดใหม่จาก method ที่เลือก ไม่ใช้ค่าที่ client ส่งมา
+	req.ShippingFee = 0
+	if req.ShippingMethod != "" {
+		if req.ShippingAddress == nil {
+			return nil, fmt.Errorf("shipping address is required for shipping method")
+		}
+
+		quoteReq := &shipping.QuoteReq{
+			StoreId:     req.StoreId,
+			Items:       make([]*shipping.QuoteItem, 0),
+			Destination: req.ShippingAddress,
+		}
+		for i := range req.Products {
+			quoteReq.Items = append(quoteReq.Items, &shipping.QuoteItem{
+				ProductId: req.Products[i].Product.Id,
+				Qty:       req.Products[i].Qty,
+			})
+		}
+
+		rate, err := u.shippingUsecase.FindRate(ctx, quoteReq, req.ShippingMethod)
+		if err != nil {
+			return nil, err
+		}
+		req.ShippingMethod = rate.Method
+		req.ShippingFee = rate.Fee
+	}
+
+	// ภาษีคิดจากราคาสินค้าและภูมิภาคที่ส่ง, address ที่พิมพ์มาเองและรับที่ร้านใช้ rate ทั่วไป
+	taxReq := &taxes.CalculateReq{
+		Lines:       make([]*taxes.CalculateLine, 0, len(req.Products)),
+		ShippingFee: req.ShippingFee,
+	}
+	if req.ShippingAddress != nil {
+		taxReq.Country = req.ShippingAddress.Country
+		taxReq.State = req.ShippingAddress.State
+	}
+	var subtotal float64
+	for _, item := range req.Products {
+		line := &taxes.CalculateLine{
+			ProductId: item.Product.Id,
+			Amount:    item.Product.Price * float64(item.Qty),
+		}
+		if item.Product.Category != nil {
+			line.CategoryId = item.Product.Category.Id
+		}
+		taxReq.Lines = append(taxReq.Lines, line)
+		subtotal += line.Amount
+	}
+	// ส่วนลดมาจาก checkout เท่านั้น ไม่เกินราคาสินค้า และหักก่อนคิดภาษี
+	req.Discount = math.Round(math.Max(0, math.Min(req.Discount, subtotal))*100) / 100
+	if req.Discount == 0 {
+		req.CouponCode = ""
+	}
+	taxReq.AllocateDiscount(req.Discount)
+	tax, err := u.taxesUsecase.Calculate(ctx, taxReq)
+	if err != nil {
+		return nil, err
+	}
+	req.Tax = tax
+
+	orderId, err := u.ordersRepository.InsertOrder(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	order, err := u.ordersRepository.FindOneOrder(orderId)
+	order, err := u.ordersRepository.FindOneOrder(ctx, orderId)
 	if err != nil {
 		return nil, err
 	}
 
+	events.Publish(orders.EventOrderCreated, &orders.OrderEvent{
+		OrderId:   order.Id,
+		UserId:    order.UserId,
+		StoreId:   order.StoreId,
+		Status:    order.Status,
+		TotalPaid: order.TotalPaid,
+	})
+
 	return order, nil
 }
 
-func (u *ordersUsecase) UpdateOrder(req *orders.OrderUpdate) (*orders.Order, error) {
-	if err := u.ordersRepository.UpdateOrder(req); err != nil {
+func (u *ordersUsecase) UpdateOrder(ctx context.Context, req *orders.OrderUpdate) (*orders.Order, error) {
+	before, err := u.ordersRepository.FindOneOrder(ctx, req.Id)
+	if err != nil {
 		return nil, err
 	}
 
-	order, err := u.ordersRepository.FindOneOrder(req.Id)
+	if req.Status != "" && req.Status != before.Status {
+		rule, err := checkTransition(before.Status, req.Status, req.ActorRoleId == 2, before.Fulfillment)
+		if err != nil {
+			return nil, err
+		}
+		if rule.pickupCode && (before.Pickup == nil || before.Pickup.Code == "" ||
+			subtle.ConstantTimeCompare([]byte(req.PickupCode), []byte(before.Pickup.Code)) != 1) {
+			return nil, fmt.Errorf("pickup code is invalid")
+		}
+
+		if err := u.ordersRepository.TransitionOrder(ctx, &orders.StatusTransition{
+			OrderId:        before.Id,
+			FromStatus:     before.Status,
+			ToStatus:       req.Status,
+			ActorId:        req.ActorId,
+			RefundRequired: rule.refundRequired,
+		}); err != nil {
+			return nil, err
+		}
+
+		pickupCode := ""
+		if req.Status == orders.StatusReadyForPickup {
+			if pickupCode, err = newPickupCode(); err != nil {
+				return nil, err
+			}
+			if err := u.ordersRepository.UpdatePickupCode(ctx, before.Id, pickupCode); err != nil {
+				return nil, err
+			}
+		}
+
+		u.workflowsUsecase.RunHooks(ctx, &workflows.Transition{
+			OrderId:    before.Id,
+			UserId:     before.UserId,
+			FromStatus: before.Status,
+			ToStatus:   req.Status,
+		})
+
+		events.Publish(orders.EventOrderStatusChanged, &orders.OrderEvent{
+			OrderId:        before.Id,
+			UserId:         before.UserId,
+			StoreId:        before.StoreId,
+			FromStatus:     before.Status,
+			Status:         req.Status,
+			TrackingNumber: before.TrackingNumber,
+		})
+
+		switch req.Status {
+		case orders.StatusShipping:
+			u.notifyGiftRecipient(ctx, before)
+		case orders.StatusReadyForPickup:
+			u.notifyPickupReady(ctx, before, pickupCode)
+		}
+	}
+
+	// status เปลี่ยนผ่าน state machine ไปแล้ว ที่เหลือคือ transfer slip กับ tracking number
+	req.Status = ""
+	if req.TransferSlip != nil || req.TrackingNumber != "" {
+		if err := u.ordersRepository.UpdateOrder(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := u.ordersRepository.FindOneOrder(ctx, req.Id)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.TrackingNumber != "" && req.TrackingNumber != before.TrackingNumber {
+		events.Publish(orders.EventOrderTrackingUpdated, &orders.OrderEvent{
+			OrderId:        order.Id,
+			UserId:         order.UserId,
+			StoreId:        order.StoreId,
+			Status:         order.Status,
+			TrackingNumber: order.TrackingNumber,
+		})
+	}
+
 	return order, nil
 }
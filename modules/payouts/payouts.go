@@ -0,0 +1,88 @@
+package payouts
+
+import (
+	"math"
+	"strconv"
+)
+
+const (
+	StatusPending = "pending" // waiting for the bank transfer
+	StatusPaid    = "paid"
+	StatusFailed  = "failed" // the amount is back in the balance of the seller
+)
+
+// DefaultMinAmount is the smallest balance paid in a batch, smaller balances wait for the next batch
+const DefaultMinAmount = 100.0
+
+// SellerBalance count completed orders only, a seller is paid after the customer received the items.
+// Refunds of an order already paid out reduce the next payout
+type SellerBalance struct {
+	SellerId    string  `json:"seller_id" db:"seller_id"`
+	ShopName    string  `json:"shop_name" db:"shop_name"`
+	BankAccount string  `json:"-" db:"bank_account"`
+	Sales       float64 `json:"sales" db:"sales"`
+	Commission  float64 `json:"commission" db:"commission"`
+	Refunded    float64 `json:"refunded" db:"refunded"`
+	PaidOut     float64 `json:"paid_out" db:"paid_out"` // pending and paid payouts
+	Balance     float64 `json:"balance" db:"-"`
+}
+
+// Calculate set Balance, sales minus commission, refunds and what has been paid out
+func (b *SellerBalance) Calculate() {
+	b.Balance = math.Round((b.Sales-b.Commission-b.Refunded-b.PaidOut)*100) / 100
+}
+
+type Batch struct {
+	Id        string    `json:"id" db:"id"`
+	Note      string    `json:"note" db:"note"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt string    `json:"created_at" db:"created_at"`
+	Total     float64   `json:"total" db:"total"`
+	Count     int       `json:"count" db:"count"`
+	Pending   int       `json:"pending" db:"pending"` // payouts not transferred yet
+	Payouts   []*Payout `json:"payouts,omitempty" db:"-"`
+}
+
+type BatchReq struct {
+	MinAmount float64 `json:"min_amount"`
+	Note      string  `json:"note"`
+	ActorId   string  `json:"-"`
+	StoreId   string  `json:"-"`
+}
+
+type Payout struct {
+	Id          string  `json:"id" db:"id"`
+	BatchId     string  `json:"batch_id" db:"batch_id"`
+	SellerId    string  `json:"seller_id" db:"seller_id"`
+	ShopName    string  `json:"shop_name" db:"shop_name"`
+	BankAccount string  `json:"bank_account" db:"bank_account"`
+	Amount      float64 `json:"amount" db:"amount"`
+	Status      string  `json:"status" db:"status"`
+	Reference   string  `json:"reference" db:"reference"` // reference of the bank transfer
+	Note        string  `json:"note" db:"note"`
+	PaidAt      *string `json:"paid_at" db:"paid_at"`
+	CreatedAt   string  `json:"created_at" db:"created_at"`
+	UpdatedAt   string  `json:"updated_at" db:"updated_at"`
+}
+
+// CsvHeader and CsvRow are the bank transfer file of a batch, payout id is the reference of the transfer
+func (p *Payout) CsvHeader() []string {
+	return []string{"payout_id", "seller_id", "shop_name", "bank_account", "amount"}
+}
+func (p *Payout) CsvRow() []string {
+	return []string{p.Id, p.SellerId, p.ShopName, p.BankAccount, strconv.FormatFloat(p.Amount, 'f', 2, 64)}
+}
+
+type PayoutUpdateReq struct {
+	Id        string `json:"-"`
+	StoreId   string `json:"-"`
+	Status    string `json:"status"`
+	Reference string `json:"reference"`
+	Note      string `json:"note"`
+}
+
+// PayoutHistory is seen by the seller
+type PayoutHistory struct {
+	Balance *SellerBalance `json:"balance"`
+	Payouts []*Payout      `json:"payouts"`
+}
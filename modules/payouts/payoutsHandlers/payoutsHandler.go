@@ -0,0 +1,252 @@
+package payoutsHandlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/payouts"
+	"github.com/NatthawutSK/ri-shop/modules/payouts/payoutsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type payoutsHandlerErrCode string
+
+const (
+	findBalanceErr      payoutsHandlerErrCode = "payouts-001"
+	insertBatchErr      payoutsHandlerErrCode = "payouts-002"
+	findBatchErr        payoutsHandlerErrCode = "payouts-003"
+	findOneBatchErr     payoutsHandlerErrCode = "payouts-004"
+	exportBatchErr      payoutsHandlerErrCode = "payouts-005"
+	updatePayoutErr     payoutsHandlerErrCode = "payouts-006"
+	findSellerPayoutErr payoutsHandlerErrCode = "payouts-007"
+)
+
+type IPayoutsHandler interface {
+	FindBalance(c *fiber.Ctx) error
+	AddBatch(c *fiber.Ctx) error
+	FindBatch(c *fiber.Ctx) error
+	FindOneBatch(c *fiber.Ctx) error
+	ExportBatch(c *fiber.Ctx) error
+	UpdatePayout(c *fiber.Ctx) error
+	FindSellerPayout(c *fiber.Ctx) error
+}
+
+type payoutsHandler struct {
+	cfg            config.IConfig
+	payoutsUsecase payoutsUsecases.IPayoutsUsecase
+}
+
+func PayoutsHandler(cfg config.IConfig, payoutsUsecase payoutsUsecases.IPayoutsUsecase) IPayoutsHandler {
+	return &payoutsHandler{
+		cfg:            cfg,
+		payoutsUsecase: payoutsUsecase,
+	}
+}
+
+func (h *payoutsHandler) FindBalance(c *fiber.Ctx) error {
+	balances, err := h.payoutsUsecase.FindBalance(c.UserContext(), c.Locals("storeId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findBalanceErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, balances).Res()
+}
+
+func (h *payoutsHandler) AddBatch(c *fiber.Ctx) error {
+	req := new(payouts.BatchReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertBatchErr),
+			err.Error(),
+		).Res()
+	}
+	req.ActorId = c.Locals("userId").(string)
+	req.StoreId = c.Locals("storeId").(string)
+
+	batch, err := h.payoutsUsecase.AddBatch(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "min amount is invalid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertBatchErr),
+				err.Error(),
+			).Res()
+		case "no seller has a balance to pay":
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnprocessableEntity.Code,
+				string(insertBatchErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertBatchErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, batch).Res()
+}
+
+func (h *payoutsHandler) FindBatch(c *fiber.Ctx) error {
+	batches, err := h.payoutsUsecase.FindBatch(c.UserContext(), c.Locals("storeId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findBatchErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, batches).Res()
+}
+
+func (h *payoutsHandler) FindOneBatch(c *fiber.Ctx) error {
+	batchId := strings.Trim(c.Params("batch_id"), " ")
+
+	batch, err := h.payoutsUsecase.FindOneBatch(c.UserContext(), c.Locals("storeId").(string), batchId)
+	if err != nil {
+		switch err.Error() {
+		case "payout batch not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneBatchErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneBatchErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, batch).Res()
+}
+
+// ExportBatch is the csv file uploaded to the bank, only pending payouts are transferred
+func (h *payoutsHandler) ExportBatch(c *fiber.Ctx) error {
+	batchId := strings.Trim(c.Params("batch_id"), " ")
+
+	batch, err := h.payoutsUsecase.FindOneBatch(c.UserContext(), c.Locals("storeId").(string), batchId)
+	if err != nil {
+		switch err.Error() {
+		case "payout batch not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(exportBatchErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(exportBatchErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.Write(new(payouts.Payout).CsvHeader()); err != nil {
+		return err
+	}
+	for _, payout := range batch.Payouts {
+		if payout.Status != payouts.StatusPending {
+			continue
+		}
+		if err := w.Write(payout.CsvRow()); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="payouts_%s.csv"`, batch.Id))
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+func (h *payoutsHandler) UpdatePayout(c *fiber.Ctx) error {
+	req := new(payouts.PayoutUpdateReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updatePayoutErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.Trim(c.Params("payout_id"), " ")
+	req.StoreId = c.Locals("storeId").(string)
+
+	payout, err := h.payoutsUsecase.UpdatePayout(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "payout not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updatePayoutErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "payout is not pending":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(updatePayoutErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "reference of the transfer is required",
+			strings.HasPrefix(err.Error(), "status must be"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updatePayoutErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updatePayoutErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, payout).Res()
+}
+
+func (h *payoutsHandler) FindSellerPayout(c *fiber.Ctx) error {
+	sellerId := strings.Trim(c.Params("user_id"), " ")
+
+	history, err := h.payoutsUsecase.FindSellerPayout(c.UserContext(), c.Locals("storeId").(string), sellerId)
+	if err != nil {
+		switch err.Error() {
+		case "seller not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findSellerPayoutErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findSellerPayoutErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, history).Res()
+}
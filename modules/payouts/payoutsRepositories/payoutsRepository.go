@@ -0,0 +1,344 @@
+package payoutsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/payouts"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPayoutsRepository interface {
+	FindBalance(ctx context.Context, storeId, sellerId string) ([]*payouts.SellerBalance, error)
+	InsertBatch(ctx context.Context, req *payouts.BatchReq) (string, error)
+	FindBatch(ctx context.Context, storeId string) ([]*payouts.Batch, error)
+	FindOneBatch(ctx context.Context, storeId, batchId string) (*payouts.Batch, error)
+	FindOnePayout(ctx context.Context, storeId, payoutId string) (*payouts.Payout, error)
+	UpdatePayout(ctx context.Context, req *payouts.PayoutUpdateReq) error
+	FindSellerPayout(ctx context.Context, sellerId string) ([]*payouts.Payout, error)
+}
+
+type payoutsRepository struct {
+	db *sqlx.DB
+}
+
+func PayoutsRepository(db *sqlx.DB) IPayoutsRepository {
+	return &payoutsRepository{
+		db: db,
+	}
+}
+
+// balanceQuery is the balance of every seller of the store ($2), or only seller $1. Refund of an order is
+// shared by the sellers of the order by their part of the items
+const balanceQuery = `
+	WITH "t" AS (
+		SELECT
+			"po"."seller_id",
+			SUM(("po"."product"->>'price')::FLOAT * "po"."qty") AS "sales",
+			SUM(COALESCE("po"."commission", ("po"."product"->>'price')::FLOAT * "po"."qty" * "po"."commission_rate")) AS "commission",
+			(
+				SELECT
+					COALESCE(SUM(("a"."product"->>'price')::FLOAT * "a"."qty"), 0)
+				FROM "products_orders" "a"
+				WHERE "a"."order_id" = "o"."id"
+			) AS "order_total",
+			(
+				SELECT
+					COALESCE(SUM("r"."amount"), 0)
+				FROM "refunds" "r"
+				WHERE "r"."order_id" = "o"."id"
+				AND "r"."status" != 'failed'
+			) AS "refunded"
+		FROM "orders" "o"
+		JOIN "products_orders" "po" ON "po"."order_id" = "o"."id"
+		WHERE "o"."status" = 'completed'
+		AND COALESCE("o"."store_id", '') = $2
+		AND "po"."seller_id" IS NOT NULL
+		AND ($1 = '' OR "po"."seller_id" = $1)
+		GROUP BY "po"."seller_id", "o"."id"
+	), "e" AS (
+		SELECT
+			"t"."seller_id",
+			SUM("t"."sales") AS "sales",
+			SUM("t"."commission") AS "commission",
+			SUM(
+				CASE WHEN "t"."order_total" > 0
+					THEN LEAST("t"."refunded", "t"."order_total") * "t"."sales" / "t"."order_total"
+					ELSE 0
+				END
+			) AS "refunded"
+		FROM "t"
+		GROUP BY "t"."seller_id"
+	)
+	SELECT
+		"s"."user_id" AS "seller_id",
+		"s"."shop_name",
+		"s"."bank_account",
+		ROUND(COALESCE("e"."sales", 0)::NUMERIC, 2)::FLOAT AS "sales",
+		ROUND(COALESCE("e"."commission", 0)::NUMERIC, 2)::FLOAT AS "commission",
+		ROUND(COALESCE("e"."refunded", 0)::NUMERIC, 2)::FLOAT AS "refunded",
+		ROUND((
+			SELECT
+				COALESCE(SUM("p"."amount"), 0)
+			FROM "payouts" "p"
+			WHERE "p"."seller_id" = "s"."user_id"
+			AND "p"."status" != 'failed'
+		)::NUMERIC, 2)::FLOAT AS "paid_out"
+	FROM "sellers" "s"
+		LEFT JOIN "e" ON "e"."seller_id" = "s"."user_id"
+		JOIN "users" "u" ON "u"."id" = "s"."user_id"
+	WHERE ($1 = '' OR "s"."user_id" = $1)
+	AND COALESCE("u"."store_id", '') = $2
+	ORDER BY "s"."user_id" ASC;`
+
+const payoutColumns = `
+		"id",
+		"batch_id",
+		"seller_id",
+		"shop_name",
+		"bank_account",
+		"amount",
+		"status",
+		"reference",
+		"note",
+		"paid_at",
+		"created_at",
+		"updated_at"`
+
+func findBalance(ctx context.Context, q sqlx.QueryerContext, storeId, sellerId string) ([]*payouts.SellerBalance, error) {
+	balances := make([]*payouts.SellerBalance, 0)
+	if err := sqlx.SelectContext(ctx, q, &balances, balanceQuery, sellerId, storeId); err != nil {
+		return nil, fmt.Errorf("find seller balance failed: %v", err)
+	}
+	for _, balance := range balances {
+		balance.Calculate()
+	}
+	return balances, nil
+}
+
+func (r *payoutsRepository) FindBalance(ctx context.Context, storeId, sellerId string) ([]*payouts.SellerBalance, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	return findBalance(ctx, r.db, storeId, sellerId)
+}
+
+// InsertBatch pay every balance of the store which is at least req.MinAmount. The payouts table is locked so two
+// batches created at the same time cannot pay the same balance twice
+func (r *payoutsRepository) InsertBatch(ctx context.Context, req *payouts.BatchReq) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE "payouts" IN EXCLUSIVE MODE;`); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("lock payouts failed: %v", err)
+	}
+
+	balances, err := findBalance(ctx, tx, req.StoreId, "")
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	due := make([]*payouts.SellerBalance, 0)
+	for _, balance := range balances {
+		if balance.Balance >= req.MinAmount {
+			due = append(due, balance)
+		}
+	}
+	if len(due) == 0 {
+		tx.Rollback()
+		return "", fmt.Errorf("no seller has a balance to pay")
+	}
+
+	var batchId string
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "payout_batches" (
+		"note",
+		"created_by",
+		"store_id"
+	)
+	VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING "id";`, req.Note, req.ActorId, req.StoreId).Scan(&batchId); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert payout batch failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "payouts" (
+		"batch_id",
+		"seller_id",
+		"shop_name",
+		"bank_account",
+		"amount"
+	)
+	VALUES`
+
+	valueStack := make([]any, 0)
+	for i, balance := range due {
+		valueStack = append(valueStack, batchId, balance.SellerId, balance.ShopName, balance.BankAccount, balance.Balance)
+
+		index := i * 5
+		query += fmt.Sprintf(`
+		($%d, $%d, $%d, $%d, $%d)`, index+1, index+2, index+3, index+4, index+5)
+		if i != len(due)-1 {
+			query += ","
+		} else {
+			query += ";"
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, query, valueStack...); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert payouts failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return batchId, nil
+}
+
+func (r *payoutsRepository) FindBatch(ctx context.Context, storeId string) ([]*payouts.Batch, error) {
+	query := `
+	SELECT
+		"b"."id",
+		"b"."note",
+		"b"."created_by",
+		"b"."created_at",
+		ROUND(COALESCE(SUM("p"."amount"), 0)::NUMERIC, 2)::FLOAT AS "total",
+		COUNT("p"."id") AS "count",
+		COUNT("p"."id") FILTER (WHERE "p"."status" = 'pending') AS "pending"
+	FROM "payout_batches" "b"
+		LEFT JOIN "payouts" "p" ON "p"."batch_id" = "b"."id"
+	WHERE COALESCE("b"."store_id", '') = $1
+	GROUP BY "b"."id"
+	ORDER BY "b"."created_at" DESC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	batches := make([]*payouts.Batch, 0)
+	if err := r.db.SelectContext(ctx, &batches, query, storeId); err != nil {
+		return nil, fmt.Errorf("find payout batches failed: %v", err)
+	}
+	return batches, nil
+}
+
+func (r *payoutsRepository) FindOneBatch(ctx context.Context, storeId, batchId string) (*payouts.Batch, error) {
+	query := `
+	SELECT
+		"b"."id",
+		"b"."note",
+		"b"."created_by",
+		"b"."created_at",
+		ROUND(COALESCE(SUM("p"."amount"), 0)::NUMERIC, 2)::FLOAT AS "total",
+		COUNT("p"."id") AS "count",
+		COUNT("p"."id") FILTER (WHERE "p"."status" = 'pending') AS "pending"
+	FROM "payout_batches" "b"
+		LEFT JOIN "payouts" "p" ON "p"."batch_id" = "b"."id"
+	WHERE "b"."id"::TEXT = $1
+	AND COALESCE("b"."store_id", '') = $2
+	GROUP BY "b"."id";`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	batch := new(payouts.Batch)
+	if err := r.db.GetContext(ctx, batch, query, batchId, storeId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payout batch not found")
+		}
+		return nil, fmt.Errorf("get payout batch failed: %v", err)
+	}
+
+	batch.Payouts = make([]*payouts.Payout, 0)
+	if err := r.db.SelectContext(ctx, &batch.Payouts, fmt.Sprintf(`
+	SELECT%s
+	FROM "payouts"
+	WHERE "batch_id" = $1
+	ORDER BY "seller_id" ASC;`, payoutColumns), batch.Id); err != nil {
+		return nil, fmt.Errorf("find payouts failed: %v", err)
+	}
+	return batch, nil
+}
+
+func (r *payoutsRepository) FindOnePayout(ctx context.Context, storeId, payoutId string) (*payouts.Payout, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	payout := new(payouts.Payout)
+	if err := r.db.GetContext(ctx, payout, fmt.Sprintf(`
+	SELECT%s
+	FROM "payouts"
+	WHERE "id"::TEXT = $1
+	AND "batch_id" IN (
+		SELECT
+			"id"
+		FROM "payout_batches"
+		WHERE COALESCE("store_id", '') = $2
+	);`, payoutColumns), payoutId, storeId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payout not found")
+		}
+		return nil, fmt.Errorf("get payout failed: %v", err)
+	}
+	return payout, nil
+}
+
+// UpdatePayout only change a pending payout, a payout which is paid or failed is final
+func (r *payoutsRepository) UpdatePayout(ctx context.Context, req *payouts.PayoutUpdateReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "payouts" SET
+		"status" = $2,
+		"reference" = $3,
+		"note" = $4,
+		"paid_at" = CASE WHEN $2 = 'paid' THEN now() ELSE NULL END
+	WHERE "id"::TEXT = $1
+	AND "status" = 'pending'
+	AND "batch_id" IN (
+		SELECT
+			"id"
+		FROM "payout_batches"
+		WHERE COALESCE("store_id", '') = $5
+	);`
+
+	result, err := r.db.ExecContext(ctx, query, req.Id, req.Status, req.Reference, req.Note, req.StoreId)
+	if err != nil {
+		return fmt.Errorf("update payout failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("payout is not pending")
+	}
+	return nil
+}
+
+func (r *payoutsRepository) FindSellerPayout(ctx context.Context, sellerId string) ([]*payouts.Payout, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	payoutsList := make([]*payouts.Payout, 0)
+	if err := r.db.SelectContext(ctx, &payoutsList, fmt.Sprintf(`
+	SELECT%s
+	FROM "payouts"
+	WHERE "seller_id" = $1
+	ORDER BY "created_at" DESC;`, payoutColumns), sellerId); err != nil {
+		return nil, fmt.Errorf("find seller payouts failed: %v", err)
+	}
+	return payoutsList, nil
+}
@@ -0,0 +1,100 @@
+package payoutsUsecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/payouts"
+	"github.com/NatthawutSK/ri-shop/modules/payouts/payoutsRepositories"
+)
+
+type IPayoutsUsecase interface {
+	FindBalance(ctx context.Context, storeId string) ([]*payouts.SellerBalance, error)
+	AddBatch(ctx context.Context, req *payouts.BatchReq) (*payouts.Batch, error)
+	FindBatch(ctx context.Context, storeId string) ([]*payouts.Batch, error)
+	FindOneBatch(ctx context.Context, storeId, batchId string) (*payouts.Batch, error)
+	UpdatePayout(ctx context.Context, req *payouts.PayoutUpdateReq) (*payouts.Payout, error)
+	FindSellerPayout(ctx context.Context, storeId, sellerId string) (*payouts.PayoutHistory, error)
+}
+
+type payoutsUsecase struct {
+	payoutsRepository payoutsRepositories.IPayoutsRepository
+}
+
+func PayoutsUsecase(payoutsRepository payoutsRepositories.IPayoutsRepository) IPayoutsUsecase {
+	return &payoutsUsecase{
+		payoutsRepository: payoutsRepository,
+	}
+}
+
+func (u *payoutsUsecase) FindBalance(ctx context.Context, storeId string) ([]*payouts.SellerBalance, error) {
+	return u.payoutsRepository.FindBalance(ctx, storeId, "")
+}
+
+func (u *payoutsUsecase) AddBatch(ctx context.Context, req *payouts.BatchReq) (*payouts.Batch, error) {
+	if req.MinAmount < 0 {
+		return nil, fmt.Errorf("min amount is invalid")
+	}
+	if req.MinAmount == 0 {
+		req.MinAmount = payouts.DefaultMinAmount
+	}
+	req.Note = strings.TrimSpace(req.Note)
+
+	batchId, err := u.payoutsRepository.InsertBatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return u.payoutsRepository.FindOneBatch(ctx, req.StoreId, batchId)
+}
+
+func (u *payoutsUsecase) FindBatch(ctx context.Context, storeId string) ([]*payouts.Batch, error) {
+	return u.payoutsRepository.FindBatch(ctx, storeId)
+}
+
+func (u *payoutsUsecase) FindOneBatch(ctx context.Context, storeId, batchId string) (*payouts.Batch, error) {
+	return u.payoutsRepository.FindOneBatch(ctx, storeId, batchId)
+}
+
+func (u *payoutsUsecase) UpdatePayout(ctx context.Context, req *payouts.PayoutUpdateReq) (*payouts.Payout, error) {
+	if _, err := u.payoutsRepository.FindOnePayout(ctx, req.StoreId, req.Id); err != nil {
+		return nil, err
+	}
+
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	req.Reference = strings.TrimSpace(req.Reference)
+	req.Note = strings.TrimSpace(req.Note)
+	switch req.Status {
+	case payouts.StatusPaid:
+		if req.Reference == "" {
+			return nil, fmt.Errorf("reference of the transfer is required")
+		}
+	case payouts.StatusFailed:
+	default:
+		return nil, fmt.Errorf("status must be %s or %s", payouts.StatusPaid, payouts.StatusFailed)
+	}
+
+	if err := u.payoutsRepository.UpdatePayout(ctx, req); err != nil {
+		return nil, err
+	}
+	return u.payoutsRepository.FindOnePayout(ctx, req.StoreId, req.Id)
+}
+
+func (u *payoutsUsecase) FindSellerPayout(ctx context.Context, storeId, sellerId string) (*payouts.PayoutHistory, error) {
+	balances, err := u.payoutsRepository.FindBalance(ctx, storeId, sellerId)
+	if err != nil {
+		return nil, err
+	}
+	if len(balances) == 0 {
+		return nil, fmt.Errorf("seller not found")
+	}
+
+	payoutsList, err := u.payoutsRepository.FindSellerPayout(ctx, sellerId)
+	if err != nil {
+		return nil, err
+	}
+	return &payouts.PayoutHistory{
+		Balance: balances[0],
+		Payouts: payoutsList,
+	}, nil
+}
@@ -0,0 +1,23 @@
+package pickups
+
+// Location is a store where customers pick up their orders
+type Location struct {
+	StoreId   string `json:"-" db:"-"`
+	Id        int    `json:"id" db:"id"`
+	Title     string `json:"title" db:"title"`
+	Address   string `json:"address" db:"address"`
+	Phone     string `json:"phone" db:"phone"`
+	Active    bool   `json:"active" db:"active"` // inactive locations are hidden and cannot take new orders
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
+
+type Slot struct {
+	StoreId    string    `json:"-" db:"-"`
+	Id         int       `json:"id" db:"id"`
+	LocationId int       `json:"location_id" db:"location_id"`
+	StartsAt   string    `json:"starts_at" db:"starts_at"` // RFC3339
+	EndsAt     string    `json:"ends_at" db:"ends_at"`
+	Capacity   int       `json:"capacity" db:"capacity"`
+	Booked     int       `json:"booked" db:"booked"` // orders in the slot which are not canceled
+	Location   *Location `json:"location,omitempty" db:"-"`
+}
@@ -0,0 +1,159 @@
+package pickupsHandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/pickups"
+	"github.com/NatthawutSK/ri-shop/modules/pickups/pickupsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type pickupsHandlerErrCode string
+
+const (
+	findLocationErr   pickupsHandlerErrCode = "pickups-001"
+	insertLocationErr pickupsHandlerErrCode = "pickups-002"
+	findSlotErr       pickupsHandlerErrCode = "pickups-003"
+	insertSlotErr     pickupsHandlerErrCode = "pickups-004"
+)
+
+type IPickupsHandler interface {
+	FindLocation(c *fiber.Ctx) error
+	AddLocation(c *fiber.Ctx) error
+	FindSlot(c *fiber.Ctx) error
+	AddSlot(c *fiber.Ctx) error
+}
+
+type pickupsHandler struct {
+	cfg            config.IConfig
+	pickupsUsecase pickupsUsecases.IPickupsUsecase
+}
+
+func PickupsHandler(cfg config.IConfig, pickupsUsecase pickupsUsecases.IPickupsUsecase) IPickupsHandler {
+	return &pickupsHandler{
+		cfg:            cfg,
+		pickupsUsecase: pickupsUsecase,
+	}
+}
+
+func (h *pickupsHandler) FindLocation(c *fiber.Ctx) error {
+	// ?all=true include inactive locations, used by the admin page
+	activeOnly := strings.ToLower(c.Query("all")) != "true"
+
+	locations, err := h.pickupsUsecase.FindLocation(c.UserContext(), c.Locals("storeId").(string), activeOnly)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findLocationErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, locations).Res()
+}
+
+func (h *pickupsHandler) AddLocation(c *fiber.Ctx) error {
+	req := &pickups.Location{
+		Active: true,
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertLocationErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	location, err := h.pickupsUsecase.AddLocation(c.UserContext(), req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "insert pickup location failed") {
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertLocationErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertLocationErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, location).Res()
+}
+
+func (h *pickupsHandler) FindSlot(c *fiber.Ctx) error {
+	locationId, err := strconv.Atoi(strings.TrimSpace(c.Params("locationId")))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findSlotErr),
+			"location id is invalid",
+		).Res()
+	}
+
+	slots, err := h.pickupsUsecase.FindSlot(c.UserContext(), c.Locals("storeId").(string), locationId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findSlotErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, slots).Res()
+}
+
+func (h *pickupsHandler) AddSlot(c *fiber.Ctx) error {
+	locationId, err := strconv.Atoi(strings.TrimSpace(c.Params("locationId")))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSlotErr),
+			"location id is invalid",
+		).Res()
+	}
+
+	req := new(pickups.Slot)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSlotErr),
+			err.Error(),
+		).Res()
+	}
+	req.LocationId = locationId
+	req.StoreId = c.Locals("storeId").(string)
+
+	slot, err := h.pickupsUsecase.AddSlot(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "pickup location not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(insertSlotErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "insert pickup slot failed"),
+			strings.HasPrefix(err.Error(), "unmarshal pickup slot failed"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertSlotErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertSlotErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, slot).Res()
+}
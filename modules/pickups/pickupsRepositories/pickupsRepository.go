@@ -0,0 +1,183 @@
+package pickupsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/pickups"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPickupsRepository interface {
+	FindLocation(ctx context.Context, storeId string, activeOnly bool) ([]*pickups.Location, error)
+	InsertLocation(ctx context.Context, req *pickups.Location) error
+	FindSlot(ctx context.Context, storeId string, locationId int) ([]*pickups.Slot, error)
+	FindOneSlot(ctx context.Context, storeId string, slotId int) (*pickups.Slot, error)
+	InsertSlot(ctx context.Context, req *pickups.Slot) error
+}
+
+type pickupsRepository struct {
+	db *sqlx.DB
+}
+
+func PickupsRepository(db *sqlx.DB) IPickupsRepository {
+	return &pickupsRepository{
+		db: db,
+	}
+}
+
+func (r *pickupsRepository) FindLocation(ctx context.Context, storeId string, activeOnly bool) ([]*pickups.Location, error) {
+	query := `
+	SELECT
+		"id",
+		"title",
+		"address",
+		"phone",
+		"active",
+		"created_at"
+	FROM "pickup_locations"
+	WHERE ("active" = TRUE OR $1 = FALSE)
+	AND COALESCE("store_id", '') = $2
+	ORDER BY "id" ASC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	locations := make([]*pickups.Location, 0)
+	if err := r.db.SelectContext(ctx, &locations, query, activeOnly, storeId); err != nil {
+		return nil, fmt.Errorf("find pickup locations failed: %v", err)
+	}
+	return locations, nil
+}
+
+func (r *pickupsRepository) InsertLocation(ctx context.Context, req *pickups.Location) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "pickup_locations" (
+		"title",
+		"address",
+		"phone",
+		"active",
+		"store_id"
+	)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING "id", "created_at";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.Title, req.Address, req.Phone, req.Active, req.StoreId).Scan(&req.Id, &req.CreatedAt); err != nil {
+		return fmt.Errorf("insert pickup location failed: %v", err)
+	}
+	return nil
+}
+
+// FindSlot return the slots of the location of the store which have not started yet
+func (r *pickupsRepository) FindSlot(ctx context.Context, storeId string, locationId int) ([]*pickups.Slot, error) {
+	query := `
+	SELECT
+		"s"."id",
+		"s"."location_id",
+		"s"."starts_at",
+		"s"."ends_at",
+		"s"."capacity",
+		(
+			SELECT
+				COUNT(*)
+			FROM "orders" "o"
+			WHERE "o"."pickup_slot_id" = "s"."id"
+			AND "o"."status" <> 'canceled'
+		) AS "booked"
+	FROM "pickup_slots" "s"
+		JOIN "pickup_locations" "l" ON "l"."id" = "s"."location_id"
+	WHERE "s"."location_id" = $1
+	AND COALESCE("l"."store_id", '') = $2
+	AND "s"."starts_at" > now()
+	ORDER BY "s"."starts_at" ASC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	slots := make([]*pickups.Slot, 0)
+	if err := r.db.SelectContext(ctx, &slots, query, locationId, storeId); err != nil {
+		return nil, fmt.Errorf("find pickup slots failed: %v", err)
+	}
+	return slots, nil
+}
+
+// FindOneSlot only find a slot of a location of the store
+func (r *pickupsRepository) FindOneSlot(ctx context.Context, storeId string, slotId int) (*pickups.Slot, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT
+			"s"."id",
+			"s"."location_id",
+			"s"."starts_at",
+			"s"."ends_at",
+			"s"."capacity",
+			(
+				SELECT
+					COUNT(*)
+				FROM "orders" "o"
+				WHERE "o"."pickup_slot_id" = "s"."id"
+				AND "o"."status" <> 'canceled'
+			) AS "booked",
+			to_jsonb("l") AS "location"
+		FROM "pickup_slots" "s"
+			JOIN "pickup_locations" "l" ON "l"."id" = "s"."location_id"
+		WHERE "s"."id" = $1
+		AND COALESCE("l"."store_id", '') = $2
+	) AS "t";`
+
+	bytes := make([]byte, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	slot := new(pickups.Slot)
+	if err := r.db.GetContext(ctx, &bytes, query, slotId, storeId); err != nil {
+		return nil, fmt.Errorf("pickup slot not found")
+	}
+	if err := json.Unmarshal(bytes, slot); err != nil {
+		return nil, fmt.Errorf("unmarshal pickup slot failed: %v", err)
+	}
+	return slot, nil
+}
+
+func (r *pickupsRepository) InsertSlot(ctx context.Context, req *pickups.Slot) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "pickup_slots" (
+		"location_id",
+		"starts_at",
+		"ends_at",
+		"capacity"
+	)
+	SELECT
+		"l"."id",
+		$2::TIMESTAMPTZ,
+		$3::TIMESTAMPTZ,
+		$4
+	FROM "pickup_locations" "l"
+	WHERE "l"."id" = $1
+	AND COALESCE("l"."store_id", '') = $5
+		RETURNING "id";`
+
+	// RFC3339 มี offset, cast เป็น timestamptz ก่อนเพื่อให้แปลงเป็นเวลาของ database
+	// location ของร้านอื่นไม่ถูก insert เหมือนไม่มีอยู่
+	if err := r.db.QueryRowContext(ctx, query, req.LocationId, req.StartsAt, req.EndsAt, req.Capacity, req.StoreId).Scan(&req.Id); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("pickup location not found")
+		default:
+			return fmt.Errorf("insert pickup slot failed: %v", err)
+		}
+	}
+	return nil
+}
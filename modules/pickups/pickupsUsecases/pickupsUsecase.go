@@ -0,0 +1,81 @@
+package pickupsUsecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/pickups"
+	"github.com/NatthawutSK/ri-shop/modules/pickups/pickupsRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/clock"
+)
+
+type IPickupsUsecase interface {
+	FindLocation(ctx context.Context, storeId string, activeOnly bool) ([]*pickups.Location, error)
+	AddLocation(ctx context.Context, req *pickups.Location) (*pickups.Location, error)
+	FindSlot(ctx context.Context, storeId string, locationId int) ([]*pickups.Slot, error)
+	AddSlot(ctx context.Context, req *pickups.Slot) (*pickups.Slot, error)
+}
+
+type pickupsUsecase struct {
+	pickupsRepository pickupsRepositories.IPickupsRepository
+	clock             clock.Clock
+}
+
+func PickupsUsecase(pickupsRepository pickupsRepositories.IPickupsRepository, clock clock.Clock) IPickupsUsecase {
+	return &pickupsUsecase{
+		pickupsRepository: pickupsRepository,
+		clock:             clock,
+	}
+}
+
+func (u *pickupsUsecase) FindLocation(ctx context.Context, storeId string, activeOnly bool) ([]*pickups.Location, error) {
+	return u.pickupsRepository.FindLocation(ctx, storeId, activeOnly)
+}
+
+func (u *pickupsUsecase) AddLocation(ctx context.Context, req *pickups.Location) (*pickups.Location, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	req.Address = strings.TrimSpace(req.Address)
+	if req.Address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	req.Phone = strings.TrimSpace(req.Phone)
+
+	if err := u.pickupsRepository.InsertLocation(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *pickupsUsecase) FindSlot(ctx context.Context, storeId string, locationId int) ([]*pickups.Slot, error) {
+	return u.pickupsRepository.FindSlot(ctx, storeId, locationId)
+}
+
+func (u *pickupsUsecase) AddSlot(ctx context.Context, req *pickups.Slot) (*pickups.Slot, error) {
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		return nil, fmt.Errorf("starts_at must be RFC3339")
+	}
+	endsAt, err := time.Parse(time.RFC3339, req.EndsAt)
+	if err != nil {
+		return nil, fmt.Errorf("ends_at must be RFC3339")
+	}
+	if !endsAt.After(startsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+	if !startsAt.After(u.clock.Now()) {
+		return nil, fmt.Errorf("starts_at must be in the future")
+	}
+	if req.Capacity <= 0 {
+		return nil, fmt.Errorf("capacity must be more than 0")
+	}
+
+	if err := u.pickupsRepository.InsertSlot(ctx, req); err != nil {
+		return nil, err
+	}
+	return u.pickupsRepository.FindOneSlot(ctx, req.StoreId, req.Id)
+}
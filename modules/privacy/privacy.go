@@ -0,0 +1,97 @@
+package privacy
+
+import (
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+)
+
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Schema is the schema of the files inside the archive, it is increased when a field is changed or removed
+const Schema = "ri-shop.account/v1"
+
+// ExportMaxAge is how long a done export is given again, a later request make a new one
+const ExportMaxAge = 24 * time.Hour
+
+// RoleCustomer is the only role which can delete its own account, an admin or a seller is removed by support
+// because of the payouts and the audit logs which refer to them
+const RoleCustomer = 1
+
+// DataExport is an archive of the personal data of a user made in the background, Url is a signed url of the
+// file once it is done
+type DataExport struct {
+	Id          string `json:"id" db:"id"`
+	Status      string `json:"status" db:"status"`
+	Error       string `json:"error,omitempty" db:"error"`
+	Destination string `json:"-" db:"destination"`
+	Url         string `json:"url,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	CreatedAt   string `json:"created_at" db:"created_at"`
+	FinishedAt  string `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// Expired report whether a done export is too old to be given again
+func (e *DataExport) Expired(now time.Time) bool {
+	createdAt, err := time.Parse("2006-01-02T15:04:05", e.CreatedAt)
+	if err != nil {
+		return true
+	}
+	return now.Sub(createdAt) > ExportMaxAge
+}
+
+type Profile struct {
+	Id        string `json:"id" db:"id"`
+	Username  string `json:"username" db:"username"`
+	Email     string `json:"email" db:"email"`
+	Locale    string `json:"locale" db:"locale"`
+	StoreId   string `json:"store_id,omitempty" db:"store_id"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	UpdatedAt string `json:"updated_at" db:"updated_at"`
+}
+
+// Order is an order of the user as it is kept, with the address and the contact given at checkout
+type Order struct {
+	Id              string             `json:"id"`
+	Status          string             `json:"status"`
+	Contact         string             `json:"contact"`
+	Address         string             `json:"address"`
+	ShippingAddress *addresses.Address `json:"shipping_address"`
+	Currency        string             `json:"currency"`
+	Discount        float64            `json:"discount"`
+	ShippingFee     float64            `json:"shipping_fee"`
+	Tax             float64            `json:"tax"`
+	Lines           []*OrderLine       `json:"lines"`
+	CreatedAt       string             `json:"created_at"`
+}
+
+type OrderLine struct {
+	ProductId string  `json:"product_id"`
+	Title     string  `json:"title"`
+	Qty       int     `json:"qty"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// Archive is what the zip of an export hold, a file per field
+type Archive struct {
+	Profile   *Profile             `json:"profile"`
+	Orders    []*Order             `json:"orders"`
+	Addresses []*addresses.Address `json:"addresses"`
+}
+
+// Account is what the deletion of an account check
+type Account struct {
+	Id       string `db:"id"`
+	Email    string `db:"email"`
+	Password string `db:"password"`
+	RoleId   int    `db:"role_id"`
+}
+
+type DeleteReq struct {
+	UserId   string `json:"-"`
+	Password string `json:"password" form:"password"`
+}
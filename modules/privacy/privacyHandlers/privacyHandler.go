@@ -0,0 +1,123 @@
+package privacyHandlers
+
+import (
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/privacy"
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type privacyHandlerErrCode string
+
+const (
+	findExportErr    privacyHandlerErrCode = "privacy-001"
+	deleteAccountErr privacyHandlerErrCode = "privacy-002"
+)
+
+type IPrivacyHandler interface {
+	FindExport(c *fiber.Ctx) error
+	DeleteAccount(c *fiber.Ctx) error
+}
+
+type privacyHandler struct {
+	cfg            config.IConfig
+	privacyUsecase privacyUsecases.IPrivacyUsecase
+	auditsUsecase  auditsUsecases.IAuditsUsecase
+}
+
+func PrivacyHandler(cfg config.IConfig, privacyUsecase privacyUsecases.IPrivacyUsecase, auditsUsecase auditsUsecases.IAuditsUsecase) IPrivacyHandler {
+	return &privacyHandler{
+		cfg:            cfg,
+		privacyUsecase: privacyUsecase,
+		auditsUsecase:  auditsUsecase,
+	}
+}
+
+// FindExport answer 202 while the archive is made and 200 with a signed url once it is done, the client ask again
+// until then
+func (h *privacyHandler) FindExport(c *fiber.Ctx) error {
+	export, err := h.privacyUsecase.FindExport(c.UserContext(), c.Locals("storeId").(string), c.Locals("userId").(string))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findExportErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findExportErr),
+			err.Error(),
+		).Res()
+	}
+	if export.Status != privacy.StatusDone {
+		return entities.NewResponse(c).Success(fiber.StatusAccepted, export).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, export).Res()
+}
+
+func (h *privacyHandler) DeleteAccount(c *fiber.Ctx) error {
+	req := new(privacy.DeleteReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteAccountErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+
+	if err := h.privacyUsecase.DeleteAccount(c.UserContext(), req); err != nil {
+		switch err.Error() {
+		case "password is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(deleteAccountErr),
+				err.Error(),
+			).Res()
+		case "password is invalid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(deleteAccountErr),
+				err.Error(),
+			).Res()
+		case "only a customer account can be deleted":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(deleteAccountErr),
+				err.Error(),
+			).Res()
+		case "the account has orders in progress":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(deleteAccountErr),
+				err.Error(),
+			).Res()
+		case "user not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteAccountErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteAccountErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	// ไม่เก็บข้อมูลส่วนตัวใน audit log เก็บแค่ว่าบัญชีไหนถูกลบ
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  req.UserId,
+		Action:   audits.UserDelete,
+		Entity:   "user",
+		EntityId: req.UserId,
+	})
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
@@ -0,0 +1,340 @@
+package privacyRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/privacy"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPrivacyRepository interface {
+	FindProfile(ctx context.Context, userId string) (*privacy.Profile, error)
+	FindOrders(ctx context.Context, userId string) ([]*privacy.Order, error)
+	FindAddresses(ctx context.Context, userId string) ([]*addresses.Address, error)
+	InsertExport(ctx context.Context, userId string) (*privacy.DataExport, error)
+	UpdateExport(ctx context.Context, req *privacy.DataExport) error
+	FindLastExport(ctx context.Context, userId string) (*privacy.DataExport, error)
+	FailPendingExports(ctx context.Context, olderThan time.Duration) (int64, error)
+	FindAccount(ctx context.Context, userId string) (*privacy.Account, error)
+	CountOpenOrders(ctx context.Context, userId string) (int, error)
+	AnonymizeUser(ctx context.Context, account *privacy.Account) error
+}
+
+type privacyRepository struct {
+	db *sqlx.DB
+}
+
+func PrivacyRepository(db *sqlx.DB) IPrivacyRepository {
+	return &privacyRepository{
+		db: db,
+	}
+}
+
+func (r *privacyRepository) FindProfile(ctx context.Context, userId string) (*privacy.Profile, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"id",
+		"username",
+		"email",
+		"locale",
+		COALESCE("store_id", '') AS "store_id",
+		to_char("created_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "created_at",
+		to_char("updated_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "updated_at"
+	FROM "users"
+	WHERE "id" = $1
+	AND "deleted_at" IS NULL;`
+
+	profile := new(privacy.Profile)
+	if err := r.db.GetContext(ctx, profile, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("find profile failed: %v", err)
+	}
+	return profile, nil
+}
+
+func (r *privacyRepository) FindOrders(ctx context.Context, userId string) ([]*privacy.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	SELECT
+		COALESCE(jsonb_agg("t" ORDER BY "t"."created_at", "t"."id"), '[]'::JSONB)
+	FROM (
+		SELECT
+			"o"."id",
+			"o"."status",
+			"o"."contact",
+			"o"."address",
+			"o"."shipping_address",
+			COALESCE((
+				SELECT "po"."product"->>'currency'
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "o"."id"
+				LIMIT 1
+			), '') AS "currency",
+			"o"."discount",
+			"o"."shipping_fee",
+			COALESCE(("o"."tax"->>'total')::FLOAT, 0) AS "tax",
+			COALESCE((
+				SELECT
+					jsonb_agg(jsonb_build_object(
+						'product_id', "po"."product"->>'id',
+						'title', "po"."product"->>'title',
+						'qty', "po"."qty",
+						'unit_price', COALESCE(("po"."product"->>'price')::FLOAT, 0)
+					))
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "o"."id"
+			), '[]'::JSONB) AS "lines",
+			to_char("o"."created_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "created_at"
+		FROM "orders" "o"
+		WHERE "o"."user_id" = $1
+	) AS "t";`
+
+	raw := make([]byte, 0)
+	if err := r.db.QueryRowxContext(ctx, query, userId).Scan(&raw); err != nil {
+		return nil, fmt.Errorf("find orders failed: %v", err)
+	}
+	orders := make([]*privacy.Order, 0)
+	if err := json.Unmarshal(raw, &orders); err != nil {
+		return nil, fmt.Errorf("unmarshal orders failed: %v", err)
+	}
+	return orders, nil
+}
+
+func (r *privacyRepository) FindAddresses(ctx context.Context, userId string) ([]*addresses.Address, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"id",
+		"user_id",
+		"recipient",
+		"phone",
+		"line1",
+		"line2",
+		"city",
+		"state",
+		"postal_code",
+		"country",
+		"is_default",
+		to_char("created_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "created_at",
+		to_char("updated_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "updated_at"
+	FROM "addresses"
+	WHERE "user_id" = $1
+	ORDER BY "created_at";`
+
+	list := make([]*addresses.Address, 0)
+	if err := r.db.SelectContext(ctx, &list, query, userId); err != nil {
+		return nil, fmt.Errorf("find addresses failed: %v", err)
+	}
+	return list, nil
+}
+
+const exportColumns = `
+		"id",
+		"status",
+		"error",
+		"destination",
+		to_char("created_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "created_at",
+		COALESCE(to_char("finished_at", 'YYYY-MM-DD"T"HH24:MI:SS'), '') AS "finished_at"`
+
+func (r *privacyRepository) InsertExport(ctx context.Context, userId string) (*privacy.DataExport, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "account_exports" ("user_id")
+	VALUES ($1)
+	RETURNING` + exportColumns + `;`
+
+	export := new(privacy.DataExport)
+	if err := r.db.GetContext(ctx, export, query, userId); err != nil {
+		return nil, fmt.Errorf("insert export failed: %v", err)
+	}
+	return export, nil
+}
+
+// UpdateExport keep the result of an export, it is finished when it is not pending anymore
+func (r *privacyRepository) UpdateExport(ctx context.Context, req *privacy.DataExport) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "account_exports" SET
+		"status" = $2,
+		"error" = $3,
+		"destination" = $4,
+		"finished_at" = CASE WHEN $2 = 'pending' THEN NULL ELSE now() END
+	WHERE "id" = $1;`
+
+	if _, err := r.db.ExecContext(ctx, query, req.Id, req.Status, req.Error, req.Destination); err != nil {
+		return fmt.Errorf("update export failed: %v", err)
+	}
+	return nil
+}
+
+func (r *privacyRepository) FindLastExport(ctx context.Context, userId string) (*privacy.DataExport, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT` + exportColumns + `
+	FROM "account_exports"
+	WHERE "user_id" = $1
+	ORDER BY "created_at" DESC
+	LIMIT 1;`
+
+	export := new(privacy.DataExport)
+	if err := r.db.GetContext(ctx, export, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("export not found")
+		}
+		return nil, fmt.Errorf("find export failed: %v", err)
+	}
+	return export, nil
+}
+
+// FailPendingExports mark the exports pending for longer than olderThan as failed, they were running when
+// the server stopped
+func (r *privacyRepository) FailPendingExports(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "account_exports" SET
+		"status" = 'failed',
+		"error" = 'the server stopped before the export was done',
+		"finished_at" = now()
+	WHERE "status" = 'pending'
+	AND "created_at" < now() - make_interval(secs => $1);`
+
+	result, err := r.db.ExecContext(ctx, query, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("fail pending exports failed: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *privacyRepository) FindAccount(ctx context.Context, userId string) (*privacy.Account, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"id",
+		"email",
+		"password",
+		"role_id"
+	FROM "users"
+	WHERE "id" = $1
+	AND "deleted_at" IS NULL;`
+
+	account := new(privacy.Account)
+	if err := r.db.GetContext(ctx, account, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("find account failed: %v", err)
+	}
+	return account, nil
+}
+
+// CountOpenOrders count the orders which are neither completed nor canceled
+func (r *privacyRepository) CountOpenOrders(ctx context.Context, userId string) (int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		COUNT(*)
+	FROM "orders"
+	WHERE "user_id" = $1
+	AND "status" NOT IN ('completed', 'canceled');`
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, query, userId); err != nil {
+		return 0, fmt.Errorf("count open orders failed: %v", err)
+	}
+	return count, nil
+}
+
+type statement struct {
+	query string
+	args  []any
+}
+
+// anonymizeStatements remove the personal data of an account. The user row and the orders stay for the accounting,
+// the orders keep the city, state, postal code and country of the address because the tax was computed from them,
+// a gift keeps only hide_prices so the tracking page of the recipient still hide the prices
+func anonymizeStatements(account *privacy.Account) []*statement {
+	id := account.Id
+	return []*statement{
+		{`UPDATE "users" SET
+			"username" = 'deleted-' || "id",
+			"email" = 'deleted-' || "id" || '@deleted.invalid',
+			"password" = '',
+			"deleted_at" = now()
+		WHERE "id" = $1;`, []any{id}},
+		{`UPDATE "orders" SET
+			"contact" = '',
+			"address" = '',
+			"shipping_address" = CASE WHEN "shipping_address" IS NULL THEN NULL ELSE jsonb_build_object(
+				'city', "shipping_address"->'city',
+				'state', "shipping_address"->'state',
+				'postal_code', "shipping_address"->'postal_code',
+				'country', "shipping_address"->'country'
+			) END,
+			"gift" = CASE WHEN "gift" IS NULL THEN NULL ELSE jsonb_build_object(
+				'hide_prices', COALESCE("gift"->'hide_prices', 'false'::jsonb)
+			) END
+		WHERE "user_id" = $1;`, []any{id}},
+		{`DELETE FROM "addresses" WHERE "user_id" = $1;`, []any{id}},
+		{`DELETE FROM "oauth" WHERE "user_id" = $1;`, []any{id}},
+		{`DELETE FROM "login_attempts" WHERE "email" = lower($1::TEXT);`, []any{account.Email}},
+		{`DELETE FROM "email_queue" WHERE "to_address" = $1 AND "status" <> 'sending';`, []any{account.Email}},
+		{`DELETE FROM "notification_preferences" WHERE "user_id" = $1;`, []any{id}},
+		{`DELETE FROM "user_notifications" WHERE "user_id" = $1;`, []any{id}},
+		{`DELETE FROM "product_watches" WHERE "user_id" = $1;`, []any{id}},
+		{`DELETE FROM "recently_viewed" WHERE "viewer" = 'user:' || $1::TEXT;`, []any{id}},
+		// ไฟล์ export เก่าลบจาก bucket ทีหลังโดยคิวเดียวกับรูปสินค้า
+		{`INSERT INTO "file_deletions" ("destination")
+		SELECT "destination" FROM "account_exports" WHERE "user_id" = $1 AND "destination" <> '';`, []any{id}},
+		{`DELETE FROM "account_exports" WHERE "user_id" = $1;`, []any{id}},
+	}
+}
+
+// AnonymizeUser remove the personal data of the account in one transaction, the sessions are revoked with it
+func (r *privacyRepository) AnonymizeUser(ctx context.Context, account *privacy.Account) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin anonymize user failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, s := range anonymizeStatements(account) {
+		if _, err := tx.ExecContext(ctx, s.query, s.args...); err != nil {
+			return fmt.Errorf("anonymize user failed: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit anonymize user failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package privacyUsecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/privacy"
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/users"
+)
+
+// exportUrlTtl is how long the signed url of an archive can be used
+const exportUrlTtl = 15 * time.Minute
+
+// pendingTimeout is how long an export can be pending, an older one was stopped with the server
+const pendingTimeout = 30 * time.Minute
+
+type IPrivacyUsecase interface {
+	FindExport(ctx context.Context, storeId, userId string) (*privacy.DataExport, error)
+	BuildArchive(ctx context.Context, userId string) ([]byte, error)
+	FailStoppedExports(ctx context.Context)
+	DeleteAccount(ctx context.Context, req *privacy.DeleteReq) error
+}
+
+type privacyUsecase struct {
+	privacyRepository privacyRepositories.IPrivacyRepository
+	filesUsecase      filesUsecases.IFilesUsecase
+}
+
+func PrivacyUsecase(privacyRepository privacyRepositories.IPrivacyRepository, filesUsecase filesUsecases.IFilesUsecase) IPrivacyUsecase {
+	return &privacyUsecase{
+		privacyRepository: privacyRepository,
+		filesUsecase:      filesUsecase,
+	}
+}
+
+// FindExport return the last export of the user. A new one is made in the background when there is none, when
+// it failed or when it is older than ExportMaxAge, so asking again while it is pending does not start another
+func (u *privacyUsecase) FindExport(ctx context.Context, storeId, userId string) (*privacy.DataExport, error) {
+	last, err := u.privacyRepository.FindLastExport(ctx, userId)
+	if err != nil && err.Error() != "export not found" {
+		return nil, err
+	}
+	if last != nil {
+		switch {
+		case last.Status == privacy.StatusPending:
+			return last, nil
+		case last.Status == privacy.StatusDone && !last.Expired(time.Now()):
+			expiresAt := time.Now().Add(exportUrlTtl)
+			url, err := u.filesUsecase.SignDownload(ctx, last.Destination, exportUrlTtl)
+			if err != nil {
+				return nil, err
+			}
+			last.Url = url
+			last.ExpiresAt = expiresAt.Format(time.RFC3339)
+			return last, nil
+		}
+	}
+
+	export, err := u.privacyRepository.InsertExport(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	go u.runExport(export.Id, storeId, userId)
+	return export, nil
+}
+
+// runExport is not canceled with the request which asked for the archive
+func (u *privacyUsecase) runExport(exportId, storeId, userId string) {
+	ctx := context.Background()
+	result := &privacy.DataExport{Id: exportId, Status: privacy.StatusFailed}
+	defer func() {
+		if err := u.privacyRepository.UpdateExport(ctx, result); err != nil {
+			log.Printf("update account export %s failed: %v\n", exportId, err)
+		}
+	}()
+
+	data, err := u.BuildArchive(ctx, userId)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	destination, err := u.filesUsecase.UploadPrivate(ctx, &files.PrivateFileReq{
+		StoreId:     storeId,
+		Destination: fmt.Sprintf("account-exports/%s/account-%s.zip", exportId, userId),
+		ContentType: "application/zip",
+		Data:        data,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	result.Status = privacy.StatusDone
+	result.Destination = destination
+}
+
+// BuildArchive zip the personal data of the user, a json file per kind of data and a manifest.json with the schema
+func (u *privacyUsecase) BuildArchive(ctx context.Context, userId string) ([]byte, error) {
+	profile, err := u.privacyRepository.FindProfile(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := u.privacyRepository.FindOrders(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := u.privacyRepository.FindAddresses(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []struct {
+		name string
+		data any
+	}{
+		{"manifest.json", map[string]any{
+			"schema":       privacy.Schema,
+			"user_id":      userId,
+			"generated_at": time.Now().UTC().Format(time.RFC3339),
+		}},
+		{"profile.json", profile},
+		{"orders.json", orders},
+		{"addresses.json", addresses},
+	}
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, entry := range entries {
+		f, err := w.Create(entry.name)
+		if err != nil {
+			return nil, fmt.Errorf("create %s failed: %v", entry.name, err)
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entry.data); err != nil {
+			return nil, fmt.Errorf("write %s failed: %v", entry.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("close archive failed: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FailStoppedExports mark the exports which were stopped with the server as failed, called at startup
+func (u *privacyUsecase) FailStoppedExports(ctx context.Context) {
+	failed, err := u.privacyRepository.FailPendingExports(ctx, pendingTimeout)
+	if err != nil {
+		log.Printf("fail stopped account exports failed: %v\n", err)
+		return
+	}
+	if failed > 0 {
+		log.Printf("%d account exports were stopped with the server and marked as failed\n", failed)
+	}
+}
+
+// DeleteAccount anonymize the account of a customer after checking the password again. The orders are kept for
+// the accounting, so an order which is not completed or canceled yet must be finished first
+func (u *privacyUsecase) DeleteAccount(ctx context.Context, req *privacy.DeleteReq) error {
+	if req.Password == "" {
+		return fmt.Errorf("password is required")
+	}
+
+	account, err := u.privacyRepository.FindAccount(ctx, req.UserId)
+	if err != nil {
+		return err
+	}
+	if account.RoleId != privacy.RoleCustomer {
+		return fmt.Errorf("only a customer account can be deleted")
+	}
+	if err := users.ComparePassword(account.Password, req.Password); err != nil {
+		return fmt.Errorf("password is invalid")
+	}
+
+	open, err := u.privacyRepository.CountOpenOrders(ctx, account.Id)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return fmt.Errorf("the account has orders in progress")
+	}
+	return u.privacyRepository.AnonymizeUser(ctx, account)
+}
@@ -1,10 +1,74 @@
 package products
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/badges"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 )
 
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived" // no longer sold, only admins see it
+)
+
+// ValidStatus report whether status is one of the product statuses
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusDraft, StatusPublished, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// EventProductSearched is published on pkg/events with a *SearchEvent payload
+const EventProductSearched = "product.searched"
+
+// EventProductAvailable and EventProductUnavailable are published on pkg/events with a *WindowEvent payload
+// when a product with an availability window opens or closes
+const (
+	EventProductAvailable   = "product.available"
+	EventProductUnavailable = "product.unavailable"
+)
+
+// EventPriceStockChanged is published on pkg/events with a *PriceStockEvent payload when an update of the
+// admins change the price or the stock of a product
+const EventPriceStockChanged = "product.price_stock_changed"
+
+type PriceStockEvent struct {
+	ProductId string  `json:"product_id"`
+	FromPrice float64 `json:"from_price"`
+	Price     float64 `json:"price"`
+	FromStock int     `json:"from_stock"`
+	Stock     int     `json:"stock"`
+}
+
+// WindowLayout is how the availability window is kept and returned, in UTC like created_at
+const WindowLayout = "2006-01-02T15:04:05"
+
+// WindowEvent is a product entering or leaving its availability window
+type WindowEvent struct {
+	ProductId      string  `json:"product_id" db:"id"`
+	Title          string  `json:"title" db:"title"`
+	StoreId        string  `json:"store_id,omitempty" db:"store_id"`
+	Open           bool    `json:"open" db:"open"`
+	AvailableFrom  *string `json:"available_from" db:"available_from"`
+	AvailableUntil *string `json:"available_until" db:"available_until"`
+}
+
+// SearchEvent is a storefront search, Id is sent to the client as X-Search-Id to report clicks
+type SearchEvent struct {
+	Id      string `json:"id"`
+	StoreId string `json:"store_id"`
+	Query   string `json:"query"`
+	Results int    `json:"results"`
+}
+
 type Products struct {
 	Id          string            `json:"id"`
 	Title       string            `json:"title"`
@@ -12,13 +76,341 @@ type Products struct {
 	Category    *appinfo.Category `json:"category"`
 	CreatedAt   string            `json:"created_at"`
 	UpdatedAt   string            `json:"updated_at"`
-	Price       float64           `json:"price"`
+	Price       float64           `json:"price"`    // major unit, e.g. 150.50
+	Currency    string            `json:"currency"` // ISO 4217 code of price
+	Prices      []*ProductPrice   `json:"prices"`   // per currency overrides
+	Stock       int               `json:"stock"`
+	Status      string            `json:"status"` // draft is only seen with a preview token, archived only by admins
 	Images      []*entities.Image `json:"images"`
+	Badges      []*badges.Badge   `json:"badges"`              // manual and rule badges, read only
+	Regions     []*ProductRegion  `json:"regions"`             // empty ships everywhere
+	Attributes  map[string]string `json:"attributes"`          // specs, e.g. brand: nike
+	SellerId    string            `json:"seller_id,omitempty"` // empty is a product of the shop itself
+	StoreId     string            `json:"store_id,omitempty"`  // empty is a product of the default store
+	Version     int               `json:"version"`             // read by the client and sent back on update
+	// limited time window in UTC, null is open. On update nil keeps the bound and "" clears it
+	AvailableFrom  *string `json:"available_from"`
+	AvailableUntil *string `json:"available_until"`
+}
+
+// AvailableAt report whether the product is inside its availability window at t
+func (p *Products) AvailableAt(t time.Time) bool {
+	t = t.UTC()
+	if p.AvailableFrom != nil && *p.AvailableFrom != "" {
+		if from, err := time.Parse("2006-01-02T15:04:05.999999999", *p.AvailableFrom); err == nil && t.Before(from) {
+			return false
+		}
+	}
+	if p.AvailableUntil != nil && *p.AvailableUntil != "" {
+		if until, err := time.Parse("2006-01-02T15:04:05.999999999", *p.AvailableUntil); err == nil && !t.Before(until) {
+			return false
+		}
+	}
+	return true
+}
+
+// VersionConflictError is returned when the product was changed after the client read it
+type VersionConflictError struct {
+	Version int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("product has been changed since version %d, read it again before updating", e.Version)
+}
+
+// ProductRegion is where the product can be delivered, e.g. frozen food only in Bangkok
+type ProductRegion struct {
+	Country string `json:"country"`         // ISO 3166-1 alpha-2
+	State   string `json:"state,omitempty"` // province, empty is the whole country
 }
 
+type ProductPrice struct {
+	Currency string  `json:"currency"`
+	Price    float64 `json:"price"`
+}
+
+// MaxBatchIds is the most products one ?ids= request returns, e.g. a cart or a wishlist page
+const MaxBatchIds = 100
+
 type ProductFilter struct {
-	Id     string `json:"id" query:"id"`
-	Search string `json:"search" query:"search"` // search by title and description
+	Id       string `json:"id" query:"id"`
+	Search   string `json:"search" query:"search"`     // search by title and description
+	Currency string `json:"currency" query:"currency"` // convert or select prices to this currency
+	Badge    string `json:"badge" query:"badge"`       // badge code
+	Country  string `json:"country" query:"country"`   // only products which ship to the region of the shopper
+	State    string `json:"state" query:"state"`
+	SellerId string `json:"seller_id" query:"seller_id"` // storefront of one seller
+	Preview  bool   `json:"-" query:"-"`                 // drafts are included, set from the preview token
+	Admin    bool   `json:"-" query:"-"`                 // every status is included, set on the admin listing
+	Status   string `json:"status" query:"status"`       // only used on the admin listing
+	// attribute filters from ?attr[brand]=nike, value is matched case insensitive
+	Attributes map[string]string `json:"attributes" query:"-"`
+	SearchId   string            `json:"-" query:"-"` // set on the first page of a search, recorded for analytics
+	Ids        []string          `json:"-" query:"-"` // from ?ids=P000001,P000002, the other filters still apply
+	StoreId    string            `json:"-" query:"-"` // set from the store of the request
+	At         time.Time         `json:"-" query:"-"` // availability windows are checked at this time, the preview time or now
+	SortKeys   []string          `json:"-" query:"-"` // parsed from ?sort=, the old ?order_by= is used when it is empty
 	*entities.PaginationReq
 	*entities.SortReq
 }
+
+const (
+	SortTitle       = "title"
+	SortPriceAsc    = "price_asc"
+	SortPriceDesc   = "price_desc"
+	SortNewest      = "newest"
+	SortBestSelling = "best_selling" // by the sales velocity of the inventory forecast job
+	SortRelevance   = "relevance"    // title matches of ?search= first, the default of a search
+)
+
+// SortKeys are the values of ?sort=. There is no rating because the shop has no reviews yet
+var SortKeys = []string{SortTitle, SortPriceAsc, SortPriceDesc, SortNewest, SortBestSelling, SortRelevance}
+
+// MaxSortKeys is the most keys of ?sort=price_asc,newest, a later key sorts the products equal by the earlier ones
+const MaxSortKeys = 3
+
+// ParseSort check each key of ?sort= against SortKeys. asc and desc are the direction of the old ?order_by=, so
+// they return no keys like an empty sort
+func ParseSort(sort string) ([]string, error) {
+	sort = strings.ToLower(strings.TrimSpace(sort))
+	if sort == "" || sort == "asc" || sort == "desc" {
+		return nil, nil
+	}
+
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(sort, ",") {
+		key = strings.TrimSpace(key)
+		if !validSortKey(key) {
+			return nil, fmt.Errorf("sort must be one of %s", strings.Join(SortKeys, ", "))
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > MaxSortKeys {
+		return nil, fmt.Errorf("at most %d sort keys", MaxSortKeys)
+	}
+	return keys, nil
+}
+
+func validSortKey(key string) bool {
+	for _, k := range SortKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Availability is the light version of product for the product page to poll, e.g. during flash sales
+type Availability struct {
+	ProductId string          `json:"product_id"`
+	InStock   bool            `json:"in_stock"`
+	Stock     int             `json:"stock"`
+	Price     float64         `json:"price"` // effective price in Currency
+	Currency  string          `json:"currency"`
+	Prices    []*ProductPrice `json:"-"`
+	UpdatedAt string          `json:"-"`
+	Version   string          `json:"version"` // changes whenever any other field changes, also sent as ETag
+}
+
+// attribute keys are lowercase words joined by _ , e.g. brand, screen_size
+var AttributeKeyPattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+const MaxAttributes = 50
+
+// Facet is the values of one attribute among the products matching a filter, for the storefront sidebar
+type Facet struct {
+	Key    string        `json:"key"`
+	Values []*FacetValue `json:"values"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"` // products with this value
+}
+
+// ImageOrderReq list every image of the product in the new order, the primary image still comes first
+type ImageOrderReq struct {
+	ImageIds []string `json:"image_ids"`
+}
+
+type ImageSearchReq struct {
+	Image   []byte
+	Limit   int    `query:"limit"`
+	StoreId string `query:"-"`
+}
+
+type SimilarProduct struct {
+	Product  *Products `json:"product"`
+	Distance float64   `json:"distance"` // lower is more similar, 0 is the same picture
+}
+
+type ProductUpdateRes struct {
+	Product *Products    `json:"product"`
+	Diff    *ProductDiff `json:"diff"`
+	Before  *Products    `json:"-"` // product before update, kept for the audit log
+}
+
+// ProductDiff is what an update changed, used by admin UIs to show "what changed"
+type ProductDiff struct {
+	Fields        []*FieldChange    `json:"fields"`
+	Category      *CategoryMove     `json:"category"` // null when category is unchanged
+	ImagesAdded   []*entities.Image `json:"images_added"`
+	ImagesRemoved []*entities.Image `json:"images_removed"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type CategoryMove struct {
+	From *appinfo.Category `json:"from"`
+	To   *appinfo.Category `json:"to"`
+}
+
+func (d *ProductDiff) IsEmpty() bool {
+	return len(d.Fields) == 0 && d.Category == nil && len(d.ImagesAdded) == 0 && len(d.ImagesRemoved) == 0
+}
+
+// DiffProduct compare product before and after update
+func DiffProduct(before, after *Products) *ProductDiff {
+	diff := &ProductDiff{
+		Fields:        make([]*FieldChange, 0),
+		ImagesAdded:   make([]*entities.Image, 0),
+		ImagesRemoved: make([]*entities.Image, 0),
+	}
+
+	if before.Title != after.Title {
+		diff.Fields = append(diff.Fields, &FieldChange{Field: "title", From: before.Title, To: after.Title})
+	}
+	if before.Description != after.Description {
+		diff.Fields = append(diff.Fields, &FieldChange{Field: "description", From: before.Description, To: after.Description})
+	}
+	if before.Price != after.Price {
+		diff.Fields = append(diff.Fields, &FieldChange{Field: "price", From: before.Price, To: after.Price})
+	}
+	if before.Stock != after.Stock {
+		diff.Fields = append(diff.Fields, &FieldChange{Field: "stock", From: before.Stock, To: after.Stock})
+	}
+
+	beforeCategoryId, afterCategoryId := 0, 0
+	if before.Category != nil {
+		beforeCategoryId = before.Category.Id
+	}
+	if after.Category != nil {
+		afterCategoryId = after.Category.Id
+	}
+	if beforeCategoryId != afterCategoryId {
+		diff.Category = &CategoryMove{
+			From: before.Category,
+			To:   after.Category,
+		}
+	}
+
+	// images are re-inserted on update so ids always change, compare by url instead
+	beforeUrls := make(map[string]bool)
+	for _, img := range before.Images {
+		beforeUrls[img.Url] = true
+	}
+	afterUrls := make(map[string]bool)
+	for _, img := range after.Images {
+		afterUrls[img.Url] = true
+		if !beforeUrls[img.Url] {
+			diff.ImagesAdded = append(diff.ImagesAdded, img)
+		}
+	}
+	for _, img := range before.Images {
+		if !afterUrls[img.Url] {
+			diff.ImagesRemoved = append(diff.ImagesRemoved, img)
+		}
+	}
+
+	return diff
+}
+
+// ShipsTo is the same rule as product_ships_to in the database
+func (p *Products) ShipsTo(country, state string) bool {
+	if len(p.Regions) == 0 {
+		return true
+	}
+	for _, region := range p.Regions {
+		if !strings.EqualFold(region.Country, country) {
+			continue
+		}
+		if region.State == "" || strings.EqualFold(region.State, strings.TrimSpace(state)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxBulkItems is the most changes one bulk update can have
+const MaxBulkItems = 500
+
+// bulk item statuses, skipped is a valid item which was not applied because another item failed
+const (
+	BulkUpdated   = "updated"
+	BulkUnchanged = "unchanged"
+	BulkFailed    = "failed"
+	BulkSkipped   = "skipped"
+)
+
+// BulkItem is a change of PATCH /v1/products/bulk, a field which is not sent is kept
+type BulkItem struct {
+	Id    string   `json:"id"`
+	Price *float64 `json:"price"` // in the currency of the product
+	Stock *int     `json:"stock"`
+}
+
+type BulkUpdateReq struct {
+	StoreId      string      `json:"-"`
+	SellerId     string      `json:"-"`              // only products of the seller can be changed, empty for admins
+	ActorId      string      `json:"-"`              // recorded in the inventory ledger
+	AllOrNothing bool        `json:"all_or_nothing"` // nothing is changed when an item is invalid
+	Items        []*BulkItem `json:"items"`
+}
+
+// BulkProduct is the price and stock of a product read or written by a bulk update
+type BulkProduct struct {
+	Id       string  `db:"id" json:"id"`
+	StoreId  string  `db:"store_id" json:"-"`
+	SellerId string  `db:"seller_id" json:"-"`
+	Price    float64 `db:"price" json:"price"`
+	Stock    int     `db:"stock" json:"stock"`
+	Version  int     `db:"version" json:"version"`
+}
+
+type BulkItemResult struct {
+	Id      string       `json:"id"`
+	Status  string       `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Product *BulkProduct `json:"product,omitempty"` // after the update, null when failed
+	Before  *BulkProduct `json:"-"`                 // kept for the audit log
+}
+
+type BulkUpdateRes struct {
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Items   []*BulkItemResult `json:"items"` // in the order of the request
+}
+
+// DiffBulkProduct is the ProductDiff of a bulk update, only price and stock can change
+func DiffBulkProduct(before, after *BulkProduct) *ProductDiff {
+	diff := &ProductDiff{
+		Fields:        make([]*FieldChange, 0),
+		ImagesAdded:   make([]*entities.Image, 0),
+		ImagesRemoved: make([]*entities.Image, 0),
+	}
+	if before.Price != after.Price {
+		diff.Fields = append(diff.Fields, &FieldChange{Field: "price", From: before.Price, To: after.Price})
+	}
+	if before.Stock != after.Stock {
+		diff.Fields = append(diff.Fields, &FieldChange{Field: "stock", From: before.Stock, To: after.Stock})
+	}
+	return diff
+}
@@ -1,18 +1,24 @@
 package productsHandlers
 
 import (
+	"errors"
 	"fmt"
-	"net/url"
+	"io"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/files"
 	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/products"
 	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 
@@ -24,33 +30,95 @@ const (
 	insertProductErr productsHandlerErrCode = "products-003"
 	updateProductErr productsHandlerErrCode = "products-004"
 	deleteProductErr productsHandlerErrCode = "products-005"
+	searchByImageErr productsHandlerErrCode = "products-006"
+	updateProductPricesErr productsHandlerErrCode = "products-007"
+	findAvailabilityErr productsHandlerErrCode = "products-008"
+	updateProductRegionsErr productsHandlerErrCode = "products-009"
+	updateProductAttributesErr productsHandlerErrCode = "products-010"
+	updateImageOrderErr productsHandlerErrCode = "products-011"
+	updatePrimaryImageErr productsHandlerErrCode = "products-012"
+	addProductImageErr productsHandlerErrCode = "products-013"
+	bulkUpdateProductErr productsHandlerErrCode = "products-014"
 )
 
+// availability is polled by product pages, a few seconds of staleness is fine
+const availabilityMaxAge = 5
+
+// ownProduct let admin change every product of the store, a seller can only change their own products
+func (h *productsHandler) ownProduct(c *fiber.Ctx, productId string) error {
+	product, err := h.productsUsecase.FindOneProduct(c.UserContext(), productId)
+	if err != nil {
+		return err
+	}
+	if product.StoreId != c.Locals("storeId").(string) {
+		return fmt.Errorf("no permission to change this product")
+	}
+	if c.Locals("userRoleId").(int) == 2 {
+		return nil
+	}
+	if product.SellerId == "" || product.SellerId != c.Locals("userId").(string) {
+		return fmt.Errorf("no permission to change this product")
+	}
+	return nil
+}
+
+// normalizeWindow turn the RFC3339 bounds of the availability window into UTC, "" is kept to clear a bound on update
+func normalizeWindow(req *products.Products) error {
+	bounds := make([]time.Time, 2)
+	for i, bound := range []*string{req.AvailableFrom, req.AvailableUntil} {
+		if bound == nil || strings.TrimSpace(*bound) == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(*bound))
+		if err != nil {
+			return fmt.Errorf("available_from and available_until must be RFC3339")
+		}
+		bounds[i] = t.UTC()
+		*bound = bounds[i].Format(products.WindowLayout)
+	}
+	if !bounds[0].IsZero() && !bounds[1].IsZero() && !bounds[1].After(bounds[0]) {
+		return fmt.Errorf("available_until must be after available_from")
+	}
+	return nil
+}
+
 type IProductsHandler interface{
 	FindOneProduct(c *fiber.Ctx) error
 	FindProduct(c *fiber.Ctx) error
+	FindProductPage(c *fiber.Ctx) error
 	AddProduct(c *fiber.Ctx) error
 	UpdateProduct(c *fiber.Ctx) error
 	DeleteProduct(c *fiber.Ctx) error
+	SearchByImage(c *fiber.Ctx) error
+	UpdateProductPrices(c *fiber.Ctx) error
+	UpdateProductRegions(c *fiber.Ctx) error
+	UpdateProductAttributes(c *fiber.Ctx) error
+	UpdateImageOrder(c *fiber.Ctx) error
+	UpdatePrimaryImage(c *fiber.Ctx) error
+	AddProductImage(c *fiber.Ctx) error
+	FindAvailability(c *fiber.Ctx) error
+	BulkUpdateProduct(c *fiber.Ctx) error
 }
 
 type productsHandler struct {
 	productsUsecase productsUsecases.IProductsUsecase
 	cfg config.IConfig
 	fileUsecase filesUsecases.IFilesUsecase
+	auditsUsecase auditsUsecases.IAuditsUsecase
 }
 
-func ProductsHandler(productsUsecase productsUsecases.IProductsUsecase, cfg config.IConfig, fileUsecase filesUsecases.IFilesUsecase) IProductsHandler {
+func ProductsHandler(productsUsecase productsUsecases.IProductsUsecase, cfg config.IConfig, fileUsecase filesUsecases.IFilesUsecase, auditsUsecase auditsUsecases.IAuditsUsecase) IProductsHandler {
 	return &productsHandler{
 		productsUsecase: productsUsecase,
 		cfg: cfg,
 		fileUsecase: fileUsecase,
+		auditsUsecase: auditsUsecase,
 	}
 }
 
 func (h *productsHandler) FindOneProduct(c *fiber.Ctx) error {
 	productId := strings.Trim(c.Params("productId"), " ")
-	product, err := h.productsUsecase.FindOneProduct(productId)
+	product, err := h.productsUsecase.FindOneProduct(c.UserContext(), productId)
 	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
@@ -59,13 +127,80 @@ func (h *productsHandler) FindOneProduct(c *fiber.Ctx) error {
 
 		).Res()
 	}
+
+	// สินค้าของร้านอื่นเหมือนไม่มีอยู่
+	if product.StoreId != c.Locals("storeId").(string) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(findOneProductErr),
+			"product not found",
+		).Res()
+	}
+
+	// draft ต้องมี preview token, archived ไม่แสดงหน้าร้าน
+	if _, preview := c.Locals("previewAt").(time.Time); product.Status == products.StatusArchived || (product.Status == products.StatusDraft && !preview) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(findOneProductErr),
+			"product not found",
+		).Res()
+	}
+
+	if err := h.productsUsecase.ConvertCurrency(c.UserContext(), []*products.Products{product}, c.Query("currency")); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	if entities.NotModified(c, product, lastModified(product)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
 	return entities.NewResponse(c).Success(
 		fiber.StatusOK,
 		product,
 	).Res()
 }
 
+// lastModified is the latest updated_at of the products, updated_at is a timestamp without time zone in UTC
+func lastModified(data ...*products.Products) time.Time {
+	var latest time.Time
+	for _, product := range data {
+		t, err := time.Parse("2006-01-02T15:04:05.999999999", product.UpdatedAt)
+		if err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// FindProduct is the list of v1, the page metadata is next to data
 func (h *productsHandler) FindProduct(c *fiber.Ctx) error {
+	return h.findProduct(c, false)
+}
+
+// FindProductPage is the list of v2, the page metadata is in pagination like every list of v2
+func (h *productsHandler) FindProductPage(c *fiber.Ctx) error {
+	return h.findProduct(c, true)
+}
+
+// productsRes reply the list in the shape of its version, the ETag is of that shape
+func productsRes(c *fiber.Ctx, res *entities.PaginateRes, page bool) error {
+	var data any = res
+	if page {
+		data = res.ToPage()
+	}
+	if list, ok := res.Data.([]*products.Products); ok && entities.NotModified(c, data, lastModified(list...)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	if page {
+		return entities.NewResponse(c).SuccessPage(fiber.StatusOK, data.(*entities.PageRes)).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, data).Res()
+}
+
+func (h *productsHandler) findProduct(c *fiber.Ctx, page bool) error {
 	req := &products.ProductFilter{
 		PaginationReq: &entities.PaginationReq{},
 		SortReq:       &entities.SortReq{},
@@ -79,6 +214,57 @@ func (h *productsHandler) FindProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	req.At, req.Preview = c.Locals("previewAt").(time.Time)
+	if !req.Preview {
+		req.At = time.Now()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	// GET /products/admin เห็นทุก status และกรองด้วย ?status= ได้
+	if roleId, ok := c.Locals("userRoleId").(int); ok && roleId == 2 {
+		req.Admin = true
+		req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+		if req.Status != "" && !products.ValidStatus(req.Status) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findProductErr),
+				"status must be draft, published or archived",
+			).Res()
+		}
+	} else {
+		req.Status = ""
+	}
+
+	// ?attr[brand]=nike&attr[color]=red
+	req.Attributes = make(map[string]string)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		k := string(key)
+		if strings.HasPrefix(k, "attr[") && strings.HasSuffix(k, "]") {
+			req.Attributes[strings.ToLower(strings.TrimSpace(k[5:len(k)-1]))] = strings.TrimSpace(string(value))
+		}
+	})
+
+	// ?ids=P000001,P000002 ของหน้า cart และ wishlist, ซ้ำได้แต่ได้สินค้าครั้งเดียว
+	if ids := strings.TrimSpace(c.Query("ids")); ids != "" {
+		seen := make(map[string]bool)
+		for _, id := range strings.Split(ids, ",") {
+			if id = strings.TrimSpace(id); id != "" && !seen[id] {
+				seen[id] = true
+				req.Ids = append(req.Ids, id)
+			}
+		}
+		if len(req.Ids) > products.MaxBatchIds {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findProductErr),
+				fmt.Sprintf("at most %d ids", products.MaxBatchIds),
+			).Res()
+		}
+	}
+	if len(req.Ids) > 0 {
+		return productsRes(c, h.productsUsecase.FindProductByIds(c.UserContext(), req), page)
+	}
+
 	if req.Page < 1 {
 		req.Page = 1
 	}
@@ -86,6 +272,19 @@ func (h *productsHandler) FindProduct(c *fiber.Ctx) error {
 		req.Limit = 3
 	}
 
+	// ?sort=price_asc,newest หรือแบบเดิม ?order_by=price&sort=desc
+	sortKeys, err := products.ParseSort(req.Sort)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findProductErr),
+			err.Error(),
+		).Res()
+	}
+	req.SortKeys = sortKeys
+	if len(req.SortKeys) == 0 && req.OrderBy == "" && req.Sort == "" && strings.TrimSpace(req.Search) != "" {
+		req.SortKeys = []string{products.SortRelevance}
+	}
 	if req.OrderBy == "" {
 		req.OrderBy = "title"
 	}
@@ -93,8 +292,13 @@ func (h *productsHandler) FindProduct(c *fiber.Ctx) error {
 		req.Sort = "ASC"
 	}
 
-	products := h.productsUsecase.FindProduct(req)
-	return entities.NewResponse(c).Success(fiber.StatusOK, products).Res()
+	// หน้าแรกของการค้นหาเท่านั้นที่นับเป็น search, หน้าถัดไปเป็นการเลื่อนดูผลเดิม
+	if strings.TrimSpace(req.Search) != "" && req.Page == 1 {
+		req.SearchId = uuid.NewString()
+		c.Set("X-Search-Id", req.SearchId)
+	}
+
+	return productsRes(c, h.productsUsecase.FindProduct(c.UserContext(), req), page)
 }
 
 func (h *productsHandler) AddProduct(c *fiber.Ctx) error {
@@ -119,8 +323,48 @@ func (h *productsHandler) AddProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	product, err := h.productsUsecase.AddProduct(req)
+	if req.Currency == "" {
+		req.Currency = h.cfg.App().Currency()
+	}
+	req.Currency = strings.ToUpper(req.Currency)
+
+	if err := normalizeWindow(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.StoreId = c.Locals("storeId").(string)
+
+	// สินค้าที่ seller สร้างเป็นของ seller เสมอ, admin สร้างให้ seller ได้
+	if c.Locals("userRoleId").(int) != 2 {
+		req.SellerId = c.Locals("userId").(string)
+	}
+
+	switch req.Status {
+	case "":
+		req.Status = products.StatusPublished
+	case products.StatusDraft, products.StatusPublished:
+	default:
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertProductErr),
+			"status must be draft or published",
+		).Res()
+	}
+
+	product, err := h.productsUsecase.AddProduct(c.UserContext(), req)
 	if err != nil {
+		// category ของร้านอื่นเหมือนไม่มีอยู่
+		if strings.HasSuffix(err.Error(), "category not found") {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertProductErr),
+				"category not found",
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(insertProductErr),
@@ -128,6 +372,14 @@ func (h *productsHandler) AddProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  c.Locals("userId").(string),
+		Action:   audits.ProductCreate,
+		Entity:   "product",
+		EntityId: product.Id,
+		After:    product,
+	})
+
 	return entities.NewResponse(c).Success(fiber.StatusCreated, product).Res()
 	
 }
@@ -151,8 +403,65 @@ func (h *productsHandler) UpdateProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	product, err := h.productsUsecase.UpdateProduct(req)
+	// version ที่อ่านไปก่อนแก้ ป้องกันการเขียนทับกันของ admin สองคน
+	if req.Version <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			"version is required",
+		).Res()
+	}
+
+	// ว่างคือไม่เปลี่ยน status
+	if req.Status != "" && !products.ValidStatus(req.Status) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			"status must be draft, published or archived",
+		).Res()
+	}
+
+	if err := normalizeWindow(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(updateProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	product, err := h.productsUsecase.UpdateProduct(c.UserContext(), req)
 	if err != nil {
+		var conflictErr *products.VersionConflictError
+		if errors.As(err, &conflictErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(updateProductErr),
+				err.Error(),
+			).Res()
+		}
+		if strings.HasSuffix(err.Error(), "category not found") {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateProductErr),
+				"category not found",
+			).Res()
+		}
+		// bound ที่ไม่ได้ส่งมาอาจทำให้ window กลับด้าน
+		if strings.Contains(err.Error(), "products_available_window_check") {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateProductErr),
+				"available_until must be after available_from",
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(updateProductErr),
@@ -160,6 +469,30 @@ func (h *productsHandler) UpdateProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	actorId := c.Locals("userId").(string)
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  actorId,
+		Action:   audits.ProductUpdate,
+		Entity:   "product",
+		EntityId: product.Product.Id,
+		Before:   product.Before,
+		After:    product.Product,
+		Diff:     product.Diff,
+	})
+	// price changes are also recorded on their own so they can be queried without reading every update
+	for _, field := range product.Diff.Fields {
+		if field.Field == "price" {
+			h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+				ActorId:  actorId,
+				Action:   audits.ProductPriceChange,
+				Entity:   "product",
+				EntityId: product.Product.Id,
+				Before:   field.From,
+				After:    field.To,
+				Diff:     field,
+			})
+		}
+	}
 
 
 	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
@@ -169,7 +502,7 @@ func (h *productsHandler) UpdateProduct(c *fiber.Ctx) error {
 func (h *productsHandler) DeleteProduct(c *fiber.Ctx) error {
 	productId := strings.Trim(c.Params("productId"), " ")
 	
-	product, err := h.productsUsecase.FindOneProduct(productId)
+	product, err := h.productsUsecase.FindOneProduct(c.UserContext(), productId)
 	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
@@ -178,23 +511,22 @@ func (h *productsHandler) DeleteProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(deleteProductErr),
+			err.Error(),
+		).Res()
+	}
+
 	deleteFileReq := make([]*files.DeleteFileReq, 0)
 	for _, image := range product.Images {
-		parsedURL, err := url.Parse(image.Url)
-		if err != nil {
-			fmt.Println("Error parsing URL:", err)
-		}	
-
-		// Get the path from the parsed URL
-		path := parsedURL.Path
-
-		// Remove the leading '/' character from the path
-		path = strings.TrimPrefix(path, fmt.Sprintf("/%s/", h.cfg.App().GCPBucket()))
+		// the url is on the storage or on the CDN
 		deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
-			Destination: fmt.Sprint(path),
+			Destination: h.fileUsecase.Destination(image.Url),
 		})
 	}
-	if err := h.fileUsecase.DeleteFileOnGCP(deleteFileReq); err != nil {
+	if err := h.fileUsecase.DeleteFileOnGCP(c.UserContext(), deleteFileReq); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(deleteProductErr),
@@ -202,7 +534,7 @@ func (h *productsHandler) DeleteProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if err := h.productsUsecase.DeleteProduct(productId); err != nil {
+	if err := h.productsUsecase.DeleteProduct(c.UserContext(), productId); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(deleteProductErr),
@@ -210,7 +542,522 @@ func (h *productsHandler) DeleteProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  c.Locals("userId").(string),
+		Action:   audits.ProductDelete,
+		Entity:   "product",
+		EntityId: product.Id,
+		Before:   product,
+	})
+
 
 	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
 
-}
\ No newline at end of file
+}
+
+func (h *productsHandler) SearchByImage(c *fiber.Ctx) error {
+	req := new(products.ImageSearchReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(searchByImageErr),
+			err.Error(),
+		).Res()
+	}
+	if req.Limit < 1 || req.Limit > 50 {
+		req.Limit = 10
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(searchByImageErr),
+			err.Error(),
+		).Res()
+	}
+
+	// files ext validation
+	extMap := map[string]string{
+		"png":  "png",
+		"jpg":  "jpg",
+		"jpeg": "jpeg",
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Filename), "."))
+	if extMap[ext] == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(searchByImageErr),
+			"invalid file extension",
+		).Res()
+	}
+	if file.Size > int64(h.cfg.App().FileLimit()) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(searchByImageErr),
+			"file is too large",
+		).Res()
+	}
+
+	container, err := file.Open()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(searchByImageErr),
+			err.Error(),
+		).Res()
+	}
+	defer container.Close()
+
+	req.Image, err = io.ReadAll(container)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(searchByImageErr),
+			err.Error(),
+		).Res()
+	}
+
+	similar, err := h.productsUsecase.SearchByImage(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(searchByImageErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, similar).Res()
+}
+
+func (h *productsHandler) UpdateProductPrices(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("productId"), " ")
+
+	req := make([]*products.ProductPrice, 0)
+	if err := c.BodyParser(&req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductPricesErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(updateProductPricesErr),
+			err.Error(),
+		).Res()
+	}
+
+	for _, price := range req {
+		if price.Currency == "" || price.Price < 0 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateProductPricesErr),
+				"currency and a non negative price are required",
+			).Res()
+		}
+	}
+
+	product, err := h.productsUsecase.UpdateProductPrices(c.UserContext(), productId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(updateProductPricesErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) UpdateProductRegions(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("productId"), " ")
+
+	req := make([]*products.ProductRegion, 0)
+	if err := c.BodyParser(&req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductRegionsErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(updateProductRegionsErr),
+			err.Error(),
+		).Res()
+	}
+
+	product, err := h.productsUsecase.UpdateProductRegions(c.UserContext(), productId, req)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateProductRegionsErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "region is duplicated",
+			strings.HasSuffix(err.Error(), "is not supported"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateProductRegionsErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateProductRegionsErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) UpdateProductAttributes(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("productId"), " ")
+
+	req := make(map[string]string)
+	if err := c.BodyParser(&req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductAttributesErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(updateProductAttributesErr),
+			err.Error(),
+		).Res()
+	}
+
+	product, err := h.productsUsecase.UpdateProductAttributes(c.UserContext(), productId, req)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateProductAttributesErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "attribute"),
+			strings.HasPrefix(err.Error(), "a product has at most"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateProductAttributesErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateProductAttributesErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) UpdateImageOrder(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("productId"), " ")
+
+	req := &products.ImageOrderReq{
+		ImageIds: make([]string, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateImageOrderErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(updateImageOrderErr),
+			err.Error(),
+		).Res()
+	}
+
+	product, err := h.productsUsecase.UpdateImageOrder(c.UserContext(), productId, req)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateImageOrderErr),
+				err.Error(),
+			).Res()
+		case "image ids are empty", "image ids must list every image of the product once":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateImageOrderErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateImageOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) UpdatePrimaryImage(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("productId"), " ")
+	imageId := strings.Trim(c.Params("imageId"), " ")
+
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(updatePrimaryImageErr),
+			err.Error(),
+		).Res()
+	}
+
+	product, err := h.productsUsecase.UpdatePrimaryImage(c.UserContext(), productId, imageId)
+	if err != nil {
+		switch err.Error() {
+		case "product not found", "image not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updatePrimaryImageErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updatePrimaryImageErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+// AddProductImage confirm an image uploaded straight to the bucket and add it to the product
+func (h *productsHandler) AddProductImage(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("productId"), " ")
+
+	req := new(files.ConfirmUploadReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addProductImageErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+	req.UserId = c.Locals("userId").(string)
+
+	if err := h.ownProduct(c, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrForbidden.Code,
+			string(addProductImageErr),
+			err.Error(),
+		).Res()
+	}
+
+	file, err := h.fileUsecase.ConfirmUpload(c.UserContext(), req)
+	if err != nil {
+		var infectedErr *files.InfectedError
+		var invalidErr *files.InvalidImageError
+		var quotaErr *files.QuotaError
+		switch {
+		case errors.As(err, &quotaErr):
+			return entities.NewResponse(c).Error(
+				fiber.ErrTooManyRequests.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		case errors.As(err, &infectedErr), errors.As(err, &invalidErr):
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnprocessableEntity.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "no permission to access the file":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "file not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "invalid file extension",
+			err.Error() == "file is larger than the file limit",
+			strings.HasPrefix(err.Error(), "content type"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	product, err := h.productsUsecase.AddProductImage(c.UserContext(), productId, &entities.Image{
+		FileName: file.FileName,
+		Url:      file.Url,
+	})
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(addProductImageErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, product).Res()
+}
+
+func (h *productsHandler) FindAvailability(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("productId"), " ")
+
+	// product ของร้านอื่นหาไม่เจอเหมือนไม่มี
+	availability, err := h.productsUsecase.FindAvailability(c.UserContext(), c.Locals("storeId").(string), productId, c.Query("currency"))
+	if err != nil {
+		switch err.Error() {
+		case "get product availability failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findAvailabilityErr),
+				"product not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findAvailabilityErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	etag := fmt.Sprintf(`"%s"`, availability.Version)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", availabilityMaxAge))
+
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, availability).Res()
+}
+
+// BulkUpdateProduct change the price and stock of many products at once, e.g. a sync from an ERP export.
+// The response has the result of every item in the order they were sent
+func (h *productsHandler) BulkUpdateProduct(c *fiber.Ctx) error {
+	req := new(products.BulkUpdateReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(bulkUpdateProductErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+	req.ActorId = c.Locals("userId").(string)
+	// seller แก้ได้เฉพาะสินค้าของตัวเอง
+	if c.Locals("userRoleId").(int) != 2 {
+		req.SellerId = c.Locals("userId").(string)
+	}
+
+	result, err := h.productsUsecase.BulkUpdateProduct(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "items are empty", err.Error() == "item is invalid", strings.HasPrefix(err.Error(), "a bulk update has at most"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(bulkUpdateProductErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(bulkUpdateProductErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	// all_or_nothing ที่มี item ไม่ผ่าน ไม่มีอะไรถูกแก้
+	if req.AllOrNothing && result.Failed > 0 {
+		fields := make(entities.ValidationErrors, 0, result.Failed)
+		for i, item := range result.Items {
+			if item.Status == products.BulkFailed {
+				fields = append(fields, &entities.FieldError{Field: fmt.Sprintf("items[%d]", i), Msg: item.Error})
+			}
+		}
+		return entities.NewResponse(c).ValidationError(
+			fiber.ErrUnprocessableEntity.Code,
+			string(bulkUpdateProductErr),
+			fields,
+		).Res()
+	}
+
+	actorId := c.Locals("userId").(string)
+	for _, item := range result.Items {
+		if item.Status != products.BulkUpdated {
+			continue
+		}
+		diff := products.DiffBulkProduct(item.Before, item.Product)
+		h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+			ActorId:  actorId,
+			Action:   audits.ProductUpdate,
+			Entity:   "product",
+			EntityId: item.Id,
+			Before:   item.Before,
+			After:    item.Product,
+			Diff:     diff,
+		})
+		for _, field := range diff.Fields {
+			if field.Field == "price" {
+				h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+					ActorId:  actorId,
+					Action:   audits.ProductPriceChange,
+					Entity:   "product",
+					EntityId: item.Id,
+					Before:   field.From,
+					After:    field.To,
+					Diff:     field,
+				})
+			}
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
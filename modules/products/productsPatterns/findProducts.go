@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/sqlbuilder"
 	"github.com/NatthawutSK/ri-shop/pkg/utils"
 	"github.com/jmoiron/sqlx"
 )
@@ -18,28 +20,34 @@ type IFindProductBuilder interface {
 	openJsonQuery()
 	initQuery()
 	countQuery()
+	facetQuery()
 	whereQuery()
+	closeFacetQuery()
 	sort()
 	paginate()
 	closeJsonQuery()
 	resetQuery()
 	Result() []*products.Products
 	Count() int
+	Facets() []*products.Facet
 	PrintQuery()
 }
 
 type findProductBuilder struct {
-	db             *sqlx.DB
-	req            *products.ProductFilter
-	query          string
-	lastStackIndex int
-	values         []any
+	ctx   context.Context
+	db    *sqlx.DB
+	req   *products.ProductFilter
+	query string
+	args  *sqlbuilder.Args
 }
 
-func FindProductBuilder(db *sqlx.DB, req *products.ProductFilter) IFindProductBuilder {
+// FindProductBuilder run the queries with ctx, a request pass the context with the deadline of its route
+func FindProductBuilder(ctx context.Context, db *sqlx.DB, req *products.ProductFilter) IFindProductBuilder {
 	return &findProductBuilder{
-		db:  db,
-		req: req,
+		ctx:  ctx,
+		db:   db,
+		req:  req,
+		args: sqlbuilder.NewArgs(),
 	}
 }
 
@@ -55,7 +63,26 @@ func (b *findProductBuilder) initQuery() {
 			"p"."id",
 			"p"."title",
 			"p"."description",
-			"p"."price",
+			minor_to_major("p"."price_minor", "p"."currency") AS "price",
+			"p"."currency",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("prt")), '[]'::json)
+				FROM (
+					SELECT
+						"pr"."currency",
+						minor_to_major("pr"."price_minor", "pr"."currency") AS "price"
+					FROM "product_prices" "pr"
+					WHERE "pr"."product_id" = "p"."id"
+				) AS "prt"
+			) AS "prices",
+			"p"."stock",
+			"p"."status",
+			COALESCE("p"."seller_id", '') AS "seller_id",
+			COALESCE("p"."store_id", '') AS "store_id",
+			"p"."version",
+			"p"."available_from",
+			"p"."available_until",
 			(
 				SELECT
 					to_jsonb("ct")
@@ -77,11 +104,33 @@ func (b *findProductBuilder) initQuery() {
 					SELECT
 						"i"."id",
 						"i"."filename",
-						"i"."url"
+						"i"."url",
+						"i"."position",
+						"i"."is_primary"
 					FROM "images" "i"
 					WHERE "i"."product_id" = "p"."id"
+					ORDER BY "i"."is_primary" DESC, "i"."position" ASC
 				) AS "it"
-			) AS "images"
+			) AS "images",
+			product_badges("p"."id") AS "badges",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("rt")), '[]'::json)
+				FROM (
+					SELECT
+						"r"."country",
+						"r"."state"
+					FROM "product_regions" "r"
+					WHERE "r"."product_id" = "p"."id"
+					ORDER BY "r"."country", "r"."state"
+				) AS "rt"
+			) AS "regions",
+			(
+				SELECT
+					COALESCE(jsonb_object_agg("a"."key", "a"."value"), '{}'::jsonb)
+				FROM "product_attributes" "a"
+				WHERE "a"."product_id" = "p"."id"
+			) AS "attributes"
 		FROM "products" "p"
 		WHERE 1 = 1`
 }
@@ -92,49 +141,112 @@ func (b *findProductBuilder) countQuery() {
 		FROM "products" "p"
 		WHERE 1 = 1`
 }
+// facetQuery count the products of every attribute value, the where is the same as the products list
+func (b *findProductBuilder) facetQuery() {
+	b.query += `
+	SELECT
+		COALESCE(array_to_json(array_agg("ft")), '[]'::json)
+	FROM (
+		SELECT
+			"v"."key",
+			json_agg(json_build_object('value', "v"."value", 'count', "v"."count") ORDER BY "v"."count" DESC, "v"."value" ASC) AS "values"
+		FROM (
+			SELECT
+				"a"."key",
+				"a"."value",
+				COUNT(*) AS "count"
+			FROM "products" "p"
+				JOIN "product_attributes" "a" ON "a"."product_id" = "p"."id"
+			WHERE 1 = 1`
+}
+func (b *findProductBuilder) closeFacetQuery() {
+	b.query += `
+			GROUP BY "a"."key", "a"."value"
+		) AS "v"
+		GROUP BY "v"."key"
+		ORDER BY "v"."key" ASC
+	) AS "ft";`
+}
 func (b *findProductBuilder) whereQuery() {
-	var queryWhere string
-	queryWhereStack := make([]string, 0)
+	where := sqlbuilder.NewWhere(b.args)
+
+	// Status check, draft เห็นได้เฉพาะ preview, archived เห็นได้เฉพาะ admin
+	switch {
+	case b.req.Admin && b.req.Status != "":
+		where.And(`"p"."status" = ?`, b.req.Status)
+	case b.req.Admin:
+	case b.req.Preview:
+		where.And(`"p"."status" <> 'archived'`)
+	default:
+		where.And(`"p"."status" = 'published'`)
+	}
+
+	// Window check, สินค้าที่ขายช่วงเวลาจำกัดแสดงเฉพาะในช่วงนั้น, admin เห็นทั้งหมด
+	if !b.req.Admin {
+		at := b.req.At
+		if at.IsZero() {
+			at = time.Now()
+		}
+		// window เก็บเป็น UTC ไม่มี time zone
+		where.And(`("p"."available_from" IS NULL OR "p"."available_from" <= ?)`, at.UTC())
+		where.And(`("p"."available_until" IS NULL OR "p"."available_until" > ?)`, at.UTC())
+	}
+
+	// Store check, แต่ละ store เห็นเฉพาะสินค้าของตัวเอง
+	where.And(`COALESCE("p"."store_id", '') = ?`, b.req.StoreId)
+
+	// Seller check, สินค้าของ seller ที่ถูก suspend ไม่แสดง
+	where.And(`("p"."seller_id" IS NULL OR EXISTS (SELECT 1 FROM "sellers" "s" WHERE "s"."user_id" = "p"."seller_id" AND "s"."status" = 'approved'))`)
+	if b.req.SellerId != "" {
+		where.And(`"p"."seller_id" = ?`, b.req.SellerId)
+	}
 
 	// Id check
 	if b.req.Id != "" {
-		b.values = append(b.values, b.req.Id)
-
-		queryWhereStack = append(queryWhereStack, `
-		AND "p"."id" = ?`)
+		where.And(`"p"."id" = ?`, b.req.Id)
+	}
+	if len(b.req.Ids) > 0 {
+		where.And(`"p"."id" = ANY(?::VARCHAR[])`, b.req.Ids)
 	}
 
 	// Search check
 	if b.req.Search != "" {
-		b.values = append(
-			b.values,
-			"%"+strings.ToLower(b.req.Search)+"%",
-			"%"+strings.ToLower(b.req.Search)+"%",
-		)
-
-		queryWhereStack = append(queryWhereStack, `
-		AND (LOWER("p"."title") LIKE ? OR LOWER("p"."description") LIKE ?)`)
-	}
-
-	for i := range queryWhereStack {
-		if i != len(queryWhereStack)-1 {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-		} else {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-			queryWhere = strings.Replace(queryWhere, "?", "$"+strconv.Itoa(i+2), 1)
-		}
+		search := "%" + strings.ToLower(b.req.Search) + "%"
+		where.And(`(LOWER("p"."title") LIKE ? OR LOWER("p"."description") LIKE ?)`, search, search)
+	}
+
+	// Badge check, badges ที่ได้จาก rule ด้วย
+	if b.req.Badge != "" {
+		where.And(`EXISTS (SELECT 1 FROM json_array_elements(product_badges("p"."id")) "b" WHERE "b"->>'code' = ?)`, strings.ToLower(b.req.Badge))
 	}
-	// Last stack record
-	b.lastStackIndex = len(b.values)
 
-	// Summary query
-	b.query += queryWhere
+	// Region check, สินค้าที่ส่งไปภูมิภาคของคนซื้อได้
+	if b.req.Country != "" {
+		where.And(`product_ships_to("p"."id", ?, ?)`, strings.ToUpper(strings.TrimSpace(b.req.Country)), strings.TrimSpace(b.req.State))
+	}
+
+	// Attribute check, เรียง key ให้ query เหมือนเดิมทุกครั้ง
+	keys := make([]string, 0, len(b.req.Attributes))
+	for key := range b.req.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		where.And(`EXISTS (SELECT 1 FROM "product_attributes" "pa" WHERE "pa"."product_id" = "p"."id" AND "pa"."key" = ? AND LOWER("pa"."value") = LOWER(?))`, key, b.req.Attributes[key])
+	}
+
+	b.query += where.String()
 }
 func (b *findProductBuilder) sort() {
+	if len(b.req.SortKeys) > 0 {
+		b.sortByKeys()
+		return
+	}
+
     orderByMap := map[string]string{
         "id":    "\"p\".\"id\"",
         "title": "\"p\".\"title\"",
-        "price": "\"p\".\"price\"",
+        "price": "\"p\".\"price_minor\"",
     }
  
     if orderByMap[strings.ToLower(b.req.OrderBy)] == "" {
@@ -154,20 +266,49 @@ func (b *findProductBuilder) sort() {
     }
  
     // โค้ดที่มีปัญหา เมื่อใช้แล้ว ORDER BY จะไม่ทำงาน
-    /* b.values = append(b.values, b.req.OrderBy)
-    b.query += fmt.Sprintf(`
-        ORDER BY $%d %s`, b.lastStackIndex+1, b.req.Sort)
-    b.lastStackIndex = len(b.values) */
+    /* b.query += fmt.Sprintf(`
+        ORDER BY %s %s`, b.args.Add(b.req.OrderBy), b.req.Sort) */
  
     b.query += fmt.Sprintf(`
-        ORDER BY %s %s`, b.req.OrderBy, b.req.Sort)
+        ORDER BY %s %s, "p"."id"`, b.req.OrderBy, b.req.Sort)
+}
+
+// sortByKeys order by ?sort=, the keys were checked by products.ParseSort. The id is last so a page never
+// repeat or skip a product which is equal to another
+func (b *findProductBuilder) sortByKeys() {
+	orders := make([]string, 0, len(b.req.SortKeys)+1)
+	for _, key := range b.req.SortKeys {
+		switch key {
+		case products.SortTitle:
+			orders = append(orders, `"p"."title" ASC`)
+		case products.SortPriceAsc:
+			orders = append(orders, `"p"."price_minor" ASC`)
+		case products.SortPriceDesc:
+			orders = append(orders, `"p"."price_minor" DESC`)
+		case products.SortNewest:
+			orders = append(orders, `"p"."created_at" DESC`)
+		case products.SortBestSelling:
+			orders = append(orders, `COALESCE((SELECT "f"."sales_velocity" FROM "inventory_forecasts" "f" WHERE "f"."product_id" = "p"."id"), 0) DESC`)
+		case products.SortRelevance:
+			// ไม่มีคำค้นก็ไม่มี relevance, คำที่อยู่ใน title ก่อน และยิ่งอยู่ต้น title ยิ่งก่อน
+			search := strings.ToLower(strings.TrimSpace(b.req.Search))
+			if search == "" {
+				continue
+			}
+			orders = append(orders,
+				fmt.Sprintf(`CASE WHEN LOWER("p"."title") LIKE %s THEN 0 ELSE 1 END`, b.args.Add("%"+search+"%")),
+				fmt.Sprintf(`POSITION(%s IN LOWER("p"."title"))`, b.args.Add(search)),
+			)
+		}
+	}
+	orders = append(orders, `"p"."id"`)
+
+	b.query += `
+		ORDER BY ` + strings.Join(orders, ", ")
 }
 func (b *findProductBuilder) paginate() {
 	// offset (page - 1)*limit
-	b.values = append(b.values, (b.req.Page-1)*b.req.Limit, b.req.Limit)
-
-	b.query += fmt.Sprintf(`	OFFSET $%d LIMIT $%d`, b.lastStackIndex+1, b.lastStackIndex+2)
-	b.lastStackIndex = len(b.values)
+	b.query += fmt.Sprintf(`	OFFSET %s LIMIT %s`, b.args.Add((b.req.Page-1)*b.req.Limit), b.args.Add(b.req.Limit))
 }
 func (b *findProductBuilder) closeJsonQuery() {
 	b.query += `
@@ -175,17 +316,16 @@ func (b *findProductBuilder) closeJsonQuery() {
 }
 func (b *findProductBuilder) resetQuery() {
 	b.query = ""
-	b.values = make([]any, 0)
-	b.lastStackIndex = 0
+	b.args = sqlbuilder.NewArgs()
 }
 func (b *findProductBuilder) Result() []*products.Products {
-	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
 	defer cancel()
 
 	bytes := make([]byte, 0)
 	productsData := make([]*products.Products, 0)
 
-	if err := b.db.Get(&bytes, b.query, b.values...); err != nil {
+	if err := b.db.GetContext(ctx, &bytes, b.query, b.args.Values()...); err != nil {
 		log.Printf("find products failed: %v\n", err)
 		return make([]*products.Products, 0)
 	}
@@ -198,19 +338,38 @@ func (b *findProductBuilder) Result() []*products.Products {
 	return productsData
 }
 func (b *findProductBuilder) Count() int {
-	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
 	defer cancel()
 
 	var count int
-	if err := b.db.Get(&count, b.query, b.values...); err != nil {
+	if err := b.db.GetContext(ctx, &count, b.query, b.args.Values()...); err != nil {
 		log.Printf("count products failed: %v\n", err)
 		return 0
 	}
 	b.resetQuery()
 	return count
 }
+func (b *findProductBuilder) Facets() []*products.Facet {
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
+	bytes := make([]byte, 0)
+	facets := make([]*products.Facet, 0)
+
+	if err := b.db.GetContext(ctx, &bytes, b.query, b.args.Values()...); err != nil {
+		log.Printf("find product facets failed: %v\n", err)
+		return make([]*products.Facet, 0)
+	}
+
+	if err := json.Unmarshal(bytes, &facets); err != nil {
+		log.Printf("unmarshal product facets failed: %v\n", err)
+		return make([]*products.Facet, 0)
+	}
+	b.resetQuery()
+	return facets
+}
 func (b *findProductBuilder) PrintQuery() {
-	utils.Debug(b.values)
+	utils.Debug(b.args.Values())
 	fmt.Println(b.query)
 }
 
@@ -237,3 +396,10 @@ func (en *findProductEngineer) CountProduct() IFindProductBuilder {
 	en.builder.whereQuery()
 	return en.builder
 }
+
+func (en *findProductEngineer) FacetProduct() IFindProductBuilder {
+	en.builder.facetQuery()
+	en.builder.whereQuery()
+	en.builder.closeFacetQuery()
+	return en.builder
+}
@@ -3,14 +3,15 @@ package productsPatterns
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/sqlbuilder"
 	"github.com/jmoiron/sqlx"
 )
 
 type IInsertProductBuilder interface {
-	initTransaction() error
+	initTransaction(ctx context.Context) error
 	insertProduct() error
 	insertCategory() error
 	insertAttachment() error
@@ -20,8 +21,9 @@ type IInsertProductBuilder interface {
 }
 
 type insertProductBuilder struct {
-	db *sqlx.DB
-	tx *sqlx.Tx
+	ctx context.Context
+	db  *sqlx.DB
+	tx  *sqlx.Tx
 	req *products.Products
 }
 
@@ -34,8 +36,10 @@ func InsertProductBuilder(db *sqlx.DB, req *products.Products) IInsertProductBui
 
 
 
-func (b *insertProductBuilder) initTransaction() error {
-	tx, err := b.db.BeginTxx(context.Background(), nil)
+// initTransaction keep ctx for the queries of the insert, the transaction is rolled back when it is canceled
+func (b *insertProductBuilder) initTransaction(ctx context.Context) error {
+	b.ctx = ctx
+	tx, err := b.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -50,7 +54,7 @@ func (b *insertProductBuilder) getProductId() string {
 }
 
 func (b *insertProductBuilder) insertProduct() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
 	defer cancel()
 
 
@@ -58,9 +62,16 @@ func (b *insertProductBuilder) insertProduct() error {
 	INSERT INTO "products" (
 		"title",
 		"description",
-		"price"
+		"price_minor",
+		"currency",
+		"stock",
+		"status",
+		"seller_id",
+		"store_id",
+		"available_from",
+		"available_until"
 	)
-	VALUES ($1, $2, $3)
+	VALUES ($1, $2, major_to_minor($3, $4), $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, '')::TIMESTAMP, NULLIF($10, '')::TIMESTAMP)
 		RETURNING "id";`
 
 	if err := b.tx.QueryRowxContext(
@@ -69,6 +80,13 @@ func (b *insertProductBuilder) insertProduct() error {
 		b.req.Title,
 		b.req.Description,
 		b.req.Price,
+		b.req.Currency,
+		b.req.Stock,
+		b.req.Status,
+		b.req.SellerId,
+		b.req.StoreId,
+		b.req.AvailableFrom,
+		b.req.AvailableUntil,
 	).Scan(&b.req.Id); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert product failed: %v", err)
@@ -77,63 +95,64 @@ func (b *insertProductBuilder) insertProduct() error {
 }
 
 func (b *insertProductBuilder) insertCategory() error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
 	defer cancel()
 
+	// category ต้องเป็นของ store เดียวกับสินค้า
 	query := `
 	INSERT INTO "products_categories" (
 		"product_id",
 		"category_id"
 	)
-	VALUES ($1, $2);`
+	SELECT $1, "c"."id"
+	FROM "categories" "c"
+	WHERE "c"."id" = $2
+	AND COALESCE("c"."store_id", '') = $3;`
 
-	if _, err := b.tx.ExecContext(
+	result, err := b.tx.ExecContext(
 		ctx,
 		query,
 		b.req.Id,
 		b.req.Category.Id,
-	); err != nil {
+		b.req.StoreId,
+	)
+	if err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert products_categories failed: %v", err)
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		b.tx.Rollback()
+		return fmt.Errorf("category not found")
+	}
 	return nil
 }
 
 func (b *insertProductBuilder) insertAttachment() error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
 	defer cancel()
 
 	query := `
 	INSERT INTO "images" (
 		"filename",
 		"url",
-		"product_id"
+		"product_id",
+		"position",
+		"is_primary"
 	)
 	VALUES`
 
-	valueStack := make([]any, 0)
-	var index int
-	for i := range b.req.Images {
-		valueStack = append(valueStack,
-			b.req.Images[i].FileName,
-			b.req.Images[i].Url,
-			b.req.Id,
-		)
-
-		if i != len(b.req.Images)-1 {
-			query += fmt.Sprintf(`
-			($%d, $%d, $%d),`, index+1, index+2, index+3)
-		} else {
-			query += fmt.Sprintf(`
-			($%d, $%d, $%d);`, index+1, index+2, index+3)
-		}
-		index += 3
+	args := sqlbuilder.NewArgs()
+	rows := make([][]any, 0, len(b.req.Images))
+	// ลำดับตามที่ส่งมา รูปแรกเป็น cover
+	for i, image := range b.req.Images {
+		rows = append(rows, []any{image.FileName, image.Url, b.req.Id, i, i == 0})
 	}
+	query += sqlbuilder.Values(args, rows) + ";"
 
 	if _, err := b.tx.ExecContext(
 		ctx,
 		query,
-		valueStack...,
+		args.Values()...,
 	); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert images failed: %v", err)
@@ -165,8 +184,8 @@ func InsertProductEngineer(builder IInsertProductBuilder) *insertProductEngineer
 	}
 }
 
-func (en *insertProductEngineer) InsertProduct() (string, error) {
-	if err := en.builder.initTransaction(); err != nil {
+func (en *insertProductEngineer) InsertProduct(ctx context.Context) (string, error) {
+	if err := en.builder.initTransaction(ctx); err != nil {
 		return "", err
 	}
 
@@ -3,57 +3,59 @@ package productsPatterns
 import (
 	"context"
 	"fmt"
-	"net/url"
-	"strings"
+	"log"
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
-	"github.com/NatthawutSK/ri-shop/modules/files"
 	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/sqlbuilder"
 	"github.com/jmoiron/sqlx"
 )
 
 type IUpdateProductBuilder interface {
-	initTransaction() error
+	initTransaction(ctx context.Context) error
 	initQuery()
 	updateTitleQuery()
 	updateDescriptionQuery()
 	updatePriceQuery()
+	updateStatusQuery()
+	updateWindowQuery()
+	updateVersionQuery()
+	setQuery()
 	updateCategory() error
 	insertImages() error
 	getOldImages() []*entities.Image
 	deleteOldImages() error
+	deleteQueuedFiles(ctx context.Context)
 	closeQuery()
 	updateProduct() error
-	getQueryFields() []string
-	getValues() []any
-	getQuery() string
-	setQuery(query string)
 	getImagesLen() int
 	commit() error
 }
 
 type updateProductBuilder struct{
+	ctx            context.Context
 	db             *sqlx.DB
 	tx             *sqlx.Tx
 	req            *products.Products
 	filesUsecases  filesUsecases.IFilesUsecase
 	query          string
-	queryFields    []string
-	lastStackIndex int
-	values         []any
+	args           *sqlbuilder.Args
+	set            *sqlbuilder.Set
+	deletionIds    []int
 	cfg 		   config.IConfig
 }
 
 func UpdateProductBuilder(db *sqlx.DB, req *products.Products, fileUsecase filesUsecases.IFilesUsecase, cfg config.IConfig) IUpdateProductBuilder {
+	args := sqlbuilder.NewArgs()
 	return &updateProductBuilder{
 		db:             db,
 		req:            req,
 		filesUsecases:  fileUsecase,
-		queryFields:    make([]string, 0),
-		values:         make([]any, 0),
-		lastStackIndex: 0,
+		args:           args,
+		set:            sqlbuilder.NewSet(args),
 		cfg: cfg,
 	}
 }
@@ -69,9 +71,10 @@ func UpdateProductEngineer(builder IUpdateProductBuilder) *updateProductEngineer
 }
 
 
-func (b *updateProductBuilder) initTransaction() error {
-
-	tx, err := b.db.BeginTxx(context.Background(), nil)
+// initTransaction keep ctx for the queries of the update, the transaction is rolled back when it is canceled
+func (b *updateProductBuilder) initTransaction(ctx context.Context) error {
+	b.ctx = ctx
+	tx, err := b.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -86,32 +89,45 @@ func (b *updateProductBuilder) initQuery() {
 
 func (b *updateProductBuilder) updateTitleQuery() {
 	if b.req.Title != "" {
-		b.values = append(b.values, b.req.Title)
-		b.lastStackIndex = len(b.values)
-
-		b.queryFields = append(b.queryFields, fmt.Sprintf(`
-		"title" = $%d`, b.lastStackIndex))
+		b.set.Add("title", b.req.Title)
 	}
 }
 
 func (b *updateProductBuilder) updateDescriptionQuery() {
 	if b.req.Description != "" {
-		b.values = append(b.values, b.req.Description)
-		b.lastStackIndex = len(b.values)
-
-		b.queryFields = append(b.queryFields, fmt.Sprintf(`
-		"description" = $%d`, b.lastStackIndex))
+		b.set.Add("description", b.req.Description)
 	}
 }
 
 func (b *updateProductBuilder) updatePriceQuery() {
 	if b.req.Price != 0 {
-		b.values = append(b.values, b.req.Price)
-		b.lastStackIndex = len(b.values)
+		b.set.Expr(`"price_minor" = major_to_minor(?, "currency")`, b.req.Price)
+	}
+}
+
+func (b *updateProductBuilder) updateStatusQuery() {
+	if b.req.Status != "" {
+		b.set.Add("status", b.req.Status)
+	}
+}
 
-		b.queryFields = append(b.queryFields, fmt.Sprintf(`
-		"price" = $%d`, b.lastStackIndex))
+// updateWindowQuery keep a bound which is not sent, "" clear it
+func (b *updateProductBuilder) updateWindowQuery() {
+	if b.req.AvailableFrom != nil {
+		b.set.Expr(`"available_from" = NULLIF(?, '')::TIMESTAMP`, *b.req.AvailableFrom)
 	}
+	if b.req.AvailableUntil != nil {
+		b.set.Expr(`"available_until" = NULLIF(?, '')::TIMESTAMP`, *b.req.AvailableUntil)
+	}
+}
+
+// updateVersionQuery is always set, closeQuery only match the version the client read
+func (b *updateProductBuilder) updateVersionQuery() {
+	b.set.Expr(`"version" = "version" + 1`)
+}
+
+func (b *updateProductBuilder) setQuery() {
+	b.query += b.set.String()
 }
 
 func (b *updateProductBuilder) updateCategory() error {
@@ -123,13 +139,34 @@ func (b *updateProductBuilder) updateCategory() error {
 		return nil
 	}
 
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
+	// category ต้องเป็นของ store เดียวกับสินค้า
+	var found bool
+	if err := b.tx.GetContext(ctx, &found, `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "categories" "c"
+			JOIN "products" "p" ON COALESCE("p"."store_id", '') = COALESCE("c"."store_id", '')
+		WHERE "c"."id" = $1
+		AND "p"."id" = $2
+	);`, b.req.Category.Id, b.req.Id); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("find category failed: %v", err)
+	}
+	if !found {
+		b.tx.Rollback()
+		return fmt.Errorf("category not found")
+	}
+
 	query := `
 	UPDATE "products_categories" SET
 		"category_id" = $1
 	WHERE "product_id" = $2;`
 
 	if _, err := b.tx.ExecContext(
-		context.Background(),
+		ctx,
 		query,
 		b.req.Category.Id,
 		b.req.Id,
@@ -141,37 +178,31 @@ func (b *updateProductBuilder) updateCategory() error {
 }
 
 func (b *updateProductBuilder) insertImages() error {
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
 	query := `
 	INSERT INTO "images" (
 		"filename",
 		"url",
-		"product_id"
+		"product_id",
+		"position",
+		"is_primary"
 	)
 	VALUES`
 
-	valueStack := make([]any, 0)
-	var index int
-	for i := range b.req.Images {
-		valueStack = append(valueStack,
-			b.req.Images[i].FileName,
-			b.req.Images[i].Url,
-			b.req.Id,
-		)
-
-		if i != len(b.req.Images)-1 {
-			query += fmt.Sprintf(`
-			($%d, $%d, $%d),`, index+1, index+2, index+3)
-		} else {
-			query += fmt.Sprintf(`
-			($%d, $%d, $%d);`, index+1, index+2, index+3)
-		}
-		index += 3
+	args := sqlbuilder.NewArgs()
+	rows := make([][]any, 0, len(b.req.Images))
+	// ลำดับตามที่ส่งมา รูปแรกเป็น cover
+	for i, image := range b.req.Images {
+		rows = append(rows, []any{image.FileName, image.Url, b.req.Id, i, i == 0})
 	}
+	query += sqlbuilder.Values(args, rows) + ";"
 
 	if _, err := b.tx.ExecContext(
-		context.Background(),
+		ctx,
 		query,
-		valueStack...,
+		args.Values()...,
 	); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert images failed: %v", err)
@@ -180,6 +211,9 @@ func (b *updateProductBuilder) insertImages() error {
 }
 
 func (b *updateProductBuilder) getOldImages() []*entities.Image {
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
 	query := `
 	SELECT
 		"id",
@@ -189,7 +223,8 @@ func (b *updateProductBuilder) getOldImages() []*entities.Image {
 	WHERE "product_id" = $1;`
 
 	images := make([]*entities.Image, 0)
-	if err := b.db.Select(
+	if err := b.tx.SelectContext(
+		ctx,
 		&images,
 		query,
 		b.req.Id,
@@ -199,79 +234,94 @@ func (b *updateProductBuilder) getOldImages() []*entities.Image {
 	return images
 }
 
+// deleteOldImages only queue the old files in the transaction, they are deleted from GCP after commit
+// so a rollback never leaves the product without images
 func (b *updateProductBuilder) deleteOldImages() error {
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
 	query := `
 	DELETE FROM "images"
 	WHERE "product_id" = $1;`
 
 	images := b.getOldImages()
-	if len(images) > 0 {
-		deleteFileReq := make([]*files.DeleteFileReq, 0)
-		for _,img := range images {
-			parsedURL, err := url.Parse(img.Url)
-			if err != nil {
-				fmt.Println("Error parsing URL:", err)
-			}	
-
-			// Get the path from the parsed URL
-			path := parsedURL.Path
-
-			// Remove the leading '/' character from the path
-			path = strings.TrimPrefix(path, fmt.Sprintf("/%s/", b.cfg.App().GCPBucket()))
-			deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
-				Destination: fmt.Sprint(path),
-			})
-		}
-		 
-		if err := b.filesUsecases.DeleteFileOnGCP(deleteFileReq) ; err != nil {
-			return fmt.Errorf("delete old images failed: %v", err)
-		}
-			
-	}
 
 	if _, err := b.tx.ExecContext(
-		context.Background(),
+		ctx,
 		query,
 		b.req.Id,
 	); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("delete images failed: %v", err)
 	}
+
+	if len(images) > 0 {
+		destinations := make([]string, 0)
+		for _,img := range images {
+			// the url is on the storage or on the CDN
+			destinations = append(destinations, b.filesUsecases.Destination(img.Url))
+		}
+
+		query, args, err := sqlx.In(`
+		INSERT INTO "file_deletions" ("destination")
+		SELECT unnest(ARRAY[?]::VARCHAR[])
+			RETURNING "id";`, destinations)
+		if err != nil {
+			b.tx.Rollback()
+			return fmt.Errorf("build queue file deletions query failed: %v", err)
+		}
+		if err := b.tx.SelectContext(
+			ctx,
+			&b.deletionIds,
+			b.tx.Rebind(query),
+			args...,
+		); err != nil {
+			b.tx.Rollback()
+			return fmt.Errorf("queue file deletions failed: %v", err)
+		}
+	}
 	return nil
 }
 
-func (b *updateProductBuilder) closeQuery() {
-	b.values = append(b.values, b.req.Id)
-	b.lastStackIndex = len(b.values)
+// deleteQueuedFiles try the deletions right after commit, a failed one is retried by the files job
+// and must not fail the update
+func (b *updateProductBuilder) deleteQueuedFiles(ctx context.Context) {
+	if len(b.deletionIds) == 0 {
+		return
+	}
+	if err := b.filesUsecases.ProcessFileDeletion(ctx, b.deletionIds); err != nil {
+		log.Printf("delete old images of product %s failed, will retry: %v\n", b.req.Id, err)
+	}
+}
 
+func (b *updateProductBuilder) closeQuery() {
 	b.query += fmt.Sprintf(`
-	WHERE "id" = $%d`, b.lastStackIndex)
+	WHERE "id" = %s
+	AND "version" = %s`, b.args.Add(b.req.Id), b.args.Add(b.req.Version))
 }
 
 func (b *updateProductBuilder) updateProduct() error {
-	if _, err := b.tx.ExecContext(context.Background(), b.query, b.values...); err != nil {
+	ctx, cancel := databases.WithQueryTimeout(b.ctx)
+	defer cancel()
+
+	result, err := b.tx.ExecContext(ctx, b.query, b.args.Values()...)
+	if err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("update product failed: %v", err)
 	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	// someone else has updated the product since the client read it
+	if rowsAffected == 0 {
+		b.tx.Rollback()
+		return &products.VersionConflictError{Version: b.req.Version}
+	}
 	return nil
 }
 
-func (b *updateProductBuilder) getQueryFields() []string {
-	return b.queryFields
-}
-
-func (b *updateProductBuilder) getValues() []any {
-	return b.values
-}
-
-func (b *updateProductBuilder) getQuery() string {
-	return b.query
-}
-
-func (b *updateProductBuilder) setQuery(query string) {
-	b.query = query
-}
-
 func (b *updateProductBuilder) getImagesLen() int {
 	return len(b.req.Images)
 }
@@ -283,9 +333,9 @@ func (b *updateProductBuilder) commit() error {
 	return nil
 }
 
-func (en *updateProductEngineer) UpdateProduct() error {
+func (en *updateProductEngineer) UpdateProduct(ctx context.Context) error {
 
-	en.builder.initTransaction();
+	en.builder.initTransaction(ctx)
 	en.builder.initQuery()
 	en.sumQueryFields()
 	en.builder.closeQuery()
@@ -295,9 +345,9 @@ func (en *updateProductEngineer) UpdateProduct() error {
 		return fmt.Errorf("update category failed: %v", err)
 	}
 
-	// update product
+	// update product ก่อนลบรูปเก่า, version ไม่ตรงต้องไม่ลบรูปบน GCP
 	if err := en.builder.updateProduct(); err != nil {
-		return fmt.Errorf("update product failed: %v", err)
+		return err
 	}
 
 	fmt.Print("len image", en.builder.getImagesLen())
@@ -317,6 +367,9 @@ func (en *updateProductEngineer) UpdateProduct() error {
 	if err := en.builder.commit(); err != nil {
 		return fmt.Errorf("commit failed: %v", err)
 	}
+
+	// ลบรูปเก่าบน GCP หลัง commit เท่านั้น
+	en.builder.deleteQueuedFiles(ctx)
 	
 	return nil
 }
@@ -325,16 +378,8 @@ func (en *updateProductEngineer) sumQueryFields() {
 	en.builder.updateTitleQuery()
 	en.builder.updateDescriptionQuery()
 	en.builder.updatePriceQuery()
-
-	fields := en.builder.getQueryFields()
-
-	for i := range fields {
-		query := en.builder.getQuery()
-		if i != len(fields) - 1 {
-			en.builder.setQuery(query + fields[i] + ",")
-		} else {
-			en.builder.setQuery(query + fields[i])
-		}
-	}
-
+	en.builder.updateStatusQuery()
+	en.builder.updateWindowQuery()
+	en.builder.updateVersionQuery()
+	en.builder.setQuery()
 }
\ No newline at end of file
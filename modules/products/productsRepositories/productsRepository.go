@@ -4,22 +4,41 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryRepositories"
 	"github.com/NatthawutSK/ri-shop/modules/products"
 	"github.com/NatthawutSK/ri-shop/modules/products/productsPatterns"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
 	"github.com/jmoiron/sqlx"
 )
 
 type IProductsRepository interface{
-	FindOneProduct(productId string) (*products.Products, error)
-	FindProduct(req *products.ProductFilter) ([]*products.Products, int)
-	InsertProduct(req *products.Products) (*products.Products, error)
-	UpdateProduct(req *products.Products) (*products.Products, error)
-	DeleteProduct(productId string) error
+	FindOneProduct(ctx context.Context, productId string) (*products.Products, error)
+	FindProduct(ctx context.Context, req *products.ProductFilter) ([]*products.Products, int)
+	FindProductByIds(ctx context.Context, req *products.ProductFilter) []*products.Products
+	FindFacet(ctx context.Context, req *products.ProductFilter) []*products.Facet
+	InsertProduct(ctx context.Context, req *products.Products) (*products.Products, error)
+	UpdateProduct(ctx context.Context, req *products.Products) (*products.Products, error)
+	DeleteProduct(ctx context.Context, productId string) error
+	FindSimilarProduct(ctx context.Context, storeId, embedding string, limit int) ([]*products.SimilarProduct, error)
+	UpdateProductPrices(ctx context.Context, productId string, req []*products.ProductPrice) error
+	UpdateProductRegions(ctx context.Context, productId string, req []*products.ProductRegion) error
+	UpdateProductAttributes(ctx context.Context, productId string, req map[string]string) error
+	UpdateImageOrder(ctx context.Context, productId string, imageIds []string) error
+	UpdatePrimaryImage(ctx context.Context, productId, imageId string) error
+	InsertImage(ctx context.Context, productId string, image *entities.Image) error
+	FindAvailability(ctx context.Context, storeId, productId string) (*products.Availability, error)
+	FindTopProductId(ctx context.Context, limit int) ([]string, error)
+	UpdateWindowOpen(ctx context.Context) ([]*products.WindowEvent, error)
+	FindBulkProduct(ctx context.Context, productIds []string) (map[string]*products.BulkProduct, error)
+	UpdateBulkProduct(ctx context.Context, actorId string, req []*products.BulkItem) (map[string]*products.BulkProduct, error)
 }
 
 type productsRepository struct {
@@ -36,7 +55,8 @@ func ProductsRepository(db *sqlx.DB, cfg config.IConfig, fileUsecase filesUsecas
 	}
 }
 
-func (r *productsRepository) FindOneProduct(productId string) (*products.Products, error) {
+// FindOneProduct stop at the deadline of ctx, or after databases.QueryTimeout when it has none
+func (r *productsRepository) FindOneProduct(ctx context.Context, productId string) (*products.Products, error) {
 	query := `
 	SELECT
 		to_jsonb("t")
@@ -45,7 +65,26 @@ func (r *productsRepository) FindOneProduct(productId string) (*products.Product
 			"p"."id",
 			"p"."title",
 			"p"."description",
-			"p"."price",
+			minor_to_major("p"."price_minor", "p"."currency") AS "price",
+			"p"."currency",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("prt")), '[]'::json)
+				FROM (
+					SELECT
+						"pr"."currency",
+						minor_to_major("pr"."price_minor", "pr"."currency") AS "price"
+					FROM "product_prices" "pr"
+					WHERE "pr"."product_id" = "p"."id"
+				) AS "prt"
+			) AS "prices",
+			"p"."stock",
+			"p"."status",
+			COALESCE("p"."seller_id", '') AS "seller_id",
+			COALESCE("p"."store_id", '') AS "store_id",
+			"p"."version",
+			"p"."available_from",
+			"p"."available_until",
 			(
 				SELECT
 					to_jsonb("ct")
@@ -67,11 +106,33 @@ func (r *productsRepository) FindOneProduct(productId string) (*products.Product
 					SELECT
 						"i"."id",
 						"i"."filename",
-						"i"."url"
+						"i"."url",
+						"i"."position",
+						"i"."is_primary"
 					FROM "images" "i"
 					WHERE "i"."product_id" = "p"."id"
+					ORDER BY "i"."is_primary" DESC, "i"."position" ASC
 				) AS "it"
-			) AS "images"
+			) AS "images",
+			product_badges("p"."id") AS "badges",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("rt")), '[]'::json)
+				FROM (
+					SELECT
+						"r"."country",
+						"r"."state"
+					FROM "product_regions" "r"
+					WHERE "r"."product_id" = "p"."id"
+					ORDER BY "r"."country", "r"."state"
+				) AS "rt"
+			) AS "regions",
+			(
+				SELECT
+					COALESCE(jsonb_object_agg("a"."key", "a"."value"), '{}'::jsonb)
+				FROM "product_attributes" "a"
+				WHERE "a"."product_id" = "p"."id"
+			) AS "attributes"
 		FROM "products" "p"
 		WHERE "p"."id" = $1
 		LIMIT 1
@@ -89,12 +150,16 @@ func (r *productsRepository) FindOneProduct(productId string) (*products.Product
 	product := &products.Products{
 		Images: make([]*entities.Image, 0), //เวลาสร้าง struct ใหม่ แล้วข้างในมี array ให้ make array ไว้เลยเพื่อป้องกัน null pointer
 	}
-	if err := r.db.Get(&productBytes, query, productId); err != nil {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if err := r.db.GetContext(ctx, &productBytes, query, productId); err != nil {
 		return nil, fmt.Errorf("get product failed: %v", err)
 	}
 	if err := json.Unmarshal(productBytes, &product); err != nil {
 		return nil, fmt.Errorf("unmarshal product failed: %v", err)
 	}
+	r.cdnImages(product)
 
 
 
@@ -103,8 +168,8 @@ func (r *productsRepository) FindOneProduct(productId string) (*products.Product
 }
 
 
-func (r *productsRepository) FindProduct(req *products.ProductFilter) ([]*products.Products, int) {
-	builder := productsPatterns.FindProductBuilder(r.db, req)
+func (r *productsRepository) FindProduct(ctx context.Context, req *products.ProductFilter) ([]*products.Products, int) {
+	builder := productsPatterns.FindProductBuilder(ctx, r.db, req)
 	engineer := productsPatterns.FindProductEngineer(builder)
 
 	result := engineer.FindProduct().Result()
@@ -112,18 +177,48 @@ func (r *productsRepository) FindProduct(req *products.ProductFilter) ([]*produc
 
 	engineer.FindProduct().PrintQuery()
 
+	r.cdnImages(result...)
 	return result, count
 }
 
+// FindProductByIds read the products of req.Ids in one query, ids which are not found or filtered out are skipped
+func (r *productsRepository) FindProductByIds(ctx context.Context, req *products.ProductFilter) []*products.Products {
+	builder := productsPatterns.FindProductBuilder(ctx, r.db, req)
+	engineer := productsPatterns.FindProductEngineer(builder)
 
-func (r *productsRepository) InsertProduct(req *products.Products) (*products.Products, error) {
+	result := engineer.FindProduct().Result()
+	r.cdnImages(result...)
+	return result
+}
+
+// cdnImages rewrite the urls of the images saved before the CDN was set
+func (r *productsRepository) cdnImages(productsData ...*products.Products) {
+	for _, product := range productsData {
+		if product == nil {
+			continue
+		}
+		for _, image := range product.Images {
+			image.Url = r.fileUsecase.CdnUrl(image.Url)
+		}
+	}
+}
+
+func (r *productsRepository) FindFacet(ctx context.Context, req *products.ProductFilter) []*products.Facet {
+	builder := productsPatterns.FindProductBuilder(ctx, r.db, req)
+	engineer := productsPatterns.FindProductEngineer(builder)
+
+	return engineer.FacetProduct().Facets()
+}
+
+
+func (r *productsRepository) InsertProduct(ctx context.Context, req *products.Products) (*products.Products, error) {
 	builder := productsPatterns.InsertProductBuilder(r.db, req)
-	productId, err := productsPatterns.InsertProductEngineer(builder).InsertProduct()
+	productId, err := productsPatterns.InsertProductEngineer(builder).InsertProduct(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("insert product failed: %v", err)
 	}
 
-	product, err := r.FindOneProduct(productId)
+	product, err := r.FindOneProduct(ctx, productId)
 	if err != nil {
 		return nil, fmt.Errorf("find product failed: %v", err)
 	}
@@ -132,15 +227,15 @@ func (r *productsRepository) InsertProduct(req *products.Products) (*products.Pr
 	
 }
 
-func (r *productsRepository) UpdateProduct(req *products.Products) (*products.Products, error) {
+func (r *productsRepository) UpdateProduct(ctx context.Context, req *products.Products) (*products.Products, error) {
 	builder := productsPatterns.UpdateProductBuilder(r.db, req, r.fileUsecase, r.cfg)
 	engineer := productsPatterns.UpdateProductEngineer(builder)
 	
-	if err := engineer.UpdateProduct(); err != nil {
+	if err := engineer.UpdateProduct(ctx); err != nil {
 		return nil, err
 	}
 
-	product, err := r.FindOneProduct(req.Id)
+	product, err := r.FindOneProduct(ctx, req.Id)
 	if err != nil {
 		return nil,  err
 	}
@@ -148,8 +243,8 @@ func (r *productsRepository) UpdateProduct(req *products.Products) (*products.Pr
 
 }
 
-func (r *productsRepository) DeleteProduct(productId string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second * 15) // Timeout of 15 seconds
+func (r *productsRepository) DeleteProduct(ctx context.Context, productId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
 	defer cancel()
 	query := `DELETE FROM "products" WHERE "id" = $1;`
 
@@ -160,3 +255,667 @@ func (r *productsRepository) DeleteProduct(productId string) error {
 	return nil
 }
 
+// similarCandidates is the nearest files read for each product of a search by image, a product has several
+// images and some files are of other stores or are not product images
+const similarCandidates = 20
+
+// FindSimilarProduct find products which have an image close to the embedding, use the files metadata saved on upload
+func (r *productsRepository) FindSimilarProduct(ctx context.Context, storeId, embedding string, limit int) ([]*products.SimilarProduct, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			(
+				SELECT
+					to_jsonb("pt")
+				FROM (
+					SELECT
+						"p"."id",
+						"p"."title",
+						"p"."description",
+						minor_to_major("p"."price_minor", "p"."currency") AS "price",
+						"p"."currency",
+						"p"."stock",
+						"p"."created_at",
+						"p"."updated_at",
+						(
+							SELECT
+								COALESCE(array_to_json(array_agg("it")), '[]'::json)
+							FROM (
+								SELECT
+									"i"."id",
+									"i"."filename",
+									"i"."url",
+									"i"."position",
+									"i"."is_primary"
+								FROM "images" "i"
+								WHERE "i"."product_id" = "p"."id"
+								ORDER BY "i"."is_primary" DESC, "i"."position" ASC
+							) AS "it"
+						) AS "images"
+				) AS "pt"
+			) AS "product",
+			"s"."distance"
+		FROM (
+			SELECT
+				"i"."product_id",
+				MIN("n"."distance") AS "distance"
+			FROM (
+				SELECT
+					"f"."url",
+					"f"."embedding" <-> $1::vector AS "distance"
+				FROM "files" "f"
+				WHERE "f"."embedding" IS NOT NULL
+				ORDER BY "f"."embedding" <-> $1::vector
+				LIMIT $4
+			) AS "n"
+				JOIN "images" "i" ON "i"."url" = "n"."url"
+			GROUP BY "i"."product_id"
+		) AS "s"
+			JOIN "products" "p" ON "p"."id" = "s"."product_id"
+		WHERE COALESCE("p"."store_id", '') = $3
+		AND "p"."status" = 'published'
+		ORDER BY "s"."distance" ASC
+		LIMIT $2
+	) AS "t";`
+
+	// <-> คือ euclidean distance ของ pgvector, embedding เป็น bit ของ perceptual hash
+	// ดังนั้น distance = sqrt(จำนวน bit ที่ต่างกัน)
+	// file ที่ใกล้ที่สุดถูกหาก่อนด้วย ORDER BY ... LIMIT เพื่อให้ใช้ hnsw index ได้ แล้วค่อยรวมเป็น product
+
+	bytes := make([]byte, 0)
+	similar := make([]*products.SimilarProduct, 0)
+	if err := r.db.GetContext(ctx, &bytes, query, embedding, limit, storeId, limit*similarCandidates); err != nil {
+		return nil, fmt.Errorf("find similar products failed: %v", err)
+	}
+	if err := json.Unmarshal(bytes, &similar); err != nil {
+		return nil, fmt.Errorf("unmarshal similar products failed: %v", err)
+	}
+	for _, s := range similar {
+		r.cdnImages(s.Product)
+	}
+	return similar, nil
+}
+
+// FindAvailability only read products and product_prices, no join with categories and images
+func (r *productsRepository) FindAvailability(ctx context.Context, storeId, productId string) (*products.Availability, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT
+			"p"."id" AS "product_id",
+			"p"."stock",
+			minor_to_major("p"."price_minor", "p"."currency") AS "price",
+			"p"."currency",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("prt")), '[]'::json)
+				FROM (
+					SELECT
+						"pr"."currency",
+						minor_to_major("pr"."price_minor", "pr"."currency") AS "price"
+					FROM "product_prices" "pr"
+					WHERE "pr"."product_id" = "p"."id"
+				) AS "prt"
+			) AS "prices",
+			"p"."updated_at"
+		FROM "products" "p"
+		WHERE "p"."id" = $1
+		AND COALESCE("p"."store_id", '') = $2
+		AND "p"."status" = 'published'
+		LIMIT 1
+	) AS "t";`
+
+	availabilityBytes := make([]byte, 0)
+	availability := &struct {
+		ProductId string                   `json:"product_id"`
+		Stock     int                      `json:"stock"`
+		Price     float64                  `json:"price"`
+		Currency  string                   `json:"currency"`
+		Prices    []*products.ProductPrice `json:"prices"`
+		UpdatedAt string                   `json:"updated_at"`
+	}{}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if err := r.db.GetContext(ctx, &availabilityBytes, query, productId, storeId); err != nil {
+		return nil, fmt.Errorf("get product availability failed: %v", err)
+	}
+	if err := json.Unmarshal(availabilityBytes, availability); err != nil {
+		return nil, fmt.Errorf("unmarshal product availability failed: %v", err)
+	}
+
+	return &products.Availability{
+		ProductId: availability.ProductId,
+		Stock:     availability.Stock,
+		Price:     availability.Price,
+		Currency:  availability.Currency,
+		Prices:    availability.Prices,
+		UpdatedAt: availability.UpdatedAt,
+	}, nil
+}
+
+// UpdateProductPrices replace every per currency price of the product
+func (r *productsRepository) UpdateProductPrices(ctx context.Context, productId string, req []*products.ProductPrice) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "product_prices" WHERE "product_id" = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete product prices failed: %v", err)
+	}
+
+	// ให้ ETag/Last-Modified ของ product เปลี่ยนด้วย
+	if _, err := tx.ExecContext(ctx, `UPDATE "products" SET "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update product updated at failed: %v", err)
+	}
+
+	if len(req) > 0 {
+		query := `
+		INSERT INTO "product_prices" (
+			"product_id",
+			"currency",
+			"price_minor"
+		)
+		VALUES`
+
+		valueStack := make([]any, 0)
+		var index int
+		for i := range req {
+			valueStack = append(valueStack, productId, req[i].Currency, req[i].Price)
+
+			if i != len(req)-1 {
+				query += fmt.Sprintf(`
+			($%d, $%d, major_to_minor($%d, $%d)),`, index+1, index+2, index+3, index+2)
+			} else {
+				query += fmt.Sprintf(`
+			($%d, $%d, major_to_minor($%d, $%d));`, index+1, index+2, index+3, index+2)
+			}
+			index += 3
+		}
+
+		if _, err := tx.ExecContext(ctx, query, valueStack...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert product prices failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+// UpdateProductRegions replace every region of the product, no regions means the product ships everywhere
+func (r *productsRepository) UpdateProductRegions(ctx context.Context, productId string, req []*products.ProductRegion) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "product_regions" WHERE "product_id" = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete product regions failed: %v", err)
+	}
+
+	// ให้ ETag/Last-Modified ของ product เปลี่ยนด้วย
+	if _, err := tx.ExecContext(ctx, `UPDATE "products" SET "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update product updated at failed: %v", err)
+	}
+
+	if len(req) > 0 {
+		query := `
+		INSERT INTO "product_regions" (
+			"product_id",
+			"country",
+			"state"
+		)
+		VALUES`
+
+		valueStack := make([]any, 0)
+		var index int
+		for i := range req {
+			valueStack = append(valueStack, productId, req[i].Country, req[i].State)
+
+			if i != len(req)-1 {
+				query += fmt.Sprintf(`
+			($%d, $%d, NULLIF($%d, '')),`, index+1, index+2, index+3)
+			} else {
+				query += fmt.Sprintf(`
+			($%d, $%d, NULLIF($%d, ''));`, index+1, index+2, index+3)
+			}
+			index += 3
+		}
+
+		if _, err := tx.ExecContext(ctx, query, valueStack...); err != nil {
+			tx.Rollback()
+			switch err.Error() {
+			case "ERROR: insert or update on table \"product_regions\" violates foreign key constraint \"product_regions_product_id_fkey\" (SQLSTATE 23503)":
+				return fmt.Errorf("product not found")
+			case "ERROR: duplicate key value violates unique constraint \"product_regions_product_id_country_state_idx\" (SQLSTATE 23505)":
+				return fmt.Errorf("region is duplicated")
+			}
+			return fmt.Errorf("insert product regions failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+// UpdateProductAttributes replace every attribute of the product
+func (r *productsRepository) UpdateProductAttributes(ctx context.Context, productId string, req map[string]string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "product_attributes" WHERE "product_id" = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete product attributes failed: %v", err)
+	}
+
+	// ให้ ETag/Last-Modified ของ product เปลี่ยนด้วย
+	if _, err := tx.ExecContext(ctx, `UPDATE "products" SET "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update product updated at failed: %v", err)
+	}
+
+	if len(req) > 0 {
+		query := `
+		INSERT INTO "product_attributes" (
+			"product_id",
+			"key",
+			"value"
+		)
+		VALUES`
+
+		valueStack := make([]any, 0)
+		var index int
+		for key, value := range req {
+			valueStack = append(valueStack, productId, key, value)
+
+			query += fmt.Sprintf(`
+			($%d, $%d, $%d),`, index+1, index+2, index+3)
+			index += 3
+		}
+		query = strings.TrimSuffix(query, ",") + ";"
+
+		if _, err := tx.ExecContext(ctx, query, valueStack...); err != nil {
+			tx.Rollback()
+			switch err.Error() {
+			case "ERROR: insert or update on table \"product_attributes\" violates foreign key constraint \"product_attributes_product_id_fkey\" (SQLSTATE 23503)":
+				return fmt.Errorf("product not found")
+			}
+			return fmt.Errorf("insert product attributes failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+// UpdateImageOrder set the position of each image to its index in imageIds, which must have every image of the product
+func (r *productsRepository) UpdateImageOrder(ctx context.Context, productId string, imageIds []string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	// lock the product so a concurrent update of its images wait
+	result, err := tx.ExecContext(ctx, `UPDATE "products" SET "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1;`, productId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update product updated at failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("product not found")
+	}
+
+	current := make([]string, 0)
+	if err := tx.SelectContext(ctx, &current, `SELECT "id" FROM "images" WHERE "product_id" = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("find product images failed: %v", err)
+	}
+	given := make(map[string]bool)
+	for _, id := range imageIds {
+		given[id] = true
+	}
+	if len(given) != len(imageIds) || len(current) != len(imageIds) {
+		tx.Rollback()
+		return fmt.Errorf("image ids must list every image of the product once")
+	}
+	for _, id := range current {
+		if !given[id] {
+			tx.Rollback()
+			return fmt.Errorf("image ids must list every image of the product once")
+		}
+	}
+
+	// position = ลำดับใน array เริ่มที่ 0
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "images" "i" SET
+		"position" = "o"."position"
+	FROM (
+		SELECT
+			"id",
+			("ord" - 1)::INT AS "position"
+		FROM unnest($2::VARCHAR[]) WITH ORDINALITY AS "t" ("id", "ord")
+	) AS "o"
+	WHERE "i"."id"::VARCHAR = "o"."id"
+	AND "i"."product_id" = $1;`, productId, imageIds); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update image order failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+// UpdatePrimaryImage make the image the cover of the product, the previous cover keep its position
+func (r *productsRepository) UpdatePrimaryImage(ctx context.Context, productId, imageId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE "products" SET "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1;`, productId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update product updated at failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("product not found")
+	}
+
+	// ปิด cover เดิมก่อน unique index อนุญาต primary ได้รูปเดียวต่อ product
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "images" SET
+		"is_primary" = false
+	WHERE "product_id" = $1
+	AND "is_primary";`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("clear primary image failed: %v", err)
+	}
+
+	result, err = tx.ExecContext(ctx, `
+	UPDATE "images" SET
+		"is_primary" = true
+	WHERE "product_id" = $1
+	AND "id"::VARCHAR = $2;`, productId, imageId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("set primary image failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("image not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+// InsertImage add the image after the other images of the product, it become the primary image when the product has none
+func (r *productsRepository) InsertImage(ctx context.Context, productId string, image *entities.Image) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	// lock the product so concurrent uploads do not get the same position
+	result, err := tx.ExecContext(ctx, `UPDATE "products" SET "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1;`, productId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update product updated at failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("product not found")
+	}
+
+	query := `
+	INSERT INTO "images" (
+		"filename",
+		"url",
+		"product_id",
+		"position",
+		"is_primary"
+	)
+	SELECT
+		$1,
+		$2,
+		$3,
+		COALESCE(MAX("i"."position") + 1, 0),
+		NOT COALESCE(bool_or("i"."is_primary"), false)
+	FROM "images" "i"
+	WHERE "i"."product_id" = $3
+		RETURNING "id", "position", "is_primary";`
+
+	if err := tx.QueryRowxContext(ctx, query, image.FileName, image.Url, productId).Scan(&image.Id, &image.Position, &image.IsPrimary); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert image failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+// FindTopProductId return the published products which sold the most qty in the last 30 days,
+// newer products come first when nothing was sold
+func (r *productsRepository) FindTopProductId(ctx context.Context, limit int) ([]string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"p"."id"
+	FROM "products" "p"
+		LEFT JOIN (
+			SELECT
+				"po"."product"->>'id' AS "product_id",
+				SUM("po"."qty") AS "sold"
+			FROM "products_orders" "po"
+				JOIN "orders" "o" ON "o"."id" = "po"."order_id"
+			WHERE "o"."status" <> 'canceled'
+			AND "o"."created_at" > now() - interval '30 days'
+			GROUP BY "po"."product"->>'id'
+		) AS "s" ON "s"."product_id" = "p"."id"
+	WHERE "p"."status" = 'published'
+	ORDER BY COALESCE("s"."sold", 0) DESC, "p"."created_at" DESC
+	LIMIT $1;`
+
+	productIds := make([]string, 0)
+	if err := r.db.SelectContext(ctx, &productIds, query, limit); err != nil {
+		return nil, fmt.Errorf("find top products failed: %v", err)
+	}
+	return productIds, nil
+}
+
+// UpdateWindowOpen save whether each product with an availability window is open now and return the ones which changed,
+// rows are locked so only one server announce a change
+func (r *productsRepository) UpdateWindowOpen(ctx context.Context) ([]*products.WindowEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	UPDATE "products" "p" SET
+		"window_open" = "w"."open"
+	FROM (
+		SELECT
+			"id",
+			"window_open" AS "was_open",
+			("available_from" IS NULL OR "available_from" <= now() AT TIME ZONE 'UTC')
+			AND ("available_until" IS NULL OR "available_until" > now() AT TIME ZONE 'UTC') AS "open"
+		FROM "products"
+		WHERE "available_from" IS NOT NULL
+		OR "available_until" IS NOT NULL
+		FOR UPDATE SKIP LOCKED
+	) AS "w"
+	WHERE "p"."id" = "w"."id"
+	AND "w"."was_open" IS DISTINCT FROM "w"."open"
+		RETURNING
+			"p"."id",
+			"p"."title",
+			COALESCE("p"."store_id", '') AS "store_id",
+			"w"."open",
+			to_char("p"."available_from", 'YYYY-MM-DD"T"HH24:MI:SS') AS "available_from",
+			to_char("p"."available_until", 'YYYY-MM-DD"T"HH24:MI:SS') AS "available_until",
+			"w"."was_open";`
+
+	rows := make([]*struct {
+		products.WindowEvent
+		WasOpen *bool `db:"was_open"`
+	}, 0)
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("update product windows failed: %v", err)
+	}
+
+	// the first run of a product which is not open yet is not a change
+	changed := make([]*products.WindowEvent, 0)
+	for _, row := range rows {
+		if row.WasOpen == nil && !row.Open {
+			continue
+		}
+		event := row.WindowEvent
+		changed = append(changed, &event)
+	}
+	return changed, nil
+}
+
+// FindBulkProduct return the price and stock of the products, ids which are not found are left out
+func (r *productsRepository) FindBulkProduct(ctx context.Context, productIds []string) (map[string]*products.BulkProduct, error) {
+	result := make(map[string]*products.BulkProduct)
+	if len(productIds) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(`
+	SELECT
+		"id",
+		COALESCE("store_id", '') AS "store_id",
+		COALESCE("seller_id", '') AS "seller_id",
+		minor_to_major("price_minor", "currency") AS "price",
+		"stock",
+		"version"
+	FROM "products"
+	WHERE "id" IN (?);`, productIds)
+	if err != nil {
+		return nil, fmt.Errorf("build find bulk products query failed: %v", err)
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows := make([]*products.BulkProduct, 0)
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("find bulk products failed: %v", err)
+	}
+	for _, row := range rows {
+		result[row.Id] = row
+	}
+	return result, nil
+}
+
+// UpdateBulkProduct change the price and stock of every item in one transaction, a nil field is kept.
+// The version is increased so a PATCH with the version read before is rejected, the stock changes are recorded
+// in the ledger as adjustments of actorId
+func (r *productsRepository) UpdateBulkProduct(ctx context.Context, actorId string, req []*products.BulkItem) (map[string]*products.BulkProduct, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	UPDATE "products" SET
+		"price_minor" = CASE WHEN $2::FLOAT IS NULL THEN "price_minor" ELSE major_to_minor($2, "currency") END,
+		"stock" = COALESCE($3, "stock"),
+		"version" = "version" + 1
+	WHERE "id" = $1
+	RETURNING
+		"id",
+		COALESCE("store_id", '') AS "store_id",
+		COALESCE("seller_id", '') AS "seller_id",
+		minor_to_major("price_minor", "currency") AS "price",
+		"stock",
+		"version";`
+
+	// stock ที่ตั้งใหม่ต้องไม่ชนกับการจองหรือตัด stock ที่ทำอยู่บน instance อื่น
+	stockIds := make([]string, 0, len(req))
+	for _, item := range req {
+		if item.Stock != nil {
+			stockIds = append(stockIds, item.Id)
+		}
+	}
+	if err := locks.LockStock(ctx, tx, stockIds...); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := inventoryRepositories.MarkMovement(ctx, tx, &inventory.MovementContext{
+		Kind:    inventory.MovementAdjustment,
+		Reason:  "bulk update",
+		ActorId: actorId,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	result := make(map[string]*products.BulkProduct)
+	for _, item := range req {
+		product := new(products.BulkProduct)
+		if err := tx.GetContext(ctx, product, query, item.Id, item.Price, item.Stock); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("update product %s failed: %v", item.Id, err)
+		}
+		result[product.Id] = product
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("commit transaction failed: %v", err)
+	}
+	return result, nil
+}
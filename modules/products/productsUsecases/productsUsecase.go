@@ -1,72 +1,503 @@
 package productsUsecases
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
 	"math"
+	"strings"
 
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/currencies/currenciesUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/products"
 	"github.com/NatthawutSK/ri-shop/modules/products/productsRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/NatthawutSK/ri-shop/pkg/imagehash"
 )
 
 type IProductsUsecase interface{
-	FindOneProduct(productId string) (*products.Products, error)
-	FindProduct(req *products.ProductFilter) *entities.PaginateRes
-	AddProduct(req *products.Products) (*products.Products, error)
-	UpdateProduct(req *products.Products) (*products.Products, error)
-	DeleteProduct(productId string) error
+	FindOneProduct(ctx context.Context, productId string) (*products.Products, error)
+	FindProduct(ctx context.Context, req *products.ProductFilter) *entities.PaginateRes
+	FindProductByIds(ctx context.Context, req *products.ProductFilter) *entities.PaginateRes
+	AddProduct(ctx context.Context, req *products.Products) (*products.Products, error)
+	UpdateProduct(ctx context.Context, req *products.Products) (*products.ProductUpdateRes, error)
+	DeleteProduct(ctx context.Context, productId string) error
+	SearchByImage(ctx context.Context, req *products.ImageSearchReq) ([]*products.SimilarProduct, error)
+	UpdateProductPrices(ctx context.Context, productId string, req []*products.ProductPrice) (*products.Products, error)
+	UpdateProductRegions(ctx context.Context, productId string, req []*products.ProductRegion) (*products.Products, error)
+	UpdateProductAttributes(ctx context.Context, productId string, req map[string]string) (*products.Products, error)
+	UpdateImageOrder(ctx context.Context, productId string, req *products.ImageOrderReq) (*products.Products, error)
+	UpdatePrimaryImage(ctx context.Context, productId, imageId string) (*products.Products, error)
+	AddProductImage(ctx context.Context, productId string, image *entities.Image) (*products.Products, error)
+	ConvertCurrency(ctx context.Context, productsData []*products.Products, currency string) error
+	FindAvailability(ctx context.Context, storeId, productId, currency string) (*products.Availability, error)
+	WarmCache(ctx context.Context, limit int) (int, error)
+	UpdateWindowOpen(ctx context.Context) (int, error)
+	BulkUpdateProduct(ctx context.Context, req *products.BulkUpdateReq) (*products.BulkUpdateRes, error)
 }
 
 type productsUsecase struct {
 	productsRepository productsRepositories.IProductsRepository
+	currenciesUsecase  currenciesUsecases.ICurrenciesUsecase
+	productCache       *cache.Cache[*products.Products]
 }
 
-func ProductsUsecase(productsRepository productsRepositories.IProductsRepository) IProductsUsecase {
+func ProductsUsecase(productsRepository productsRepositories.IProductsRepository, currenciesUsecase currenciesUsecases.ICurrenciesUsecase, productCache *cache.Cache[*products.Products]) IProductsUsecase {
 	return &productsUsecase{
 		productsRepository: productsRepository,
+		currenciesUsecase:  currenciesUsecase,
+		productCache:       productCache,
 	}
 }
 
-func (u *productsUsecase) FindOneProduct(productId string) (*products.Products, error) {
-	product, err := u.productsRepository.FindOneProduct(productId)
+// FindOneProduct return a copy of the cached product, callers convert the currency in place.
+// Stock changed by orders is seen after the cache ttl
+func (u *productsUsecase) FindOneProduct(ctx context.Context, productId string) (*products.Products, error) {
+	if cached, ok := u.productCache.Get(productId); ok {
+		product := *cached
+		return &product, nil
+	}
+
+	product, err := u.productsRepository.FindOneProduct(ctx, productId)
 	if err != nil {
 		return nil, err
 	}
+	cached := *product
+	u.productCache.Set(productId, &cached)
 	return product, nil
 }
 
+// WarmCache load the best selling products into the cache, called on startup before the server is ready
+func (u *productsUsecase) WarmCache(ctx context.Context, limit int) (int, error) {
+	productIds, err := u.productsRepository.FindTopProductId(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	warmed := 0
+	for _, productId := range productIds {
+		if _, err := u.FindOneProduct(ctx, productId); err != nil {
+			log.Printf("warm product %s failed: %v\n", productId, err)
+			continue
+		}
+		warmed++
+	}
+	return warmed, nil
+}
+
+
+// UpdateWindowOpen announce the products which entered or left their availability window since the last run
+func (u *productsUsecase) UpdateWindowOpen(ctx context.Context) (int, error) {
+	changed, err := u.productsRepository.UpdateWindowOpen(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range changed {
+		u.productCache.Delete(event.ProductId)
+		if event.Open {
+			events.Publish(products.EventProductAvailable, event)
+		} else {
+			events.Publish(products.EventProductUnavailable, event)
+		}
+	}
+	return len(changed), nil
+}
 
-func (u *productsUsecase) FindProduct(req *products.ProductFilter) *entities.PaginateRes {
-	products, count := u.productsRepository.FindProduct(req)
+func (u *productsUsecase) FindProduct(ctx context.Context, req *products.ProductFilter) *entities.PaginateRes {
+	productsData, count := u.productsRepository.FindProduct(ctx, req)
+	if err := u.ConvertCurrency(ctx, productsData, req.Currency); err != nil {
+		log.Printf("convert products currency failed: %v\n", err)
+	}
+	if req.SearchId != "" {
+		events.Publish(products.EventProductSearched, &products.SearchEvent{
+			Id:      req.SearchId,
+			StoreId: req.StoreId,
+			Query:   req.Search,
+			Results: count,
+		})
+	}
 	return &entities.PaginateRes{
-		Data: products,
+		Data: productsData,
 		TotalItem: count,
 		Page: req.Page,
 		Limit: req.Limit,
 		TotalPage: int(math.Ceil(float64(count) / float64(req.Limit))),
-
+		Facets: u.productsRepository.FindFacet(ctx, req),
 	}
 	
 }
 
-func (u *productsUsecase) AddProduct(req *products.Products) (*products.Products, error) {
-	product, err := u.productsRepository.InsertProduct(req)
+// FindProductByIds return the products in the order of req.Ids as one page, there is no count or facets
+func (u *productsUsecase) FindProductByIds(ctx context.Context, req *products.ProductFilter) *entities.PaginateRes {
+	req.Page = 1
+	req.Limit = len(req.Ids)
+
+	found := make(map[string]*products.Products)
+	for _, product := range u.productsRepository.FindProductByIds(ctx, req) {
+		found[product.Id] = product
+	}
+	productsData := make([]*products.Products, 0, len(found))
+	for _, id := range req.Ids {
+		if product, ok := found[id]; ok {
+			productsData = append(productsData, product)
+		}
+	}
+
+	if err := u.ConvertCurrency(ctx, productsData, req.Currency); err != nil {
+		log.Printf("convert products currency failed: %v\n", err)
+	}
+	return &entities.PaginateRes{
+		Data:      productsData,
+		TotalItem: len(productsData),
+		Page:      1,
+		Limit:     req.Limit,
+		TotalPage: 1,
+	}
+}
+
+func (u *productsUsecase) AddProduct(ctx context.Context, req *products.Products) (*products.Products, error) {
+	product, err := u.productsRepository.InsertProduct(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
-func (u *productsUsecase) UpdateProduct(req *products.Products) (*products.Products, error) {
-	product, err := u.productsRepository.UpdateProduct(req)
+func (u *productsUsecase) UpdateProduct(ctx context.Context, req *products.Products) (*products.ProductUpdateRes, error) {
+	// keep the old version to build the diff
+	before, err := u.productsRepository.FindOneProduct(ctx, req.Id)
 	if err != nil {
 		return nil, err
 	}
-	return product, nil
+	if before.Version != req.Version {
+		return nil, &products.VersionConflictError{Version: req.Version}
+	}
+
+	product, err := u.productsRepository.UpdateProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	u.productCache.Delete(req.Id)
+	if before.Price != product.Price || before.Stock != product.Stock {
+		events.Publish(products.EventPriceStockChanged, &products.PriceStockEvent{
+			ProductId: product.Id,
+			FromPrice: before.Price,
+			Price:     product.Price,
+			FromStock: before.Stock,
+			Stock:     product.Stock,
+		})
+	}
+
+	return &products.ProductUpdateRes{
+		Product: product,
+		Diff:    products.DiffProduct(before, product),
+		Before:  before,
+	}, nil
 }
 
-func (u *productsUsecase) DeleteProduct(productId string) error {
-	if err := u.productsRepository.DeleteProduct(productId); err != nil {
+func (u *productsUsecase) DeleteProduct(ctx context.Context, productId string) error {
+	if err := u.productsRepository.DeleteProduct(ctx, productId); err != nil {
 		return err
 	}
+	u.productCache.Delete(productId)
+	return nil
+}
+
+func (u *productsUsecase) SearchByImage(ctx context.Context, req *products.ImageSearchReq) ([]*products.SimilarProduct, error) {
+	hash, err := imagehash.DHash(bytes.NewReader(req.Image))
+	if err != nil {
+		return nil, err
+	}
+
+	similar, err := u.productsRepository.FindSimilarProduct(ctx, req.StoreId, imagehash.ToVector(hash), req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return similar, nil
+}
+
+func (u *productsUsecase) UpdateProductPrices(ctx context.Context, productId string, req []*products.ProductPrice) (*products.Products, error) {
+	for _, price := range req {
+		currency, err := u.currenciesUsecase.FindOneCurrency(ctx, price.Currency)
+		if err != nil {
+			return nil, err
+		}
+		price.Currency = currency.Code
+	}
+
+	if err := u.productsRepository.UpdateProductPrices(ctx, productId, req); err != nil {
+		return nil, err
+	}
+	u.productCache.Delete(productId)
+
+	product, err := u.productsRepository.FindOneProduct(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (u *productsUsecase) UpdateProductRegions(ctx context.Context, productId string, req []*products.ProductRegion) (*products.Products, error) {
+	for _, region := range req {
+		region.Country = strings.ToUpper(strings.TrimSpace(region.Country))
+		region.State = strings.TrimSpace(region.State)
+		if !addresses.SupportedCountry(region.Country) {
+			return nil, fmt.Errorf("country %s is not supported", region.Country)
+		}
+	}
+
+	if err := u.productsRepository.UpdateProductRegions(ctx, productId, req); err != nil {
+		return nil, err
+	}
+	u.productCache.Delete(productId)
+
+	product, err := u.productsRepository.FindOneProduct(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// UpdateProductAttributes replace the specs of the product, keys are lowercase and values are kept as written
+func (u *productsUsecase) UpdateProductAttributes(ctx context.Context, productId string, req map[string]string) (*products.Products, error) {
+	if len(req) > products.MaxAttributes {
+		return nil, fmt.Errorf("a product has at most %d attributes", products.MaxAttributes)
+	}
+
+	attributes := make(map[string]string)
+	for key, value := range req {
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !products.AttributeKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("attribute key %s is invalid", key)
+		}
+		if value == "" || len(value) > 255 {
+			return nil, fmt.Errorf("attribute %s must have a value of 1-255 characters", key)
+		}
+		if _, ok := attributes[key]; ok {
+			return nil, fmt.Errorf("attribute %s is duplicated", key)
+		}
+		attributes[key] = value
+	}
+
+	if err := u.productsRepository.UpdateProductAttributes(ctx, productId, attributes); err != nil {
+		return nil, err
+	}
+	u.productCache.Delete(productId)
+
+	product, err := u.productsRepository.FindOneProduct(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (u *productsUsecase) UpdateImageOrder(ctx context.Context, productId string, req *products.ImageOrderReq) (*products.Products, error) {
+	if len(req.ImageIds) == 0 {
+		return nil, fmt.Errorf("image ids are empty")
+	}
+	if err := u.productsRepository.UpdateImageOrder(ctx, productId, req.ImageIds); err != nil {
+		return nil, err
+	}
+	u.productCache.Delete(productId)
+
+	return u.productsRepository.FindOneProduct(ctx, productId)
+}
+
+func (u *productsUsecase) UpdatePrimaryImage(ctx context.Context, productId, imageId string) (*products.Products, error) {
+	if err := u.productsRepository.UpdatePrimaryImage(ctx, productId, imageId); err != nil {
+		return nil, err
+	}
+	u.productCache.Delete(productId)
+
+	return u.productsRepository.FindOneProduct(ctx, productId)
+}
+
+// AddProductImage add an image uploaded with a signed policy to the end of the product images
+func (u *productsUsecase) AddProductImage(ctx context.Context, productId string, image *entities.Image) (*products.Products, error) {
+	if err := u.productsRepository.InsertImage(ctx, productId, image); err != nil {
+		return nil, err
+	}
+	u.productCache.Delete(productId)
+
+	return u.productsRepository.FindOneProduct(ctx, productId)
+}
+
+func (u *productsUsecase) FindAvailability(ctx context.Context, storeId, productId, currency string) (*products.Availability, error) {
+	availability, err := u.productsRepository.FindAvailability(ctx, storeId, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	// ใช้ ConvertCurrency ตัวเดียวกับหน้า product เพื่อให้ราคาตรงกัน
+	product := &products.Products{
+		Price:    availability.Price,
+		Currency: availability.Currency,
+		Prices:   availability.Prices,
+	}
+	if err := u.ConvertCurrency(ctx, []*products.Products{product}, currency); err != nil {
+		return nil, err
+	}
+	availability.Price = product.Price
+	availability.Currency = product.Currency
+	availability.InStock = availability.Stock > 0
+
+	// version is computed from the response itself so it also changes with exchange rates and per currency prices
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%d|%v|%s|%s", availability.ProductId, availability.Stock, availability.Price, availability.Currency, availability.UpdatedAt)
+	availability.Version = fmt.Sprintf("%x", h.Sum64())
+
+	return availability, nil
+}
+
+// ConvertCurrency set price of products in the currency, a per currency price is used when the product has one
+// otherwise the base price is converted with the exchange rate
+func (u *productsUsecase) ConvertCurrency(ctx context.Context, productsData []*products.Products, currency string) error {
+	if currency == "" {
+		return nil
+	}
+	currency = strings.ToUpper(currency)
+
+	for _, product := range productsData {
+		if product.Currency == currency {
+			continue
+		}
+
+		overridden := false
+		for _, price := range product.Prices {
+			if price.Currency == currency {
+				product.Price = price.Price
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			price, err := u.currenciesUsecase.Convert(ctx, product.Price, product.Currency, currency)
+			if err != nil {
+				return err
+			}
+			product.Price = price
+		}
+		product.Currency = currency
+	}
+	return nil
+}
+
+// validateBulkItem check one change of a bulk update against the product it changes, seen is the ids of
+// the items before it
+func validateBulkItem(req *products.BulkUpdateReq, item *products.BulkItem, product *products.BulkProduct, seen map[string]bool) error {
+	switch {
+	case item.Id == "":
+		return fmt.Errorf("id is required")
+	case seen[item.Id]:
+		return fmt.Errorf("product is duplicated")
+	case item.Price == nil && item.Stock == nil:
+		return fmt.Errorf("price or stock is required")
+	case item.Price != nil && *item.Price <= 0:
+		return fmt.Errorf("price must be greater than 0")
+	case item.Stock != nil && *item.Stock < 0:
+		return fmt.Errorf("stock must not be negative")
+	case product == nil || product.StoreId != req.StoreId:
+		return fmt.Errorf("product not found")
+	case req.SellerId != "" && product.SellerId != req.SellerId:
+		return fmt.Errorf("no permission to change this product")
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// BulkUpdateProduct change the price and stock of many products in one transaction. An invalid item is
+// reported and skipped, with AllOrNothing nothing is changed when any item is invalid
+func (u *productsUsecase) BulkUpdateProduct(ctx context.Context, req *products.BulkUpdateReq) (*products.BulkUpdateRes, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items are empty")
+	}
+	if len(req.Items) > products.MaxBulkItems {
+		return nil, fmt.Errorf("a bulk update has at most %d items", products.MaxBulkItems)
+	}
+
+	productIds := make([]string, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item == nil {
+			return nil, fmt.Errorf("item is invalid")
+		}
+		item.Id = strings.TrimSpace(item.Id)
+		if item.Id != "" {
+			productIds = append(productIds, item.Id)
+		}
+	}
+	current, err := u.productsRepository.FindBulkProduct(ctx, productIds)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &products.BulkUpdateRes{
+		Items: make([]*products.BulkItemResult, 0, len(req.Items)),
+	}
+	changes := make([]*products.BulkItem, 0)
+	seen := make(map[string]bool)
+	for _, item := range req.Items {
+		result := &products.BulkItemResult{Id: item.Id}
+		res.Items = append(res.Items, result)
+
+		product := current[item.Id]
+		if err := validateBulkItem(req, item, product, seen); err != nil {
+			result.Status = products.BulkFailed
+			result.Error = err.Error()
+			res.Failed++
+			continue
+		}
+		seen[item.Id] = true
+
+		// ค่าเดิมไม่ต้องเขียน version จะได้ไม่เปลี่ยน
+		if (item.Price == nil || *item.Price == product.Price) && (item.Stock == nil || *item.Stock == product.Stock) {
+			result.Status = products.BulkUnchanged
+			result.Product = product
+			continue
+		}
+		result.Status = products.BulkUpdated
+		result.Before = product
+		changes = append(changes, item)
+	}
+
+	if req.AllOrNothing && res.Failed > 0 {
+		for _, result := range res.Items {
+			if result.Status == products.BulkUpdated {
+				result.Status = products.BulkSkipped
+				result.Before = nil
+			}
+		}
+		return res, nil
+	}
+	if len(changes) == 0 {
+		return res, nil
+	}
+
+	updated, err := u.productsRepository.UpdateBulkProduct(ctx, req.ActorId, changes)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range res.Items {
+		if result.Status != products.BulkUpdated {
+			continue
+		}
+		result.Product = updated[result.Id]
+		u.productCache.Delete(result.Id)
+		res.Updated++
+		if after := updated[result.Id]; after != nil {
+			events.Publish(products.EventPriceStockChanged, &products.PriceStockEvent{
+				ProductId: result.Id,
+				FromPrice: result.Before.Price,
+				Price:     after.Price,
+				FromStock: result.Before.Stock,
+				Stock:     after.Stock,
+			})
+		}
+	}
+	return res, nil
+}
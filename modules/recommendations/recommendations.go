@@ -0,0 +1,20 @@
+package recommendations
+
+import "github.com/NatthawutSK/ri-shop/modules/products"
+
+type Kind string
+
+const (
+	FrequentlyBoughtTogether Kind = "frequently_bought_together"
+)
+
+type Recommendation struct {
+	Product *products.Products `json:"product"`
+	Score   int                `json:"score"` // number of orders containing both products
+}
+
+type RecommendationFilter struct {
+	StoreId   string
+	ProductId string
+	Limit     int `query:"limit"`
+}
@@ -0,0 +1,61 @@
+package recommendationsHandlers
+
+import (
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/recommendations"
+	"github.com/NatthawutSK/ri-shop/modules/recommendations/recommendationsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type recommendationsHandlerErrCode string
+
+const (
+	findFrequentlyBoughtTogetherErr recommendationsHandlerErrCode = "recommendations-001"
+)
+
+type IRecommendationsHandler interface {
+	FindFrequentlyBoughtTogether(c *fiber.Ctx) error
+}
+
+type recommendationsHandler struct {
+	cfg                    config.IConfig
+	recommendationsUsecase recommendationsUsecases.IRecommendationsUsecase
+}
+
+func RecommendationsHandler(cfg config.IConfig, recommendationsUsecase recommendationsUsecases.IRecommendationsUsecase) IRecommendationsHandler {
+	return &recommendationsHandler{
+		cfg:                    cfg,
+		recommendationsUsecase: recommendationsUsecase,
+	}
+}
+
+func (h *recommendationsHandler) FindFrequentlyBoughtTogether(c *fiber.Ctx) error {
+	req := new(recommendations.RecommendationFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findFrequentlyBoughtTogetherErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("productId"), " ")
+	req.StoreId = c.Locals("storeId").(string)
+
+	if req.Limit < 1 || req.Limit > 20 {
+		req.Limit = 4
+	}
+
+	recommendationsData, err := h.recommendationsUsecase.FindFrequentlyBoughtTogether(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findFrequentlyBoughtTogetherErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, recommendationsData).Res()
+}
@@ -0,0 +1,153 @@
+package recommendationsRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/recommendations"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IRecommendationsRepository interface {
+	RefreshFrequentlyBoughtTogether(ctx context.Context, windowDays, minSupport int) error
+	FindFrequentlyBoughtTogether(ctx context.Context, req *recommendations.RecommendationFilter) ([]*recommendations.Recommendation, error)
+}
+
+type recommendationsRepository struct {
+	db          *sqlx.DB
+	fileUsecase filesUsecases.IFilesUsecase
+}
+
+func RecommendationsRepository(db *sqlx.DB, fileUsecase filesUsecases.IFilesUsecase) IRecommendationsRepository {
+	return &recommendationsRepository{
+		db:          db,
+		fileUsecase: fileUsecase,
+	}
+}
+
+// RefreshFrequentlyBoughtTogether rebuild product pairs bought in the same order in the last windowDays,
+// pairs seen in less than minSupport orders are dropped
+func (r *recommendationsRepository) RefreshFrequentlyBoughtTogether(ctx context.Context, windowDays, minSupport int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "recommendations" WHERE "kind" = $1;`, recommendations.FrequentlyBoughtTogether); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete recommendations failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "recommendations" (
+		"product_id",
+		"recommended_id",
+		"kind",
+		"score"
+	)
+	SELECT
+		"a"."product"->>'id',
+		"b"."product"->>'id',
+		$1,
+		COUNT(DISTINCT "a"."order_id")
+	FROM "products_orders" "a"
+		LEFT JOIN "products_orders" "b" ON "b"."order_id" = "a"."order_id"
+		LEFT JOIN "orders" "o" ON "o"."id" = "a"."order_id"
+	WHERE "a"."product"->>'id' <> "b"."product"->>'id'
+	AND "o"."status" <> 'canceled'
+	AND "o"."created_at" >= now() - make_interval(days => $2::INT)
+	AND "a"."product"->>'id' IN (SELECT "id" FROM "products")
+	AND "b"."product"->>'id' IN (SELECT "id" FROM "products")
+	GROUP BY "a"."product"->>'id', "b"."product"->>'id'
+	HAVING COUNT(DISTINCT "a"."order_id") >= $3;`
+
+	// join products_orders กับตัวเองด้วย order_id จะได้ทุกคู่ของสินค้าที่อยู่ใน order เดียวกัน
+	// product ที่ถูกลบไปแล้วจะไม่ถูกนำมาคิด
+
+	if _, err := tx.ExecContext(ctx, query, recommendations.FrequentlyBoughtTogether, windowDays, minSupport); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert recommendations failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+func (r *recommendationsRepository) FindFrequentlyBoughtTogether(ctx context.Context, req *recommendations.RecommendationFilter) ([]*recommendations.Recommendation, error) {
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			(
+				SELECT
+					to_jsonb("pt")
+				FROM (
+					SELECT
+						"p"."id",
+						"p"."title",
+						"p"."description",
+						minor_to_major("p"."price_minor", "p"."currency") AS "price",
+						"p"."currency",
+						"p"."stock",
+						"p"."created_at",
+						"p"."updated_at",
+						(
+							SELECT
+								COALESCE(array_to_json(array_agg("it")), '[]'::json)
+							FROM (
+								SELECT
+									"i"."id",
+									"i"."filename",
+									"i"."url",
+									"i"."position",
+									"i"."is_primary"
+								FROM "images" "i"
+								WHERE "i"."product_id" = "p"."id"
+								ORDER BY "i"."is_primary" DESC, "i"."position" ASC
+							) AS "it"
+						) AS "images"
+				) AS "pt"
+			) AS "product",
+			"r"."score"
+		FROM "recommendations" "r"
+			JOIN "products" "p" ON "p"."id" = "r"."recommended_id"
+		WHERE "r"."product_id" = $1
+		AND "r"."kind" = $2
+		AND "p"."status" = 'published'
+		AND COALESCE("p"."store_id", '') = $4
+		ORDER BY "r"."score" DESC, "p"."id" ASC
+		LIMIT $3
+	) AS "t";`
+
+	bytes := make([]byte, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	recommendationsData := make([]*recommendations.Recommendation, 0)
+	if err := r.db.GetContext(ctx, &bytes, query, req.ProductId, recommendations.FrequentlyBoughtTogether, req.Limit, req.StoreId); err != nil {
+		return nil, fmt.Errorf("get recommendations failed: %v", err)
+	}
+	if err := json.Unmarshal(bytes, &recommendationsData); err != nil {
+		return nil, fmt.Errorf("unmarshal recommendations failed: %v", err)
+	}
+	// images saved before the CDN was set still have the storage url
+	for _, recommendation := range recommendationsData {
+		if recommendation.Product == nil {
+			continue
+		}
+		for _, image := range recommendation.Product.Images {
+			image.Url = r.fileUsecase.CdnUrl(image.Url)
+		}
+	}
+	return recommendationsData, nil
+}
@@ -0,0 +1,45 @@
+package recommendationsUsecases
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/recommendations"
+	"github.com/NatthawutSK/ri-shop/modules/recommendations/recommendationsRepositories"
+)
+
+const (
+	// number of days of order history used to find co-purchases
+	recommendationWindowDays = 90
+	// a pair must be bought together in at least this many orders
+	recommendationMinSupport = 2
+)
+
+type IRecommendationsUsecase interface {
+	RefreshFrequentlyBoughtTogether(ctx context.Context) error
+	FindFrequentlyBoughtTogether(ctx context.Context, req *recommendations.RecommendationFilter) ([]*recommendations.Recommendation, error)
+}
+
+type recommendationsUsecase struct {
+	recommendationsRepository recommendationsRepositories.IRecommendationsRepository
+}
+
+func RecommendationsUsecase(recommendationsRepository recommendationsRepositories.IRecommendationsRepository) IRecommendationsUsecase {
+	return &recommendationsUsecase{
+		recommendationsRepository: recommendationsRepository,
+	}
+}
+
+func (u *recommendationsUsecase) RefreshFrequentlyBoughtTogether(ctx context.Context) error {
+	if err := u.recommendationsRepository.RefreshFrequentlyBoughtTogether(ctx, recommendationWindowDays, recommendationMinSupport); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *recommendationsUsecase) FindFrequentlyBoughtTogether(ctx context.Context, req *recommendations.RecommendationFilter) ([]*recommendations.Recommendation, error) {
+	recommendationsData, err := u.recommendationsRepository.FindFrequentlyBoughtTogether(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return recommendationsData, nil
+}
@@ -0,0 +1,49 @@
+package refunds
+
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+
+	CancellationPending  = "pending"
+	CancellationApproved = "approved"
+	CancellationRejected = "rejected"
+
+	// EventRefundIssued is published on pkg/events with a *Refund payload
+	EventRefundIssued = "refund.issued"
+)
+
+type Refund struct {
+	Id         string  `json:"id" db:"id"`
+	OrderId    string  `json:"order_id" db:"order_id"`
+	Amount     float64 `json:"amount" db:"amount"` // 0 on request means the whole refundable amount
+	Reason     string  `json:"reason" db:"reason"`
+	Status     string  `json:"status" db:"status"`
+	GatewayRef string  `json:"gateway_ref" db:"gateway_ref"`
+	Restock    bool    `json:"restock" db:"restocked"` // put the products of the order back to stock, full refund only
+	ActorId    string  `json:"actor_id" db:"actor_id"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+}
+
+type Cancellation struct {
+	Id         string  `json:"id" db:"id"`
+	OrderId    string  `json:"order_id" db:"order_id"`
+	UserId     string  `json:"user_id" db:"user_id"`
+	Reason     string  `json:"reason" db:"reason"`
+	Status     string  `json:"status" db:"status"`
+	ReviewedBy *string `json:"reviewed_by" db:"reviewed_by"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+	UpdatedAt  string  `json:"updated_at" db:"updated_at"`
+}
+
+type CancellationReview struct {
+	Id         string `json:"-"`
+	Status     string `json:"status"` // approved or rejected
+	ReviewedBy string `json:"-"`
+}
+
+// CancellationRes tell whether the order was canceled right away or waits for an admin
+type CancellationRes struct {
+	Canceled     bool          `json:"canceled"`
+	Cancellation *Cancellation `json:"cancellation,omitempty"`
+}
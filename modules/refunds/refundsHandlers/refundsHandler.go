@@ -0,0 +1,245 @@
+package refundsHandlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/NatthawutSK/ri-shop/modules/refunds/refundsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type refundsHandlerErrCode string
+
+const (
+	findRefundErr          refundsHandlerErrCode = "refunds-001"
+	issueRefundErr         refundsHandlerErrCode = "refunds-002"
+	findCancellationErr    refundsHandlerErrCode = "refunds-003"
+	requestCancellationErr refundsHandlerErrCode = "refunds-004"
+	reviewCancellationErr  refundsHandlerErrCode = "refunds-005"
+)
+
+type IRefundsHandler interface {
+	FindRefund(c *fiber.Ctx) error
+	IssueRefund(c *fiber.Ctx) error
+	FindCancellation(c *fiber.Ctx) error
+	RequestCancellation(c *fiber.Ctx) error
+	ReviewCancellation(c *fiber.Ctx) error
+}
+
+type refundsHandler struct {
+	cfg            config.IConfig
+	refundsUsecase refundsUsecases.IRefundsUsecase
+	auditsUsecase  auditsUsecases.IAuditsUsecase
+}
+
+func RefundsHandler(cfg config.IConfig, refundsUsecase refundsUsecases.IRefundsUsecase, auditsUsecase auditsUsecases.IAuditsUsecase) IRefundsHandler {
+	return &refundsHandler{
+		cfg:            cfg,
+		refundsUsecase: refundsUsecase,
+		auditsUsecase:  auditsUsecase,
+	}
+}
+
+func (h *refundsHandler) FindRefund(c *fiber.Ctx) error {
+	orderId := strings.TrimSpace(c.Query("order_id"))
+	if orderId == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findRefundErr),
+			"order_id is required",
+		).Res()
+	}
+
+	refundsList, err := h.refundsUsecase.FindRefund(c.UserContext(), orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findRefundErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, refundsList).Res()
+}
+
+func (h *refundsHandler) IssueRefund(c *fiber.Ctx) error {
+	req := new(refunds.Refund)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(issueRefundErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.OrderId = strings.TrimSpace(req.OrderId)
+	if req.OrderId == "" {
+		return entities.NewResponse(c).ValidationError(
+			fiber.ErrBadRequest.Code,
+			string(issueRefundErr),
+			entities.ValidationErrors{{Field: "order_id", Msg: "is required"}},
+		).Res()
+	}
+	req.ActorId = c.Locals("userId").(string)
+
+	refund, err := h.refundsUsecase.IssueRefund(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "order is not paid",
+			err.Error() == "order has been fully refunded",
+			err.Error() == "restock is only allowed on a full refund",
+			strings.HasPrefix(err.Error(), "amount must be"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(issueRefundErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(issueRefundErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  refund.ActorId,
+		Action:   audits.RefundIssue,
+		Entity:   "order",
+		EntityId: refund.OrderId,
+		After:    refund,
+	})
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, refund).Res()
+}
+
+func (h *refundsHandler) FindCancellation(c *fiber.Ctx) error {
+	status := strings.ToLower(c.Query("status"))
+
+	cancellations, err := h.refundsUsecase.FindCancellation(c.UserContext(), status)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCancellationErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, cancellations).Res()
+}
+
+func (h *refundsHandler) RequestCancellation(c *fiber.Ctx) error {
+	req := new(refunds.Cancellation)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(requestCancellationErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.OrderId = strings.TrimSpace(req.OrderId)
+	if req.OrderId == "" {
+		return entities.NewResponse(c).ValidationError(
+			fiber.ErrBadRequest.Code,
+			string(requestCancellationErr),
+			entities.ValidationErrors{{Field: "order_id", Msg: "is required"}},
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	res, err := h.refundsUsecase.RequestCancellation(c.UserContext(), req, c.Locals("userRoleId").(int))
+	if err != nil {
+		var transitionErr *orders.TransitionError
+		switch {
+		case errors.As(err, &transitionErr), err.Error() == "cancellation has already been requested":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(requestCancellationErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "no permission to access":
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(requestCancellationErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(requestCancellationErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, res).Res()
+}
+
+func (h *refundsHandler) ReviewCancellation(c *fiber.Ctx) error {
+	req := new(refunds.CancellationReview)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(reviewCancellationErr),
+			err.Error(),
+		).Res()
+	}
+
+	statusMap := map[string]string{
+		"approved": refunds.CancellationApproved,
+		"rejected": refunds.CancellationRejected,
+	}
+	req.Status = statusMap[strings.ToLower(req.Status)]
+	if req.Status == "" {
+		return entities.NewResponse(c).ValidationError(
+			fiber.ErrBadRequest.Code,
+			string(reviewCancellationErr),
+			entities.ValidationErrors{{Field: "status", Msg: "must be approved or rejected"}},
+		).Res()
+	}
+	req.Id = strings.TrimSpace(c.Params("cancellationId"))
+	req.ReviewedBy = c.Locals("userId").(string)
+
+	cancellation, err := h.refundsUsecase.ReviewCancellation(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "cancellation not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(reviewCancellationErr),
+				err.Error(),
+			).Res()
+		case "cancellation has already been reviewed":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(reviewCancellationErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(reviewCancellationErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  req.ReviewedBy,
+		Action:   audits.CancellationReview,
+		Entity:   "order",
+		EntityId: cancellation.OrderId,
+		After:    cancellation,
+	})
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, cancellation).Res()
+}
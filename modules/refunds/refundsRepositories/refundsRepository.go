@@ -0,0 +1,284 @@
+package refundsRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+	"github.com/jmoiron/sqlx"
+)
+
+type IRefundsRepository interface {
+	FindRefund(ctx context.Context, orderId string) ([]*refunds.Refund, error)
+	SumRefunded(ctx context.Context, orderId string) (float64, error)
+	InsertRefund(ctx context.Context, req *refunds.Refund) error
+	FindCancellation(ctx context.Context, status string) ([]*refunds.Cancellation, error)
+	FindOneCancellation(ctx context.Context, cancellationId string) (*refunds.Cancellation, error)
+	InsertCancellation(ctx context.Context, req *refunds.Cancellation) error
+	ReviewCancellation(ctx context.Context, req *refunds.CancellationReview) error
+}
+
+type refundsRepository struct {
+	db *sqlx.DB
+}
+
+func RefundsRepository(db *sqlx.DB) IRefundsRepository {
+	return &refundsRepository{
+		db: db,
+	}
+}
+
+func (r *refundsRepository) FindRefund(ctx context.Context, orderId string) ([]*refunds.Refund, error) {
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"r"."id",
+			"r"."order_id",
+			"r"."amount",
+			"r"."reason",
+			"r"."status",
+			"r"."gateway_ref",
+			"r"."restocked" AS "restock",
+			"r"."actor_id",
+			"r"."created_at"
+		FROM "refunds" "r"
+		WHERE "r"."order_id" = $1
+		ORDER BY "r"."created_at" DESC
+	) AS "t";`
+
+	bytes := make([]byte, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	refundsList := make([]*refunds.Refund, 0)
+	if err := r.db.GetContext(ctx, &bytes, query, orderId); err != nil {
+		return nil, fmt.Errorf("get refunds failed: %v", err)
+	}
+	if err := json.Unmarshal(bytes, &refundsList); err != nil {
+		return nil, fmt.Errorf("unmarshal refunds failed: %v", err)
+	}
+	return refundsList, nil
+}
+
+// SumRefunded is the amount already given back, failed refunds are not counted
+func (r *refundsRepository) SumRefunded(ctx context.Context, orderId string) (float64, error) {
+	query := `
+	SELECT
+		COALESCE(SUM("amount"), 0)
+	FROM "refunds"
+	WHERE "order_id" = $1
+	AND "status" != 'failed';`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var sum float64
+	if err := r.db.GetContext(ctx, &sum, query, orderId); err != nil {
+		return 0, fmt.Errorf("sum refunded failed: %v", err)
+	}
+	return sum, nil
+}
+
+// InsertRefund record the refund and put the products of the order back to stock in the same transaction
+func (r *refundsRepository) InsertRefund(ctx context.Context, req *refunds.Refund) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "refunds" (
+		"order_id",
+		"amount",
+		"reason",
+		"status",
+		"gateway_ref",
+		"restocked",
+		"actor_id"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING "id", "created_at";`
+
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		req.OrderId,
+		req.Amount,
+		req.Reason,
+		req.Status,
+		req.GatewayRef,
+		req.Restock,
+		req.ActorId,
+	).Scan(&req.Id, &req.CreatedAt); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert refund failed: %v", err)
+	}
+
+	if req.Restock {
+		productIds := make([]string, 0)
+		if err := tx.SelectContext(ctx, &productIds, `
+		SELECT
+			"product"->>'id'
+		FROM "products_orders"
+		WHERE "order_id" = $1;`, req.OrderId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("find products of order failed: %v", err)
+		}
+		if err := locks.LockStock(ctx, tx, productIds...); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := inventoryRepositories.MarkMovement(ctx, tx, &inventory.MovementContext{
+			Kind:    inventory.MovementRefund,
+			Reason:  req.Reason,
+			ActorId: req.ActorId,
+			OrderId: req.OrderId,
+		}); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// product ใน products_orders เป็น snapshot ตอนสั่ง ใช้แค่ id ไปหา product จริง
+		// รวม qty ต่อ product ก่อน เพราะ UPDATE ... FROM ใช้แค่แถวเดียวต่อ product เมื่อ order มีหลายบรรทัด
+		if _, err := tx.ExecContext(ctx, `
+		UPDATE "products" "p" SET
+			"stock" = "p"."stock" + "po"."qty"
+		FROM (
+			SELECT
+				"product"->>'id' AS "product_id",
+				SUM("qty")::INT AS "qty"
+			FROM "products_orders"
+			WHERE "order_id" = $1
+			GROUP BY "product"->>'id'
+		) "po"
+		WHERE "p"."id" = "po"."product_id";`, req.OrderId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("restock products failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+func (r *refundsRepository) FindCancellation(ctx context.Context, status string) ([]*refunds.Cancellation, error) {
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"c"."id",
+			"c"."order_id",
+			"c"."user_id",
+			"c"."reason",
+			"c"."status",
+			"c"."reviewed_by",
+			"c"."created_at",
+			"c"."updated_at"
+		FROM "cancellation_requests" "c"
+		WHERE ($1 = '' OR "c"."status"::TEXT = $1)
+		ORDER BY "c"."created_at" ASC
+	) AS "t";`
+
+	bytes := make([]byte, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	cancellations := make([]*refunds.Cancellation, 0)
+	if err := r.db.GetContext(ctx, &bytes, query, status); err != nil {
+		return nil, fmt.Errorf("get cancellations failed: %v", err)
+	}
+	if err := json.Unmarshal(bytes, &cancellations); err != nil {
+		return nil, fmt.Errorf("unmarshal cancellations failed: %v", err)
+	}
+	return cancellations, nil
+}
+
+func (r *refundsRepository) FindOneCancellation(ctx context.Context, cancellationId string) (*refunds.Cancellation, error) {
+	query := `
+	SELECT
+		"id",
+		"order_id",
+		"user_id",
+		"reason",
+		"status",
+		"reviewed_by",
+		"created_at",
+		"updated_at"
+	FROM "cancellation_requests"
+	WHERE "id" = $1;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	cancellation := new(refunds.Cancellation)
+	if err := r.db.GetContext(ctx, cancellation, query, cancellationId); err != nil {
+		return nil, fmt.Errorf("cancellation not found")
+	}
+	return cancellation, nil
+}
+
+func (r *refundsRepository) InsertCancellation(ctx context.Context, req *refunds.Cancellation) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "cancellation_requests" (
+		"order_id",
+		"user_id",
+		"reason"
+	)
+	VALUES ($1, $2, $3)
+		RETURNING "id", "status", "created_at", "updated_at";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.OrderId, req.UserId, req.Reason).Scan(
+		&req.Id,
+		&req.Status,
+		&req.CreatedAt,
+		&req.UpdatedAt,
+	); err != nil {
+		switch err.Error() {
+		case `ERROR: duplicate key value violates unique constraint "cancellation_requests_pending_idx" (SQLSTATE 23505)`:
+			return fmt.Errorf("cancellation has already been requested")
+		default:
+			return fmt.Errorf("insert cancellation failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// ReviewCancellation only change a pending request, so it cannot be approved twice
+func (r *refundsRepository) ReviewCancellation(ctx context.Context, req *refunds.CancellationReview) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "cancellation_requests" SET
+		"status" = $1,
+		"reviewed_by" = $2
+	WHERE "id" = $3
+	AND "status" = 'pending';`, req.Status, req.ReviewedBy, req.Id)
+	if err != nil {
+		return fmt.Errorf("review cancellation failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("cancellation has already been reviewed")
+	}
+	return nil
+}
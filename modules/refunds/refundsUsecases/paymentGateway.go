@@ -0,0 +1,27 @@
+package refundsUsecases
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/google/uuid"
+)
+
+type IPaymentGateway interface {
+	Code() string
+	// Refund give amount back to the customer, return the reference of the gateway and the refund status
+	Refund(order *orders.Order, amount float64) (string, string, error)
+}
+
+// manualTransferGateway is for orders paid by a bank transfer slip, the admin who issue the refund
+// transfer the money back by themselves so the refund succeed right away
+type manualTransferGateway struct{}
+
+func ManualTransferGateway() IPaymentGateway {
+	return &manualTransferGateway{}
+}
+
+func (g *manualTransferGateway) Code() string { return "manual" }
+
+func (g *manualTransferGateway) Refund(order *orders.Order, amount float64) (string, string, error) {
+	return g.Code() + ":" + uuid.NewString(), refunds.StatusSucceeded, nil
+}
@@ -0,0 +1,184 @@
+package refundsUsecases
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/NatthawutSK/ri-shop/modules/refunds/refundsRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type IRefundsUsecase interface {
+	FindRefund(ctx context.Context, orderId string) ([]*refunds.Refund, error)
+	IssueRefund(ctx context.Context, req *refunds.Refund) (*refunds.Refund, error)
+	FindCancellation(ctx context.Context, status string) ([]*refunds.Cancellation, error)
+	RequestCancellation(ctx context.Context, req *refunds.Cancellation, userRoleId int) (*refunds.CancellationRes, error)
+	ReviewCancellation(ctx context.Context, req *refunds.CancellationReview) (*refunds.Cancellation, error)
+	RefundCharge(ctx context.Context, orderId string, amount float64) (string, error)
+}
+
+type refundsUsecase struct {
+	refundsRepository refundsRepositories.IRefundsRepository
+	ordersUsecase     ordersUsecases.IOrdersUsecase
+	gateway           IPaymentGateway
+}
+
+func RefundsUsecase(refundsRepository refundsRepositories.IRefundsRepository, ordersUsecase ordersUsecases.IOrdersUsecase, gateway IPaymentGateway) IRefundsUsecase {
+	return &refundsUsecase{
+		refundsRepository: refundsRepository,
+		ordersUsecase:     ordersUsecase,
+		gateway:           gateway,
+	}
+}
+
+func (u *refundsUsecase) FindRefund(ctx context.Context, orderId string) ([]*refunds.Refund, error) {
+	return u.refundsRepository.FindRefund(ctx, orderId)
+}
+
+func (u *refundsUsecase) FindCancellation(ctx context.Context, status string) ([]*refunds.Cancellation, error) {
+	return u.refundsRepository.FindCancellation(ctx, status)
+}
+
+// IssueRefund refund req.Amount of the order (the whole refundable amount when 0), a full refund
+// cancel the order if it is not completed yet
+func (u *refundsUsecase) IssueRefund(ctx context.Context, req *refunds.Refund) (*refunds.Refund, error) {
+	order, err := u.ordersUsecase.FindOneOrder(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == orders.StatusWaiting {
+		return nil, fmt.Errorf("order is not paid")
+	}
+
+	refunded, err := u.refundsRepository.SumRefunded(ctx, order.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	refundable := math.Round((order.TotalPaid-refunded)*100) / 100
+	if refundable <= 0 {
+		return nil, fmt.Errorf("order has been fully refunded")
+	}
+	if req.Amount == 0 {
+		req.Amount = refundable
+	}
+	if req.Amount < 0 || req.Amount > refundable {
+		return nil, fmt.Errorf("amount must be between 0 and %.2f", refundable)
+	}
+
+	full := req.Amount == refundable
+	if req.Restock && !full {
+		return nil, fmt.Errorf("restock is only allowed on a full refund")
+	}
+
+	ref, status, err := u.gateway.Refund(order, req.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("refund failed: %v", err)
+	}
+	req.GatewayRef = ref
+	req.Status = status
+
+	if err := u.refundsRepository.InsertRefund(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if full && order.Status != orders.StatusCompleted && order.Status != orders.StatusCanceled {
+		if _, err := u.ordersUsecase.UpdateOrder(ctx, &orders.OrderUpdate{
+			Id:          order.Id,
+			Status:      orders.StatusCanceled,
+			ActorId:     req.ActorId,
+			ActorRoleId: 2,
+		}); err != nil {
+			return nil, fmt.Errorf("refund %s was issued but cancel order failed: %v", req.Id, err)
+		}
+	}
+
+	events.Publish(refunds.EventRefundIssued, req)
+	return req, nil
+}
+
+// RefundCharge give back a charge which is not part of the order total, e.g. a duplicate payment,
+// so it is not counted as a refund of the order and never cancel it
+func (u *refundsUsecase) RefundCharge(ctx context.Context, orderId string, amount float64) (string, error) {
+	order, err := u.ordersUsecase.FindOneOrder(ctx, orderId)
+	if err != nil {
+		return "", err
+	}
+	if amount <= 0 {
+		return "", fmt.Errorf("amount must be more than 0")
+	}
+
+	ref, status, err := u.gateway.Refund(order, amount)
+	if err != nil {
+		return "", fmt.Errorf("refund failed: %v", err)
+	}
+	if status == refunds.StatusFailed {
+		return "", fmt.Errorf("refund failed: %s", ref)
+	}
+	return ref, nil
+}
+
+// RequestCancellation cancel a waiting order right away, a paid order need an admin to approve and refund it
+func (u *refundsUsecase) RequestCancellation(ctx context.Context, req *refunds.Cancellation, userRoleId int) (*refunds.CancellationRes, error) {
+	order, err := u.ordersUsecase.FindOneOrder(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if userRoleId != 2 && order.UserId != req.UserId {
+		return nil, fmt.Errorf("no permission to access")
+	}
+
+	switch order.Status {
+	case orders.StatusWaiting:
+		if _, err := u.ordersUsecase.UpdateOrder(ctx, &orders.OrderUpdate{
+			Id:          order.Id,
+			Status:      orders.StatusCanceled,
+			ActorId:     req.UserId,
+			ActorRoleId: userRoleId,
+		}); err != nil {
+			return nil, err
+		}
+		return &refunds.CancellationRes{Canceled: true}, nil
+
+	case orders.StatusPaid, orders.StatusReadyForPickup:
+		req.UserId = order.UserId
+		if err := u.refundsRepository.InsertCancellation(ctx, req); err != nil {
+			return nil, err
+		}
+		return &refunds.CancellationRes{Cancellation: req}, nil
+
+	default:
+		return nil, &orders.TransitionError{From: order.Status, To: orders.StatusCanceled, Reason: "order cannot be canceled after shipment"}
+	}
+}
+
+func (u *refundsUsecase) ReviewCancellation(ctx context.Context, req *refunds.CancellationReview) (*refunds.Cancellation, error) {
+	cancellation, err := u.refundsRepository.FindOneCancellation(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if cancellation.Status != refunds.CancellationPending {
+		return nil, fmt.Errorf("cancellation has already been reviewed")
+	}
+
+	// refund ก่อนแล้วค่อยปิด request ถ้า refund ไม่ผ่าน request ยัง pending ให้ลองใหม่ได้
+	if req.Status == refunds.CancellationApproved {
+		if _, err := u.IssueRefund(ctx, &refunds.Refund{
+			OrderId: cancellation.OrderId,
+			Reason:  cancellation.Reason,
+			Restock: true,
+			ActorId: req.ReviewedBy,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.refundsRepository.ReviewCancellation(ctx, req); err != nil {
+		return nil, err
+	}
+	return u.refundsRepository.FindOneCancellation(ctx, req.Id)
+}
@@ -0,0 +1,147 @@
+package reports
+
+import "strconv"
+
+type ReportFilter struct {
+	StoreId   string `query:"-"`
+	StartDate string `query:"start_date"` // YYYY-MM-DD, 30 days ago when empty
+	EndDate   string `query:"end_date"`   // YYYY-MM-DD, today when empty
+	Period    string `query:"period"`     // day, week or month, only for revenue
+	Limit     int    `query:"limit"`      // only for top products and searches
+	Threshold int    `query:"threshold"`  // only for low stock
+	Format    string `query:"format"`     // csv or json
+}
+
+// ICsvRow is a report row which can be exported as csv
+type ICsvRow interface {
+	CsvHeader() []string
+	CsvRow() []string
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Revenue count orders which have been paid, canceled orders are counted until they are refunded
+type Revenue struct {
+	Period   string  `json:"period" db:"period"` // first day of the period
+	Orders   int     `json:"orders" db:"orders"`
+	Gross    float64 `json:"gross" db:"gross"`
+	Refunded float64 `json:"refunded" db:"refunded"`
+	Net      float64 `json:"net" db:"net"`
+}
+
+func (r *Revenue) CsvHeader() []string {
+	return []string{"period", "orders", "gross", "refunded", "net"}
+}
+func (r *Revenue) CsvRow() []string {
+	return []string{r.Period, strconv.Itoa(r.Orders), formatAmount(r.Gross), formatAmount(r.Refunded), formatAmount(r.Net)}
+}
+
+type TopProduct struct {
+	ProductId string  `json:"product_id" db:"product_id"`
+	Title     string  `json:"title" db:"title"`
+	Qty       int     `json:"qty" db:"qty"`
+	Revenue   float64 `json:"revenue" db:"revenue"`
+}
+
+func (p *TopProduct) CsvHeader() []string {
+	return []string{"product_id", "title", "qty", "revenue"}
+}
+func (p *TopProduct) CsvRow() []string {
+	return []string{p.ProductId, p.Title, strconv.Itoa(p.Qty), formatAmount(p.Revenue)}
+}
+
+type OrderStatus struct {
+	Status string  `json:"status" db:"status"`
+	Orders int     `json:"orders" db:"orders"`
+	Total  float64 `json:"total" db:"total"`
+}
+
+func (s *OrderStatus) CsvHeader() []string {
+	return []string{"status", "orders", "total"}
+}
+func (s *OrderStatus) CsvRow() []string {
+	return []string{s.Status, strconv.Itoa(s.Orders), formatAmount(s.Total)}
+}
+
+// Commission is what the shop earned from seller items of paid orders, orders which are not completed
+// yet are estimated with the commission rate of the seller
+type Commission struct {
+	Period      string  `json:"period" db:"period"`
+	Orders      int     `json:"orders" db:"orders"`
+	SellerSales float64 `json:"seller_sales" db:"seller_sales"`
+	Commission  float64 `json:"commission" db:"commission"`
+	Estimated   float64 `json:"estimated" db:"estimated"` // part of commission which is not final
+}
+
+func (c *Commission) CsvHeader() []string {
+	return []string{"period", "orders", "seller_sales", "commission", "estimated"}
+}
+func (c *Commission) CsvRow() []string {
+	return []string{c.Period, strconv.Itoa(c.Orders), formatAmount(c.SellerSales), formatAmount(c.Commission), formatAmount(c.Estimated)}
+}
+
+type LowStock struct {
+	ProductId string `json:"product_id" db:"product_id"`
+	Title     string `json:"title" db:"title"`
+	Stock     int    `json:"stock" db:"stock"`
+}
+
+func (l *LowStock) CsvHeader() []string {
+	return []string{"product_id", "title", "stock"}
+}
+func (l *LowStock) CsvRow() []string {
+	return []string{l.ProductId, l.Title, strconv.Itoa(l.Stock)}
+}
+
+// TopSearch is a storefront query by the number of searches, CTR is the share of searches with a click
+type TopSearch struct {
+	Query      string  `json:"query" db:"query"`
+	Searches   int     `json:"searches" db:"searches"`
+	AvgResults float64 `json:"avg_results" db:"avg_results"`
+	Clicks     int     `json:"clicks" db:"clicks"`
+	Ctr        float64 `json:"ctr" db:"ctr"`
+}
+
+func (t *TopSearch) CsvHeader() []string {
+	return []string{"query", "searches", "avg_results", "clicks", "ctr"}
+}
+func (t *TopSearch) CsvRow() []string {
+	return []string{t.Query, strconv.Itoa(t.Searches), formatAmount(t.AvgResults), strconv.Itoa(t.Clicks), formatAmount(t.Ctr)}
+}
+
+// ZeroResultSearch is a query which found no product, a gap merchandising can fill
+type ZeroResultSearch struct {
+	Query          string `json:"query" db:"query"`
+	Searches       int    `json:"searches" db:"searches"`
+	LastSearchedAt string `json:"last_searched_at" db:"last_searched_at"`
+}
+
+func (z *ZeroResultSearch) CsvHeader() []string {
+	return []string{"query", "searches", "last_searched_at"}
+}
+func (z *ZeroResultSearch) CsvRow() []string {
+	return []string{z.Query, strconv.Itoa(z.Searches), z.LastSearchedAt}
+}
+
+// OrderSummary is the paid orders of a customer in a store, for the account page and the admin crm view
+type OrderSummary struct {
+	UserId             string              `json:"user_id"`
+	Orders             int                 `json:"orders" db:"orders"`
+	LifetimeSpend      float64             `json:"lifetime_spend" db:"lifetime_spend"` // products, shipping and tax minus refunds
+	LastOrderAt        *string             `json:"last_order_at" db:"last_order_at"`   // null when the customer has no paid order
+	FavoriteCategories []*FavoriteCategory `json:"favorite_categories"`
+}
+
+// FavoriteCategory is a category by the number of items the customer bought in it
+type FavoriteCategory struct {
+	Id    int    `json:"id" db:"id"`
+	Title string `json:"title" db:"title"`
+	Qty   int    `json:"qty" db:"qty"`
+}
+
+type OrderSummaryReq struct {
+	StoreId string
+	UserId  string
+}
@@ -0,0 +1,307 @@
+package reportsHandlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/reports"
+	"github.com/NatthawutSK/ri-shop/modules/reports/reportsUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+type reportsHandlerErrCode string
+
+const (
+	findRevenueErr      reportsHandlerErrCode = "reports-001"
+	findTopProductErr   reportsHandlerErrCode = "reports-002"
+	findOrderStatusErr  reportsHandlerErrCode = "reports-003"
+	findLowStockErr     reportsHandlerErrCode = "reports-004"
+	findCommissionErr   reportsHandlerErrCode = "reports-005"
+	findTopSearchErr    reportsHandlerErrCode = "reports-006"
+	findZeroSearchErr   reportsHandlerErrCode = "reports-007"
+	findOrderSummaryErr reportsHandlerErrCode = "reports-008"
+)
+
+type IReportsHandler interface {
+	FindRevenue(c *fiber.Ctx) error
+	FindTopProduct(c *fiber.Ctx) error
+	FindOrderStatus(c *fiber.Ctx) error
+	FindLowStock(c *fiber.Ctx) error
+	FindCommission(c *fiber.Ctx) error
+	FindTopSearch(c *fiber.Ctx) error
+	FindZeroResultSearch(c *fiber.Ctx) error
+	FindOrderSummary(c *fiber.Ctx) error
+	ForgetOrderSummary(ctx context.Context, e *events.Event)
+}
+
+type reportsHandler struct {
+	cfg            config.IConfig
+	reportsUsecase reportsUsecases.IReportsUsecase
+}
+
+func ReportsHandler(cfg config.IConfig, reportsUsecase reportsUsecases.IReportsUsecase) IReportsHandler {
+	return &reportsHandler{
+		cfg:            cfg,
+		reportsUsecase: reportsUsecase,
+	}
+}
+
+// parseFilter read the query and fill the defaults, the last 30 days grouped by day
+func parseFilter(c *fiber.Ctx) (*reports.ReportFilter, error) {
+	req := new(reports.ReportFilter)
+	if err := c.QueryParser(req); err != nil {
+		return nil, err
+	}
+
+	// Date	YYYY-MM-DD
+	now := time.Now()
+	if req.EndDate == "" {
+		req.EndDate = now.Format("2006-01-02")
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("end date is invalid")
+	}
+	if req.StartDate == "" {
+		req.StartDate = end.AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("start date is invalid")
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("start date is after end date")
+	}
+
+	periodMap := map[string]string{
+		"day":   "day",
+		"week":  "week",
+		"month": "month",
+	}
+	req.Period = periodMap[strings.ToLower(req.Period)]
+	if req.Period == "" {
+		req.Period = periodMap["day"]
+	}
+
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+	if c.Query("threshold") == "" || req.Threshold < 0 {
+		req.Threshold = 5
+	}
+
+	req.Format = strings.ToLower(req.Format)
+	req.StoreId = c.Locals("storeId").(string)
+	return req, nil
+}
+
+// respond send rows as json, or as a csv file when ?format=csv
+func respond[T reports.ICsvRow](c *fiber.Ctx, name string, req *reports.ReportFilter, rows []T) error {
+	if req.Format != "csv" {
+		return entities.NewResponse(c).Success(fiber.StatusOK, rows).Res()
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	var header T // CsvHeader does not read the row, the zero value is enough
+	if err := w.Write(header.CsvHeader()); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.Write(row.CsvRow()); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s_%s_%s.csv"`, name, req.StartDate, req.EndDate))
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+func (h *reportsHandler) FindRevenue(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findRevenueErr),
+			err.Error(),
+		).Res()
+	}
+
+	revenue, err := h.reportsUsecase.FindRevenue(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findRevenueErr),
+			err.Error(),
+		).Res()
+	}
+
+	return respond(c, "revenue", req, revenue)
+}
+
+func (h *reportsHandler) FindTopProduct(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findTopProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	products, err := h.reportsUsecase.FindTopProduct(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findTopProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	return respond(c, "top_products", req, products)
+}
+
+func (h *reportsHandler) FindOrderStatus(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOrderStatusErr),
+			err.Error(),
+		).Res()
+	}
+
+	statuses, err := h.reportsUsecase.FindOrderStatus(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findOrderStatusErr),
+			err.Error(),
+		).Res()
+	}
+
+	return respond(c, "orders_by_status", req, statuses)
+}
+
+func (h *reportsHandler) FindLowStock(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findLowStockErr),
+			err.Error(),
+		).Res()
+	}
+
+	products, err := h.reportsUsecase.FindLowStock(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findLowStockErr),
+			err.Error(),
+		).Res()
+	}
+
+	return respond(c, "low_stock", req, products)
+}
+
+func (h *reportsHandler) FindCommission(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findCommissionErr),
+			err.Error(),
+		).Res()
+	}
+
+	commission, err := h.reportsUsecase.FindCommission(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCommissionErr),
+			err.Error(),
+		).Res()
+	}
+
+	return respond(c, "commission", req, commission)
+}
+
+func (h *reportsHandler) FindTopSearch(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findTopSearchErr),
+			err.Error(),
+		).Res()
+	}
+
+	searches, err := h.reportsUsecase.FindTopSearch(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findTopSearchErr),
+			err.Error(),
+		).Res()
+	}
+
+	return respond(c, "top_searches", req, searches)
+}
+
+func (h *reportsHandler) FindZeroResultSearch(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findZeroSearchErr),
+			err.Error(),
+		).Res()
+	}
+
+	searches, err := h.reportsUsecase.FindZeroResultSearch(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findZeroSearchErr),
+			err.Error(),
+		).Res()
+	}
+
+	return respond(c, "zero_result_searches", req, searches)
+}
+
+func (h *reportsHandler) FindOrderSummary(c *fiber.Ctx) error {
+	summary, err := h.reportsUsecase.FindOrderSummary(c.UserContext(), &reports.OrderSummaryReq{
+		StoreId: c.Locals("storeId").(string),
+		UserId:  strings.Trim(c.Params("user_id"), " "),
+	})
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findOrderSummaryErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, summary).Res()
+}
+
+func (h *reportsHandler) ForgetOrderSummary(ctx context.Context, e *events.Event) {
+	if payload, ok := e.Payload.(*orders.OrderEvent); ok {
+		h.reportsUsecase.ForgetOrderSummary(payload.StoreId, payload.UserId)
+	}
+}
@@ -0,0 +1,339 @@
+package reportsRepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/reports"
+	"github.com/jmoiron/sqlx"
+)
+
+type IReportsRepository interface {
+	FindRevenue(ctx context.Context, req *reports.ReportFilter) ([]*reports.Revenue, error)
+	FindTopProduct(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopProduct, error)
+	FindOrderStatus(ctx context.Context, req *reports.ReportFilter) ([]*reports.OrderStatus, error)
+	FindLowStock(ctx context.Context, req *reports.ReportFilter) ([]*reports.LowStock, error)
+	FindCommission(ctx context.Context, req *reports.ReportFilter) ([]*reports.Commission, error)
+	FindTopSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopSearch, error)
+	FindZeroResultSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.ZeroResultSearch, error)
+	FindOrderSummary(ctx context.Context, req *reports.OrderSummaryReq) (*reports.OrderSummary, error)
+}
+
+type reportsRepository struct {
+	db *sqlx.DB
+}
+
+func ReportsRepository(db *sqlx.DB) IReportsRepository {
+	return &reportsRepository{
+		db: db,
+	}
+}
+
+// paidOrders is every order of the store ($3) in the date range ($1 - $2) which has been paid, orders before
+// the status history existed are judged by their status only
+const paidOrders = `
+	SELECT
+		"o"."id",
+		"o"."shipping_fee",
+		"o"."discount",
+		"o"."created_at"
+	FROM "orders" "o"
+	WHERE "o"."created_at" >= ($1)::DATE
+	AND "o"."created_at" < ($2)::DATE + 1
+	AND COALESCE("o"."store_id", '') = $3
+	AND (
+		"o"."status" IN ('paid', 'shipping', 'ready_for_pickup', 'completed')
+		OR EXISTS (
+			SELECT 1
+			FROM "order_status_history" "h"
+			WHERE "h"."order_id" = "o"."id"
+			AND "h"."to_status" = 'paid'
+		)
+	)`
+
+func (r *reportsRepository) FindRevenue(ctx context.Context, req *reports.ReportFilter) ([]*reports.Revenue, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+	WITH "paid" AS (%s), "t" AS (
+		SELECT
+			"p"."created_at",
+			(
+				SELECT
+					COALESCE(SUM(("po"."product"->>'price')::FLOAT * "po"."qty"), 0)
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "p"."id"
+			) - "p"."discount" + "p"."shipping_fee" AS "gross",
+			(
+				SELECT
+					COALESCE(SUM("r"."amount"), 0)
+				FROM "refunds" "r"
+				WHERE "r"."order_id" = "p"."id"
+				AND "r"."status" != 'failed'
+			) AS "refunded"
+		FROM "paid" "p"
+	)
+	SELECT
+		to_char(date_trunc($4, "t"."created_at"), 'YYYY-MM-DD') AS "period",
+		COUNT(*) AS "orders",
+		ROUND(SUM("t"."gross")::NUMERIC, 2)::FLOAT AS "gross",
+		ROUND(SUM("t"."refunded")::NUMERIC, 2)::FLOAT AS "refunded",
+		ROUND(SUM("t"."gross" - "t"."refunded")::NUMERIC, 2)::FLOAT AS "net"
+	FROM "t"
+	GROUP BY 1
+	ORDER BY 1 ASC;`, paidOrders)
+
+	revenue := make([]*reports.Revenue, 0)
+	if err := r.db.SelectContext(ctx, &revenue, query, req.StartDate, req.EndDate, req.StoreId, req.Period); err != nil {
+		return nil, fmt.Errorf("find revenue failed: %v", err)
+	}
+	return revenue, nil
+}
+
+func (r *reportsRepository) FindTopProduct(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopProduct, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	// title มาจาก snapshot ตอนสั่ง ใช้ title ล่าสุดถ้า product ยังอยู่
+	query := fmt.Sprintf(`
+	WITH "paid" AS (%s)
+	SELECT
+		"po"."product"->>'id' AS "product_id",
+		COALESCE(MAX("pr"."title"), MAX("po"."product"->>'title'), '') AS "title",
+		SUM("po"."qty") AS "qty",
+		ROUND(SUM(("po"."product"->>'price')::FLOAT * "po"."qty")::NUMERIC, 2)::FLOAT AS "revenue"
+	FROM "paid" "p"
+	JOIN "products_orders" "po" ON "po"."order_id" = "p"."id"
+	LEFT JOIN "products" "pr" ON "pr"."id" = "po"."product"->>'id'
+	GROUP BY 1
+	ORDER BY "qty" DESC, "revenue" DESC
+	LIMIT $4;`, paidOrders)
+
+	products := make([]*reports.TopProduct, 0)
+	if err := r.db.SelectContext(ctx, &products, query, req.StartDate, req.EndDate, req.StoreId, req.Limit); err != nil {
+		return nil, fmt.Errorf("find top products failed: %v", err)
+	}
+	return products, nil
+}
+
+func (r *reportsRepository) FindOrderStatus(ctx context.Context, req *reports.ReportFilter) ([]*reports.OrderStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	SELECT
+		"o"."status"::TEXT AS "status",
+		COUNT(*) AS "orders",
+		ROUND(SUM(
+			(
+				SELECT
+					COALESCE(SUM(("po"."product"->>'price')::FLOAT * "po"."qty"), 0)
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "o"."id"
+			) - "o"."discount" + "o"."shipping_fee"
+		)::NUMERIC, 2)::FLOAT AS "total"
+	FROM "orders" "o"
+	WHERE "o"."created_at" >= ($1)::DATE
+	AND "o"."created_at" < ($2)::DATE + 1
+	AND COALESCE("o"."store_id", '') = $3
+	GROUP BY 1
+	ORDER BY 1 ASC;`
+
+	statuses := make([]*reports.OrderStatus, 0)
+	if err := r.db.SelectContext(ctx, &statuses, query, req.StartDate, req.EndDate, req.StoreId); err != nil {
+		return nil, fmt.Errorf("find orders by status failed: %v", err)
+	}
+	return statuses, nil
+}
+
+func (r *reportsRepository) FindLowStock(ctx context.Context, req *reports.ReportFilter) ([]*reports.LowStock, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	SELECT
+		"p"."id" AS "product_id",
+		"p"."title",
+		"p"."stock"
+	FROM "products" "p"
+	WHERE "p"."stock" <= $1
+	AND COALESCE("p"."store_id", '') = $2
+	ORDER BY "p"."stock" ASC, "p"."id" ASC;`
+
+	products := make([]*reports.LowStock, 0)
+	if err := r.db.SelectContext(ctx, &products, query, req.Threshold, req.StoreId); err != nil {
+		return nil, fmt.Errorf("find low stock failed: %v", err)
+	}
+	return products, nil
+}
+
+func (r *reportsRepository) FindCommission(ctx context.Context, req *reports.ReportFilter) ([]*reports.Commission, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+	WITH "paid" AS (%s)
+	SELECT
+		to_char(date_trunc($4, "p"."created_at"), 'YYYY-MM-DD') AS "period",
+		COUNT(DISTINCT "p"."id") AS "orders",
+		ROUND(SUM(("po"."product"->>'price')::FLOAT * "po"."qty")::NUMERIC, 2)::FLOAT AS "seller_sales",
+		ROUND(SUM(COALESCE("po"."commission", ("po"."product"->>'price')::FLOAT * "po"."qty" * "po"."commission_rate"))::NUMERIC, 2)::FLOAT AS "commission",
+		ROUND(SUM(
+			CASE WHEN "po"."commission" IS NULL
+				THEN ("po"."product"->>'price')::FLOAT * "po"."qty" * "po"."commission_rate"
+				ELSE 0
+			END
+		)::NUMERIC, 2)::FLOAT AS "estimated"
+	FROM "paid" "p"
+	JOIN "products_orders" "po" ON "po"."order_id" = "p"."id"
+	WHERE "po"."seller_id" IS NOT NULL
+	GROUP BY 1
+	ORDER BY 1 ASC;`, paidOrders)
+
+	commission := make([]*reports.Commission, 0)
+	if err := r.db.SelectContext(ctx, &commission, query, req.StartDate, req.EndDate, req.StoreId, req.Period); err != nil {
+		return nil, fmt.Errorf("find commission failed: %v", err)
+	}
+	return commission, nil
+}
+
+func (r *reportsRepository) FindTopSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopSearch, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	SELECT
+		"s"."query",
+		COUNT(*) AS "searches",
+		ROUND(AVG("s"."results")::NUMERIC, 2)::FLOAT AS "avg_results",
+		COALESCE(SUM("c"."clicks"), 0) AS "clicks",
+		ROUND((COUNT(*) FILTER (WHERE "c"."clicks" > 0))::NUMERIC / COUNT(*), 2)::FLOAT AS "ctr"
+	FROM "search_queries" "s"
+	LEFT JOIN (
+		SELECT
+			"search_id",
+			COUNT(*) AS "clicks"
+		FROM "search_clicks"
+		GROUP BY "search_id"
+	) AS "c" ON "c"."search_id" = "s"."id"
+	WHERE "s"."created_at" >= ($1)::DATE
+	AND "s"."created_at" < ($2)::DATE + 1
+	AND COALESCE("s"."store_id", '') = $4
+	GROUP BY "s"."query"
+	ORDER BY "searches" DESC, "s"."query" ASC
+	LIMIT $3;`
+
+	searches := make([]*reports.TopSearch, 0)
+	if err := r.db.SelectContext(ctx, &searches, query, req.StartDate, req.EndDate, req.Limit, req.StoreId); err != nil {
+		return nil, fmt.Errorf("find top searches failed: %v", err)
+	}
+	return searches, nil
+}
+
+func (r *reportsRepository) FindZeroResultSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.ZeroResultSearch, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	SELECT
+		"query",
+		COUNT(*) AS "searches",
+		to_char(MAX("created_at"), 'YYYY-MM-DD"T"HH24:MI:SS') AS "last_searched_at"
+	FROM "search_queries"
+	WHERE "results" = 0
+	AND "created_at" >= ($1)::DATE
+	AND "created_at" < ($2)::DATE + 1
+	AND COALESCE("store_id", '') = $4
+	GROUP BY "query"
+	ORDER BY "searches" DESC, "query" ASC
+	LIMIT $3;`
+
+	searches := make([]*reports.ZeroResultSearch, 0)
+	if err := r.db.SelectContext(ctx, &searches, query, req.StartDate, req.EndDate, req.Limit, req.StoreId); err != nil {
+		return nil, fmt.Errorf("find zero result searches failed: %v", err)
+	}
+	return searches, nil
+}
+
+// favoriteCategoryLimit is the number of favorite categories in an order summary
+const favoriteCategoryLimit = 3
+
+// userPaidOrders is every order of the user ($1) in the store ($2) which has been paid
+const userPaidOrders = `
+	SELECT
+		"o"."id",
+		"o"."shipping_fee",
+		"o"."discount",
+		"o"."tax",
+		"o"."created_at"
+	FROM "orders" "o"
+	WHERE "o"."user_id" = $1
+	AND COALESCE("o"."store_id", '') = $2
+	AND (
+		"o"."status" IN ('paid', 'shipping', 'ready_for_pickup', 'completed')
+		OR EXISTS (
+			SELECT 1
+			FROM "order_status_history" "h"
+			WHERE "h"."order_id" = "o"."id"
+			AND "h"."to_status" = 'paid'
+		)
+	)`
+
+func (r *reportsRepository) FindOrderSummary(ctx context.Context, req *reports.OrderSummaryReq) (*reports.OrderSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	// ยอดที่ลูกค้าจ่ายรวม tax ต่างจาก revenue ที่ไม่นับ tax
+	query := fmt.Sprintf(`
+	WITH "paid" AS (%s), "t" AS (
+		SELECT
+			"p"."created_at",
+			(
+				SELECT
+					COALESCE(SUM(("po"."product"->>'price')::FLOAT * "po"."qty"), 0)
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "p"."id"
+			) - "p"."discount" + "p"."shipping_fee" + COALESCE(("p"."tax"->>'total')::FLOAT, 0) - (
+				SELECT
+					COALESCE(SUM("r"."amount"), 0)
+				FROM "refunds" "r"
+				WHERE "r"."order_id" = "p"."id"
+				AND "r"."status" != 'failed'
+			) AS "spend"
+		FROM "paid" "p"
+	)
+	SELECT
+		COUNT(*) AS "orders",
+		ROUND(COALESCE(SUM("t"."spend"), 0)::NUMERIC, 2)::FLOAT AS "lifetime_spend",
+		to_char(MAX("t"."created_at"), 'YYYY-MM-DD"T"HH24:MI:SS') AS "last_order_at"
+	FROM "t";`, userPaidOrders)
+
+	summary := &reports.OrderSummary{
+		UserId:             req.UserId,
+		FavoriteCategories: make([]*reports.FavoriteCategory, 0),
+	}
+	if err := r.db.GetContext(ctx, summary, query, req.UserId, req.StoreId); err != nil {
+		return nil, fmt.Errorf("find order summary failed: %v", err)
+	}
+
+	query = fmt.Sprintf(`
+	WITH "paid" AS (%s)
+	SELECT
+		"c"."id",
+		"c"."title",
+		SUM("po"."qty") AS "qty"
+	FROM "paid" "p"
+		JOIN "products_orders" "po" ON "po"."order_id" = "p"."id"
+		JOIN "products_categories" "pc" ON "pc"."product_id" = "po"."product"->>'id'
+		JOIN "categories" "c" ON "c"."id" = "pc"."category_id"
+	GROUP BY "c"."id", "c"."title"
+	ORDER BY "qty" DESC, "c"."title" ASC
+	LIMIT $3;`, userPaidOrders)
+
+	if err := r.db.SelectContext(ctx, &summary.FavoriteCategories, query, req.UserId, req.StoreId, favoriteCategoryLimit); err != nil {
+		return nil, fmt.Errorf("find favorite categories failed: %v", err)
+	}
+	return summary, nil
+}
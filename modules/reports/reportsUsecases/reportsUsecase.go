@@ -0,0 +1,84 @@
+package reportsUsecases
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/reports"
+	"github.com/NatthawutSK/ri-shop/modules/reports/reportsRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+)
+
+type IReportsUsecase interface {
+	FindRevenue(ctx context.Context, req *reports.ReportFilter) ([]*reports.Revenue, error)
+	FindTopProduct(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopProduct, error)
+	FindOrderStatus(ctx context.Context, req *reports.ReportFilter) ([]*reports.OrderStatus, error)
+	FindLowStock(ctx context.Context, req *reports.ReportFilter) ([]*reports.LowStock, error)
+	FindCommission(ctx context.Context, req *reports.ReportFilter) ([]*reports.Commission, error)
+	FindTopSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopSearch, error)
+	FindZeroResultSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.ZeroResultSearch, error)
+	FindOrderSummary(ctx context.Context, req *reports.OrderSummaryReq) (*reports.OrderSummary, error)
+	ForgetOrderSummary(storeId, userId string)
+}
+
+type reportsUsecase struct {
+	reportsRepository reportsRepositories.IReportsRepository
+	summaryCache      *cache.Cache[*reports.OrderSummary]
+}
+
+func ReportsUsecase(reportsRepository reportsRepositories.IReportsRepository, summaryCache *cache.Cache[*reports.OrderSummary]) IReportsUsecase {
+	return &reportsUsecase{
+		reportsRepository: reportsRepository,
+		summaryCache:      summaryCache,
+	}
+}
+
+func (u *reportsUsecase) FindRevenue(ctx context.Context, req *reports.ReportFilter) ([]*reports.Revenue, error) {
+	return u.reportsRepository.FindRevenue(ctx, req)
+}
+
+func (u *reportsUsecase) FindTopProduct(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopProduct, error) {
+	return u.reportsRepository.FindTopProduct(ctx, req)
+}
+
+func (u *reportsUsecase) FindOrderStatus(ctx context.Context, req *reports.ReportFilter) ([]*reports.OrderStatus, error) {
+	return u.reportsRepository.FindOrderStatus(ctx, req)
+}
+
+func (u *reportsUsecase) FindLowStock(ctx context.Context, req *reports.ReportFilter) ([]*reports.LowStock, error) {
+	return u.reportsRepository.FindLowStock(ctx, req)
+}
+
+func (u *reportsUsecase) FindCommission(ctx context.Context, req *reports.ReportFilter) ([]*reports.Commission, error) {
+	return u.reportsRepository.FindCommission(ctx, req)
+}
+
+func (u *reportsUsecase) FindTopSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopSearch, error) {
+	return u.reportsRepository.FindTopSearch(ctx, req)
+}
+
+func (u *reportsUsecase) FindZeroResultSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.ZeroResultSearch, error) {
+	return u.reportsRepository.FindZeroResultSearch(ctx, req)
+}
+
+func summaryKey(storeId, userId string) string {
+	return storeId + "/" + userId
+}
+
+func (u *reportsUsecase) FindOrderSummary(ctx context.Context, req *reports.OrderSummaryReq) (*reports.OrderSummary, error) {
+	key := summaryKey(req.StoreId, req.UserId)
+	if summary, ok := u.summaryCache.Get(key); ok {
+		return summary, nil
+	}
+
+	summary, err := u.reportsRepository.FindOrderSummary(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	u.summaryCache.Set(key, summary)
+	return summary, nil
+}
+
+// ForgetOrderSummary drop the cached summary when an order of the user change, a refund is seen after the ttl
+func (u *reportsUsecase) ForgetOrderSummary(storeId, userId string) {
+	u.summaryCache.Delete(summaryKey(storeId, userId))
+}
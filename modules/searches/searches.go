@@ -0,0 +1,39 @@
+package searches
+
+import (
+	"regexp"
+	"strings"
+)
+
+// searches older than RetentionDays are deleted with their clicks
+const RetentionDays = 90
+
+// a query is cut to MaxQueryLength characters, longer queries are rarely typed by people
+const MaxQueryLength = 100
+
+var (
+	emailPattern  = regexp.MustCompile(`[^\s@]+@[^\s@]+`)
+	numberPattern = regexp.MustCompile(`\d[\d\s-]{5,}\d`)
+	spacePattern  = regexp.MustCompile(`\s+`)
+)
+
+// Click is a product opened from the results of a search
+type Click struct {
+	SearchId  string `json:"-"`
+	ProductId string `json:"product_id"`
+	Position  int    `json:"position"` // 1 is the first result
+}
+
+// NormalizeQuery make the same search count as one query in the reports. Emails and long numbers,
+// e.g. phone or card numbers typed in the search box, are masked so no personal data is kept
+func NormalizeQuery(query string) string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	query = emailPattern.ReplaceAllString(query, "[email]")
+	query = numberPattern.ReplaceAllString(query, "[number]")
+	query = spacePattern.ReplaceAllString(query, " ")
+
+	if runes := []rune(query); len(runes) > MaxQueryLength {
+		query = strings.TrimSpace(string(runes[:MaxQueryLength]))
+	}
+	return query
+}
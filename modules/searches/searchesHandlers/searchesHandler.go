@@ -0,0 +1,88 @@
+package searchesHandlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/searches"
+	"github.com/NatthawutSK/ri-shop/modules/searches/searchesUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+type searchesHandlerErrCode string
+
+const (
+	addClickErr searchesHandlerErrCode = "searches-001"
+)
+
+type ISearchesHandler interface {
+	AddClick(c *fiber.Ctx) error
+	RecordSearch(ctx context.Context, e *events.Event)
+}
+
+type searchesHandler struct {
+	cfg             config.IConfig
+	searchesUsecase searchesUsecases.ISearchesUsecase
+}
+
+func SearchesHandler(cfg config.IConfig, searchesUsecase searchesUsecases.ISearchesUsecase) ISearchesHandler {
+	return &searchesHandler{
+		cfg:             cfg,
+		searchesUsecase: searchesUsecase,
+	}
+}
+
+// AddClick is sent by the storefront when a product is opened from the results, search_id is the
+// X-Search-Id header of the search
+func (h *searchesHandler) AddClick(c *fiber.Ctx) error {
+	req := new(searches.Click)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addClickErr),
+			err.Error(),
+		).Res()
+	}
+	req.SearchId = strings.Trim(c.Params("search_id"), " ")
+
+	if err := h.searchesUsecase.AddClick(c.UserContext(), req); err != nil {
+		switch err.Error() {
+		case "search not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(addClickErr),
+				err.Error(),
+			).Res()
+		case "product id is required", "position is invalid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addClickErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(addClickErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, req).Res()
+}
+
+// RecordSearch is the event subscriber of product searches
+func (h *searchesHandler) RecordSearch(ctx context.Context, e *events.Event) {
+	payload, ok := e.Payload.(*products.SearchEvent)
+	if !ok {
+		return
+	}
+	if err := h.searchesUsecase.RecordSearch(ctx, payload); err != nil {
+		log.Printf("record search failed: %v\n", err)
+	}
+}
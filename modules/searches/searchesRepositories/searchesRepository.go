@@ -0,0 +1,90 @@
+package searchesRepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/searches"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type ISearchesRepository interface {
+	InsertSearch(ctx context.Context, req *products.SearchEvent) error
+	InsertClick(ctx context.Context, req *searches.Click) error
+	DeleteExpiredSearch(ctx context.Context, days int) (int64, error)
+}
+
+type searchesRepository struct {
+	db *sqlx.DB
+}
+
+func SearchesRepository(db *sqlx.DB) ISearchesRepository {
+	return &searchesRepository{
+		db: db,
+	}
+}
+
+func (r *searchesRepository) InsertSearch(ctx context.Context, req *products.SearchEvent) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "search_queries" (
+		"id",
+		"query",
+		"results",
+		"store_id"
+	)
+	VALUES ($1, $2, $3, NULLIF($4, ''));`
+
+	if _, err := r.db.ExecContext(ctx, query, req.Id, req.Query, req.Results, req.StoreId); err != nil {
+		return fmt.Errorf("insert search failed: %v", err)
+	}
+	return nil
+}
+
+func (r *searchesRepository) InsertClick(ctx context.Context, req *searches.Click) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "search_clicks" (
+		"search_id",
+		"product_id",
+		"position"
+	)
+	VALUES ($1, $2, $3);`
+
+	if _, err := r.db.ExecContext(ctx, query, req.SearchId, req.ProductId, req.Position); err != nil {
+		switch err.Error() {
+		case `ERROR: insert or update on table "search_clicks" violates foreign key constraint "search_clicks_search_id_fkey" (SQLSTATE 23503)`:
+			return fmt.Errorf("search not found")
+		default:
+			return fmt.Errorf("insert search click failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// DeleteExpiredSearch delete the searches older than days, their clicks are deleted by cascade
+func (r *searchesRepository) DeleteExpiredSearch(ctx context.Context, days int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	query := `
+	DELETE FROM "search_queries"
+	WHERE "created_at" < now() - interval '1 day' * $1;`
+
+	result, err := r.db.ExecContext(ctx, query, days)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired searches failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected failed: %v", err)
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,68 @@
+package searchesUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/searches"
+	"github.com/NatthawutSK/ri-shop/modules/searches/searchesRepositories"
+	"github.com/google/uuid"
+)
+
+type ISearchesUsecase interface {
+	RecordSearch(ctx context.Context, req *products.SearchEvent) error
+	AddClick(ctx context.Context, req *searches.Click) error
+	DeleteExpiredSearch(ctx context.Context) error
+}
+
+type searchesUsecase struct {
+	searchesRepository searchesRepositories.ISearchesRepository
+}
+
+func SearchesUsecase(searchesRepository searchesRepositories.ISearchesRepository) ISearchesUsecase {
+	return &searchesUsecase{
+		searchesRepository: searchesRepository,
+	}
+}
+
+func (u *searchesUsecase) RecordSearch(ctx context.Context, req *products.SearchEvent) error {
+	query := searches.NormalizeQuery(req.Query)
+	if query == "" {
+		return nil
+	}
+	return u.searchesRepository.InsertSearch(ctx, &products.SearchEvent{
+		Id:      req.Id,
+		StoreId: req.StoreId,
+		Query:   query,
+		Results: req.Results,
+	})
+}
+
+func (u *searchesUsecase) AddClick(ctx context.Context, req *searches.Click) error {
+	if _, err := uuid.Parse(req.SearchId); err != nil {
+		return fmt.Errorf("search not found")
+	}
+	req.ProductId = strings.TrimSpace(req.ProductId)
+	if req.ProductId == "" {
+		return fmt.Errorf("product id is required")
+	}
+	if req.Position < 0 {
+		return fmt.Errorf("position is invalid")
+	}
+	return u.searchesRepository.InsertClick(ctx, req)
+}
+
+// DeleteExpiredSearch delete the searches older than searches.RetentionDays
+func (u *searchesUsecase) DeleteExpiredSearch(ctx context.Context) error {
+	deleted, err := u.searchesRepository.DeleteExpiredSearch(ctx, searches.RetentionDays)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("search retention deleted %d searches\n", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package sellers
+
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusSuspended = "suspended"
+)
+
+// RoleId of an approved seller, customer is 1 and admin is 2
+const RoleId = 4
+
+// DefaultCommissionRate is taken from every sale when admin does not give a rate on approval
+const DefaultCommissionRate = 0.1
+
+type Seller struct {
+	UserId         string  `json:"user_id" db:"user_id"`
+	ShopName       string  `json:"shop_name" db:"shop_name"`
+	Description    string  `json:"description" db:"description"`
+	BankAccount    string  `json:"bank_account" db:"bank_account"`
+	Status         string  `json:"status" db:"status"`
+	CommissionRate float64 `json:"commission_rate" db:"commission_rate"` // 0.1 is 10% of the sale, used when no commission rule matches
+	Tier           string  `json:"tier" db:"tier"`                       // matched by commission rules
+	Note           string  `json:"note" db:"note"`                       // reason of admin, e.g. why it is rejected
+	ApprovedAt     *string `json:"approved_at" db:"approved_at"`
+	CreatedAt      string  `json:"created_at" db:"created_at"`
+	UpdatedAt      string  `json:"updated_at" db:"updated_at"`
+}
+
+// SellerApplyReq is sent by a customer who want to sell on the shop, admin approve it later
+type SellerApplyReq struct {
+	UserId      string `json:"-"`
+	ShopName    string `json:"shop_name" form:"shop_name"`
+	Description string `json:"description" form:"description"`
+	BankAccount string `json:"bank_account" form:"bank_account"`
+}
+
+// SellerReviewReq is the decision of admin, CommissionRate nil keep the current rate
+type SellerReviewReq struct {
+	UserId         string   `json:"-"`
+	Status         string   `json:"status"`
+	CommissionRate *float64 `json:"commission_rate"`
+	Tier           string   `json:"tier"` // empty keep the current tier
+	Note           string   `json:"note"`
+}
+
+type SellerFilter struct {
+	StoreId string `query:"-"`
+	Status  string `query:"status"`
+}
+
+// SellerOrderFilter is the date range of the dashboard, both are YYYY-MM-DD and optional
+type SellerOrderFilter struct {
+	UserId    string `query:"-"`
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+}
+
+// Dashboard only count orders which have been paid, amounts are in the shop currency
+type Dashboard struct {
+	Seller     *Seller `json:"seller"`
+	Orders     int     `json:"orders" db:"orders"`
+	ItemsSold  int     `json:"items_sold" db:"items_sold"`
+	Sales      float64 `json:"sales" db:"sales"`           // price * qty of the seller items
+	Commission float64 `json:"commission" db:"commission"` // kept by the shop
+	Refunded   float64 `json:"refunded" db:"refunded"`     // share of the seller in refunds of the orders
+	Revenue    float64 `json:"revenue" db:"revenue"`       // sales - commission - refunded
+}
+
+// SellerOrder is an order with only the items of the seller
+type SellerOrder struct {
+	OrderId    string             `json:"order_id"`
+	Status     string             `json:"status"`
+	CreatedAt  string             `json:"created_at"`
+	Items      []*SellerOrderItem `json:"items"`
+	Sales      float64            `json:"sales"`
+	Commission float64            `json:"commission"`
+}
+
+type SellerOrderItem struct {
+	ProductId      string   `json:"product_id"`
+	Title          string   `json:"title"`
+	Qty            int      `json:"qty"`
+	Price          float64  `json:"price"`
+	CommissionRate float64  `json:"commission_rate"`
+	Commission     *float64 `json:"commission"` // set by the commission rules when the order is completed
+}
@@ -0,0 +1,273 @@
+package sellersHandlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/sellers"
+	"github.com/NatthawutSK/ri-shop/modules/sellers/sellersUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type sellersHandlerErrCode string
+
+const (
+	applySellerErr     sellersHandlerErrCode = "sellers-001"
+	findSellerErr      sellersHandlerErrCode = "sellers-002"
+	findOneSellerErr   sellersHandlerErrCode = "sellers-003"
+	reviewSellerErr    sellersHandlerErrCode = "sellers-004"
+	findDashboardErr   sellersHandlerErrCode = "sellers-005"
+	findSellerOrderErr sellersHandlerErrCode = "sellers-006"
+)
+
+type ISellersHandler interface {
+	ApplySeller(c *fiber.Ctx) error
+	FindSeller(c *fiber.Ctx) error
+	FindOneSeller(c *fiber.Ctx) error
+	ReviewSeller(c *fiber.Ctx) error
+	FindDashboard(c *fiber.Ctx) error
+	FindSellerOrder(c *fiber.Ctx) error
+}
+
+type sellersHandler struct {
+	cfg            config.IConfig
+	sellersUsecase sellersUsecases.ISellersUsecase
+}
+
+func SellersHandler(cfg config.IConfig, sellersUsecase sellersUsecases.ISellersUsecase) ISellersHandler {
+	return &sellersHandler{
+		cfg:            cfg,
+		sellersUsecase: sellersUsecase,
+	}
+}
+
+// parseOrderFilter read the date range of the dashboard, both dates are optional
+func parseOrderFilter(c *fiber.Ctx) (*sellers.SellerOrderFilter, error) {
+	req := new(sellers.SellerOrderFilter)
+	if err := c.QueryParser(req); err != nil {
+		return nil, err
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+
+	// Date	YYYY-MM-DD
+	if req.StartDate != "" {
+		if _, err := time.Parse("2006-01-02", req.StartDate); err != nil {
+			return nil, fmt.Errorf("start date is invalid")
+		}
+	}
+	if req.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", req.EndDate); err != nil {
+			return nil, fmt.Errorf("end date is invalid")
+		}
+	}
+	if req.StartDate != "" && req.EndDate != "" && req.StartDate > req.EndDate {
+		return nil, fmt.Errorf("start date is after end date")
+	}
+	return req, nil
+}
+
+func (h *sellersHandler) ApplySeller(c *fiber.Ctx) error {
+	req := new(sellers.SellerApplyReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(applySellerErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+
+	seller, err := h.sellersUsecase.ApplySeller(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "shop name has been used", "seller has already applied":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(applySellerErr),
+				err.Error(),
+			).Res()
+		case "shop name is required", "bank account is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(applySellerErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(applySellerErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, seller).Res()
+}
+
+func (h *sellersHandler) FindSeller(c *fiber.Ctx) error {
+	req := new(sellers.SellerFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findSellerErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.StoreId = c.Locals("storeId").(string)
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	switch req.Status {
+	case "", sellers.StatusPending, sellers.StatusApproved, sellers.StatusRejected, sellers.StatusSuspended:
+	default:
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findSellerErr),
+			"status is invalid",
+		).Res()
+	}
+
+	sellersList, err := h.sellersUsecase.FindSeller(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findSellerErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, sellersList).Res()
+}
+
+func (h *sellersHandler) FindOneSeller(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+
+	seller, err := h.sellersUsecase.FindOneSeller(c.UserContext(), userId)
+	if err != nil {
+		switch err.Error() {
+		case "seller not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneSellerErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneSellerErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, seller).Res()
+}
+
+func (h *sellersHandler) ReviewSeller(c *fiber.Ctx) error {
+	req := new(sellers.SellerReviewReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(reviewSellerErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+
+	seller, err := h.sellersUsecase.ReviewSeller(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "seller not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(reviewSellerErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "seller can not be changed"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(reviewSellerErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "commission rate must be between 0 and 1",
+			err.Error() == "tier must be lowercase letters, digits and dashes":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(reviewSellerErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(reviewSellerErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, seller).Res()
+}
+
+func (h *sellersHandler) FindDashboard(c *fiber.Ctx) error {
+	req, err := parseOrderFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findDashboardErr),
+			err.Error(),
+		).Res()
+	}
+
+	dashboard, err := h.sellersUsecase.FindDashboard(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "seller not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findDashboardErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findDashboardErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, dashboard).Res()
+}
+
+func (h *sellersHandler) FindSellerOrder(c *fiber.Ctx) error {
+	req, err := parseOrderFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findSellerOrderErr),
+			err.Error(),
+		).Res()
+	}
+
+	ordersList, err := h.sellersUsecase.FindSellerOrder(c.UserContext(), req)
+	if err != nil {
+		switch err.Error() {
+		case "seller not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findSellerOrderErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findSellerOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, ordersList).Res()
+}
@@ -0,0 +1,321 @@
+package sellersRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/sellers"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type ISellersRepository interface {
+	InsertSeller(ctx context.Context, req *sellers.SellerApplyReq) error
+	FindOneSeller(ctx context.Context, userId string) (*sellers.Seller, error)
+	FindSeller(ctx context.Context, req *sellers.SellerFilter) ([]*sellers.Seller, error)
+	UpdateSellerStatus(ctx context.Context, req *sellers.SellerReviewReq) error
+	FindDashboard(ctx context.Context, req *sellers.SellerOrderFilter) (*sellers.Dashboard, error)
+	FindSellerOrder(ctx context.Context, req *sellers.SellerOrderFilter) ([]*sellers.SellerOrder, error)
+}
+
+type sellersRepository struct {
+	db *sqlx.DB
+}
+
+func SellersRepository(db *sqlx.DB) ISellersRepository {
+	return &sellersRepository{
+		db: db,
+	}
+}
+
+// sellerPaidOrders is every order in the date range ($2 - $3) which has been paid and has an item of
+// the seller ($1), an empty date is not limited
+const sellerPaidOrders = `
+	SELECT
+		"o"."id"
+	FROM "orders" "o"
+	WHERE "o"."created_at" >= COALESCE(NULLIF($2, '')::DATE, '-infinity'::DATE)
+	AND "o"."created_at" < COALESCE(NULLIF($3, '')::DATE + 1, 'infinity'::DATE)
+	AND EXISTS (
+		SELECT 1
+		FROM "products_orders" "po"
+		WHERE "po"."order_id" = "o"."id"
+		AND "po"."seller_id" = $1
+	)
+	AND (
+		"o"."status" IN ('paid', 'shipping', 'ready_for_pickup', 'completed')
+		OR EXISTS (
+			SELECT 1
+			FROM "order_status_history" "h"
+			WHERE "h"."order_id" = "o"."id"
+			AND "h"."to_status" = 'paid'
+		)
+	)`
+
+func (r *sellersRepository) InsertSeller(ctx context.Context, req *sellers.SellerApplyReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// สมัครใหม่ได้เฉพาะตอนที่ถูก reject
+	query := `
+	INSERT INTO "sellers" (
+		"user_id",
+		"shop_name",
+		"description",
+		"bank_account"
+	)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT ("user_id") DO UPDATE SET
+		"shop_name" = EXCLUDED."shop_name",
+		"description" = EXCLUDED."description",
+		"bank_account" = EXCLUDED."bank_account",
+		"status" = 'pending',
+		"note" = ''
+	WHERE "sellers"."status" = 'rejected';`
+
+	result, err := r.db.ExecContext(ctx, query, req.UserId, req.ShopName, req.Description, req.BankAccount)
+	if err != nil {
+		switch err.Error() {
+		case `ERROR: duplicate key value violates unique constraint "sellers_shop_name_key" (SQLSTATE 23505)`:
+			return fmt.Errorf("shop name has been used")
+		default:
+			return fmt.Errorf("insert seller failed: %v", err)
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("seller has already applied")
+	}
+	return nil
+}
+
+func (r *sellersRepository) FindOneSeller(ctx context.Context, userId string) (*sellers.Seller, error) {
+	query := `
+	SELECT
+		"user_id",
+		"shop_name",
+		"description",
+		"bank_account",
+		"status",
+		"commission_rate",
+		"tier",
+		"note",
+		"approved_at",
+		"created_at",
+		"updated_at"
+	FROM "sellers"
+	WHERE "user_id" = $1;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	seller := new(sellers.Seller)
+	if err := r.db.GetContext(ctx, seller, query, userId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("seller not found")
+		}
+		return nil, fmt.Errorf("get seller failed: %v", err)
+	}
+	return seller, nil
+}
+
+func (r *sellersRepository) FindSeller(ctx context.Context, req *sellers.SellerFilter) ([]*sellers.Seller, error) {
+	query := `
+	SELECT
+		"user_id",
+		"shop_name",
+		"description",
+		"bank_account",
+		"status",
+		"commission_rate",
+		"tier",
+		"note",
+		"approved_at",
+		"created_at",
+		"updated_at"
+	FROM "sellers"
+	WHERE ($1 = '' OR "status"::TEXT = $1)
+	AND "user_id" IN (
+		SELECT
+			"id"
+		FROM "users"
+		WHERE COALESCE("store_id", '') = $2
+	)
+	ORDER BY "created_at" ASC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	sellersList := make([]*sellers.Seller, 0)
+	if err := r.db.SelectContext(ctx, &sellersList, query, req.Status, req.StoreId); err != nil {
+		return nil, fmt.Errorf("find sellers failed: %v", err)
+	}
+	return sellersList, nil
+}
+
+// UpdateSellerStatus also change the role of the user, the seller must sign in again to get the new role
+func (r *sellersRepository) UpdateSellerStatus(ctx context.Context, req *sellers.SellerReviewReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE "sellers" SET
+		"status" = $2,
+		"commission_rate" = COALESCE($3, "commission_rate"),
+		"tier" = COALESCE(NULLIF($5, ''), "tier"),
+		"note" = $4,
+		"approved_at" = CASE WHEN $2 = 'approved' AND "status" <> 'approved' THEN now() ELSE "approved_at" END
+	WHERE "user_id" = $1;`
+
+	if _, err := tx.ExecContext(ctx, query, req.UserId, req.Status, req.CommissionRate, req.Note, req.Tier); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update seller failed: %v", err)
+	}
+
+	// admin ที่สมัครเป็น seller ยังเป็น admin
+	roleId := 1
+	if req.Status == sellers.StatusApproved {
+		roleId = sellers.RoleId
+	}
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "users" SET
+		"role_id" = $2
+	WHERE "id" = $1
+	AND "role_id" <> 2;`, req.UserId, roleId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update user role failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *sellersRepository) FindDashboard(ctx context.Context, req *sellers.SellerOrderFilter) (*sellers.Dashboard, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	// refund ของ order แบ่งให้ seller ตามสัดส่วนยอดขายของ seller ใน order นั้น
+	// commission ของ order ที่ยังไม่ completed เป็นค่าประมาณจาก rate ตอนขาย
+	query := fmt.Sprintf(`
+	WITH "paid" AS (%s), "t" AS (
+		SELECT
+			"p"."id",
+			SUM("po"."qty") AS "qty",
+			SUM(("po"."product"->>'price')::FLOAT * "po"."qty") AS "sales",
+			SUM(COALESCE("po"."commission", ("po"."product"->>'price')::FLOAT * "po"."qty" * "po"."commission_rate")) AS "commission",
+			(
+				SELECT
+					COALESCE(SUM(("a"."product"->>'price')::FLOAT * "a"."qty"), 0)
+				FROM "products_orders" "a"
+				WHERE "a"."order_id" = "p"."id"
+			) AS "order_total",
+			(
+				SELECT
+					COALESCE(SUM("r"."amount"), 0)
+				FROM "refunds" "r"
+				WHERE "r"."order_id" = "p"."id"
+				AND "r"."status" != 'failed'
+			) AS "refunded"
+		FROM "paid" "p"
+		JOIN "products_orders" "po" ON "po"."order_id" = "p"."id" AND "po"."seller_id" = $1
+		GROUP BY "p"."id"
+	)
+	SELECT
+		COUNT(*) AS "orders",
+		COALESCE(SUM("t"."qty"), 0) AS "items_sold",
+		ROUND(COALESCE(SUM("t"."sales"), 0)::NUMERIC, 2)::FLOAT AS "sales",
+		ROUND(COALESCE(SUM("t"."commission"), 0)::NUMERIC, 2)::FLOAT AS "commission",
+		ROUND(COALESCE(SUM(
+			CASE WHEN "t"."order_total" > 0
+				THEN LEAST("t"."refunded", "t"."order_total") * "t"."sales" / "t"."order_total"
+				ELSE 0
+			END
+		), 0)::NUMERIC, 2)::FLOAT AS "refunded"
+	FROM "t";`, sellerPaidOrders)
+
+	dashboard := new(sellers.Dashboard)
+	if err := r.db.GetContext(ctx, dashboard, query, req.UserId, req.StartDate, req.EndDate); err != nil {
+		return nil, fmt.Errorf("find seller dashboard failed: %v", err)
+	}
+	return dashboard, nil
+}
+
+func (r *sellersRepository) FindSellerOrder(ctx context.Context, req *sellers.SellerOrderFilter) ([]*sellers.SellerOrder, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	// seller เห็นทุก order ที่มีสินค้าของตัวเอง รวมที่ยังไม่จ่าย แต่เห็นเฉพาะ item ของตัวเอง
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"o"."id" AS "order_id",
+			"o"."status",
+			"o"."created_at",
+			(
+				SELECT
+					array_to_json(array_agg("it"))
+				FROM (
+					SELECT
+						"po"."product"->>'id' AS "product_id",
+						"po"."product"->>'title' AS "title",
+						"po"."qty",
+						("po"."product"->>'price')::FLOAT AS "price",
+						"po"."commission_rate",
+						"po"."commission"
+					FROM "products_orders" "po"
+					WHERE "po"."order_id" = "o"."id"
+					AND "po"."seller_id" = $1
+				) AS "it"
+			) AS "items",
+			(
+				SELECT
+					ROUND(SUM(("po"."product"->>'price')::FLOAT * "po"."qty")::NUMERIC, 2)::FLOAT
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "o"."id"
+				AND "po"."seller_id" = $1
+			) AS "sales",
+			(
+				SELECT
+					ROUND(SUM(COALESCE("po"."commission", ("po"."product"->>'price')::FLOAT * "po"."qty" * "po"."commission_rate"))::NUMERIC, 2)::FLOAT
+				FROM "products_orders" "po"
+				WHERE "po"."order_id" = "o"."id"
+				AND "po"."seller_id" = $1
+			) AS "commission"
+		FROM "orders" "o"
+		WHERE "o"."created_at" >= COALESCE(NULLIF($2, '')::DATE, '-infinity'::DATE)
+		AND "o"."created_at" < COALESCE(NULLIF($3, '')::DATE + 1, 'infinity'::DATE)
+		AND EXISTS (
+			SELECT 1
+			FROM "products_orders" "po"
+			WHERE "po"."order_id" = "o"."id"
+			AND "po"."seller_id" = $1
+		)
+		ORDER BY "o"."created_at" DESC
+	) AS "t";`
+
+	raw := make([]byte, 0)
+	if err := r.db.GetContext(ctx, &raw, query, req.UserId, req.StartDate, req.EndDate); err != nil {
+		return nil, fmt.Errorf("find seller orders failed: %v", err)
+	}
+
+	ordersList := make([]*sellers.SellerOrder, 0)
+	if err := json.Unmarshal(raw, &ordersList); err != nil {
+		return nil, fmt.Errorf("unmarshal seller orders failed: %v", err)
+	}
+	return ordersList, nil
+}
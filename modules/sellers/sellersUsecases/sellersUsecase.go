@@ -0,0 +1,124 @@
+package sellersUsecases
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/sellers"
+	"github.com/NatthawutSK/ri-shop/modules/sellers/sellersRepositories"
+)
+
+// tierPattern is the seller tier matched by commission rules, e.g. standard, gold
+var tierPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// reviewTransitions is the status admin can move a seller to from its current status
+var reviewTransitions = map[string][]string{
+	sellers.StatusPending:   {sellers.StatusApproved, sellers.StatusRejected},
+	sellers.StatusApproved:  {sellers.StatusApproved, sellers.StatusSuspended},
+	sellers.StatusSuspended: {sellers.StatusApproved},
+	sellers.StatusRejected:  {},
+}
+
+type ISellersUsecase interface {
+	ApplySeller(ctx context.Context, req *sellers.SellerApplyReq) (*sellers.Seller, error)
+	FindOneSeller(ctx context.Context, userId string) (*sellers.Seller, error)
+	FindSeller(ctx context.Context, req *sellers.SellerFilter) ([]*sellers.Seller, error)
+	ReviewSeller(ctx context.Context, req *sellers.SellerReviewReq) (*sellers.Seller, error)
+	FindDashboard(ctx context.Context, req *sellers.SellerOrderFilter) (*sellers.Dashboard, error)
+	FindSellerOrder(ctx context.Context, req *sellers.SellerOrderFilter) ([]*sellers.SellerOrder, error)
+}
+
+type sellersUsecase struct {
+	sellersRepository sellersRepositories.ISellersRepository
+}
+
+func SellersUsecase(sellersRepository sellersRepositories.ISellersRepository) ISellersUsecase {
+	return &sellersUsecase{
+		sellersRepository: sellersRepository,
+	}
+}
+
+func (u *sellersUsecase) ApplySeller(ctx context.Context, req *sellers.SellerApplyReq) (*sellers.Seller, error) {
+	req.ShopName = strings.TrimSpace(req.ShopName)
+	if req.ShopName == "" {
+		return nil, fmt.Errorf("shop name is required")
+	}
+	req.BankAccount = strings.TrimSpace(req.BankAccount)
+	if req.BankAccount == "" {
+		return nil, fmt.Errorf("bank account is required")
+	}
+	req.Description = strings.TrimSpace(req.Description)
+
+	if err := u.sellersRepository.InsertSeller(ctx, req); err != nil {
+		return nil, err
+	}
+	return u.sellersRepository.FindOneSeller(ctx, req.UserId)
+}
+
+func (u *sellersUsecase) FindOneSeller(ctx context.Context, userId string) (*sellers.Seller, error) {
+	return u.sellersRepository.FindOneSeller(ctx, userId)
+}
+
+func (u *sellersUsecase) FindSeller(ctx context.Context, req *sellers.SellerFilter) ([]*sellers.Seller, error) {
+	return u.sellersRepository.FindSeller(ctx, req)
+}
+
+func (u *sellersUsecase) ReviewSeller(ctx context.Context, req *sellers.SellerReviewReq) (*sellers.Seller, error) {
+	seller, err := u.sellersRepository.FindOneSeller(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if req.Status == "" {
+		req.Status = seller.Status
+	}
+	allowed := false
+	for _, status := range reviewTransitions[seller.Status] {
+		if status == req.Status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("seller can not be changed from %s to %s", seller.Status, req.Status)
+	}
+
+	if req.CommissionRate != nil && (*req.CommissionRate < 0 || *req.CommissionRate > 1) {
+		return nil, fmt.Errorf("commission rate must be between 0 and 1")
+	}
+	req.Tier = strings.ToLower(strings.TrimSpace(req.Tier))
+	if req.Tier != "" && !tierPattern.MatchString(req.Tier) {
+		return nil, fmt.Errorf("tier must be lowercase letters, digits and dashes")
+	}
+	req.Note = strings.TrimSpace(req.Note)
+
+	if err := u.sellersRepository.UpdateSellerStatus(ctx, req); err != nil {
+		return nil, err
+	}
+	return u.sellersRepository.FindOneSeller(ctx, req.UserId)
+}
+
+func (u *sellersUsecase) FindDashboard(ctx context.Context, req *sellers.SellerOrderFilter) (*sellers.Dashboard, error) {
+	seller, err := u.sellersRepository.FindOneSeller(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard, err := u.sellersRepository.FindDashboard(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	dashboard.Seller = seller
+	dashboard.Revenue = math.Round((dashboard.Sales-dashboard.Commission-dashboard.Refunded)*100) / 100
+	return dashboard, nil
+}
+
+func (u *sellersUsecase) FindSellerOrder(ctx context.Context, req *sellers.SellerOrderFilter) ([]*sellers.SellerOrder, error) {
+	if _, err := u.sellersRepository.FindOneSeller(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+	return u.sellersRepository.FindSellerOrder(ctx, req)
+}
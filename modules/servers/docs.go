@@ -0,0 +1,220 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/users"
+	"github.com/NatthawutSK/ri-shop/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	docsPath = "/docs"
+	docsErr  = "servers-002"
+
+	bearerAuth = "bearerAuth" // JwtAuth, the access token of /v1/users/signin
+	apiKeyAuth = "apiKeyAuth" // ApiKeyAuth, the key of /v1/appinfo/apikey
+)
+
+// pages of the documented lists, Data of entities.PaginateRes and entities.PageRes is any
+type (
+	productPage struct {
+		*entities.PaginateRes
+		Data []*products.Products `json:"data"`
+	}
+	orderPage struct {
+		*entities.PaginateRes
+		Data []*orders.Order `json:"data"`
+	}
+	productPageV2 struct {
+		*entities.PageRes
+		Data []*products.Products `json:"data"`
+	}
+)
+
+type tokenRes struct {
+	Token string `json:"token"`
+}
+
+type importUsersQuery struct {
+	DryRun bool `query:"dry_run"`
+}
+
+// productQuery add the query of FindProduct which is not read by the QueryParser
+type productQuery struct {
+	*products.ProductFilter
+	Ids string `query:"ids"` // comma separated, e.g. P000001,P000002
+}
+
+type currencyQuery struct {
+	Currency string `query:"currency"`
+}
+
+// docRoutes are documented with their bodies and responses, the other routes of the server are listed with
+// their path only. A path without a version is used by every version which has the route, a path with a
+// version, e.g. /v2/products, is only used by that version. A route which is added to products, files, users
+// or orders should be added here too
+var docRoutes = []*openapi.Route{
+	// users
+	{Method: "POST", Path: "/users/signup", Tag: "users", Id: "SignUpCustomer", Summary: "sign up a customer", Security: []string{apiKeyAuth}, Body: &users.UserRegisterReq{}, Status: 201, Response: &users.UserPassport{}},
+	{Method: "POST", Path: "/users/signin", Tag: "users", Id: "SignIn", Summary: "sign in with email and password", Body: &users.UserCredential{}, Response: &users.UserPassport{}},
+	{Method: "POST", Path: "/users/refresh", Tag: "users", Id: "RefreshPassport", Summary: "get new tokens with the refresh token", Security: []string{apiKeyAuth}, Body: &users.UserRefreshCredential{}, Response: &users.UserPassport{}},
+	{Method: "POST", Path: "/users/signout", Tag: "users", Id: "SignOut", Summary: "remove the tokens of a sign in", Security: []string{apiKeyAuth}, Body: &users.UserRemoveCredential{}},
+	{Method: "POST", Path: "/users/signup-admin", Tag: "users", Id: "SignUpAdmin", Summary: "sign up an admin", Security: []string{bearerAuth}, Body: &users.UserRegisterReq{}, Status: 201, Response: &users.UserPassport{}},
+	{Method: "POST", Path: "/users/import", Tag: "users", Id: "ImportUsers", Summary: "import a csv of users, dry_run only validate it", Security: []string{bearerAuth}, Query: &importUsersQuery{}, Form: map[string]*openapi.Schema{"file": openapi.File}, Response: &users.UserImportReport{}},
+	{Method: "GET", Path: "/users/admin/secret", Tag: "users", Id: "GenerateAdminToken", Summary: "generate the token which sign up an admin", Security: []string{bearerAuth}, Response: &tokenRes{}},
+	{Method: "GET", Path: "/users/admin/locked", Tag: "users", Id: "FindLockedLogins", Summary: "emails with failed sign ins", Security: []string{bearerAuth}, Response: []*users.LoginAttempt{}},
+	{Method: "POST", Path: "/users/admin/unlock", Tag: "users", Id: "UnlockLogin", Summary: "unlock an email", Security: []string{bearerAuth}, Body: &users.UserUnlockReq{}},
+	{Method: "GET", Path: "/users/:user_id", Tag: "users", Id: "GetUserProfile", Summary: "profile of the signed in user", Security: []string{bearerAuth}, Response: &users.User{}},
+	{Method: "PATCH", Path: "/users/:user_id/locale", Tag: "users", Id: "UpdateLocale", Summary: "preferred language of the messages", Security: []string{bearerAuth}, Body: &users.UserLocaleReq{}, Response: &users.UserLocaleReq{}},
+
+	// products
+	{Method: "GET", Path: "/products", Tag: "products", Id: "FindProduct", Summary: "search the products, ids return those products in one page", Security: []string{apiKeyAuth}, Query: &productQuery{}, Response: &productPage{}},
+	{Method: "GET", Path: "/products/admin", Tag: "products", Id: "FindProductAdmin", Summary: "search the products of every status", Security: []string{bearerAuth}, Query: &productQuery{}, Response: &productPage{}},
+	{Method: "GET", Path: "/v2/products", Tag: "products", Id: "FindProduct", Summary: "search the products, ids return those products in one page", Security: []string{apiKeyAuth}, Query: &productQuery{}, Response: &productPageV2{}},
+	{Method: "GET", Path: "/v2/products/admin", Tag: "products", Id: "FindProductAdmin", Summary: "search the products of every status", Security: []string{bearerAuth}, Query: &productQuery{}, Response: &productPageV2{}},
+	{Method: "GET", Path: "/products/:productId", Tag: "products", Id: "FindOneProduct", Summary: "a product", Security: []string{apiKeyAuth}, Query: &currencyQuery{}, Response: &products.Products{}},
+	{Method: "GET", Path: "/products/:productId/availability", Tag: "products", Id: "FindAvailability", Summary: "stock and price of a product", Security: []string{apiKeyAuth}, Query: &currencyQuery{}, Response: &products.Availability{}},
+	{Method: "POST", Path: "/products/search-by-image", Tag: "products", Id: "SearchByImage", Summary: "products which look like the image", Security: []string{apiKeyAuth}, Query: &products.ImageSearchReq{}, Form: map[string]*openapi.Schema{"file": openapi.File}, Response: []*products.SimilarProduct{}},
+	{Method: "POST", Path: "/products", Tag: "products", Id: "AddProduct", Summary: "add a product", Security: []string{bearerAuth}, Body: &products.Products{}, Status: 201, Response: &products.Products{}},
+	{Method: "PATCH", Path: "/products/bulk", Tag: "products", Id: "BulkUpdateProduct", Summary: "change the price and stock of many products", Security: []string{bearerAuth}, Body: &products.BulkUpdateReq{}, Response: &products.BulkUpdateRes{}},
+	{Method: "PATCH", Path: "/products/:productId", Tag: "products", Id: "UpdateProduct", Summary: "update a product, the response has what changed", Security: []string{bearerAuth}, Body: &products.Products{}, Response: &products.ProductUpdateRes{}},
+	{Method: "PUT", Path: "/products/:productId/prices", Tag: "products", Id: "UpdateProductPrices", Summary: "prices of a product by currency", Security: []string{bearerAuth}, Body: []*products.ProductPrice{}, Response: &products.Products{}},
+	{Method: "PUT", Path: "/products/:productId/regions", Tag: "products", Id: "UpdateProductRegions", Summary: "where a product ships to", Security: []string{bearerAuth}, Body: []*products.ProductRegion{}, Response: &products.Products{}},
+	{Method: "PUT", Path: "/products/:productId/attributes", Tag: "products", Id: "UpdateProductAttributes", Summary: "attributes of a product", Security: []string{bearerAuth}, Body: map[string]string{}, Response: &products.Products{}},
+	{Method: "PUT", Path: "/products/:productId/images/order", Tag: "products", Id: "UpdateImageOrder", Summary: "order of the images", Security: []string{bearerAuth}, Body: &products.ImageOrderReq{}, Response: &products.Products{}},
+	{Method: "PATCH", Path: "/products/:productId/images/:imageId/primary", Tag: "products", Id: "UpdatePrimaryImage", Summary: "set the cover image", Security: []string{bearerAuth}, Response: &products.Products{}},
+	{Method: "POST", Path: "/products/:productId/images", Tag: "products", Id: "AddProductImage", Summary: "add an image uploaded with a signed policy", Security: []string{bearerAuth}, Body: &files.ConfirmUploadReq{}, Status: 201, Response: &products.Products{}},
+	{Method: "DELETE", Path: "/products/:productId", Tag: "products", Id: "DeleteProduct", Summary: "delete a product", Security: []string{bearerAuth}, Status: 204},
+
+	// files
+	{Method: "POST", Path: "/files/upload", Tag: "files", Id: "UploadFiles", Summary: "upload png or jpg images", Security: []string{bearerAuth}, Form: map[string]*openapi.Schema{"files": openapi.Files, "destination": {Type: "string"}}, Status: 201, Response: []*files.FileRes{}},
+	{Method: "PATCH", Path: "/files/delete", Tag: "files", Id: "DeleteFile", Summary: "delete files", Security: []string{bearerAuth}, Body: []*files.DeleteFileReq{}},
+	{Method: "POST", Path: "/files/signed-upload", Tag: "files", Id: "SignUpload", Summary: "a policy to upload a file to the bucket from the browser", Security: []string{bearerAuth}, Body: &files.SignedUploadReq{}, Status: 201, Response: &files.SignedUploadRes{}},
+	{Method: "POST", Path: "/files/confirm", Tag: "files", Id: "ConfirmUpload", Summary: "check a file uploaded with a signed policy", Security: []string{bearerAuth}, Body: &files.ConfirmUploadReq{}, Status: 201, Response: &files.FileRes{}},
+	{Method: "GET", Path: "/files/images/*", Tag: "files", Id: "ServeImage", Summary: "redirect to the webp or avif of an image when the Accept header allow it", Status: 302},
+
+	// orders
+	{Method: "POST", Path: "/orders", Tag: "orders", Id: "InsertOrder", Summary: "place an order", Security: []string{bearerAuth}, Body: &orders.Order{}, Status: 201, Response: &orders.Order{}},
+	{Method: "GET", Path: "/orders", Tag: "orders", Id: "FindOrder", Summary: "search the orders", Security: []string{bearerAuth}, Query: &orders.OrderFilter{}, Response: &orderPage{}},
+	{Method: "GET", Path: "/orders/ws", Tag: "orders", Id: "OrderSocket", Summary: "websocket of the order events, the token is sent with ?token=", Status: 101},
+	{Method: "GET", Path: "/orders/gift/:token", Tag: "orders", Id: "FindGiftTracking", Summary: "tracking of a gift for its recipient", Security: []string{apiKeyAuth}, Response: &orders.GiftTracking{}},
+	{Method: "GET", Path: "/orders/:user_id/:order_id", Tag: "orders", Id: "FindOneOrder", Summary: "an order", Security: []string{bearerAuth}, Response: &orders.Order{}},
+	{Method: "PATCH", Path: "/orders/:user_id/:order_id", Tag: "orders", Id: "UpdateOrder", Summary: "change the status of an order", Security: []string{bearerAuth}, Body: &orders.OrderUpdate{}, Response: &orders.Order{}},
+	{Method: "GET", Path: "/orders/:user_id/:order_id/packing-slip", Tag: "orders", Id: "FindPackingSlip", Summary: "packing slip of an order", Security: []string{bearerAuth}, Response: &orders.PackingSlip{}},
+	{Method: "POST", Path: "/orders/:user_id/:order_id/pickup", Tag: "orders", Id: "ConfirmPickup", Summary: "confirm the pickup of an order", Security: []string{bearerAuth}, Body: &orders.PickupConfirmReq{}, Response: &orders.Order{}},
+}
+
+// docs serve the OpenAPI document and Swagger UI of each api version, a document is built on its first request
+// because every module has registered its routes by then
+type docs struct {
+	mu    sync.Mutex
+	specs map[string][]byte // by version
+	build func(version *apiVersion) *openapi.Document
+}
+
+func newDocs(build func(version *apiVersion) *openapi.Document) *docs {
+	return &docs{
+		specs: make(map[string][]byte),
+		build: build,
+	}
+}
+
+func (d *docs) spec(version *apiVersion) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if spec, ok := d.specs[version.name]; ok {
+		return spec, nil
+	}
+	spec, err := json.Marshal(d.build(version))
+	if err != nil {
+		return nil, err
+	}
+	d.specs[version.name] = spec
+	return spec, nil
+}
+
+// Spec is mounted on v1 and inherited by the next versions, the version is read from the path
+func (d *docs) Spec(c *fiber.Ctx) error {
+	spec, err := d.spec(findApiVersion(c.Path()))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			docsErr,
+			err.Error(),
+		).Res()
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(spec)
+}
+
+func (d *docs) Ui(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(fmt.Sprintf(swaggerUi, findApiVersion(c.Path()).prefix()+docsPath+"/openapi.json"))
+}
+
+// swaggerUi load Swagger UI from a CDN, so nothing is vendored into the server
+const swaggerUi = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>ri-shop API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: "%s", dom_id: "#swagger-ui" });
+	</script>
+</body>
+</html>`
+
+// openApi list every route of the version, the ones in docRoutes with their schemas
+func (s *server) openApi(version *apiVersion) *openapi.Document {
+	doc := openapi.New(s.cfg.App().Name(), version.name, &entities.ErrorResponse{})
+	doc.AddSecurity(bearerAuth, &openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
+	doc.AddSecurity(apiKeyAuth, &openapi.SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-KEY"})
+
+	documented := make(map[string]*openapi.Route, len(docRoutes))
+	for _, route := range docRoutes {
+		documented[route.Method+" "+route.Path] = route
+	}
+
+	for _, route := range s.app.GetRoutes(true) {
+		// HEAD is added by fiber for every GET
+		if route.Method == fiber.MethodHead || findApiVersion(route.Path) != version {
+			continue
+		}
+		// a route of "/" in a group is /v1/products/
+		path := strings.TrimSuffix(strings.TrimPrefix(route.Path, version.prefix()), "/")
+		if path == "" || strings.HasPrefix(path, docsPath) {
+			continue
+		}
+
+		res := &openapi.Route{
+			Method: route.Method,
+			Tag:    strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0],
+		}
+		documentedRoute, ok := documented[route.Method+" "+version.prefix()+path]
+		if !ok {
+			documentedRoute, ok = documented[route.Method+" "+path]
+		}
+		if ok {
+			copied := *documentedRoute
+			res = &copied
+		}
+		res.Path = version.prefix() + path
+		res.Deprecated = version.deprecated()
+		doc.Add(res)
+	}
+	return doc
+}
@@ -0,0 +1,221 @@
+package servers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// a comment line is sent when nothing happened, so proxies do not close an idle stream
+	feedHeartbeat = 15 * time.Second
+	// messages waiting for a slow client, newer messages are dropped when it is full
+	feedBuffer = 64
+	// milliseconds the EventSource of the browser wait before it reconnect
+	feedRetry = 3000
+)
+
+// Types of the admin feed, the client choose them with ?types=order,payment
+const (
+	FeedOrder   = "order"
+	FeedPayment = "payment"
+	FeedStock   = "stock"
+)
+
+const feedInvalidFilterErr = "servers-001"
+
+type feedMessage struct {
+	Id        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	Event     string    `json:"event"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// feedFilter is chosen per connection, an empty filter receive everything
+type feedFilter struct {
+	types    map[string]bool
+	minTotal float64 // only for orders and payments
+}
+
+type feedClient struct {
+	filter   *feedFilter
+	messages chan *feedMessage
+}
+
+// feed stream new orders, payments and stock alerts from pkg/events to the admin dashboard as server-sent events
+type feed struct {
+	mu      sync.RWMutex
+	clients map[*feedClient]bool
+	lastId  uint64
+	done    chan struct{}
+}
+
+func newFeed() *feed {
+	f := &feed{
+		clients: make(map[*feedClient]bool),
+		done:    make(chan struct{}),
+	}
+
+	events.Subscribe(orders.EventOrderCreated, func(ctx context.Context, e *events.Event) {
+		f.broadcast(FeedOrder, e)
+	})
+	events.Subscribe(orders.EventOrderStatusChanged, func(ctx context.Context, e *events.Event) {
+		if payload, ok := e.Payload.(*orders.OrderEvent); ok && payload.Status == orders.StatusPaid {
+			f.broadcast(FeedPayment, e)
+		}
+	})
+	// a duplicate charge need an admin to review or watch the automatic refund
+	events.Subscribe(webhooks.EventDuplicateCharge, func(ctx context.Context, e *events.Event) {
+		f.broadcast(FeedPayment, e)
+	})
+	events.Subscribe(inventory.EventStockAlert, func(ctx context.Context, e *events.Event) {
+		f.broadcast(FeedStock, e)
+	})
+	events.Subscribe(inventory.EventLowStock, func(ctx context.Context, e *events.Event) {
+		f.broadcast(FeedStock, e)
+	})
+	return f
+}
+
+func (f *feed) broadcast(feedType string, e *events.Event) {
+	msg := &feedMessage{
+		Id:        atomic.AddUint64(&f.lastId, 1),
+		Type:      feedType,
+		Event:     e.Name,
+		Data:      e.Payload,
+		CreatedAt: e.CreatedAt,
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for client := range f.clients {
+		if !client.filter.match(msg) {
+			continue
+		}
+		select {
+		case client.messages <- msg:
+		default:
+			log.Printf("admin feed client is too slow, message %d is dropped\n", msg.Id)
+		}
+	}
+}
+
+func (ft *feedFilter) match(msg *feedMessage) bool {
+	if len(ft.types) != 0 && !ft.types[msg.Type] {
+		return false
+	}
+	if payload, ok := msg.Data.(*orders.OrderEvent); ok && ft.minTotal > 0 && payload.TotalPaid < ft.minTotal {
+		return false
+	}
+	return true
+}
+
+func parseFeedFilter(c *fiber.Ctx) (*feedFilter, error) {
+	filter := &feedFilter{
+		types: make(map[string]bool),
+	}
+
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		switch t {
+		case "":
+		case FeedOrder, FeedPayment, FeedStock:
+			filter.types[t] = true
+		default:
+			return nil, fmt.Errorf("type %s is invalid, must be one of %s, %s, %s", t, FeedOrder, FeedPayment, FeedStock)
+		}
+	}
+
+	if c.Query("min_total") != "" {
+		filter.minTotal = c.QueryFloat("min_total", -1)
+		if filter.minTotal < 0 {
+			return nil, fmt.Errorf("min_total must be a positive number")
+		}
+	}
+	return filter, nil
+}
+
+func (f *feed) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		filter, err := parseFeedFilter(c)
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				feedInvalidFilterErr,
+				err.Error(),
+			).Res()
+		}
+
+		client := &feedClient{
+			filter:   filter,
+			messages: make(chan *feedMessage, feedBuffer),
+		}
+		f.mu.Lock()
+		f.clients[client] = true
+		f.mu.Unlock()
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		// WriteTimeout of the server is for the whole response, the stream extend it on every write instead
+		conn := c.Context().Conn()
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer f.remove(client)
+
+			heartbeat := time.NewTicker(feedHeartbeat)
+			defer heartbeat.Stop()
+
+			fmt.Fprintf(w, "retry: %d\n\n", feedRetry)
+			for {
+				conn.SetWriteDeadline(time.Now().Add(2 * feedHeartbeat))
+				if err := w.Flush(); err != nil {
+					// the dashboard is closed
+					return
+				}
+
+				select {
+				case <-f.done:
+					return
+				case <-heartbeat.C:
+					fmt.Fprintf(w, ": heartbeat %s\n\n", time.Now().Format(time.RFC3339))
+				case msg := <-client.messages:
+					data, err := json.Marshal(msg)
+					if err != nil {
+						log.Printf("marshal feed message failed: %v\n", err)
+						continue
+					}
+					fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.Id, msg.Type, data)
+				}
+			}
+		})
+		return nil
+	}
+}
+
+func (f *feed) remove(client *feedClient) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.clients, client)
+}
+
+// Close end every stream, the server can not shut down while a stream is open
+func (f *feed) Close() {
+	close(f.done)
+}
@@ -0,0 +1,53 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/addresses/addressesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/addresses/addressesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/addresses/addressesUsecases"
+)
+
+type IAddressesModule interface {
+	Init()
+	Repository() addressesRepositories.IAddressesRepository
+	Usecase() addressesUsecases.IAddressesUsecase
+	Handler() addressesHandlers.IAddressesHandler
+}
+
+type addressesModule struct {
+	*moduleFactory
+	repository addressesRepositories.IAddressesRepository
+	usecase    addressesUsecases.IAddressesUsecase
+	handler    addressesHandlers.IAddressesHandler
+}
+
+func (m *moduleFactory) AddressesModule() IAddressesModule {
+	repository := addressesRepositories.AddressesRepository(m.s.db)
+	usecase := addressesUsecases.AddressesUsecase(repository)
+	handler := addressesHandlers.AddressesHandler(m.s.cfg, usecase)
+
+	return &addressesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (a *addressesModule) Init() {
+	router := a.r.Group("/users/:user_id/addresses")
+
+	router.Get("/", a.mid.JwtAuth(), a.mid.ParamsCheck(), a.handler.FindAddress)
+	router.Post("/", a.mid.JwtAuth(), a.mid.ParamsCheck(), a.handler.AddAddress)
+	router.Patch("/:address_id", a.mid.JwtAuth(), a.mid.ParamsCheck(), a.handler.UpdateAddress)
+	router.Delete("/:address_id", a.mid.JwtAuth(), a.mid.ParamsCheck(), a.handler.DeleteAddress)
+}
+
+func (a *addressesModule) Repository() addressesRepositories.IAddressesRepository {
+	return a.repository
+}
+func (a *addressesModule) Usecase() addressesUsecases.IAddressesUsecase {
+	return a.usecase
+}
+func (a *addressesModule) Handler() addressesHandlers.IAddressesHandler {
+	return a.handler
+}
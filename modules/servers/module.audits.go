@@ -0,0 +1,44 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
+)
+
+type IAuditsModule interface {
+	Init()
+	Repository() auditsRepositories.IAuditsRepository
+	Usecase() auditsUsecases.IAuditsUsecase
+	Handler() auditsHandlers.IAuditsHandler
+}
+
+type auditsModule struct {
+	*moduleFactory
+	repository auditsRepositories.IAuditsRepository
+	usecase    auditsUsecases.IAuditsUsecase
+	handler    auditsHandlers.IAuditsHandler
+}
+
+func (m *moduleFactory) AuditsModule() IAuditsModule {
+	repository := auditsRepositories.AuditsRepository(m.s.db)
+	usecase := auditsUsecases.AuditsUsecase(repository)
+	handler := auditsHandlers.AuditsHandler(m.s.cfg, usecase)
+
+	return &auditsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (a *auditsModule) Init() {
+	router := a.r.Group("/audits")
+
+	router.Get("/", a.mid.JwtAuth(), a.mid.Authorize(2), a.handler.FindAudit)
+}
+
+func (a *auditsModule) Repository() auditsRepositories.IAuditsRepository { return a.repository }
+func (a *auditsModule) Usecase() auditsUsecases.IAuditsUsecase           { return a.usecase }
+func (a *auditsModule) Handler() auditsHandlers.IAuditsHandler           { return a.handler }
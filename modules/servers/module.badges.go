@@ -0,0 +1,54 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/badges/badgesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/badges/badgesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/badges/badgesUsecases"
+)
+
+type IBadgesModule interface {
+	Init()
+	Repository() badgesRepositories.IBadgesRepository
+	Usecase() badgesUsecases.IBadgesUsecase
+	Handler() badgesHandlers.IBadgesHandler
+}
+
+type badgesModule struct {
+	*moduleFactory
+	repository badgesRepositories.IBadgesRepository
+	usecase    badgesUsecases.IBadgesUsecase
+	handler    badgesHandlers.IBadgesHandler
+}
+
+func (m *moduleFactory) BadgesModule() IBadgesModule {
+	repository := badgesRepositories.BadgesRepository(m.s.db)
+	usecase := badgesUsecases.BadgesUsecase(repository)
+	handler := badgesHandlers.BadgesHandler(m.s.cfg, usecase)
+
+	return &badgesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (b *badgesModule) Init() {
+	router := b.r.Group("/badges")
+
+	router.Get("/", b.mid.ApiKeyAuth(), b.handler.FindBadge)
+	router.Post("/", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.AddBadge)
+	router.Delete("/:badgeId", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.DeleteBadge)
+	router.Post("/:badgeId/products", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.AssignBadge)
+	router.Delete("/:badgeId/products/:productId", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.UnassignBadge)
+}
+
+func (b *badgesModule) Repository() badgesRepositories.IBadgesRepository {
+	return b.repository
+}
+func (b *badgesModule) Usecase() badgesUsecases.IBadgesUsecase {
+	return b.usecase
+}
+func (b *badgesModule) Handler() badgesHandlers.IBadgesHandler {
+	return b.handler
+}
@@ -0,0 +1,50 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/carts/cartsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/carts/cartsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/carts/cartsUsecases"
+)
+
+type ICartsModule interface {
+	Init()
+	Repository() cartsRepositories.ICartsRepository
+	Usecase() cartsUsecases.ICartsUsecase
+	Handler() cartsHandlers.ICartsHandler
+}
+
+type cartsModule struct {
+	*moduleFactory
+	repository cartsRepositories.ICartsRepository
+	usecase    cartsUsecases.ICartsUsecase
+	handler    cartsHandlers.ICartsHandler
+}
+
+func (m *moduleFactory) CartsModule() ICartsModule {
+	repository := cartsRepositories.CartsRepository(m.s.db)
+	usecase := cartsUsecases.CartsUsecase(m.s.cfg, repository, m.ShippingModule().Usecase(), m.TaxesModule().Usecase(), m.s.clock)
+	handler := cartsHandlers.CartsHandler(m.s.cfg, usecase)
+
+	return &cartsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (c *cartsModule) Init() {
+	router := c.r.Group("/cart")
+
+	router.Post("/quote", c.mid.ApiKeyAuth(), c.mid.Preview(), c.handler.Quote)
+}
+
+func (c *cartsModule) Repository() cartsRepositories.ICartsRepository {
+	return c.repository
+}
+func (c *cartsModule) Usecase() cartsUsecases.ICartsUsecase {
+	return c.usecase
+}
+func (c *cartsModule) Handler() cartsHandlers.ICartsHandler {
+	return c.handler
+}
@@ -0,0 +1,36 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/catalog/catalogHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/catalog/catalogpb"
+)
+
+// ICatalogModule is the internal grpc api, it is registered on the grpc server instead of the fiber router
+type ICatalogModule interface {
+	Init()
+	Handler() catalogHandlers.ICatalogHandler
+}
+
+type catalogModule struct {
+	*moduleFactory
+	handler catalogHandlers.ICatalogHandler
+}
+
+func (m *moduleFactory) CatalogModule() ICatalogModule {
+	handler := catalogHandlers.CatalogHandler(
+		m.s.cfg,
+		m.ProductsModule().Usecase(),
+		m.InventoryModule().Usecase(),
+	)
+
+	return &catalogModule{
+		moduleFactory: m,
+		handler:       handler,
+	}
+}
+
+func (c *catalogModule) Init() {
+	catalogpb.RegisterCatalogServiceServer(c.s.grpc, c.handler)
+}
+
+func (c *catalogModule) Handler() catalogHandlers.ICatalogHandler { return c.handler }
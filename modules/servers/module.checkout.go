@@ -0,0 +1,43 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/checkout/checkoutHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/checkout/checkoutUsecases"
+)
+
+// ICheckoutModule place an order with the usecases of the other modules, it has no table of its own
+type ICheckoutModule interface {
+	Init()
+	Usecase() checkoutUsecases.ICheckoutUsecase
+	Handler() checkoutHandlers.ICheckoutHandler
+}
+
+type checkoutModule struct {
+	*moduleFactory
+	usecase checkoutUsecases.ICheckoutUsecase
+	handler checkoutHandlers.ICheckoutHandler
+}
+
+func (m *moduleFactory) CheckoutModule() ICheckoutModule {
+	usecase := checkoutUsecases.CheckoutUsecase(
+		m.CartsModule().Usecase(),
+		m.InventoryModule().Usecase(),
+		m.OrdersModule().Usecase(),
+		m.AddressesModule().Usecase(),
+		checkoutUsecases.ManualTransferGateway(),
+	)
+	handler := checkoutHandlers.CheckoutHandler(m.s.cfg, usecase)
+
+	return &checkoutModule{
+		moduleFactory: m,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (c *checkoutModule) Init() {
+	c.r.Post("/checkout", c.mid.JwtAuth(), c.handler.Checkout)
+}
+
+func (c *checkoutModule) Usecase() checkoutUsecases.ICheckoutUsecase { return c.usecase }
+func (c *checkoutModule) Handler() checkoutHandlers.ICheckoutHandler { return c.handler }
@@ -0,0 +1,53 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/commissions/commissionsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/commissions/commissionsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/commissions/commissionsUsecases"
+)
+
+type ICommissionsModule interface {
+	Init()
+	Repository() commissionsRepositories.ICommissionsRepository
+	Usecase() commissionsUsecases.ICommissionsUsecase
+	Handler() commissionsHandlers.ICommissionsHandler
+}
+
+type commissionsModule struct {
+	*moduleFactory
+	repository commissionsRepositories.ICommissionsRepository
+	usecase    commissionsUsecases.ICommissionsUsecase
+	handler    commissionsHandlers.ICommissionsHandler
+}
+
+func (m *moduleFactory) CommissionsModule() ICommissionsModule {
+	repository := commissionsRepositories.CommissionsRepository(m.s.db)
+	usecase := commissionsUsecases.CommissionsUsecase(repository)
+	handler := commissionsHandlers.CommissionsHandler(m.s.cfg, usecase)
+
+	return &commissionsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (b *commissionsModule) Init() {
+	router := b.r.Group("/commissions")
+
+	router.Get("/rules", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.FindRule)
+	router.Post("/rules", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.AddRule)
+	router.Patch("/rules/:rule_id", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.UpdateRule)
+	router.Delete("/rules/:rule_id", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.DeleteRule)
+}
+
+func (b *commissionsModule) Repository() commissionsRepositories.ICommissionsRepository {
+	return b.repository
+}
+func (b *commissionsModule) Usecase() commissionsUsecases.ICommissionsUsecase {
+	return b.usecase
+}
+func (b *commissionsModule) Handler() commissionsHandlers.ICommissionsHandler {
+	return b.handler
+}
@@ -0,0 +1,50 @@
+package servers
+
+import (
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/currencies/currenciesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/currencies/currenciesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/currencies/currenciesUsecases"
+)
+
+type ICurrenciesModule interface {
+	Init()
+	Repository() currenciesRepositories.ICurrenciesRepository
+	Usecase() currenciesUsecases.ICurrenciesUsecase
+	Handler() currenciesHandlers.ICurrenciesHandler
+}
+
+type currenciesModule struct {
+	*moduleFactory
+	repository currenciesRepositories.ICurrenciesRepository
+	usecase    currenciesUsecases.ICurrenciesUsecase
+	handler    currenciesHandlers.ICurrenciesHandler
+}
+
+func (m *moduleFactory) CurrenciesModule() ICurrenciesModule {
+	repository := currenciesRepositories.CurrenciesRepository(m.s.db)
+	rateProvider := currenciesUsecases.CachedRateProvider(currenciesUsecases.DbRateProvider(repository), 10*time.Minute)
+	usecase := currenciesUsecases.CurrenciesUsecase(repository, rateProvider)
+	handler := currenciesHandlers.CurrenciesHandler(m.s.cfg, usecase)
+
+	return &currenciesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (cu *currenciesModule) Init() {
+	router := cu.r.Group("/currencies")
+
+	router.Get("/", cu.mid.ApiKeyAuth(), cu.handler.FindCurrency)
+	router.Put("/rates", cu.mid.JwtAuth(), cu.mid.Authorize(2), cu.handler.UpsertExchangeRate)
+}
+
+func (cu *currenciesModule) Repository() currenciesRepositories.ICurrenciesRepository {
+	return cu.repository
+}
+func (cu *currenciesModule) Usecase() currenciesUsecases.ICurrenciesUsecase { return cu.usecase }
+func (cu *currenciesModule) Handler() currenciesHandlers.ICurrenciesHandler { return cu.handler }
@@ -0,0 +1,57 @@
+package servers
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/exports/exportsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/exports/exportsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/exports/exportsUsecases"
+)
+
+type IExportsModule interface {
+	Init()
+	Repository() exportsRepositories.IExportsRepository
+	Usecase() exportsUsecases.IExportsUsecase
+	Handler() exportsHandlers.IExportsHandler
+}
+
+type exportsModule struct {
+	*moduleFactory
+	repository exportsRepositories.IExportsRepository
+	usecase    exportsUsecases.IExportsUsecase
+	handler    exportsHandlers.IExportsHandler
+}
+
+func (m *moduleFactory) ExportsModule() IExportsModule {
+	repository := exportsRepositories.ExportsRepository(m.s.db)
+	usecase := exportsUsecases.ExportsUsecase(repository, m.FilesModule().Usecase())
+	handler := exportsHandlers.ExportsHandler(m.s.cfg, usecase)
+
+	return &exportsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (e *exportsModule) Init() {
+	router := e.r.Group("/exports")
+
+	// GET stream the file, POST make it in the background for a big range
+	router.Get("/orders", e.mid.JwtAuth(), e.mid.Authorize(2), e.handler.DownloadOrders)
+	router.Post("/orders", e.mid.JwtAuth(), e.mid.Authorize(2), e.handler.StartExport)
+	router.Get("/:export_id", e.mid.JwtAuth(), e.mid.Authorize(2), e.handler.FindExport)
+
+	go e.usecase.FailStoppedExports(context.Background())
+}
+
+func (e *exportsModule) Repository() exportsRepositories.IExportsRepository {
+	return e.repository
+}
+func (e *exportsModule) Usecase() exportsUsecases.IExportsUsecase {
+	return e.usecase
+}
+func (e *exportsModule) Handler() exportsHandlers.IExportsHandler {
+	return e.handler
+}
@@ -0,0 +1,63 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/feeds/feedsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/feeds/feedsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/feeds/feedsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+)
+
+type IFeedsModule interface {
+	Init()
+	Repository() feedsRepositories.IFeedsRepository
+	Usecase() feedsUsecases.IFeedsUsecase
+	Handler() feedsHandlers.IFeedsHandler
+}
+
+type feedsModule struct {
+	*moduleFactory
+	repository feedsRepositories.IFeedsRepository
+	usecase    feedsUsecases.IFeedsUsecase
+	handler    feedsHandlers.IFeedsHandler
+}
+
+func (m *moduleFactory) FeedsModule() IFeedsModule {
+	repository := feedsRepositories.FeedsRepository(m.s.db)
+	usecase := feedsUsecases.FeedsUsecase(m.s.cfg, repository)
+	handler := feedsHandlers.FeedsHandler(m.s.cfg, usecase)
+
+	return &feedsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (f *feedsModule) Init() {
+	// public, crawlers and ad platforms fetch them without a key
+	router := f.r.Group("/feeds")
+	router.Get("/sitemap.xml", f.handler.Sitemap)
+	router.Get("/google.xml", f.handler.GoogleFeed)
+	router.Get("/facebook.csv", f.handler.FacebookFeed)
+
+	// a sitemap only list urls under the path it is served from, so it is served at the root as well
+	f.s.app.Get("/sitemap.xml", f.handler.Sitemap)
+
+	f.s.tasks.Register(&tasks.Task{
+		Name:        "feeds.refresh",
+		Schedule:    "15 * * * *",
+		Description: "generate the sitemap and the product feeds again",
+		Run:         f.usecase.RefreshFeeds,
+	})
+}
+
+func (f *feedsModule) Repository() feedsRepositories.IFeedsRepository {
+	return f.repository
+}
+func (f *feedsModule) Usecase() feedsUsecases.IFeedsUsecase {
+	return f.usecase
+}
+func (f *feedsModule) Handler() feedsHandlers.IFeedsHandler {
+	return f.handler
+}
@@ -1,8 +1,12 @@
 package servers
 
 import (
+	"context"
+
 	"github.com/NatthawutSK/ri-shop/modules/files/filesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesRepositories"
 	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
 )
 
 type IFilesModule interface {
@@ -18,8 +22,9 @@ type filesModule struct {
 }
 
 func (m *moduleFactory) FilesModule() IFilesModule {
-	usecase := filesUsecases.FilesUsecase(m.s.cfg)
-	handler := filesHandlers.FileHandler(m.s.cfg, usecase)
+	repository := filesRepositories.FilesRepository(m.s.db)
+	usecase := filesUsecases.FilesUsecase(m.s.cfg, repository, filesUsecases.FileScanner(m.s.cfg.Scan()), filesUsecases.CdnPurger(m.s.cfg.Cdn()), filesUsecases.ImageTranscoders(m.s.cfg.Image()), m.s.locker)
+	handler := filesHandlers.FileHandler(m.s.cfg, usecase, m.AuditsModule().Usecase())
 
 	return &filesModule{
 		moduleFactory: m,
@@ -33,6 +38,19 @@ func (f *filesModule) Init() {
 
 	router.Post("/upload", f.mid.JwtAuth(), f.mid.Authorize(2), f.handler.UploadFiles)
 	router.Patch("/delete", f.mid.JwtAuth(), f.mid.Authorize(2), f.handler.DeleteFile)
+	// the browser upload straight to the bucket with a signed policy, then confirm the upload
+	router.Post("/signed-upload", f.mid.JwtAuth(), f.mid.Authorize(2, 4), f.handler.SignUpload)
+	router.Post("/confirm", f.mid.JwtAuth(), f.mid.Authorize(2, 4), f.handler.ConfirmUpload)
+	// public images in the format the browser accept, e.g. <img src="/v1/files/images/products/a.jpg">
+	router.Get("/images/*", f.handler.ServeImage)
+
+	// files of a product are deleted after the product is saved, the failed ones are retried here
+	f.s.tasks.Register(&tasks.Task{
+		Name:        filesUsecases.FileDeletionTask,
+		Schedule:    "* * * * *",
+		Description: "delete the queued files which are due, failed deletions are retried with a backoff",
+		Run:         func(ctx context.Context) error { return f.usecase.ProcessFileDeletion(ctx, nil) },
+	})
 }
 
 func (f *filesModule) Usecase() filesUsecases.IFilesUsecase { return f.usecase }
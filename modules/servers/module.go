@@ -4,15 +4,10 @@ import (
 	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoHandlers"
 	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoRepositories"
 	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoUsecases"
-	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/middlewares/middlewaresHandlers"
 	"github.com/NatthawutSK/ri-shop/modules/middlewares/middlewaresRepositories"
 	"github.com/NatthawutSK/ri-shop/modules/middlewares/middlewaresUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/monitor/monitorHandlers"
-	"github.com/NatthawutSK/ri-shop/modules/orders/ordersHandlers"
-	"github.com/NatthawutSK/ri-shop/modules/orders/ordersRepositories"
-	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
-	"github.com/NatthawutSK/ri-shop/modules/products/productsRepositories"
 	"github.com/NatthawutSK/ri-shop/modules/users/usersHandlers"
 	"github.com/NatthawutSK/ri-shop/modules/users/usersRepositories"
 	"github.com/NatthawutSK/ri-shop/modules/users/usersUsecases"
@@ -25,39 +20,79 @@ type IModuleFactory interface {
 	AppinfoModule()
 	FilesModule() IFilesModule
 	ProductsModule() IProductModule
-	OrdersModule()
+	OrdersModule() IOrdersModule
+	InventoryModule() IInventoryModule
+	AuditsModule() IAuditsModule
+	CurrenciesModule() ICurrenciesModule
+	RecommendationsModule() IRecommendationsModule
+	WorkflowsModule() IWorkflowsModule
+	AddressesModule() IAddressesModule
+	ShippingModule() IShippingModule
+	RefundsModule() IRefundsModule
+	CartsModule() ICartsModule
+	NotificationsModule() INotificationsModule
+	ReportsModule() IReportsModule
+	BadgesModule() IBadgesModule
+	CatalogModule() ICatalogModule
+	PickupsModule() IPickupsModule
+	SellersModule() ISellersModule
+	PayoutsModule() IPayoutsModule
+	CommissionsModule() ICommissionsModule
+	SearchesModule() ISearchesModule
+	WebhooksModule() IWebhooksModule
+	TaxesModule() ITaxesModule
+	InvoicesModule() IInvoicesModule
+	ViewsModule() IViewsModule
+	ExportsModule() IExportsModule
+	TasksModule() ITasksModule
+	FeedsModule() IFeedsModule
+	WatchesModule() IWatchesModule
+	PrivacyModule() IPrivacyModule
+	CheckoutModule() ICheckoutModule
+	GraphqlModule() IGraphqlModule
 }
 
 type moduleFactory struct {
-	r   fiber.Router
-	s   *server
-	mid middlewaresHandlers.IMiddlewaresHandler
+	r        fiber.Router // v1
+	versions map[string]fiber.Router
+	s        *server
+	mid      middlewaresHandlers.IMiddlewaresHandler
 }
 
-func InitModule(r fiber.Router, s *server, mid middlewaresHandlers.IMiddlewaresHandler) IModuleFactory {
+func InitModule(versions map[string]fiber.Router, s *server, mid middlewaresHandlers.IMiddlewaresHandler) IModuleFactory {
 	return &moduleFactory{
-		r:   r,
-		s:   s,
-		mid: mid,
+		r:        versions["v1"],
+		versions: versions,
+		s:        s,
+		mid:      mid,
 	}
 }
 
 func InitMiddlewares(s *server) middlewaresHandlers.IMiddlewaresHandler {
 	repository := middlewaresRepositories.MiddlewaresRepository(s.db)
 	usecase := middlewaresUsecases.MiddlewaresUsecase(repository)
-	return middlewaresHandlers.MiddlewaresHandler(s.cfg, usecase)
+	return middlewaresHandlers.MiddlewaresHandler(s.cfg, usecase, s.accessLog)
 }
 
 func (m *moduleFactory) MonitorModule() {
-	handle := monitorHandlers.MonitorHandler(m.s.cfg)
+	handle := monitorHandlers.MonitorHandler(m.s.cfg, m.s.ready.Load, m.s.db)
 
 	m.r.Get("/", handle.HealthCheck)
+	m.r.Get("/ready", handle.ReadinessCheck)
+	m.r.Get("/metrics/db", m.mid.JwtAuth(), m.mid.Authorize(2), handle.DbMetrics)
+	m.r.Get("/metrics/breakers", m.mid.JwtAuth(), m.mid.Authorize(2), handle.BreakerMetrics)
+	m.r.Get("/metrics/workerpools", m.mid.JwtAuth(), m.mid.Authorize(2), handle.WorkerPoolMetrics)
+
+	debug := m.r.Group("/monitor/debug", m.mid.JwtAuth(), m.mid.Authorize(2))
+	debug.Get("/pprof/:profile?", handle.Pprof)
+	debug.Get("/runtime", handle.RuntimeStats)
+	debug.Post("/dump", handle.DebugDump)
 }
 
 func (m *moduleFactory) UsersModule() {
 	repository := usersRepositories.UsersRepositoryHandler(m.s.db)
 	usecase := usersUsecases.UserUsecaseHandler(repository, m.s.cfg)
-	handler := usersHandlers.UsersHandler(m.s.cfg, usecase)
+	handler := usersHandlers.UsersHandler(m.s.cfg, usecase, m.AuditsModule().Usecase())
 
 	router := m.r.Group("/users")
 
@@ -66,21 +101,32 @@ func (m *moduleFactory) UsersModule() {
 	router.Post("/refresh", m.mid.ApiKeyAuth(), handler.RefreshPassport)
 	router.Post("/signout", m.mid.ApiKeyAuth(), handler.SignOut)
 	router.Post("/signup-admin", m.mid.JwtAuth(), m.mid.Authorize(2), handler.SignUpAdmin)
+	router.Post("/import", m.mid.JwtAuth(), m.mid.Authorize(2), handler.ImportUsers)
 	router.Get("/admin/secret", m.mid.JwtAuth(), m.mid.Authorize(2), handler.GenerateAdminToken)
+	router.Get("/admin/locked", m.mid.JwtAuth(), m.mid.Authorize(2), handler.FindLockedLogins)
+	router.Post("/admin/unlock", m.mid.JwtAuth(), m.mid.Authorize(2), handler.UnlockLogin)
 	router.Get("/:user_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.GetUserProfile)
+	router.Patch("/:user_id/locale", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.UpdateLocale)
 }
 
 func (m *moduleFactory) AppinfoModule() {
 	repository := appinfoRepositories.AppinfoRepository(m.s.db)
-	usecase := appinfoUsecases.AppinfoUsecase(repository)
-	handler := appinfoHandlers.AppinfoHandler(usecase, m.s.cfg)
+	usecase := appinfoUsecases.AppinfoUsecase(repository, m.s.categoryCache)
+	handler := appinfoHandlers.AppinfoHandler(usecase, m.s.cfg, m.s.clock)
 
 	router := m.r.Group("/appinfo")
 
 	router.Get("/apikey", m.mid.JwtAuth(), m.mid.Authorize(2), handler.GenerateApiKey)
+	router.Post("/preview-token", m.mid.JwtAuth(), m.mid.Authorize(2), handler.GeneratePreviewToken)
 	router.Get("/categories", m.mid.ApiKeyAuth(), handler.FindCategory)
 	router.Post("/categories", m.mid.JwtAuth(), m.mid.Authorize(2), handler.InsertCategory)
 	router.Delete("/:categoryId/categories", m.mid.JwtAuth(), m.mid.Authorize(2), handler.DeleteCategory)
+
+	// test clock ให้ integration test เลื่อนเวลาได้ ไม่มีใน production
+	if !m.s.cfg.App().IsProduction() {
+		router.Get("/test-clock", m.mid.JwtAuth(), m.mid.Authorize(2), handler.FindTestClock)
+		router.Post("/test-clock", m.mid.JwtAuth(), m.mid.Authorize(2), handler.UpdateTestClock)
+	}
 }
 
 // func (m *moduleFactory) FilesModule() {
@@ -109,22 +155,3 @@ func (m *moduleFactory) AppinfoModule() {
 // 	router.Delete("/:productId", m.mid.JwtAuth(), m.mid.Authorize(2), handler.DeleteProduct)
 
 // }
-
-func (m *moduleFactory) OrdersModule() {
-	fileUsecase := filesUsecases.FilesUsecase(m.s.cfg)
-	productRepository := productsRepositories.ProductsRepository(m.s.db, m.s.cfg, fileUsecase)
-
-	ordersRepository := ordersRepositories.OrdersRepository(m.s.db)
-	ordersUsecase := ordersUsecases.OrdersUsecase(ordersRepository, productRepository)
-	ordersHandler := ordersHandlers.OrdersHandler(ordersUsecase, m.s.cfg)
-
-	router := m.r.Group("/orders")
-
-	router.Post("/", m.mid.JwtAuth(), ordersHandler.InsertOrder)
-	router.Get("/", m.mid.JwtAuth(), m.mid.Authorize(2), ordersHandler.FindOrder)
-	router.Get("/:user_id/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), ordersHandler.FindOneOrder)
-
-	//admin แก้ได้ทั้งหมด แต่ customer แก้ได้แค่ status เป็น cancel
-	router.Patch("/:user_id/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), ordersHandler.UpdateOrder)
-
-}
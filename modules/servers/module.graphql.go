@@ -0,0 +1,62 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/graphql/graphqlHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/graphql/graphqlRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/graphql/graphqlResolvers"
+	"github.com/gofiber/fiber/v2"
+)
+
+type IGraphqlModule interface {
+	Init()
+	Repository() graphqlRepositories.IGraphqlRepository
+	Resolver() graphqlResolvers.IGraphqlResolver
+	Handler() graphqlHandlers.IGraphqlHandler
+}
+
+type graphqlModule struct {
+	*moduleFactory
+	repository graphqlRepositories.IGraphqlRepository
+	resolver   graphqlResolvers.IGraphqlResolver
+	handler    graphqlHandlers.IGraphqlHandler
+}
+
+func (m *moduleFactory) GraphqlModule() IGraphqlModule {
+	repository := graphqlRepositories.GraphqlRepository(m.s.db, m.FilesModule().Usecase())
+	resolver := graphqlResolvers.GraphqlResolver(
+		repository,
+		m.ProductsModule().Usecase(),
+		appinfoUsecases.AppinfoUsecase(appinfoRepositories.AppinfoRepository(m.s.db), m.s.categoryCache),
+		m.OrdersModule().Usecase(),
+	)
+	handler := graphqlHandlers.GraphqlHandler(m.s.cfg, resolver)
+
+	return &graphqlModule{
+		moduleFactory: m,
+		repository:    repository,
+		resolver:      resolver,
+		handler:       handler,
+	}
+}
+
+func (g *graphqlModule) Init() {
+	router := g.r.Group("/graphql")
+
+	// the access token is optional, only the order query needs a signed in user
+	signedIn := g.mid.JwtAuth()
+	optionalJwtAuth := func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return signedIn(c)
+	}
+
+	router.Get("/", g.mid.ApiKeyAuth(), g.mid.Preview(), optionalJwtAuth, g.handler.Query)
+	router.Post("/", g.mid.ApiKeyAuth(), g.mid.Preview(), optionalJwtAuth, g.handler.Query)
+}
+
+func (g *graphqlModule) Repository() graphqlRepositories.IGraphqlRepository { return g.repository }
+func (g *graphqlModule) Resolver() graphqlResolvers.IGraphqlResolver        { return g.resolver }
+func (g *graphqlModule) Handler() graphqlHandlers.IGraphqlHandler           { return g.handler }
@@ -0,0 +1,75 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type IInventoryModule interface {
+	Init()
+	Repository() inventoryRepositories.IInventoryRepository
+	Usecase() inventoryUsecases.IInventoryUsecase
+	Handler() inventoryHandlers.IInventoryHandler
+}
+
+type inventoryModule struct {
+	*moduleFactory
+	repository inventoryRepositories.IInventoryRepository
+	usecase    inventoryUsecases.IInventoryUsecase
+	handler    inventoryHandlers.IInventoryHandler
+}
+
+func (m *moduleFactory) InventoryModule() IInventoryModule {
+	repository := inventoryRepositories.InventoryRepository(m.s.db)
+	usecase := inventoryUsecases.InventoryUsecase(m.s.cfg.Inventory(), repository, m.NotificationsModule().Usecase())
+	handler := inventoryHandlers.InventoryHandler(m.s.cfg, usecase)
+
+	return &inventoryModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (i *inventoryModule) Init() {
+	router := i.r.Group("/inventory")
+
+	router.Get("/reorder-suggestions", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.FindReorderSuggestion)
+	router.Get("/suppliers", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.FindSupplier)
+	router.Post("/suppliers", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.AddSupplier)
+	router.Patch("/:productId/supplier", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.UpdateProductSupplier)
+	router.Post("/checkouts", i.mid.JwtAuth(), i.handler.StartCheckout)
+	router.Get("/low-stock", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.FindLowStock)
+	router.Patch("/:productId/low-stock-threshold", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.UpdateLowStockThreshold)
+	router.Get("/movements", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.FindMovement)
+	router.Post("/movements", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.AddMovement)
+	router.Get("/reconcile", i.mid.JwtAuth(), i.mid.Authorize(2), i.handler.FindReconcile)
+
+	events.Subscribe(orders.EventOrderCreated, i.handler.CheckLowStock)
+
+	// sales velocity is recomputed in the background, the report only reads the latest snapshot
+	i.s.tasks.Register(&tasks.Task{
+		Name:        "inventory.forecast",
+		Schedule:    "0 * * * *",
+		Description: "recompute the sales velocity of the stock forecast",
+		Run:         i.usecase.RefreshForecast,
+	})
+	// holds of abandoned checkouts are removed once they expire
+	i.s.tasks.Register(&tasks.Task{
+		Name:        "inventory.reservations",
+		Schedule:    "* * * * *",
+		Description: "remove the expired stock reservations of abandoned checkouts",
+		Run:         i.usecase.DeleteExpiredReservation,
+	})
+}
+
+func (i *inventoryModule) Repository() inventoryRepositories.IInventoryRepository {
+	return i.repository
+}
+func (i *inventoryModule) Usecase() inventoryUsecases.IInventoryUsecase { return i.usecase }
+func (i *inventoryModule) Handler() inventoryHandlers.IInventoryHandler { return i.handler }
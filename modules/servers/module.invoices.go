@@ -0,0 +1,55 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/invoices/invoicesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/invoices/invoicesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/invoices/invoicesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type IInvoicesModule interface {
+	Init()
+	Repository() invoicesRepositories.IInvoicesRepository
+	Usecase() invoicesUsecases.IInvoicesUsecase
+	Handler() invoicesHandlers.IInvoicesHandler
+}
+
+type invoicesModule struct {
+	*moduleFactory
+	repository invoicesRepositories.IInvoicesRepository
+	usecase    invoicesUsecases.IInvoicesUsecase
+	handler    invoicesHandlers.IInvoicesHandler
+}
+
+func (m *moduleFactory) InvoicesModule() IInvoicesModule {
+	repository := invoicesRepositories.InvoicesRepository(m.s.db)
+	usecase := invoicesUsecases.InvoicesUsecase(m.s.cfg, repository, m.OrdersModule().Repository(), m.FilesModule().Usecase())
+	handler := invoicesHandlers.InvoicesHandler(usecase)
+
+	return &invoicesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (i *invoicesModule) Init() {
+	router := i.r.Group("/orders")
+
+	// เจ้าของ order หรือ admin เท่านั้น, url ที่ได้ใช้ได้ไม่กี่นาที
+	router.Get("/:user_id/:order_id/invoice", i.mid.JwtAuth(), i.mid.ParamsCheck(), i.handler.FindInvoice)
+
+	events.Subscribe(orders.EventOrderStatusChanged, i.handler.GenerateOnPaid)
+}
+
+func (i *invoicesModule) Repository() invoicesRepositories.IInvoicesRepository {
+	return i.repository
+}
+func (i *invoicesModule) Usecase() invoicesUsecases.IInvoicesUsecase {
+	return i.usecase
+}
+func (i *invoicesModule) Handler() invoicesHandlers.IInvoicesHandler {
+	return i.handler
+}
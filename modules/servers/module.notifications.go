@@ -0,0 +1,79 @@
+package servers
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type INotificationsModule interface {
+	Init()
+	Repository() notificationsRepositories.INotificationsRepository
+	Usecase() notificationsUsecases.INotificationsUsecase
+	Handler() notificationsHandlers.INotificationsHandler
+}
+
+type notificationsModule struct {
+	*moduleFactory
+	repository notificationsRepositories.INotificationsRepository
+	usecase    notificationsUsecases.INotificationsUsecase
+	handler    notificationsHandlers.INotificationsHandler
+}
+
+func (m *moduleFactory) NotificationsModule() INotificationsModule {
+	repository := notificationsRepositories.NotificationsRepository(m.s.db)
+	providers := make([]notificationsUsecases.IMailProvider, 0)
+	if m.s.cfg.Mail().SmtpHost() != "" {
+		providers = append(providers, notificationsUsecases.SmtpProvider(m.s.cfg.Mail()))
+	}
+	if m.s.cfg.Mail().ApiUrl() != "" {
+		providers = append(providers, notificationsUsecases.ApiProvider(m.s.cfg.Mail()))
+	}
+	usecase := notificationsUsecases.NotificationsUsecase(m.s.cfg.Mail(), repository, providers...)
+	handler := notificationsHandlers.NotificationsHandler(m.s.cfg, usecase)
+
+	return &notificationsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (n *notificationsModule) Init() {
+	router := n.r.Group("/notifications")
+
+	router.Post("/emails", n.mid.JwtAuth(), n.mid.Authorize(2), n.handler.SendEmail)
+	router.Get("/emails/metrics", n.mid.JwtAuth(), n.mid.Authorize(2), n.handler.FindEmailMetrics)
+
+	// inbox ของ account area
+	inbox := n.r.Group("/users/:user_id/notifications")
+	inbox.Get("/", n.mid.JwtAuth(), n.mid.ParamsCheck(), n.handler.FindInbox)
+	inbox.Post("/read", n.mid.JwtAuth(), n.mid.ParamsCheck(), n.handler.MarkAllRead)
+	inbox.Get("/preferences", n.mid.JwtAuth(), n.mid.ParamsCheck(), n.handler.FindPreference)
+	inbox.Put("/preferences", n.mid.JwtAuth(), n.mid.ParamsCheck(), n.handler.UpdatePreference)
+	inbox.Patch("/:notification_id/read", n.mid.JwtAuth(), n.mid.ParamsCheck(), n.handler.MarkRead)
+
+	events.Subscribe(orders.EventOrderCreated, n.handler.NotifyOrder)
+	events.Subscribe(orders.EventOrderStatusChanged, n.handler.NotifyOrder)
+	events.Subscribe(orders.EventOrderTrackingUpdated, n.handler.NotifyOrder)
+	events.Subscribe(refunds.EventRefundIssued, n.handler.NotifyRefund)
+
+	// email ถูกส่งจาก queue ใน background
+	go n.usecase.StartEmailWorker(context.Background())
+}
+
+func (n *notificationsModule) Repository() notificationsRepositories.INotificationsRepository {
+	return n.repository
+}
+func (n *notificationsModule) Usecase() notificationsUsecases.INotificationsUsecase {
+	return n.usecase
+}
+func (n *notificationsModule) Handler() notificationsHandlers.INotificationsHandler {
+	return n.handler
+}
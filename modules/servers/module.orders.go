@@ -0,0 +1,76 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type IOrdersModule interface {
+	Init()
+	Repository() ordersRepositories.IOrdersRepository
+	Usecase() ordersUsecases.IOrdersUsecase
+	Handler() ordersHandlers.IOrdersHandler
+}
+
+type ordersModule struct {
+	*moduleFactory
+	repository ordersRepositories.IOrdersRepository
+	usecase    ordersUsecases.IOrdersUsecase
+	handler    ordersHandlers.IOrdersHandler
+}
+
+func (m *moduleFactory) OrdersModule() IOrdersModule {
+	repository := ordersRepositories.OrdersRepository(m.s.db)
+	usecase := ordersUsecases.OrdersUsecase(
+		repository,
+		m.ProductsModule().Repository(),
+		m.WorkflowsModule().Usecase(),
+		m.AddressesModule().Usecase(),
+		m.ShippingModule().Usecase(),
+		m.NotificationsModule().Usecase(),
+		m.PickupsModule().Repository(),
+		m.TaxesModule().Usecase(),
+	)
+	handler := ordersHandlers.OrdersHandler(usecase, m.s.cfg)
+
+	return &ordersModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (o *ordersModule) Init() {
+	router := o.r.Group("/orders")
+
+	router.Post("/", o.mid.JwtAuth(), o.handler.InsertOrder)
+	// websocket ส่ง status ของ order ที่ subscribe ไว้
+	router.Get("/ws", o.mid.QueryToken(), o.mid.JwtAuth(), o.handler.OrderSocket())
+	router.Get("/", o.mid.JwtAuth(), o.mid.Authorize(2), o.handler.FindOrder)
+	// ผู้รับของขวัญดูสถานะด้วย token ที่ได้ทาง email
+	router.Get("/gift/:token", o.mid.ApiKeyAuth(), o.handler.FindGiftTracking)
+	router.Get("/:user_id/:order_id/packing-slip", o.mid.JwtAuth(), o.mid.Authorize(2), o.handler.FindPackingSlip)
+	// staff ตรวจ code ที่ลูกค้าแสดงก่อนส่งของให้
+	router.Post("/:user_id/:order_id/pickup", o.mid.JwtAuth(), o.mid.Authorize(2), o.handler.ConfirmPickup)
+	router.Get("/:user_id/:order_id", o.mid.JwtAuth(), o.mid.ParamsCheck(), o.handler.FindOneOrder)
+
+	//admin แก้ได้ทั้งหมด แต่ customer แก้ได้แค่ status เป็น cancel
+	router.Patch("/:user_id/:order_id", o.mid.JwtAuth(), o.mid.ParamsCheck(), o.handler.UpdateOrder)
+
+	events.Subscribe(orders.EventOrderStatusChanged, o.handler.PushOrderEvent)
+	events.Subscribe(orders.EventOrderTrackingUpdated, o.handler.PushOrderEvent)
+}
+
+func (o *ordersModule) Repository() ordersRepositories.IOrdersRepository {
+	return o.repository
+}
+func (o *ordersModule) Usecase() ordersUsecases.IOrdersUsecase {
+	return o.usecase
+}
+func (o *ordersModule) Handler() ordersHandlers.IOrdersHandler {
+	return o.handler
+}
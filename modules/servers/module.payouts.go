@@ -0,0 +1,56 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/payouts/payoutsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/payouts/payoutsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/payouts/payoutsUsecases"
+)
+
+type IPayoutsModule interface {
+	Init()
+	Repository() payoutsRepositories.IPayoutsRepository
+	Usecase() payoutsUsecases.IPayoutsUsecase
+	Handler() payoutsHandlers.IPayoutsHandler
+}
+
+type payoutsModule struct {
+	*moduleFactory
+	repository payoutsRepositories.IPayoutsRepository
+	usecase    payoutsUsecases.IPayoutsUsecase
+	handler    payoutsHandlers.IPayoutsHandler
+}
+
+func (m *moduleFactory) PayoutsModule() IPayoutsModule {
+	repository := payoutsRepositories.PayoutsRepository(m.s.db)
+	usecase := payoutsUsecases.PayoutsUsecase(repository)
+	handler := payoutsHandlers.PayoutsHandler(m.s.cfg, usecase)
+
+	return &payoutsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (b *payoutsModule) Init() {
+	router := b.r.Group("/payouts")
+
+	router.Get("/balances", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.FindBalance)
+	router.Post("/batches", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.AddBatch)
+	router.Get("/batches", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.FindBatch)
+	router.Get("/batches/:batch_id", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.FindOneBatch)
+	router.Get("/batches/:batch_id/export", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.ExportBatch)
+	router.Patch("/:payout_id", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.UpdatePayout)
+	router.Get("/sellers/:user_id", b.mid.JwtAuth(), b.mid.Authorize(2, 4), b.mid.ParamsCheck(), b.handler.FindSellerPayout)
+}
+
+func (b *payoutsModule) Repository() payoutsRepositories.IPayoutsRepository {
+	return b.repository
+}
+func (b *payoutsModule) Usecase() payoutsUsecases.IPayoutsUsecase {
+	return b.usecase
+}
+func (b *payoutsModule) Handler() payoutsHandlers.IPayoutsHandler {
+	return b.handler
+}
@@ -0,0 +1,53 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/pickups/pickupsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/pickups/pickupsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/pickups/pickupsUsecases"
+)
+
+type IPickupsModule interface {
+	Init()
+	Repository() pickupsRepositories.IPickupsRepository
+	Usecase() pickupsUsecases.IPickupsUsecase
+	Handler() pickupsHandlers.IPickupsHandler
+}
+
+type pickupsModule struct {
+	*moduleFactory
+	repository pickupsRepositories.IPickupsRepository
+	usecase    pickupsUsecases.IPickupsUsecase
+	handler    pickupsHandlers.IPickupsHandler
+}
+
+func (m *moduleFactory) PickupsModule() IPickupsModule {
+	repository := pickupsRepositories.PickupsRepository(m.s.db)
+	usecase := pickupsUsecases.PickupsUsecase(repository, m.s.clock)
+	handler := pickupsHandlers.PickupsHandler(m.s.cfg, usecase)
+
+	return &pickupsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (p *pickupsModule) Init() {
+	router := p.r.Group("/pickups")
+
+	router.Get("/locations", p.mid.ApiKeyAuth(), p.handler.FindLocation)
+	router.Post("/locations", p.mid.JwtAuth(), p.mid.Authorize(2), p.handler.AddLocation)
+	router.Get("/locations/:locationId/slots", p.mid.ApiKeyAuth(), p.handler.FindSlot)
+	router.Post("/locations/:locationId/slots", p.mid.JwtAuth(), p.mid.Authorize(2), p.handler.AddSlot)
+}
+
+func (p *pickupsModule) Repository() pickupsRepositories.IPickupsRepository {
+	return p.repository
+}
+func (p *pickupsModule) Usecase() pickupsUsecases.IPickupsUsecase {
+	return p.usecase
+}
+func (p *pickupsModule) Handler() pickupsHandlers.IPickupsHandler {
+	return p.handler
+}
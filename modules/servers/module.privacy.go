@@ -0,0 +1,55 @@
+package servers
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyUsecases"
+)
+
+type IPrivacyModule interface {
+	Init()
+	Repository() privacyRepositories.IPrivacyRepository
+	Usecase() privacyUsecases.IPrivacyUsecase
+	Handler() privacyHandlers.IPrivacyHandler
+}
+
+type privacyModule struct {
+	*moduleFactory
+	repository privacyRepositories.IPrivacyRepository
+	usecase    privacyUsecases.IPrivacyUsecase
+	handler    privacyHandlers.IPrivacyHandler
+}
+
+func (m *moduleFactory) PrivacyModule() IPrivacyModule {
+	repository := privacyRepositories.PrivacyRepository(m.s.db)
+	usecase := privacyUsecases.PrivacyUsecase(repository, m.FilesModule().Usecase())
+	handler := privacyHandlers.PrivacyHandler(m.s.cfg, usecase, m.AuditsModule().Usecase())
+
+	return &privacyModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (p *privacyModule) Init() {
+	// บัญชีของคนที่ login อยู่ ไม่ชนกับ GET /users/:user_id เพราะ path ยาวกว่าและ method ต่างกัน
+	router := p.r.Group("/users/me")
+	router.Get("/export", p.mid.JwtAuth(), p.handler.FindExport)
+	router.Delete("/", p.mid.JwtAuth(), p.handler.DeleteAccount)
+
+	go p.usecase.FailStoppedExports(context.Background())
+}
+
+func (p *privacyModule) Repository() privacyRepositories.IPrivacyRepository {
+	return p.repository
+}
+func (p *privacyModule) Usecase() privacyUsecases.IPrivacyUsecase {
+	return p.usecase
+}
+func (p *privacyModule) Handler() privacyHandlers.IPrivacyHandler {
+	return p.handler
+}
@@ -1,9 +1,12 @@
 package servers
 
 import (
+	"context"
+
 	"github.com/NatthawutSK/ri-shop/modules/products/productsHandlers"
 	"github.com/NatthawutSK/ri-shop/modules/products/productsRepositories"
 	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
 )
 
 type IProductModule interface {
@@ -22,8 +25,8 @@ type ProductsModule struct {
 
 func (m *moduleFactory) ProductsModule() IProductModule {
 	repository := productsRepositories.ProductsRepository(m.s.db, m.s.cfg, m.FilesModule().Usecase())
-	usecase := productsUsecases.ProductsUsecase(repository)
-	handler := productsHandlers.ProductsHandler(usecase, m.s.cfg, m.FilesModule().Usecase())
+	usecase := productsUsecases.ProductsUsecase(repository, m.CurrenciesModule().Usecase(), m.s.productCache)
+	handler := productsHandlers.ProductsHandler(usecase, m.s.cfg, m.FilesModule().Usecase(), m.AuditsModule().Usecase())
 
 	return &ProductsModule{
 		moduleFactory: m,
@@ -36,11 +39,37 @@ func (m *moduleFactory) ProductsModule() IProductModule {
 func (p *ProductsModule) Init() {
 	router := p.r.Group("/products")
 
-	router.Post("/", p.mid.JwtAuth(), p.mid.Authorize(2), p.handler.AddProduct)
-	router.Patch("/:productId", p.mid.JwtAuth(), p.mid.Authorize(2), p.handler.UpdateProduct)
-	router.Get("/", p.mid.ApiKeyAuth(), p.handler.FindProduct)
-	router.Get("/:productId", p.mid.ApiKeyAuth(), p.handler.FindOneProduct)
-	router.Delete("/:productId", p.mid.JwtAuth(), p.mid.Authorize(2), p.handler.DeleteProduct)
+	router.Post("/", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.AddProduct)
+	router.Post("/search-by-image", p.mid.ApiKeyAuth(), p.handler.SearchByImage)
+	router.Patch("/bulk", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.BulkUpdateProduct)
+	router.Patch("/:productId", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.UpdateProduct)
+	router.Put("/:productId/prices", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.UpdateProductPrices)
+	router.Put("/:productId/regions", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.UpdateProductRegions)
+	router.Put("/:productId/attributes", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.UpdateProductAttributes)
+	router.Put("/:productId/images/order", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.UpdateImageOrder)
+	router.Patch("/:productId/images/:imageId/primary", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.UpdatePrimaryImage)
+	router.Post("/:productId/images", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.AddProductImage)
+	router.Get("/", p.mid.ApiKeyAuth(), p.mid.Preview(), p.mid.CacheControl("products"), p.handler.FindProduct)
+	router.Get("/admin", p.mid.JwtAuth(), p.mid.Authorize(2), p.handler.FindProduct)
+	router.Get("/:productId", p.mid.ApiKeyAuth(), p.mid.Preview(), p.mid.CacheControl("products"), p.handler.FindOneProduct)
+	router.Get("/:productId/availability", p.mid.ApiKeyAuth(), p.mid.CacheControl("products"), p.handler.FindAvailability)
+	router.Delete("/:productId", p.mid.JwtAuth(), p.mid.Authorize(2, 4), p.handler.DeleteProduct)
+
+	// v2 ตอบ list ในรูปแบบ entities.PageRes, route อื่นใช้ของ v1
+	v2 := p.Version("v2").Group("/products")
+	v2.Get("/", p.mid.ApiKeyAuth(), p.mid.Preview(), p.mid.CacheControl("products"), p.handler.FindProductPage)
+	v2.Get("/admin", p.mid.JwtAuth(), p.mid.Authorize(2), p.handler.FindProductPage)
+
+	// products which entered or left their availability window are announced every minute
+	p.s.tasks.Register(&tasks.Task{
+		Name:        "products.windows",
+		Schedule:    "* * * * *",
+		Description: "announce the products which entered or left their availability window",
+		Run: func(ctx context.Context) error {
+			_, err := p.usecase.UpdateWindowOpen(ctx)
+			return err
+		},
+	})
 }
 
 func (p *ProductsModule) Repository() productsRepositories.IProductsRepository { return p.repository }
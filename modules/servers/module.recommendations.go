@@ -0,0 +1,58 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/recommendations/recommendationsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/recommendations/recommendationsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/recommendations/recommendationsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+)
+
+type IRecommendationsModule interface {
+	Init()
+	Repository() recommendationsRepositories.IRecommendationsRepository
+	Usecase() recommendationsUsecases.IRecommendationsUsecase
+	Handler() recommendationsHandlers.IRecommendationsHandler
+}
+
+type recommendationsModule struct {
+	*moduleFactory
+	repository recommendationsRepositories.IRecommendationsRepository
+	usecase    recommendationsUsecases.IRecommendationsUsecase
+	handler    recommendationsHandlers.IRecommendationsHandler
+}
+
+func (m *moduleFactory) RecommendationsModule() IRecommendationsModule {
+	repository := recommendationsRepositories.RecommendationsRepository(m.s.db, m.FilesModule().Usecase())
+	usecase := recommendationsUsecases.RecommendationsUsecase(repository)
+	handler := recommendationsHandlers.RecommendationsHandler(m.s.cfg, usecase)
+
+	return &recommendationsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (re *recommendationsModule) Init() {
+	router := re.r.Group("/products")
+
+	router.Get("/:productId/frequently-bought-together", re.mid.ApiKeyAuth(), re.handler.FindFrequentlyBoughtTogether)
+
+	re.s.tasks.Register(&tasks.Task{
+		Name:        "recommendations.refresh",
+		Schedule:    "0 */6 * * *",
+		Description: "recompute the products frequently bought together",
+		Run:         re.usecase.RefreshFrequentlyBoughtTogether,
+	})
+}
+
+func (re *recommendationsModule) Repository() recommendationsRepositories.IRecommendationsRepository {
+	return re.repository
+}
+func (re *recommendationsModule) Usecase() recommendationsUsecases.IRecommendationsUsecase {
+	return re.usecase
+}
+func (re *recommendationsModule) Handler() recommendationsHandlers.IRecommendationsHandler {
+	return re.handler
+}
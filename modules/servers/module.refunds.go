@@ -0,0 +1,60 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/refunds/refundsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/refunds/refundsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/refunds/refundsUsecases"
+)
+
+type IRefundsModule interface {
+	Init()
+	Repository() refundsRepositories.IRefundsRepository
+	Usecase() refundsUsecases.IRefundsUsecase
+	Handler() refundsHandlers.IRefundsHandler
+}
+
+type refundsModule struct {
+	*moduleFactory
+	repository refundsRepositories.IRefundsRepository
+	usecase    refundsUsecases.IRefundsUsecase
+	handler    refundsHandlers.IRefundsHandler
+}
+
+func (m *moduleFactory) RefundsModule() IRefundsModule {
+	repository := refundsRepositories.RefundsRepository(m.s.db)
+	usecase := refundsUsecases.RefundsUsecase(
+		repository,
+		m.OrdersModule().Usecase(),
+		refundsUsecases.ManualTransferGateway(),
+	)
+	handler := refundsHandlers.RefundsHandler(m.s.cfg, usecase, m.AuditsModule().Usecase())
+
+	return &refundsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (r *refundsModule) Init() {
+	router := r.r.Group("/refunds")
+
+	router.Get("/", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindRefund)
+	router.Post("/", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.IssueRefund)
+
+	// customer ขอยกเลิก order ของตัวเองได้ ก่อนของถูกส่ง
+	router.Post("/cancellations", r.mid.JwtAuth(), r.handler.RequestCancellation)
+	router.Get("/cancellations", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindCancellation)
+	router.Patch("/cancellations/:cancellationId", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.ReviewCancellation)
+}
+
+func (r *refundsModule) Repository() refundsRepositories.IRefundsRepository {
+	return r.repository
+}
+func (r *refundsModule) Usecase() refundsUsecases.IRefundsUsecase {
+	return r.usecase
+}
+func (r *refundsModule) Handler() refundsHandlers.IRefundsHandler {
+	return r.handler
+}
@@ -0,0 +1,63 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/reports/reportsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/reports/reportsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/reports/reportsUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type IReportsModule interface {
+	Init()
+	Repository() reportsRepositories.IReportsRepository
+	Usecase() reportsUsecases.IReportsUsecase
+	Handler() reportsHandlers.IReportsHandler
+}
+
+type reportsModule struct {
+	*moduleFactory
+	repository reportsRepositories.IReportsRepository
+	usecase    reportsUsecases.IReportsUsecase
+	handler    reportsHandlers.IReportsHandler
+}
+
+func (m *moduleFactory) ReportsModule() IReportsModule {
+	repository := reportsRepositories.ReportsRepository(m.s.db)
+	usecase := reportsUsecases.ReportsUsecase(repository, m.s.summaryCache)
+	handler := reportsHandlers.ReportsHandler(m.s.cfg, usecase)
+
+	return &reportsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (r *reportsModule) Init() {
+	router := r.r.Group("/reports")
+
+	router.Get("/revenue", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindRevenue)
+	router.Get("/top-products", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindTopProduct)
+	router.Get("/orders-by-status", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindOrderStatus)
+	router.Get("/low-stock", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindLowStock)
+	router.Get("/commission", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindCommission)
+	router.Get("/top-searches", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindTopSearch)
+	router.Get("/zero-result-searches", r.mid.JwtAuth(), r.mid.Authorize(2), r.handler.FindZeroResultSearch)
+
+	// หน้า account ของลูกค้า และ crm ของ admin
+	r.r.Get("/users/:user_id/orders/summary", r.mid.JwtAuth(), r.mid.ParamsCheck(), r.handler.FindOrderSummary)
+
+	events.Subscribe(orders.EventOrderStatusChanged, r.handler.ForgetOrderSummary)
+}
+
+func (r *reportsModule) Repository() reportsRepositories.IReportsRepository {
+	return r.repository
+}
+func (r *reportsModule) Usecase() reportsUsecases.IReportsUsecase {
+	return r.usecase
+}
+func (r *reportsModule) Handler() reportsHandlers.IReportsHandler {
+	return r.handler
+}
@@ -0,0 +1,63 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/searches/searchesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/searches/searchesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/searches/searchesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type ISearchesModule interface {
+	Init()
+	Repository() searchesRepositories.ISearchesRepository
+	Usecase() searchesUsecases.ISearchesUsecase
+	Handler() searchesHandlers.ISearchesHandler
+}
+
+type searchesModule struct {
+	*moduleFactory
+	repository searchesRepositories.ISearchesRepository
+	usecase    searchesUsecases.ISearchesUsecase
+	handler    searchesHandlers.ISearchesHandler
+}
+
+func (m *moduleFactory) SearchesModule() ISearchesModule {
+	repository := searchesRepositories.SearchesRepository(m.s.db)
+	usecase := searchesUsecases.SearchesUsecase(repository)
+	handler := searchesHandlers.SearchesHandler(m.s.cfg, usecase)
+
+	return &searchesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (s *searchesModule) Init() {
+	router := s.r.Group("/searches")
+
+	router.Post("/:search_id/clicks", s.mid.ApiKeyAuth(), s.handler.AddClick)
+
+	events.Subscribe(products.EventProductSearched, s.handler.RecordSearch)
+
+	// searches are only kept for searches.RetentionDays
+	s.s.tasks.Register(&tasks.Task{
+		Name:        "searches.retention",
+		Schedule:    "30 3 * * *",
+		Description: "delete the expired searches",
+		Run:         s.usecase.DeleteExpiredSearch,
+	})
+}
+
+func (s *searchesModule) Repository() searchesRepositories.ISearchesRepository {
+	return s.repository
+}
+func (s *searchesModule) Usecase() searchesUsecases.ISearchesUsecase {
+	return s.usecase
+}
+func (s *searchesModule) Handler() searchesHandlers.ISearchesHandler {
+	return s.handler
+}
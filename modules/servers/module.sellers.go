@@ -0,0 +1,55 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/sellers/sellersHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/sellers/sellersRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/sellers/sellersUsecases"
+)
+
+type ISellersModule interface {
+	Init()
+	Repository() sellersRepositories.ISellersRepository
+	Usecase() sellersUsecases.ISellersUsecase
+	Handler() sellersHandlers.ISellersHandler
+}
+
+type sellersModule struct {
+	*moduleFactory
+	repository sellersRepositories.ISellersRepository
+	usecase    sellersUsecases.ISellersUsecase
+	handler    sellersHandlers.ISellersHandler
+}
+
+func (m *moduleFactory) SellersModule() ISellersModule {
+	repository := sellersRepositories.SellersRepository(m.s.db)
+	usecase := sellersUsecases.SellersUsecase(repository)
+	handler := sellersHandlers.SellersHandler(m.s.cfg, usecase)
+
+	return &sellersModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (b *sellersModule) Init() {
+	router := b.r.Group("/sellers")
+
+	router.Post("/", b.mid.JwtAuth(), b.handler.ApplySeller)
+	router.Get("/", b.mid.JwtAuth(), b.mid.Authorize(2), b.handler.FindSeller)
+	router.Get("/:user_id", b.mid.JwtAuth(), b.mid.ParamsCheck(), b.handler.FindOneSeller)
+	router.Patch("/:user_id", b.mid.JwtAuth(), b.mid.Authorize(2), b.mid.ParamsCheck(), b.handler.ReviewSeller)
+	router.Get("/:user_id/dashboard", b.mid.JwtAuth(), b.mid.Authorize(2, 4), b.mid.ParamsCheck(), b.handler.FindDashboard)
+	router.Get("/:user_id/orders", b.mid.JwtAuth(), b.mid.Authorize(2, 4), b.mid.ParamsCheck(), b.handler.FindSellerOrder)
+}
+
+func (b *sellersModule) Repository() sellersRepositories.ISellersRepository {
+	return b.repository
+}
+func (b *sellersModule) Usecase() sellersUsecases.ISellersUsecase {
+	return b.usecase
+}
+func (b *sellersModule) Handler() sellersHandlers.ISellersHandler {
+	return b.handler
+}
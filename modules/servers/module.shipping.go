@@ -0,0 +1,56 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/shipping/shippingHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/shipping/shippingRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/shipping/shippingUsecases"
+)
+
+type IShippingModule interface {
+	Init()
+	Repository() shippingRepositories.IShippingRepository
+	Usecase() shippingUsecases.IShippingUsecase
+	Handler() shippingHandlers.IShippingHandler
+}
+
+type shippingModule struct {
+	*moduleFactory
+	repository shippingRepositories.IShippingRepository
+	usecase    shippingUsecases.IShippingUsecase
+	handler    shippingHandlers.IShippingHandler
+}
+
+func (m *moduleFactory) ShippingModule() IShippingModule {
+	repository := shippingRepositories.ShippingRepository(m.s.db)
+	providers := []shippingUsecases.IShippingProvider{
+		shippingUsecases.FlatRateProvider(m.s.cfg.Shipping()),
+	}
+	if m.s.cfg.Shipping().CarrierUrl() != "" {
+		providers = append(providers, shippingUsecases.CarrierProvider(m.s.cfg.Shipping()))
+	}
+	usecase := shippingUsecases.ShippingUsecase(repository, providers...)
+	handler := shippingHandlers.ShippingHandler(m.s.cfg, usecase)
+
+	return &shippingModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (s *shippingModule) Init() {
+	router := s.r.Group("/shipping")
+
+	router.Post("/quote", s.mid.ApiKeyAuth(), s.handler.Quote)
+}
+
+func (s *shippingModule) Repository() shippingRepositories.IShippingRepository {
+	return s.repository
+}
+func (s *shippingModule) Usecase() shippingUsecases.IShippingUsecase {
+	return s.usecase
+}
+func (s *shippingModule) Handler() shippingHandlers.IShippingHandler {
+	return s.handler
+}
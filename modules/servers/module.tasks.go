@@ -0,0 +1,58 @@
+package servers
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksUsecases"
+)
+
+type ITasksModule interface {
+	Init()
+	Usecase() tasksUsecases.ITasksUsecase
+	Handler() tasksHandlers.ITasksHandler
+}
+
+type tasksModule struct {
+	*moduleFactory
+	repository tasksRepositories.ITasksRepository
+	usecase    tasksUsecases.ITasksUsecase
+	handler    tasksHandlers.ITasksHandler
+}
+
+// TasksModule use the scheduler of the server, the tasks of the modules are registered on it
+func (m *moduleFactory) TasksModule() ITasksModule {
+	repository := tasksRepositories.TasksRepository(m.s.db)
+	handler := tasksHandlers.TasksHandler(m.s.tasks, m.AuditsModule().Usecase())
+
+	return &tasksModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       m.s.tasks,
+		handler:       handler,
+	}
+}
+
+func (t *tasksModule) Init() {
+	router := t.r.Group("/tasks")
+
+	router.Get("/", t.mid.JwtAuth(), t.mid.Authorize(2), t.handler.FindTasks)
+	router.Get("/:name/runs", t.mid.JwtAuth(), t.mid.Authorize(2), t.handler.FindRuns)
+	router.Post("/:name/run", t.mid.JwtAuth(), t.mid.Authorize(2), t.handler.TriggerTask)
+	router.Patch("/:name", t.mid.JwtAuth(), t.mid.Authorize(2), t.handler.UpdatePaused)
+
+	t.usecase.Register(&tasks.Task{
+		Name:        "tasks.history",
+		Schedule:    "0 4 * * *",
+		Description: "delete the runs older than 30 days and fail the runs which never finished",
+		Run: func(ctx context.Context) error {
+			_, err := t.repository.DeleteOldRuns(ctx, tasks.RunRetentionDays, tasks.StaleRunHours)
+			return err
+		},
+	})
+}
+
+func (t *tasksModule) Usecase() tasksUsecases.ITasksUsecase { return t.usecase }
+func (t *tasksModule) Handler() tasksHandlers.ITasksHandler { return t.handler }
@@ -0,0 +1,54 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesUsecases"
+)
+
+type ITaxesModule interface {
+	Init()
+	Repository() taxesRepositories.ITaxesRepository
+	Usecase() taxesUsecases.ITaxesUsecase
+	Handler() taxesHandlers.ITaxesHandler
+}
+
+type taxesModule struct {
+	*moduleFactory
+	repository taxesRepositories.ITaxesRepository
+	usecase    taxesUsecases.ITaxesUsecase
+	handler    taxesHandlers.ITaxesHandler
+}
+
+func (m *moduleFactory) TaxesModule() ITaxesModule {
+	repository := taxesRepositories.TaxesRepository(m.s.db)
+	// an external tax api is plugged in here in place of the rate table
+	provider := taxesUsecases.RateTableProvider(repository, m.s.cfg.App().TaxRate())
+	usecase := taxesUsecases.TaxesUsecase(repository, provider)
+	handler := taxesHandlers.TaxesHandler(m.s.cfg, usecase)
+
+	return &taxesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (t *taxesModule) Init() {
+	router := t.r.Group("/taxes")
+
+	router.Get("/rates", t.mid.JwtAuth(), t.mid.Authorize(2), t.handler.FindRate)
+	router.Post("/rates", t.mid.JwtAuth(), t.mid.Authorize(2), t.handler.AddRate)
+	router.Delete("/rates/:rateId", t.mid.JwtAuth(), t.mid.Authorize(2), t.handler.DeleteRate)
+}
+
+func (t *taxesModule) Repository() taxesRepositories.ITaxesRepository {
+	return t.repository
+}
+func (t *taxesModule) Usecase() taxesUsecases.ITaxesUsecase {
+	return t.usecase
+}
+func (t *taxesModule) Handler() taxesHandlers.ITaxesHandler {
+	return t.handler
+}
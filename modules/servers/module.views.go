@@ -0,0 +1,61 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/views/viewsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/views/viewsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/views/viewsUsecases"
+)
+
+type IViewsModule interface {
+	Init()
+	Repository() viewsRepositories.IViewsRepository
+	Usecase() viewsUsecases.IViewsUsecase
+	Handler() viewsHandlers.IViewsHandler
+}
+
+type viewsModule struct {
+	*moduleFactory
+	repository viewsRepositories.IViewsRepository
+	usecase    viewsUsecases.IViewsUsecase
+	handler    viewsHandlers.IViewsHandler
+}
+
+func (m *moduleFactory) ViewsModule() IViewsModule {
+	repository := viewsRepositories.ViewsRepository(m.s.db)
+	usecase := viewsUsecases.ViewsUsecase(repository, m.ProductsModule().Usecase())
+	handler := viewsHandlers.ViewsHandler(m.s.cfg, usecase)
+
+	return &viewsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (v *viewsModule) Init() {
+	// ชั้น recently viewed ของ user ที่ login แล้ว และของ session ที่ยังไม่ login
+	v.r.Post("/users/:user_id/recently-viewed", v.mid.JwtAuth(), v.mid.ParamsCheck(), v.handler.RecordView)
+	v.r.Get("/users/:user_id/recently-viewed", v.mid.JwtAuth(), v.mid.ParamsCheck(), v.handler.FindRecentlyViewed)
+	v.r.Post("/sessions/:session_id/recently-viewed", v.mid.ApiKeyAuth(), v.handler.RecordView)
+	v.r.Get("/sessions/:session_id/recently-viewed", v.mid.ApiKeyAuth(), v.handler.FindRecentlyViewed)
+
+	// views of sessions are only kept for views.SessionRetentionDays
+	v.s.tasks.Register(&tasks.Task{
+		Name:        "views.retention",
+		Schedule:    "45 3 * * *",
+		Description: "delete the expired views of sessions",
+		Run:         v.usecase.DeleteExpiredSessionView,
+	})
+}
+
+func (v *viewsModule) Repository() viewsRepositories.IViewsRepository {
+	return v.repository
+}
+func (v *viewsModule) Usecase() viewsUsecases.IViewsUsecase {
+	return v.usecase
+}
+func (v *viewsModule) Handler() viewsHandlers.IViewsHandler {
+	return v.handler
+}
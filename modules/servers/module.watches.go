@@ -0,0 +1,72 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/watches/watchesHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/watches/watchesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/watches/watchesUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type IWatchesModule interface {
+	Init()
+	Repository() watchesRepositories.IWatchesRepository
+	Usecase() watchesUsecases.IWatchesUsecase
+	Handler() watchesHandlers.IWatchesHandler
+}
+
+type watchesModule struct {
+	*moduleFactory
+	repository watchesRepositories.IWatchesRepository
+	usecase    watchesUsecases.IWatchesUsecase
+	handler    watchesHandlers.IWatchesHandler
+}
+
+func (m *moduleFactory) WatchesModule() IWatchesModule {
+	repository := watchesRepositories.WatchesRepository(m.s.db)
+	usecase := watchesUsecases.WatchesUsecase(repository, m.ProductsModule().Usecase(), m.NotificationsModule().Usecase())
+	handler := watchesHandlers.WatchesHandler(m.s.cfg, usecase)
+
+	return &watchesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (w *watchesModule) Init() {
+	// "notify me" ของลูกค้า
+	router := w.r.Group("/users/:user_id/watches")
+	router.Post("/", w.mid.JwtAuth(), w.mid.ParamsCheck(), w.handler.AddWatch)
+	router.Get("/", w.mid.JwtAuth(), w.mid.ParamsCheck(), w.handler.FindWatch)
+	router.Delete("/:watch_id", w.mid.JwtAuth(), w.mid.ParamsCheck(), w.handler.CancelWatch)
+
+	w.r.Get("/watches/demand", w.mid.JwtAuth(), w.mid.Authorize(2), w.handler.FindDemand)
+
+	events.Subscribe(products.EventPriceStockChanged, w.handler.CheckOnChange)
+
+	w.s.tasks.Register(&tasks.Task{
+		Name:        "watches.check",
+		Schedule:    "*/10 * * * *",
+		Description: "notify the watches whose product is back in stock or cheaper",
+		Run:         w.usecase.CheckWatch,
+	})
+	w.s.tasks.Register(&tasks.Task{
+		Name:        "watches.expire",
+		Schedule:    "15 4 * * *",
+		Description: "expire the watches older than 90 days",
+		Run:         w.usecase.ExpireWatch,
+	})
+}
+
+func (w *watchesModule) Repository() watchesRepositories.IWatchesRepository {
+	return w.repository
+}
+func (w *watchesModule) Usecase() watchesUsecases.IWatchesUsecase {
+	return w.usecase
+}
+func (w *watchesModule) Handler() watchesHandlers.IWatchesHandler {
+	return w.handler
+}
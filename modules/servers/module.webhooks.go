@@ -0,0 +1,64 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks/webhooksHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks/webhooksRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks/webhooksUsecases"
+)
+
+type IWebhooksModule interface {
+	Init()
+	Repository() webhooksRepositories.IWebhooksRepository
+	Usecase() webhooksUsecases.IWebhooksUsecase
+	Handler() webhooksHandlers.IWebhooksHandler
+}
+
+type webhooksModule struct {
+	*moduleFactory
+	repository webhooksRepositories.IWebhooksRepository
+	usecase    webhooksUsecases.IWebhooksUsecase
+	handler    webhooksHandlers.IWebhooksHandler
+}
+
+func (m *moduleFactory) WebhooksModule() IWebhooksModule {
+	repository := webhooksRepositories.WebhooksRepository(m.s.db)
+	usecase := webhooksUsecases.WebhooksUsecase(m.s.cfg.Webhook(), repository, m.OrdersModule().Usecase(), m.RefundsModule().Usecase())
+	handler := webhooksHandlers.WebhooksHandler(m.s.cfg, usecase)
+
+	return &webhooksModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (w *webhooksModule) Init() {
+	router := w.r.Group("/webhooks")
+
+	// ผู้ส่งเป็นระบบภายนอก ไม่มี jwt หรือ api key, ใช้ signature ของแต่ละ source แทน
+	router.Post("/payments", w.mid.WebhookAuth(webhooks.SourcePayments), w.handler.PaymentWebhook)
+	router.Post("/carriers", w.mid.WebhookAuth(webhooks.SourceCarriers), w.handler.CarrierWebhook)
+	router.Get("/metrics", w.mid.JwtAuth(), w.mid.Authorize(2), w.handler.FindMetric)
+	router.Get("/duplicate-charges", w.mid.JwtAuth(), w.mid.Authorize(2), w.handler.FindDuplicateCharge)
+	router.Patch("/duplicate-charges/:chargeId", w.mid.JwtAuth(), w.mid.Authorize(2), w.handler.ReviewDuplicateCharge)
+
+	w.s.tasks.Register(&tasks.Task{
+		Name:        "webhooks.nonces",
+		Schedule:    "0 * * * *",
+		Description: "delete the expired nonces of the webhooks",
+		Run:         w.usecase.DeleteExpiredNonce,
+	})
+}
+
+func (w *webhooksModule) Repository() webhooksRepositories.IWebhooksRepository {
+	return w.repository
+}
+func (w *webhooksModule) Usecase() webhooksUsecases.IWebhooksUsecase {
+	return w.usecase
+}
+func (w *webhooksModule) Handler() webhooksHandlers.IWebhooksHandler {
+	return w.handler
+}
@@ -0,0 +1,52 @@
+package servers
+
+import (
+	"github.com/NatthawutSK/ri-shop/modules/workflows/workflowsHandlers"
+	"github.com/NatthawutSK/ri-shop/modules/workflows/workflowsRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/workflows/workflowsUsecases"
+)
+
+type IWorkflowsModule interface {
+	Init()
+	Repository() workflowsRepositories.IWorkflowsRepository
+	Usecase() workflowsUsecases.IWorkflowsUsecase
+	Handler() workflowsHandlers.IWorkflowsHandler
+}
+
+type workflowsModule struct {
+	*moduleFactory
+	repository workflowsRepositories.IWorkflowsRepository
+	usecase    workflowsUsecases.IWorkflowsUsecase
+	handler    workflowsHandlers.IWorkflowsHandler
+}
+
+func (m *moduleFactory) WorkflowsModule() IWorkflowsModule {
+	repository := workflowsRepositories.WorkflowsRepository(m.s.db)
+	usecase := workflowsUsecases.WorkflowsUsecase(repository)
+	handler := workflowsHandlers.WorkflowsHandler(m.s.cfg, usecase)
+
+	return &workflowsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (w *workflowsModule) Init() {
+	router := w.r.Group("/workflows")
+
+	router.Get("/hooks", w.mid.JwtAuth(), w.mid.Authorize(2), w.handler.FindHook)
+	router.Post("/hooks", w.mid.JwtAuth(), w.mid.Authorize(2), w.handler.AddHook)
+	router.Delete("/hooks/:hookId", w.mid.JwtAuth(), w.mid.Authorize(2), w.handler.DeleteHook)
+}
+
+func (w *workflowsModule) Repository() workflowsRepositories.IWorkflowsRepository {
+	return w.repository
+}
+func (w *workflowsModule) Usecase() workflowsUsecases.IWorkflowsUsecase {
+	return w.usecase
+}
+func (w *workflowsModule) Handler() workflowsHandlers.IWorkflowsHandler {
+	return w.handler
+}
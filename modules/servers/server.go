@@ -3,12 +3,29 @@ package servers
 import (
 	"encoding/json"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"sync/atomic"
 
 	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/reports"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+	"github.com/NatthawutSK/ri-shop/pkg/clock"
+	"github.com/NatthawutSK/ri-shop/pkg/i18n"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+	"github.com/NatthawutSK/ri-shop/pkg/logfile"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+	riAuth "github.com/NatthawutSK/ri-shop/pkg/riauth"
+	"github.com/NatthawutSK/ri-shop/pkg/serializer"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
+	"google.golang.org/grpc"
 )
 
 type IServer interface {
@@ -17,21 +34,56 @@ type IServer interface {
 }
 
 type server struct {
-	app *fiber.App
-	cfg config.IConfig
-	db  *sqlx.DB
+	app   *fiber.App
+	grpc  *grpc.Server // nil when APP_GRPC_PORT is not set
+	feed  *feed
+	cfg   config.IConfig
+	db    *sqlx.DB
+	clock clock.Clock // a test clock which admins can move outside production
+	ready atomic.Bool // set after the caches are warmed
+	// advisory locks of the database, shared by the instances of the server behind the load balancer
+	locker locks.ILocker
+	// background tasks of every module, registered in their Init and started after the modules
+	tasks tasksUsecases.ITasksUsecase
+	// nil when ACCESS_LOG_FILE is not set, the requests are then logged to stdout
+	accessLog *logfile.Writer
+
+	// shared by every instance of the modules, ProductsModule() build a new usecase on each call
+	productCache  *cache.Cache[*products.Products]
+	categoryCache *cache.Cache[[]*appinfo.Category]
+	summaryCache  *cache.Cache[*reports.OrderSummary]
 }
 
 func NewSever(cfg config.IConfig, db *sqlx.DB) IServer {
+	clk := clock.Real()
+	if !cfg.App().IsProduction() {
+		clk = clock.NewTestClock()
+	}
+	riAuth.SetClock(clk)
+	resilience.Configure(resilience.Settings{
+		Attempts:  cfg.Resilience().Attempts(),
+		BaseDelay: cfg.Resilience().BaseDelay(),
+		MaxDelay:  cfg.Resilience().MaxDelay(),
+		Failures:  cfg.Resilience().Failures(),
+		OpenFor:   cfg.Resilience().OpenFor(),
+	})
+	locker := locks.DbLocker(db)
+
 	return &server{
-		cfg: cfg,
-		db:  db,
+		cfg:           cfg,
+		db:            db,
+		clock:         clk,
+		locker:        locker,
+		tasks:         tasksUsecases.TasksUsecase(tasksRepositories.TasksRepository(db), locker),
+		productCache:  cache.New[*products.Products](cfg.Cache().ProductTtl()),
+		categoryCache: cache.New[[]*appinfo.Category](cfg.Cache().ProductTtl()),
+		summaryCache:  cache.New[*reports.OrderSummary](cfg.Cache().SummaryTtl()),
 		app: fiber.New(fiber.Config{
 			AppName:      cfg.App().Name(),
-			BodyLimit:    cfg.App().BodyLimit(),
+			BodyLimit:    cfg.Upload().MaxBodyLimit(), // each route group is checked by the BodyLimit middleware
 			ReadTimeout:  cfg.App().ReadTimeout(),
 			WriteTimeout: cfg.App().WriteTimeout(),
-			JSONEncoder:  json.Marshal,
+			JSONEncoder:  serializer.Marshal,
 			JSONDecoder:  json.Unmarshal,
 		}),
 	}
@@ -39,39 +91,157 @@ func NewSever(cfg config.IConfig, db *sqlx.DB) IServer {
 }
 
 func (s *server) Start() {
+	if path := s.cfg.AccessLog().File(); path != "" {
+		accessLog, err := logfile.New(logfile.Config{
+			Path:     path,
+			MaxBytes: s.cfg.AccessLog().MaxBytes(),
+			Every:    s.cfg.AccessLog().Every(),
+			MaxFiles: s.cfg.AccessLog().MaxFiles(),
+			Buffer:   s.cfg.AccessLog().Buffer(),
+		})
+		if err != nil {
+			log.Fatalf("open access log failed: %v", err)
+		}
+		s.accessLog = accessLog
+	}
+
 	// Middleware
 	middleware := InitMiddlewares(s)
 	s.app.Use(middleware.Logger())
 	s.app.Use(middleware.Cors())
-	s.app.Use(middleware.StreamingFile())
+	s.app.Use(middleware.SecurityHeaders())
+	s.app.Use(middleware.Compress())
+	s.app.Use(middleware.Store())
+	s.app.Use(middleware.BodyLimit())
+	s.app.Use(middleware.Timeout())
+
+	// ไฟล์จาก UploadToStorage, ชื่อไฟล์สุ่มใหม่ทุกครั้งจึง cache ได้นาน
+	s.app.Use(files.LocalStoragePath, middleware.StaticHeaders())
+	s.app.Static(files.LocalStoragePath, files.LocalStorageDir, fiber.Static{
+		ByteRange: true,
+		MaxAge:    s.cfg.App().StaticMaxAge(),
+	})
+	if s.cfg.Chaos().Enabled() && !s.cfg.App().IsProduction() {
+		log.Printf("chaos middleware is enabled on %s", s.cfg.App().Env())
+		s.app.Use(middleware.Chaos())
+	}
+
+	// rate limits, worker counts and file limit reload without a restart
+	go s.cfg.StartWatcher()
+
+	// Messages
+	if err := i18n.LoadOverrides(s.db); err != nil {
+		log.Printf("load message overrides failed: %v", err)
+	}
+
+	// gRPC ใช้ api key เดียวกับ http
+	if s.cfg.App().GrpcPort() != 0 {
+		s.grpc = grpc.NewServer(grpc.UnaryInterceptor(middleware.GrpcApiKeyAuth()))
+	}
 
 	// Module
-	v1 := s.app.Group("/v1")
+	// routes are registered on v1, a later version only register the routes which changed (see versions.go)
+	versions := s.versionRouters()
+	v1 := versions["v1"]
 
-	modules := InitModule(v1, s, middleware)
+	modules := InitModule(versions, s, middleware)
 
 	modules.MonitorModule()
 	modules.UsersModule()
 	modules.AppinfoModule()
 	modules.FilesModule().Init()
 	modules.ProductsModule().Init()
-	modules.OrdersModule()
+	modules.OrdersModule().Init()
+	modules.InventoryModule().Init()
+	modules.AuditsModule().Init()
+	modules.CurrenciesModule().Init()
+	modules.RecommendationsModule().Init()
+	modules.WorkflowsModule().Init()
+	modules.AddressesModule().Init()
+	modules.ShippingModule().Init()
+	modules.RefundsModule().Init()
+	modules.CartsModule().Init()
+	modules.NotificationsModule().Init()
+	modules.ReportsModule().Init()
+	modules.BadgesModule().Init()
+	modules.PickupsModule().Init()
+	modules.SellersModule().Init()
+	modules.PayoutsModule().Init()
+	modules.CommissionsModule().Init()
+	modules.SearchesModule().Init()
+	modules.WebhooksModule().Init()
+	modules.TaxesModule().Init()
+	modules.InvoicesModule().Init()
+	modules.ViewsModule().Init()
+	modules.ExportsModule().Init()
+	modules.TasksModule().Init()
+	modules.FeedsModule().Init()
+	modules.WatchesModule().Init()
+	modules.PrivacyModule().Init()
+	modules.CheckoutModule().Init()
+	modules.GraphqlModule().Init()
+	if s.grpc != nil {
+		modules.CatalogModule().Init()
+	}
+
+	// admin dashboard, EventSource ส่ง header ไม่ได้ token มาทาง query
+	s.feed = newFeed()
+	v1.Get("/admin/feed", middleware.QueryToken(), middleware.JwtAuth(), middleware.Authorize(2), s.feed.Handler())
+
+	// OpenAPI ของทุก route สำหรับ frontend และ partner, ดู docs.go
+	apiDocs := newDocs(s.openApi)
+	v1.Get("/docs", apiDocs.Ui)
+	v1.Get("/docs/openapi.json", apiDocs.Spec)
 
+	s.inheritRoutes()
 	s.app.Use(middleware.RouterCheck())
 
+	// every module has registered its tasks
+	go s.tasks.Start()
+
+	// ready หลัง warm cache เสร็จ ระหว่างนี้ /ready ตอบ 503
+	go s.warmCache(modules)
+
 	//Graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		<-c
 		log.Println("server is shutting down...")
+		s.feed.Close()
+		if s.grpc != nil {
+			s.grpc.GracefulStop()
+		}
 		_ = s.app.Shutdown()
 	}()
 
+	//Listen to host:grpc port
+	if s.grpc != nil {
+		lis, err := net.Listen("tcp", s.cfg.App().GrpcUrl())
+		if err != nil {
+			log.Fatalf("listen grpc failed: %v", err)
+		}
+		go func() {
+			log.Printf("grpc server is running at %v", s.cfg.App().GrpcUrl())
+			if err := s.grpc.Serve(lis); err != nil {
+				log.Printf("grpc server stopped: %v", err)
+			}
+		}()
+	}
+
 	//Listen to host:port
 	log.Printf("server is running at %v", s.cfg.App().Url())
 	s.app.Listen(s.cfg.App().Url())
 
+	// Listen return after the shutdown, เขียน access log ที่ค้างใน buffer ให้หมดก่อนจบ
+	if s.accessLog != nil {
+		if dropped := s.accessLog.Dropped(); dropped > 0 {
+			log.Printf("access log dropped %d lines", dropped)
+		}
+		if err := s.accessLog.Close(); err != nil {
+			log.Printf("close access log failed: %v", err)
+		}
+	}
 }
 
 func (s *server) GetServer() *server {
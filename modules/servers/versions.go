@@ -0,0 +1,107 @@
+package servers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiVersion is a prefix of the routes, e.g. /v2. A route of a version is also served by the next versions
+// until one of them registers the same method and path, so a new version only registers what changed
+type apiVersion struct {
+	name string
+	// set when the version is deprecated, its responses then have the Deprecation and Link headers
+	deprecatedAt time.Time
+	// when the version is removed, the Sunset header is only sent once it is decided
+	sunsetAt time.Time
+}
+
+// apiVersions are in order, the last one is the latest
+var apiVersions = []*apiVersion{
+	{name: "v1"},
+	{name: "v2"},
+}
+
+func (v *apiVersion) prefix() string { return "/" + v.name }
+
+func (v *apiVersion) deprecated() bool { return !v.deprecatedAt.IsZero() }
+
+// headers tell the clients of a deprecated version where the same route is in the latest version
+// (RFC 9745 and RFC 8594)
+func (v *apiVersion) headers() fiber.Handler {
+	latest := apiVersions[len(apiVersions)-1]
+	if !v.deprecated() || v == latest {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	deprecation := fmt.Sprintf("@%d", v.deprecatedAt.Unix())
+	sunset := ""
+	if !v.sunsetAt.IsZero() {
+		sunset = v.sunsetAt.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", deprecation)
+		if sunset != "" {
+			c.Set("Sunset", sunset)
+		}
+		c.Set(fiber.HeaderLink, fmt.Sprintf(`<%s%s>; rel="successor-version"`, latest.prefix(), strings.TrimPrefix(c.Path(), v.prefix())))
+		return c.Next()
+	}
+}
+
+// findApiVersion return the version of a path, nil when the path is not under a version
+func findApiVersion(path string) *apiVersion {
+	for _, v := range apiVersions {
+		if path == v.prefix() || strings.HasPrefix(path, v.prefix()+"/") {
+			return v
+		}
+	}
+	return nil
+}
+
+// versionRouters create the group of every version, the routes of the modules are registered on them
+func (s *server) versionRouters() map[string]fiber.Router {
+	routers := make(map[string]fiber.Router, len(apiVersions))
+	for _, v := range apiVersions {
+		routers[v.name] = s.app.Group(v.prefix(), v.headers())
+	}
+	return routers
+}
+
+// inheritRoutes register the routes of each version on the next version when it does not have the same
+// method and path. It is called after every module has registered its routes
+func (s *server) inheritRoutes() {
+	registered := make(map[string]bool)
+	for _, route := range s.app.GetRoutes(true) {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	for i := 1; i < len(apiVersions); i++ {
+		from, to := apiVersions[i-1], apiVersions[i]
+		// the routes of the previous version include the ones it inherited
+		for _, route := range s.app.GetRoutes(true) {
+			if findApiVersion(route.Path) != from {
+				continue
+			}
+			path := to.prefix() + strings.TrimPrefix(route.Path, from.prefix())
+			if registered[route.Method+" "+path] {
+				continue
+			}
+			registered[route.Method+" "+path] = true
+			s.app.Add(route.Method, path, route.Handlers...)
+		}
+	}
+}
+
+// Version return the router of an api version, e.g. m.Version("v2").Group("/products") for the routes whose
+// response changed in v2. m.r is the router of v1
+func (m *moduleFactory) Version(name string) fiber.Router {
+	router, ok := m.versions[name]
+	if !ok {
+		panic(fmt.Sprintf("api version %s is not in apiVersions", name))
+	}
+	return router
+}
@@ -0,0 +1,37 @@
+package servers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/appinfo/appinfoUsecases"
+)
+
+// warmCache load the category tree and the best selling products before the server is marked ready,
+// so the first requests after a deploy do not all miss the cache. A failure is logged and the server
+// becomes ready anyway, the caches then fill on the first requests
+func (s *server) warmCache(modules IModuleFactory) {
+	defer s.ready.Store(true)
+
+	if s.cfg.Cache().ProductTtl() <= 0 {
+		return
+	}
+	start := time.Now()
+	ctx := context.Background()
+
+	categoryUsecase := appinfoUsecases.AppinfoUsecase(appinfoRepositories.AppinfoRepository(s.db), s.categoryCache)
+	categories, err := categoryUsecase.FindCategory(ctx, &appinfo.CategoryFilter{})
+	if err != nil {
+		log.Printf("warm categories failed: %v", err)
+	}
+
+	warmed, err := modules.ProductsModule().Usecase().WarmCache(ctx, s.cfg.Cache().WarmProducts())
+	if err != nil {
+		log.Printf("warm products failed: %v", err)
+	}
+
+	log.Printf("cache warmed with %d categories and %d products in %v", len(categories), warmed, time.Since(start))
+}
@@ -0,0 +1,36 @@
+package shipping
+
+import "github.com/NatthawutSK/ri-shop/modules/addresses"
+
+type QuoteReq struct {
+	StoreId     string             `json:"-"`
+	Items       []*QuoteItem       `json:"items"`
+	Destination *addresses.Address `json:"destination"` // country and postal_code are required
+}
+
+type QuoteItem struct {
+	ProductId string `json:"product_id"`
+	Qty       int    `json:"qty"`
+}
+
+// Parcel is what a provider needs to price a shipment
+type Parcel struct {
+	WeightGrams int     `json:"weight_grams"`
+	Value       float64 `json:"value"` // sum of item prices in Currency
+	Currency    string  `json:"currency"`
+}
+
+type Rate struct {
+	Method        string  `json:"method"` // <provider>:<service>, sent back when placing the order
+	Provider      string  `json:"provider"`
+	Service       string  `json:"service"`
+	Name          string  `json:"name"`
+	Fee           float64 `json:"fee"`
+	Currency      string  `json:"currency"`
+	EstimatedDays int     `json:"estimated_days"`
+}
+
+type QuoteRes struct {
+	Parcel *Parcel `json:"parcel"`
+	Rates  []*Rate `json:"rates"`
+}
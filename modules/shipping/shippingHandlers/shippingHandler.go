@@ -0,0 +1,67 @@
+package shippingHandlers
+
+import (
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/modules/shipping/shippingUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type shippingHandlerErrCode string
+
+const (
+	quoteErr shippingHandlerErrCode = "shipping-001"
+)
+
+type IShippingHandler interface {
+	Quote(c *fiber.Ctx) error
+}
+
+type shippingHandler struct {
+	cfg             config.IConfig
+	shippingUsecase shippingUsecases.IShippingUsecase
+}
+
+func ShippingHandler(cfg config.IConfig, shippingUsecase shippingUsecases.IShippingUsecase) IShippingHandler {
+	return &shippingHandler{
+		cfg:             cfg,
+		shippingUsecase: shippingUsecase,
+	}
+}
+
+func (h *shippingHandler) Quote(c *fiber.Ctx) error {
+	req := &shipping.QuoteReq{
+		Items: make([]*shipping.QuoteItem, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(quoteErr),
+			err.Error(),
+		).Res()
+	}
+
+	for _, item := range req.Items {
+		if item.Qty < 1 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(quoteErr),
+				"qty is invalid",
+			).Res()
+		}
+	}
+
+	req.StoreId = c.Locals("storeId").(string)
+
+	quote, err := h.shippingUsecase.Quote(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(quoteErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, quote).Res()
+}
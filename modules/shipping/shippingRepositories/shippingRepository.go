@@ -0,0 +1,76 @@
+package shippingRepositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IShippingRepository interface {
+	FindParcel(ctx context.Context, storeId string, items []*shipping.QuoteItem) (*shipping.Parcel, error)
+}
+
+type shippingRepository struct {
+	db *sqlx.DB
+}
+
+func ShippingRepository(db *sqlx.DB) IShippingRepository {
+	return &shippingRepository{
+		db: db,
+	}
+}
+
+// FindParcel sum weight and value of the items, value is in the base currency of the products.
+// Products of other stores are not found
+func (r *shippingRepository) FindParcel(ctx context.Context, storeId string, items []*shipping.QuoteItem) (*shipping.Parcel, error) {
+	ids := make([]string, 0)
+	for _, item := range items {
+		ids = append(ids, item.ProductId)
+	}
+
+	query, args, err := sqlx.In(`
+	SELECT
+		"id",
+		"weight_grams",
+		minor_to_major("price_minor", "currency") AS "price",
+		"currency"
+	FROM "products"
+	WHERE "id" IN (?)
+	AND COALESCE("store_id", '') = ?;`, ids, storeId)
+	if err != nil {
+		return nil, fmt.Errorf("build find parcel query failed: %v", err)
+	}
+
+	products := make([]*struct {
+		Id          string  `db:"id"`
+		WeightGrams int     `db:"weight_grams"`
+		Price       float64 `db:"price"`
+		Currency    string  `db:"currency"`
+	}, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if err := r.db.SelectContext(ctx, &products, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("find parcel failed: %v", err)
+	}
+
+	productsMap := make(map[string]int)
+	for i, product := range products {
+		productsMap[product.Id] = i
+	}
+
+	parcel := new(shipping.Parcel)
+	for _, item := range items {
+		i, ok := productsMap[item.ProductId]
+		if !ok {
+			return nil, fmt.Errorf("product %s not found", item.ProductId)
+		}
+		parcel.WeightGrams += products[i].WeightGrams * item.Qty
+		parcel.Value += products[i].Price * float64(item.Qty)
+		parcel.Currency = products[i].Currency
+	}
+	return parcel, nil
+}
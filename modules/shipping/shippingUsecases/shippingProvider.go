@@ -0,0 +1,143 @@
+package shippingUsecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+)
+
+type IShippingProvider interface {
+	Code() string
+	Quote(ctx context.Context, parcel *shipping.Parcel, destination *addresses.Address) ([]*shipping.Rate, error)
+}
+
+// flatRateProvider charge the same fee for every parcel, free when the value reach freeOver
+type flatRateProvider struct {
+	cfg config.IShippingConfig
+}
+
+func FlatRateProvider(cfg config.IShippingConfig) IShippingProvider {
+	return &flatRateProvider{
+		cfg: cfg,
+	}
+}
+
+func (p *flatRateProvider) Code() string { return "flat" }
+
+func (p *flatRateProvider) Quote(ctx context.Context, parcel *shipping.Parcel, destination *addresses.Address) ([]*shipping.Rate, error) {
+	fee := p.cfg.FlatRate()
+	if p.cfg.FreeOver() > 0 && parcel.Value >= p.cfg.FreeOver() {
+		fee = 0
+	}
+
+	return []*shipping.Rate{
+		{
+			Provider:      p.Code(),
+			Service:       "standard",
+			Name:          "Standard shipping",
+			Fee:           fee,
+			Currency:      parcel.Currency,
+			EstimatedDays: 3,
+		},
+	}, nil
+}
+
+// carrierProvider ask the rate api of a carrier, the api get origin, destination and parcel as json
+// and respond {"rates": [{"service", "name", "amount", "currency", "estimated_days"}]}
+type carrierProvider struct {
+	cfg    config.IShippingConfig
+	client *http.Client
+}
+
+func CarrierProvider(cfg config.IShippingConfig) IShippingProvider {
+	return &carrierProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *carrierProvider) Code() string { return "carrier" }
+
+type carrierLocation struct {
+	Country    string `json:"country"`
+	PostalCode string `json:"postal_code"`
+}
+
+type carrierRateReq struct {
+	Origin      *carrierLocation `json:"origin"`
+	Destination *carrierLocation `json:"destination"`
+	WeightGrams int              `json:"weight_grams"`
+	Value       float64          `json:"value"`
+	Currency    string           `json:"currency"`
+}
+
+type carrierRateRes struct {
+	Rates []*struct {
+		Service       string  `json:"service"`
+		Name          string  `json:"name"`
+		Amount        float64 `json:"amount"`
+		Currency      string  `json:"currency"`
+		EstimatedDays int     `json:"estimated_days"`
+	} `json:"rates"`
+}
+
+func (p *carrierProvider) Quote(ctx context.Context, parcel *shipping.Parcel, destination *addresses.Address) ([]*shipping.Rate, error) {
+	body, err := json.Marshal(&carrierRateReq{
+		Origin: &carrierLocation{
+			Country:    p.cfg.OriginCountry(),
+			PostalCode: p.cfg.OriginPostalCode(),
+		},
+		Destination: &carrierLocation{
+			Country:    destination.Country,
+			PostalCode: destination.PostalCode,
+		},
+		WeightGrams: parcel.WeightGrams,
+		Value:       parcel.Value,
+		Currency:    parcel.Currency,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal carrier rate request failed: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.CarrierUrl(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create carrier rate request failed: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.cfg.CarrierKey())
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call carrier rate api failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("carrier rate api responded %d", res.StatusCode)
+	}
+
+	result := new(carrierRateRes)
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("decode carrier rates failed: %v", err)
+	}
+
+	rates := make([]*shipping.Rate, 0)
+	for _, rate := range result.Rates {
+		rates = append(rates, &shipping.Rate{
+			Provider:      p.Code(),
+			Service:       rate.Service,
+			Name:          rate.Name,
+			Fee:           rate.Amount,
+			Currency:      rate.Currency,
+			EstimatedDays: rate.EstimatedDays,
+		})
+	}
+	return rates, nil
+}
@@ -0,0 +1,76 @@
+package shippingUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/modules/shipping/shippingRepositories"
+)
+
+type IShippingUsecase interface {
+	Quote(ctx context.Context, req *shipping.QuoteReq) (*shipping.QuoteRes, error)
+	FindRate(ctx context.Context, req *shipping.QuoteReq, method string) (*shipping.Rate, error)
+}
+
+type shippingUsecase struct {
+	shippingRepository shippingRepositories.IShippingRepository
+	providers          []IShippingProvider
+}
+
+func ShippingUsecase(shippingRepository shippingRepositories.IShippingRepository, providers ...IShippingProvider) IShippingUsecase {
+	return &shippingUsecase{
+		shippingRepository: shippingRepository,
+		providers:          providers,
+	}
+}
+
+// Quote ask every provider for rates, a provider which fail is skipped so checkout still has the other rates
+func (u *shippingUsecase) Quote(ctx context.Context, req *shipping.QuoteReq) (*shipping.QuoteRes, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items are empty")
+	}
+	if req.Destination == nil || req.Destination.Country == "" || req.Destination.PostalCode == "" {
+		return nil, fmt.Errorf("destination country and postal code are required")
+	}
+	req.Destination.Normalize()
+
+	parcel, err := u.shippingRepository.FindParcel(ctx, req.StoreId, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]*shipping.Rate, 0)
+	for _, provider := range u.providers {
+		providerRates, err := provider.Quote(ctx, parcel, req.Destination)
+		if err != nil {
+			log.Printf("shipping provider %s quote failed: %v\n", provider.Code(), err)
+			continue
+		}
+		for _, rate := range providerRates {
+			rate.Method = fmt.Sprintf("%s:%s", rate.Provider, rate.Service)
+			rates = append(rates, rate)
+		}
+	}
+
+	return &shipping.QuoteRes{
+		Parcel: parcel,
+		Rates:  rates,
+	}, nil
+}
+
+// FindRate quote again and return the rate of the method, the fee sent by the client is never trusted
+func (u *shippingUsecase) FindRate(ctx context.Context, req *shipping.QuoteReq, method string) (*shipping.Rate, error) {
+	quote, err := u.Quote(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, rate := range quote.Rates {
+		if strings.EqualFold(rate.Method, method) {
+			return rate, nil
+		}
+	}
+	return nil, fmt.Errorf("shipping method %s is not available", method)
+}
@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	// RunRetentionDays is how long the history of the runs is kept
+	RunRetentionDays = 30
+	// StaleRunHours is when a run which never finished, e.g. its instance was killed, is marked failed
+	StaleRunHours = 24
+)
+
+// Task is a background job of a module, it runs on one instance at a time on its Schedule (see pkg/cron).
+// Run get the context of the run, the queries of the task derive their timeout from it
+type Task struct {
+	Name        string
+	Schedule    string
+	Description string
+	Run         func(ctx context.Context) error
+}
+
+// TaskRes is a task with its state for the admins
+type TaskRes struct {
+	Name        string   `json:"name"`
+	Schedule    string   `json:"schedule"`
+	Description string   `json:"description"`
+	Paused      bool     `json:"paused"`
+	NextRunAt   string   `json:"next_run_at,omitempty"`
+	LastRun     *TaskRun `json:"last_run"`
+}
+
+type TaskRun struct {
+	Id          int64  `json:"id" db:"id"`
+	Task        string `json:"task" db:"task"`
+	Trigger     string `json:"trigger" db:"trigger"`
+	TriggeredBy string `json:"triggered_by,omitempty" db:"triggered_by"`
+	Status      string `json:"status" db:"status"`
+	Instance    string `json:"instance" db:"instance"`
+	Error       string `json:"error,omitempty" db:"error"`
+	StartedAt   string `json:"started_at" db:"started_at"`
+	FinishedAt  string `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+type PauseReq struct {
+	Paused *bool `json:"paused"`
+}
+
+// RunningError is returned when a task is triggered while it is running on an instance
+type RunningError struct {
+	Name string
+}
+
+func (e *RunningError) Error() string {
+	return fmt.Sprintf("task %s is running", e.Name)
+}
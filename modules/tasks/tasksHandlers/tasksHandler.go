@@ -0,0 +1,149 @@
+package tasksHandlers
+
+import (
+	"errors"
+
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type tasksHandlerErrCode string
+
+const (
+	findTasksErr    tasksHandlerErrCode = "tasks-001"
+	findRunsErr     tasksHandlerErrCode = "tasks-002"
+	triggerTaskErr  tasksHandlerErrCode = "tasks-003"
+	updatePausedErr tasksHandlerErrCode = "tasks-004"
+)
+
+type ITasksHandler interface {
+	FindTasks(c *fiber.Ctx) error
+	FindRuns(c *fiber.Ctx) error
+	TriggerTask(c *fiber.Ctx) error
+	UpdatePaused(c *fiber.Ctx) error
+}
+
+type tasksHandler struct {
+	tasksUsecase  tasksUsecases.ITasksUsecase
+	auditsUsecase auditsUsecases.IAuditsUsecase
+}
+
+func TasksHandler(tasksUsecase tasksUsecases.ITasksUsecase, auditsUsecase auditsUsecases.IAuditsUsecase) ITasksHandler {
+	return &tasksHandler{
+		tasksUsecase:  tasksUsecase,
+		auditsUsecase: auditsUsecase,
+	}
+}
+
+func (h *tasksHandler) FindTasks(c *fiber.Ctx) error {
+	res, err := h.tasksUsecase.FindTasks(c.UserContext())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findTasksErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, res).Res()
+}
+
+func (h *tasksHandler) FindRuns(c *fiber.Ctx) error {
+	res, err := h.tasksUsecase.FindRuns(c.UserContext(), c.Params("name"), c.QueryInt("limit", 20))
+	if err != nil {
+		if err.Error() == "task not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findRunsErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findRunsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, res).Res()
+}
+
+// TriggerTask run the task now, the response is sent once the run is recorded and the result is in its history
+func (h *tasksHandler) TriggerTask(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := h.tasksUsecase.Trigger(name, c.Locals("userId").(string)); err != nil {
+		var runningErr *tasks.RunningError
+		switch {
+		case err.Error() == "task not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(triggerTaskErr),
+				err.Error(),
+			).Res()
+		case errors.As(err, &runningErr):
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(triggerTaskErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(triggerTaskErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusAccepted, fiber.Map{"task": name}).Res()
+}
+
+// UpdatePaused pause or resume the task on every instance, a paused task can still be triggered
+func (h *tasksHandler) UpdatePaused(c *fiber.Ctx) error {
+	req := new(tasks.PauseReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updatePausedErr),
+			err.Error(),
+		).Res()
+	}
+	if req.Paused == nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updatePausedErr),
+			"paused is required",
+		).Res()
+	}
+
+	userId := c.Locals("userId").(string)
+	res, err := h.tasksUsecase.UpdatePaused(c.UserContext(), c.Params("name"), *req.Paused, userId)
+	if err != nil {
+		if err.Error() == "task not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updatePausedErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(updatePausedErr),
+			err.Error(),
+		).Res()
+	}
+
+	action := audits.TaskResume
+	if res.Paused {
+		action = audits.TaskPause
+	}
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  userId,
+		Action:   action,
+		Entity:   "task",
+		EntityId: res.Name,
+		After:    req,
+	})
+	return entities.NewResponse(c).Success(fiber.StatusOK, res).Res()
+}
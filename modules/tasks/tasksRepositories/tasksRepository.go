@@ -0,0 +1,200 @@
+package tasksRepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type ITasksRepository interface {
+	FindPaused(ctx context.Context) (map[string]bool, error)
+	IsPaused(ctx context.Context, name string) (bool, error)
+	UpdatePaused(ctx context.Context, name string, paused bool, userId string) error
+	FindLastRuns(ctx context.Context) (map[string]*tasks.TaskRun, error)
+	FindRuns(ctx context.Context, name string, limit int) ([]*tasks.TaskRun, error)
+	InsertRun(ctx context.Context, req *tasks.TaskRun) error
+	FinishRun(ctx context.Context, req *tasks.TaskRun) error
+	DeleteOldRuns(ctx context.Context, retentionDays, staleHours int) (int64, error)
+}
+
+type tasksRepository struct {
+	db *sqlx.DB
+}
+
+func TasksRepository(db *sqlx.DB) ITasksRepository {
+	return &tasksRepository{
+		db: db,
+	}
+}
+
+const runColumns = `
+		"id",
+		"task",
+		"trigger",
+		COALESCE("triggered_by", '') AS "triggered_by",
+		"status",
+		"instance",
+		COALESCE("error", '') AS "error",
+		to_char("started_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "started_at",
+		COALESCE(to_char("finished_at", 'YYYY-MM-DD"T"HH24:MI:SS'), '') AS "finished_at"`
+
+func (r *tasksRepository) FindPaused(ctx context.Context) (map[string]bool, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	names := make([]string, 0)
+	if err := r.db.SelectContext(ctx, &names, `SELECT "name" FROM "tasks" WHERE "paused";`); err != nil {
+		return nil, fmt.Errorf("find paused tasks failed: %v", err)
+	}
+	paused := make(map[string]bool, len(names))
+	for _, name := range names {
+		paused[name] = true
+	}
+	return paused, nil
+}
+
+func (r *tasksRepository) IsPaused(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var paused bool
+	if err := r.db.GetContext(ctx, &paused, `
+	SELECT
+		EXISTS (SELECT 1 FROM "tasks" WHERE "name" = $1 AND "paused");`, name); err != nil {
+		return false, fmt.Errorf("find task failed: %v", err)
+	}
+	return paused, nil
+}
+
+func (r *tasksRepository) UpdatePaused(ctx context.Context, name string, paused bool, userId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "tasks" (
+		"name",
+		"paused",
+		"updated_by"
+	)
+	VALUES ($1, $2, NULLIF($3, ''))
+	ON CONFLICT ("name") DO UPDATE
+	SET "paused" = EXCLUDED."paused",
+		"updated_by" = EXCLUDED."updated_by",
+		"updated_at" = now();`
+
+	if _, err := r.db.ExecContext(ctx, query, name, paused, userId); err != nil {
+		return fmt.Errorf("update task failed: %v", err)
+	}
+	return nil
+}
+
+// FindLastRuns return the latest run of every task which has run
+func (r *tasksRepository) FindLastRuns(ctx context.Context) (map[string]*tasks.TaskRun, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT DISTINCT ON ("task")` + runColumns + `
+	FROM "task_runs"
+	ORDER BY "task", "started_at" DESC, "id" DESC;`
+
+	runs := make([]*tasks.TaskRun, 0)
+	if err := r.db.SelectContext(ctx, &runs, query); err != nil {
+		return nil, fmt.Errorf("find last runs failed: %v", err)
+	}
+	lastRuns := make(map[string]*tasks.TaskRun, len(runs))
+	for _, run := range runs {
+		lastRuns[run.Task] = run
+	}
+	return lastRuns, nil
+}
+
+func (r *tasksRepository) FindRuns(ctx context.Context, name string, limit int) ([]*tasks.TaskRun, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT` + runColumns + `
+	FROM "task_runs"
+	WHERE "task" = $1
+	ORDER BY "started_at" DESC, "id" DESC
+	LIMIT $2;`
+
+	runs := make([]*tasks.TaskRun, 0)
+	if err := r.db.SelectContext(ctx, &runs, query, name, limit); err != nil {
+		return nil, fmt.Errorf("find runs failed: %v", err)
+	}
+	return runs, nil
+}
+
+func (r *tasksRepository) InsertRun(ctx context.Context, req *tasks.TaskRun) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "task_runs" (
+		"task",
+		"trigger",
+		"triggered_by",
+		"status",
+		"instance"
+	)
+	VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+	RETURNING "id", to_char("started_at", 'YYYY-MM-DD"T"HH24:MI:SS');`
+
+	if err := r.db.QueryRowContext(ctx, query, req.Task, req.Trigger, req.TriggeredBy, req.Status, req.Instance).Scan(&req.Id, &req.StartedAt); err != nil {
+		return fmt.Errorf("insert run failed: %v", err)
+	}
+	return nil
+}
+
+func (r *tasksRepository) FinishRun(ctx context.Context, req *tasks.TaskRun) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "task_runs" SET
+		"status" = $2,
+		"error" = NULLIF($3, ''),
+		"finished_at" = now()
+	WHERE "id" = $1
+	RETURNING to_char("finished_at", 'YYYY-MM-DD"T"HH24:MI:SS');`
+
+	if err := r.db.QueryRowContext(ctx, query, req.Id, req.Status, req.Error).Scan(&req.FinishedAt); err != nil {
+		return fmt.Errorf("finish run failed: %v", err)
+	}
+	return nil
+}
+
+// DeleteOldRuns delete the history older than retentionDays, a run which is still running after staleHours
+// never finished and is marked failed
+func (r *tasksRepository) DeleteOldRuns(ctx context.Context, retentionDays, staleHours int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `
+	UPDATE "task_runs" SET
+		"status" = 'failed',
+		"error" = 'the run did not finish',
+		"finished_at" = now()
+	WHERE "status" = 'running'
+	AND "started_at" < now() - make_interval(hours => $1);`, staleHours); err != nil {
+		return 0, fmt.Errorf("fail stale runs failed: %v", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+	DELETE FROM "task_runs"
+	WHERE "started_at" < now() - make_interval(days => $1);`, retentionDays)
+	if err != nil {
+		return 0, fmt.Errorf("delete old runs failed: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete old runs failed: %v", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,264 @@
+package tasksUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/cron"
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+)
+
+// tickInterval is how often the scheduler check which tasks are due
+const tickInterval = time.Second
+
+type ITasksUsecase interface {
+	// Register add a task of a module, it panics on a schedule which cannot be parsed
+	Register(task *tasks.Task)
+	// Start run the tasks on their schedule, must be called in a goroutine after the tasks are registered
+	Start()
+	// RunDue start the tasks which are due at now, Start call it every tickInterval
+	RunDue(now time.Time)
+	FindTasks(ctx context.Context) ([]*tasks.TaskRes, error)
+	FindRuns(ctx context.Context, name string, limit int) ([]*tasks.TaskRun, error)
+	Trigger(name, userId string) error
+	UpdatePaused(ctx context.Context, name string, paused bool, userId string) (*tasks.TaskRes, error)
+}
+
+// entry is a registered task with the time it is due next on this instance
+type entry struct {
+	task     *tasks.Task
+	schedule cron.Schedule
+	next     time.Time
+	running  atomic.Bool
+}
+
+type tasksUsecase struct {
+	tasksRepository tasksRepositories.ITasksRepository
+	locker          locks.ILocker
+	instance        string
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func TasksUsecase(tasksRepository tasksRepositories.ITasksRepository, locker locks.ILocker) ITasksUsecase {
+	hostname, _ := os.Hostname()
+	return &tasksUsecase{
+		tasksRepository: tasksRepository,
+		locker:          locker,
+		instance:        fmt.Sprintf("%s/%d", hostname, os.Getpid()),
+		entries:         make(map[string]*entry),
+	}
+}
+
+func (u *tasksUsecase) Register(task *tasks.Task) {
+	schedule, err := cron.Parse(task.Schedule)
+	if err != nil {
+		panic(fmt.Sprintf("register task %s failed: %v", task.Name, err))
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.entries[task.Name] = &entry{
+		task:     task,
+		schedule: schedule,
+		next:     schedule.Next(time.Now()),
+	}
+}
+
+func (u *tasksUsecase) Start() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		u.RunDue(now)
+	}
+}
+
+func (u *tasksUsecase) RunDue(now time.Time) {
+	u.mu.Lock()
+	due := make([]*entry, 0)
+	for _, e := range u.entries {
+		if e.next.IsZero() || now.Before(e.next) {
+			continue
+		}
+		e.next = e.schedule.Next(now)
+		due = append(due, e)
+	}
+	u.mu.Unlock()
+
+	for _, e := range due {
+		go func(e *entry) {
+			ctx := context.Background()
+			// หยุดชั่วคราวได้จากทุก instance จึงอ่านจาก database ทุกครั้ง
+			paused, err := u.tasksRepository.IsPaused(ctx, e.task.Name)
+			if err != nil {
+				log.Printf("task %s failed: %v\n", e.task.Name, err)
+				return
+			}
+			if paused {
+				return
+			}
+			if _, err := u.run(ctx, e, tasks.TriggerSchedule, "", nil); err != nil {
+				log.Printf("task %s failed: %v\n", e.task.Name, err)
+			}
+		}(e)
+	}
+}
+
+// run the task when no instance is running it, the lock is the name of the task. ran is false when it
+// was skipped, started is called once the run is recorded
+func (u *tasksUsecase) run(ctx context.Context, e *entry, trigger, userId string, started func()) (ran bool, err error) {
+	if !e.running.CompareAndSwap(false, true) {
+		return false, nil
+	}
+	defer e.running.Store(false)
+
+	return u.locker.TryRun(e.task.Name, func() error {
+		run := &tasks.TaskRun{
+			Task:        e.task.Name,
+			Trigger:     trigger,
+			TriggeredBy: userId,
+			Status:      tasks.StatusRunning,
+			Instance:    u.instance,
+		}
+		if err := u.tasksRepository.InsertRun(ctx, run); err != nil {
+			return err
+		}
+		if started != nil {
+			started()
+		}
+
+		runErr := call(ctx, e.task.Run)
+		run.Status = tasks.StatusSucceeded
+		if runErr != nil {
+			run.Status = tasks.StatusFailed
+			run.Error = runErr.Error()
+		}
+		if err := u.tasksRepository.FinishRun(ctx, run); err != nil {
+			log.Printf("record run of task %s failed: %v\n", e.task.Name, err)
+		}
+		return runErr
+	})
+}
+
+// call fn, a panic of a task fail the run instead of the server
+func call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+func (u *tasksUsecase) find(name string) (*entry, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	e, ok := u.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("task not found")
+	}
+	return e, nil
+}
+
+func (u *tasksUsecase) taskRes(e *entry, paused bool, lastRun *tasks.TaskRun) *tasks.TaskRes {
+	res := &tasks.TaskRes{
+		Name:        e.task.Name,
+		Schedule:    e.task.Schedule,
+		Description: e.task.Description,
+		Paused:      paused,
+		LastRun:     lastRun,
+	}
+	if !paused && !e.next.IsZero() {
+		res.NextRunAt = e.next.Format(time.RFC3339)
+	}
+	return res
+}
+
+func (u *tasksUsecase) FindTasks(ctx context.Context) ([]*tasks.TaskRes, error) {
+	paused, err := u.tasksRepository.FindPaused(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastRuns, err := u.tasksRepository.FindLastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	res := make([]*tasks.TaskRes, 0, len(u.entries))
+	for name, e := range u.entries {
+		res = append(res, u.taskRes(e, paused[name], lastRuns[name]))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+func (u *tasksUsecase) FindRuns(ctx context.Context, name string, limit int) ([]*tasks.TaskRun, error) {
+	if _, err := u.find(name); err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return u.tasksRepository.FindRuns(ctx, name, limit)
+}
+
+// Trigger run the task now in the background, a paused task can be run too. It return a RunningError
+// when the task is running on any instance. The run outlives the request so it has its own context
+func (u *tasksUsecase) Trigger(name, userId string) error {
+	e, err := u.find(name)
+	if err != nil {
+		return err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		started := false
+		ran, err := u.run(context.Background(), e, tasks.TriggerManual, userId, func() {
+			started = true
+			result <- nil
+		})
+		if started {
+			if err != nil {
+				log.Printf("task %s failed: %v\n", name, err)
+			}
+			return
+		}
+		if !ran && err == nil {
+			err = &tasks.RunningError{Name: name}
+		}
+		result <- err
+	}()
+	return <-result
+}
+
+func (u *tasksUsecase) UpdatePaused(ctx context.Context, name string, paused bool, userId string) (*tasks.TaskRes, error) {
+	e, err := u.find(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.tasksRepository.UpdatePaused(ctx, name, paused, userId); err != nil {
+		return nil, err
+	}
+	lastRuns, err := u.tasksRepository.FindLastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.taskRes(e, paused, lastRuns[name]), nil
+}
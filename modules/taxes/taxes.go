@@ -0,0 +1,77 @@
+package taxes
+
+import "math"
+
+// Rate is a tax rule, the most specific rule of the region wins and a category rule beats the
+// general rule of the same region. Without any rule APP_TAX_RATE is used
+type Rate struct {
+	Id         int     `json:"id" db:"id"`
+	Name       string  `json:"name" db:"name"`               // shown on the breakdown, e.g. VAT
+	Country    string  `json:"country" db:"country"`         // ISO 3166-1 alpha-2, empty is every country
+	State      string  `json:"state" db:"state"`             // province, empty is the whole country
+	CategoryId int     `json:"category_id" db:"category_id"` // 0 is every category
+	Rate       float64 `json:"rate" db:"rate"`               // e.g. 0.07
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+}
+
+type RateFilter struct {
+	Country string `query:"country"`
+}
+
+// CalculateReq is priced lines after discounts, tax is added on top
+type CalculateReq struct {
+	Country     string           `json:"country"`
+	State       string           `json:"state"`
+	Currency    string           `json:"currency"`
+	Lines       []*CalculateLine `json:"lines"`
+	ShippingFee float64          `json:"shipping_fee"`
+}
+
+type CalculateLine struct {
+	ProductId  string  `json:"product_id"`
+	CategoryId int     `json:"category_id"`
+	Amount     float64 `json:"amount"` // qty * unit price - the share of the discounts
+}
+
+type LineTax struct {
+	ProductId string  `json:"product_id"`
+	Name      string  `json:"name,omitempty"`
+	Taxable   float64 `json:"taxable"`
+	Rate      float64 `json:"rate"`
+	Tax       float64 `json:"tax"`
+}
+
+// Breakdown is the tax of an order or a quote, kept with the order so later rate changes do not change it
+type Breakdown struct {
+	Provider     string     `json:"provider"`
+	Lines        []*LineTax `json:"lines"`
+	ShippingRate float64    `json:"shipping_rate"`
+	ShippingTax  float64    `json:"shipping_tax"`
+	Total        float64    `json:"total"`
+}
+
+// AllocateDiscount take discount off the lines by their share of the amount, the last line take the rounding
+func (r *CalculateReq) AllocateDiscount(discount float64) {
+	var total float64
+	for _, line := range r.Lines {
+		total += line.Amount
+	}
+	if discount <= 0 || total <= 0 {
+		return
+	}
+	discount = math.Min(discount, total)
+
+	left := discount
+	for i, line := range r.Lines {
+		share := round(discount * line.Amount / total)
+		if i == len(r.Lines)-1 {
+			share = round(left)
+		}
+		left -= share
+		line.Amount = round(line.Amount - share)
+	}
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,125 @@
+package taxesHandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type taxesHandlerErrCode string
+
+const (
+	findRateErr   taxesHandlerErrCode = "taxes-001"
+	insertRateErr taxesHandlerErrCode = "taxes-002"
+	deleteRateErr taxesHandlerErrCode = "taxes-003"
+)
+
+type ITaxesHandler interface {
+	FindRate(c *fiber.Ctx) error
+	AddRate(c *fiber.Ctx) error
+	DeleteRate(c *fiber.Ctx) error
+}
+
+type taxesHandler struct {
+	cfg          config.IConfig
+	taxesUsecase taxesUsecases.ITaxesUsecase
+}
+
+func TaxesHandler(cfg config.IConfig, taxesUsecase taxesUsecases.ITaxesUsecase) ITaxesHandler {
+	return &taxesHandler{
+		cfg:          cfg,
+		taxesUsecase: taxesUsecase,
+	}
+}
+
+func (h *taxesHandler) FindRate(c *fiber.Ctx) error {
+	req := new(taxes.RateFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	rates, err := h.taxesUsecase.FindRate(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, rates).Res()
+}
+
+func (h *taxesHandler) AddRate(c *fiber.Ctx) error {
+	req := new(taxes.Rate)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	rate, err := h.taxesUsecase.AddRate(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "tax rate of this region and category already exists":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(insertRateErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "insert tax rate failed"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertRateErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertRateErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, rate).Res()
+}
+
+func (h *taxesHandler) DeleteRate(c *fiber.Ctx) error {
+	rateId, err := strconv.Atoi(strings.TrimSpace(c.Params("rateId")))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteRateErr),
+			"rate id is invalid",
+		).Res()
+	}
+
+	if err := h.taxesUsecase.DeleteRate(c.UserContext(), rateId); err != nil {
+		if err.Error() == "tax rate not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteRateErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(deleteRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
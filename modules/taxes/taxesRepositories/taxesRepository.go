@@ -0,0 +1,121 @@
+package taxesRepositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type ITaxesRepository interface {
+	FindRate(ctx context.Context, req *taxes.RateFilter) ([]*taxes.Rate, error)
+	FindRegionRate(ctx context.Context, country, state string) ([]*taxes.Rate, error)
+	InsertRate(ctx context.Context, req *taxes.Rate) (*taxes.Rate, error)
+	DeleteRate(ctx context.Context, rateId int) error
+}
+
+type taxesRepository struct {
+	db *sqlx.DB
+}
+
+func TaxesRepository(db *sqlx.DB) ITaxesRepository {
+	return &taxesRepository{
+		db: db,
+	}
+}
+
+const rateColumns = `
+		"id",
+		"name",
+		"country",
+		"state",
+		COALESCE("category_id", 0) AS "category_id",
+		"rate",
+		"created_at"`
+
+func (r *taxesRepository) FindRate(ctx context.Context, req *taxes.RateFilter) ([]*taxes.Rate, error) {
+	query := `
+	SELECT` + rateColumns + `
+	FROM "tax_rates"`
+
+	args := make([]any, 0)
+	if req.Country != "" {
+		query += `
+	WHERE "country" = $1`
+		args = append(args, strings.ToUpper(req.Country))
+	}
+	query += `
+	ORDER BY "country", "state", "category_id" NULLS FIRST, "id";`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rates := make([]*taxes.Rate, 0)
+	if err := r.db.SelectContext(ctx, &rates, query, args...); err != nil {
+		return nil, fmt.Errorf("find tax rates failed: %v", err)
+	}
+	return rates, nil
+}
+
+// FindRegionRate return every rule which can apply to the region, from the whole world to the state
+func (r *taxesRepository) FindRegionRate(ctx context.Context, country, state string) ([]*taxes.Rate, error) {
+	query := `
+	SELECT` + rateColumns + `
+	FROM "tax_rates"
+	WHERE "country" IN ('', $1)
+	AND "state" IN ('', $2);`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rates := make([]*taxes.Rate, 0)
+	if err := r.db.SelectContext(ctx, &rates, query, strings.ToUpper(country), strings.ToUpper(state)); err != nil {
+		return nil, fmt.Errorf("find tax rates failed: %v", err)
+	}
+	return rates, nil
+}
+
+func (r *taxesRepository) InsertRate(ctx context.Context, req *taxes.Rate) (*taxes.Rate, error) {
+	query := `
+	INSERT INTO "tax_rates" (
+		"name",
+		"country",
+		"state",
+		"category_id",
+		"rate"
+	)
+	VALUES ($1, $2, $3, NULLIF($4, 0), $5)
+		RETURNING` + rateColumns + `;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rate := new(taxes.Rate)
+	if err := r.db.GetContext(ctx, rate, query, req.Name, req.Country, req.State, req.CategoryId, req.Rate); err != nil {
+		if strings.Contains(err.Error(), "tax_rates_region_category_idx") {
+			return nil, fmt.Errorf("tax rate of this region and category already exists")
+		}
+		if strings.Contains(err.Error(), "tax_rates_category_id_fkey") {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, fmt.Errorf("insert tax rate failed: %v", err)
+	}
+	return rate, nil
+}
+
+func (r *taxesRepository) DeleteRate(ctx context.Context, rateId int) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM "tax_rates" WHERE "id" = $1;`, rateId)
+	if err != nil {
+		return fmt.Errorf("delete tax rate failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("tax rate not found")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package taxesUsecases
+
+import (
+	"context"
+	"math"
+
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesRepositories"
+)
+
+// ITaxProvider calculate the tax of priced lines, an external tax api can implement it in place of the rate table
+type ITaxProvider interface {
+	Code() string
+	Calculate(ctx context.Context, req *taxes.CalculateReq) (*taxes.Breakdown, error)
+}
+
+// rateTableProvider use the rules in tax_rates, defaultRate is used when no rule match
+type rateTableProvider struct {
+	taxesRepository taxesRepositories.ITaxesRepository
+	defaultRate     float64
+}
+
+func RateTableProvider(taxesRepository taxesRepositories.ITaxesRepository, defaultRate float64) ITaxProvider {
+	return &rateTableProvider{
+		taxesRepository: taxesRepository,
+		defaultRate:     defaultRate,
+	}
+}
+
+func (p *rateTableProvider) Code() string { return "table" }
+
+func (p *rateTableProvider) Calculate(ctx context.Context, req *taxes.CalculateReq) (*taxes.Breakdown, error) {
+	rates, err := p.taxesRepository.FindRegionRate(ctx, req.Country, req.State)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &taxes.Breakdown{
+		Provider: p.Code(),
+		Lines:    make([]*taxes.LineTax, 0, len(req.Lines)),
+	}
+	for _, line := range req.Lines {
+		rate := p.match(rates, line.CategoryId)
+		tax := &taxes.LineTax{
+			ProductId: line.ProductId,
+			Name:      rate.Name,
+			Taxable:   line.Amount,
+			Rate:      rate.Rate,
+			Tax:       round(line.Amount * rate.Rate),
+		}
+		breakdown.Lines = append(breakdown.Lines, tax)
+		breakdown.Total += tax.Tax
+	}
+
+	// shipping only use the rules of every category
+	shippingRate := p.match(rates, 0)
+	breakdown.ShippingRate = shippingRate.Rate
+	breakdown.ShippingTax = round(req.ShippingFee * shippingRate.Rate)
+	breakdown.Total = round(breakdown.Total + breakdown.ShippingTax)
+	return breakdown, nil
+}
+
+// match return the most specific rule for the category: state over country over the whole world,
+// then a rule of the category over the rule of every category
+func (p *rateTableProvider) match(rates []*taxes.Rate, categoryId int) *taxes.Rate {
+	best := &taxes.Rate{Rate: p.defaultRate}
+	bestScore := -1
+	for _, rate := range rates {
+		if rate.CategoryId != 0 && rate.CategoryId != categoryId {
+			continue
+		}
+		score := 0
+		if rate.State != "" {
+			score += 4
+		}
+		if rate.Country != "" {
+			score += 2
+		}
+		if rate.CategoryId != 0 {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rate, score
+		}
+	}
+	return best
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
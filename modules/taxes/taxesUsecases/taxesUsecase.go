@@ -0,0 +1,71 @@
+package taxesUsecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesRepositories"
+)
+
+type ITaxesUsecase interface {
+	FindRate(ctx context.Context, req *taxes.RateFilter) ([]*taxes.Rate, error)
+	AddRate(ctx context.Context, req *taxes.Rate) (*taxes.Rate, error)
+	DeleteRate(ctx context.Context, rateId int) error
+	Calculate(ctx context.Context, req *taxes.CalculateReq) (*taxes.Breakdown, error)
+}
+
+type taxesUsecase struct {
+	taxesRepository taxesRepositories.ITaxesRepository
+	provider        ITaxProvider
+}
+
+func TaxesUsecase(taxesRepository taxesRepositories.ITaxesRepository, provider ITaxProvider) ITaxesUsecase {
+	return &taxesUsecase{
+		taxesRepository: taxesRepository,
+		provider:        provider,
+	}
+}
+
+func (u *taxesUsecase) FindRate(ctx context.Context, req *taxes.RateFilter) ([]*taxes.Rate, error) {
+	return u.taxesRepository.FindRate(ctx, req)
+}
+
+func (u *taxesUsecase) AddRate(ctx context.Context, req *taxes.Rate) (*taxes.Rate, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = "Tax"
+	}
+	req.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+	req.State = strings.ToUpper(strings.TrimSpace(req.State))
+	if req.Country != "" && len(req.Country) != 2 {
+		return nil, fmt.Errorf("country must be an ISO 3166-1 alpha-2 code")
+	}
+	if req.State != "" && req.Country == "" {
+		return nil, fmt.Errorf("state requires a country")
+	}
+	if req.CategoryId < 0 {
+		return nil, fmt.Errorf("category id is invalid")
+	}
+	if req.Rate < 0 || req.Rate >= 1 {
+		return nil, fmt.Errorf("rate must be from 0 to less than 1, e.g. 0.07")
+	}
+	return u.taxesRepository.InsertRate(ctx, req)
+}
+
+func (u *taxesUsecase) DeleteRate(ctx context.Context, rateId int) error {
+	return u.taxesRepository.DeleteRate(ctx, rateId)
+}
+
+// Calculate itemize the tax of every line and the shipping fee with the configured provider
+func (u *taxesUsecase) Calculate(ctx context.Context, req *taxes.CalculateReq) (*taxes.Breakdown, error) {
+	req.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+	req.State = strings.ToUpper(strings.TrimSpace(req.State))
+
+	breakdown, err := u.provider.Calculate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("calculate tax with %s failed: %v", u.provider.Code(), err)
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,65 @@
+package users
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// IsSupportedHash report whether ComparePassword can verify the hash, used to validate imported users
+func IsSupportedHash(hash string) bool {
+	return isBcrypt(hash) || strings.HasPrefix(hash, "$argon2id$")
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// ComparePassword verify a bcrypt hash or an argon2id hash imported from the previous platform
+func ComparePassword(hash, password string) error {
+	if isBcrypt(hash) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	}
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return compareArgon2id(hash, password)
+	}
+	return fmt.Errorf("hash is not supported")
+}
+
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func compareArgon2id(hash, password string) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return fmt.Errorf("argon2id hash is invalid")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return fmt.Errorf("argon2id version is not supported")
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return fmt.Errorf("argon2id params are invalid")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return fmt.Errorf("argon2id salt is invalid")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return fmt.Errorf("argon2id key is invalid")
+	}
+
+	other := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return fmt.Errorf("password does not match")
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ type User struct {
 	Email    string `db:"email" json:"email"`
 	Username string `db:"username" json:"username"`
 	RoleId   int `db:"role_id" json:"role_id"`
+	StoreId  string `db:"store_id" json:"store_id,omitempty"` // empty for the default store
 }
 
 type UserRegisterReq struct {
@@ -26,11 +27,51 @@ type UserCredentialCheck struct {
 	Password string `db:"password" json:"password"`
 	Username string `db:"username" json:"username"`
 	RoleId   int `db:"role_id" json:"role_id"`
+	PasswordResetRequired bool `db:"password_reset_required" json:"password_reset_required"`
+	StoreId string `db:"store_id" json:"store_id"`
 }
 
 type UserCredential struct {
 	Email string `db:"email" json:"email" form:"email"`
 	Password string `db:"password" json:"password" form:"password"`
+	Ip string `json:"-"` // ip of the request, kept with a failed sign in
+}
+
+// LoginAttempt is the failed sign ins of an email since its last successful sign in
+type LoginAttempt struct {
+	Email string `db:"email" json:"email"`
+	UserId string `db:"user_id" json:"user_id,omitempty"` // empty for an email without a user
+	Username string `db:"username" json:"username,omitempty"`
+	Failures int `db:"failures" json:"failures"`
+	LockedUntil string `db:"locked_until" json:"locked_until,omitempty"`
+	Locked bool `db:"locked" json:"locked"`
+	LockedSeconds int `db:"locked_seconds" json:"-"` // seconds left of the lock
+	CaptchaRequired bool `db:"-" json:"captcha_required"`
+	LastFailedAt string `db:"last_failed_at" json:"last_failed_at"`
+	LastIp string `db:"last_ip" json:"last_ip"`
+}
+
+// LockedError is returned when an email is locked after too many failed sign ins
+type LockedError struct {
+	RetryAfter int // seconds
+}
+
+func (e *LockedError) Error() string {
+	return "account is locked"
+}
+
+// SignInError is a failed sign in, CaptchaRequired tell the client to ask for a captcha before the next try
+type SignInError struct {
+	Msg string
+	CaptchaRequired bool
+}
+
+func (e *SignInError) Error() string {
+	return e.Msg
+}
+
+type UserUnlockReq struct {
+	Email string `json:"email" form:"email"`
 }
 
 func (obj *UserRegisterReq) BcryptHashing() error {
@@ -67,6 +108,7 @@ type UserToken struct {
 type UserClaims struct {
 	Id string `json:"id" db:"id"`
 	RoleId int `json:"role" db:"role"`
+	StoreId string `json:"store,omitempty" db:"store"` // tenant of the user, empty for the default store
 }
 
 
@@ -81,4 +123,31 @@ type Oauth struct {
 
 type UserRemoveCredential struct {
 	OauthId string `db:"id" json:"oauth_id" form:"oauth_id"`
-}
\ No newline at end of file
+}
+
+type UserLocaleReq struct {
+	UserId string `json:"user_id"`
+	Locale string `json:"locale" form:"locale"` // preferred locale of error messages, e.g. en, th
+}
+
+// UserImport is one row of the import csv
+type UserImport struct {
+	Line                  int      `json:"line"`
+	Email                 string   `json:"email"`
+	Username              string   `json:"username"`
+	Password              string   `json:"-"` // bcrypt or argon2id hash
+	PasswordResetRequired bool     `json:"password_reset_required"`
+	CreatedAt             string   `json:"created_at"`
+	LegacyId              string   `json:"legacy_id"`
+	LegacyOrderRefs       []string `json:"legacy_order_refs"`
+	Errors                []string `json:"errors"`
+}
+
+type UserImportReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Total    int           `json:"total"`
+	Valid    int           `json:"valid"`
+	Invalid  int           `json:"invalid"`
+	Imported int           `json:"imported"` // 0 unless every row is valid, the import is all or nothing
+	Rows     []*UserImport `json:"rows"`     // invalid rows only
+}
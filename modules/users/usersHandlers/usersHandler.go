@@ -1,9 +1,14 @@
 package usersHandlers
 
 import (
+	"errors"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/audits"
+	"github.com/NatthawutSK/ri-shop/modules/audits/auditsUsecases"
 	"github.com/NatthawutSK/ri-shop/modules/entities"
 	"github.com/NatthawutSK/ri-shop/modules/users"
 	"github.com/NatthawutSK/ri-shop/modules/users/usersUsecases"
@@ -21,6 +26,10 @@ const (
 	signUpAdminErr        userHandlerErrCode = "users-005"
 	generateAdminTokenErr userHandlerErrCode = "users-006"
 	getUserProfileErr     userHandlerErrCode = "users-007"
+	updateLocaleErr       userHandlerErrCode = "users-008"
+	importUsersErr        userHandlerErrCode = "users-009"
+	findLockedLoginsErr   userHandlerErrCode = "users-010"
+	unlockLoginErr        userHandlerErrCode = "users-011"
 )
 
 type IUsersHandler interface {
@@ -31,17 +40,23 @@ type IUsersHandler interface {
 	SignOut(c *fiber.Ctx) error
 	GenerateAdminToken(c *fiber.Ctx) error
 	GetUserProfile(c *fiber.Ctx) error
+	UpdateLocale(c *fiber.Ctx) error
+	ImportUsers(c *fiber.Ctx) error
+	FindLockedLogins(c *fiber.Ctx) error
+	UnlockLogin(c *fiber.Ctx) error
 }
 
 type usersHandler struct {
-	cfg         config.IConfig
-	userUsecase usersUsecases.IUserUsecase
+	cfg           config.IConfig
+	userUsecase   usersUsecases.IUserUsecase
+	auditsUsecase auditsUsecases.IAuditsUsecase
 }
 
-func UsersHandler(cfg config.IConfig, UserUsecase usersUsecases.IUserUsecase) IUsersHandler {
+func UsersHandler(cfg config.IConfig, UserUsecase usersUsecases.IUserUsecase, auditsUsecase auditsUsecases.IAuditsUsecase) IUsersHandler {
 	return &usersHandler{
-		cfg:         cfg,
-		userUsecase: UserUsecase,
+		cfg:           cfg,
+		userUsecase:   UserUsecase,
+		auditsUsecase: auditsUsecase,
 	}
 }
 
@@ -66,7 +81,7 @@ func (h *usersHandler) SignUpCustomer(c *fiber.Ctx) error {
 	}
 
 	// Insert user
-	result, err := h.userUsecase.InsertCustomer(req)
+	result, err := h.userUsecase.InsertCustomer(c.UserContext(), req)
 	if err != nil {
 		switch err.Error() {
 		case "username has been used":
@@ -115,7 +130,7 @@ func (h *usersHandler) SignUpAdmin(c *fiber.Ctx) error {
 	}
 
 	// Insert user
-	result, err := h.userUsecase.InsertAdmin(req)
+	result, err := h.userUsecase.InsertAdmin(c.UserContext(), req)
 	if err != nil {
 		switch err.Error() {
 		case "username has been used":
@@ -140,6 +155,15 @@ func (h *usersHandler) SignUpAdmin(c *fiber.Ctx) error {
 		}
 	}
 
+	// granting the admin role is the only role change the api can make
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  c.Locals("userId").(string),
+		Action:   audits.UserCreateAdmin,
+		Entity:   "user",
+		EntityId: result.User.Id,
+		After:    result.User,
+	})
+
 	return entities.NewResponse(c).Success(fiber.StatusCreated, result).Res()
 }
 
@@ -179,8 +203,23 @@ func (h *usersHandler) SignIn(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	result, err := h.userUsecase.GetPassport(req)
+	req.Ip = c.IP()
+
+	result, err := h.userUsecase.GetPassport(c.UserContext(), req)
 	if err != nil {
+		var lockedErr *users.LockedError
+		var failedErr *users.SignInError
+		switch {
+		case errors.As(err, &lockedErr):
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(lockedErr.RetryAfter))
+			return entities.NewResponse(c).Error(
+				fiber.ErrTooManyRequests.Code,
+				string(signInErr),
+				err.Error(),
+			).Res()
+		case errors.As(err, &failedErr) && failedErr.CaptchaRequired:
+			c.Set("X-Captcha-Required", "true")
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
 			string(signInErr),
@@ -201,7 +240,7 @@ func (h *usersHandler) RefreshPassport(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	passport, err := h.userUsecase.RefreshPassport(req)
+	passport, err := h.userUsecase.RefreshPassport(c.UserContext(), req)
 	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
@@ -224,7 +263,7 @@ func (h *usersHandler) SignOut(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if err := h.userUsecase.DeleteOauth(req.OauthId); err != nil {
+	if err := h.userUsecase.DeleteOauth(c.UserContext(), req.OauthId); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
 			string(signOutErr),
@@ -238,7 +277,7 @@ func (h *usersHandler) SignOut(c *fiber.Ctx) error {
 func (h *usersHandler) GetUserProfile(c *fiber.Ctx) error {
 	userId := strings.Trim(c.Params("user_id"), " ")
 
-	result, err := h.userUsecase.GetUserProfile(userId)
+	result, err := h.userUsecase.GetUserProfile(c.UserContext(), userId)
 	if err != nil {
 		switch err.Error() {
 		case "get user failed: sql: no rows in result set":
@@ -260,3 +299,135 @@ func (h *usersHandler) GetUserProfile(c *fiber.Ctx) error {
 
 	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
 }
+
+func (h *usersHandler) UpdateLocale(c *fiber.Ctx) error {
+	req := new(users.UserLocaleReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateLocaleErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+	req.Locale = strings.TrimSpace(req.Locale)
+
+	if err := h.userUsecase.UpdateLocale(c.UserContext(), req); err != nil {
+		switch err.Error() {
+		case "locale is not supported":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateLocaleErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateLocaleErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, req).Res()
+}
+
+// ImportUsers import a csv of users from the previous platform, ?dry_run=true only validate the file
+func (h *usersHandler) ImportUsers(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importUsersErr),
+			err.Error(),
+		).Res()
+	}
+	if strings.ToLower(filepath.Ext(file.Filename)) != ".csv" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importUsersErr),
+			"invalid file extension",
+		).Res()
+	}
+
+	container, err := file.Open()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(importUsersErr),
+			err.Error(),
+		).Res()
+	}
+	defer container.Close()
+
+	report, err := h.userUsecase.ImportUsers(c.UserContext(), container, c.QueryBool("dry_run"))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importUsersErr),
+			err.Error(),
+		).Res()
+	}
+
+	if report.Imported > 0 {
+		h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+			ActorId:  c.Locals("userId").(string),
+			Action:   audits.UserImport,
+			Entity:   "user",
+			EntityId: file.Filename,
+			After:    report,
+		})
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, report).Res()
+}
+
+// FindLockedLogins list the emails which are locked after too many failed sign ins
+func (h *usersHandler) FindLockedLogins(c *fiber.Ctx) error {
+	result, err := h.userUsecase.FindLockedLogins(c.UserContext())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findLockedLoginsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *usersHandler) UnlockLogin(c *fiber.Ctx) error {
+	req := new(users.UserUnlockReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(unlockLoginErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.userUsecase.UnlockLogin(c.UserContext(), req.Email); err != nil {
+		switch err.Error() {
+		case "email is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(unlockLoginErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(unlockLoginErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	h.auditsUsecase.Record(c.UserContext(), &audits.AuditLog{
+		ActorId:  c.Locals("userId").(string),
+		Action:   audits.UserUnlock,
+		Entity:   "login",
+		EntityId: strings.ToLower(strings.TrimSpace(req.Email)),
+	})
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
+}
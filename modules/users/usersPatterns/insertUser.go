@@ -4,9 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/NatthawutSK/ri-shop/modules/users"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -17,6 +17,7 @@ type IInsertUser interface {
 }
 
 type userReq struct {
+	ctx context.Context
 	id  string
 	req *users.UserRegisterReq
 	db  *sqlx.DB
@@ -30,16 +31,17 @@ type admin struct {
 	*userReq
 }
 
-func InsertUser(db *sqlx.DB, req *users.UserRegisterReq, isAdmin bool) IInsertUser {
+func InsertUser(ctx context.Context, db *sqlx.DB, req *users.UserRegisterReq, isAdmin bool) IInsertUser {
 	if !isAdmin {
-		return newCustomer(db, req)
+		return newCustomer(ctx, db, req)
 	}
-	return newAdmin(db, req)
+	return newAdmin(ctx, db, req)
 }
 
-func newCustomer(db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
+func newCustomer(ctx context.Context, db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
 	return &customer{
 		userReq: &userReq{
+			ctx: ctx,
 			req: req,
 			db:  db,
 		},
@@ -47,9 +49,10 @@ func newCustomer(db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
 
 }
 
-func newAdmin(db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
+func newAdmin(ctx context.Context, db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
 	return &admin{
 		userReq: &userReq{
+			ctx: ctx,
 			req: req,
 			db:  db,
 		},
@@ -57,7 +60,7 @@ func newAdmin(db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
 }
 
 func (f *userReq) Customer() (IInsertUser, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := databases.WithQueryTimeout(f.ctx)
 	defer cancel()
 
 	query := `
@@ -89,7 +92,7 @@ func (f *userReq) Customer() (IInsertUser, error) {
 }
 
 func (f *userReq) Admin() (IInsertUser, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := databases.WithQueryTimeout(f.ctx)
 	defer cancel()
 
 	query := `
@@ -139,8 +142,11 @@ func (f *userReq) Result() (*users.UserPassport, error) {
 
 	//json_build_object คือ การสร้าง json จากข้อมูลได้
 
+	ctx, cancel := databases.WithQueryTimeout(f.ctx)
+	defer cancel()
+
 	data := make([]byte, 0)
-	if err := f.db.Get(&data, query, f.id); err != nil {
+	if err := f.db.GetContext(ctx, &data, query, f.id); err != nil {
 		return nil, fmt.Errorf("get user failed: %v", err)
 	}
 
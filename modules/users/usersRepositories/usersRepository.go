@@ -2,22 +2,33 @@ package usersRepositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/NatthawutSK/ri-shop/modules/users"
 	"github.com/NatthawutSK/ri-shop/modules/users/usersPatterns"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
 	"github.com/jmoiron/sqlx"
 )
 
 type IUsersRepository interface {
-	InsertUser(req *users.UserRegisterReq, isAdmin bool) (*users.UserPassport, error)
-	FindOneUserByEmail(email string) (*users.UserCredentialCheck, error)
-	InsertOauth(req *users.UserPassport) error
-	FindOneOauth(refreshToken string) (*users.Oauth, error)
-	UpdateOauth(req *users.UserToken) error
-	GetProfile(userId string) (*users.User, error)
-	DeleteOauth(oauthId string) error
+	InsertUser(ctx context.Context, req *users.UserRegisterReq, isAdmin bool) (*users.UserPassport, error)
+	FindOneUserByEmail(ctx context.Context, email string) (*users.UserCredentialCheck, error)
+	InsertOauth(ctx context.Context, req *users.UserPassport) error
+	FindOneOauth(ctx context.Context, refreshToken string) (*users.Oauth, error)
+	UpdateOauth(ctx context.Context, req *users.UserToken) error
+	GetProfile(ctx context.Context, userId string) (*users.User, error)
+	DeleteOauth(ctx context.Context, oauthId string) error
+	UpdateLocale(ctx context.Context, req *users.UserLocaleReq) error
+	FindUsedEmailAndUsername(ctx context.Context, emails, usernames []string) (map[string]bool, error)
+	ImportUsers(ctx context.Context, req []*users.UserImport) error
+	FindLoginAttempt(ctx context.Context, email string) (*users.LoginAttempt, error)
+	AddLoginFailure(ctx context.Context, email, ip string) (*users.LoginAttempt, error)
+	LockLogin(ctx context.Context, email string, lock time.Duration) error
+	ResetLoginAttempts(ctx context.Context, email string) error
+	FindLockedLogins(ctx context.Context) ([]*users.LoginAttempt, error)
 }
 
 type usersRepository struct {
@@ -30,8 +41,8 @@ func UsersRepositoryHandler(db *sqlx.DB) IUsersRepository {
 	}
 }
 
-func (r *usersRepository) InsertUser(req *users.UserRegisterReq, isAdmin bool) (*users.UserPassport, error) {
-	result := usersPatterns.InsertUser(r.db, req, isAdmin)
+func (r *usersRepository) InsertUser(ctx context.Context, req *users.UserRegisterReq, isAdmin bool) (*users.UserPassport, error) {
+	result := usersPatterns.InsertUser(ctx, r.db, req, isAdmin)
 
 	var err error
 	if isAdmin {
@@ -53,25 +64,30 @@ func (r *usersRepository) InsertUser(req *users.UserRegisterReq, isAdmin bool) (
 	return user, nil
 }
 
-func (r *usersRepository) FindOneUserByEmail(email string) (*users.UserCredentialCheck, error) {
+func (r *usersRepository) FindOneUserByEmail(ctx context.Context, email string) (*users.UserCredentialCheck, error) {
 	query := `
 	SELECT
 		"id",
 		"email",
 		"password",
 		"username",
-		"role_id"
+		"role_id",
+		"password_reset_required",
+		COALESCE("store_id", '') AS "store_id"
 	FROM "users"
 	WHERE "email" = $1;`
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
 	user := new(users.UserCredentialCheck)
-	if err := r.db.Get(user, query, email); err != nil {
+	if err := r.db.GetContext(ctx, user, query, email); err != nil {
 		return nil, fmt.Errorf("user not found")
 	}
 	return user, nil
 }
 
-func (r *usersRepository) InsertOauth(req *users.UserPassport) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *usersRepository) InsertOauth(ctx context.Context, req *users.UserPassport) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -95,7 +111,7 @@ func (r *usersRepository) InsertOauth(req *users.UserPassport) error {
 	return nil
 }
 
-func (r *usersRepository) FindOneOauth(refreshToken string) (*users.Oauth, error) {
+func (r *usersRepository) FindOneOauth(ctx context.Context, refreshToken string) (*users.Oauth, error) {
 	query := `
 	SELECT
 		"id",
@@ -103,50 +119,271 @@ func (r *usersRepository) FindOneOauth(refreshToken string) (*users.Oauth, error
 	FROM "oauth"
 	WHERE "refresh_token" = $1;`
 
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
 	oauth := new(users.Oauth)
-	if err := r.db.Get(oauth, query, refreshToken); err != nil {
+	if err := r.db.GetContext(ctx, oauth, query, refreshToken); err != nil {
 		return nil, fmt.Errorf("oauth not found")
 	}
 	return oauth, nil
 }
 
-func (r *usersRepository) UpdateOauth(req *users.UserToken) error {
+func (r *usersRepository) UpdateOauth(ctx context.Context, req *users.UserToken) error {
 	query := `
 	UPDATE "oauth" SET
 		"access_token" = :access_token,
 		"refresh_token" = :refresh_token
 	WHERE "id" = :id;`
 
-	if _, err := r.db.NamedExecContext(context.Background(), query, req); err != nil {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NamedExecContext(ctx, query, req); err != nil {
 		return fmt.Errorf("update oauth failed: %v", err)
 	}
 	return nil
 }
 
-func (r *usersRepository) GetProfile(userId string) (*users.User, error) {
+func (r *usersRepository) GetProfile(ctx context.Context, userId string) (*users.User, error) {
 	query := `
 	SELECT
 		"id",
 		"email",
 		"username",
-		"role_id"
+		"role_id",
+		COALESCE("store_id", '') AS "store_id"
 	FROM "users"
 	WHERE "id" = $1;`
 
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
 	profile := new(users.User)
-	if err := r.db.Get(profile, query, userId); err != nil {
+	if err := r.db.GetContext(ctx, profile, query, userId); err != nil {
 		return nil, fmt.Errorf("get user failed: %v", err)
 	}
 	return profile, nil
 }
 
-func (r *usersRepository) DeleteOauth(oauthId string) error {
+func (r *usersRepository) DeleteOauth(ctx context.Context, oauthId string) error {
 	query := `
 	DELETE FROM "oauth"
 	WHERE "id" = $1;`
 
-	if _, err := r.db.ExecContext(context.Background(), query, oauthId); err != nil {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, oauthId); err != nil {
 		return fmt.Errorf("oauth not found")
 	}
 	return nil
 }
+
+func (r *usersRepository) UpdateLocale(ctx context.Context, req *users.UserLocaleReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "users" SET
+		"locale" = $1
+	WHERE "id" = $2;`
+
+	if _, err := r.db.ExecContext(ctx, query, req.Locale, req.UserId); err != nil {
+		return fmt.Errorf("update locale failed: %v", err)
+	}
+	return nil
+}
+
+// FindUsedEmailAndUsername return every email and username which already exist in users
+func (r *usersRepository) FindUsedEmailAndUsername(ctx context.Context, emails, usernames []string) (map[string]bool, error) {
+	used := make(map[string]bool)
+	if len(emails) == 0 && len(usernames) == 0 {
+		return used, nil
+	}
+	// IN () ว่างไม่ได้
+	emails = append(emails, "")
+	usernames = append(usernames, "")
+
+	query, args, err := sqlx.In(`
+	SELECT
+		"email",
+		"username"
+	FROM "users"
+	WHERE "email" IN (?)
+	OR "username" IN (?);`, emails, usernames)
+	if err != nil {
+		return nil, fmt.Errorf("build find used users query failed: %v", err)
+	}
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows := make([]*struct {
+		Email    string `db:"email"`
+		Username string `db:"username"`
+	}, 0)
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("find used users failed: %v", err)
+	}
+
+	for _, row := range rows {
+		used[row.Email] = true
+		used[row.Username] = true
+	}
+	return used, nil
+}
+
+// ImportUsers insert users as customers with their legacy references in one transaction
+func (r *usersRepository) ImportUsers(ctx context.Context, req []*users.UserImport) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	for _, user := range req {
+		query := `
+		INSERT INTO "users" (
+			"email",
+			"password",
+			"username",
+			"role_id",
+			"password_reset_required",
+			"created_at"
+		)
+		VALUES ($1, $2, $3, 1, $4, COALESCE(NULLIF($5, '')::TIMESTAMP, now()))
+			RETURNING "id";`
+
+		var userId string
+		if err := tx.QueryRowContext(ctx, query, user.Email, user.Password, user.Username, user.PasswordResetRequired, user.CreatedAt).Scan(&userId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert user on line %d failed: %v", user.Line, err)
+		}
+
+		if user.LegacyId != "" {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO "legacy_users" ("user_id", "legacy_id") VALUES ($1, $2);`, userId, user.LegacyId); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("insert legacy user on line %d failed: %v", user.Line, err)
+			}
+		}
+
+		for _, ref := range user.LegacyOrderRefs {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO "legacy_orders" ("legacy_ref", "user_id") VALUES ($1, $2);`, ref, userId); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("insert legacy order %s on line %d failed: %v", ref, user.Line, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %v", err)
+	}
+	return nil
+}
+
+// loginAttemptColumns is the columns of a LoginAttempt, "a" is login_attempts and "u" is users
+const loginAttemptColumns = `
+		"a"."email",
+		COALESCE("u"."id", '') AS "user_id",
+		COALESCE("u"."username", '') AS "username",
+		"a"."failures",
+		COALESCE(to_char("a"."locked_until", 'YYYY-MM-DD"T"HH24:MI:SS'), '') AS "locked_until",
+		COALESCE("a"."locked_until" > now(), false) AS "locked",
+		GREATEST(CEIL(EXTRACT(EPOCH FROM COALESCE("a"."locked_until", now()) - now())), 0)::INT AS "locked_seconds",
+		to_char("a"."last_failed_at", 'YYYY-MM-DD"T"HH24:MI:SS') AS "last_failed_at",
+		"a"."last_ip"`
+
+// FindLoginAttempt return the failed sign ins of the email, an email without any has 0 failures
+func (r *usersRepository) FindLoginAttempt(ctx context.Context, email string) (*users.LoginAttempt, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT` + loginAttemptColumns + `
+	FROM "login_attempts" "a"
+	LEFT JOIN "users" "u" ON "u"."email" = "a"."email"
+	WHERE "a"."email" = $1;`
+
+	attempt := new(users.LoginAttempt)
+	if err := r.db.GetContext(ctx, attempt, query, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &users.LoginAttempt{Email: email}, nil
+		}
+		return nil, fmt.Errorf("get login attempt failed: %v", err)
+	}
+	return attempt, nil
+}
+
+// AddLoginFailure count a failed sign in of the email, failures older than a day are not counted anymore
+func (r *usersRepository) AddLoginFailure(ctx context.Context, email, ip string) (*users.LoginAttempt, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	WITH "a" AS (
+		INSERT INTO "login_attempts" AS "l" ("email", "failures", "last_failed_at", "last_ip")
+		VALUES ($1, 1, now(), $2)
+		ON CONFLICT ("email") DO UPDATE SET
+			"failures" = CASE WHEN "l"."last_failed_at" < now() - INTERVAL '1 day' THEN 1 ELSE "l"."failures" + 1 END,
+			"last_failed_at" = now(),
+			"last_ip" = $2
+		RETURNING *
+	)
+	SELECT` + loginAttemptColumns + `
+	FROM "a"
+	LEFT JOIN "users" "u" ON "u"."email" = "a"."email";`
+
+	attempt := new(users.LoginAttempt)
+	if err := r.db.GetContext(ctx, attempt, query, email, ip); err != nil {
+		return nil, fmt.Errorf("add login failure failed: %v", err)
+	}
+	return attempt, nil
+}
+
+func (r *usersRepository) LockLogin(ctx context.Context, email string, lock time.Duration) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "login_attempts" SET
+		"locked_until" = now() + make_interval(secs => $2)
+	WHERE "email" = $1;`
+
+	if _, err := r.db.ExecContext(ctx, query, email, lock.Seconds()); err != nil {
+		return fmt.Errorf("lock login failed: %v", err)
+	}
+	return nil
+}
+
+// ResetLoginAttempts forget the failed sign ins of the email and unlock it
+func (r *usersRepository) ResetLoginAttempts(ctx context.Context, email string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM "login_attempts" WHERE "email" = $1;`, email); err != nil {
+		return fmt.Errorf("reset login attempts failed: %v", err)
+	}
+	return nil
+}
+
+// FindLockedLogins return the emails which are locked now, the longest lock first
+func (r *usersRepository) FindLockedLogins(ctx context.Context) ([]*users.LoginAttempt, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT` + loginAttemptColumns + `
+	FROM "login_attempts" "a"
+	LEFT JOIN "users" "u" ON "u"."email" = "a"."email"
+	WHERE "a"."locked_until" > now()
+	ORDER BY "a"."locked_until" DESC;`
+
+	attempts := make([]*users.LoginAttempt, 0)
+	if err := r.db.SelectContext(ctx, &attempts, query); err != nil {
+		return nil, fmt.Errorf("get locked logins failed: %v", err)
+	}
+	return attempts, nil
+}
@@ -1,22 +1,33 @@
 package usersUsecases
 
 import (
+	"context"
+	"encoding/csv"
 	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/users"
 	"github.com/NatthawutSK/ri-shop/modules/users/usersRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/i18n"
 	riAuth "github.com/NatthawutSK/ri-shop/pkg/riauth"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type IUserUsecase interface {
-	InsertCustomer(req *users.UserRegisterReq) (*users.UserPassport, error)
-	InsertAdmin(req *users.UserRegisterReq) (*users.UserPassport, error)
-	GetPassport(req *users.UserCredential) (*users.UserPassport, error)
-	RefreshPassport(req *users.UserRefreshCredential) (*users.UserPassport, error)
-	DeleteOauth(oauthId string) error
-	GetUserProfile(userId string) (*users.User, error)
+	InsertCustomer(ctx context.Context, req *users.UserRegisterReq) (*users.UserPassport, error)
+	InsertAdmin(ctx context.Context, req *users.UserRegisterReq) (*users.UserPassport, error)
+	GetPassport(ctx context.Context, req *users.UserCredential) (*users.UserPassport, error)
+	RefreshPassport(ctx context.Context, req *users.UserRefreshCredential) (*users.UserPassport, error)
+	DeleteOauth(ctx context.Context, oauthId string) error
+	GetUserProfile(ctx context.Context, userId string) (*users.User, error)
+	UpdateLocale(ctx context.Context, req *users.UserLocaleReq) error
+	ImportUsers(ctx context.Context, file io.Reader, dryRun bool) (*users.UserImportReport, error)
+	FindLockedLogins(ctx context.Context) ([]*users.LoginAttempt, error)
+	UnlockLogin(ctx context.Context, email string) error
 }
 
 type UserUsecase struct {
@@ -31,54 +42,75 @@ func UserUsecaseHandler(usersRepository usersRepositories.IUsersRepository, cfg
 	}
 }
 
-func (u *UserUsecase) InsertCustomer(req *users.UserRegisterReq) (*users.UserPassport, error) {
+func (u *UserUsecase) InsertCustomer(ctx context.Context, req *users.UserRegisterReq) (*users.UserPassport, error) {
 	//hashing password
 	if err := req.BcryptHashing(); err != nil {
 		return nil, err
 	}
 	//insert user
-	result, err := u.usersRepository.InsertUser(req, false)
+	result, err := u.usersRepository.InsertUser(ctx, req, false)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (u *UserUsecase) InsertAdmin(req *users.UserRegisterReq) (*users.UserPassport, error) {
+func (u *UserUsecase) InsertAdmin(ctx context.Context, req *users.UserRegisterReq) (*users.UserPassport, error) {
 	//hashing password
 	if err := req.BcryptHashing(); err != nil {
 		return nil, err
 	}
 	//insert user
-	result, err := u.usersRepository.InsertUser(req, true)
+	result, err := u.usersRepository.InsertUser(ctx, req, true)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (u *UserUsecase) GetPassport(req *users.UserCredential) (*users.UserPassport, error) {
-	user, err := u.usersRepository.FindOneUserByEmail(req.Email)
+func (u *UserUsecase) GetPassport(ctx context.Context, req *users.UserCredential) (*users.UserPassport, error) {
+	// email ที่ถูกล็อกไม่ต้องเช็ค password เลย
+	attempt, err := u.usersRepository.FindLoginAttempt(ctx, loginKey(req.Email))
 	if err != nil {
 		return nil, err
 	}
+	if attempt.Locked {
+		return nil, &users.LockedError{RetryAfter: attempt.LockedSeconds}
+	}
+
+	user, err := u.usersRepository.FindOneUserByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, u.loginFailed(ctx, req, err)
+	}
+
+	if user.PasswordResetRequired {
+		return nil, fmt.Errorf("password reset required")
+	}
+
+	// compare password, argon2id hashes come from imported users
+	if err := users.ComparePassword(user.Password, req.Password); err != nil {
+		return nil, u.loginFailed(ctx, req, fmt.Errorf("invalid password"))
+	}
 
-	// compare password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, fmt.Errorf("invalid password")
+	if attempt.Failures > 0 {
+		if err := u.usersRepository.ResetLoginAttempts(ctx, attempt.Email); err != nil {
+			log.Printf("reset login attempts of %s failed: %v\n", attempt.Email, err)
+		}
 	}
 
 	// sign token
 	accessToken, err1 := riAuth.NewRiAuth(riAuth.Access, u.cfg.Jwt(), &users.UserClaims{
-		Id:     user.Id,
-		RoleId: user.RoleId,
+		Id:      user.Id,
+		RoleId:  user.RoleId,
+		StoreId: user.StoreId,
 	})
 	if err1 != nil {
 		return nil, err
 	}
 	refreshToken, err2 := riAuth.NewRiAuth(riAuth.Refresh, u.cfg.Jwt(), &users.UserClaims{
-		Id:     user.Id,
-		RoleId: user.RoleId,
+		Id:      user.Id,
+		RoleId:  user.RoleId,
+		StoreId: user.StoreId,
 	})
 	if err2 != nil {
 		return nil, err
@@ -91,6 +123,7 @@ func (u *UserUsecase) GetPassport(req *users.UserCredential) (*users.UserPasspor
 			Email:    user.Email,
 			Username: user.Username,
 			RoleId:   user.RoleId,
+			StoreId:  user.StoreId,
 		},
 		Token: &users.UserToken{
 			AccessToken:  accessToken.SignToken(),
@@ -98,7 +131,7 @@ func (u *UserUsecase) GetPassport(req *users.UserCredential) (*users.UserPasspor
 		},
 	}
 
-	if err := u.usersRepository.InsertOauth(passport); err != nil {
+	if err := u.usersRepository.InsertOauth(ctx, passport); err != nil {
 		return nil, err
 	}
 	return passport, nil
@@ -106,27 +139,28 @@ func (u *UserUsecase) GetPassport(req *users.UserCredential) (*users.UserPasspor
 }
 
 // use for refresh token
-func (u *UserUsecase) RefreshPassport(req *users.UserRefreshCredential) (*users.UserPassport, error) {
+func (u *UserUsecase) RefreshPassport(ctx context.Context, req *users.UserRefreshCredential) (*users.UserPassport, error) {
 	claims, err := riAuth.ParseToken(u.cfg.Jwt(), req.RefreshToken)
 	if err != nil {
 		return nil, err
 	}
 
 	//check oauth
-	oauth, err := u.usersRepository.FindOneOauth(req.RefreshToken)
+	oauth, err := u.usersRepository.FindOneOauth(ctx, req.RefreshToken)
 	if err != nil {
 		return nil, err
 	}
 
 	//find profile
-	profile, err := u.usersRepository.GetProfile(oauth.UserId)
+	profile, err := u.usersRepository.GetProfile(ctx, oauth.UserId)
 	if err != nil {
 		return nil, err
 	}
 
 	newClaims := &users.UserClaims{
-		Id:     profile.Id,
-		RoleId: profile.RoleId,
+		Id:      profile.Id,
+		RoleId:  profile.RoleId,
+		StoreId: profile.StoreId,
 	}
 
 	accessToken, err := riAuth.NewRiAuth(riAuth.Access, u.cfg.Jwt(), newClaims)
@@ -144,7 +178,7 @@ func (u *UserUsecase) RefreshPassport(req *users.UserRefreshCredential) (*users.
 		},
 	}
 
-	if err := u.usersRepository.UpdateOauth(passport.Token); err != nil {
+	if err := u.usersRepository.UpdateOauth(ctx, passport.Token); err != nil {
 		return nil, err
 	}
 
@@ -152,19 +186,264 @@ func (u *UserUsecase) RefreshPassport(req *users.UserRefreshCredential) (*users.
 
 }
 
-func (u *UserUsecase) DeleteOauth(oauthId string) error {
-	if err := u.usersRepository.DeleteOauth(oauthId); err != nil {
+func (u *UserUsecase) DeleteOauth(ctx context.Context, oauthId string) error {
+	if err := u.usersRepository.DeleteOauth(ctx, oauthId); err != nil {
 		return err
 	}
 	return nil
 
 }
 
-func (u *UserUsecase) GetUserProfile(userId string) (*users.User, error) {
-	profile, err := u.usersRepository.GetProfile(userId)
+func (u *UserUsecase) GetUserProfile(ctx context.Context, userId string) (*users.User, error) {
+	profile, err := u.usersRepository.GetProfile(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
 	return profile, nil
 
 }
+
+func (u *UserUsecase) UpdateLocale(ctx context.Context, req *users.UserLocaleReq) error {
+	if !i18n.IsSupported(req.Locale) {
+		return fmt.Errorf("locale is not supported")
+	}
+	if err := u.usersRepository.UpdateLocale(ctx, req); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ImportUsers read a csv of users from the previous platform, columns are matched by the header:
+// email, username, password_hash, force_reset, created_at, legacy_id, legacy_order_refs (separated by ;)
+func (u *UserUsecase) ImportUsers(ctx context.Context, file io.Reader, dryRun bool) (*users.UserImportReport, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header failed: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"email", "username"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is required", name)
+		}
+	}
+
+	rows := make([]*users.UserImport, 0)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("read csv line %d failed: %v", line, err)
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := &users.UserImport{
+			Line:            line,
+			Email:           strings.ToLower(get("email")),
+			Username:        get("username"),
+			Password:        get("password_hash"),
+			CreatedAt:       get("created_at"),
+			LegacyId:        get("legacy_id"),
+			LegacyOrderRefs: make([]string, 0),
+			Errors:          make([]string, 0),
+		}
+		for _, ref := range strings.Split(get("legacy_order_refs"), ";") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				row.LegacyOrderRefs = append(row.LegacyOrderRefs, ref)
+			}
+		}
+		if forceReset := get("force_reset"); forceReset != "" {
+			row.PasswordResetRequired, err = strconv.ParseBool(forceReset)
+			if err != nil {
+				row.Errors = append(row.Errors, "force_reset is invalid")
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	u.validateImport(ctx, rows)
+
+	report := &users.UserImportReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]*users.UserImport, 0),
+	}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			report.Rows = append(report.Rows, row)
+		}
+	}
+	report.Invalid = len(report.Rows)
+	report.Valid = report.Total - report.Invalid
+
+	if dryRun || report.Invalid > 0 || report.Total == 0 {
+		return report, nil
+	}
+
+	if err := u.usersRepository.ImportUsers(ctx, rows); err != nil {
+		return nil, err
+	}
+	report.Imported = report.Total
+	return report, nil
+}
+
+func (u *UserUsecase) validateImport(ctx context.Context, rows []*users.UserImport) {
+	emails := make([]string, 0)
+	usernames := make([]string, 0)
+	for _, row := range rows {
+		emails = append(emails, row.Email)
+		usernames = append(usernames, row.Username)
+	}
+
+	used, err := u.usersRepository.FindUsedEmailAndUsername(ctx, emails, usernames)
+	if err != nil {
+		for _, row := range rows {
+			row.Errors = append(row.Errors, err.Error())
+		}
+		return
+	}
+
+	// firstSeen return the first line of a value which is in more than one row
+	seen := make(map[string]int)
+	firstSeen := func(key string, line int) (int, bool) {
+		if first, ok := seen[key]; ok {
+			return first, true
+		}
+		seen[key] = line
+		return 0, false
+	}
+
+	for _, row := range rows {
+		if !(&users.UserRegisterReq{Email: row.Email}).IsEmail() {
+			row.Errors = append(row.Errors, "email is invalid")
+		} else if used[row.Email] {
+			row.Errors = append(row.Errors, "email has been used")
+		} else if first, ok := firstSeen("email:"+row.Email, row.Line); ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("email is duplicated with line %d", first))
+		}
+
+		if row.Username == "" {
+			row.Errors = append(row.Errors, "username is required")
+		} else if used[row.Username] {
+			row.Errors = append(row.Errors, "username has been used")
+		} else if first, ok := firstSeen("username:"+row.Username, row.Line); ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("username is duplicated with line %d", first))
+		}
+
+		// ถ้าไม่มี hash ที่ใช้ได้ ต้องบังคับให้ reset password
+		if row.Password == "" && !row.PasswordResetRequired {
+			row.Errors = append(row.Errors, "password_hash is required unless force_reset is true")
+		} else if row.Password != "" && !users.IsSupportedHash(row.Password) {
+			row.Errors = append(row.Errors, "password_hash must be bcrypt or argon2id")
+		}
+
+		if row.CreatedAt != "" {
+			createdAt, err := parseImportTime(row.CreatedAt)
+			if err != nil {
+				row.Errors = append(row.Errors, "created_at is invalid")
+			} else {
+				row.CreatedAt = createdAt.UTC().Format("2006-01-02 15:04:05")
+			}
+		}
+
+		if row.LegacyId != "" {
+			if first, ok := firstSeen("legacy_id:"+row.LegacyId, row.Line); ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("legacy_id is duplicated with line %d", first))
+			}
+		}
+		for _, ref := range row.LegacyOrderRefs {
+			if first, ok := firstSeen("legacy_order:"+ref, row.Line); ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("legacy order %s is duplicated with line %d", ref, first))
+			}
+		}
+	}
+}
+
+func parseImportTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time %s is invalid", s)
+}
+
+// loginKey is the email which failed sign ins are counted by, a different case is the same email
+func loginKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// LockDuration is the lock after the failures of an email, base after the lockAfter-th failure and doubled for
+// every further failure up to max
+func LockDuration(failures, lockAfter int, base, max time.Duration) time.Duration {
+	if lockAfter <= 0 || failures < lockAfter {
+		return 0
+	}
+	lock := base
+	for i := lockAfter; i < failures && lock < max; i++ {
+		lock *= 2
+	}
+	if lock > max {
+		lock = max
+	}
+	return lock
+}
+
+// loginFailed count the failed sign in and lock the email when it has failed too many times, cause is the
+// error of the sign in
+func (u *UserUsecase) loginFailed(ctx context.Context, req *users.UserCredential, cause error) error {
+	security := u.cfg.Security()
+	attempt, err := u.usersRepository.AddLoginFailure(ctx, loginKey(req.Email), req.Ip)
+	if err != nil {
+		return err
+	}
+
+	if lock := LockDuration(attempt.Failures, security.LoginLockAfter(), security.LoginLockBase(), security.LoginLockMax()); lock > 0 {
+		if err := u.usersRepository.LockLogin(ctx, attempt.Email, lock); err != nil {
+			return err
+		}
+		log.Printf("login of %s is locked for %s after %d failures, last from %s\n", attempt.Email, lock, attempt.Failures, req.Ip)
+	}
+
+	captchaAfter := security.LoginCaptchaAfter()
+	return &users.SignInError{
+		Msg:             cause.Error(),
+		CaptchaRequired: captchaAfter > 0 && attempt.Failures >= captchaAfter,
+	}
+}
+
+// FindLockedLogins return the emails which are locked now
+func (u *UserUsecase) FindLockedLogins(ctx context.Context) ([]*users.LoginAttempt, error) {
+	attempts, err := u.usersRepository.FindLockedLogins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	captchaAfter := u.cfg.Security().LoginCaptchaAfter()
+	for _, attempt := range attempts {
+		attempt.CaptchaRequired = captchaAfter > 0 && attempt.Failures >= captchaAfter
+	}
+	return attempts, nil
+}
+
+// UnlockLogin unlock the email and forget its failed sign ins
+func (u *UserUsecase) UnlockLogin(ctx context.Context, email string) error {
+	if loginKey(email) == "" {
+		return fmt.Errorf("email is required")
+	}
+	return u.usersRepository.ResetLoginAttempts(ctx, loginKey(email))
+}
@@ -0,0 +1,43 @@
+package views
+
+import "regexp"
+
+// only the last Capacity products a viewer saw in a store are kept
+const Capacity = 20
+
+// DefaultLimit is the products returned when the limit is not given
+const DefaultLimit = 10
+
+// views of an anonymous session older than SessionRetentionDays are deleted, views of users are kept
+const SessionRetentionDays = 30
+
+// a session id is made by the storefront, e.g. a uuid kept in a cookie
+var sessionPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,64}$`)
+
+// UserViewer is the viewer of a signed in user
+func UserViewer(userId string) string {
+	return "user:" + userId
+}
+
+// SessionViewer is the viewer of an anonymous session
+func SessionViewer(sessionId string) string {
+	return "session:" + sessionId
+}
+
+// ValidSession report whether sessionId can be used as a viewer
+func ValidSession(sessionId string) bool {
+	return sessionPattern.MatchString(sessionId)
+}
+
+type ViewReq struct {
+	Viewer    string `json:"-"`
+	StoreId   string `json:"-"`
+	ProductId string `json:"product_id" form:"product_id"`
+}
+
+type RecentReq struct {
+	Viewer   string `json:"-"`
+	StoreId  string `json:"-"`
+	Limit    int    `query:"limit"`
+	Currency string `query:"currency"`
+}
@@ -0,0 +1,127 @@
+package viewsHandlers
+
+import (
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/views"
+	"github.com/NatthawutSK/ri-shop/modules/views/viewsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type viewsHandlerErrCode string
+
+const (
+	recordViewErr         viewsHandlerErrCode = "views-001"
+	findRecentlyViewedErr viewsHandlerErrCode = "views-002"
+)
+
+type IViewsHandler interface {
+	RecordView(c *fiber.Ctx) error
+	FindRecentlyViewed(c *fiber.Ctx) error
+}
+
+type viewsHandler struct {
+	cfg          config.IConfig
+	viewsUsecase viewsUsecases.IViewsUsecase
+}
+
+func ViewsHandler(cfg config.IConfig, viewsUsecase viewsUsecases.IViewsUsecase) IViewsHandler {
+	return &viewsHandler{
+		cfg:          cfg,
+		viewsUsecase: viewsUsecase,
+	}
+}
+
+// viewer is the signed in user of /users/:user_id or the anonymous session of /sessions/:session_id
+func viewer(c *fiber.Ctx) (string, bool) {
+	if userId := strings.Trim(c.Params("user_id"), " "); userId != "" {
+		return views.UserViewer(userId), true
+	}
+	sessionId := strings.Trim(c.Params("session_id"), " ")
+	return views.SessionViewer(sessionId), views.ValidSession(sessionId)
+}
+
+func (h *viewsHandler) RecordView(c *fiber.Ctx) error {
+	req := new(views.ViewReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(recordViewErr),
+			err.Error(),
+		).Res()
+	}
+
+	v, ok := viewer(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(recordViewErr),
+			"session id is invalid",
+		).Res()
+	}
+	req.Viewer = v
+	req.StoreId = c.Locals("storeId").(string)
+
+	if err := h.viewsUsecase.RecordView(c.UserContext(), req); err != nil {
+		switch err.Error() {
+		case "product id is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(recordViewErr),
+				err.Error(),
+			).Res()
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(recordViewErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(recordViewErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, req).Res()
+}
+
+// FindRecentlyViewed return the last products the viewer saw, ?limit is at most views.Capacity
+func (h *viewsHandler) FindRecentlyViewed(c *fiber.Ctx) error {
+	req := new(views.RecentReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findRecentlyViewedErr),
+			err.Error(),
+		).Res()
+	}
+
+	v, ok := viewer(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findRecentlyViewedErr),
+			"session id is invalid",
+		).Res()
+	}
+	req.Viewer = v
+	req.StoreId = c.Locals("storeId").(string)
+
+	result, err := h.viewsUsecase.FindRecentlyViewed(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findRecentlyViewedErr),
+			err.Error(),
+		).Res()
+	}
+
+	// รายการของแต่ละคน ห้าม cache ร่วมกัน
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
@@ -0,0 +1,114 @@
+package viewsRepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/views"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IViewsRepository interface {
+	InsertView(ctx context.Context, req *views.ViewReq) error
+	FindViewedProductId(ctx context.Context, viewer, storeId string, limit int) ([]string, error)
+	DeleteExpiredSessionView(ctx context.Context, days int) (int64, error)
+}
+
+type viewsRepository struct {
+	db *sqlx.DB
+}
+
+func ViewsRepository(db *sqlx.DB) IViewsRepository {
+	return &viewsRepository{
+		db: db,
+	}
+}
+
+// InsertView move the product to the top of the list of the viewer and drop what is past views.Capacity
+func (r *viewsRepository) InsertView(ctx context.Context, req *views.ViewReq) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO "recently_viewed" (
+		"viewer",
+		"store_id",
+		"product_id"
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ("viewer", "product_id") DO UPDATE SET
+		"viewed_at" = now();`
+
+	if _, err := tx.ExecContext(ctx, query, req.Viewer, req.StoreId, req.ProductId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert view failed: %v", err)
+	}
+
+	query = `
+	DELETE FROM "recently_viewed"
+	WHERE "viewer" = $1
+	AND "store_id" = $2
+	AND "product_id" NOT IN (
+		SELECT "product_id"
+		FROM "recently_viewed"
+		WHERE "viewer" = $1
+		AND "store_id" = $2
+		ORDER BY "viewed_at" DESC
+		LIMIT $3
+	);`
+
+	if _, err := tx.ExecContext(ctx, query, req.Viewer, req.StoreId, views.Capacity); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("trim views failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("commit transaction failed: %v", err)
+	}
+	return nil
+}
+
+// FindViewedProductId return the products the viewer saw in the store, the last one first
+func (r *viewsRepository) FindViewedProductId(ctx context.Context, viewer, storeId string, limit int) ([]string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT "product_id"
+	FROM "recently_viewed"
+	WHERE "viewer" = $1
+	AND "store_id" = $2
+	ORDER BY "viewed_at" DESC
+	LIMIT $3;`
+
+	productIds := make([]string, 0)
+	if err := r.db.SelectContext(ctx, &productIds, query, viewer, storeId, limit); err != nil {
+		return nil, fmt.Errorf("get viewed products failed: %v", err)
+	}
+	return productIds, nil
+}
+
+// DeleteExpiredSessionView delete the views of anonymous sessions older than days
+func (r *viewsRepository) DeleteExpiredSessionView(ctx context.Context, days int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	query := `
+	DELETE FROM "recently_viewed"
+	WHERE "viewer" LIKE 'session:%'
+	AND "viewed_at" < now() - interval '1 day' * $1;`
+
+	result, err := r.db.ExecContext(ctx, query, days)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired views failed: %v", err)
+	}
+	return result.RowsAffected()
+}
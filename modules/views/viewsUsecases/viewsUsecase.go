@@ -0,0 +1,92 @@
+package viewsUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/views"
+	"github.com/NatthawutSK/ri-shop/modules/views/viewsRepositories"
+)
+
+type IViewsUsecase interface {
+	RecordView(ctx context.Context, req *views.ViewReq) error
+	FindRecentlyViewed(ctx context.Context, req *views.RecentReq) ([]*products.Products, error)
+	DeleteExpiredSessionView(ctx context.Context) error
+}
+
+type viewsUsecase struct {
+	viewsRepository viewsRepositories.IViewsRepository
+	productsUsecase productsUsecases.IProductsUsecase
+}
+
+func ViewsUsecase(viewsRepository viewsRepositories.IViewsRepository, productsUsecase productsUsecases.IProductsUsecase) IViewsUsecase {
+	return &viewsUsecase{
+		viewsRepository: viewsRepository,
+		productsUsecase: productsUsecase,
+	}
+}
+
+// visible report whether the storefront of the store show the product
+func visible(product *products.Products, storeId string) bool {
+	return product.StoreId == storeId && product.Status == products.StatusPublished
+}
+
+func (u *viewsUsecase) RecordView(ctx context.Context, req *views.ViewReq) error {
+	req.ProductId = strings.TrimSpace(req.ProductId)
+	if req.ProductId == "" {
+		return fmt.Errorf("product id is required")
+	}
+
+	product, err := u.productsUsecase.FindOneProduct(ctx, req.ProductId)
+	if err != nil || !visible(product, req.StoreId) {
+		return fmt.Errorf("product not found")
+	}
+	return u.viewsRepository.InsertView(ctx, req)
+}
+
+// FindRecentlyViewed return the products the viewer saw last, the products which are not shown anymore
+// are skipped
+func (u *viewsUsecase) FindRecentlyViewed(ctx context.Context, req *views.RecentReq) ([]*products.Products, error) {
+	if req.Limit <= 0 {
+		req.Limit = views.DefaultLimit
+	}
+	if req.Limit > views.Capacity {
+		req.Limit = views.Capacity
+	}
+
+	productIds, err := u.viewsRepository.FindViewedProductId(ctx, req.Viewer, req.StoreId, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// สินค้าส่วนใหญ่อยู่ใน product cache อยู่แล้ว
+	result := make([]*products.Products, 0, len(productIds))
+	for _, productId := range productIds {
+		product, err := u.productsUsecase.FindOneProduct(ctx, productId)
+		if err != nil || !visible(product, req.StoreId) {
+			continue
+		}
+		result = append(result, product)
+	}
+
+	if err := u.productsUsecase.ConvertCurrency(ctx, result, req.Currency); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteExpiredSessionView delete the views of sessions older than views.SessionRetentionDays
+func (u *viewsUsecase) DeleteExpiredSessionView(ctx context.Context) error {
+	deleted, err := u.viewsRepository.DeleteExpiredSessionView(ctx, views.SessionRetentionDays)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("view retention deleted %d views\n", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package watches
+
+const (
+	KindBackInStock = "back_in_stock"
+	KindPriceDrop   = "price_drop"
+)
+
+const (
+	StatusActive   = "active"
+	StatusNotified = "notified" // the customer was told once, the watch is over
+	StatusExpired  = "expired"
+	StatusCanceled = "canceled"
+)
+
+// ExpireDays is how long a watch wait for its product
+const ExpireDays = 90
+
+// MaxActive is the most active watches of a customer
+const MaxActive = 50
+
+// Watch is a customer waiting for a product. A price drop watch fire when the price is at most TargetPrice,
+// or below WatchedPrice when the customer did not give a target
+type Watch struct {
+	Id           string   `json:"id" db:"id"`
+	UserId       string   `json:"-" db:"user_id"`
+	ProductId    string   `json:"product_id" db:"product_id"`
+	Title        string   `json:"title" db:"title"`
+	Kind         string   `json:"kind" db:"kind"`
+	TargetPrice  *float64 `json:"target_price" db:"target_price"`   // major unit of the currency of the product
+	WatchedPrice float64  `json:"watched_price" db:"watched_price"` // price of the product when it was watched
+	Status       string   `json:"status" db:"status"`
+	NotifiedAt   *string  `json:"notified_at" db:"notified_at"`
+	ExpiresAt    string   `json:"expires_at" db:"expires_at"`
+	CreatedAt    string   `json:"created_at" db:"created_at"`
+	Price        float64  `json:"-" db:"price"` // of the product when the watch is due
+	Currency     string   `json:"-" db:"currency"`
+	ProductStock int      `json:"-" db:"stock"`
+}
+
+type WatchReq struct {
+	UserId      string   `json:"-"`
+	StoreId     string   `json:"-"`
+	ProductId   string   `json:"product_id" form:"product_id"`
+	Kind        string   `json:"kind" form:"kind"`
+	TargetPrice *float64 `json:"target_price" form:"target_price"` // only for a price drop
+}
+
+// Demand is an out of stock product with the customers waiting for it
+type Demand struct {
+	ProductId string `json:"product_id" db:"product_id"`
+	Title     string `json:"title" db:"title"`
+	Stock     int    `json:"stock" db:"stock"`
+	Watchers  int    `json:"watchers" db:"watchers"`
+	Since     string `json:"since" db:"since"` // the oldest active watch
+}
+
+type DemandReq struct {
+	StoreId string `json:"-"`
+	Limit   int    `query:"limit"`
+}
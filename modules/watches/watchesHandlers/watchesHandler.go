@@ -0,0 +1,162 @@
+package watchesHandlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/watches"
+	"github.com/NatthawutSK/ri-shop/modules/watches/watchesUsecases"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+	"github.com/gofiber/fiber/v2"
+)
+
+type watchesHandlerErrCode string
+
+const (
+	addWatchErr    watchesHandlerErrCode = "watches-001"
+	findWatchErr   watchesHandlerErrCode = "watches-002"
+	cancelWatchErr watchesHandlerErrCode = "watches-003"
+	findDemandErr  watchesHandlerErrCode = "watches-004"
+)
+
+type IWatchesHandler interface {
+	AddWatch(c *fiber.Ctx) error
+	FindWatch(c *fiber.Ctx) error
+	CancelWatch(c *fiber.Ctx) error
+	FindDemand(c *fiber.Ctx) error
+	CheckOnChange(ctx context.Context, e *events.Event)
+}
+
+type watchesHandler struct {
+	cfg            config.IConfig
+	watchesUsecase watchesUsecases.IWatchesUsecase
+}
+
+func WatchesHandler(cfg config.IConfig, watchesUsecase watchesUsecases.IWatchesUsecase) IWatchesHandler {
+	return &watchesHandler{
+		cfg:            cfg,
+		watchesUsecase: watchesUsecase,
+	}
+}
+
+func (h *watchesHandler) AddWatch(c *fiber.Ctx) error {
+	req := new(watches.WatchReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addWatchErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+	req.StoreId = c.Locals("storeId").(string)
+
+	watch, err := h.watchesUsecase.AddWatch(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(addWatchErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "product is in stock":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(addWatchErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "product id is required",
+			err.Error() == "kind is invalid",
+			err.Error() == "target price must be below the current price",
+			strings.HasPrefix(err.Error(), "a customer has at most"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addWatchErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(addWatchErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, watch).Res()
+}
+
+func (h *watchesHandler) FindWatch(c *fiber.Ctx) error {
+	list, err := h.watchesUsecase.FindWatch(c.UserContext(), strings.Trim(c.Params("user_id"), " "))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findWatchErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, list).Res()
+}
+
+func (h *watchesHandler) CancelWatch(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	watchId := strings.Trim(c.Params("watch_id"), " ")
+
+	if err := h.watchesUsecase.CancelWatch(c.UserContext(), userId, watchId); err != nil {
+		if err.Error() == "watch not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(cancelWatchErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(cancelWatchErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+func (h *watchesHandler) FindDemand(c *fiber.Ctx) error {
+	req := new(watches.DemandReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findDemandErr),
+			err.Error(),
+		).Res()
+	}
+	req.StoreId = c.Locals("storeId").(string)
+
+	list, err := h.watchesUsecase.FindDemand(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findDemandErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, list).Res()
+}
+
+// CheckOnChange is the event subscriber of price and stock changes, only a restock or a lower price can
+// fire a watch
+func (h *watchesHandler) CheckOnChange(ctx context.Context, e *events.Event) {
+	payload, ok := e.Payload.(*products.PriceStockEvent)
+	if !ok {
+		return
+	}
+	restocked := payload.FromStock <= 0 && payload.Stock > 0
+	if !restocked && payload.Price >= payload.FromPrice {
+		return
+	}
+	if _, err := h.watchesUsecase.CheckProduct(ctx, payload.ProductId); err != nil {
+		log.Printf("check watches of product %s failed: %v\n", payload.ProductId, err)
+	}
+}
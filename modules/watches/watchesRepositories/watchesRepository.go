@@ -0,0 +1,250 @@
+package watchesRepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/watches"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IWatchesRepository interface {
+	InsertWatch(ctx context.Context, req *watches.WatchReq, watchedPrice float64) (string, error)
+	FindOneWatch(ctx context.Context, watchId string) (*watches.Watch, error)
+	FindWatch(ctx context.Context, userId string) ([]*watches.Watch, error)
+	CountActiveWatch(ctx context.Context, userId string) (int, error)
+	CancelWatch(ctx context.Context, userId, watchId string) error
+	FindDueWatch(ctx context.Context, productId string, limit int) ([]*watches.Watch, error)
+	MarkWatchNotified(ctx context.Context, watchId string) (bool, error)
+	ExpireWatch(ctx context.Context) (int64, error)
+	FindDemand(ctx context.Context, req *watches.DemandReq) ([]*watches.Demand, error)
+}
+
+type watchesRepository struct {
+	db *sqlx.DB
+}
+
+func WatchesRepository(db *sqlx.DB) IWatchesRepository {
+	return &watchesRepository{
+		db: db,
+	}
+}
+
+const watchColumns = `
+		"w"."id",
+		"w"."user_id",
+		"w"."product_id",
+		"p"."title",
+		"w"."kind",
+		"w"."target_price",
+		"w"."watched_price",
+		"w"."status",
+		"w"."notified_at",
+		"w"."expires_at",
+		"w"."created_at",
+		minor_to_major("p"."price_minor", "p"."currency") AS "price",
+		"p"."currency",
+		"p"."stock"`
+
+// InsertWatch add the watch, an active watch of the same kind is renewed instead
+func (r *watchesRepository) InsertWatch(ctx context.Context, req *watches.WatchReq, watchedPrice float64) (string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "product_watches" (
+		"user_id",
+		"product_id",
+		"kind",
+		"target_price",
+		"watched_price",
+		"expires_at"
+	)
+	VALUES ($1, $2, $3, $4, $5, now() + make_interval(days => $6))
+	ON CONFLICT ("user_id", "product_id", "kind") WHERE "status" = 'active' DO UPDATE SET
+		"target_price" = EXCLUDED."target_price",
+		"watched_price" = EXCLUDED."watched_price",
+		"expires_at" = EXCLUDED."expires_at"
+	RETURNING "id";`
+
+	var watchId string
+	if err := r.db.QueryRowContext(ctx, query,
+		req.UserId,
+		req.ProductId,
+		req.Kind,
+		req.TargetPrice,
+		watchedPrice,
+		watches.ExpireDays,
+	).Scan(&watchId); err != nil {
+		return "", fmt.Errorf("insert watch failed: %v", err)
+	}
+	return watchId, nil
+}
+
+func (r *watchesRepository) FindOneWatch(ctx context.Context, watchId string) (*watches.Watch, error) {
+	query := `
+	SELECT` + watchColumns + `
+	FROM "product_watches" "w"
+		JOIN "products" "p" ON "p"."id" = "w"."product_id"
+	WHERE "w"."id"::TEXT = $1;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	watch := new(watches.Watch)
+	if err := r.db.GetContext(ctx, watch, query, watchId); err != nil {
+		return nil, fmt.Errorf("get watch failed: %v", err)
+	}
+	return watch, nil
+}
+
+// FindWatch return the active watches of the user, newest first
+func (r *watchesRepository) FindWatch(ctx context.Context, userId string) ([]*watches.Watch, error) {
+	query := `
+	SELECT` + watchColumns + `
+	FROM "product_watches" "w"
+		JOIN "products" "p" ON "p"."id" = "w"."product_id"
+	WHERE "w"."user_id" = $1
+	AND "w"."status" = 'active'
+	ORDER BY "w"."created_at" DESC;`
+
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	list := make([]*watches.Watch, 0)
+	if err := r.db.SelectContext(ctx, &list, query, userId); err != nil {
+		return nil, fmt.Errorf("get watches failed: %v", err)
+	}
+	return list, nil
+}
+
+func (r *watchesRepository) CountActiveWatch(ctx context.Context, userId string) (int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, `
+	SELECT
+		COUNT(*)
+	FROM "product_watches"
+	WHERE "user_id" = $1
+	AND "status" = 'active';`, userId); err != nil {
+		return 0, fmt.Errorf("count watches failed: %v", err)
+	}
+	return count, nil
+}
+
+func (r *watchesRepository) CancelWatch(ctx context.Context, userId, watchId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "product_watches" SET
+		"status" = 'canceled'
+	WHERE "id"::TEXT = $1
+	AND "user_id" = $2
+	AND "status" = 'active';`, watchId, userId)
+	if err != nil {
+		return fmt.Errorf("cancel watch failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("watch not found")
+	}
+	return nil
+}
+
+// FindDueWatch return the active watches whose product is back in stock or cheap enough, of every product
+// when productId is empty. A product which is not published does not fire its watches
+func (r *watchesRepository) FindDueWatch(ctx context.Context, productId string, limit int) ([]*watches.Watch, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	query := `
+	SELECT` + watchColumns + `
+	FROM "product_watches" "w"
+		JOIN "products" "p" ON "p"."id" = "w"."product_id"
+	WHERE "w"."status" = 'active'
+	AND "w"."expires_at" > now()
+	AND "p"."status" = 'published'
+	AND ($1 = '' OR "w"."product_id" = $1)
+	AND (
+		("w"."kind" = 'back_in_stock' AND "p"."stock" > 0)
+		OR ("w"."kind" = 'price_drop' AND "w"."target_price" IS NULL AND minor_to_major("p"."price_minor", "p"."currency") < "w"."watched_price")
+		OR ("w"."kind" = 'price_drop' AND minor_to_major("p"."price_minor", "p"."currency") <= "w"."target_price")
+	)
+	ORDER BY "w"."created_at" ASC
+	LIMIT $2;`
+
+	list := make([]*watches.Watch, 0)
+	if err := r.db.SelectContext(ctx, &list, query, productId, limit); err != nil {
+		return nil, fmt.Errorf("get due watches failed: %v", err)
+	}
+	return list, nil
+}
+
+// MarkWatchNotified end the watch, false when another instance has done it already
+func (r *watchesRepository) MarkWatchNotified(ctx context.Context, watchId string) (bool, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "product_watches" SET
+		"status" = 'notified',
+		"notified_at" = now()
+	WHERE "id"::TEXT = $1
+	AND "status" = 'active';`, watchId)
+	if err != nil {
+		return false, fmt.Errorf("mark watch notified failed: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("mark watch notified failed: %v", err)
+	}
+	return rows == 1, nil
+}
+
+func (r *watchesRepository) ExpireWatch(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE "product_watches" SET
+		"status" = 'expired'
+	WHERE "status" = 'active'
+	AND "expires_at" <= now();`)
+	if err != nil {
+		return 0, fmt.Errorf("expire watches failed: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// FindDemand return the out of stock products of the store by the number of customers waiting for them
+func (r *watchesRepository) FindDemand(ctx context.Context, req *watches.DemandReq) ([]*watches.Demand, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"p"."id" AS "product_id",
+		"p"."title",
+		"p"."stock",
+		COUNT(*) AS "watchers",
+		MIN("w"."created_at") AS "since"
+	FROM "product_watches" "w"
+		JOIN "products" "p" ON "p"."id" = "w"."product_id"
+	WHERE "w"."status" = 'active'
+	AND "w"."kind" = 'back_in_stock'
+	AND "p"."stock" <= 0
+	AND COALESCE("p"."store_id", '') = $1
+	GROUP BY "p"."id", "p"."title", "p"."stock"
+	ORDER BY "watchers" DESC, "p"."id" ASC
+	LIMIT $2;`
+
+	list := make([]*watches.Demand, 0)
+	if err := r.db.SelectContext(ctx, &list, query, req.StoreId, req.Limit); err != nil {
+		return nil, fmt.Errorf("get demand failed: %v", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,179 @@
+package watchesUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/watches"
+	"github.com/NatthawutSK/ri-shop/modules/watches/watchesRepositories"
+)
+
+// dueBatch is the watches notified by one run of the check
+const dueBatch = 500
+
+type IWatchesUsecase interface {
+	AddWatch(ctx context.Context, req *watches.WatchReq) (*watches.Watch, error)
+	FindWatch(ctx context.Context, userId string) ([]*watches.Watch, error)
+	CancelWatch(ctx context.Context, userId, watchId string) error
+	CheckProduct(ctx context.Context, productId string) (int, error)
+	CheckWatch(ctx context.Context) error
+	ExpireWatch(ctx context.Context) error
+	FindDemand(ctx context.Context, req *watches.DemandReq) ([]*watches.Demand, error)
+}
+
+type watchesUsecase struct {
+	watchesRepository    watchesRepositories.IWatchesRepository
+	productsUsecase      productsUsecases.IProductsUsecase
+	notificationsUsecase notificationsUsecases.INotificationsUsecase
+}
+
+func WatchesUsecase(
+	watchesRepository watchesRepositories.IWatchesRepository,
+	productsUsecase productsUsecases.IProductsUsecase,
+	notificationsUsecase notificationsUsecases.INotificationsUsecase,
+) IWatchesUsecase {
+	return &watchesUsecase{
+		watchesRepository:    watchesRepository,
+		productsUsecase:      productsUsecase,
+		notificationsUsecase: notificationsUsecase,
+	}
+}
+
+// AddWatch start a watch on a product the storefront show. A product in stock can not be watched for a restock
+func (u *watchesUsecase) AddWatch(ctx context.Context, req *watches.WatchReq) (*watches.Watch, error) {
+	req.ProductId = strings.TrimSpace(req.ProductId)
+	if req.ProductId == "" {
+		return nil, fmt.Errorf("product id is required")
+	}
+
+	product, err := u.productsUsecase.FindOneProduct(ctx, req.ProductId)
+	if err != nil || product.StoreId != req.StoreId || product.Status != products.StatusPublished {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	switch req.Kind {
+	case watches.KindBackInStock:
+		if product.Stock > 0 {
+			return nil, fmt.Errorf("product is in stock")
+		}
+		req.TargetPrice = nil
+	case watches.KindPriceDrop:
+		if req.TargetPrice != nil && (*req.TargetPrice <= 0 || *req.TargetPrice >= product.Price) {
+			return nil, fmt.Errorf("target price must be below the current price")
+		}
+	default:
+		return nil, fmt.Errorf("kind is invalid")
+	}
+
+	active, err := u.watchesRepository.CountActiveWatch(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if active >= watches.MaxActive {
+		return nil, fmt.Errorf("a customer has at most %d watches", watches.MaxActive)
+	}
+
+	watchId, err := u.watchesRepository.InsertWatch(ctx, req, product.Price)
+	if err != nil {
+		return nil, err
+	}
+	return u.watchesRepository.FindOneWatch(ctx, watchId)
+}
+
+func (u *watchesUsecase) FindWatch(ctx context.Context, userId string) ([]*watches.Watch, error) {
+	return u.watchesRepository.FindWatch(ctx, userId)
+}
+
+func (u *watchesUsecase) CancelWatch(ctx context.Context, userId, watchId string) error {
+	return u.watchesRepository.CancelWatch(ctx, userId, strings.TrimSpace(watchId))
+}
+
+// CheckProduct notify the due watches of a product, it runs when an update change its price or stock
+func (u *watchesUsecase) CheckProduct(ctx context.Context, productId string) (int, error) {
+	due, err := u.watchesRepository.FindDueWatch(ctx, productId, dueBatch)
+	if err != nil {
+		return 0, err
+	}
+	return u.notify(ctx, due), nil
+}
+
+// CheckWatch notify the due watches of every product, it catch the stock which came back without an update
+// of the admins, e.g. a canceled order or a refund with restock
+func (u *watchesUsecase) CheckWatch(ctx context.Context) error {
+	for {
+		due, err := u.watchesRepository.FindDueWatch(ctx, "", dueBatch)
+		if err != nil {
+			return err
+		}
+		notified := u.notify(ctx, due)
+		if notified > 0 {
+			log.Printf("notified %d watches\n", notified)
+		}
+		// ที่เหลือเป็น watch ที่ instance อื่นแจ้งไปแล้ว หรือแจ้งไม่สำเร็จ รอรอบหน้า
+		if len(due) < dueBatch || notified == 0 {
+			return nil
+		}
+	}
+}
+
+// notify end each watch before its notification, so two instances never notify the same watch. A watch whose
+// notification failed is over anyway, the customer can watch the product again
+func (u *watchesUsecase) notify(ctx context.Context, due []*watches.Watch) int {
+	notified := 0
+	for _, watch := range due {
+		ok, err := u.watchesRepository.MarkWatchNotified(ctx, watch.Id)
+		if err != nil {
+			log.Printf("notify watch %s failed: %v\n", watch.Id, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		n := &notifications.Notification{
+			UserId: watch.UserId,
+			Link:   "/products/" + watch.ProductId,
+		}
+		switch watch.Kind {
+		case watches.KindBackInStock:
+			n.Type = notifications.TypeBackInStock
+			n.Title = "Back in stock"
+			n.Body = fmt.Sprintf("%s is back in stock.", watch.Title)
+		case watches.KindPriceDrop:
+			n.Type = notifications.TypePriceDrop
+			n.Title = "Price drop"
+			n.Body = fmt.Sprintf("%s is now %s %s.", watch.Title, strconv.FormatFloat(watch.Price, 'f', -1, 64), watch.Currency)
+		}
+		if err := u.notificationsUsecase.Notify(ctx, n); err != nil {
+			log.Printf("notify watch %s failed: %v\n", watch.Id, err)
+			continue
+		}
+		notified++
+	}
+	return notified
+}
+
+func (u *watchesUsecase) ExpireWatch(ctx context.Context) error {
+	expired, err := u.watchesRepository.ExpireWatch(ctx)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("expired %d watches\n", expired)
+	}
+	return nil
+}
+
+func (u *watchesUsecase) FindDemand(ctx context.Context, req *watches.DemandReq) ([]*watches.Demand, error) {
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	return u.watchesRepository.FindDemand(ctx, req)
+}
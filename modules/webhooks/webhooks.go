@@ -0,0 +1,85 @@
+package webhooks
+
+// sources of inbound webhooks, the signing secret of each is WEBHOOK_SECRET_<SOURCE>
+const (
+	SourcePayments = "payments"
+	SourceCarriers = "carriers"
+)
+
+// EventDuplicateCharge is published on pkg/events with a *DuplicateCharge payload
+const EventDuplicateCharge = "payment.duplicate_charge"
+
+// reasons and statuses of a duplicate charge
+const (
+	DuplicateSameOrder  = "same_order"  // the order was paid before
+	DuplicateSameAmount = "same_amount" // the customer paid the same amount for another order within the window
+
+	DuplicateReview    = "review"
+	DuplicateRefunded  = "refunded"
+	DuplicateDismissed = "dismissed"
+)
+
+// PaymentEvent is sent by the payment gateway when the payment of an order is settled
+type PaymentEvent struct {
+	OrderId   string  `json:"order_id"`
+	Status    string  `json:"status"`    // paid or failed
+	Reference string  `json:"reference"` // unique per payment of the gateway, required when paid
+	Amount    float64 `json:"amount"`    // total paid of the order when empty
+}
+
+type Payment struct {
+	Id        string  `json:"id" db:"id"`
+	OrderId   string  `json:"order_id" db:"order_id"`
+	UserId    string  `json:"user_id" db:"user_id"`
+	Reference string  `json:"reference" db:"reference"`
+	Amount    float64 `json:"amount" db:"amount"`
+	CreatedAt string  `json:"created_at" db:"created_at"`
+}
+
+type DuplicateCharge struct {
+	Id              int      `json:"id" db:"id"`
+	Payment         *Payment `json:"payment" db:"payment"`
+	OriginalPayment *Payment `json:"original_payment" db:"original_payment"`
+	Reason          string   `json:"reason" db:"reason"`
+	Status          string   `json:"status" db:"status"`
+	RefundRef       *string  `json:"refund_ref" db:"refund_ref"`
+	ReviewedBy      *string  `json:"reviewed_by" db:"reviewed_by"`
+	CreatedAt       string   `json:"created_at" db:"created_at"`
+	UpdatedAt       string   `json:"updated_at" db:"updated_at"`
+}
+
+type DuplicateChargeFilter struct {
+	Status string `query:"status"` // review, refunded or dismissed, every status when empty
+}
+
+// DuplicateChargeReview refund the duplicate payment or dismiss it when the charge was intended
+type DuplicateChargeReview struct {
+	Id         int    `json:"-"`
+	Status     string `json:"status"` // refunded or dismissed
+	ReviewedBy string `json:"-"`
+}
+
+// CarrierEvent is sent by the carrier when a parcel is picked up or delivered
+type CarrierEvent struct {
+	OrderId        string `json:"order_id"`
+	TrackingNumber string `json:"tracking_number"`
+	Status         string `json:"status"` // shipping or completed
+}
+
+// Metric is what a source sent in the window, rejected webhooks are counted by reason
+type Metric struct {
+	Source   string         `json:"source"`
+	Accepted int            `json:"accepted"`
+	Rejected int            `json:"rejected"`
+	Reasons  map[string]int `json:"reasons"`
+}
+
+type MetricFilter struct {
+	Hours int `query:"hours"` // last 24 hours when empty
+}
+
+type SourceCount struct {
+	Source string `db:"source"`
+	Reason string `db:"reason"`
+	Count  int    `db:"count"`
+}
@@ -0,0 +1,225 @@
+package webhooksHandlers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks/webhooksUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type webhooksHandlerErrCode string
+
+const (
+	paymentWebhookErr webhooksHandlerErrCode = "webhooks-001"
+	carrierWebhookErr webhooksHandlerErrCode = "webhooks-002"
+	findMetricErr     webhooksHandlerErrCode = "webhooks-003"
+
+	findDuplicateChargeErr   webhooksHandlerErrCode = "webhooks-004"
+	reviewDuplicateChargeErr webhooksHandlerErrCode = "webhooks-005"
+)
+
+type IWebhooksHandler interface {
+	PaymentWebhook(c *fiber.Ctx) error
+	CarrierWebhook(c *fiber.Ctx) error
+	FindMetric(c *fiber.Ctx) error
+	FindDuplicateCharge(c *fiber.Ctx) error
+	ReviewDuplicateCharge(c *fiber.Ctx) error
+}
+
+type webhooksHandler struct {
+	cfg             config.IConfig
+	webhooksUsecase webhooksUsecases.IWebhooksUsecase
+}
+
+func WebhooksHandler(cfg config.IConfig, webhooksUsecase webhooksUsecases.IWebhooksUsecase) IWebhooksHandler {
+	return &webhooksHandler{
+		cfg:             cfg,
+		webhooksUsecase: webhooksUsecase,
+	}
+}
+
+// orderError map the errors of an order update, the sender retries 5xx so a bad event must be 4xx
+func orderError(c *fiber.Ctx, code webhooksHandlerErrCode, err error) error {
+	var transitionErr *orders.TransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		return entities.NewResponse(c).Error(
+			fiber.ErrConflict.Code,
+			string(code),
+			err.Error(),
+		).Res()
+	case errors.Is(err, sql.ErrNoRows):
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(code),
+			"order not found",
+		).Res()
+	case err.Error() == "order id is required",
+		err.Error() == "reference is required",
+		strings.HasPrefix(err.Error(), "status must be"):
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(code),
+			err.Error(),
+		).Res()
+	default:
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(code),
+			err.Error(),
+		).Res()
+	}
+}
+
+func (h *webhooksHandler) PaymentWebhook(c *fiber.Ctx) error {
+	req := new(webhooks.PaymentEvent)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(paymentWebhookErr),
+			err.Error(),
+		).Res()
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+
+	order, err := h.webhooksUsecase.ReceivePayment(c.UserContext(), req)
+	if err != nil {
+		return orderError(c, paymentWebhookErr, err)
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, order).Res()
+}
+
+func (h *webhooksHandler) CarrierWebhook(c *fiber.Ctx) error {
+	req := new(webhooks.CarrierEvent)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(carrierWebhookErr),
+			err.Error(),
+		).Res()
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+
+	order, err := h.webhooksUsecase.ReceiveCarrier(c.UserContext(), req)
+	if err != nil {
+		return orderError(c, carrierWebhookErr, err)
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, order).Res()
+}
+
+func (h *webhooksHandler) FindMetric(c *fiber.Ctx) error {
+	req := new(webhooks.MetricFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findMetricErr),
+			err.Error(),
+		).Res()
+	}
+
+	metrics, err := h.webhooksUsecase.FindMetric(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findMetricErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, metrics).Res()
+}
+
+func (h *webhooksHandler) FindDuplicateCharge(c *fiber.Ctx) error {
+	req := new(webhooks.DuplicateChargeFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findDuplicateChargeErr),
+			err.Error(),
+		).Res()
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+
+	charges, err := h.webhooksUsecase.FindDuplicateCharge(c.UserContext(), req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "status must be") {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findDuplicateChargeErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findDuplicateChargeErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, charges).Res()
+}
+
+func (h *webhooksHandler) ReviewDuplicateCharge(c *fiber.Ctx) error {
+	chargeId, err := strconv.Atoi(strings.Trim(c.Params("chargeId"), " "))
+	if err != nil || chargeId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(reviewDuplicateChargeErr),
+			"duplicate charge id is invalid",
+		).Res()
+	}
+
+	req := &webhooks.DuplicateChargeReview{
+		Id:         chargeId,
+		ReviewedBy: c.Locals("userId").(string),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(reviewDuplicateChargeErr),
+			err.Error(),
+		).Res()
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+
+	charge, err := h.webhooksUsecase.ReviewDuplicateCharge(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "duplicate charge not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(reviewDuplicateChargeErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "duplicate charge has been reviewed":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(reviewDuplicateChargeErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "status must be"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(reviewDuplicateChargeErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(reviewDuplicateChargeErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, charge).Res()
+}
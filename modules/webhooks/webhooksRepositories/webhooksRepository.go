@@ -0,0 +1,289 @@
+package webhooksRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/webhooks"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IWebhooksRepository interface {
+	FindAccepted(ctx context.Context, hours int) ([]*webhooks.SourceCount, error)
+	FindRejected(ctx context.Context, hours int) ([]*webhooks.SourceCount, error)
+	DeleteExpiredNonce(ctx context.Context, olderThan time.Duration) (int64, error)
+	InsertPayment(ctx context.Context, req *webhooks.Payment) (bool, error)
+	FindDuplicatePayment(ctx context.Context, req *webhooks.Payment, window time.Duration) (*webhooks.Payment, string, error)
+	InsertDuplicateCharge(ctx context.Context, paymentId, originalPaymentId, reason string) (int, error)
+	FindDuplicateCharge(ctx context.Context, req *webhooks.DuplicateChargeFilter) ([]*webhooks.DuplicateCharge, error)
+	FindOneDuplicateCharge(ctx context.Context, id int) (*webhooks.DuplicateCharge, error)
+	UpdateDuplicateCharge(ctx context.Context, id int, status, reviewedBy string) error
+	UpdateDuplicateChargeRefund(ctx context.Context, id int, refundRef string) error
+}
+
+type webhooksRepository struct {
+	db *sqlx.DB
+}
+
+func WebhooksRepository(db *sqlx.DB) IWebhooksRepository {
+	return &webhooksRepository{
+		db: db,
+	}
+}
+
+// FindAccepted count the accepted webhooks by their nonces, nonces older than twice the tolerance are
+// deleted so a window longer than that is not complete
+func (r *webhooksRepository) FindAccepted(ctx context.Context, hours int) ([]*webhooks.SourceCount, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"source",
+		'' AS "reason",
+		COUNT(*) AS "count"
+	FROM "webhook_nonces"
+	WHERE "created_at" >= now() - interval '1 hour' * $1
+	GROUP BY "source";`
+
+	counts := make([]*webhooks.SourceCount, 0)
+	if err := r.db.SelectContext(ctx, &counts, query, hours); err != nil {
+		return nil, fmt.Errorf("find accepted webhooks failed: %v", err)
+	}
+	return counts, nil
+}
+
+func (r *webhooksRepository) FindRejected(ctx context.Context, hours int) ([]*webhooks.SourceCount, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		"source",
+		"reason",
+		COUNT(*) AS "count"
+	FROM "webhook_rejections"
+	WHERE "created_at" >= now() - interval '1 hour' * $1
+	GROUP BY "source", "reason";`
+
+	counts := make([]*webhooks.SourceCount, 0)
+	if err := r.db.SelectContext(ctx, &counts, query, hours); err != nil {
+		return nil, fmt.Errorf("find rejected webhooks failed: %v", err)
+	}
+	return counts, nil
+}
+
+func (r *webhooksRepository) DeleteExpiredNonce(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	query := `
+	DELETE FROM "webhook_nonces"
+	WHERE "created_at" < now() - interval '1 second' * $1;`
+
+	result, err := r.db.ExecContext(ctx, query, int(olderThan.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("delete expired webhook nonces failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected failed: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// InsertPayment record a successful payment, false when the reference has been recorded by an earlier delivery
+func (r *webhooksRepository) InsertPayment(ctx context.Context, req *webhooks.Payment) (bool, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "payments" (
+		"order_id",
+		"user_id",
+		"reference",
+		"amount"
+	)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT ("reference") DO NOTHING
+		RETURNING "id", "created_at";`
+
+	if err := r.db.QueryRowxContext(ctx, query, req.OrderId, req.UserId, req.Reference, req.Amount).Scan(&req.Id, &req.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("insert payment failed: %v", err)
+	}
+	return true, nil
+}
+
+// FindDuplicatePayment find an earlier payment of the same order, or of the same customer and amount for another
+// order within window. Only earlier payments count so two concurrent payments flag the second one only
+func (r *webhooksRepository) FindDuplicatePayment(ctx context.Context, req *webhooks.Payment, window time.Duration) (*webhooks.Payment, string, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	selectQuery := `
+	SELECT
+		"p"."id",
+		"p"."order_id",
+		"p"."user_id",
+		"p"."reference",
+		"p"."amount",
+		"p"."created_at"
+	FROM "payments" "p", "payments" "cur"
+	WHERE "cur"."id" = $1
+	AND ("p"."created_at", "p"."id") < ("cur"."created_at", "cur"."id")`
+
+	original := new(webhooks.Payment)
+	err := r.db.GetContext(ctx, original, selectQuery+`
+	AND "p"."order_id" = "cur"."order_id"
+	ORDER BY "p"."created_at" ASC
+	LIMIT 1;`, req.Id)
+	if err == nil {
+		return original, webhooks.DuplicateSameOrder, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, "", fmt.Errorf("find duplicate payment failed: %v", err)
+	}
+
+	err = r.db.GetContext(ctx, original, selectQuery+`
+	AND "p"."order_id" <> "cur"."order_id"
+	AND "p"."user_id" = "cur"."user_id"
+	AND "p"."amount" = "cur"."amount"
+	AND "p"."created_at" >= "cur"."created_at" - interval '1 second' * $2
+	ORDER BY "p"."created_at" DESC
+	LIMIT 1;`, req.Id, int(window.Seconds()))
+	if err == nil {
+		return original, webhooks.DuplicateSameAmount, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, "", fmt.Errorf("find duplicate payment failed: %v", err)
+	}
+	return nil, "", nil
+}
+
+func (r *webhooksRepository) InsertDuplicateCharge(ctx context.Context, paymentId, originalPaymentId, reason string) (int, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO "duplicate_charges" (
+		"payment_id",
+		"original_payment_id",
+		"reason"
+	)
+	VALUES ($1, $2, $3)
+		RETURNING "id";`
+
+	var id int
+	if err := r.db.QueryRowxContext(ctx, query, paymentId, originalPaymentId, reason).Scan(&id); err != nil {
+		return 0, fmt.Errorf("insert duplicate charge failed: %v", err)
+	}
+	return id, nil
+}
+
+// duplicateChargeQuery select the duplicate charges with both payments, the caller add the condition
+const duplicateChargeQuery = `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"d"."id",
+			to_jsonb("p") AS "payment",
+			to_jsonb("op") AS "original_payment",
+			"d"."reason",
+			"d"."status",
+			"d"."refund_ref",
+			"d"."reviewed_by",
+			"d"."created_at",
+			"d"."updated_at"
+		FROM "duplicate_charges" "d"
+			LEFT JOIN "payments" "p" ON "p"."id" = "d"."payment_id"
+			LEFT JOIN "payments" "op" ON "op"."id" = "d"."original_payment_id"
+		WHERE %s
+		ORDER BY "d"."id" DESC
+		LIMIT 200
+	) AS "t";`
+
+func (r *webhooksRepository) selectDuplicateCharge(ctx context.Context, condition string, args ...any) ([]*webhooks.DuplicateCharge, error) {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	bytes := make([]byte, 0)
+	if err := r.db.GetContext(ctx, &bytes, fmt.Sprintf(duplicateChargeQuery, condition), args...); err != nil {
+		return nil, fmt.Errorf("find duplicate charges failed: %v", err)
+	}
+
+	charges := make([]*webhooks.DuplicateCharge, 0)
+	if err := json.Unmarshal(bytes, &charges); err != nil {
+		return nil, fmt.Errorf("unmarshal duplicate charges failed: %v", err)
+	}
+	return charges, nil
+}
+
+func (r *webhooksRepository) FindDuplicateCharge(ctx context.Context, req *webhooks.DuplicateChargeFilter) ([]*webhooks.DuplicateCharge, error) {
+	if req.Status == "" {
+		return r.selectDuplicateCharge(ctx, `TRUE`)
+	}
+	return r.selectDuplicateCharge(ctx, `"d"."status" = $1`, req.Status)
+}
+
+func (r *webhooksRepository) FindOneDuplicateCharge(ctx context.Context, id int) (*webhooks.DuplicateCharge, error) {
+	charges, err := r.selectDuplicateCharge(ctx, `"d"."id" = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(charges) == 0 {
+		return nil, fmt.Errorf("duplicate charge not found")
+	}
+	return charges[0], nil
+}
+
+// UpdateDuplicateCharge only change a charge which is still in review, so it is not refunded twice
+func (r *webhooksRepository) UpdateDuplicateCharge(ctx context.Context, id int, status, reviewedBy string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "duplicate_charges" SET
+		"status" = $1,
+		"reviewed_by" = $2
+	WHERE "id" = $3
+	AND "status" = 'review';`
+
+	result, err := r.db.ExecContext(ctx, query, status, reviewedBy, id)
+	if err != nil {
+		return fmt.Errorf("update duplicate charge failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("duplicate charge has been reviewed")
+	}
+	return nil
+}
+
+// UpdateDuplicateChargeRefund save the reference of the refund, an empty reference means the refund failed
+// and the charge goes back to review
+func (r *webhooksRepository) UpdateDuplicateChargeRefund(ctx context.Context, id int, refundRef string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "duplicate_charges" SET
+		"status" = CASE WHEN $1 = '' THEN 'review'::duplicate_charge_status ELSE "status" END,
+		"refund_ref" = NULLIF($1, '')
+	WHERE "id" = $2;`
+
+	if _, err := r.db.ExecContext(ctx, query, refundRef, id); err != nil {
+		return fmt.Errorf("update duplicate charge refund failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package webhooksUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/refunds/refundsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks"
+	"github.com/NatthawutSK/ri-shop/modules/webhooks/webhooksRepositories"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+type IWebhooksUsecase interface {
+	ReceivePayment(ctx context.Context, req *webhooks.PaymentEvent) (*orders.Order, error)
+	ReceiveCarrier(ctx context.Context, req *webhooks.CarrierEvent) (*orders.Order, error)
+	FindMetric(ctx context.Context, req *webhooks.MetricFilter) ([]*webhooks.Metric, error)
+	DeleteExpiredNonce(ctx context.Context) error
+	FindDuplicateCharge(ctx context.Context, req *webhooks.DuplicateChargeFilter) ([]*webhooks.DuplicateCharge, error)
+	ReviewDuplicateCharge(ctx context.Context, req *webhooks.DuplicateChargeReview) (*webhooks.DuplicateCharge, error)
+}
+
+type webhooksUsecase struct {
+	cfg                config.IWebhookConfig
+	webhooksRepository webhooksRepositories.IWebhooksRepository
+	ordersUsecase      ordersUsecases.IOrdersUsecase
+	refundsUsecase     refundsUsecases.IRefundsUsecase
+}
+
+func WebhooksUsecase(cfg config.IWebhookConfig, webhooksRepository webhooksRepositories.IWebhooksRepository, ordersUsecase ordersUsecases.IOrdersUsecase, refundsUsecase refundsUsecases.IRefundsUsecase) IWebhooksUsecase {
+	return &webhooksUsecase{
+		cfg:                cfg,
+		webhooksRepository: webhooksRepository,
+		ordersUsecase:      ordersUsecase,
+		refundsUsecase:     refundsUsecase,
+	}
+}
+
+// ReceivePayment mark the order as paid, a failed payment leave the order waiting so the customer can
+// pay again. The gateway may send the same payment twice, the reference is recorded once and an order
+// which is already paid is unchanged. A second payment which charged the customer again is flagged
+func (u *webhooksUsecase) ReceivePayment(ctx context.Context, req *webhooks.PaymentEvent) (*orders.Order, error) {
+	if strings.TrimSpace(req.OrderId) == "" {
+		return nil, fmt.Errorf("order id is required")
+	}
+
+	switch req.Status {
+	case orders.StatusPaid:
+		req.Reference = strings.TrimSpace(req.Reference)
+		if req.Reference == "" {
+			return nil, fmt.Errorf("reference is required")
+		}
+
+		order, err := u.ordersUsecase.FindOneOrder(ctx, req.OrderId)
+		if err != nil {
+			return nil, err
+		}
+		if req.Amount <= 0 {
+			req.Amount = order.TotalPaid
+		}
+
+		payment := &webhooks.Payment{
+			OrderId:   order.Id,
+			UserId:    order.UserId,
+			Reference: req.Reference,
+			Amount:    req.Amount,
+		}
+		recorded, err := u.webhooksRepository.InsertPayment(ctx, payment)
+		if err != nil {
+			return nil, err
+		}
+		if !recorded && order.Status != orders.StatusWaiting {
+			return order, nil
+		}
+
+		if recorded {
+			original, reason, err := u.webhooksRepository.FindDuplicatePayment(ctx, payment, u.cfg.DuplicateWindow())
+			if err != nil {
+				return nil, err
+			}
+			if original != nil {
+				u.flagDuplicateCharge(ctx, payment, original, reason)
+				// order นี้จ่ายไปแล้ว ไม่ต้องเปลี่ยน status
+				if reason == webhooks.DuplicateSameOrder {
+					return order, nil
+				}
+			}
+		}
+
+		return u.ordersUsecase.UpdateOrder(ctx, &orders.OrderUpdate{
+			Id:          req.OrderId,
+			Status:      orders.StatusPaid,
+			ActorId:     "webhook:" + webhooks.SourcePayments,
+			ActorRoleId: 2,
+		})
+	case "failed":
+		log.Printf("payment %s of order %s failed\n", req.Reference, req.OrderId)
+		return u.ordersUsecase.FindOneOrder(ctx, req.OrderId)
+	default:
+		return nil, fmt.Errorf("status must be paid or failed")
+	}
+}
+
+func (u *webhooksUsecase) ReceiveCarrier(ctx context.Context, req *webhooks.CarrierEvent) (*orders.Order, error) {
+	if strings.TrimSpace(req.OrderId) == "" {
+		return nil, fmt.Errorf("order id is required")
+	}
+	if req.Status != orders.StatusShipping && req.Status != orders.StatusCompleted {
+		return nil, fmt.Errorf("status must be shipping or completed")
+	}
+
+	return u.ordersUsecase.UpdateOrder(ctx, &orders.OrderUpdate{
+		Id:             req.OrderId,
+		Status:         req.Status,
+		TrackingNumber: strings.TrimSpace(req.TrackingNumber),
+		ActorId:        "webhook:" + webhooks.SourceCarriers,
+		ActorRoleId:    2,
+	})
+}
+
+func (u *webhooksUsecase) FindMetric(ctx context.Context, req *webhooks.MetricFilter) ([]*webhooks.Metric, error) {
+	if req.Hours < 1 {
+		req.Hours = 24
+	}
+
+	accepted, err := u.webhooksRepository.FindAccepted(ctx, req.Hours)
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := u.webhooksRepository.FindRejected(ctx, req.Hours)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make(map[string]*webhooks.Metric)
+	metric := func(source string) *webhooks.Metric {
+		if metrics[source] == nil {
+			metrics[source] = &webhooks.Metric{
+				Source:  source,
+				Reasons: make(map[string]int),
+			}
+		}
+		return metrics[source]
+	}
+	for _, source := range []string{webhooks.SourcePayments, webhooks.SourceCarriers} {
+		metric(source)
+	}
+	for _, count := range accepted {
+		metric(count.Source).Accepted += count.Count
+	}
+	for _, count := range rejected {
+		m := metric(count.Source)
+		m.Rejected += count.Count
+		m.Reasons[count.Reason] += count.Count
+	}
+
+	res := make([]*webhooks.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		res = append(res, m)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Source < res[j].Source })
+	return res, nil
+}
+
+// DeleteExpiredNonce delete the nonces older than twice the timestamp tolerance, a webhook that old is
+// rejected as expired before its nonce is checked
+func (u *webhooksUsecase) DeleteExpiredNonce(ctx context.Context) error {
+	if _, err := u.webhooksRepository.DeleteExpiredNonce(ctx, u.cfg.Tolerance()*2); err != nil {
+		return err
+	}
+	return nil
+}
+
+// flagDuplicateCharge record the charge and alert the admins, a second payment of the same order is refunded
+// right away when WEBHOOK_DUPLICATE_AUTO_REFUND is on. The payment webhook still succeed when this fails
+func (u *webhooksUsecase) flagDuplicateCharge(ctx context.Context, payment, original *webhooks.Payment, reason string) {
+	id, err := u.webhooksRepository.InsertDuplicateCharge(ctx, payment.Id, original.Id, reason)
+	if err != nil {
+		log.Printf("flag duplicate charge of payment %s failed: %v\n", payment.Reference, err)
+		return
+	}
+
+	if reason == webhooks.DuplicateSameOrder && u.cfg.DuplicateAutoRefund() {
+		if _, err := u.ReviewDuplicateCharge(ctx, &webhooks.DuplicateChargeReview{
+			Id:         id,
+			Status:     webhooks.DuplicateRefunded,
+			ReviewedBy: "webhook:" + webhooks.SourcePayments,
+		}); err != nil {
+			log.Printf("refund duplicate charge %d failed, it is left for review: %v\n", id, err)
+		}
+	}
+
+	charge, err := u.webhooksRepository.FindOneDuplicateCharge(ctx, id)
+	if err != nil {
+		log.Printf("find duplicate charge %d failed: %v\n", id, err)
+		return
+	}
+	events.Publish(webhooks.EventDuplicateCharge, charge)
+}
+
+func (u *webhooksUsecase) FindDuplicateCharge(ctx context.Context, req *webhooks.DuplicateChargeFilter) ([]*webhooks.DuplicateCharge, error) {
+	switch req.Status {
+	case "", webhooks.DuplicateReview, webhooks.DuplicateRefunded, webhooks.DuplicateDismissed:
+	default:
+		return nil, fmt.Errorf("status must be review, refunded or dismissed")
+	}
+	return u.webhooksRepository.FindDuplicateCharge(ctx, req)
+}
+
+// ReviewDuplicateCharge refund the duplicate payment or dismiss the charge. The charge is claimed before the
+// refund so it is never refunded twice, a failed refund put it back to review
+func (u *webhooksUsecase) ReviewDuplicateCharge(ctx context.Context, req *webhooks.DuplicateChargeReview) (*webhooks.DuplicateCharge, error) {
+	if req.Status != webhooks.DuplicateRefunded && req.Status != webhooks.DuplicateDismissed {
+		return nil, fmt.Errorf("status must be refunded or dismissed")
+	}
+
+	charge, err := u.webhooksRepository.FindOneDuplicateCharge(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.webhooksRepository.UpdateDuplicateCharge(ctx, req.Id, req.Status, req.ReviewedBy); err != nil {
+		return nil, err
+	}
+
+	if req.Status == webhooks.DuplicateRefunded {
+		ref, err := u.refundsUsecase.RefundCharge(ctx, charge.Payment.OrderId, charge.Payment.Amount)
+		if err != nil {
+			if err := u.webhooksRepository.UpdateDuplicateChargeRefund(ctx, req.Id, ""); err != nil {
+				log.Printf("put duplicate charge %d back to review failed: %v\n", req.Id, err)
+			}
+			return nil, err
+		}
+		if err := u.webhooksRepository.UpdateDuplicateChargeRefund(ctx, req.Id, ref); err != nil {
+			return nil, fmt.Errorf("refund %s was issued but save it failed: %v", ref, err)
+		}
+	}
+
+	return u.webhooksRepository.FindOneDuplicateCharge(ctx, req.Id)
+}
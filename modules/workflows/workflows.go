@@ -0,0 +1,36 @@
+package workflows
+
+type Action string
+
+const (
+	Webhook       Action = "webhook"         // params: url
+	AddTag        Action = "add_tag"         // params: tag
+	SendTemplate  Action = "send_template"   // params: template
+	HoldForReview Action = "hold_for_review" // params: reason (optional)
+)
+
+// RequiredParams is the params each action must have
+var RequiredParams = map[Action][]string{
+	Webhook:       {"url"},
+	AddTag:        {"tag"},
+	SendTemplate:  {"template"},
+	HoldForReview: {},
+}
+
+type Hook struct {
+	Id         int               `json:"id"`
+	FromStatus *string           `json:"from_status"` // null matches any status
+	ToStatus   string            `json:"to_status"`
+	Action     Action            `json:"action"`
+	Params     map[string]string `json:"params"`
+	Enabled    bool              `json:"enabled"`
+	CreatedAt  string            `json:"created_at"`
+}
+
+// Transition is an order status change passed to the hooks
+type Transition struct {
+	OrderId    string `json:"order_id"`
+	UserId     string `json:"user_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+}
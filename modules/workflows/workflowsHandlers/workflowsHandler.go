@@ -0,0 +1,141 @@
+package workflowsHandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/workflows"
+	"github.com/NatthawutSK/ri-shop/modules/workflows/workflowsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type workflowsHandlerErrCode string
+
+const (
+	findHookErr   workflowsHandlerErrCode = "workflows-001"
+	insertHookErr workflowsHandlerErrCode = "workflows-002"
+	deleteHookErr workflowsHandlerErrCode = "workflows-003"
+)
+
+type IWorkflowsHandler interface {
+	FindHook(c *fiber.Ctx) error
+	AddHook(c *fiber.Ctx) error
+	DeleteHook(c *fiber.Ctx) error
+}
+
+type workflowsHandler struct {
+	cfg              config.IConfig
+	workflowsUsecase workflowsUsecases.IWorkflowsUsecase
+}
+
+func WorkflowsHandler(cfg config.IConfig, workflowsUsecase workflowsUsecases.IWorkflowsUsecase) IWorkflowsHandler {
+	return &workflowsHandler{
+		cfg:              cfg,
+		workflowsUsecase: workflowsUsecase,
+	}
+}
+
+func (h *workflowsHandler) FindHook(c *fiber.Ctx) error {
+	hooks, err := h.workflowsUsecase.FindHook(c.UserContext())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findHookErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, hooks).Res()
+}
+
+func (h *workflowsHandler) AddHook(c *fiber.Ctx) error {
+	req := &workflows.Hook{
+		Params:  make(map[string]string),
+		Enabled: true,
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertHookErr),
+			err.Error(),
+		).Res()
+	}
+
+	statusMap := map[string]string{
+		"waiting":          "waiting",
+		"paid":             "paid",
+		"shipping":         "shipping",
+		"ready_for_pickup": "ready_for_pickup",
+		"completed":        "completed",
+		"canceled":         "canceled",
+	}
+
+	fields := make(entities.ValidationErrors, 0)
+	req.ToStatus = statusMap[strings.ToLower(req.ToStatus)]
+	if req.ToStatus == "" {
+		fields = append(fields, &entities.FieldError{Field: "to_status", Msg: "is invalid"})
+	}
+	if req.FromStatus != nil {
+		fromStatus := statusMap[strings.ToLower(*req.FromStatus)]
+		if fromStatus == "" {
+			fields = append(fields, &entities.FieldError{Field: "from_status", Msg: "is invalid"})
+		}
+		req.FromStatus = &fromStatus
+	}
+	if _, ok := workflows.RequiredParams[req.Action]; !ok {
+		fields = append(fields, &entities.FieldError{Field: "action", Msg: "is invalid"})
+	}
+	if len(fields) > 0 {
+		return entities.NewResponse(c).ValidationError(
+			fiber.ErrBadRequest.Code,
+			string(insertHookErr),
+			fields,
+		).Res()
+	}
+	if req.Params == nil {
+		req.Params = make(map[string]string)
+	}
+
+	hook, err := h.workflowsUsecase.AddHook(c.UserContext(), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertHookErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, hook).Res()
+}
+
+func (h *workflowsHandler) DeleteHook(c *fiber.Ctx) error {
+	hookId, err := strconv.Atoi(strings.Trim(c.Params("hookId"), " "))
+	if err != nil || hookId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteHookErr),
+			"hook id is invalid",
+		).Res()
+	}
+
+	if err := h.workflowsUsecase.DeleteHook(c.UserContext(), hookId); err != nil {
+		switch err.Error() {
+		case "hook not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteHookErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteHookErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
+}
@@ -0,0 +1,143 @@
+package workflowsRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/NatthawutSK/ri-shop/modules/workflows"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+type IWorkflowsRepository interface {
+	FindHook(ctx context.Context) ([]*workflows.Hook, error)
+	FindHookByTransition(ctx context.Context, fromStatus, toStatus string) ([]*workflows.Hook, error)
+	InsertHook(ctx context.Context, req *workflows.Hook) error
+	DeleteHook(ctx context.Context, hookId int) error
+	AddOrderTag(ctx context.Context, orderId, tag string) error
+	HoldOrder(ctx context.Context, orderId string) error
+}
+
+type workflowsRepository struct {
+	db *sqlx.DB
+}
+
+func WorkflowsRepository(db *sqlx.DB) IWorkflowsRepository {
+	return &workflowsRepository{
+		db: db,
+	}
+}
+
+func (r *workflowsRepository) findHook(ctx context.Context, where string, args ...any) ([]*workflows.Hook, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"h"."id",
+			"h"."from_status",
+			"h"."to_status",
+			"h"."action",
+			"h"."params",
+			"h"."enabled",
+			"h"."created_at"
+		FROM "order_hooks" "h"
+		WHERE 1 = 1 %s
+		ORDER BY "h"."id" ASC
+	) AS "t";`, where)
+
+	bytes := make([]byte, 0)
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	hooks := make([]*workflows.Hook, 0)
+	if err := r.db.GetContext(ctx, &bytes, query, args...); err != nil {
+		return nil, fmt.Errorf("get hooks failed: %v", err)
+	}
+	if err := json.Unmarshal(bytes, &hooks); err != nil {
+		return nil, fmt.Errorf("unmarshal hooks failed: %v", err)
+	}
+	return hooks, nil
+}
+
+func (r *workflowsRepository) FindHook(ctx context.Context) ([]*workflows.Hook, error) {
+	return r.findHook(ctx, "")
+}
+
+func (r *workflowsRepository) FindHookByTransition(ctx context.Context, fromStatus, toStatus string) ([]*workflows.Hook, error) {
+	return r.findHook(ctx, `
+		AND "h"."enabled" = TRUE
+		AND "h"."to_status" = $1
+		AND ("h"."from_status" IS NULL OR "h"."from_status"::TEXT = $2)`, toStatus, fromStatus)
+}
+
+func (r *workflowsRepository) InsertHook(ctx context.Context, req *workflows.Hook) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return fmt.Errorf("marshal hook params failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "order_hooks" (
+		"from_status",
+		"to_status",
+		"action",
+		"params",
+		"enabled"
+	)
+	VALUES ($1, $2, $3, $4::jsonb, $5)
+		RETURNING "id", "created_at";`
+
+	if err := r.db.QueryRowContext(ctx, query, req.FromStatus, req.ToStatus, req.Action, string(params), req.Enabled).Scan(&req.Id, &req.CreatedAt); err != nil {
+		return fmt.Errorf("insert hook failed: %v", err)
+	}
+	return nil
+}
+
+func (r *workflowsRepository) DeleteHook(ctx context.Context, hookId int) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM "order_hooks" WHERE "id" = $1;`, hookId)
+	if err != nil {
+		return fmt.Errorf("delete hook failed: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected failed: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("hook not found")
+	}
+	return nil
+}
+
+func (r *workflowsRepository) AddOrderTag(ctx context.Context, orderId, tag string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE "orders" SET
+		"tags" = array_append("tags", $1)
+	WHERE "id" = $2
+	AND NOT ($1 = ANY("tags"));`
+
+	if _, err := r.db.ExecContext(ctx, query, tag, orderId); err != nil {
+		return fmt.Errorf("add order tag failed: %v", err)
+	}
+	return nil
+}
+
+func (r *workflowsRepository) HoldOrder(ctx context.Context, orderId string) error {
+	ctx, cancel := databases.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE "orders" SET "on_hold" = TRUE WHERE "id" = $1;`, orderId); err != nil {
+		return fmt.Errorf("hold order failed: %v", err)
+	}
+	return nil
+}
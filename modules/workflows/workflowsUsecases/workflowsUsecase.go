@@ -0,0 +1,132 @@
+package workflowsUsecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/workflows"
+	"github.com/NatthawutSK/ri-shop/modules/workflows/workflowsRepositories"
+)
+
+type IWorkflowsUsecase interface {
+	FindHook(ctx context.Context) ([]*workflows.Hook, error)
+	AddHook(ctx context.Context, req *workflows.Hook) (*workflows.Hook, error)
+	DeleteHook(ctx context.Context, hookId int) error
+	RunHooks(ctx context.Context, req *workflows.Transition)
+}
+
+type workflowsUsecase struct {
+	workflowsRepository workflowsRepositories.IWorkflowsRepository
+	client              *http.Client
+}
+
+func WorkflowsUsecase(workflowsRepository workflowsRepositories.IWorkflowsRepository) IWorkflowsUsecase {
+	return &workflowsUsecase{
+		workflowsRepository: workflowsRepository,
+		client:              &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (u *workflowsUsecase) FindHook(ctx context.Context) ([]*workflows.Hook, error) {
+	hooks, err := u.workflowsRepository.FindHook(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (u *workflowsUsecase) AddHook(ctx context.Context, req *workflows.Hook) (*workflows.Hook, error) {
+	required, ok := workflows.RequiredParams[req.Action]
+	if !ok {
+		return nil, fmt.Errorf("action %s is invalid", req.Action)
+	}
+	for _, key := range required {
+		if req.Params[key] == "" {
+			return nil, fmt.Errorf("param %s is required for action %s", key, req.Action)
+		}
+	}
+	if req.Action == workflows.Webhook {
+		if u, err := url.ParseRequestURI(req.Params["url"]); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("webhook url is invalid")
+		}
+	}
+
+	if err := u.workflowsRepository.InsertHook(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *workflowsUsecase) DeleteHook(ctx context.Context, hookId int) error {
+	if err := u.workflowsRepository.DeleteHook(ctx, hookId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RunHooks run every enabled hook matching the transition, a failing hook is logged and does not stop the others
+// or the status change itself
+func (u *workflowsUsecase) RunHooks(ctx context.Context, req *workflows.Transition) {
+	if req.FromStatus == req.ToStatus {
+		return
+	}
+
+	hooks, err := u.workflowsRepository.FindHookByTransition(ctx, req.FromStatus, req.ToStatus)
+	if err != nil {
+		log.Printf("find order hooks failed: %v\n", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if err := u.runHook(ctx, hook, req); err != nil {
+			log.Printf("order hook %d (%s) on order %s failed: %v\n", hook.Id, hook.Action, req.OrderId, err)
+		}
+	}
+}
+
+func (u *workflowsUsecase) runHook(ctx context.Context, hook *workflows.Hook, req *workflows.Transition) error {
+	switch hook.Action {
+	case workflows.Webhook:
+		// webhook ไม่ต้องรอ response เพื่อไม่ให้ update order ช้า
+		go func(endpoint string) {
+			if err := u.callWebhook(endpoint, req); err != nil {
+				log.Printf("order hook %d (%s) on order %s failed: %v\n", hook.Id, hook.Action, req.OrderId, err)
+			}
+		}(hook.Params["url"])
+		return nil
+	case workflows.AddTag:
+		return u.workflowsRepository.AddOrderTag(ctx, req.OrderId, hook.Params["tag"])
+	case workflows.HoldForReview:
+		return u.workflowsRepository.HoldOrder(ctx, req.OrderId)
+	case workflows.SendTemplate:
+		// there is no mailer yet, the hook is only logged
+		log.Printf("order hook %d: send template %s for order %s to user %s\n", hook.Id, hook.Params["template"], req.OrderId, req.UserId)
+		return nil
+	default:
+		return fmt.Errorf("action %s is invalid", hook.Action)
+	}
+}
+
+func (u *workflowsUsecase) callWebhook(endpoint string, req *workflows.Transition) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal webhook body failed: %v", err)
+	}
+
+	res, err := u.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("call webhook failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package myTests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/carts"
+	"github.com/NatthawutSK/ri-shop/modules/checkout"
+	"github.com/NatthawutSK/ri-shop/modules/checkout/checkoutUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/shipping"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/resilience"
+)
+
+// checkoutQuote is 3 x 50 with a coupon of 10, picked up and taxed 7%
+func checkoutQuote() *mocks.CartsUsecase {
+	return &mocks.CartsUsecase{
+		QuoteFn: func(ctx context.Context, req *carts.QuoteReq) (*carts.Quote, error) {
+			quote := &carts.Quote{
+				Lines:    []*carts.QuoteLine{{ProductId: "P000001", Title: "Coffee", Qty: 3, UnitPrice: 50, Total: 150}},
+				Subtotal: 150,
+				Tax:      9.8,
+				Total:    149.8,
+				Currency: "THB",
+			}
+			if req.CouponCode != "" {
+				quote.Discounts = []*carts.Discount{{Code: req.CouponCode, Name: "Save 10", Amount: 10}}
+				quote.DiscountTotal = 10
+			}
+			return quote, nil
+		},
+	}
+}
+
+func checkoutStock() *mocks.InventoryRepository {
+	return &mocks.InventoryRepository{
+		ReleaseCheckoutFn: func(ctx context.Context, userId string) error { return nil },
+		ReserveStockFn: func(ctx context.Context, req *inventory.Reservation) error {
+			req.ExpiresAt = "2026-01-01T10:10:00Z"
+			return nil
+		},
+		ReleaseStockFn: func(ctx context.Context, reference string) (int, error) { return 1, nil },
+	}
+}
+
+func checkoutOrders(totalPaid float64) *mocks.OrdersUsecase {
+	return &mocks.OrdersUsecase{
+		InsertOrderFn: func(ctx context.Context, req *orders.Order) (*orders.Order, error) {
+			order := *req
+			order.Id = "O000001"
+			order.TotalPaid = totalPaid
+			order.Tax = &taxes.Breakdown{Total: 9.8}
+			return &order, nil
+		},
+		UpdateOrderFn: func(ctx context.Context, req *orders.OrderUpdate) (*orders.Order, error) {
+			return &orders.Order{Id: req.Id, Status: req.Status}, nil
+		},
+	}
+}
+
+func checkoutReq() *checkout.CheckoutReq {
+	return &checkout.CheckoutReq{
+		UserId: "U000001",
+		Items: []*shipping.QuoteItem{
+			{ProductId: "P000001", Qty: 1},
+			{ProductId: " P000001", Qty: 2},
+		},
+		CouponCode:   "SAVE10",
+		Fulfillment:  orders.FulfillmentPickup,
+		PickupSlotId: 1,
+	}
+}
+
+type failedGateway struct{}
+
+func (g *failedGateway) Code() string { return "card" }
+
+func (g *failedGateway) Initiate(ctx context.Context, order *orders.Order, amount float64, currency string) (*checkout.Payment, error) {
+	return nil, resilience.Permanent(fmt.Errorf("card declined"))
+}
+
+func TestCheckout(t *testing.T) {
+	stock := checkoutStock()
+	ordersUsecase := checkoutOrders(149.8)
+	usecase := checkoutUsecases.CheckoutUsecase(checkoutQuote(), inventoryUsecases.InventoryUsecase(nil, stock, nil), ordersUsecase, nil, checkoutUsecases.ManualTransferGateway())
+
+	var placed *orders.Order
+	insertOrder := ordersUsecase.InsertOrderFn
+	ordersUsecase.InsertOrderFn = func(ctx context.Context, req *orders.Order) (*orders.Order, error) {
+		placed = req
+		return insertOrder(ctx, req)
+	}
+
+	res, err := usecase.Checkout(context.Background(), checkoutReq())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(placed.Products) != 1 || placed.Products[0].Qty != 3 {
+		t.Errorf("expected the items of the same product to be merged, got: %+v", placed.Products)
+	}
+	if placed.Reservation == "" || placed.Discount != 10 || placed.CouponCode != "SAVE10" || placed.Status != orders.StatusWaiting {
+		t.Errorf("expected the order to have the hold and the coupon, got: %+v", placed)
+	}
+	if res.Breakdown.Total != 149.8 || res.Breakdown.DiscountTotal != 10 || res.Breakdown.Tax != 9.8 {
+		t.Errorf("unexpected breakdown: %+v", res.Breakdown)
+	}
+	if res.Payment.Method != "manual" || res.Payment.Status != checkout.PaymentPending || res.Payment.Amount != 149.8 {
+		t.Errorf("unexpected payment: %+v", res.Payment)
+	}
+	if res.HeldUntil == "" {
+		t.Error("expected the expiry of the hold")
+	}
+	if stock.Calls("ReleaseStock") != 0 || ordersUsecase.Calls("UpdateOrder") != 0 {
+		t.Error("expected nothing to be undone")
+	}
+}
+
+func TestCheckoutValidate(t *testing.T) {
+	usecase := checkoutUsecases.CheckoutUsecase(checkoutQuote(), nil, nil, nil, checkoutUsecases.ManualTransferGateway())
+
+	tests := []struct {
+		name  string
+		req   *checkout.CheckoutReq
+		field string
+	}{
+		{"qty", &checkout.CheckoutReq{Items: []*shipping.QuoteItem{{ProductId: "P000001"}}}, "items[0].qty"},
+		{"product", &checkout.CheckoutReq{Items: []*shipping.QuoteItem{{Qty: 1}}}, "items[0].product_id"},
+		{"fulfillment", &checkout.CheckoutReq{Items: []*shipping.QuoteItem{{ProductId: "P000001", Qty: 1}}, Fulfillment: "drone"}, "fulfillment"},
+		{"gift", &checkout.CheckoutReq{Items: []*shipping.QuoteItem{{ProductId: "P000001", Qty: 1}}, Gift: &orders.Gift{}}, "gift.recipient"},
+		{"payment", &checkout.CheckoutReq{Items: []*shipping.QuoteItem{{ProductId: "P000001", Qty: 1}}, Fulfillment: orders.FulfillmentPickup, PaymentMethod: "bitcoin"}, "payment_method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := usecase.Checkout(context.Background(), tt.req)
+			var fieldErrs entities.ValidationErrors
+			if !errors.As(err, &fieldErrs) || fieldErrs[0].Field != tt.field {
+				t.Errorf("expected an error of %s, got: %v", tt.field, err)
+			}
+		})
+	}
+
+	if _, err := usecase.Checkout(context.Background(), &checkout.CheckoutReq{}); err == nil || err.Error() != "items are empty" {
+		t.Errorf("expected items are empty, got: %v", err)
+	}
+}
+
+func TestCheckoutCouponError(t *testing.T) {
+	quote := &mocks.CartsUsecase{
+		QuoteFn: func(ctx context.Context, req *carts.QuoteReq) (*carts.Quote, error) {
+			return &carts.Quote{CouponError: "coupon not found"}, nil
+		},
+	}
+	stock := checkoutStock()
+	usecase := checkoutUsecases.CheckoutUsecase(quote, inventoryUsecases.InventoryUsecase(nil, stock, nil), nil, nil, checkoutUsecases.ManualTransferGateway())
+
+	_, err := usecase.Checkout(context.Background(), checkoutReq())
+	var fieldErrs entities.ValidationErrors
+	if !errors.As(err, &fieldErrs) || fieldErrs[0].Field != "coupon_code" || fieldErrs[0].Msg != "coupon not found" {
+		t.Fatalf("expected the coupon to be rejected, got: %v", err)
+	}
+	if stock.Calls("ReserveStock") != 0 {
+		t.Error("expected no stock to be held")
+	}
+}
+
+func TestCheckoutCompensation(t *testing.T) {
+	t.Run("order failed", func(t *testing.T) {
+		stock := checkoutStock()
+		ordersUsecase := &mocks.OrdersUsecase{
+			InsertOrderFn: func(ctx context.Context, req *orders.Order) (*orders.Order, error) {
+				return nil, fmt.Errorf("pickup slot is full")
+			},
+		}
+		usecase := checkoutUsecases.CheckoutUsecase(checkoutQuote(), inventoryUsecases.InventoryUsecase(nil, stock, nil), ordersUsecase, nil, checkoutUsecases.ManualTransferGateway())
+
+		if _, err := usecase.Checkout(context.Background(), checkoutReq()); err == nil || err.Error() != "pickup slot is full" {
+			t.Fatalf("expected the error of the order, got: %v", err)
+		}
+		if stock.Calls("ReleaseStock") != 1 {
+			t.Error("expected the hold to be released")
+		}
+	})
+
+	t.Run("price changed", func(t *testing.T) {
+		stock := checkoutStock()
+		ordersUsecase := checkoutOrders(159.8)
+		usecase := checkoutUsecases.CheckoutUsecase(checkoutQuote(), inventoryUsecases.InventoryUsecase(nil, stock, nil), ordersUsecase, nil, checkoutUsecases.ManualTransferGateway())
+
+		if _, err := usecase.Checkout(context.Background(), checkoutReq()); err == nil || err.Error() != "prices changed during checkout" {
+			t.Fatalf("expected prices changed, got: %v", err)
+		}
+		if ordersUsecase.Calls("UpdateOrder") != 1 || stock.Calls("ReleaseStock") != 0 {
+			t.Error("expected the order to be canceled, its hold is released with it")
+		}
+	})
+
+	t.Run("payment failed", func(t *testing.T) {
+		ordersUsecase := checkoutOrders(149.8)
+		var canceled *orders.OrderUpdate
+		ordersUsecase.UpdateOrderFn = func(ctx context.Context, req *orders.OrderUpdate) (*orders.Order, error) {
+			canceled = req
+			return &orders.Order{Id: req.Id, Status: req.Status}, nil
+		}
+		req := checkoutReq()
+		req.PaymentMethod = "card"
+		usecase := checkoutUsecases.CheckoutUsecase(checkoutQuote(), inventoryUsecases.InventoryUsecase(nil, checkoutStock(), nil), ordersUsecase, nil, &failedGateway{})
+
+		if _, err := usecase.Checkout(context.Background(), req); err == nil || err.Error() != "card declined" {
+			t.Fatalf("expected the error of the gateway, got: %v", err)
+		}
+		if canceled == nil || canceled.Id != "O000001" || canceled.Status != orders.StatusCanceled {
+			t.Errorf("expected the order to be canceled, got: %+v", canceled)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package myTests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/exports"
+	"github.com/NatthawutSK/ri-shop/modules/exports/exportsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func exportsRepository() *mocks.ExportsRepository {
+	rows := []*exports.OrderRow{
+		{OrderId: "O000001", CreatedAt: "2026-01-02T10:00:00", Status: "paid", CustomerId: "U000001", Contact: "Ann, Bangkok", Currency: "THB", Subtotal: 200, ShippingFee: 50, Tax: 14, Total: 264, Lines: []*exports.OrderLine{{ProductId: "P000001", Title: "Coffee", Qty: 2, UnitPrice: 100}}},
+		{OrderId: "O000002", CreatedAt: "2026-01-03T11:00:00", Status: "completed", CustomerId: "U000002", Currency: "THB", Subtotal: 35.5, Total: 35.5, Lines: []*exports.OrderLine{{ProductId: "P000002", Qty: 1, UnitPrice: 35.5}}},
+	}
+	return &mocks.ExportsRepository{
+		FindOrderRowsFn: func(ctx context.Context, req *exports.ExportReq, fn func(row *exports.OrderRow) error) (int, error) {
+			for i, row := range rows {
+				if err := fn(row); err != nil {
+					return i, err
+				}
+			}
+			return len(rows), nil
+		},
+	}
+}
+
+func TestExportsValidate(t *testing.T) {
+	usecase := exportsUsecases.ExportsUsecase(exportsRepository(), nil)
+
+	req := &exports.ExportReq{EndDate: "2026-02-01"}
+	if err := usecase.Validate(req); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if req.StartDate != "2026-01-02" || req.Format != exports.FormatCsv || len(req.Columns) != len(exports.CsvColumns) {
+		t.Errorf("expected the defaults, got: %+v", req)
+	}
+
+	tests := []struct {
+		req *exports.ExportReq
+		err string
+	}{
+		{&exports.ExportReq{StartDate: "2026-13-01"}, "start date is invalid"},
+		{&exports.ExportReq{StartDate: "2026-02-02", EndDate: "2026-02-01"}, "start date is after end date"},
+		{&exports.ExportReq{StartDate: "2024-01-01", EndDate: "2026-01-01"}, "an export has at most 366 days"},
+		{&exports.ExportReq{Status: "lost"}, "status is invalid"},
+		{&exports.ExportReq{Format: "xml"}, "format must be csv or json"},
+		{&exports.ExportReq{Columns: []string{"order_id", "password"}}, "column password is invalid"},
+	}
+	for _, test := range tests {
+		err := usecase.Validate(test.req)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("expected: %v, got: %v", test.err, err)
+		}
+	}
+}
+
+func TestExportsWriteCsv(t *testing.T) {
+	usecase := exportsUsecases.ExportsUsecase(exportsRepository(), nil)
+	req := &exports.ExportReq{StartDate: "2026-01-01", EndDate: "2026-01-31", Columns: []string{"order_id", "contact", "items", "total"}}
+	if err := usecase.Validate(req); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	count, err := usecase.WriteOrders(context.Background(), req, buf)
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 orders, got: %v %v", count, err)
+	}
+	expect := "order_id,contact,items,total\nO000001,\"Ann, Bangkok\",2,264.00\nO000002,,1,35.50\n"
+	if buf.String() != expect {
+		t.Errorf("expected: %q, got: %q", expect, buf.String())
+	}
+	if name := req.FileName(); name != "orders_2026-01-01_2026-01-31.csv" {
+		t.Errorf("unexpected file name: %v", name)
+	}
+}
+
+func TestExportsWriteJson(t *testing.T) {
+	usecase := exportsUsecases.ExportsUsecase(exportsRepository(), nil)
+	req := &exports.ExportReq{StartDate: "2026-01-01", EndDate: "2026-01-31", Format: "JSON"}
+	if err := usecase.Validate(req); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if _, err := usecase.WriteOrders(context.Background(), req, buf); err != nil {
+		t.Fatal(err)
+	}
+	file := struct {
+		Schema string              `json:"schema"`
+		Orders []*exports.OrderRow `json:"orders"`
+		Count  int                 `json:"count"`
+	}{}
+	if err := json.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatalf("expected valid json, got: %v\n%s", err, buf.String())
+	}
+	if file.Schema != exports.Schema || file.Count != 2 || len(file.Orders) != 2 || file.Orders[0].Lines[0].Title != "Coffee" {
+		t.Errorf("unexpected file: %+v", file)
+	}
+	if strings.Contains(buf.String(), "store") {
+		t.Errorf("expected the store not to be in the file: %s", buf.String())
+	}
+}
@@ -0,0 +1,145 @@
+package myTests
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// FactoryPassword is the password of every user made by NewUser
+const FactoryPassword = "rishop-test"
+
+// sequence keep unique keys unique between tests which share the harness
+var sequence int64
+
+func next() int64 {
+	return atomic.AddInt64(&sequence, 1)
+}
+
+// NewUser insert a user of the role, 1 customer, 2 admin or 4 seller
+func (h *Harness) NewUser(t *testing.T, roleId int) string {
+	t.Helper()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(FactoryPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password failed: %v", err)
+	}
+
+	n := next()
+	var userId string
+	if err := h.Db.QueryRow(`
+	INSERT INTO "users" (
+		"email",
+		"password",
+		"username",
+		"role_id"
+	)
+	VALUES ($1, $2, $3, $4)
+		RETURNING "id";`,
+		fmt.Sprintf("user%d@test.ri-shop.dev", n),
+		string(hashed),
+		fmt.Sprintf("user%d", n),
+		roleId,
+	).Scan(&userId); err != nil {
+		t.Fatalf("insert user failed: %v", err)
+	}
+	return userId
+}
+
+func (h *Harness) NewCategory(t *testing.T) int {
+	t.Helper()
+
+	var categoryId int
+	if err := h.Db.QueryRow(`
+	INSERT INTO "categories" ("title")
+	VALUES ($1)
+		RETURNING "id";`, fmt.Sprintf("category %d", next())).Scan(&categoryId); err != nil {
+		t.Fatalf("insert category failed: %v", err)
+	}
+	return categoryId
+}
+
+// NewProduct insert a published product of the shop with a category and one image
+func (h *Harness) NewProduct(t *testing.T, price float64, stock int) string {
+	t.Helper()
+
+	categoryId := h.NewCategory(t)
+
+	var productId string
+	if err := h.Db.QueryRow(`
+	INSERT INTO "products" (
+		"title",
+		"description",
+		"price_minor",
+		"stock",
+		"status"
+	)
+	VALUES ($1, 'made by the test factory', major_to_minor($2, 'THB'), $3, 'published')
+		RETURNING "id";`, fmt.Sprintf("product %d", next()), price, stock).Scan(&productId); err != nil {
+		t.Fatalf("insert product failed: %v", err)
+	}
+
+	if _, err := h.Db.Exec(`
+	INSERT INTO "products_categories" (
+		"product_id",
+		"category_id"
+	)
+	VALUES ($1, $2);`, productId, categoryId); err != nil {
+		t.Fatalf("insert product category failed: %v", err)
+	}
+	if _, err := h.Db.Exec(`
+	INSERT INTO "images" (
+		"filename",
+		"url",
+		"product_id",
+		"is_primary"
+	)
+	VALUES ($1, 'https://example.com/test.jpg', $2, true);`, productId+".jpg", productId); err != nil {
+		t.Fatalf("insert product image failed: %v", err)
+	}
+	return productId
+}
+
+// NewOrder insert an order of the user with a snapshot of each product like a checkout
+func (h *Harness) NewOrder(t *testing.T, userId, status string, items map[string]int) string {
+	t.Helper()
+
+	var orderId string
+	if err := h.Db.QueryRow(`
+	INSERT INTO "orders" (
+		"user_id",
+		"contact",
+		"address",
+		"status"
+	)
+	VALUES ($1, '0800000000', '1 Test Road, Bangkok 10110', $2)
+		RETURNING "id";`, userId, status).Scan(&orderId); err != nil {
+		t.Fatalf("insert order failed: %v", err)
+	}
+
+	for productId, qty := range items {
+		if _, err := h.Db.Exec(`
+		INSERT INTO "products_orders" (
+			"order_id",
+			"qty",
+			"product"
+		)
+		SELECT
+			$1,
+			$2,
+			jsonb_build_object(
+				'id', "p"."id",
+				'title', "p"."title",
+				'description', "p"."description",
+				'price', minor_to_major("p"."price_minor", "p"."currency"),
+				'currency', "p"."currency"
+			)
+		FROM "products" "p"
+		WHERE "p"."id" = $3;`, orderId, qty, productId); err != nil {
+			t.Fatalf("insert order item failed: %v", err)
+		}
+	}
+	return orderId
+}
@@ -0,0 +1,89 @@
+package myTests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/feeds"
+	"github.com/NatthawutSK/ri-shop/modules/feeds/feedsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func feedsRepository() *mocks.FeedsRepository {
+	updatedAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	return &mocks.FeedsRepository{
+		FindItemsFn: func(ctx context.Context, storeId string, limit int) ([]*feeds.Item, error) {
+			return []*feeds.Item{
+				{Id: "P000001", Title: "Coffee & Milk", Price: 150.5, Currency: "THB", MinorUnit: 2, Stock: 3, Brand: "nike", UpdatedAt: updatedAt},
+				{Id: "P000002", Title: "Tea, green", Price: 1200, Currency: "JPY", MinorUnit: 0, UpdatedAt: updatedAt},
+			}, nil
+		},
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	usecase := feedsUsecases.FeedsUsecase(cdnConfig(t, ""), feedsRepository())
+
+	sitemap, err := usecase.FindFeed(context.Background(), "", feeds.KindSitemap)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	for _, expected := range []string{
+		"<loc>https://shop.example.com/products/P000001</loc>",
+		"<lastmod>2026-05-01</lastmod>",
+	} {
+		if !strings.Contains(string(sitemap.Body), expected) {
+			t.Errorf("expected the sitemap to contain %s, got: %s", expected, sitemap.Body)
+		}
+	}
+
+	google, _ := usecase.FindFeed(context.Background(), "", feeds.KindGoogle)
+	for _, expected := range []string{
+		`xmlns:g="http://base.google.com/ns/1.0"`,
+		"<g:title>Coffee &amp; Milk</g:title>",
+		"<g:price>150.50 THB</g:price>",
+		"<g:price>1200 JPY</g:price>",
+		"<g:availability>out of stock</g:availability>",
+	} {
+		if !strings.Contains(string(google.Body), expected) {
+			t.Errorf("expected the google feed to contain %s, got: %s", expected, google.Body)
+		}
+	}
+
+	facebook, _ := usecase.FindFeed(context.Background(), "", feeds.KindFacebook)
+	lines := strings.Split(strings.TrimSpace(string(facebook.Body)), "\n")
+	if len(lines) != 3 || lines[0] != strings.Join(feeds.FacebookColumns, ",") {
+		t.Fatalf("expected a header and 2 products, got: %q", lines)
+	}
+	if !strings.HasPrefix(lines[2], `P000002,"Tea, green",`) {
+		t.Errorf("expected a quoted title, got: %s", lines[2])
+	}
+}
+
+func TestFeedsCached(t *testing.T) {
+	repo := feedsRepository()
+	usecase := feedsUsecases.FeedsUsecase(cdnConfig(t, ""), repo)
+
+	// ทั้งสามไฟล์มาจากการอ่านครั้งเดียว
+	for _, kind := range []string{feeds.KindSitemap, feeds.KindGoogle, feeds.KindFacebook, feeds.KindSitemap} {
+		if _, err := usecase.FindFeed(context.Background(), "", kind); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+	}
+	if repo.Calls("FindItems") != 1 {
+		t.Errorf("expected the products to be read once, got: %d", repo.Calls("FindItems"))
+	}
+
+	if err := usecase.RefreshFeeds(context.Background()); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if repo.Calls("FindItems") != 2 {
+		t.Errorf("expected the task to read the products again, got: %d", repo.Calls("FindItems"))
+	}
+
+	if _, err := usecase.FindFeed(context.Background(), "", "rss"); err == nil || err.Error() != "feed not found" {
+		t.Errorf("expected: feed not found, got: %v", err)
+	}
+}
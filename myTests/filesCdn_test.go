@@ -0,0 +1,83 @@
+package myTests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+)
+
+func cdnConfig(t *testing.T, cdnBaseUrl string) config.IConfig {
+	envPath := filepath.Join(t.TempDir(), "cdn.env")
+	err := writeEnv(envPath, map[string]string{
+		"APP_HOST":            "127.0.0.1",
+		"APP_PORT":            "3000",
+		"APP_BODY_LIMIT":      "10490000",
+		"APP_READ_TIMEOUT":    "60",
+		"APP_WRITE_TIMEOUT":   "60",
+		"APP_FILE_LIMIT":      "2097000",
+		"APP_GCP_BUCKET":      "ri-shop-bucket",
+		"APP_PUBLIC_URL":      "https://shop.example.com",
+		"JWT_SECRET_KEY":      "test-secret",
+		"JWT_ADMIN_KEY":       "test-admin",
+		"JWT_API_KEY":         "test-api",
+		"JWT_ACCESS_EXPIRES":  "86400",
+		"JWT_REFRESH_EXPIRES": "604800",
+		"DB_HOST":             "127.0.0.1",
+		"DB_PORT":             "5432",
+		"DB_PROTOCOL":         "tcp",
+		"DB_USERNAME":         "rishop",
+		"DB_PASSWORD":         "rishop",
+		"DB_DATABASE":         "rishop_test",
+		"DB_SSL_MODE":         "disable",
+		"DB_MAX_CONNECTIONS":  "10",
+		"CDN_BASE_URL":        cdnBaseUrl,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(envPath) })
+	return config.LoadConfig(envPath)
+}
+
+func TestFilesCdnUrl(t *testing.T) {
+	usecase := filesUsecases.FilesUsecase(cdnConfig(t, "https://cdn.example.com/"), nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		url         string
+		cdnUrl      string
+		destination string
+	}{
+		{"https://storage.googleapis.com/ri-shop-bucket/products/a.png", "https://cdn.example.com/products/a.png", "products/a.png"},
+		{"https://shop.example.com/static/images/products/b.png", "https://cdn.example.com/products/b.png", "products/b.png"},
+		{"https://cdn.example.com/stores/s1/c.png", "https://cdn.example.com/stores/s1/c.png", "stores/s1/c.png"},
+	}
+	for _, test := range tests {
+		if got := usecase.CdnUrl(test.url); got != test.cdnUrl {
+			t.Errorf("CdnUrl(%q) expect %q, got %q", test.url, test.cdnUrl, got)
+		}
+		if got := usecase.Destination(test.url); got != test.destination {
+			t.Errorf("Destination(%q) expect %q, got %q", test.url, test.destination, got)
+		}
+	}
+
+	// a url of another bucket is not ours, it is kept
+	other := "https://storage.googleapis.com/other-bucket/d.png"
+	if got := usecase.CdnUrl(other); got != other {
+		t.Errorf("CdnUrl(%q) expect the url to be kept, got %q", other, got)
+	}
+}
+
+func TestFilesCdnUrlDisabled(t *testing.T) {
+	usecase := filesUsecases.FilesUsecase(cdnConfig(t, ""), nil, nil, nil, nil, nil)
+
+	url := "https://storage.googleapis.com/ri-shop-bucket/products/a.png"
+	if got := usecase.CdnUrl(url); got != url {
+		t.Errorf("expect the storage url without a CDN, got %q", got)
+	}
+	if got := usecase.Destination(url); got != "products/a.png" {
+		t.Errorf("expect products/a.png, got %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package myTests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func TestFilesStoreOf(t *testing.T) {
+	tests := map[string]string{
+		"products/a.png":               "",
+		"stores/shop-a/products/a.png": "shop-a",
+		"stores/shop-b":                "shop-b",
+		"storesx/a.png":                "",
+	}
+	for destination, expect := range tests {
+		if got := files.StoreOf(destination); got != expect {
+			t.Errorf("StoreOf(%q) expect %q, got %q", destination, expect, got)
+		}
+	}
+}
+
+func TestFilesDuplicateSize(t *testing.T) {
+	res := []*files.FileRes{
+		{Url: "a", Size: 100},
+		{Url: "b", Size: 250, Duplicate: true},
+		{Url: "c", Size: 50, Duplicate: true},
+	}
+	if size := filesUsecases.DuplicateSize(res); size != 300 {
+		t.Errorf("expect 300 bytes of duplicates, got %d", size)
+	}
+}
+
+func TestFilesDeleteSharedFile(t *testing.T) {
+	repo := &mocks.FilesRepository{
+		CountFileReferencesFn: func(ctx context.Context, destinations []string) (map[string]int, error) {
+			// images of two products use the same upload
+			return map[string]int{"products/a.png": 2}, nil
+		},
+	}
+	usecase := filesUsecases.FilesUsecase(nil, repo, nil, nil, nil, nil)
+
+	if err := usecase.DeleteFileOnStorage(context.Background(), []*files.DeleteFileReq{{Destination: "products/a.png"}}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if calls := repo.Calls("DeleteFiles"); calls != 0 {
+		t.Errorf("expected a shared file to be kept, DeleteFiles is called %d times", calls)
+	}
+}
+
+func TestFilesDeleteFailedFile(t *testing.T) {
+	repo := &mocks.FilesRepository{
+		CountFileReferencesFn: func(ctx context.Context, destinations []string) (map[string]int, error) {
+			return map[string]int{}, nil
+		},
+	}
+	usecase := filesUsecases.FilesUsecase(cdnConfig(t, ""), repo, nil, nil, nil, nil)
+
+	// ไฟล์ไม่มีอยู่จริง ทุกไฟล์ลบไม่สำเร็จ batch ต้องจบด้วย error แรกโดยไม่ค้าง
+	req := make([]*files.DeleteFileReq, 0)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		req = append(req, &files.DeleteFileReq{Destination: "products/missing-" + name + ".png"})
+	}
+	err := usecase.DeleteFileOnStorage(context.Background(), req)
+	if err == nil || !strings.HasPrefix(err.Error(), "remove file: products/missing-") {
+		t.Fatalf("expected the error of the first failed file, got: %v", err)
+	}
+	if calls := repo.Calls("DeleteFiles"); calls != 0 {
+		t.Errorf("expected the files to be kept after a failure, DeleteFiles is called %d times", calls)
+	}
+}
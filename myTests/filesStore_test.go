@@ -0,0 +1,64 @@
+package myTests
+
+import (
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+)
+
+func TestFilesStoreDestination(t *testing.T) {
+	tests := []struct {
+		storeId     string
+		destination string
+		expect      string
+		isErr       bool
+	}{
+		{storeId: "", destination: "products/a.png", expect: "products/a.png"},
+		{storeId: "shop-a", destination: "products/a.png", expect: "stores/shop-a/products/a.png"},
+		{storeId: "shop-a", destination: "/products/../a.png", expect: "stores/shop-a/a.png"},
+		{storeId: "shop-a", destination: "../../shop-b/products/a.png", expect: "stores/shop-a/shop-b/products/a.png"},
+		{storeId: "", destination: "stores/shop-b/products/a.png", isErr: true},
+		{storeId: "", destination: "quarantine/a.png", isErr: true},
+		{storeId: "", destination: "/", isErr: true},
+	}
+
+	for _, test := range tests {
+		dest, err := files.StoreDestination(test.storeId, test.destination)
+		if test.isErr {
+			if err == nil {
+				t.Errorf("StoreDestination(%q, %q) expect an error, got %q", test.storeId, test.destination, dest)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("StoreDestination(%q, %q) failed: %v", test.storeId, test.destination, err)
+			continue
+		}
+		if dest != test.expect {
+			t.Errorf("StoreDestination(%q, %q) expect %q, got %q", test.storeId, test.destination, test.expect, dest)
+		}
+	}
+}
+
+func TestFilesOwnedBy(t *testing.T) {
+	tests := []struct {
+		storeId     string
+		destination string
+		expect      bool
+	}{
+		{storeId: "", destination: "products/a.png", expect: true},
+		{storeId: "", destination: "stores/shop-a/products/a.png", expect: false},
+		{storeId: "shop-a", destination: "stores/shop-a/products/a.png", expect: true},
+		{storeId: "shop-a", destination: "stores/shop-ab/products/a.png", expect: false},
+		{storeId: "shop-a", destination: "stores/shop-b/products/a.png", expect: false},
+		{storeId: "shop-a", destination: "stores/shop-a/../shop-b/a.png", expect: false},
+		{storeId: "shop-a", destination: "products/a.png", expect: false},
+		{storeId: "", destination: "quarantine/a.png", expect: false},
+	}
+
+	for _, test := range tests {
+		if owned := files.OwnedBy(test.storeId, test.destination); owned != test.expect {
+			t.Errorf("OwnedBy(%q, %q) expect %v, got %v", test.storeId, test.destination, test.expect, owned)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func TestFilesNegotiateFormat(t *testing.T) {
+	both := []string{"webp", "avif"}
+
+	tests := []struct {
+		accept   string
+		variants []string
+		expect   string
+	}{
+		{"image/avif,image/webp,image/apng,*/*;q=0.8", both, "avif"},
+		{"image/webp,*/*", both, "webp"},
+		{"image/avif,image/webp,*/*", []string{"webp"}, "webp"},
+		{"image/avif;q=0, image/webp", both, "webp"},
+		{"image/webp;q=0.0", both, ""},
+		{"IMAGE/WEBP; q=0.5", both, "webp"},
+		// a wildcard does not mean the browser can show a webp
+		{"*/*", both, ""},
+		{"", both, ""},
+		{"image/avif,image/webp", nil, ""},
+	}
+	for _, test := range tests {
+		if got := files.NegotiateFormat(test.accept, test.variants); got != test.expect {
+			t.Errorf("NegotiateFormat(%q, %v) expect %q, got %q", test.accept, test.variants, test.expect, got)
+		}
+	}
+}
+
+func TestFilesImageUrl(t *testing.T) {
+	repo := &mocks.FilesRepository{
+		FindImageFn: func(ctx context.Context, destination string) (*files.ImageRes, error) {
+			return &files.ImageRes{
+				Url:      "https://storage.googleapis.com/ri-shop-bucket/" + destination,
+				Variants: []string{"webp"},
+			}, nil
+		},
+	}
+	usecase := filesUsecases.FilesUsecase(cdnConfig(t, "https://cdn.example.com"), repo, nil, nil, nil, nil)
+
+	url, err := usecase.ImageUrl(context.Background(), "products/a.jpg", "image/avif,image/webp,*/*")
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if url != "https://cdn.example.com/products/a.jpg.webp" {
+		t.Errorf("expect the webp variant on the CDN, got %q", url)
+	}
+
+	url, err = usecase.ImageUrl(context.Background(), "products/a.jpg", "image/png,*/*")
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if url != "https://cdn.example.com/products/a.jpg" {
+		t.Errorf("expect the original image, got %q", url)
+	}
+}
@@ -0,0 +1,320 @@
+package myTests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/graphql"
+	"github.com/NatthawutSK/ri-shop/modules/graphql/graphqlResolvers"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+)
+
+func graphqlOrder() *orders.Order {
+	return &orders.Order{
+		Id:     "O000001",
+		UserId: "U000001",
+		Status: orders.StatusPaid,
+		Products: []*orders.ProductsOrder{
+			{Id: "1", Qty: 1, Product: &products.Products{Id: "P000001", Title: "Coffee"}},
+			{Id: "2", Qty: 2, Product: &products.Products{Id: "P000002", Title: "Tea"}},
+			{Id: "3", Qty: 1, Product: &products.Products{Id: "P000001", Title: "Coffee"}},
+		},
+	}
+}
+
+func graphqlResolver(repo *mocks.GraphqlRepository, productsRepo *mocks.ProductsRepository) graphqlResolvers.IGraphqlResolver {
+	productsUsecase := productsUsecases.ProductsUsecase(productsRepo, nil, cache.New[*products.Products](time.Minute))
+	ordersUsecase := &mocks.OrdersUsecase{
+		FindOneOrderFn: func(ctx context.Context, orderId string) (*orders.Order, error) {
+			if orderId != "O000001" {
+				return nil, fmt.Errorf("cannot get order: %w", sql.ErrNoRows)
+			}
+			return graphqlOrder(), nil
+		},
+	}
+	return graphqlResolvers.GraphqlResolver(repo, productsUsecase, nil, ordersUsecase)
+}
+
+func TestGraphqlOrderBatchesProductFields(t *testing.T) {
+	batches := make([][]string, 0)
+	repo := &mocks.GraphqlRepository{
+		FindCategoryByProductIdFn: func(ctx context.Context, productIds []string) (map[string]*appinfo.Category, error) {
+			return map[string]*appinfo.Category{"P000001": {Id: 1, Title: "Drinks"}}, nil
+		},
+		FindImageByProductIdFn: func(ctx context.Context, productIds []string) (map[string][]*entities.Image, error) {
+			ids := append([]string{}, productIds...)
+			sort.Strings(ids)
+			batches = append(batches, ids)
+			return map[string][]*entities.Image{
+				"P000002": {{Id: "I1", FileName: "tea.jpg", Url: "https://cdn/tea.jpg"}},
+			}, nil
+		},
+	}
+	resolver := graphqlResolver(repo, &mocks.ProductsRepository{})
+
+	query := `{ order(id: "O000001") { id products { qty product { id category { title } images { url } } } } }`
+	result := resolver.Execute(context.Background(), &graphql.Viewer{UserId: "U000001", RoleId: 1}, &graphql.Request{Query: query})
+	if result.HasErrors() {
+		t.Fatalf("expected: no errors, got: %v", result.Errors)
+	}
+
+	// 3 lines of 2 products cost one query per field
+	if repo.Calls("FindImageByProductId") != 1 || repo.Calls("FindCategoryByProductId") != 1 {
+		t.Errorf("expected one batch per field, got images: %d, categories: %d", repo.Calls("FindImageByProductId"), repo.Calls("FindCategoryByProductId"))
+	}
+	if len(batches) != 1 || len(batches[0]) != 2 || batches[0][0] != "P000001" || batches[0][1] != "P000002" {
+		t.Errorf("expected: [[P000001 P000002]], got: %v", batches)
+	}
+
+	data, _ := json.Marshal(result.Data)
+	expected := `{"order":{"id":"O000001","products":[` +
+		`{"product":{"category":{"title":"Drinks"},"id":"P000001","images":[]},"qty":1},` +
+		`{"product":{"category":null,"id":"P000002","images":[{"url":"https://cdn/tea.jpg"}]},"qty":2},` +
+		`{"product":{"category":{"title":"Drinks"},"id":"P000001","images":[]},"qty":1}]}}`
+	if string(data) != expected {
+		t.Errorf("expected: %s, got: %s", expected, data)
+	}
+}
+
+func TestGraphqlProductsArePrimed(t *testing.T) {
+	repo := &mocks.GraphqlRepository{}
+	productsRepo := &mocks.ProductsRepository{
+		FindProductFn: func(ctx context.Context, req *products.ProductFilter) ([]*products.Products, int) {
+			if req.StoreId != "shop-a" || req.Limit != 5 || req.Search != "tea" {
+				t.Errorf("expected the filter of the query and the store, got: %+v", req)
+			}
+			return []*products.Products{
+				{Id: "P000002", Title: "Tea", Category: &appinfo.Category{Id: 1, Title: "Drinks"}, Images: []*entities.Image{}},
+			}, 1
+		},
+		FindFacetFn: func(ctx context.Context, req *products.ProductFilter) []*products.Facet { return nil },
+	}
+	resolver := graphqlResolver(repo, productsRepo)
+
+	query := `query($search: String) { products(filter: {search: $search, limit: 5}) { total_item data { title category { title } images { url } } } }`
+	result := resolver.Execute(context.Background(), &graphql.Viewer{StoreId: "shop-a"}, &graphql.Request{
+		Query:     query,
+		Variables: map[string]any{"search": "tea"},
+	})
+	if result.HasErrors() {
+		t.Fatalf("expected: no errors, got: %v", result.Errors)
+	}
+
+	// the list already has the category and the images
+	if repo.Calls("FindImageByProductId") != 0 || repo.Calls("FindCategoryByProductId") != 0 {
+		t.Errorf("expected the loaders not to query a listed product")
+	}
+	data, _ := json.Marshal(result.Data)
+	expected := `{"products":{"data":[{"category":{"title":"Drinks"},"images":[],"title":"Tea"}],"total_item":1}}`
+	if string(data) != expected {
+		t.Errorf("expected: %s, got: %s", expected, data)
+	}
+}
+
+func TestGraphqlOrderAccess(t *testing.T) {
+	resolver := graphqlResolver(&mocks.GraphqlRepository{}, &mocks.ProductsRepository{})
+	query := `{ order(id: "O000001") { id } }`
+
+	tests := []struct {
+		name    string
+		viewer  *graphql.Viewer
+		found   bool
+		wantErr bool
+	}{
+		{name: "owner", viewer: &graphql.Viewer{UserId: "U000001", RoleId: 1}, found: true},
+		{name: "admin", viewer: &graphql.Viewer{UserId: "U000009", RoleId: 2}, found: true},
+		{name: "another customer", viewer: &graphql.Viewer{UserId: "U000002", RoleId: 1}},
+		{name: "another store", viewer: &graphql.Viewer{StoreId: "shop-a", UserId: "U000001", RoleId: 1}},
+		{name: "signed out", viewer: &graphql.Viewer{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := resolver.Execute(context.Background(), tt.viewer, &graphql.Request{Query: query})
+			if result.HasErrors() != tt.wantErr {
+				t.Fatalf("expected errors: %v, got: %v", tt.wantErr, result.Errors)
+			}
+			order, _ := result.Data.(map[string]any)["order"].(map[string]any)
+			if (order != nil) != tt.found {
+				t.Errorf("expected found: %v, got: %v", tt.found, order)
+			}
+		})
+	}
+
+	// an order which does not exist is null
+	result := resolver.Execute(context.Background(), &graphql.Viewer{UserId: "U000001"}, &graphql.Request{Query: `{ order(id: "O000404") { id } }`})
+	if result.HasErrors() || result.Data.(map[string]any)["order"] != nil {
+		t.Errorf("expected: null, got: %v %v", result.Data, result.Errors)
+	}
+}
+
+// schemaType is the part of an introspected __Type which is printed in schema.graphqls
+type schemaType struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *schemaType `json:"ofType"`
+}
+
+func (t *schemaType) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+type schemaValue struct {
+	Name         string      `json:"name"`
+	DefaultValue *string     `json:"defaultValue"`
+	Type         *schemaType `json:"type"`
+}
+
+func (v *schemaValue) String() string {
+	if v.DefaultValue != nil {
+		return fmt.Sprintf("%s: %s = %s", v.Name, v.Type, *v.DefaultValue)
+	}
+	return fmt.Sprintf("%s: %s", v.Name, v.Type)
+}
+
+// printSchema print the objects and inputs of the executable schema, the fields and the args are sorted
+// because graphql-go keep them in maps
+func printSchema(t *testing.T, resolver graphqlResolvers.IGraphqlResolver) string {
+	t.Helper()
+
+	query := `{ __schema { types {
+		kind name
+		fields { name type { ...T } args { name defaultValue type { ...T } } }
+		inputFields { name defaultValue type { ...T } }
+	} } }
+	fragment T on __Type { kind name ofType { kind name ofType { kind name ofType { kind name } } } }`
+	result := resolver.Execute(context.Background(), &graphql.Viewer{}, &graphql.Request{Query: query})
+	if result.HasErrors() {
+		t.Fatalf("expected: no errors, got: %v", result.Errors)
+	}
+
+	data, _ := json.Marshal(result.Data)
+	introspection := new(struct {
+		Schema struct {
+			Types []struct {
+				Kind   string `json:"kind"`
+				Name   string `json:"name"`
+				Fields []struct {
+					Name string         `json:"name"`
+					Type *schemaType    `json:"type"`
+					Args []*schemaValue `json:"args"`
+				} `json:"fields"`
+				InputFields []*schemaValue `json:"inputFields"`
+			} `json:"types"`
+		} `json:"__schema"`
+	})
+	if err := json.Unmarshal(data, introspection); err != nil {
+		t.Fatalf("unmarshal introspection failed: %v", err)
+	}
+
+	blocks := make([]string, 0)
+	for _, typ := range introspection.Schema.Types {
+		if strings.HasPrefix(typ.Name, "__") {
+			continue
+		}
+		lines := make([]string, 0)
+		switch typ.Kind {
+		case "OBJECT":
+			for _, f := range typ.Fields {
+				args := make([]string, 0, len(f.Args))
+				for _, a := range f.Args {
+					args = append(args, a.String())
+				}
+				lines = append(lines, schemaField(f.Name, args, f.Type.String()))
+			}
+			blocks = append(blocks, schemaBlock("type "+typ.Name, lines))
+		case "INPUT_OBJECT":
+			for _, f := range typ.InputFields {
+				lines = append(lines, f.String())
+			}
+			blocks = append(blocks, schemaBlock("input "+typ.Name, lines))
+		}
+	}
+	sort.Strings(blocks)
+	return strings.Join(blocks, "\n")
+}
+
+// readSchema print schema.graphqls like printSchema, without the comments
+func readSchema(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read schema failed: %v", err)
+	}
+
+	fieldRe := regexp.MustCompile(`^(\w+)(?:\((.*)\))?: (.+)$`)
+	blocks := make([]string, 0)
+	var header string
+	var lines []string
+	for _, line := range strings.Split(string(file), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.Join(strings.Fields(line), " ")
+		switch {
+		case line == "":
+		case strings.HasSuffix(line, "{"):
+			header, lines = strings.TrimSuffix(line, " {"), make([]string, 0)
+		case line == "}":
+			blocks = append(blocks, schemaBlock(header, lines))
+		case strings.HasPrefix(header, "input "):
+			lines = append(lines, line)
+		default:
+			m := fieldRe.FindStringSubmatch(line)
+			if m == nil {
+				t.Fatalf("field of %s cannot be read: %s", header, line)
+			}
+			args := make([]string, 0)
+			if m[2] != "" {
+				args = strings.Split(m[2], ", ")
+			}
+			lines = append(lines, schemaField(m[1], args, m[3]))
+		}
+	}
+	sort.Strings(blocks)
+	return strings.Join(blocks, "\n")
+}
+
+func schemaField(name string, args []string, typ string) string {
+	if len(args) == 0 {
+		return fmt.Sprintf("%s: %s", name, typ)
+	}
+	sort.Strings(args)
+	return fmt.Sprintf("%s(%s): %s", name, strings.Join(args, ", "), typ)
+}
+
+func schemaBlock(header string, lines []string) string {
+	sort.Strings(lines)
+	return header + " {\n  " + strings.Join(lines, "\n  ") + "\n}\n"
+}
+
+// schema.graphqls is the documented schema, it must describe what schema.go build
+func TestGraphqlSchemaFile(t *testing.T) {
+	resolver := graphqlResolver(&mocks.GraphqlRepository{}, &mocks.ProductsRepository{})
+
+	expected := readSchema(t, "../modules/graphql/schema.graphqls")
+	if got := printSchema(t, resolver); got != expected {
+		t.Errorf("schema.graphqls is out of date\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
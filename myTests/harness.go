@@ -0,0 +1,192 @@
+package myTests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/config"
+	"github.com/NatthawutSK/ri-shop/modules/servers"
+	"github.com/NatthawutSK/ri-shop/pkg/databases"
+	"github.com/jmoiron/sqlx"
+)
+
+// harnessImage has the vector extension of the image search migration
+const harnessImage = "pgvector/pgvector:pg16"
+
+const migrationsDir = "../pkg/databases/migrations"
+
+// Harness is a disposable Postgres in docker with every migration applied, tests share one harness
+// and create their own rows with the factories so they do not depend on each other
+type Harness struct {
+	Db        *sqlx.DB
+	Cfg       config.IConfig
+	container string
+}
+
+var (
+	harness     *Harness
+	harnessErr  error
+	harnessOnce sync.Once
+)
+
+// SetupHarness start the harness on the first call, the test is skipped when docker is not available
+func SetupHarness(t *testing.T) *Harness {
+	t.Helper()
+
+	harnessOnce.Do(func() {
+		harness, harnessErr = newHarness()
+	})
+	if harnessErr != nil {
+		t.Skipf("database harness is not available: %v", harnessErr)
+	}
+	return harness
+}
+
+// Modules build the modules on the harness database like SetupTest does on .env.test
+func (h *Harness) Modules() servers.IModuleFactory {
+	s := servers.NewSever(h.Cfg, h.Db)
+	return servers.InitModule(nil, s.GetServer(), nil)
+}
+
+func newHarness() (*Harness, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, err
+	}
+
+	out, err := exec.Command(
+		"docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_USER=rishop",
+		"-e", "POSTGRES_PASSWORD=rishop",
+		"-e", "POSTGRES_DB=rishop_test",
+		"-p", "127.0.0.1::5432",
+		harnessImage,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("start postgres failed: %v", err)
+	}
+	h := &Harness{container: strings.TrimSpace(string(out))}
+
+	out, err = exec.Command("docker", "port", h.container, "5432/tcp").Output()
+	if err != nil {
+		h.Close()
+		return nil, fmt.Errorf("find postgres port failed: %v", err)
+	}
+	_, port, _ := strings.Cut(strings.TrimSpace(strings.Split(string(out), "\n")[0]), ":")
+
+	// written to a .env file of its own instead of the environment, so SetupTest still read .env.test
+	settings := map[string]string{
+		"APP_HOST":            "127.0.0.1",
+		"APP_PORT":            "3000",
+		"APP_NAME":            "ri-shop-test",
+		"APP_VERSION":         "test",
+		"APP_ENV":             "test",
+		"APP_BODY_LIMIT":      "10490000",
+		"APP_READ_TIMEOUT":    "60",
+		"APP_WRITE_TIMEOUT":   "60",
+		"APP_FILE_LIMIT":      "2097000",
+		"JWT_SECRET_KEY":      "test-secret",
+		"JWT_ADMIN_KEY":       "test-admin",
+		"JWT_API_KEY":         "test-api",
+		"JWT_ACCESS_EXPIRES":  "86400",
+		"JWT_REFRESH_EXPIRES": "604800",
+		"DB_HOST":             "127.0.0.1",
+		"DB_PORT":             port,
+		"DB_PROTOCOL":         "tcp",
+		"DB_USERNAME":         "rishop",
+		"DB_PASSWORD":         "rishop",
+		"DB_DATABASE":         "rishop_test",
+		"DB_SSL_MODE":         "disable",
+		"DB_MAX_CONNECTIONS":  "10",
+	}
+	envPath := filepath.Join(os.TempDir(), "ri-shop-harness-"+h.container[:12]+".env")
+	if err := writeEnv(envPath, settings); err != nil {
+		h.Close()
+		return nil, err
+	}
+	defer os.Remove(envPath)
+	h.Cfg = config.LoadConfig(envPath)
+
+	if err := waitForPostgres(h.Cfg.Db().Url(), 60*time.Second); err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.Db = databases.DbConnect(h.Cfg.Db())
+
+	if err := ApplyMigrations(h.Db, migrationsDir); err != nil {
+		h.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+func writeEnv(path string, settings map[string]string) error {
+	lines := make([]string, 0, len(settings))
+	for key, value := range settings {
+		lines = append(lines, key+"="+value)
+	}
+	sort.Strings(lines)
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
+// postgres of the image restart once after init, so a single successful ping is not enough
+func waitForPostgres(url string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	ready := 0
+	for time.Now().Before(deadline) {
+		db, err := sqlx.Open("pgx", url)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			err = db.PingContext(ctx)
+			cancel()
+			db.Close()
+		}
+		if err == nil {
+			ready++
+			if ready == 3 {
+				return nil
+			}
+		} else {
+			ready = 0
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("postgres is not ready after %v", timeout)
+}
+
+// ApplyMigrations run every up migration in order, each file has its own transaction
+func ApplyMigrations(db *sqlx.DB, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		query, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(string(query)); err != nil {
+			return fmt.Errorf("apply migration %s failed: %v", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// Close remove the container, it is also removed by docker when the test binary is killed (--rm)
+func (h *Harness) Close() {
+	if h.Db != nil {
+		h.Db.Close()
+	}
+	if h.container != "" {
+		exec.Command("docker", "stop", h.container).Run()
+	}
+}
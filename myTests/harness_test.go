@@ -0,0 +1,19 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHarnessFindOneProduct(t *testing.T) {
+	h := SetupHarness(t)
+
+	productId := h.NewProduct(t, 150, 10)
+	product, err := h.Modules().ProductsModule().Usecase().FindOneProduct(context.Background(), productId)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if product.Id != productId || product.Price != 150 {
+		t.Errorf("expected: %v, got: %v", productId, CompressToJSON(product))
+	}
+}
@@ -0,0 +1,85 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func TestInventoryAddMovement(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *inventory.MovementReq
+		expected string
+	}{
+		{"no product", &inventory.MovementReq{Kind: inventory.MovementRestock, Qty: 5, Reason: "delivery"}, "product id is required"},
+		{"sale", &inventory.MovementReq{ProductId: "P000001", Kind: inventory.MovementSale, Qty: -1, Reason: "sold"}, "kind must be restock or adjustment"},
+		{"zero", &inventory.MovementReq{ProductId: "P000001", Kind: inventory.MovementAdjustment, Reason: "count"}, "qty must not be 0"},
+		{"negative restock", &inventory.MovementReq{ProductId: "P000001", Kind: inventory.MovementRestock, Qty: -2, Reason: "delivery"}, "qty of a restock must be more than 0"},
+		{"no reason", &inventory.MovementReq{ProductId: "P000001", Kind: inventory.MovementAdjustment, Qty: -2, Reason: "  "}, "reason is required"},
+		{"damaged", &inventory.MovementReq{ProductId: "P000001", Kind: inventory.MovementAdjustment, Qty: -2, Reason: "damaged"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.InventoryRepository{
+				InsertMovementFn: func(ctx context.Context, req *inventory.MovementReq) (*inventory.Movement, error) {
+					return &inventory.Movement{Id: 1, ProductId: req.ProductId, Kind: req.Kind, Qty: req.Qty, Reason: req.Reason}, nil
+				},
+			}
+			usecase := inventoryUsecases.InventoryUsecase(nil, repo, nil)
+
+			movement, err := usecase.AddMovement(context.Background(), tt.req)
+			if tt.expected == "" {
+				if err != nil || movement.Qty != tt.req.Qty {
+					t.Errorf("expected the movement to be added, got: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected: %s, got: %v", tt.expected, err)
+			}
+			if repo.Calls("InsertMovement") != 0 {
+				t.Error("expected no movement to be added")
+			}
+		})
+	}
+}
+
+func TestInventoryFindMovement(t *testing.T) {
+	repo := &mocks.InventoryRepository{
+		FindMovementFn: func(ctx context.Context, req *inventory.MovementFilter) ([]*inventory.Movement, int, error) {
+			return []*inventory.Movement{{Id: 2, Kind: inventory.MovementSale, Qty: -1, OrderId: "O000001"}}, 21, nil
+		},
+	}
+	usecase := inventoryUsecases.InventoryUsecase(nil, repo, nil)
+
+	page, err := usecase.FindMovement(context.Background(), &inventory.MovementFilter{
+		Kind:          inventory.MovementSale,
+		StartDate:     "2026-01-01",
+		PaginationReq: &entities.PaginationReq{Page: 1, Limit: 10},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if page.Pagination.Total != 21 || page.Pagination.TotalPages != 3 {
+		t.Errorf("expected 21 movements on 3 pages, got: %d on %d", page.Pagination.Total, page.Pagination.TotalPages)
+	}
+
+	for _, req := range []*inventory.MovementFilter{
+		{Kind: "stolen"},
+		{StartDate: "01/01/2026"},
+		{StartDate: "2026-02-01", EndDate: "2026-01-01"},
+	} {
+		req.PaginationReq = &entities.PaginationReq{Page: 1, Limit: 10}
+		if _, err := usecase.FindMovement(context.Background(), req); err == nil {
+			t.Errorf("expected an error of %+v", req)
+		}
+	}
+	if calls := repo.Calls("FindMovement"); calls != 1 {
+		t.Errorf("expected an invalid filter not to be queried, got: %d queries", calls)
+	}
+}
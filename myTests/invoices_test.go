@@ -0,0 +1,56 @@
+package myTests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/invoices/invoicesUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+)
+
+func TestInvoiceText(t *testing.T) {
+	order := &orders.Order{
+		Id:      "O000001",
+		Contact: "0800000000",
+		Address: "1 Seed Road, Bangkok 10110",
+		Products: []*orders.ProductsOrder{
+			{Qty: 2, Product: &products.Products{Id: "P000001", Title: "Coffee (250g)", Price: 150, Currency: "THB"}},
+			{Qty: 1, Product: &products.Products{Id: "P000002", Title: "A very long product title which does not fit", Price: 99.5, Currency: "THB"}},
+		},
+		ShippingFee: 50,
+		Tax: &taxes.Breakdown{
+			Lines: []*taxes.LineTax{
+				{ProductId: "P000001", Name: "VAT", Taxable: 300, Rate: 0.07, Tax: 21},
+				{ProductId: "P000002", Name: "VAT", Taxable: 99.5, Rate: 0.07, Tax: 6.97},
+			},
+			ShippingRate: 0.07,
+			ShippingTax:  3.5,
+			Total:        31.47,
+		},
+		TotalPaid: 480.97,
+	}
+
+	text, err := invoicesUsecases.InvoiceText("ri-shop", "INV000001", order, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("invoice text: %v", err)
+	}
+
+	expects := []string{
+		"INVOICE INV000001",
+		"Date      2024-01-02",
+		"Coffee (250g)                              2       150.00     7%       300.00",
+		"A very long product title which does~      1        99.50     7%        99.50",
+		"        Subtotal       399.50",
+		"             VAT        27.97",
+		"    Shipping tax         3.50",
+		"     Total (THB)       480.97",
+	}
+	for _, expect := range expects {
+		if !strings.Contains(text, expect) {
+			t.Errorf("expected line %q in:\n%s", expect, text)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package myTests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/users/usersUsecases"
+)
+
+func TestLoginLockDuration(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 4, want: 0},
+		{failures: 5, want: time.Minute},
+		{failures: 6, want: 2 * time.Minute},
+		{failures: 8, want: 8 * time.Minute},
+		{failures: 20, want: time.Hour},
+	}
+	for _, tt := range tests {
+		if got := usersUsecases.LockDuration(tt.failures, 5, time.Minute, time.Hour); got != tt.want {
+			t.Errorf("LockDuration(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+
+	// 0 ปิดการล็อก
+	if got := usersUsecases.LockDuration(100, 0, time.Minute, time.Hour); got != 0 {
+		t.Errorf("LockDuration with lockAfter 0 = %s, want 0", got)
+	}
+}
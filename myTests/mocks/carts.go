@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/carts"
+	"github.com/NatthawutSK/ri-shop/modules/carts/cartsUsecases"
+)
+
+var _ cartsUsecases.ICartsUsecase = (*CartsUsecase)(nil)
+
+type CartsUsecase struct {
+	calls
+	QuoteFn func(ctx context.Context, req *carts.QuoteReq) (*carts.Quote, error)
+}
+
+func (m *CartsUsecase) Quote(ctx context.Context, req *carts.QuoteReq) (*carts.Quote, error) {
+	m.record("Quote")
+	if m.QuoteFn == nil {
+		panic(notMocked("Quote"))
+	}
+	return m.QuoteFn(ctx, req)
+}
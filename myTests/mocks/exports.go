@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/exports"
+	"github.com/NatthawutSK/ri-shop/modules/exports/exportsRepositories"
+)
+
+var _ exportsRepositories.IExportsRepository = (*ExportsRepository)(nil)
+
+type ExportsRepository struct {
+	calls
+	FindOrderRowsFn      func(ctx context.Context, req *exports.ExportReq, fn func(row *exports.OrderRow) error) (int, error)
+	InsertExportFn       func(ctx context.Context, req *exports.ExportReq) (*exports.Export, error)
+	UpdateExportFn       func(ctx context.Context, storeId string, req *exports.Export) error
+	FindExportFn         func(ctx context.Context, storeId, exportId string) (*exports.Export, error)
+	FailPendingExportsFn func(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+func (m *ExportsRepository) FindOrderRows(ctx context.Context, req *exports.ExportReq, fn func(row *exports.OrderRow) error) (int, error) {
+	m.record("FindOrderRows")
+	if m.FindOrderRowsFn == nil {
+		panic(notMocked("FindOrderRows"))
+	}
+	return m.FindOrderRowsFn(ctx, req, fn)
+}
+
+func (m *ExportsRepository) InsertExport(ctx context.Context, req *exports.ExportReq) (*exports.Export, error) {
+	m.record("InsertExport")
+	if m.InsertExportFn == nil {
+		panic(notMocked("InsertExport"))
+	}
+	return m.InsertExportFn(ctx, req)
+}
+
+func (m *ExportsRepository) UpdateExport(ctx context.Context, storeId string, req *exports.Export) error {
+	m.record("UpdateExport")
+	if m.UpdateExportFn == nil {
+		panic(notMocked("UpdateExport"))
+	}
+	return m.UpdateExportFn(ctx, storeId, req)
+}
+
+func (m *ExportsRepository) FindExport(ctx context.Context, storeId, exportId string) (*exports.Export, error) {
+	m.record("FindExport")
+	if m.FindExportFn == nil {
+		panic(notMocked("FindExport"))
+	}
+	return m.FindExportFn(ctx, storeId, exportId)
+}
+
+func (m *ExportsRepository) FailPendingExports(ctx context.Context, olderThan time.Duration) (int64, error) {
+	m.record("FailPendingExports")
+	if m.FailPendingExportsFn == nil {
+		panic(notMocked("FailPendingExports"))
+	}
+	return m.FailPendingExportsFn(ctx, olderThan)
+}
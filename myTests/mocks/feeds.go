@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/feeds"
+	"github.com/NatthawutSK/ri-shop/modules/feeds/feedsRepositories"
+)
+
+var _ feedsRepositories.IFeedsRepository = (*FeedsRepository)(nil)
+
+type FeedsRepository struct {
+	calls
+	FindItemsFn      func(ctx context.Context, storeId string, limit int) ([]*feeds.Item, error)
+	FindStoreTitleFn func(ctx context.Context, storeId string) (string, error)
+}
+
+func (m *FeedsRepository) FindItems(ctx context.Context, storeId string, limit int) ([]*feeds.Item, error) {
+	m.record("FindItems")
+	if m.FindItemsFn == nil {
+		panic(notMocked("FindItems"))
+	}
+	return m.FindItemsFn(ctx, storeId, limit)
+}
+
+func (m *FeedsRepository) FindStoreTitle(ctx context.Context, storeId string) (string, error) {
+	m.record("FindStoreTitle")
+	if m.FindStoreTitleFn == nil {
+		panic(notMocked("FindStoreTitle"))
+	}
+	return m.FindStoreTitleFn(ctx, storeId)
+}
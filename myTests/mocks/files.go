@@ -0,0 +1,256 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/files/filesUsecases"
+)
+
+var _ filesUsecases.IFilesUsecase = (*FilesUsecase)(nil)
+
+type FilesUsecase struct {
+	calls
+	UploadToGCPFn         func(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error)
+	DeleteFileOnGCPFn     func(ctx context.Context, req []*files.DeleteFileReq) error
+	UploadToStorageFn     func(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error)
+	DeleteFileOnStorageFn func(ctx context.Context, req []*files.DeleteFileReq) error
+	ProcessFileDeletionFn func(ctx context.Context, ids []int) error
+	RetryFileDeletionFn   func(ctx context.Context) (int, error)
+	SignUploadFn          func(ctx context.Context, req *files.SignedUploadReq) (*files.SignedUploadRes, error)
+	ConfirmUploadFn       func(ctx context.Context, req *files.ConfirmUploadReq) (*files.FileRes, error)
+	UploadPrivateFn       func(ctx context.Context, req *files.PrivateFileReq) (string, error)
+	SignDownloadFn        func(ctx context.Context, destination string, ttl time.Duration) (string, error)
+	ReserveUploadFn       func(ctx context.Context, userId string, bytes int64) error
+	ReleaseUploadFn       func(ctx context.Context, userId string, bytes int64)
+	CdnUrlFn              func(fileUrl string) string
+	DestinationFn         func(fileUrl string) string
+	ImageUrlFn            func(ctx context.Context, destination, accept string) (string, error)
+}
+
+func (m *FilesUsecase) UploadToGCP(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error) {
+	m.record("UploadToGCP")
+	if m.UploadToGCPFn == nil {
+		panic(notMocked("UploadToGCP"))
+	}
+	return m.UploadToGCPFn(ctx, req)
+}
+
+func (m *FilesUsecase) DeleteFileOnGCP(ctx context.Context, req []*files.DeleteFileReq) error {
+	m.record("DeleteFileOnGCP")
+	if m.DeleteFileOnGCPFn == nil {
+		panic(notMocked("DeleteFileOnGCP"))
+	}
+	return m.DeleteFileOnGCPFn(ctx, req)
+}
+
+func (m *FilesUsecase) UploadToStorage(ctx context.Context, req []*files.FileReq) ([]*files.FileRes, error) {
+	m.record("UploadToStorage")
+	if m.UploadToStorageFn == nil {
+		panic(notMocked("UploadToStorage"))
+	}
+	return m.UploadToStorageFn(ctx, req)
+}
+
+func (m *FilesUsecase) DeleteFileOnStorage(ctx context.Context, req []*files.DeleteFileReq) error {
+	m.record("DeleteFileOnStorage")
+	if m.DeleteFileOnStorageFn == nil {
+		panic(notMocked("DeleteFileOnStorage"))
+	}
+	return m.DeleteFileOnStorageFn(ctx, req)
+}
+
+func (m *FilesUsecase) ProcessFileDeletion(ctx context.Context, ids []int) error {
+	m.record("ProcessFileDeletion")
+	if m.ProcessFileDeletionFn == nil {
+		panic(notMocked("ProcessFileDeletion"))
+	}
+	return m.ProcessFileDeletionFn(ctx, ids)
+}
+
+func (m *FilesUsecase) RetryFileDeletion(ctx context.Context) (int, error) {
+	m.record("RetryFileDeletion")
+	if m.RetryFileDeletionFn == nil {
+		panic(notMocked("RetryFileDeletion"))
+	}
+	return m.RetryFileDeletionFn(ctx)
+}
+
+func (m *FilesUsecase) SignUpload(ctx context.Context, req *files.SignedUploadReq) (*files.SignedUploadRes, error) {
+	m.record("SignUpload")
+	if m.SignUploadFn == nil {
+		panic(notMocked("SignUpload"))
+	}
+	return m.SignUploadFn(ctx, req)
+}
+
+func (m *FilesUsecase) ConfirmUpload(ctx context.Context, req *files.ConfirmUploadReq) (*files.FileRes, error) {
+	m.record("ConfirmUpload")
+	if m.ConfirmUploadFn == nil {
+		panic(notMocked("ConfirmUpload"))
+	}
+	return m.ConfirmUploadFn(ctx, req)
+}
+
+func (m *FilesUsecase) UploadPrivate(ctx context.Context, req *files.PrivateFileReq) (string, error) {
+	m.record("UploadPrivate")
+	if m.UploadPrivateFn == nil {
+		panic(notMocked("UploadPrivate"))
+	}
+	return m.UploadPrivateFn(ctx, req)
+}
+
+func (m *FilesUsecase) SignDownload(ctx context.Context, destination string, ttl time.Duration) (string, error) {
+	m.record("SignDownload")
+	if m.SignDownloadFn == nil {
+		panic(notMocked("SignDownload"))
+	}
+	return m.SignDownloadFn(ctx, destination, ttl)
+}
+
+func (m *FilesUsecase) ReserveUpload(ctx context.Context, userId string, bytes int64) error {
+	m.record("ReserveUpload")
+	if m.ReserveUploadFn == nil {
+		panic(notMocked("ReserveUpload"))
+	}
+	return m.ReserveUploadFn(ctx, userId, bytes)
+}
+
+func (m *FilesUsecase) ReleaseUpload(ctx context.Context, userId string, bytes int64) {
+	m.record("ReleaseUpload")
+	if m.ReleaseUploadFn == nil {
+		panic(notMocked("ReleaseUpload"))
+	}
+	m.ReleaseUploadFn(ctx, userId, bytes)
+}
+
+func (m *FilesUsecase) CdnUrl(fileUrl string) string {
+	m.record("CdnUrl")
+	if m.CdnUrlFn == nil {
+		panic(notMocked("CdnUrl"))
+	}
+	return m.CdnUrlFn(fileUrl)
+}
+
+func (m *FilesUsecase) Destination(fileUrl string) string {
+	m.record("Destination")
+	if m.DestinationFn == nil {
+		panic(notMocked("Destination"))
+	}
+	return m.DestinationFn(fileUrl)
+}
+
+func (m *FilesUsecase) ImageUrl(ctx context.Context, destination, accept string) (string, error) {
+	m.record("ImageUrl")
+	if m.ImageUrlFn == nil {
+		panic(notMocked("ImageUrl"))
+	}
+	return m.ImageUrlFn(ctx, destination, accept)
+}
+
+var _ filesRepositories.IFilesRepository = (*FilesRepository)(nil)
+
+type FilesRepository struct {
+	calls
+	InsertFilesFn         func(ctx context.Context, req []*files.FileRes) error
+	FindFileByHashFn      func(ctx context.Context, storeId, sha256 string) (*files.FileRes, error)
+	CountFileReferencesFn func(ctx context.Context, destinations []string) (map[string]int, error)
+	DeleteFilesFn         func(ctx context.Context, req []*files.DeleteFileReq) error
+	InsertFileVariantFn   func(ctx context.Context, destination, format string, bytes int) error
+	FindImageFn           func(ctx context.Context, destination string) (*files.ImageRes, error)
+	FindFileDeletionFn    func(ctx context.Context, ids []int) ([]*files.FileDeletion, error)
+	UpdateFileDeletionFn  func(ctx context.Context, id int, deleteErr error) error
+	RetryFileDeletionFn   func(ctx context.Context) ([]int, error)
+	AddUploadUsageFn      func(ctx context.Context, userId string, bytes, quota int64) (bool, error)
+	ReleaseUploadUsageFn  func(ctx context.Context, userId string, bytes int64) error
+}
+
+func (m *FilesRepository) InsertFiles(ctx context.Context, req []*files.FileRes) error {
+	m.record("InsertFiles")
+	if m.InsertFilesFn == nil {
+		panic(notMocked("InsertFiles"))
+	}
+	return m.InsertFilesFn(ctx, req)
+}
+
+func (m *FilesRepository) FindFileByHash(ctx context.Context, storeId, sha256 string) (*files.FileRes, error) {
+	m.record("FindFileByHash")
+	if m.FindFileByHashFn == nil {
+		panic(notMocked("FindFileByHash"))
+	}
+	return m.FindFileByHashFn(ctx, storeId, sha256)
+}
+
+func (m *FilesRepository) CountFileReferences(ctx context.Context, destinations []string) (map[string]int, error) {
+	m.record("CountFileReferences")
+	if m.CountFileReferencesFn == nil {
+		panic(notMocked("CountFileReferences"))
+	}
+	return m.CountFileReferencesFn(ctx, destinations)
+}
+
+func (m *FilesRepository) DeleteFiles(ctx context.Context, req []*files.DeleteFileReq) error {
+	m.record("DeleteFiles")
+	if m.DeleteFilesFn == nil {
+		panic(notMocked("DeleteFiles"))
+	}
+	return m.DeleteFilesFn(ctx, req)
+}
+
+func (m *FilesRepository) InsertFileVariant(ctx context.Context, destination, format string, bytes int) error {
+	m.record("InsertFileVariant")
+	if m.InsertFileVariantFn == nil {
+		panic(notMocked("InsertFileVariant"))
+	}
+	return m.InsertFileVariantFn(ctx, destination, format, bytes)
+}
+
+func (m *FilesRepository) FindImage(ctx context.Context, destination string) (*files.ImageRes, error) {
+	m.record("FindImage")
+	if m.FindImageFn == nil {
+		panic(notMocked("FindImage"))
+	}
+	return m.FindImageFn(ctx, destination)
+}
+
+func (m *FilesRepository) FindFileDeletion(ctx context.Context, ids []int) ([]*files.FileDeletion, error) {
+	m.record("FindFileDeletion")
+	if m.FindFileDeletionFn == nil {
+		panic(notMocked("FindFileDeletion"))
+	}
+	return m.FindFileDeletionFn(ctx, ids)
+}
+
+func (m *FilesRepository) UpdateFileDeletion(ctx context.Context, id int, deleteErr error) error {
+	m.record("UpdateFileDeletion")
+	if m.UpdateFileDeletionFn == nil {
+		panic(notMocked("UpdateFileDeletion"))
+	}
+	return m.UpdateFileDeletionFn(ctx, id, deleteErr)
+}
+
+func (m *FilesRepository) RetryFileDeletion(ctx context.Context) ([]int, error) {
+	m.record("RetryFileDeletion")
+	if m.RetryFileDeletionFn == nil {
+		panic(notMocked("RetryFileDeletion"))
+	}
+	return m.RetryFileDeletionFn(ctx)
+}
+
+func (m *FilesRepository) AddUploadUsage(ctx context.Context, userId string, bytes, quota int64) (bool, error) {
+	m.record("AddUploadUsage")
+	if m.AddUploadUsageFn == nil {
+		panic(notMocked("AddUploadUsage"))
+	}
+	return m.AddUploadUsageFn(ctx, userId, bytes, quota)
+}
+
+func (m *FilesRepository) ReleaseUploadUsage(ctx context.Context, userId string, bytes int64) error {
+	m.record("ReleaseUploadUsage")
+	if m.ReleaseUploadUsageFn == nil {
+		panic(notMocked("ReleaseUploadUsage"))
+	}
+	return m.ReleaseUploadUsageFn(ctx, userId, bytes)
+}
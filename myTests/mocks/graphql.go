@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/graphql/graphqlRepositories"
+)
+
+var _ graphqlRepositories.IGraphqlRepository = (*GraphqlRepository)(nil)
+
+type GraphqlRepository struct {
+	calls
+	FindCategoryByProductIdFn func(ctx context.Context, productIds []string) (map[string]*appinfo.Category, error)
+	FindImageByProductIdFn    func(ctx context.Context, productIds []string) (map[string][]*entities.Image, error)
+}
+
+func (m *GraphqlRepository) FindCategoryByProductId(ctx context.Context, productIds []string) (map[string]*appinfo.Category, error) {
+	m.record("FindCategoryByProductId")
+	if m.FindCategoryByProductIdFn == nil {
+		panic(notMocked("FindCategoryByProductId"))
+	}
+	return m.FindCategoryByProductIdFn(ctx, productIds)
+}
+
+func (m *GraphqlRepository) FindImageByProductId(ctx context.Context, productIds []string) (map[string][]*entities.Image, error) {
+	m.record("FindImageByProductId")
+	if m.FindImageByProductIdFn == nil {
+		panic(notMocked("FindImageByProductId"))
+	}
+	return m.FindImageByProductIdFn(ctx, productIds)
+}
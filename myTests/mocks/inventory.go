@@ -0,0 +1,149 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/inventory"
+	"github.com/NatthawutSK/ri-shop/modules/inventory/inventoryRepositories"
+)
+
+var _ inventoryRepositories.IInventoryRepository = (*InventoryRepository)(nil)
+
+type InventoryRepository struct {
+	calls
+	RefreshForecastFn          func(ctx context.Context, windowDays int) error
+	FindReorderSuggestionFn    func(ctx context.Context, req *inventory.ReorderFilter) ([]*inventory.ReorderSuggestion, error)
+	FindSupplierFn             func(ctx context.Context, storeId string) ([]*inventory.Supplier, error)
+	InsertSupplierFn           func(ctx context.Context, req *inventory.Supplier) error
+	UpdateProductSupplierFn    func(ctx context.Context, req *inventory.ProductSupplierReq) error
+	ReserveStockFn             func(ctx context.Context, req *inventory.Reservation) error
+	ReleaseStockFn             func(ctx context.Context, reference string) (int, error)
+	ReleaseCheckoutFn          func(ctx context.Context, userId string) error
+	DeleteExpiredReservationFn func(ctx context.Context) (int, error)
+	MarkLowStockFn             func(ctx context.Context, orderId string, threshold int) ([]*inventory.LowStock, error)
+	FindLowStockFn             func(ctx context.Context, storeId string, threshold int) ([]*inventory.LowStock, error)
+	UpdateLowStockThresholdFn  func(ctx context.Context, req *inventory.LowStockThresholdReq) error
+	FindMovementFn             func(ctx context.Context, req *inventory.MovementFilter) ([]*inventory.Movement, int, error)
+	InsertMovementFn           func(ctx context.Context, req *inventory.MovementReq) (*inventory.Movement, error)
+	FindReconcileFn            func(ctx context.Context, req *inventory.ReconcileReq) ([]*inventory.Reconcile, error)
+}
+
+func (m *InventoryRepository) RefreshForecast(ctx context.Context, windowDays int) error {
+	m.record("RefreshForecast")
+	if m.RefreshForecastFn == nil {
+		panic(notMocked("RefreshForecast"))
+	}
+	return m.RefreshForecastFn(ctx, windowDays)
+}
+
+func (m *InventoryRepository) FindReorderSuggestion(ctx context.Context, req *inventory.ReorderFilter) ([]*inventory.ReorderSuggestion, error) {
+	m.record("FindReorderSuggestion")
+	if m.FindReorderSuggestionFn == nil {
+		panic(notMocked("FindReorderSuggestion"))
+	}
+	return m.FindReorderSuggestionFn(ctx, req)
+}
+
+func (m *InventoryRepository) FindSupplier(ctx context.Context, storeId string) ([]*inventory.Supplier, error) {
+	m.record("FindSupplier")
+	if m.FindSupplierFn == nil {
+		panic(notMocked("FindSupplier"))
+	}
+	return m.FindSupplierFn(ctx, storeId)
+}
+
+func (m *InventoryRepository) InsertSupplier(ctx context.Context, req *inventory.Supplier) error {
+	m.record("InsertSupplier")
+	if m.InsertSupplierFn == nil {
+		panic(notMocked("InsertSupplier"))
+	}
+	return m.InsertSupplierFn(ctx, req)
+}
+
+func (m *InventoryRepository) UpdateProductSupplier(ctx context.Context, req *inventory.ProductSupplierReq) error {
+	m.record("UpdateProductSupplier")
+	if m.UpdateProductSupplierFn == nil {
+		panic(notMocked("UpdateProductSupplier"))
+	}
+	return m.UpdateProductSupplierFn(ctx, req)
+}
+
+func (m *InventoryRepository) ReserveStock(ctx context.Context, req *inventory.Reservation) error {
+	m.record("ReserveStock")
+	if m.ReserveStockFn == nil {
+		panic(notMocked("ReserveStock"))
+	}
+	return m.ReserveStockFn(ctx, req)
+}
+
+func (m *InventoryRepository) ReleaseStock(ctx context.Context, reference string) (int, error) {
+	m.record("ReleaseStock")
+	if m.ReleaseStockFn == nil {
+		panic(notMocked("ReleaseStock"))
+	}
+	return m.ReleaseStockFn(ctx, reference)
+}
+
+func (m *InventoryRepository) ReleaseCheckout(ctx context.Context, userId string) error {
+	m.record("ReleaseCheckout")
+	if m.ReleaseCheckoutFn == nil {
+		panic(notMocked("ReleaseCheckout"))
+	}
+	return m.ReleaseCheckoutFn(ctx, userId)
+}
+
+func (m *InventoryRepository) DeleteExpiredReservation(ctx context.Context) (int, error) {
+	m.record("DeleteExpiredReservation")
+	if m.DeleteExpiredReservationFn == nil {
+		panic(notMocked("DeleteExpiredReservation"))
+	}
+	return m.DeleteExpiredReservationFn(ctx)
+}
+
+func (m *InventoryRepository) MarkLowStock(ctx context.Context, orderId string, threshold int) ([]*inventory.LowStock, error) {
+	m.record("MarkLowStock")
+	if m.MarkLowStockFn == nil {
+		panic(notMocked("MarkLowStock"))
+	}
+	return m.MarkLowStockFn(ctx, orderId, threshold)
+}
+
+func (m *InventoryRepository) FindLowStock(ctx context.Context, storeId string, threshold int) ([]*inventory.LowStock, error) {
+	m.record("FindLowStock")
+	if m.FindLowStockFn == nil {
+		panic(notMocked("FindLowStock"))
+	}
+	return m.FindLowStockFn(ctx, storeId, threshold)
+}
+
+func (m *InventoryRepository) UpdateLowStockThreshold(ctx context.Context, req *inventory.LowStockThresholdReq) error {
+	m.record("UpdateLowStockThreshold")
+	if m.UpdateLowStockThresholdFn == nil {
+		panic(notMocked("UpdateLowStockThreshold"))
+	}
+	return m.UpdateLowStockThresholdFn(ctx, req)
+}
+
+func (m *InventoryRepository) FindMovement(ctx context.Context, req *inventory.MovementFilter) ([]*inventory.Movement, int, error) {
+	m.record("FindMovement")
+	if m.FindMovementFn == nil {
+		panic(notMocked("FindMovement"))
+	}
+	return m.FindMovementFn(ctx, req)
+}
+
+func (m *InventoryRepository) InsertMovement(ctx context.Context, req *inventory.MovementReq) (*inventory.Movement, error) {
+	m.record("InsertMovement")
+	if m.InsertMovementFn == nil {
+		panic(notMocked("InsertMovement"))
+	}
+	return m.InsertMovementFn(ctx, req)
+}
+
+func (m *InventoryRepository) FindReconcile(ctx context.Context, req *inventory.ReconcileReq) ([]*inventory.Reconcile, error) {
+	m.record("FindReconcile")
+	if m.FindReconcileFn == nil {
+		panic(notMocked("FindReconcile"))
+	}
+	return m.FindReconcileFn(ctx, req)
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/NatthawutSK/ri-shop/pkg/locks"
+)
+
+var _ locks.ILocker = (*Locker)(nil)
+
+// Locker is an in-memory locker, Held mark a name as locked by another instance
+type Locker struct {
+	calls
+	mu   sync.Mutex
+	Held map[string]bool
+}
+
+func (m *Locker) TryRun(name string, fn func() error) (bool, error) {
+	m.record("TryRun")
+	m.mu.Lock()
+	held := m.Held[name]
+	m.mu.Unlock()
+	if held {
+		return false, nil
+	}
+	return true, fn()
+}
+
+func (m *Locker) Run(ctx context.Context, name string, fn func() error) error {
+	m.record("Run")
+	return fn()
+}
@@ -0,0 +1,36 @@
+// Package mocks has test doubles of the module interfaces. Each method call the func of the same name,
+// a method without a func panics so a test notices a call it did not expect. They are written by hand
+// because mockery and testify are not in go.mod, keep them in the order of the interface
+package mocks
+
+import (
+	"fmt"
+	"sync"
+)
+
+// calls count the calls of each method, tests read it with Calls
+type calls struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *calls) record(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[method]++
+}
+
+func (c *calls) Calls(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.counts[method]
+}
+
+func notMocked(method string) string {
+	return fmt.Sprintf("%s is called but not mocked", method)
+}
@@ -0,0 +1,160 @@
+package mocks
+
+import (
+	"context"
+
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsRepositories"
+)
+
+var _ notificationsRepositories.INotificationsRepository = (*NotificationsRepository)(nil)
+
+type NotificationsRepository struct {
+	calls
+	InsertEmailFn        func(ctx context.Context, req *notifications.Email) error
+	ClaimEmailFn         func(ctx context.Context, limit int) ([]*notifications.Email, error)
+	MarkEmailSentFn      func(ctx context.Context, emailId, provider string) error
+	MarkEmailRetryFn     func(ctx context.Context, emailId, lastError string, nextAttemptAt time.Time, failed bool) error
+	RequeueEmailFn       func(ctx context.Context, emailId string, nextAttemptAt time.Time) error
+	CountEmailByStatusFn func(ctx context.Context) (map[string]int, error)
+	RetryFailedEmailFn   func(ctx context.Context) (int, error)
+	InsertNotificationFn func(ctx context.Context, req *notifications.Notification) error
+	FindNotificationFn   func(ctx context.Context, req *notifications.InboxReq) ([]*notifications.Notification, int, error)
+	CountUnreadFn        func(ctx context.Context, userId string) (int, error)
+	MarkReadFn           func(ctx context.Context, userId, notificationId string) error
+	MarkAllReadFn        func(ctx context.Context, userId string) (int, error)
+	FindPreferenceFn     func(ctx context.Context, userId string) ([]*notifications.Preference, error)
+	UpsertPreferenceFn   func(ctx context.Context, userId string, preferences []*notifications.Preference) error
+	FindUserEmailFn      func(ctx context.Context, userId string) (string, error)
+	FindOrderUserIdFn    func(ctx context.Context, orderId string) (string, error)
+}
+
+func (m *NotificationsRepository) InsertEmail(ctx context.Context, req *notifications.Email) error {
+	m.record("InsertEmail")
+	if m.InsertEmailFn == nil {
+		panic(notMocked("InsertEmail"))
+	}
+	return m.InsertEmailFn(ctx, req)
+}
+
+func (m *NotificationsRepository) ClaimEmail(ctx context.Context, limit int) ([]*notifications.Email, error) {
+	m.record("ClaimEmail")
+	if m.ClaimEmailFn == nil {
+		panic(notMocked("ClaimEmail"))
+	}
+	return m.ClaimEmailFn(ctx, limit)
+}
+
+func (m *NotificationsRepository) MarkEmailSent(ctx context.Context, emailId, provider string) error {
+	m.record("MarkEmailSent")
+	if m.MarkEmailSentFn == nil {
+		panic(notMocked("MarkEmailSent"))
+	}
+	return m.MarkEmailSentFn(ctx, emailId, provider)
+}
+
+func (m *NotificationsRepository) MarkEmailRetry(ctx context.Context, emailId, lastError string, nextAttemptAt time.Time, failed bool) error {
+	m.record("MarkEmailRetry")
+	if m.MarkEmailRetryFn == nil {
+		panic(notMocked("MarkEmailRetry"))
+	}
+	return m.MarkEmailRetryFn(ctx, emailId, lastError, nextAttemptAt, failed)
+}
+
+func (m *NotificationsRepository) RequeueEmail(ctx context.Context, emailId string, nextAttemptAt time.Time) error {
+	m.record("RequeueEmail")
+	if m.RequeueEmailFn == nil {
+		panic(notMocked("RequeueEmail"))
+	}
+	return m.RequeueEmailFn(ctx, emailId, nextAttemptAt)
+}
+
+func (m *NotificationsRepository) CountEmailByStatus(ctx context.Context) (map[string]int, error) {
+	m.record("CountEmailByStatus")
+	if m.CountEmailByStatusFn == nil {
+		panic(notMocked("CountEmailByStatus"))
+	}
+	return m.CountEmailByStatusFn(ctx)
+}
+
+func (m *NotificationsRepository) RetryFailedEmail(ctx context.Context) (int, error) {
+	m.record("RetryFailedEmail")
+	if m.RetryFailedEmailFn == nil {
+		panic(notMocked("RetryFailedEmail"))
+	}
+	return m.RetryFailedEmailFn(ctx)
+}
+
+func (m *NotificationsRepository) InsertNotification(ctx context.Context, req *notifications.Notification) error {
+	m.record("InsertNotification")
+	if m.InsertNotificationFn == nil {
+		panic(notMocked("InsertNotification"))
+	}
+	return m.InsertNotificationFn(ctx, req)
+}
+
+func (m *NotificationsRepository) FindNotification(ctx context.Context, req *notifications.InboxReq) ([]*notifications.Notification, int, error) {
+	m.record("FindNotification")
+	if m.FindNotificationFn == nil {
+		panic(notMocked("FindNotification"))
+	}
+	return m.FindNotificationFn(ctx, req)
+}
+
+func (m *NotificationsRepository) CountUnread(ctx context.Context, userId string) (int, error) {
+	m.record("CountUnread")
+	if m.CountUnreadFn == nil {
+		panic(notMocked("CountUnread"))
+	}
+	return m.CountUnreadFn(ctx, userId)
+}
+
+func (m *NotificationsRepository) MarkRead(ctx context.Context, userId, notificationId string) error {
+	m.record("MarkRead")
+	if m.MarkReadFn == nil {
+		panic(notMocked("MarkRead"))
+	}
+	return m.MarkReadFn(ctx, userId, notificationId)
+}
+
+func (m *NotificationsRepository) MarkAllRead(ctx context.Context, userId string) (int, error) {
+	m.record("MarkAllRead")
+	if m.MarkAllReadFn == nil {
+		panic(notMocked("MarkAllRead"))
+	}
+	return m.MarkAllReadFn(ctx, userId)
+}
+
+func (m *NotificationsRepository) FindPreference(ctx context.Context, userId string) ([]*notifications.Preference, error) {
+	m.record("FindPreference")
+	if m.FindPreferenceFn == nil {
+		panic(notMocked("FindPreference"))
+	}
+	return m.FindPreferenceFn(ctx, userId)
+}
+
+func (m *NotificationsRepository) UpsertPreference(ctx context.Context, userId string, preferences []*notifications.Preference) error {
+	m.record("UpsertPreference")
+	if m.UpsertPreferenceFn == nil {
+		panic(notMocked("UpsertPreference"))
+	}
+	return m.UpsertPreferenceFn(ctx, userId, preferences)
+}
+
+func (m *NotificationsRepository) FindUserEmail(ctx context.Context, userId string) (string, error) {
+	m.record("FindUserEmail")
+	if m.FindUserEmailFn == nil {
+		panic(notMocked("FindUserEmail"))
+	}
+	return m.FindUserEmailFn(ctx, userId)
+}
+
+func (m *NotificationsRepository) FindOrderUserId(ctx context.Context, orderId string) (string, error) {
+	m.record("FindOrderUserId")
+	if m.FindOrderUserIdFn == nil {
+		panic(notMocked("FindOrderUserId"))
+	}
+	return m.FindOrderUserIdFn(ctx, orderId)
+}
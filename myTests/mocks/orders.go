@@ -0,0 +1,78 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/orders/ordersUsecases"
+)
+
+var _ ordersUsecases.IOrdersUsecase = (*OrdersUsecase)(nil)
+
+type OrdersUsecase struct {
+	calls
+	FindOneOrderFn     func(ctx context.Context, orderId string) (*orders.Order, error)
+	FindOrderFn        func(ctx context.Context, req *orders.OrderFilter) *entities.PaginateRes
+	InsertOrderFn      func(ctx context.Context, req *orders.Order) (*orders.Order, error)
+	UpdateOrderFn      func(ctx context.Context, req *orders.OrderUpdate) (*orders.Order, error)
+	FindPackingSlipFn  func(ctx context.Context, orderId string) (*orders.PackingSlip, error)
+	FindGiftTrackingFn func(ctx context.Context, token string) (*orders.GiftTracking, error)
+	ConfirmPickupFn    func(ctx context.Context, req *orders.PickupConfirmReq) (*orders.Order, error)
+}
+
+func (m *OrdersUsecase) FindOneOrder(ctx context.Context, orderId string) (*orders.Order, error) {
+	m.record("FindOneOrder")
+	if m.FindOneOrderFn == nil {
+		panic(notMocked("FindOneOrder"))
+	}
+	return m.FindOneOrderFn(ctx, orderId)
+}
+
+func (m *OrdersUsecase) FindOrder(ctx context.Context, req *orders.OrderFilter) *entities.PaginateRes {
+	m.record("FindOrder")
+	if m.FindOrderFn == nil {
+		panic(notMocked("FindOrder"))
+	}
+	return m.FindOrderFn(ctx, req)
+}
+
+func (m *OrdersUsecase) InsertOrder(ctx context.Context, req *orders.Order) (*orders.Order, error) {
+	m.record("InsertOrder")
+	if m.InsertOrderFn == nil {
+		panic(notMocked("InsertOrder"))
+	}
+	return m.InsertOrderFn(ctx, req)
+}
+
+func (m *OrdersUsecase) UpdateOrder(ctx context.Context, req *orders.OrderUpdate) (*orders.Order, error) {
+	m.record("UpdateOrder")
+	if m.UpdateOrderFn == nil {
+		panic(notMocked("UpdateOrder"))
+	}
+	return m.UpdateOrderFn(ctx, req)
+}
+
+func (m *OrdersUsecase) FindPackingSlip(ctx context.Context, orderId string) (*orders.PackingSlip, error) {
+	m.record("FindPackingSlip")
+	if m.FindPackingSlipFn == nil {
+		panic(notMocked("FindPackingSlip"))
+	}
+	return m.FindPackingSlipFn(ctx, orderId)
+}
+
+func (m *OrdersUsecase) FindGiftTracking(ctx context.Context, token string) (*orders.GiftTracking, error) {
+	m.record("FindGiftTracking")
+	if m.FindGiftTrackingFn == nil {
+		panic(notMocked("FindGiftTracking"))
+	}
+	return m.FindGiftTrackingFn(ctx, token)
+}
+
+func (m *OrdersUsecase) ConfirmPickup(ctx context.Context, req *orders.PickupConfirmReq) (*orders.Order, error) {
+	m.record("ConfirmPickup")
+	if m.ConfirmPickupFn == nil {
+		panic(notMocked("ConfirmPickup"))
+	}
+	return m.ConfirmPickupFn(ctx, req)
+}
@@ -0,0 +1,107 @@
+package mocks
+
+import (
+	"context"
+
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/privacy"
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyRepositories"
+)
+
+var _ privacyRepositories.IPrivacyRepository = (*PrivacyRepository)(nil)
+
+type PrivacyRepository struct {
+	calls
+	FindProfileFn        func(ctx context.Context, userId string) (*privacy.Profile, error)
+	FindOrdersFn         func(ctx context.Context, userId string) ([]*privacy.Order, error)
+	FindAddressesFn      func(ctx context.Context, userId string) ([]*addresses.Address, error)
+	InsertExportFn       func(ctx context.Context, userId string) (*privacy.DataExport, error)
+	UpdateExportFn       func(ctx context.Context, req *privacy.DataExport) error
+	FindLastExportFn     func(ctx context.Context, userId string) (*privacy.DataExport, error)
+	FailPendingExportsFn func(ctx context.Context, olderThan time.Duration) (int64, error)
+	FindAccountFn        func(ctx context.Context, userId string) (*privacy.Account, error)
+	CountOpenOrdersFn    func(ctx context.Context, userId string) (int, error)
+	AnonymizeUserFn      func(ctx context.Context, account *privacy.Account) error
+}
+
+func (m *PrivacyRepository) FindProfile(ctx context.Context, userId string) (*privacy.Profile, error) {
+	m.record("FindProfile")
+	if m.FindProfileFn == nil {
+		panic(notMocked("FindProfile"))
+	}
+	return m.FindProfileFn(ctx, userId)
+}
+
+func (m *PrivacyRepository) FindOrders(ctx context.Context, userId string) ([]*privacy.Order, error) {
+	m.record("FindOrders")
+	if m.FindOrdersFn == nil {
+		panic(notMocked("FindOrders"))
+	}
+	return m.FindOrdersFn(ctx, userId)
+}
+
+func (m *PrivacyRepository) FindAddresses(ctx context.Context, userId string) ([]*addresses.Address, error) {
+	m.record("FindAddresses")
+	if m.FindAddressesFn == nil {
+		panic(notMocked("FindAddresses"))
+	}
+	return m.FindAddressesFn(ctx, userId)
+}
+
+func (m *PrivacyRepository) InsertExport(ctx context.Context, userId string) (*privacy.DataExport, error) {
+	m.record("InsertExport")
+	if m.InsertExportFn == nil {
+		panic(notMocked("InsertExport"))
+	}
+	return m.InsertExportFn(ctx, userId)
+}
+
+func (m *PrivacyRepository) UpdateExport(ctx context.Context, req *privacy.DataExport) error {
+	m.record("UpdateExport")
+	if m.UpdateExportFn == nil {
+		panic(notMocked("UpdateExport"))
+	}
+	return m.UpdateExportFn(ctx, req)
+}
+
+func (m *PrivacyRepository) FindLastExport(ctx context.Context, userId string) (*privacy.DataExport, error) {
+	m.record("FindLastExport")
+	if m.FindLastExportFn == nil {
+		panic(notMocked("FindLastExport"))
+	}
+	return m.FindLastExportFn(ctx, userId)
+}
+
+func (m *PrivacyRepository) FailPendingExports(ctx context.Context, olderThan time.Duration) (int64, error) {
+	m.record("FailPendingExports")
+	if m.FailPendingExportsFn == nil {
+		panic(notMocked("FailPendingExports"))
+	}
+	return m.FailPendingExportsFn(ctx, olderThan)
+}
+
+func (m *PrivacyRepository) FindAccount(ctx context.Context, userId string) (*privacy.Account, error) {
+	m.record("FindAccount")
+	if m.FindAccountFn == nil {
+		panic(notMocked("FindAccount"))
+	}
+	return m.FindAccountFn(ctx, userId)
+}
+
+func (m *PrivacyRepository) CountOpenOrders(ctx context.Context, userId string) (int, error) {
+	m.record("CountOpenOrders")
+	if m.CountOpenOrdersFn == nil {
+		panic(notMocked("CountOpenOrders"))
+	}
+	return m.CountOpenOrdersFn(ctx, userId)
+}
+
+func (m *PrivacyRepository) AnonymizeUser(ctx context.Context, account *privacy.Account) error {
+	m.record("AnonymizeUser")
+	if m.AnonymizeUserFn == nil {
+		panic(notMocked("AnonymizeUser"))
+	}
+	return m.AnonymizeUserFn(ctx, account)
+}
@@ -0,0 +1,186 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsRepositories"
+)
+
+var _ productsRepositories.IProductsRepository = (*ProductsRepository)(nil)
+
+type ProductsRepository struct {
+	calls
+	FindOneProductFn          func(ctx context.Context, productId string) (*products.Products, error)
+	FindProductFn             func(ctx context.Context, req *products.ProductFilter) ([]*products.Products, int)
+	FindProductByIdsFn        func(ctx context.Context, req *products.ProductFilter) []*products.Products
+	FindFacetFn               func(ctx context.Context, req *products.ProductFilter) []*products.Facet
+	InsertProductFn           func(ctx context.Context, req *products.Products) (*products.Products, error)
+	UpdateProductFn           func(ctx context.Context, req *products.Products) (*products.Products, error)
+	DeleteProductFn           func(ctx context.Context, productId string) error
+	FindSimilarProductFn      func(ctx context.Context, storeId, embedding string, limit int) ([]*products.SimilarProduct, error)
+	UpdateProductPricesFn     func(ctx context.Context, productId string, req []*products.ProductPrice) error
+	UpdateProductRegionsFn    func(ctx context.Context, productId string, req []*products.ProductRegion) error
+	UpdateProductAttributesFn func(ctx context.Context, productId string, req map[string]string) error
+	UpdateImageOrderFn        func(ctx context.Context, productId string, imageIds []string) error
+	UpdatePrimaryImageFn      func(ctx context.Context, productId, imageId string) error
+	InsertImageFn             func(ctx context.Context, productId string, image *entities.Image) error
+	FindAvailabilityFn        func(ctx context.Context, storeId, productId string) (*products.Availability, error)
+	FindTopProductIdFn        func(ctx context.Context, limit int) ([]string, error)
+	UpdateWindowOpenFn        func(ctx context.Context) ([]*products.WindowEvent, error)
+	FindBulkProductFn         func(ctx context.Context, productIds []string) (map[string]*products.BulkProduct, error)
+	UpdateBulkProductFn       func(ctx context.Context, actorId string, req []*products.BulkItem) (map[string]*products.BulkProduct, error)
+}
+
+func (m *ProductsRepository) FindOneProduct(ctx context.Context, productId string) (*products.Products, error) {
+	m.record("FindOneProduct")
+	if m.FindOneProductFn == nil {
+		panic(notMocked("FindOneProduct"))
+	}
+	return m.FindOneProductFn(ctx, productId)
+}
+
+func (m *ProductsRepository) FindProduct(ctx context.Context, req *products.ProductFilter) ([]*products.Products, int) {
+	m.record("FindProduct")
+	if m.FindProductFn == nil {
+		panic(notMocked("FindProduct"))
+	}
+	return m.FindProductFn(ctx, req)
+}
+
+func (m *ProductsRepository) FindProductByIds(ctx context.Context, req *products.ProductFilter) []*products.Products {
+	m.record("FindProductByIds")
+	if m.FindProductByIdsFn == nil {
+		panic(notMocked("FindProductByIds"))
+	}
+	return m.FindProductByIdsFn(ctx, req)
+}
+
+func (m *ProductsRepository) FindFacet(ctx context.Context, req *products.ProductFilter) []*products.Facet {
+	m.record("FindFacet")
+	if m.FindFacetFn == nil {
+		panic(notMocked("FindFacet"))
+	}
+	return m.FindFacetFn(ctx, req)
+}
+
+func (m *ProductsRepository) InsertProduct(ctx context.Context, req *products.Products) (*products.Products, error) {
+	m.record("InsertProduct")
+	if m.InsertProductFn == nil {
+		panic(notMocked("InsertProduct"))
+	}
+	return m.InsertProductFn(ctx, req)
+}
+
+func (m *ProductsRepository) UpdateProduct(ctx context.Context, req *products.Products) (*products.Products, error) {
+	m.record("UpdateProduct")
+	if m.UpdateProductFn == nil {
+		panic(notMocked("UpdateProduct"))
+	}
+	return m.UpdateProductFn(ctx, req)
+}
+
+func (m *ProductsRepository) DeleteProduct(ctx context.Context, productId string) error {
+	m.record("DeleteProduct")
+	if m.DeleteProductFn == nil {
+		panic(notMocked("DeleteProduct"))
+	}
+	return m.DeleteProductFn(ctx, productId)
+}
+
+func (m *ProductsRepository) FindSimilarProduct(ctx context.Context, storeId, embedding string, limit int) ([]*products.SimilarProduct, error) {
+	m.record("FindSimilarProduct")
+	if m.FindSimilarProductFn == nil {
+		panic(notMocked("FindSimilarProduct"))
+	}
+	return m.FindSimilarProductFn(ctx, storeId, embedding, limit)
+}
+
+func (m *ProductsRepository) UpdateProductPrices(ctx context.Context, productId string, req []*products.ProductPrice) error {
+	m.record("UpdateProductPrices")
+	if m.UpdateProductPricesFn == nil {
+		panic(notMocked("UpdateProductPrices"))
+	}
+	return m.UpdateProductPricesFn(ctx, productId, req)
+}
+
+func (m *ProductsRepository) UpdateProductRegions(ctx context.Context, productId string, req []*products.ProductRegion) error {
+	m.record("UpdateProductRegions")
+	if m.UpdateProductRegionsFn == nil {
+		panic(notMocked("UpdateProductRegions"))
+	}
+	return m.UpdateProductRegionsFn(ctx, productId, req)
+}
+
+func (m *ProductsRepository) UpdateProductAttributes(ctx context.Context, productId string, req map[string]string) error {
+	m.record("UpdateProductAttributes")
+	if m.UpdateProductAttributesFn == nil {
+		panic(notMocked("UpdateProductAttributes"))
+	}
+	return m.UpdateProductAttributesFn(ctx, productId, req)
+}
+
+func (m *ProductsRepository) UpdateImageOrder(ctx context.Context, productId string, imageIds []string) error {
+	m.record("UpdateImageOrder")
+	if m.UpdateImageOrderFn == nil {
+		panic(notMocked("UpdateImageOrder"))
+	}
+	return m.UpdateImageOrderFn(ctx, productId, imageIds)
+}
+
+func (m *ProductsRepository) UpdatePrimaryImage(ctx context.Context, productId, imageId string) error {
+	m.record("UpdatePrimaryImage")
+	if m.UpdatePrimaryImageFn == nil {
+		panic(notMocked("UpdatePrimaryImage"))
+	}
+	return m.UpdatePrimaryImageFn(ctx, productId, imageId)
+}
+
+func (m *ProductsRepository) InsertImage(ctx context.Context, productId string, image *entities.Image) error {
+	m.record("InsertImage")
+	if m.InsertImageFn == nil {
+		panic(notMocked("InsertImage"))
+	}
+	return m.InsertImageFn(ctx, productId, image)
+}
+
+func (m *ProductsRepository) FindAvailability(ctx context.Context, storeId, productId string) (*products.Availability, error) {
+	m.record("FindAvailability")
+	if m.FindAvailabilityFn == nil {
+		panic(notMocked("FindAvailability"))
+	}
+	return m.FindAvailabilityFn(ctx, storeId, productId)
+}
+
+func (m *ProductsRepository) FindTopProductId(ctx context.Context, limit int) ([]string, error) {
+	m.record("FindTopProductId")
+	if m.FindTopProductIdFn == nil {
+		panic(notMocked("FindTopProductId"))
+	}
+	return m.FindTopProductIdFn(ctx, limit)
+}
+
+func (m *ProductsRepository) UpdateWindowOpen(ctx context.Context) ([]*products.WindowEvent, error) {
+	m.record("UpdateWindowOpen")
+	if m.UpdateWindowOpenFn == nil {
+		panic(notMocked("UpdateWindowOpen"))
+	}
+	return m.UpdateWindowOpenFn(ctx)
+}
+
+func (m *ProductsRepository) FindBulkProduct(ctx context.Context, productIds []string) (map[string]*products.BulkProduct, error) {
+	m.record("FindBulkProduct")
+	if m.FindBulkProductFn == nil {
+		panic(notMocked("FindBulkProduct"))
+	}
+	return m.FindBulkProductFn(ctx, productIds)
+}
+
+func (m *ProductsRepository) UpdateBulkProduct(ctx context.Context, actorId string, req []*products.BulkItem) (map[string]*products.BulkProduct, error) {
+	m.record("UpdateBulkProduct")
+	if m.UpdateBulkProductFn == nil {
+		panic(notMocked("UpdateBulkProduct"))
+	}
+	return m.UpdateBulkProductFn(ctx, actorId, req)
+}
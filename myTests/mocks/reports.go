@@ -0,0 +1,86 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/reports"
+	"github.com/NatthawutSK/ri-shop/modules/reports/reportsRepositories"
+)
+
+var _ reportsRepositories.IReportsRepository = (*ReportsRepository)(nil)
+
+type ReportsRepository struct {
+	calls
+	FindRevenueFn          func(ctx context.Context, req *reports.ReportFilter) ([]*reports.Revenue, error)
+	FindTopProductFn       func(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopProduct, error)
+	FindOrderStatusFn      func(ctx context.Context, req *reports.ReportFilter) ([]*reports.OrderStatus, error)
+	FindLowStockFn         func(ctx context.Context, req *reports.ReportFilter) ([]*reports.LowStock, error)
+	FindCommissionFn       func(ctx context.Context, req *reports.ReportFilter) ([]*reports.Commission, error)
+	FindTopSearchFn        func(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopSearch, error)
+	FindZeroResultSearchFn func(ctx context.Context, req *reports.ReportFilter) ([]*reports.ZeroResultSearch, error)
+	FindOrderSummaryFn     func(ctx context.Context, req *reports.OrderSummaryReq) (*reports.OrderSummary, error)
+}
+
+func (m *ReportsRepository) FindRevenue(ctx context.Context, req *reports.ReportFilter) ([]*reports.Revenue, error) {
+	m.record("FindRevenue")
+	if m.FindRevenueFn == nil {
+		panic(notMocked("FindRevenue"))
+	}
+	return m.FindRevenueFn(ctx, req)
+}
+
+func (m *ReportsRepository) FindTopProduct(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopProduct, error) {
+	m.record("FindTopProduct")
+	if m.FindTopProductFn == nil {
+		panic(notMocked("FindTopProduct"))
+	}
+	return m.FindTopProductFn(ctx, req)
+}
+
+func (m *ReportsRepository) FindOrderStatus(ctx context.Context, req *reports.ReportFilter) ([]*reports.OrderStatus, error) {
+	m.record("FindOrderStatus")
+	if m.FindOrderStatusFn == nil {
+		panic(notMocked("FindOrderStatus"))
+	}
+	return m.FindOrderStatusFn(ctx, req)
+}
+
+func (m *ReportsRepository) FindLowStock(ctx context.Context, req *reports.ReportFilter) ([]*reports.LowStock, error) {
+	m.record("FindLowStock")
+	if m.FindLowStockFn == nil {
+		panic(notMocked("FindLowStock"))
+	}
+	return m.FindLowStockFn(ctx, req)
+}
+
+func (m *ReportsRepository) FindCommission(ctx context.Context, req *reports.ReportFilter) ([]*reports.Commission, error) {
+	m.record("FindCommission")
+	if m.FindCommissionFn == nil {
+		panic(notMocked("FindCommission"))
+	}
+	return m.FindCommissionFn(ctx, req)
+}
+
+func (m *ReportsRepository) FindTopSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.TopSearch, error) {
+	m.record("FindTopSearch")
+	if m.FindTopSearchFn == nil {
+		panic(notMocked("FindTopSearch"))
+	}
+	return m.FindTopSearchFn(ctx, req)
+}
+
+func (m *ReportsRepository) FindZeroResultSearch(ctx context.Context, req *reports.ReportFilter) ([]*reports.ZeroResultSearch, error) {
+	m.record("FindZeroResultSearch")
+	if m.FindZeroResultSearchFn == nil {
+		panic(notMocked("FindZeroResultSearch"))
+	}
+	return m.FindZeroResultSearchFn(ctx, req)
+}
+
+func (m *ReportsRepository) FindOrderSummary(ctx context.Context, req *reports.OrderSummaryReq) (*reports.OrderSummary, error) {
+	m.record("FindOrderSummary")
+	if m.FindOrderSummaryFn == nil {
+		panic(notMocked("FindOrderSummary"))
+	}
+	return m.FindOrderSummaryFn(ctx, req)
+}
@@ -0,0 +1,86 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksRepositories"
+)
+
+var _ tasksRepositories.ITasksRepository = (*TasksRepository)(nil)
+
+type TasksRepository struct {
+	calls
+	FindPausedFn    func(ctx context.Context) (map[string]bool, error)
+	IsPausedFn      func(ctx context.Context, name string) (bool, error)
+	UpdatePausedFn  func(ctx context.Context, name string, paused bool, userId string) error
+	FindLastRunsFn  func(ctx context.Context) (map[string]*tasks.TaskRun, error)
+	FindRunsFn      func(ctx context.Context, name string, limit int) ([]*tasks.TaskRun, error)
+	InsertRunFn     func(ctx context.Context, req *tasks.TaskRun) error
+	FinishRunFn     func(ctx context.Context, req *tasks.TaskRun) error
+	DeleteOldRunsFn func(ctx context.Context, retentionDays, staleHours int) (int64, error)
+}
+
+func (m *TasksRepository) FindPaused(ctx context.Context) (map[string]bool, error) {
+	m.record("FindPaused")
+	if m.FindPausedFn == nil {
+		panic(notMocked("FindPaused"))
+	}
+	return m.FindPausedFn(ctx)
+}
+
+func (m *TasksRepository) IsPaused(ctx context.Context, name string) (bool, error) {
+	m.record("IsPaused")
+	if m.IsPausedFn == nil {
+		panic(notMocked("IsPaused"))
+	}
+	return m.IsPausedFn(ctx, name)
+}
+
+func (m *TasksRepository) UpdatePaused(ctx context.Context, name string, paused bool, userId string) error {
+	m.record("UpdatePaused")
+	if m.UpdatePausedFn == nil {
+		panic(notMocked("UpdatePaused"))
+	}
+	return m.UpdatePausedFn(ctx, name, paused, userId)
+}
+
+func (m *TasksRepository) FindLastRuns(ctx context.Context) (map[string]*tasks.TaskRun, error) {
+	m.record("FindLastRuns")
+	if m.FindLastRunsFn == nil {
+		panic(notMocked("FindLastRuns"))
+	}
+	return m.FindLastRunsFn(ctx)
+}
+
+func (m *TasksRepository) FindRuns(ctx context.Context, name string, limit int) ([]*tasks.TaskRun, error) {
+	m.record("FindRuns")
+	if m.FindRunsFn == nil {
+		panic(notMocked("FindRuns"))
+	}
+	return m.FindRunsFn(ctx, name, limit)
+}
+
+func (m *TasksRepository) InsertRun(ctx context.Context, req *tasks.TaskRun) error {
+	m.record("InsertRun")
+	if m.InsertRunFn == nil {
+		panic(notMocked("InsertRun"))
+	}
+	return m.InsertRunFn(ctx, req)
+}
+
+func (m *TasksRepository) FinishRun(ctx context.Context, req *tasks.TaskRun) error {
+	m.record("FinishRun")
+	if m.FinishRunFn == nil {
+		panic(notMocked("FinishRun"))
+	}
+	return m.FinishRunFn(ctx, req)
+}
+
+func (m *TasksRepository) DeleteOldRuns(ctx context.Context, retentionDays, staleHours int) (int64, error) {
+	m.record("DeleteOldRuns")
+	if m.DeleteOldRunsFn == nil {
+		panic(notMocked("DeleteOldRuns"))
+	}
+	return m.DeleteOldRunsFn(ctx, retentionDays, staleHours)
+}
@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesRepositories"
+)
+
+var _ taxesRepositories.ITaxesRepository = (*TaxesRepository)(nil)
+
+type TaxesRepository struct {
+	calls
+	FindRateFn       func(ctx context.Context, req *taxes.RateFilter) ([]*taxes.Rate, error)
+	FindRegionRateFn func(ctx context.Context, country, state string) ([]*taxes.Rate, error)
+	InsertRateFn     func(ctx context.Context, req *taxes.Rate) (*taxes.Rate, error)
+	DeleteRateFn     func(ctx context.Context, rateId int) error
+}
+
+func (m *TaxesRepository) FindRate(ctx context.Context, req *taxes.RateFilter) ([]*taxes.Rate, error) {
+	m.record("FindRate")
+	if m.FindRateFn == nil {
+		panic(notMocked("FindRate"))
+	}
+	return m.FindRateFn(ctx, req)
+}
+
+func (m *TaxesRepository) FindRegionRate(ctx context.Context, country, state string) ([]*taxes.Rate, error) {
+	m.record("FindRegionRate")
+	if m.FindRegionRateFn == nil {
+		panic(notMocked("FindRegionRate"))
+	}
+	return m.FindRegionRateFn(ctx, country, state)
+}
+
+func (m *TaxesRepository) InsertRate(ctx context.Context, req *taxes.Rate) (*taxes.Rate, error) {
+	m.record("InsertRate")
+	if m.InsertRateFn == nil {
+		panic(notMocked("InsertRate"))
+	}
+	return m.InsertRateFn(ctx, req)
+}
+
+func (m *TaxesRepository) DeleteRate(ctx context.Context, rateId int) error {
+	m.record("DeleteRate")
+	if m.DeleteRateFn == nil {
+		panic(notMocked("DeleteRate"))
+	}
+	return m.DeleteRateFn(ctx, rateId)
+}
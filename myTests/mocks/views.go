@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/views"
+	"github.com/NatthawutSK/ri-shop/modules/views/viewsRepositories"
+)
+
+var _ viewsRepositories.IViewsRepository = (*ViewsRepository)(nil)
+
+type ViewsRepository struct {
+	calls
+	InsertViewFn               func(ctx context.Context, req *views.ViewReq) error
+	FindViewedProductIdFn      func(ctx context.Context, viewer, storeId string, limit int) ([]string, error)
+	DeleteExpiredSessionViewFn func(ctx context.Context, days int) (int64, error)
+}
+
+func (m *ViewsRepository) InsertView(ctx context.Context, req *views.ViewReq) error {
+	m.record("InsertView")
+	if m.InsertViewFn == nil {
+		panic(notMocked("InsertView"))
+	}
+	return m.InsertViewFn(ctx, req)
+}
+
+func (m *ViewsRepository) FindViewedProductId(ctx context.Context, viewer, storeId string, limit int) ([]string, error) {
+	m.record("FindViewedProductId")
+	if m.FindViewedProductIdFn == nil {
+		panic(notMocked("FindViewedProductId"))
+	}
+	return m.FindViewedProductIdFn(ctx, viewer, storeId, limit)
+}
+
+func (m *ViewsRepository) DeleteExpiredSessionView(ctx context.Context, days int) (int64, error) {
+	m.record("DeleteExpiredSessionView")
+	if m.DeleteExpiredSessionViewFn == nil {
+		panic(notMocked("DeleteExpiredSessionView"))
+	}
+	return m.DeleteExpiredSessionViewFn(ctx, days)
+}
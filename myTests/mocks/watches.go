@@ -0,0 +1,95 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/NatthawutSK/ri-shop/modules/watches"
+	"github.com/NatthawutSK/ri-shop/modules/watches/watchesRepositories"
+)
+
+var _ watchesRepositories.IWatchesRepository = (*WatchesRepository)(nil)
+
+type WatchesRepository struct {
+	calls
+	InsertWatchFn       func(ctx context.Context, req *watches.WatchReq, watchedPrice float64) (string, error)
+	FindOneWatchFn      func(ctx context.Context, watchId string) (*watches.Watch, error)
+	FindWatchFn         func(ctx context.Context, userId string) ([]*watches.Watch, error)
+	CountActiveWatchFn  func(ctx context.Context, userId string) (int, error)
+	CancelWatchFn       func(ctx context.Context, userId, watchId string) error
+	FindDueWatchFn      func(ctx context.Context, productId string, limit int) ([]*watches.Watch, error)
+	MarkWatchNotifiedFn func(ctx context.Context, watchId string) (bool, error)
+	ExpireWatchFn       func(ctx context.Context) (int64, error)
+	FindDemandFn        func(ctx context.Context, req *watches.DemandReq) ([]*watches.Demand, error)
+}
+
+func (m *WatchesRepository) InsertWatch(ctx context.Context, req *watches.WatchReq, watchedPrice float64) (string, error) {
+	m.record("InsertWatch")
+	if m.InsertWatchFn == nil {
+		panic(notMocked("InsertWatch"))
+	}
+	return m.InsertWatchFn(ctx, req, watchedPrice)
+}
+
+func (m *WatchesRepository) FindOneWatch(ctx context.Context, watchId string) (*watches.Watch, error) {
+	m.record("FindOneWatch")
+	if m.FindOneWatchFn == nil {
+		panic(notMocked("FindOneWatch"))
+	}
+	return m.FindOneWatchFn(ctx, watchId)
+}
+
+func (m *WatchesRepository) FindWatch(ctx context.Context, userId string) ([]*watches.Watch, error) {
+	m.record("FindWatch")
+	if m.FindWatchFn == nil {
+		panic(notMocked("FindWatch"))
+	}
+	return m.FindWatchFn(ctx, userId)
+}
+
+func (m *WatchesRepository) CountActiveWatch(ctx context.Context, userId string) (int, error) {
+	m.record("CountActiveWatch")
+	if m.CountActiveWatchFn == nil {
+		panic(notMocked("CountActiveWatch"))
+	}
+	return m.CountActiveWatchFn(ctx, userId)
+}
+
+func (m *WatchesRepository) CancelWatch(ctx context.Context, userId, watchId string) error {
+	m.record("CancelWatch")
+	if m.CancelWatchFn == nil {
+		panic(notMocked("CancelWatch"))
+	}
+	return m.CancelWatchFn(ctx, userId, watchId)
+}
+
+func (m *WatchesRepository) FindDueWatch(ctx context.Context, productId string, limit int) ([]*watches.Watch, error) {
+	m.record("FindDueWatch")
+	if m.FindDueWatchFn == nil {
+		panic(notMocked("FindDueWatch"))
+	}
+	return m.FindDueWatchFn(ctx, productId, limit)
+}
+
+func (m *WatchesRepository) MarkWatchNotified(ctx context.Context, watchId string) (bool, error) {
+	m.record("MarkWatchNotified")
+	if m.MarkWatchNotifiedFn == nil {
+		panic(notMocked("MarkWatchNotified"))
+	}
+	return m.MarkWatchNotifiedFn(ctx, watchId)
+}
+
+func (m *WatchesRepository) ExpireWatch(ctx context.Context) (int64, error) {
+	m.record("ExpireWatch")
+	if m.ExpireWatchFn == nil {
+		panic(notMocked("ExpireWatch"))
+	}
+	return m.ExpireWatchFn(ctx)
+}
+
+func (m *WatchesRepository) FindDemand(ctx context.Context, req *watches.DemandReq) ([]*watches.Demand, error) {
+	m.record("FindDemand")
+	if m.FindDemandFn == nil {
+		panic(notMocked("FindDemand"))
+	}
+	return m.FindDemandFn(ctx, req)
+}
@@ -0,0 +1,83 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func inboxRepository(saved []*notifications.Preference) *mocks.NotificationsRepository {
+	return &mocks.NotificationsRepository{
+		InsertNotificationFn: func(ctx context.Context, req *notifications.Notification) error {
+			req.Id = "n1"
+			return nil
+		},
+		FindPreferenceFn: func(ctx context.Context, userId string) ([]*notifications.Preference, error) {
+			return saved, nil
+		},
+		FindUserEmailFn: func(ctx context.Context, userId string) (string, error) {
+			return "customer@example.com", nil
+		},
+		InsertEmailFn: func(ctx context.Context, req *notifications.Email) error { return nil },
+	}
+}
+
+func TestNotifyOrder(t *testing.T) {
+	// ปิด email ของ status change ไว้
+	repo := inboxRepository([]*notifications.Preference{
+		{Type: notifications.TypeOrderStatus, Email: false, Push: true},
+	})
+	usecase := notificationsUsecases.NotificationsUsecase(nil, repo)
+
+	event := &orders.OrderEvent{OrderId: "O000001", UserId: "U000001", Status: orders.StatusShipping}
+	if err := usecase.NotifyOrder(context.Background(), orders.EventOrderStatusChanged, event); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if repo.Calls("InsertNotification") != 1 || repo.Calls("InsertEmail") != 0 {
+		t.Errorf("expected the inbox only, got: %d notifications and %d emails", repo.Calls("InsertNotification"), repo.Calls("InsertEmail"))
+	}
+
+	// order.created ใช้ค่า default ที่ส่ง email
+	if err := usecase.NotifyOrder(context.Background(), orders.EventOrderCreated, event); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if repo.Calls("InsertNotification") != 2 || repo.Calls("InsertEmail") != 1 {
+		t.Errorf("expected the inbox and an email, got: %d notifications and %d emails", repo.Calls("InsertNotification"), repo.Calls("InsertEmail"))
+	}
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	repo := inboxRepository([]*notifications.Preference{
+		{Type: notifications.TypeRefundIssued, Email: false, Sms: true, Push: false},
+	})
+	usecase := notificationsUsecases.NotificationsUsecase(nil, repo)
+
+	preferences, err := usecase.FindPreference(context.Background(), "U000001")
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if len(preferences) != len(notifications.Types) {
+		t.Fatalf("expected every type, got: %d", len(preferences))
+	}
+	for _, preference := range preferences {
+		expected := notifications.DefaultPreference(preference.Type)
+		if preference.Type == notifications.TypeRefundIssued {
+			expected = &notifications.Preference{Type: notifications.TypeRefundIssued, Sms: true}
+		}
+		if *preference != *expected {
+			t.Errorf("expected: %+v, got: %+v", expected, preference)
+		}
+	}
+
+	_, err = usecase.UpdatePreference(context.Background(), &notifications.PreferencesReq{
+		UserId:      "U000001",
+		Preferences: []*notifications.Preference{{Type: "order.deleted"}},
+	})
+	if err == nil || err.Error() != "notification type is invalid" {
+		t.Errorf("expected: notification type is invalid, got: %v", err)
+	}
+}
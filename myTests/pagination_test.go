@@ -0,0 +1,56 @@
+package myTests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+)
+
+func TestPaginationTotalPages(t *testing.T) {
+	tests := []struct {
+		page, limit, total int
+		expect             int
+	}{
+		{page: 1, limit: 10, total: 0, expect: 0},
+		{page: 1, limit: 10, total: 10, expect: 1},
+		{page: 2, limit: 10, total: 11, expect: 2},
+		{page: 1, limit: 0, total: 5, expect: 0},
+	}
+
+	for i, test := range tests {
+		pagination := entities.NewPagination(test.page, test.limit, test.total)
+		if pagination.TotalPages != test.expect {
+			t.Errorf("case %d: expected: %d, got: %d", i, test.expect, pagination.TotalPages)
+		}
+	}
+}
+
+func TestPaginationToPage(t *testing.T) {
+	res := &entities.PaginateRes{
+		Data:      []string{"P000001", "P000002"},
+		Page:      2,
+		Limit:     2,
+		TotalPage: 3,
+		TotalItem: 5,
+		Facets:    map[string]int{"nike": 5},
+	}
+
+	bytes, err := json.Marshal(res.ToPage())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	expect := `{"data":["P000001","P000002"],"pagination":{"total":5,"limit":2,"page":2,"total_pages":3},"facets":{"nike":5}}`
+	if string(bytes) != expect {
+		t.Errorf("expected: %s, got: %s", expect, bytes)
+	}
+
+	bytes, err = json.Marshal(entities.NewPage([]string{}, entities.NewCursorPagination(20, 41, "c2")))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	expect = `{"data":[],"pagination":{"total":41,"limit":20,"next_cursor":"c2"}}`
+	if string(bytes) != expect {
+		t.Errorf("expected: %s, got: %s", expect, bytes)
+	}
+}
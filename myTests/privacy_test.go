@@ -0,0 +1,234 @@
+package myTests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/addresses"
+	"github.com/NatthawutSK/ri-shop/modules/files"
+	"github.com/NatthawutSK/ri-shop/modules/privacy"
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyRepositories"
+	"github.com/NatthawutSK/ri-shop/modules/privacy/privacyUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func privacyRepository() *mocks.PrivacyRepository {
+	return &mocks.PrivacyRepository{
+		FindProfileFn: func(ctx context.Context, userId string) (*privacy.Profile, error) {
+			return &privacy.Profile{Id: userId, Username: "jane", Email: "jane@example.com"}, nil
+		},
+		FindOrdersFn: func(ctx context.Context, userId string) ([]*privacy.Order, error) {
+			return []*privacy.Order{{Id: "O000001", Status: "completed", Contact: "0812345678"}}, nil
+		},
+		FindAddressesFn: func(ctx context.Context, userId string) ([]*addresses.Address, error) {
+			return []*addresses.Address{{Id: "a1", UserId: userId, Recipient: "Jane"}}, nil
+		},
+	}
+}
+
+func TestPrivacyArchive(t *testing.T) {
+	usecase := privacyUsecases.PrivacyUsecase(privacyRepository(), nil)
+
+	data, err := usecase.BuildArchive(context.Background(), "U000001")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("expected a zip, got: %v", err)
+	}
+
+	contents := make(map[string][]byte)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		contents[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	for _, name := range []string{"manifest.json", "profile.json", "orders.json", "addresses.json"} {
+		if _, ok := contents[name]; !ok {
+			t.Errorf("expected %s in the archive, got: %d files", name, len(contents))
+		}
+	}
+
+	orders := make([]*privacy.Order, 0)
+	if err := json.Unmarshal(contents["orders.json"], &orders); err != nil || len(orders) != 1 || orders[0].Contact != "0812345678" {
+		t.Errorf("expected the order with its contact, got: %s (%v)", contents["orders.json"], err)
+	}
+	manifest := make(map[string]string)
+	json.Unmarshal(contents["manifest.json"], &manifest)
+	if manifest["schema"] != privacy.Schema {
+		t.Errorf("expected schema: %s, got: %s", privacy.Schema, manifest["schema"])
+	}
+}
+
+func TestPrivacyFindExport(t *testing.T) {
+	now := time.Now()
+	fresh := now.Add(-time.Hour).Format("2006-01-02T15:04:05")
+	stale := now.Add(-privacy.ExportMaxAge - time.Hour).Format("2006-01-02T15:04:05")
+
+	tests := []struct {
+		name    string
+		last    *privacy.DataExport
+		started bool
+	}{
+		{"none", nil, true},
+		{"pending", &privacy.DataExport{Id: "e1", Status: privacy.StatusPending, CreatedAt: stale}, false},
+		{"done", &privacy.DataExport{Id: "e1", Status: privacy.StatusDone, Destination: "d", CreatedAt: fresh}, false},
+		{"done too long ago", &privacy.DataExport{Id: "e1", Status: privacy.StatusDone, Destination: "d", CreatedAt: stale}, true},
+		{"failed", &privacy.DataExport{Id: "e1", Status: privacy.StatusFailed, CreatedAt: fresh}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finished := make(chan *privacy.DataExport, 1)
+			repo := privacyRepository()
+			repo.FindLastExportFn = func(ctx context.Context, userId string) (*privacy.DataExport, error) {
+				if tt.last == nil {
+					return nil, fmt.Errorf("export not found")
+				}
+				last := *tt.last
+				return &last, nil
+			}
+			repo.InsertExportFn = func(ctx context.Context, userId string) (*privacy.DataExport, error) {
+				return &privacy.DataExport{Id: "e2", Status: privacy.StatusPending}, nil
+			}
+			repo.UpdateExportFn = func(ctx context.Context, req *privacy.DataExport) error {
+				finished <- req
+				return nil
+			}
+			filesUsecase := &mocks.FilesUsecase{
+				UploadPrivateFn: func(ctx context.Context, req *files.PrivateFileReq) (string, error) {
+					return "exports/" + req.Destination, nil
+				},
+				SignDownloadFn: func(ctx context.Context, destination string, ttl time.Duration) (string, error) {
+					return "https://storage.example.com/" + destination, nil
+				},
+			}
+			usecase := privacyUsecases.PrivacyUsecase(repo, filesUsecase)
+
+			export, err := usecase.FindExport(context.Background(), "", "U000001")
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !tt.started {
+				if repo.Calls("InsertExport") != 0 || export.Id != "e1" {
+					t.Errorf("expected the last export, got: %s (%d inserted)", export.Id, repo.Calls("InsertExport"))
+				}
+				if tt.last.Status == privacy.StatusDone && export.Url == "" {
+					t.Error("expected a signed url of a done export")
+				}
+				return
+			}
+
+			if export.Id != "e2" || export.Status != privacy.StatusPending {
+				t.Fatalf("expected a new pending export, got: %s %s", export.Id, export.Status)
+			}
+			select {
+			case result := <-finished:
+				if result.Status != privacy.StatusDone || result.Destination != "exports/account-exports/e2/account-U000001.zip" {
+					t.Errorf("expected a done export, got: %s %s %s", result.Status, result.Destination, result.Error)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("expected the export to finish")
+			}
+		})
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a user imported from the previous platform keeps the argon2id hash until the next login
+	salt := []byte("0123456789abcdef")
+	imported := fmt.Sprintf("$argon2id$v=%d$m=1024,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("secret123"), salt, 1, 1024, 1, 32)))
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		roleId   int
+		open     int
+		expected string
+	}{
+		{"no password", string(hashed), "", privacy.RoleCustomer, 0, "password is required"},
+		{"admin", string(hashed), "secret123", 2, 0, "only a customer account can be deleted"},
+		{"wrong password", string(hashed), "nope", privacy.RoleCustomer, 0, "password is invalid"},
+		{"open orders", string(hashed), "secret123", privacy.RoleCustomer, 1, "the account has orders in progress"},
+		{"deleted", string(hashed), "secret123", privacy.RoleCustomer, 0, ""},
+		{"imported wrong password", imported, "nope", privacy.RoleCustomer, 0, "password is invalid"},
+		{"imported deleted", imported, "secret123", privacy.RoleCustomer, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.PrivacyRepository{
+				FindAccountFn: func(ctx context.Context, userId string) (*privacy.Account, error) {
+					return &privacy.Account{Id: userId, Email: "jane@example.com", Password: tt.hash, RoleId: tt.roleId}, nil
+				},
+				CountOpenOrdersFn: func(ctx context.Context, userId string) (int, error) { return tt.open, nil },
+				AnonymizeUserFn:   func(ctx context.Context, account *privacy.Account) error { return nil },
+			}
+			usecase := privacyUsecases.PrivacyUsecase(repo, nil)
+
+			err := usecase.DeleteAccount(context.Background(), &privacy.DeleteReq{UserId: "U000001", Password: tt.password})
+			if tt.expected == "" {
+				if err != nil || repo.Calls("AnonymizeUser") != 1 {
+					t.Errorf("expected the account to be anonymized, got: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected: %s, got: %v", tt.expected, err)
+			}
+			if repo.Calls("AnonymizeUser") != 0 {
+				t.Error("expected the account not to be anonymized")
+			}
+		})
+	}
+}
+
+func TestHarnessAnonymizeUserGift(t *testing.T) {
+	h := SetupHarness(t)
+	repo := privacyRepositories.PrivacyRepository(h.Db)
+	userId := h.NewUser(t, privacy.RoleCustomer)
+	orderId := h.NewOrder(t, userId, "completed", nil)
+
+	if _, err := h.Db.Exec(`UPDATE "orders" SET "gift" = $2 WHERE "id" = $1;`, orderId, `{
+		"recipient": {"name": "Somchai", "phone": "0812345678", "address": "9 Silom Road", "city": "Bangkok"},
+		"email": "somchai@example.com",
+		"message": "happy birthday",
+		"hide_prices": true
+	}`); err != nil {
+		t.Fatalf("set gift failed: %v", err)
+	}
+
+	account, err := repo.FindAccount(context.Background(), userId)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if err := repo.AnonymizeUser(context.Background(), account); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+
+	var gift string
+	if err := h.Db.Get(&gift, `SELECT "gift"::TEXT FROM "orders" WHERE "id" = $1;`, orderId); err != nil {
+		t.Fatalf("find gift failed: %v", err)
+	}
+	if expected := `{"hide_prices": true}`; gift != expected {
+		t.Errorf("expected: %s, got: %s", expected, gift)
+	}
+}
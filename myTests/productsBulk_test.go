@@ -0,0 +1,122 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+)
+
+func bulkRepository() *mocks.ProductsRepository {
+	current := map[string]*products.BulkProduct{
+		"P000001": {Id: "P000001", Price: 100, Stock: 5, Version: 1},
+		"P000002": {Id: "P000002", Price: 50, Stock: 0, Version: 3, SellerId: "U000009"},
+		"P000003": {Id: "P000003", Price: 20, Stock: 1, Version: 1, StoreId: "other"},
+	}
+	return &mocks.ProductsRepository{
+		FindBulkProductFn: func(ctx context.Context, productIds []string) (map[string]*products.BulkProduct, error) {
+			return current, nil
+		},
+		UpdateBulkProductFn: func(ctx context.Context, actorId string, req []*products.BulkItem) (map[string]*products.BulkProduct, error) {
+			updated := make(map[string]*products.BulkProduct)
+			for _, item := range req {
+				product := *current[item.Id]
+				if item.Price != nil {
+					product.Price = *item.Price
+				}
+				if item.Stock != nil {
+					product.Stock = *item.Stock
+				}
+				product.Version++
+				updated[item.Id] = &product
+			}
+			return updated, nil
+		},
+	}
+}
+
+func bulkItems() []*products.BulkItem {
+	price, stock, negative := 120.0, 5, -1
+	return []*products.BulkItem{
+		{Id: " P000001 ", Price: &price},
+		{Id: "P000002", Stock: &stock},
+		{Id: "P000003", Stock: &stock},
+		{Id: "P000001", Stock: &stock},
+		{Id: "P000004", Stock: &negative},
+	}
+}
+
+func TestBulkUpdateProduct(t *testing.T) {
+	repo := bulkRepository()
+	usecase := productsUsecases.ProductsUsecase(repo, nil, cache.New[*products.Products](time.Minute))
+
+	res, err := usecase.BulkUpdateProduct(context.Background(), &products.BulkUpdateReq{Items: bulkItems()})
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	expected := []struct {
+		status string
+		error  string
+	}{
+		{status: products.BulkUpdated},
+		{status: products.BulkUpdated},
+		{status: products.BulkFailed, error: "product not found"},
+		{status: products.BulkFailed, error: "product is duplicated"},
+		{status: products.BulkFailed, error: "stock must not be negative"},
+	}
+	for i, want := range expected {
+		if got := res.Items[i]; got.Status != want.status || got.Error != want.error {
+			t.Errorf("item %d expected: %v %v, got: %v %v", i, want.status, want.error, got.Status, got.Error)
+		}
+	}
+	if res.Updated != 2 || res.Failed != 3 {
+		t.Errorf("expected: 2 updated 3 failed, got: %v updated %v failed", res.Updated, res.Failed)
+	}
+	if product := res.Items[0].Product; product.Price != 120 || product.Version != 2 {
+		t.Errorf("expected: price 120 version 2, got: %+v", product)
+	}
+}
+
+func TestBulkUpdateProductAllOrNothing(t *testing.T) {
+	repo := bulkRepository()
+	usecase := productsUsecases.ProductsUsecase(repo, nil, cache.New[*products.Products](time.Minute))
+
+	res, err := usecase.BulkUpdateProduct(context.Background(), &products.BulkUpdateReq{Items: bulkItems(), AllOrNothing: true})
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if calls := repo.Calls("UpdateBulkProduct"); calls != 0 {
+		t.Errorf("expected: %v, got: %v", 0, calls)
+	}
+	if res.Items[0].Status != products.BulkSkipped || res.Updated != 0 {
+		t.Errorf("expected the valid items to be skipped, got: %v", res.Items[0].Status)
+	}
+}
+
+func TestBulkUpdateProductSeller(t *testing.T) {
+	repo := bulkRepository()
+	usecase := productsUsecases.ProductsUsecase(repo, nil, cache.New[*products.Products](time.Minute))
+
+	// same stock is unchanged, a product of another seller is refused
+	stock := 0
+	res, err := usecase.BulkUpdateProduct(context.Background(), &products.BulkUpdateReq{
+		SellerId: "U000009",
+		Items: []*products.BulkItem{
+			{Id: "P000002", Stock: &stock},
+			{Id: "P000001", Stock: &stock},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if res.Items[0].Status != products.BulkUnchanged || res.Items[1].Error != "no permission to change this product" {
+		t.Errorf("expected: unchanged and no permission, got: %v and %v", res.Items[0].Status, res.Items[1].Error)
+	}
+	if calls := repo.Calls("UpdateBulkProduct"); calls != 0 {
+		t.Errorf("expected: %v, got: %v", 0, calls)
+	}
+}
@@ -0,0 +1,44 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+)
+
+func TestProductCache(t *testing.T) {
+	repo := &mocks.ProductsRepository{
+		FindOneProductFn: func(ctx context.Context, productId string) (*products.Products, error) {
+			return &products.Products{Id: productId, Title: "Coffee", Version: 1}, nil
+		},
+		UpdateProductFn: func(ctx context.Context, req *products.Products) (*products.Products, error) {
+			return &products.Products{Id: req.Id, Title: req.Title, Version: req.Version + 1}, nil
+		},
+	}
+	usecase := productsUsecases.ProductsUsecase(repo, nil, cache.New[*products.Products](time.Minute))
+
+	for i := 0; i < 2; i++ {
+		if _, err := usecase.FindOneProduct(context.Background(), "P000001"); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+	}
+	if calls := repo.Calls("FindOneProduct"); calls != 1 {
+		t.Errorf("expected: %v, got: %v", 1, calls)
+	}
+
+	// the update read the product from the repository and drop the cached one
+	if _, err := usecase.UpdateProduct(context.Background(), &products.Products{Id: "P000001", Title: "Tea", Version: 1}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, err := usecase.FindOneProduct(context.Background(), "P000001"); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if calls := repo.Calls("FindOneProduct"); calls != 3 {
+		t.Errorf("expected: %v, got: %v", 3, calls)
+	}
+}
@@ -0,0 +1,36 @@
+package myTests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+)
+
+func TestProductSort(t *testing.T) {
+	tests := []struct {
+		sort     string
+		expected []string
+		err      bool
+	}{
+		{"", nil, false},
+		{"DESC", nil, false},
+		{"price_desc", []string{products.SortPriceDesc}, false},
+		{" Price_Asc , newest,price_asc ", []string{products.SortPriceAsc, products.SortNewest}, false},
+		{"rating", nil, true},
+		{"price; DROP TABLE products", nil, true},
+		{"title,newest,best_selling,relevance", nil, true},
+	}
+	for _, tt := range tests {
+		keys, err := products.ParseSort(tt.sort)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, got: %v", tt.sort, keys)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(keys, tt.expected) {
+			t.Errorf("%q: expected: %v, got: %v (%v)", tt.sort, tt.expected, keys, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+	"github.com/NatthawutSK/ri-shop/pkg/events"
+)
+
+func TestProductAvailableAt(t *testing.T) {
+	from, until := "2024-12-01T00:00:00", "2024-12-02T00:00:00"
+	bangkok := time.FixedZone("ICT", 7*60*60)
+
+	tests := []struct {
+		from   *string
+		until  *string
+		at     time.Time
+		expect bool
+	}{
+		{at: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), expect: true},
+		{from: &from, until: &until, at: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC), expect: false},
+		{from: &from, until: &until, at: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), expect: true},
+		{from: &from, until: &until, at: time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC), expect: false},
+		// 07:00 in Bangkok is midnight UTC
+		{from: &from, at: time.Date(2024, 12, 1, 6, 59, 0, 0, bangkok), expect: false},
+		{from: &from, at: time.Date(2024, 12, 1, 7, 0, 0, 0, bangkok), expect: true},
+		{until: &until, at: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), expect: false},
+	}
+
+	for i, test := range tests {
+		product := &products.Products{AvailableFrom: test.from, AvailableUntil: test.until}
+		if available := product.AvailableAt(test.at); available != test.expect {
+			t.Errorf("case %d: expected: %v, got: %v", i, test.expect, available)
+		}
+	}
+}
+
+func TestProductWindowJob(t *testing.T) {
+	repo := &mocks.ProductsRepository{
+		FindOneProductFn: func(ctx context.Context, productId string) (*products.Products, error) {
+			return &products.Products{Id: productId, Title: "Limited sneaker"}, nil
+		},
+		UpdateWindowOpenFn: func(ctx context.Context) ([]*products.WindowEvent, error) {
+			return []*products.WindowEvent{
+				{ProductId: "P000001", Open: true},
+				{ProductId: "P000002", Open: false},
+			}, nil
+		},
+	}
+	usecase := productsUsecases.ProductsUsecase(repo, nil, cache.New[*products.Products](time.Minute))
+
+	opened := make(chan string, 1)
+	closed := make(chan string, 1)
+	events.Subscribe(products.EventProductAvailable, func(ctx context.Context, e *events.Event) {
+		opened <- e.Payload.(*products.WindowEvent).ProductId
+	})
+	events.Subscribe(products.EventProductUnavailable, func(ctx context.Context, e *events.Event) {
+		closed <- e.Payload.(*products.WindowEvent).ProductId
+	})
+
+	if _, err := usecase.FindOneProduct(context.Background(), "P000001"); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+
+	changed, err := usecase.UpdateWindowOpen(context.Background())
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if changed != 2 {
+		t.Errorf("expected: %v, got: %v", 2, changed)
+	}
+
+	for _, test := range []struct {
+		ch     chan string
+		expect string
+	}{{opened, "P000001"}, {closed, "P000002"}} {
+		select {
+		case productId := <-test.ch:
+			if productId != test.expect {
+				t.Errorf("expected: %v, got: %v", test.expect, productId)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected an event of %v", test.expect)
+		}
+	}
+
+	// the product which opened is read again with its new state
+	if _, err := usecase.FindOneProduct(context.Background(), "P000001"); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if calls := repo.Calls("FindOneProduct"); calls != 2 {
+		t.Errorf("expected: %v, got: %v", 2, calls)
+	}
+}
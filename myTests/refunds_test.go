@@ -0,0 +1,43 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/refunds"
+	"github.com/NatthawutSK/ri-shop/modules/refunds/refundsRepositories"
+)
+
+func TestHarnessRefundRestock(t *testing.T) {
+	h := SetupHarness(t)
+	repo := refundsRepositories.RefundsRepository(h.Db)
+	userId := h.NewUser(t, 1)
+	adminId := h.NewUser(t, 2)
+	productId := h.NewProduct(t, 100, 10)
+	orderId := h.NewOrder(t, userId, "canceled", map[string]int{productId: 2})
+
+	// the same product on a second line of the order
+	if _, err := h.Db.Exec(`
+	INSERT INTO "products_orders" ("order_id", "qty", "product")
+	SELECT "order_id", 3, "product" FROM "products_orders" WHERE "order_id" = $1;`, orderId); err != nil {
+		t.Fatalf("insert order item failed: %v", err)
+	}
+
+	if err := repo.InsertRefund(context.Background(), &refunds.Refund{
+		OrderId: orderId,
+		Amount:  500,
+		Status:  refunds.StatusSucceeded,
+		Restock: true,
+		ActorId: adminId,
+	}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+
+	var stock int
+	if err := h.Db.Get(&stock, `SELECT "stock" FROM "products" WHERE "id" = $1;`, productId); err != nil {
+		t.Fatalf("find stock failed: %v", err)
+	}
+	if stock != 15 {
+		t.Errorf("expected: %d, got: %d", 15, stock)
+	}
+}
@@ -0,0 +1,48 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/reports"
+	"github.com/NatthawutSK/ri-shop/modules/reports/reportsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+)
+
+func TestOrderSummaryCache(t *testing.T) {
+	repo := &mocks.ReportsRepository{
+		FindOrderSummaryFn: func(ctx context.Context, req *reports.OrderSummaryReq) (*reports.OrderSummary, error) {
+			return &reports.OrderSummary{UserId: req.UserId, Orders: 2, LifetimeSpend: 480.97}, nil
+		},
+	}
+	usecase := reportsUsecases.ReportsUsecase(repo, cache.New[*reports.OrderSummary](time.Minute))
+
+	req := &reports.OrderSummaryReq{StoreId: "", UserId: "U000001"}
+	for i := 0; i < 2; i++ {
+		if _, err := usecase.FindOrderSummary(context.Background(), req); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+	}
+	if calls := repo.Calls("FindOrderSummary"); calls != 1 {
+		t.Errorf("expected: %v, got: %v", 1, calls)
+	}
+
+	// the summary of another store is not shared
+	if _, err := usecase.FindOrderSummary(context.Background(), &reports.OrderSummaryReq{StoreId: "acme", UserId: "U000001"}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if calls := repo.Calls("FindOrderSummary"); calls != 2 {
+		t.Errorf("expected: %v, got: %v", 2, calls)
+	}
+
+	// an order of the user changed
+	usecase.ForgetOrderSummary("", "U000001")
+	if _, err := usecase.FindOrderSummary(context.Background(), req); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if calls := repo.Calls("FindOrderSummary"); calls != 3 {
+		t.Errorf("expected: %v, got: %v", 3, calls)
+	}
+}
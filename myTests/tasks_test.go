@@ -0,0 +1,132 @@
+package myTests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/tasks"
+	"github.com/NatthawutSK/ri-shop/modules/tasks/tasksUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func newTasksRepository(finished chan *tasks.TaskRun) *mocks.TasksRepository {
+	return &mocks.TasksRepository{
+		IsPausedFn: func(ctx context.Context, name string) (bool, error) { return name == "paused", nil },
+		InsertRunFn: func(ctx context.Context, req *tasks.TaskRun) error {
+			req.Id = 1
+			return nil
+		},
+		FinishRunFn: func(ctx context.Context, req *tasks.TaskRun) error {
+			finished <- req
+			return nil
+		},
+	}
+}
+
+func waitRun(t *testing.T, finished chan *tasks.TaskRun) *tasks.TaskRun {
+	t.Helper()
+	select {
+	case run := <-finished:
+		return run
+	case <-time.After(time.Second):
+		t.Fatal("expected the run to finish")
+		return nil
+	}
+}
+
+func TestTasksRunDue(t *testing.T) {
+	finished := make(chan *tasks.TaskRun, 2)
+	repo := newTasksRepository(finished)
+	usecase := tasksUsecases.TasksUsecase(repo, &mocks.Locker{})
+
+	ran := make(chan string, 2)
+	for _, name := range []string{"active", "paused"} {
+		name := name
+		usecase.Register(&tasks.Task{
+			Name:     name,
+			Schedule: "* * * * *",
+			Run: func(ctx context.Context) error {
+				ran <- name
+				return nil
+			},
+		})
+	}
+
+	// ยังไม่ถึงเวลา
+	usecase.RunDue(time.Now())
+	if calls := repo.Calls("IsPaused"); calls != 0 {
+		t.Fatalf("expected no due task, got: %d", calls)
+	}
+
+	usecase.RunDue(time.Now().Add(2 * time.Minute))
+	run := waitRun(t, finished)
+	if run.Task != "active" || run.Trigger != tasks.TriggerSchedule || run.Status != tasks.StatusSucceeded {
+		t.Errorf("expected a succeeded scheduled run of active, got: %+v", run)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if name := <-ran; name != "active" || len(ran) != 0 {
+		t.Errorf("expected the paused task to be skipped")
+	}
+}
+
+func TestTasksTrigger(t *testing.T) {
+	finished := make(chan *tasks.TaskRun, 1)
+	usecase := tasksUsecases.TasksUsecase(newTasksRepository(finished), &mocks.Locker{})
+	usecase.Register(&tasks.Task{Name: "ok", Schedule: "@daily", Run: func(ctx context.Context) error { return nil }})
+	usecase.Register(&tasks.Task{Name: "failing", Schedule: "@daily", Run: func(ctx context.Context) error { return errors.New("boom") }})
+	usecase.Register(&tasks.Task{Name: "panicking", Schedule: "@daily", Run: func(ctx context.Context) error { panic("nil map") }})
+
+	tests := []struct {
+		name   string
+		status string
+		err    string
+	}{
+		{name: "ok", status: tasks.StatusSucceeded},
+		{name: "failing", status: tasks.StatusFailed, err: "boom"},
+		{name: "panicking", status: tasks.StatusFailed, err: "panic: nil map"},
+	}
+
+	for _, test := range tests {
+		if err := usecase.Trigger(test.name, "U000001"); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", test.name, err)
+		}
+		run := waitRun(t, finished)
+		if run.Trigger != tasks.TriggerManual || run.TriggeredBy != "U000001" {
+			t.Errorf("%s: expected a manual run by U000001, got: %+v", test.name, run)
+		}
+		if run.Status != test.status || run.Error != test.err {
+			t.Errorf("%s: expected: %s %q, got: %s %q", test.name, test.status, test.err, run.Status, run.Error)
+		}
+	}
+}
+
+func TestTasksTriggerRunning(t *testing.T) {
+	repo := newTasksRepository(make(chan *tasks.TaskRun, 1))
+	locker := &mocks.Locker{Held: map[string]bool{"busy": true}}
+	usecase := tasksUsecases.TasksUsecase(repo, locker)
+	usecase.Register(&tasks.Task{Name: "busy", Schedule: "@daily", Run: func(ctx context.Context) error { return nil }})
+
+	var runningErr *tasks.RunningError
+	if err := usecase.Trigger("busy", "U000001"); !errors.As(err, &runningErr) {
+		t.Errorf("expected a RunningError, got: %v", err)
+	}
+	if calls := repo.Calls("InsertRun"); calls != 0 {
+		t.Errorf("expected no run to be recorded, got: %d", calls)
+	}
+
+	if err := usecase.Trigger("unknown", "U000001"); err == nil || err.Error() != "task not found" {
+		t.Errorf("expected task not found, got: %v", err)
+	}
+}
+
+func TestTasksRegisterInvalid(t *testing.T) {
+	usecase := tasksUsecases.TasksUsecase(&mocks.TasksRepository{}, &mocks.Locker{})
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an invalid schedule")
+		}
+	}()
+	usecase.Register(&tasks.Task{Name: "invalid", Schedule: "61 * * * *", Run: func(ctx context.Context) error { return nil }})
+}
@@ -0,0 +1,108 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/taxes"
+	"github.com/NatthawutSK/ri-shop/modules/taxes/taxesUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+)
+
+func TestTaxRateTable(t *testing.T) {
+	repo := &mocks.TaxesRepository{
+		FindRegionRateFn: func(ctx context.Context, country, state string) ([]*taxes.Rate, error) {
+			all := []*taxes.Rate{
+				{Id: 1, Name: "vat", Country: "TH", Rate: 0.07},
+				{Id: 2, Name: "books", Country: "TH", CategoryId: 3, Rate: 0},
+				{Id: 3, Name: "state", Country: "US", State: "CA", Rate: 0.0725},
+			}
+			// เหมือน repository จริงที่คืนเฉพาะกฎของ region นั้น
+			rates := make([]*taxes.Rate, 0)
+			for _, rate := range all {
+				if (rate.Country == "" || rate.Country == country) && (rate.State == "" || rate.State == state) {
+					rates = append(rates, rate)
+				}
+			}
+			return rates, nil
+		},
+	}
+	provider := taxesUsecases.RateTableProvider(repo, 0.1)
+
+	breakdown, err := provider.Calculate(context.Background(), &taxes.CalculateReq{
+		Country: "TH",
+		Lines: []*taxes.CalculateLine{
+			{ProductId: "P1", CategoryId: 1, Amount: 99.99},
+			{ProductId: "P2", CategoryId: 3, Amount: 250},
+		},
+		ShippingFee: 50,
+	})
+	if err != nil {
+		t.Fatalf("calculate: %v", err)
+	}
+
+	expects := []struct {
+		name string
+		rate float64
+		tax  float64
+	}{
+		{name: "vat", rate: 0.07, tax: 7},
+		{name: "books", rate: 0, tax: 0},
+	}
+	for i, expect := range expects {
+		line := breakdown.Lines[i]
+		if line.Name != expect.name || line.Rate != expect.rate || line.Tax != expect.tax {
+			t.Errorf("line %d: expected: %+v, got: %+v", i, expect, line)
+		}
+	}
+	if breakdown.ShippingTax != 3.5 {
+		t.Errorf("shipping tax: expected: 3.5, got: %v", breakdown.ShippingTax)
+	}
+	if breakdown.Total != 10.5 {
+		t.Errorf("total: expected: 10.5, got: %v", breakdown.Total)
+	}
+}
+
+func TestTaxRateTableDefault(t *testing.T) {
+	repo := &mocks.TaxesRepository{
+		FindRegionRateFn: func(ctx context.Context, country, state string) ([]*taxes.Rate, error) {
+			return []*taxes.Rate{}, nil
+		},
+	}
+	provider := taxesUsecases.RateTableProvider(repo, 0.1)
+
+	breakdown, err := provider.Calculate(context.Background(), &taxes.CalculateReq{
+		Lines: []*taxes.CalculateLine{{ProductId: "P1", Amount: 10.05}},
+	})
+	if err != nil {
+		t.Fatalf("calculate: %v", err)
+	}
+	if breakdown.Lines[0].Rate != 0.1 || breakdown.Total != 1.01 {
+		t.Errorf("expected rate 0.1 and total 1.01, got: %+v", breakdown.Lines[0])
+	}
+}
+
+func TestTaxAllocateDiscount(t *testing.T) {
+	req := &taxes.CalculateReq{
+		Lines: []*taxes.CalculateLine{
+			{ProductId: "P000001", Amount: 100},
+			{ProductId: "P000002", Amount: 50},
+			{ProductId: "P000003", Amount: 50},
+		},
+	}
+	req.AllocateDiscount(10)
+
+	// 5 + 2.5 + 2.5, the last line take the rounding
+	for i, want := range []float64{95, 47.5, 47.5} {
+		if req.Lines[i].Amount != want {
+			t.Errorf("expected line %d to be %.2f, got: %.2f", i, want, req.Lines[i].Amount)
+		}
+	}
+
+	req.AllocateDiscount(500)
+	for _, line := range req.Lines {
+		if line.Amount != 0 {
+			t.Errorf("expected the discount to be at most the amount, got: %.2f", line.Amount)
+		}
+	}
+}
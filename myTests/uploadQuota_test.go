@@ -0,0 +1,42 @@
+package myTests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/files/filesRepositories"
+)
+
+func TestHarnessUploadUsage(t *testing.T) {
+	h := SetupHarness(t)
+	repo := filesRepositories.FilesRepository(h.Db)
+	userId := h.NewUser(t, 2)
+
+	steps := []struct {
+		add    int64
+		expect bool
+	}{
+		{add: 600, expect: true},
+		{add: 400, expect: true},
+		// 1000 is used, nothing more fit
+		{add: 1, expect: false},
+		{add: 2000, expect: false},
+	}
+	for i, step := range steps {
+		ok, err := repo.AddUploadUsage(context.Background(), userId, step.add, 1000)
+		if err != nil {
+			t.Fatalf("step %d: expected: %v, got: %v", i, nil, err)
+		}
+		if ok != step.expect {
+			t.Errorf("step %d: expected: %v, got: %v", i, step.expect, ok)
+		}
+	}
+
+	// a failed upload give its bytes back
+	if err := repo.ReleaseUploadUsage(context.Background(), userId, 400); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if ok, _ := repo.AddUploadUsage(context.Background(), userId, 400, 1000); !ok {
+		t.Errorf("expected the released bytes to be usable again")
+	}
+}
@@ -0,0 +1,79 @@
+package myTests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/views"
+	"github.com/NatthawutSK/ri-shop/modules/views/viewsUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+)
+
+func viewsProducts() *mocks.ProductsRepository {
+	all := map[string]*products.Products{
+		"P000001": {Id: "P000001", Title: "Coffee", Status: products.StatusPublished},
+		"P000002": {Id: "P000002", Title: "Tea", Status: products.StatusArchived},
+		"P000003": {Id: "P000003", Title: "Cocoa", Status: products.StatusPublished, StoreId: "other"},
+		"P000004": {Id: "P000004", Title: "Milk", Status: products.StatusPublished},
+	}
+	return &mocks.ProductsRepository{
+		FindOneProductFn: func(ctx context.Context, productId string) (*products.Products, error) {
+			if product, ok := all[productId]; ok {
+				return product, nil
+			}
+			return nil, fmt.Errorf("get product failed: sql: no rows in result set")
+		},
+	}
+}
+
+func TestRecentlyViewed(t *testing.T) {
+	productsUsecase := productsUsecases.ProductsUsecase(viewsProducts(), nil, cache.New[*products.Products](time.Minute))
+	repo := &mocks.ViewsRepository{
+		FindViewedProductIdFn: func(ctx context.Context, viewer, storeId string, limit int) ([]string, error) {
+			if limit != views.Capacity {
+				t.Errorf("expected limit: %v, got: %v", views.Capacity, limit)
+			}
+			return []string{"P000004", "P000002", "P000003", "P000009", "P000001"}, nil
+		},
+	}
+	usecase := viewsUsecases.ViewsUsecase(repo, productsUsecase)
+
+	// archived, another store and deleted products are skipped
+	result, err := usecase.FindRecentlyViewed(context.Background(), &views.RecentReq{Viewer: views.UserViewer("U000001"), Limit: 100})
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if len(result) != 2 || result[0].Id != "P000004" || result[1].Id != "P000001" {
+		t.Errorf("expected: [P000004 P000001], got: %v", result)
+	}
+}
+
+func TestRecordView(t *testing.T) {
+	productsUsecase := productsUsecases.ProductsUsecase(viewsProducts(), nil, cache.New[*products.Products](time.Minute))
+	repo := &mocks.ViewsRepository{
+		InsertViewFn: func(ctx context.Context, req *views.ViewReq) error { return nil },
+	}
+	usecase := viewsUsecases.ViewsUsecase(repo, productsUsecase)
+
+	if err := usecase.RecordView(context.Background(), &views.ViewReq{Viewer: views.SessionViewer("0f8e4c2b9a7d4e31"), ProductId: " P000001 "}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	for _, productId := range []string{"P000002", "P000003", "P000009"} {
+		err := usecase.RecordView(context.Background(), &views.ViewReq{Viewer: views.SessionViewer("0f8e4c2b9a7d4e31"), ProductId: productId})
+		if err == nil || err.Error() != "product not found" {
+			t.Errorf("%s expected: product not found, got: %v", productId, err)
+		}
+	}
+	if calls := repo.Calls("InsertView"); calls != 1 {
+		t.Errorf("expected: %v, got: %v", 1, calls)
+	}
+
+	if views.ValidSession("short") || !views.ValidSession("0f8e4c2b-9a7d-4e31") {
+		t.Errorf("session id validation is wrong")
+	}
+}
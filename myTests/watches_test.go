@@ -0,0 +1,108 @@
+package myTests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NatthawutSK/ri-shop/modules/notifications"
+	"github.com/NatthawutSK/ri-shop/modules/notifications/notificationsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/products/productsUsecases"
+	"github.com/NatthawutSK/ri-shop/modules/watches"
+	"github.com/NatthawutSK/ri-shop/modules/watches/watchesUsecases"
+	"github.com/NatthawutSK/ri-shop/myTests/mocks"
+	"github.com/NatthawutSK/ri-shop/pkg/cache"
+)
+
+func watchesProducts() productsUsecases.IProductsUsecase {
+	all := map[string]*products.Products{
+		"P000001": {Id: "P000001", Title: "Coffee", Price: 150, Stock: 0, Status: products.StatusPublished},
+		"P000002": {Id: "P000002", Title: "Tea", Price: 80, Stock: 5, Status: products.StatusPublished},
+	}
+	repo := &mocks.ProductsRepository{
+		FindOneProductFn: func(ctx context.Context, productId string) (*products.Products, error) {
+			if product, ok := all[productId]; ok {
+				return product, nil
+			}
+			return nil, fmt.Errorf("get product failed: sql: no rows in result set")
+		},
+	}
+	return productsUsecases.ProductsUsecase(repo, nil, cache.New[*products.Products](time.Minute))
+}
+
+func TestAddWatch(t *testing.T) {
+	repo := &mocks.WatchesRepository{
+		CountActiveWatchFn: func(ctx context.Context, userId string) (int, error) { return 0, nil },
+		InsertWatchFn: func(ctx context.Context, req *watches.WatchReq, watchedPrice float64) (string, error) {
+			if watchedPrice != 150 {
+				t.Errorf("expected watched price: 150, got: %v", watchedPrice)
+			}
+			return "w1", nil
+		},
+		FindOneWatchFn: func(ctx context.Context, watchId string) (*watches.Watch, error) {
+			return &watches.Watch{Id: watchId, Status: watches.StatusActive}, nil
+		},
+	}
+	usecase := watchesUsecases.WatchesUsecase(repo, watchesProducts(), nil)
+
+	target := 200.0
+	tests := []struct {
+		req      *watches.WatchReq
+		expected string
+	}{
+		{&watches.WatchReq{ProductId: "P000002", Kind: watches.KindBackInStock}, "product is in stock"},
+		{&watches.WatchReq{ProductId: "P000001", Kind: watches.KindPriceDrop, TargetPrice: &target}, "target price must be below the current price"},
+		{&watches.WatchReq{ProductId: "P000001", Kind: "restock"}, "kind is invalid"},
+		{&watches.WatchReq{ProductId: "P000009", Kind: watches.KindBackInStock}, "product not found"},
+	}
+	for _, test := range tests {
+		if _, err := usecase.AddWatch(context.Background(), test.req); err == nil || err.Error() != test.expected {
+			t.Errorf("expected: %v, got: %v", test.expected, err)
+		}
+	}
+	if repo.Calls("InsertWatch") != 0 {
+		t.Fatalf("expected no watch for an invalid request, got: %d", repo.Calls("InsertWatch"))
+	}
+
+	watch, err := usecase.AddWatch(context.Background(), &watches.WatchReq{UserId: "U000001", ProductId: "P000001", Kind: watches.KindBackInStock})
+	if err != nil || watch.Id != "w1" {
+		t.Errorf("expected watch w1, got: %v, %v", watch, err)
+	}
+}
+
+func TestCheckWatch(t *testing.T) {
+	repo := &mocks.WatchesRepository{
+		FindDueWatchFn: func(ctx context.Context, productId string, limit int) ([]*watches.Watch, error) {
+			return []*watches.Watch{
+				{Id: "w1", UserId: "U000001", ProductId: "P000001", Title: "Coffee", Kind: watches.KindBackInStock},
+				{Id: "w2", UserId: "U000002", ProductId: "P000001", Title: "Coffee", Kind: watches.KindPriceDrop, Price: 120, Currency: "THB"},
+				{Id: "w3", UserId: "U000003", ProductId: "P000001", Title: "Coffee", Kind: watches.KindBackInStock},
+			}, nil
+		},
+		// อีก instance แจ้ง w3 ไปแล้ว
+		MarkWatchNotifiedFn: func(ctx context.Context, watchId string) (bool, error) { return watchId != "w3", nil },
+	}
+	inbox := inboxRepository(nil)
+	sent := make([]*notifications.Notification, 0)
+	inbox.InsertNotificationFn = func(ctx context.Context, req *notifications.Notification) error {
+		sent = append(sent, req)
+		return nil
+	}
+	usecase := watchesUsecases.WatchesUsecase(repo, nil, notificationsUsecases.NotificationsUsecase(nil, inbox))
+
+	notified, err := usecase.CheckProduct(context.Background(), "P000001")
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if notified != 2 || len(sent) != 2 {
+		t.Fatalf("expected 2 notifications, got: %d (%d sent)", notified, len(sent))
+	}
+	if sent[0].Type != notifications.TypeBackInStock || sent[1].Type != notifications.TypePriceDrop {
+		t.Errorf("expected back in stock then price drop, got: %s, %s", sent[0].Type, sent[1].Type)
+	}
+	if sent[1].Body != "Coffee is now 120 THB." {
+		t.Errorf("expected: Coffee is now 120 THB., got: %s", sent[1].Body)
+	}
+}
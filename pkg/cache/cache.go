@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache keep values in memory for ttl, it is shared by every instance of a module so a write
+// through one instance is not served stale by another. A ttl of 0 disables it
+type Cache[V any] struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]*entry[V]
+}
+
+func New[V any](ttl time.Duration) *Cache[V] {
+	return &Cache[V]{
+		ttl:     ttl,
+		entries: make(map[string]*entry[V]),
+	}
+}
+
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.RLock()
+	cached, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok || !time.Now().Before(cached.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return cached.value, true
+}
+
+func (c *Cache[V]) Set(key string, value V) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	c.entries[key] = &entry[V]{
+		value:     value,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+	c.mu.Unlock()
+}
+
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// Clear drop every value, e.g. after a write which change many keys
+func (c *Cache[V]) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]*entry[V])
+	c.mu.Unlock()
+}
+
+// Len is the number of values including the expired ones which were not read again
+func (c *Cache[V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.entries)
+}
@@ -0,0 +1,68 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time of time dependent usecases, integration tests move a TestClock instead of sleeping
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+// Real is the system clock, production always use it
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// TestClock is the system clock shifted by an offset, so time keeps moving after it is set
+type TestClock struct {
+	mu     sync.RWMutex
+	offset time.Duration
+}
+
+func NewTestClock() *TestClock {
+	return &TestClock{}
+}
+
+func (c *TestClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return time.Now().Add(c.offset)
+}
+
+// Advance move the clock forward by d, a negative d move it back
+func (c *TestClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.offset += d
+}
+
+// Set move the clock to t
+func (c *TestClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.offset = time.Until(t)
+}
+
+// Reset go back to the system clock
+func (c *TestClock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.offset = 0
+}
+
+func (c *TestClock) Offset() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.offset
+}
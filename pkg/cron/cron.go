@@ -0,0 +1,159 @@
+// Package cron parse the schedules of the background tasks: the 5 fields of crontab (minute hour day month
+// weekday) with *, lists, ranges and steps, or @hourly, @daily, @weekly, @monthly and @every <duration>
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	// Next return the first time after t the schedule is due
+	Next(t time.Time) time.Time
+}
+
+// Parse return the schedule of expr, e.g. "*/5 * * * *", "30 3 * * 1-5" or "@every 90s"
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	switch expr {
+	case "@hourly":
+		expr = "0 * * * *"
+	case "@daily", "@midnight":
+		expr = "0 0 * * *"
+	case "@weekly":
+		expr = "0 0 * * 0"
+	case "@monthly":
+		expr = "0 0 1 * *"
+	}
+
+	if every, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q is invalid: %v", expr, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("schedule %q is invalid: the interval must be at least 1s", expr)
+		}
+		return &everySchedule{interval: d}, nil
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q is invalid: expect 5 fields, got %d", expr, len(fields))
+	}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]uint64{}
+	for i, field := range fields {
+		set, err := parseField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q is invalid: %v", expr, err)
+		}
+		sets[i] = set
+	}
+	// 7 เป็นวันอาทิตย์เหมือน 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &cronSchedule{
+		minute:  sets[0],
+		hour:    sets[1],
+		day:     sets[2],
+		month:   sets[3],
+		weekday: sets[4],
+		anyDay:  fields[2] == "*",
+		anyWeek: fields[4] == "*",
+	}, nil
+}
+
+// parseField return the values of a field as bits, e.g. "1-5" or "*/15" or "0,30"
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		valueRange, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("step %q is invalid", stepText)
+			}
+			step = n
+		}
+
+		from, to := min, max
+		if valueRange != "*" {
+			fromText, toText, isRange := strings.Cut(valueRange, "-")
+			n, err := strconv.Atoi(fromText)
+			if err != nil {
+				return 0, fmt.Errorf("value %q is invalid", fromText)
+			}
+			from, to = n, n
+			if isRange {
+				if to, err = strconv.Atoi(toText); err != nil {
+					return 0, fmt.Errorf("value %q is invalid", toText)
+				}
+			} else if hasStep {
+				// "5/15" is from 5 to the end
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("%q is out of %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s *everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval).Truncate(time.Second)
+}
+
+type cronSchedule struct {
+	minute, hour, day, month, weekday uint64
+	// like crontab, when both the day and the weekday are set a time matching either of them is due
+	anyDay, anyWeek bool
+}
+
+func (s *cronSchedule) dayMatch(t time.Time) bool {
+	day := s.day&(1<<uint(t.Day())) != 0
+	weekday := s.weekday&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeek {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// a schedule which is never due, e.g. "0 0 31 2 *", stop after 5 years
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatch(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
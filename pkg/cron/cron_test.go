@@ -0,0 +1,58 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// วันพุธ
+	from := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr   string
+		expect time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 5, 16, 3, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, 5, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"5,10 */6 * * *", time.Date(2024, 5, 15, 12, 5, 0, 0, time.UTC)},
+		// the day or the weekday, like crontab
+		{"0 0 20 * 5", time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2024, 5, 15, 10, 9, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", test.expr, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(test.expect) {
+			t.Errorf("%q: expected %v, got %v", test.expr, test.expect, got)
+		}
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	schedule, err := Parse("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schedule.Next(time.Now()); !got.IsZero() {
+		t.Errorf("expected no time, got %v", got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every 1ms", "@every soon"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
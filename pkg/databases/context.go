@@ -0,0 +1,18 @@
+package databases
+
+import (
+	"context"
+	"time"
+)
+
+// QueryTimeout is the deadline of a query whose context has none, e.g. the query of a task
+const QueryTimeout = 15 * time.Second
+
+// WithQueryTimeout add QueryTimeout to ctx when it has no deadline, the queries of a request stop at the
+// budget of its route group instead
+func WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, QueryTimeout)
+}
@@ -1,19 +1,40 @@
 package databases
 
 import (
+	"context"
+	"database/sql"
 	"log"
 
 	"github.com/NatthawutSK/ri-shop/config"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 )
 
 func DbConnect(cfg config.IDbConfig) *sqlx.DB {
-	// Connect
-	db, err := sqlx.Connect("pgx", cfg.Url())
+	connConfig, err := pgx.ParseConfig(cfg.Url())
 	if err != nil {
+		log.Fatalf("parse db url failed: %v\n", err)
+	}
+
+	if cfg.SlowQuery() > 0 {
+		connConfig.Tracer = &slowQueryTracer{threshold: cfg.SlowQuery()}
+	}
+
+	// password อาจถูก rotate จาก secrets provider, connection ใหม่ใช้ password ล่าสุด
+	connector := stdlib.GetConnector(*connConfig, stdlib.OptionBeforeConnect(func(ctx context.Context, cc *pgx.ConnConfig) error {
+		cc.Password = cfg.Password()
+		return nil
+	}))
+
+	// Connect
+	db := sqlx.NewDb(sql.OpenDB(connector), "pgx")
+	if err := db.Ping(); err != nil {
 		log.Fatalf("connect to db failed: %v\n", err)
 	}
 	db.DB.SetMaxOpenConns(cfg.MaxOpenConns())
+	db.DB.SetMaxIdleConns(cfg.MaxIdleConns())
+	db.DB.SetConnMaxLifetime(cfg.ConnMaxLifetime())
+	db.DB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime())
 	return db
-}
\ No newline at end of file
+}
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "inventory_forecasts" CASCADE;
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "supplier_id";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "stock";
+
+DROP TABLE IF EXISTS "suppliers" CASCADE;
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+--Stock on hand per product
+ALTER TABLE "products" ADD COLUMN "stock" INT NOT NULL DEFAULT 0;
+
+CREATE TABLE "suppliers" (
+  "id" SERIAL PRIMARY KEY,
+  "title" VARCHAR UNIQUE NOT NULL,
+  "contact" VARCHAR NOT NULL DEFAULT ''
+);
+
+--Preferred supplier used by the reorder suggestion report
+ALTER TABLE "products" ADD COLUMN "supplier_id" INT;
+
+--Refreshed by the inventory forecast job
+CREATE TABLE "inventory_forecasts" (
+  "product_id" VARCHAR PRIMARY KEY,
+  "sales_velocity" FLOAT NOT NULL DEFAULT 0,
+  "days_of_stock" FLOAT,
+  "computed_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "products" ADD FOREIGN KEY ("supplier_id") REFERENCES "suppliers" ("id") ON DELETE SET NULL;
+ALTER TABLE "inventory_forecasts" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE "audit_logs" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "actor_id" VARCHAR NOT NULL,
+  "action" VARCHAR NOT NULL,
+  "entity" VARCHAR NOT NULL,
+  "entity_id" VARCHAR NOT NULL,
+  "before" jsonb,
+  "after" jsonb,
+  "diff" jsonb,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "audit_logs_actor_id_idx" ON "audit_logs" ("actor_id");
+CREATE INDEX "audit_logs_entity_idx" ON "audit_logs" ("entity", "entity_id");
+CREATE INDEX "audit_logs_created_at_idx" ON "audit_logs" ("created_at");
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "images_url_idx";
+DROP TABLE IF EXISTS "files" CASCADE;
+
+DROP EXTENSION IF EXISTS "vector";
+
+COMMIT;
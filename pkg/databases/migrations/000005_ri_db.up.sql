@@ -0,0 +1,19 @@
+BEGIN;
+
+--Install pgvector extension
+CREATE EXTENSION IF NOT EXISTS "vector";
+
+--Metadata of every uploaded file
+CREATE TABLE "files" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "filename" VARCHAR NOT NULL,
+  "destination" VARCHAR NOT NULL,
+  "url" VARCHAR UNIQUE NOT NULL,
+  "embedding" vector(64),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "files_embedding_idx" ON "files" USING hnsw ("embedding" vector_l2_ops);
+CREATE INDEX "images_url_idx" ON "images" ("url");
+
+COMMIT;
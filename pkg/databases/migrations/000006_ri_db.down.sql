@@ -0,0 +1,18 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_exchange_rates_table ON "exchange_rates";
+
+DROP TABLE IF EXISTS "exchange_rates" CASCADE;
+DROP TABLE IF EXISTS "product_prices" CASCADE;
+
+ALTER TABLE "products" ADD COLUMN "price" FLOAT NOT NULL DEFAULT 0;
+UPDATE "products" SET "price" = minor_to_major("price_minor", "currency");
+ALTER TABLE "products" DROP COLUMN IF EXISTS "price_minor";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "currency";
+
+DROP FUNCTION IF EXISTS minor_to_major(BIGINT, VARCHAR);
+DROP FUNCTION IF EXISTS major_to_minor(FLOAT, VARCHAR);
+
+DROP TABLE IF EXISTS "currencies" CASCADE;
+
+COMMIT;
@@ -0,0 +1,60 @@
+BEGIN;
+
+--minor_unit is the number of decimals of the currency, THB 2 -> 150.00 is stored as 15000
+CREATE TABLE "currencies" (
+  "code" VARCHAR(3) PRIMARY KEY,
+  "minor_unit" INT NOT NULL DEFAULT 2
+);
+
+INSERT INTO "currencies" (
+    "code",
+    "minor_unit"
+)
+VALUES
+    ('THB', 2),
+    ('USD', 2),
+    ('EUR', 2),
+    ('JPY', 0);
+
+CREATE OR REPLACE FUNCTION minor_to_major(amount BIGINT, currency VARCHAR)
+RETURNS FLOAT AS $$
+    SELECT amount::FLOAT / POWER(10, "minor_unit") FROM "currencies" WHERE "code" = currency;
+$$ language 'sql' STABLE;
+
+CREATE OR REPLACE FUNCTION major_to_minor(amount FLOAT, currency VARCHAR)
+RETURNS BIGINT AS $$
+    SELECT ROUND(amount * POWER(10, "minor_unit"))::BIGINT FROM "currencies" WHERE "code" = currency;
+$$ language 'sql' STABLE;
+
+--Prices are stored in minor units with their currency
+ALTER TABLE "products" ADD COLUMN "currency" VARCHAR(3) NOT NULL DEFAULT 'THB';
+ALTER TABLE "products" ADD COLUMN "price_minor" BIGINT NOT NULL DEFAULT 0;
+UPDATE "products" SET "price_minor" = major_to_minor("price", "currency");
+ALTER TABLE "products" DROP COLUMN "price";
+
+--Per currency price which is used instead of converting the base price
+CREATE TABLE "product_prices" (
+  "product_id" VARCHAR NOT NULL,
+  "currency" VARCHAR(3) NOT NULL,
+  "price_minor" BIGINT NOT NULL,
+  PRIMARY KEY ("product_id", "currency")
+);
+
+--1 base = rate quote
+CREATE TABLE "exchange_rates" (
+  "base" VARCHAR(3) NOT NULL,
+  "quote" VARCHAR(3) NOT NULL,
+  "rate" FLOAT NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("base", "quote")
+);
+
+ALTER TABLE "products" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+ALTER TABLE "product_prices" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "product_prices" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON DELETE CASCADE;
+ALTER TABLE "exchange_rates" ADD FOREIGN KEY ("base") REFERENCES "currencies" ("code") ON DELETE CASCADE;
+ALTER TABLE "exchange_rates" ADD FOREIGN KEY ("quote") REFERENCES "currencies" ("code") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_exchange_rates_table BEFORE UPDATE ON "exchange_rates" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
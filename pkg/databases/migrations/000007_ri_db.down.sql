@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "products_orders_order_id_idx";
+DROP TABLE IF EXISTS "recommendations" CASCADE;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+--Refreshed by the recommendations job
+CREATE TABLE "recommendations" (
+  "product_id" VARCHAR NOT NULL,
+  "recommended_id" VARCHAR NOT NULL,
+  "kind" VARCHAR NOT NULL DEFAULT 'frequently_bought_together',
+  "score" INT NOT NULL DEFAULT 0,
+  "computed_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("product_id", "recommended_id", "kind")
+);
+
+ALTER TABLE "recommendations" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "recommendations" ADD FOREIGN KEY ("recommended_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "products_orders_order_id_idx" ON "products_orders" ("order_id");
+
+COMMIT;
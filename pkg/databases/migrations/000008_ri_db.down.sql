@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "order_hooks" CASCADE;
+DROP TYPE IF EXISTS "hook_action";
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "on_hold";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tags";
+
+COMMIT;
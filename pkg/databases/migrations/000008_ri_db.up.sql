@@ -0,0 +1,26 @@
+BEGIN;
+
+ALTER TABLE "orders" ADD COLUMN "tags" VARCHAR[] NOT NULL DEFAULT '{}';
+ALTER TABLE "orders" ADD COLUMN "on_hold" BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TYPE "hook_action" AS ENUM (
+    'webhook',
+    'add_tag',
+    'send_template',
+    'hold_for_review'
+);
+
+--from_status null means any status
+CREATE TABLE "order_hooks" (
+  "id" SERIAL PRIMARY KEY,
+  "from_status" order_status,
+  "to_status" order_status NOT NULL,
+  "action" hook_action NOT NULL,
+  "params" jsonb NOT NULL DEFAULT '{}',
+  "enabled" BOOLEAN NOT NULL DEFAULT TRUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "order_hooks_to_status_idx" ON "order_hooks" ("to_status");
+
+COMMIT;
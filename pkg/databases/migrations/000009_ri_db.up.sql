@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE "addresses" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR NOT NULL,
+  "recipient" VARCHAR NOT NULL,
+  "phone" VARCHAR NOT NULL,
+  "line1" VARCHAR NOT NULL,
+  "line2" VARCHAR NOT NULL DEFAULT '',
+  "city" VARCHAR NOT NULL,
+  "state" VARCHAR NOT NULL DEFAULT '',
+  "postal_code" VARCHAR NOT NULL,
+  "country" VARCHAR(2) NOT NULL,
+  "is_default" BOOLEAN NOT NULL DEFAULT FALSE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "addresses" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+--One default address per user
+CREATE UNIQUE INDEX "addresses_user_id_default_idx" ON "addresses" ("user_id") WHERE "is_default";
+
+CREATE TRIGGER set_updated_at_timestamp_addresses_table BEFORE UPDATE ON "addresses" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Copy of the address at checkout, the address itself can be edited or deleted later
+ALTER TABLE "orders" ADD COLUMN "shipping_address" jsonb;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "messages" CASCADE;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "locale";
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "locale" VARCHAR(10) NOT NULL DEFAULT 'en';
+
+--Override the embedded message files without a deploy
+CREATE TABLE "messages" (
+  "locale" VARCHAR(10) NOT NULL,
+  "key" VARCHAR NOT NULL,
+  "message" VARCHAR NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("locale", "key")
+);
+
+COMMIT;
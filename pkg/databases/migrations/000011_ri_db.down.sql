@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "legacy_orders" CASCADE;
+DROP TABLE IF EXISTS "legacy_users" CASCADE;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "password_reset_required";
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+--Imported users without a usable password hash must reset it before signing in
+ALTER TABLE "users" ADD COLUMN "password_reset_required" BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE "legacy_users" (
+  "user_id" VARCHAR PRIMARY KEY,
+  "legacy_id" VARCHAR UNIQUE NOT NULL
+);
+
+CREATE TABLE "legacy_orders" (
+  "legacy_ref" VARCHAR PRIMARY KEY,
+  "user_id" VARCHAR NOT NULL
+);
+
+ALTER TABLE "legacy_users" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "legacy_orders" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+COMMIT;
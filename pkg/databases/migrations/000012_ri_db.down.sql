@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tracking_number";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "shipping_fee";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "shipping_method";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "weight_grams";
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+--Used to weigh parcels for carrier rates
+ALTER TABLE "products" ADD COLUMN "weight_grams" INT NOT NULL DEFAULT 0;
+
+ALTER TABLE "orders" ADD COLUMN "shipping_method" VARCHAR;
+ALTER TABLE "orders" ADD COLUMN "shipping_fee" FLOAT NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN "tracking_number" VARCHAR;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "order_status_history" CASCADE;
+
+--Enum values cannot be dropped, paid orders go back to waiting and the value is left in the type
+UPDATE "orders" SET "status" = 'waiting' WHERE "status" = 'paid';
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+--Needs PostgreSQL 12+ to add an enum value inside a transaction
+ALTER TYPE "order_status" ADD VALUE IF NOT EXISTS 'paid' AFTER 'waiting';
+
+CREATE TABLE "order_status_history" (
+  "id" SERIAL PRIMARY KEY,
+  "order_id" VARCHAR NOT NULL,
+  "from_status" VARCHAR NOT NULL,
+  "to_status" VARCHAR NOT NULL,
+  "actor_id" VARCHAR NOT NULL,
+  "refund_required" BOOLEAN NOT NULL DEFAULT FALSE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "order_status_history" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "order_status_history_order_id_idx" ON "order_status_history" ("order_id");
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_cancellation_requests_table ON "cancellation_requests";
+
+DROP TABLE IF EXISTS "cancellation_requests" CASCADE;
+DROP TABLE IF EXISTS "refunds" CASCADE;
+
+DROP TYPE IF EXISTS "cancellation_status";
+DROP TYPE IF EXISTS "refund_status";
+
+COMMIT;
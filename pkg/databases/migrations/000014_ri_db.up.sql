@@ -0,0 +1,49 @@
+BEGIN;
+
+CREATE TYPE "refund_status" AS ENUM (
+  'pending',
+  'succeeded',
+  'failed'
+);
+
+CREATE TYPE "cancellation_status" AS ENUM (
+  'pending',
+  'approved',
+  'rejected'
+);
+
+CREATE TABLE "refunds" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "amount" FLOAT NOT NULL,
+  "reason" VARCHAR NOT NULL DEFAULT '',
+  "status" refund_status NOT NULL,
+  "gateway_ref" VARCHAR NOT NULL DEFAULT '',
+  "restocked" BOOLEAN NOT NULL DEFAULT FALSE,
+  "actor_id" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "cancellation_requests" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "user_id" VARCHAR NOT NULL,
+  "reason" VARCHAR NOT NULL DEFAULT '',
+  "status" cancellation_status NOT NULL DEFAULT 'pending',
+  "reviewed_by" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "refunds" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "cancellation_requests" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "cancellation_requests" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "refunds_order_id_idx" ON "refunds" ("order_id");
+
+--One open cancellation request per order
+CREATE UNIQUE INDEX "cancellation_requests_pending_idx" ON "cancellation_requests" ("order_id") WHERE "status" = 'pending';
+
+CREATE TRIGGER set_updated_at_timestamp_cancellation_requests_table BEFORE UPDATE ON "cancellation_requests" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_promotions_table ON "promotions";
+
+DROP TABLE IF EXISTS "promotions" CASCADE;
+
+DROP TYPE IF EXISTS "discount_kind";
+
+COMMIT;
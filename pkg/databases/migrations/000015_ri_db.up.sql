@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TYPE "discount_kind" AS ENUM (
+  'percent',
+  'fixed'
+);
+
+--A promotion without code is applied automatically, with code it is a coupon
+CREATE TABLE "promotions" (
+  "id" SERIAL PRIMARY KEY,
+  "code" VARCHAR UNIQUE,
+  "name" VARCHAR NOT NULL,
+  "kind" discount_kind NOT NULL,
+  "value" FLOAT NOT NULL CHECK ("value" > 0),
+  "min_subtotal" FLOAT NOT NULL DEFAULT 0,
+  "starts_at" TIMESTAMP,
+  "ends_at" TIMESTAMP,
+  "active" BOOLEAN NOT NULL DEFAULT TRUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER set_updated_at_timestamp_promotions_table BEFORE UPDATE ON "promotions" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
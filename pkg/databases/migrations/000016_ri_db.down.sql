@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_email_queue_table ON "email_queue";
+
+DROP TABLE IF EXISTS "email_queue" CASCADE;
+
+DROP TYPE IF EXISTS "email_status";
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TYPE "email_status" AS ENUM (
+  'queued',
+  'sending',
+  'sent',
+  'failed'
+);
+
+CREATE TABLE "email_queue" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "to_address" VARCHAR NOT NULL,
+  "subject" VARCHAR NOT NULL,
+  "body" TEXT NOT NULL,
+  "status" email_status NOT NULL DEFAULT 'queued',
+  "attempts" INT NOT NULL DEFAULT 0,
+  "provider" VARCHAR,
+  "last_error" VARCHAR,
+  "next_attempt_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "sent_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "email_queue_due_idx" ON "email_queue" ("next_attempt_at") WHERE "status" = 'queued';
+
+CREATE TRIGGER set_updated_at_timestamp_email_queue_table BEFORE UPDATE ON "email_queue" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS product_badges(VARCHAR);
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_badges_table ON "badges";
+
+DROP TABLE IF EXISTS "products_badges" CASCADE;
+DROP TABLE IF EXISTS "badges" CASCADE;
+
+DROP TYPE IF EXISTS "badge_rule";
+
+COMMIT;
@@ -0,0 +1,63 @@
+BEGIN;
+
+CREATE TYPE "badge_rule" AS ENUM (
+  'best_seller',
+  'new_arrival'
+);
+
+--A badge with rule is given automatically, rule_value is the top N sellers or the days since created
+CREATE TABLE "badges" (
+  "id" SERIAL PRIMARY KEY,
+  "code" VARCHAR UNIQUE NOT NULL,
+  "title" VARCHAR NOT NULL,
+  "color" VARCHAR NOT NULL DEFAULT '',
+  "rule" badge_rule,
+  "rule_value" INT NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+--Badges assigned by hand
+CREATE TABLE "products_badges" (
+  "product_id" VARCHAR NOT NULL,
+  "badge_id" INT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("product_id", "badge_id")
+);
+
+ALTER TABLE "products_badges" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "products_badges" ADD FOREIGN KEY ("badge_id") REFERENCES "badges" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_badges_table BEFORE UPDATE ON "badges" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Manual and rule badges of a product, best sellers are ranked by the sales velocity of the inventory forecast job
+CREATE OR REPLACE FUNCTION product_badges(pid VARCHAR)
+RETURNS json AS $$
+    SELECT
+        COALESCE(array_to_json(array_agg("bt" ORDER BY "bt"."id")), '[]'::json)
+    FROM (
+        SELECT
+            "b"."id",
+            "b"."code",
+            "b"."title",
+            "b"."color"
+        FROM "badges" "b"
+        WHERE EXISTS (
+            SELECT 1 FROM "products_badges" "pb" WHERE "pb"."badge_id" = "b"."id" AND "pb"."product_id" = pid
+        )
+        OR (
+            "b"."rule" = 'new_arrival'
+            AND EXISTS (
+                SELECT 1 FROM "products" "p" WHERE "p"."id" = pid AND "p"."created_at" >= now() - make_interval(days => "b"."rule_value")
+            )
+        )
+        OR (
+            "b"."rule" = 'best_seller'
+            AND pid IN (
+                SELECT "f"."product_id" FROM "inventory_forecasts" "f" WHERE "f"."sales_velocity" > 0 ORDER BY "f"."sales_velocity" DESC LIMIT "b"."rule_value"
+            )
+        )
+    ) AS "bt";
+$$ language 'sql' STABLE;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "gift_token";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "gift";
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+--Recipient, message and invoice option of a gift order, gift_token let the recipient track the order
+ALTER TABLE "orders" ADD COLUMN "gift" jsonb;
+ALTER TABLE "orders" ADD COLUMN "gift_token" VARCHAR UNIQUE;
+
+COMMIT;
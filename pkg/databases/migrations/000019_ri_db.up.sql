@@ -0,0 +1,18 @@
+BEGIN;
+
+--Stock held for another service, e.g. the warehouse, a hold stops counting once it expires
+CREATE TABLE "stock_reservations" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "reference" VARCHAR NOT NULL,
+  "product_id" VARCHAR NOT NULL,
+  "qty" INT NOT NULL CHECK ("qty" > 0),
+  "expires_at" TIMESTAMP NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "stock_reservations" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "stock_reservations_reference_idx" ON "stock_reservations" ("reference");
+CREATE INDEX "stock_reservations_product_id_expires_at_idx" ON "stock_reservations" ("product_id", "expires_at");
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+--A value cannot be removed from an enum, orders ready for pickup go back to paid
+UPDATE "orders" SET "status" = 'paid' WHERE "status" = 'ready_for_pickup';
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "pickup_code";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "pickup_slot_id";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "fulfillment";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_pickup_locations_table ON "pickup_locations";
+
+DROP TABLE IF EXISTS "pickup_slots";
+DROP TABLE IF EXISTS "pickup_locations";
+
+COMMIT;
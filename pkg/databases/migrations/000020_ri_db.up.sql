@@ -0,0 +1,41 @@
+BEGIN;
+
+ALTER TYPE "order_status" ADD VALUE IF NOT EXISTS 'ready_for_pickup' AFTER 'shipping';
+
+CREATE TABLE "pickup_locations" (
+  "id" SERIAL PRIMARY KEY,
+  "title" VARCHAR NOT NULL,
+  "address" VARCHAR NOT NULL,
+  "phone" VARCHAR NOT NULL DEFAULT '',
+  "active" BOOLEAN NOT NULL DEFAULT TRUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+--A slot takes up to capacity orders which are not canceled
+CREATE TABLE "pickup_slots" (
+  "id" SERIAL PRIMARY KEY,
+  "location_id" INT NOT NULL,
+  "starts_at" TIMESTAMP NOT NULL,
+  "ends_at" TIMESTAMP NOT NULL,
+  "capacity" INT NOT NULL CHECK ("capacity" > 0),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("ends_at" > "starts_at")
+);
+
+ALTER TABLE "pickup_slots" ADD FOREIGN KEY ("location_id") REFERENCES "pickup_locations" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "pickup_slots_location_id_starts_at_idx" ON "pickup_slots" ("location_id", "starts_at");
+
+--pickup_code is given when the order is ready for pickup, staff check it before handing the order over
+ALTER TABLE "orders" ADD COLUMN "fulfillment" VARCHAR NOT NULL DEFAULT 'delivery';
+ALTER TABLE "orders" ADD COLUMN "pickup_slot_id" INT;
+ALTER TABLE "orders" ADD COLUMN "pickup_code" VARCHAR;
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("pickup_slot_id") REFERENCES "pickup_slots" ("id");
+
+CREATE INDEX "orders_pickup_slot_id_idx" ON "orders" ("pickup_slot_id");
+
+CREATE TRIGGER set_updated_at_timestamp_pickup_locations_table BEFORE UPDATE ON "pickup_locations" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
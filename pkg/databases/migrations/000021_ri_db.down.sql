@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS product_ships_to(VARCHAR, VARCHAR, VARCHAR);
+
+DROP TABLE IF EXISTS "product_regions" CASCADE;
+
+COMMIT;
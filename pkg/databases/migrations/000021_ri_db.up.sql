@@ -0,0 +1,31 @@
+BEGIN;
+
+--A product without regions ships everywhere, a region without state is the whole country
+CREATE TABLE "product_regions" (
+  "id" SERIAL PRIMARY KEY,
+  "product_id" VARCHAR NOT NULL,
+  "country" VARCHAR(2) NOT NULL,
+  "state" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "product_regions" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "product_regions_product_id_country_state_idx" ON "product_regions" ("product_id", "country", LOWER(COALESCE("state", '')));
+
+--Same rule as Products.ShipsTo in go
+CREATE OR REPLACE FUNCTION product_ships_to(pid VARCHAR, ship_country VARCHAR, ship_state VARCHAR)
+RETURNS BOOLEAN AS $$
+    SELECT
+        NOT EXISTS (
+            SELECT 1 FROM "product_regions" "r" WHERE "r"."product_id" = pid
+        )
+        OR EXISTS (
+            SELECT 1 FROM "product_regions" "r"
+            WHERE "r"."product_id" = pid
+            AND "r"."country" = UPPER(ship_country)
+            AND ("r"."state" IS NULL OR LOWER("r"."state") = LOWER(COALESCE(ship_state, '')))
+        );
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
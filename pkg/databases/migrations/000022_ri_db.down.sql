@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "status";
+
+DROP TYPE IF EXISTS "product_status";
+
+COMMIT;
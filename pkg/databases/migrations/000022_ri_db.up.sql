@@ -0,0 +1,13 @@
+BEGIN;
+
+--Customers only see published products, drafts are reviewed with a preview token
+CREATE TYPE "product_status" AS ENUM (
+  'draft',
+  'published'
+);
+
+ALTER TABLE "products" ADD COLUMN "status" product_status NOT NULL DEFAULT 'published';
+
+CREATE INDEX "products_status_idx" ON "products" ("status");
+
+COMMIT;
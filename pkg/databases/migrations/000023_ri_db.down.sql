@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "commission_rate";
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "seller_id";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "seller_id";
+
+DROP TABLE IF EXISTS "sellers" CASCADE;
+
+DROP TYPE IF EXISTS "seller_status";
+
+UPDATE "users" SET "role_id" = 1 WHERE "role_id" = 4;
+
+DELETE FROM "roles" WHERE "id" = 4;
+
+COMMIT;
@@ -0,0 +1,46 @@
+BEGIN;
+
+--Sellers list their own products on the marketplace, role id is a bit of the authorize mask
+INSERT INTO "roles" (
+    "id",
+    "title"
+)
+VALUES
+    (4, 'seller');
+
+CREATE TYPE "seller_status" AS ENUM (
+  'pending',
+  'approved',
+  'rejected',
+  'suspended'
+);
+
+CREATE TABLE "sellers" (
+  "user_id" VARCHAR PRIMARY KEY,
+  "shop_name" VARCHAR UNIQUE NOT NULL,
+  "description" VARCHAR NOT NULL DEFAULT '',
+  "bank_account" VARCHAR NOT NULL,
+  "status" seller_status NOT NULL DEFAULT 'pending',
+  "commission_rate" FLOAT NOT NULL DEFAULT 0.1 CHECK ("commission_rate" >= 0 AND "commission_rate" <= 1),
+  "note" VARCHAR NOT NULL DEFAULT '',
+  "approved_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "sellers" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_sellers_table BEFORE UPDATE ON "sellers" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--NULL is a product of the shop itself
+ALTER TABLE "products" ADD COLUMN "seller_id" VARCHAR REFERENCES "sellers" ("user_id") ON DELETE SET NULL;
+
+CREATE INDEX "products_seller_id_idx" ON "products" ("seller_id");
+
+--commission rate is kept at the time of the sale, changing the rate later does not change old orders
+ALTER TABLE "products_orders" ADD COLUMN "seller_id" VARCHAR;
+ALTER TABLE "products_orders" ADD COLUMN "commission_rate" FLOAT NOT NULL DEFAULT 0;
+
+CREATE INDEX "products_orders_seller_id_idx" ON "products_orders" ("seller_id");
+
+COMMIT;
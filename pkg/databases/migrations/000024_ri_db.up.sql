@@ -0,0 +1,6 @@
+BEGIN;
+
+--Increased on every edit of admin or seller, an update must carry the version it read
+ALTER TABLE "products" ADD COLUMN "version" INT NOT NULL DEFAULT 1;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "payouts" CASCADE;
+DROP TABLE IF EXISTS "payout_batches" CASCADE;
+
+DROP TYPE IF EXISTS "payout_status";
+
+COMMIT;
@@ -0,0 +1,40 @@
+BEGIN;
+
+--Money owed to sellers is paid in batches, one payout per seller in a batch
+CREATE TYPE "payout_status" AS ENUM (
+  'pending',
+  'paid',
+  'failed'
+);
+
+CREATE TABLE "payout_batches" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "note" VARCHAR NOT NULL DEFAULT '',
+  "created_by" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+--bank account and shop name are kept as they were when the batch was created
+CREATE TABLE "payouts" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "batch_id" uuid NOT NULL,
+  "seller_id" VARCHAR NOT NULL,
+  "shop_name" VARCHAR NOT NULL,
+  "bank_account" VARCHAR NOT NULL,
+  "amount" FLOAT NOT NULL CHECK ("amount" > 0),
+  "status" payout_status NOT NULL DEFAULT 'pending',
+  "reference" VARCHAR NOT NULL DEFAULT '',
+  "note" VARCHAR NOT NULL DEFAULT '',
+  "paid_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "payouts" ADD FOREIGN KEY ("batch_id") REFERENCES "payout_batches" ("id") ON DELETE CASCADE;
+ALTER TABLE "payouts" ADD FOREIGN KEY ("seller_id") REFERENCES "sellers" ("user_id") ON DELETE CASCADE;
+
+CREATE INDEX "payouts_seller_id_idx" ON "payouts" ("seller_id");
+
+CREATE TRIGGER set_updated_at_timestamp_payouts_table BEFORE UPDATE ON "payouts" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
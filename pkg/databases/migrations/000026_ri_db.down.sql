@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS evaluate_commission(VARCHAR);
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "commission_rule_id";
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "commission";
+
+DROP TABLE IF EXISTS "commission_rules" CASCADE;
+
+ALTER TABLE "sellers" DROP COLUMN IF EXISTS "tier";
+
+COMMIT;
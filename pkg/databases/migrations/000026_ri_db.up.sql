@@ -0,0 +1,55 @@
+BEGIN;
+
+ALTER TABLE "sellers" ADD COLUMN "tier" VARCHAR NOT NULL DEFAULT 'standard';
+
+--A rule without category or seller tier matches every item, the most specific active rule wins
+CREATE TABLE "commission_rules" (
+  "id" SERIAL PRIMARY KEY,
+  "title" VARCHAR NOT NULL,
+  "category_id" INT,
+  "seller_tier" VARCHAR,
+  "percentage" FLOAT NOT NULL DEFAULT 0 CHECK ("percentage" >= 0 AND "percentage" <= 1),
+  "flat" FLOAT NOT NULL DEFAULT 0 CHECK ("flat" >= 0),
+  "active" BOOLEAN NOT NULL DEFAULT TRUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "commission_rules" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_commission_rules_table BEFORE UPDATE ON "commission_rules" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--NULL until the order is completed, then it is the commission of the item
+ALTER TABLE "products_orders" ADD COLUMN "commission" FLOAT;
+ALTER TABLE "products_orders" ADD COLUMN "commission_rule_id" INT REFERENCES "commission_rules" ("id") ON DELETE SET NULL;
+
+--Called when the order is completed. Category is the one of the product snapshot, an item without a
+--matching rule pays the commission rate the seller had at the time of the sale
+CREATE OR REPLACE FUNCTION evaluate_commission(oid VARCHAR)
+RETURNS VOID AS $$
+    UPDATE "products_orders" "po" SET
+        "commission_rule_id" = "r"."id",
+        "commission" = ROUND((
+            ("x"."product"->>'price')::FLOAT * "x"."qty" * COALESCE("r"."percentage", "x"."commission_rate")
+            + COALESCE("r"."flat", 0) * "x"."qty"
+        )::NUMERIC, 2)::FLOAT
+    FROM "products_orders" "x"
+        LEFT JOIN "sellers" "s" ON "s"."user_id" = "x"."seller_id"
+        LEFT JOIN LATERAL (
+            SELECT
+                "cr"."id",
+                "cr"."percentage",
+                "cr"."flat"
+            FROM "commission_rules" "cr"
+            WHERE "cr"."active"
+            AND ("cr"."category_id" IS NULL OR "cr"."category_id" = ("x"."product"->'category'->>'id')::INT)
+            AND ("cr"."seller_tier" IS NULL OR "cr"."seller_tier" = "s"."tier")
+            ORDER BY ("cr"."category_id" IS NOT NULL) DESC, ("cr"."seller_tier" IS NOT NULL) DESC, "cr"."id" ASC
+            LIMIT 1
+        ) "r" ON TRUE
+    WHERE "po"."id" = "x"."id"
+    AND "x"."order_id" = oid
+    AND "x"."seller_id" IS NOT NULL;
+$$ LANGUAGE sql VOLATILE;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+--Files removed from a product are deleted from storage after the transaction commits,
+--a failed delete stays here and is retried later
+CREATE TABLE "file_deletions" (
+  "id" SERIAL PRIMARY KEY,
+  "destination" VARCHAR NOT NULL,
+  "attempts" INT NOT NULL DEFAULT 0,
+  "last_error" VARCHAR NOT NULL DEFAULT '',
+  "next_attempt_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "done_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "file_deletions_due_idx" ON "file_deletions" ("next_attempt_at") WHERE "done_at" IS NULL;
+
+COMMIT;
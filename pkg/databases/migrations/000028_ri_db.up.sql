@@ -0,0 +1,17 @@
+BEGIN;
+
+--Specs of a product, e.g. brand = nike, material = cotton. Keys are lowercase, one value per key
+CREATE TABLE "product_attributes" (
+  "product_id" VARCHAR NOT NULL,
+  "key" VARCHAR NOT NULL,
+  "value" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("product_id", "key")
+);
+
+ALTER TABLE "product_attributes" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+--Facet filter ?attr[brand]=nike
+CREATE INDEX "product_attributes_key_value_idx" ON "product_attributes" ("key", LOWER("value"));
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "search_clicks" CASCADE;
+DROP TABLE IF EXISTS "search_queries" CASCADE;
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+--Storefront searches, no user or ip is kept. Emails and long numbers in the query are masked before insert
+CREATE TABLE "search_queries" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY,
+  "query" VARCHAR NOT NULL,
+  "results" INT NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "search_clicks" (
+  "id" SERIAL PRIMARY KEY,
+  "search_id" uuid NOT NULL,
+  "product_id" VARCHAR NOT NULL,
+  "position" INT NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "search_clicks" ADD FOREIGN KEY ("search_id") REFERENCES "search_queries" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "search_queries_created_at_idx" ON "search_queries" ("created_at");
+CREATE INDEX "search_clicks_search_id_idx" ON "search_clicks" ("search_id");
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "webhook_rejections" CASCADE;
+DROP TABLE IF EXISTS "webhook_nonces" CASCADE;
+
+COMMIT;
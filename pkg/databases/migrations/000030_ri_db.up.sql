@@ -0,0 +1,23 @@
+BEGIN;
+
+--Nonce of every accepted webhook, a nonce sent again is a replay. Kept twice the timestamp tolerance
+CREATE TABLE "webhook_nonces" (
+  "source" VARCHAR NOT NULL,
+  "nonce" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("source", "nonce")
+);
+
+--Webhooks rejected by the signature, timestamp or nonce check
+CREATE TABLE "webhook_rejections" (
+  "id" SERIAL PRIMARY KEY,
+  "source" VARCHAR NOT NULL,
+  "reason" VARCHAR NOT NULL,
+  "ip" VARCHAR NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "webhook_nonces_created_at_idx" ON "webhook_nonces" ("created_at");
+CREATE INDEX "webhook_rejections_created_at_idx" ON "webhook_rejections" ("created_at");
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS convert_reservations(VARCHAR);
+
+DROP INDEX IF EXISTS "stock_reservations_expires_at_idx";
+DROP INDEX IF EXISTS "stock_reservations_user_id_idx";
+ALTER TABLE "stock_reservations" DROP COLUMN IF EXISTS "user_id";
+
+COMMIT;
@@ -0,0 +1,57 @@
+BEGIN;
+
+--Checkout holds belong to the customer, the hold moves to the order id when the order is placed
+ALTER TABLE "stock_reservations" ADD COLUMN "user_id" VARCHAR;
+
+CREATE INDEX "stock_reservations_user_id_idx" ON "stock_reservations" ("user_id");
+CREATE INDEX "stock_reservations_expires_at_idx" ON "stock_reservations" ("expires_at");
+
+--Decrement stock of a paid order and drop its holds, returns the first product without enough stock or NULL
+CREATE OR REPLACE FUNCTION convert_reservations(oid VARCHAR)
+RETURNS VARCHAR AS $$
+DECLARE
+    "item" RECORD;
+    "available" INT;
+BEGIN
+    FOR "item" IN
+        SELECT
+            "po"."product"->>'id' AS "product_id",
+            SUM("po"."qty")::INT AS "qty"
+        FROM "products_orders" "po"
+        WHERE "po"."order_id" = oid
+        GROUP BY 1
+        ORDER BY 1
+    LOOP
+        --hold ของ order นี้เองไม่นับ เพราะเป็น stock ที่กันไว้ให้ order นี้
+        SELECT
+            "p"."stock" - COALESCE((
+                SELECT
+                    SUM("r"."qty")
+                FROM "stock_reservations" "r"
+                WHERE "r"."product_id" = "p"."id"
+                AND "r"."expires_at" > now()
+                AND "r"."reference" <> oid
+            ), 0)
+        INTO "available"
+        FROM "products" "p"
+        WHERE "p"."id" = "item"."product_id"
+        FOR UPDATE;
+
+        IF NOT FOUND THEN
+            CONTINUE;
+        END IF;
+        IF "available" < "item"."qty" THEN
+            RETURN "item"."product_id";
+        END IF;
+
+        UPDATE "products" SET
+            "stock" = "stock" - "item"."qty"
+        WHERE "id" = "item"."product_id";
+    END LOOP;
+
+    DELETE FROM "stock_reservations" WHERE "reference" = oid;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql VOLATILE;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_duplicate_charges_table ON "duplicate_charges";
+
+DROP TABLE IF EXISTS "duplicate_charges" CASCADE;
+DROP TABLE IF EXISTS "payments" CASCADE;
+
+DROP TYPE IF EXISTS "duplicate_charge_status";
+
+COMMIT;
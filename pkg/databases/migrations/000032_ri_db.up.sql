@@ -0,0 +1,42 @@
+BEGIN;
+
+--Successful payments from the payment gateway webhooks, a redelivered webhook has the same reference
+CREATE TABLE "payments" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "user_id" VARCHAR NOT NULL,
+  "reference" VARCHAR UNIQUE NOT NULL,
+  "amount" FLOAT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TYPE "duplicate_charge_status" AS ENUM (
+  'review',
+  'refunded',
+  'dismissed'
+);
+
+--A payment which charged the customer again, for the same order or the same amount within a short window
+CREATE TABLE "duplicate_charges" (
+  "id" SERIAL PRIMARY KEY,
+  "payment_id" uuid UNIQUE NOT NULL,
+  "original_payment_id" uuid NOT NULL,
+  "reason" VARCHAR NOT NULL,
+  "status" duplicate_charge_status NOT NULL DEFAULT 'review',
+  "refund_ref" VARCHAR,
+  "reviewed_by" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "payments" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "duplicate_charges" ADD FOREIGN KEY ("payment_id") REFERENCES "payments" ("id") ON DELETE CASCADE;
+ALTER TABLE "duplicate_charges" ADD FOREIGN KEY ("original_payment_id") REFERENCES "payments" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "payments_order_id_idx" ON "payments" ("order_id");
+CREATE INDEX "payments_user_id_amount_created_at_idx" ON "payments" ("user_id", "amount", "created_at");
+CREATE INDEX "duplicate_charges_status_idx" ON "duplicate_charges" ("status");
+
+CREATE TRIGGER set_updated_at_timestamp_duplicate_charges_table BEFORE UPDATE ON "duplicate_charges" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
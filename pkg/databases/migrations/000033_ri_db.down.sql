@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS available_stock(VARCHAR);
+
+DROP TABLE IF EXISTS "low_stock_alerts" CASCADE;
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "low_stock_threshold";
+
+COMMIT;
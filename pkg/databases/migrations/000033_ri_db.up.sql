@@ -0,0 +1,31 @@
+BEGIN;
+
+--Alert when the available stock fall to the threshold, NULL use INVENTORY_LOW_STOCK_THRESHOLD
+ALTER TABLE "products" ADD COLUMN "low_stock_threshold" INT CHECK ("low_stock_threshold" >= 0);
+
+--Products which have been alerted, the row is removed when the stock is back above the threshold so the next drop alert again
+CREATE TABLE "low_stock_alerts" (
+  "product_id" VARCHAR PRIMARY KEY,
+  "available" INT NOT NULL,
+  "threshold" INT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "low_stock_alerts" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+--Stock which can still be sold, holds of checkouts and placed orders are taken out
+CREATE OR REPLACE FUNCTION available_stock(pid VARCHAR)
+RETURNS INT AS $$
+    SELECT
+        "p"."stock" - COALESCE((
+            SELECT
+                SUM("r"."qty")
+            FROM "stock_reservations" "r"
+            WHERE "r"."product_id" = "p"."id"
+            AND "r"."expires_at" > now()
+        ), 0)::INT
+    FROM "products" "p"
+    WHERE "p"."id" = pid;
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+--Images are shown by position, the primary image is the cover and always comes first
+ALTER TABLE "images" ADD COLUMN "position" INT NOT NULL DEFAULT 0;
+ALTER TABLE "images" ADD COLUMN "is_primary" BOOLEAN NOT NULL DEFAULT false;
+
+--รูปเดิมเรียงตามลำดับที่ insert (ctid) ให้เหมือนที่แสดงอยู่, รูปแรกเป็น cover
+UPDATE "images" "i" SET
+  "position" = "o"."position",
+  "is_primary" = "o"."position" = 0
+FROM (
+  SELECT
+    "id",
+    (ROW_NUMBER() OVER (PARTITION BY "product_id" ORDER BY "created_at", ctid) - 1)::INT AS "position"
+  FROM "images"
+) AS "o"
+WHERE "o"."id" = "i"."id";
+
+CREATE UNIQUE INDEX "images_product_id_primary_idx" ON "images" ("product_id") WHERE "is_primary";
+CREATE INDEX "images_product_id_position_idx" ON "images" ("product_id", "position");
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "files_infected_idx";
+
+ALTER TABLE "files" DROP CONSTRAINT IF EXISTS "files_scan_status_check";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "scanned_at";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "scan_signature";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "scan_engine";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "scan_status";
+
+COMMIT;
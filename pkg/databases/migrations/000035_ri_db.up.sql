@@ -0,0 +1,13 @@
+BEGIN;
+
+--Antivirus result of every uploaded file, files uploaded before scanning was added are not_scanned
+ALTER TABLE "files" ADD COLUMN "scan_status" VARCHAR NOT NULL DEFAULT 'not_scanned';
+ALTER TABLE "files" ADD COLUMN "scan_engine" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "files" ADD COLUMN "scan_signature" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "files" ADD COLUMN "scanned_at" TIMESTAMP;
+
+ALTER TABLE "files" ADD CONSTRAINT "files_scan_status_check" CHECK ("scan_status" IN ('clean', 'infected', 'not_scanned'));
+
+CREATE INDEX "files_infected_idx" ON "files" ("created_at") WHERE "scan_status" = 'infected';
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "users_store_id_idx";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "store_id";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_stores_table ON "stores";
+DROP TABLE IF EXISTS "stores";
+
+COMMIT;
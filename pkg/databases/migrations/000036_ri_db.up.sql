@@ -0,0 +1,16 @@
+BEGIN;
+
+--Storefronts served by the deployment, users without a store belong to the default store
+CREATE TABLE "stores" (
+  "id" VARCHAR PRIMARY KEY CHECK ("id" ~ '^[a-z0-9][a-z0-9-]*$'),
+  "title" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER set_updated_at_timestamp_stores_table BEFORE UPDATE ON "stores" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+ALTER TABLE "users" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id");
+CREATE INDEX "users_store_id_idx" ON "users" ("store_id");
+
+COMMIT;
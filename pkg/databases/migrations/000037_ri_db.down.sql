@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "categories_store_id_title_idx";
+ALTER TABLE "categories" ADD CONSTRAINT "categories_title_key" UNIQUE ("title");
+
+DROP INDEX IF EXISTS "orders_store_id_idx";
+DROP INDEX IF EXISTS "products_store_id_idx";
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "store_id";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "store_id";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "store_id";
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+--Catalog and orders of every store, null is the default store
+ALTER TABLE "products" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id");
+ALTER TABLE "categories" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id");
+ALTER TABLE "orders" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id");
+
+CREATE INDEX "products_store_id_idx" ON "products" ("store_id");
+CREATE INDEX "orders_store_id_idx" ON "orders" ("store_id");
+
+--ชื่อ category ซ้ำกันได้ข้าม store
+ALTER TABLE "categories" DROP CONSTRAINT IF EXISTS "categories_title_key";
+CREATE UNIQUE INDEX "categories_store_id_title_idx" ON "categories" (COALESCE("store_id", ''), "title");
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+--An enum value cannot be dropped, the type is created again without archived
+UPDATE "products" SET "status" = 'draft' WHERE "status" = 'archived';
+
+ALTER TYPE "product_status" RENAME TO "product_status_old";
+
+CREATE TYPE "product_status" AS ENUM (
+  'draft',
+  'published'
+);
+
+ALTER TABLE "products" ALTER COLUMN "status" DROP DEFAULT;
+ALTER TABLE "products" ALTER COLUMN "status" TYPE product_status USING "status"::TEXT::product_status;
+ALTER TABLE "products" ALTER COLUMN "status" SET DEFAULT 'published';
+
+DROP TYPE "product_status_old";
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+--Archived products are hidden from everyone but admins, they stay for the orders which have them
+ALTER TYPE "product_status" ADD VALUE IF NOT EXISTS 'archived';
+
+COMMIT;
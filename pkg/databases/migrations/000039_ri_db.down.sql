@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "products_available_window_idx";
+
+ALTER TABLE "products" DROP CONSTRAINT IF EXISTS "products_available_window_check";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "window_open";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "available_until";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "available_from";
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+--Limited time drops, a product is only sold inside its window, a null bound is open.
+--window_open is the state the availability job last announced, null until the first run
+ALTER TABLE "products" ADD COLUMN "available_from" TIMESTAMP;
+ALTER TABLE "products" ADD COLUMN "available_until" TIMESTAMP;
+ALTER TABLE "products" ADD COLUMN "window_open" BOOLEAN;
+
+ALTER TABLE "products" ADD CONSTRAINT "products_available_window_check" CHECK ("available_until" > "available_from");
+
+CREATE INDEX "products_available_window_idx" ON "products" ("available_from", "available_until") WHERE "available_from" IS NOT NULL OR "available_until" IS NOT NULL;
+
+COMMIT;
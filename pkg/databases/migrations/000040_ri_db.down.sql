@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tax";
+
+DROP TABLE IF EXISTS "tax_rates";
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+--Tax rules per region and category, the breakdown of an order is kept on the order
+CREATE TABLE "tax_rates" (
+  "id" SERIAL PRIMARY KEY,
+  "name" VARCHAR NOT NULL DEFAULT 'Tax',
+  "country" VARCHAR(2) NOT NULL DEFAULT '',
+  "state" VARCHAR NOT NULL DEFAULT '',
+  "category_id" INT REFERENCES "categories" ("id") ON DELETE CASCADE,
+  "rate" FLOAT NOT NULL CHECK ("rate" >= 0 AND "rate" < 1),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("state" = '' OR "country" <> '')
+);
+
+CREATE UNIQUE INDEX "tax_rates_region_category_idx" ON "tax_rates" ("country", "state", COALESCE("category_id", 0));
+
+ALTER TABLE "orders" ADD COLUMN "tax" jsonb;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "invoices";
+DROP SEQUENCE IF EXISTS "invoices_number_seq";
+
+COMMIT;
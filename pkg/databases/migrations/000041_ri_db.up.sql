@@ -0,0 +1,13 @@
+BEGIN;
+
+--PDF invoice of a paid order, the file is private in the bucket
+CREATE SEQUENCE "invoices_number_seq" START WITH 1 INCREMENT BY 1;
+
+CREATE TABLE "invoices" (
+  "order_id" VARCHAR(7) PRIMARY KEY REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "number" VARCHAR NOT NULL UNIQUE,
+  "destination" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+--Bytes uploaded by a user per day, checked against UPLOAD_DAILY_QUOTA_BYTES
+CREATE TABLE "upload_usage" (
+  "user_id" VARCHAR NOT NULL,
+  "day" DATE NOT NULL DEFAULT CURRENT_DATE,
+  "bytes" BIGINT NOT NULL DEFAULT 0 CHECK ("bytes" >= 0),
+  PRIMARY KEY ("user_id", "day")
+);
+
+COMMIT;
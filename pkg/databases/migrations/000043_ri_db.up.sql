@@ -0,0 +1,14 @@
+BEGIN;
+
+--Failed sign ins of an email, an unknown email is counted too so a lock does not tell which emails exist
+CREATE TABLE "login_attempts" (
+  "email" VARCHAR PRIMARY KEY,
+  "failures" INT NOT NULL DEFAULT 0,
+  "locked_until" TIMESTAMP,
+  "last_failed_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "last_ip" VARCHAR NOT NULL DEFAULT ''
+);
+
+CREATE INDEX "login_attempts_locked_until_idx" ON "login_attempts" ("locked_until");
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+--The last products a user or an anonymous session saw, capped per viewer and store by the api
+CREATE TABLE "recently_viewed" (
+  "viewer" VARCHAR NOT NULL,
+  "store_id" VARCHAR NOT NULL DEFAULT '',
+  "product_id" VARCHAR NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+  "viewed_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("viewer", "product_id")
+);
+
+CREATE INDEX "recently_viewed_viewer_idx" ON "recently_viewed" ("viewer", "store_id", "viewed_at" DESC);
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+--Exports of orders made in the background, the file is private and read with a signed url
+CREATE TABLE "order_exports" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "store_id" VARCHAR NOT NULL DEFAULT '',
+  "user_id" VARCHAR NOT NULL,
+  "filter" jsonb NOT NULL,
+  "status" VARCHAR NOT NULL DEFAULT 'pending',
+  "rows" INT NOT NULL DEFAULT 0,
+  "error" VARCHAR NOT NULL DEFAULT '',
+  "destination" VARCHAR NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "finished_at" TIMESTAMP
+);
+
+CREATE INDEX "order_exports_store_id_created_at_idx" ON "order_exports" ("store_id", "created_at" DESC);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "files_sha256_idx";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "sha256";
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+--Sha256 of the content of a file, an upload with the same content in the same store reuse the file
+ALTER TABLE "files" ADD COLUMN "sha256" VARCHAR;
+
+CREATE INDEX "files_sha256_idx" ON "files" ("sha256") WHERE "sha256" IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+--Smaller formats of an uploaded image, the object is at the destination of the image with the format as extension
+CREATE TABLE "file_variants" (
+  "destination" VARCHAR NOT NULL,
+  "format" VARCHAR NOT NULL,
+  "bytes" INT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("destination", "format")
+);
+
+ALTER TABLE "file_variants" ADD CONSTRAINT "file_variants_format_check" CHECK ("format" IN ('webp', 'avif'));
+
+COMMIT;
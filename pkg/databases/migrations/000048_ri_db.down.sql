@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "task_runs";
+DROP TABLE IF EXISTS "tasks";
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+--Background tasks which an admin paused, a task without a row is running on its schedule
+CREATE TABLE "tasks" (
+  "name" VARCHAR PRIMARY KEY,
+  "paused" BOOLEAN NOT NULL DEFAULT FALSE,
+  "updated_by" VARCHAR,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+--Every run of a task on any instance of the server
+CREATE TABLE "task_runs" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "task" VARCHAR NOT NULL,
+  "trigger" VARCHAR NOT NULL,
+  "triggered_by" VARCHAR,
+  "status" VARCHAR NOT NULL DEFAULT 'running',
+  "instance" VARCHAR NOT NULL,
+  "error" VARCHAR,
+  "started_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "finished_at" TIMESTAMP
+);
+
+ALTER TABLE "task_runs" ADD CONSTRAINT "task_runs_trigger_check" CHECK ("trigger" IN ('schedule', 'manual'));
+ALTER TABLE "task_runs" ADD CONSTRAINT "task_runs_status_check" CHECK ("status" IN ('running', 'succeeded', 'failed'));
+
+CREATE INDEX "task_runs_task_idx" ON "task_runs" ("task", "started_at" DESC);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "user_notifications";
+DROP TABLE IF EXISTS "notification_preferences";
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+--Channels a customer wants for each type of notification, a type without a row use the defaults of the code
+CREATE TABLE "notification_preferences" (
+  "user_id" VARCHAR NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "event_type" VARCHAR NOT NULL,
+  "email" BOOLEAN NOT NULL,
+  "sms" BOOLEAN NOT NULL,
+  "push" BOOLEAN NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("user_id", "event_type")
+);
+
+--In-app notifications of the account area
+CREATE TABLE "user_notifications" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "event_type" VARCHAR NOT NULL,
+  "title" VARCHAR NOT NULL,
+  "body" VARCHAR NOT NULL DEFAULT '',
+  "link" VARCHAR NOT NULL DEFAULT '',
+  "read_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "user_notifications_user_idx" ON "user_notifications" ("user_id", "created_at" DESC);
+CREATE INDEX "user_notifications_unread_idx" ON "user_notifications" ("user_id") WHERE "read_at" IS NULL;
+
+COMMIT;
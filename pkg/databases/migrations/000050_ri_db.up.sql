@@ -0,0 +1,27 @@
+BEGIN;
+
+--Customers waiting for a product to be back in stock or cheaper, a watch is notified once
+CREATE TABLE "product_watches" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "product_id" VARCHAR NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+  "kind" VARCHAR NOT NULL,
+  "target_price" FLOAT,
+  "watched_price" FLOAT NOT NULL,
+  "status" VARCHAR NOT NULL DEFAULT 'active',
+  "notified_at" TIMESTAMP,
+  "expires_at" TIMESTAMP NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "product_watches" ADD CONSTRAINT "product_watches_kind_check" CHECK ("kind" IN ('back_in_stock', 'price_drop'));
+ALTER TABLE "product_watches" ADD CONSTRAINT "product_watches_status_check" CHECK ("status" IN ('active', 'notified', 'expired', 'canceled'));
+
+CREATE TRIGGER set_updated_at_timestamp_product_watches_table BEFORE UPDATE ON "product_watches" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--A customer has one active watch of each kind on a product
+CREATE UNIQUE INDEX "product_watches_active_idx" ON "product_watches" ("user_id", "product_id", "kind") WHERE "status" = 'active';
+CREATE INDEX "product_watches_product_idx" ON "product_watches" ("product_id") WHERE "status" = 'active';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
+
+DROP TABLE IF EXISTS "account_exports";
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+--Archives of the personal data of a user made in the background, the file is private and read with a signed url
+CREATE TABLE "account_exports" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "status" VARCHAR NOT NULL DEFAULT 'pending',
+  "error" VARCHAR NOT NULL DEFAULT '',
+  "destination" VARCHAR NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "finished_at" TIMESTAMP
+);
+
+CREATE INDEX "account_exports_user_id_created_at_idx" ON "account_exports" ("user_id", "created_at" DESC);
+
+--A deleted account is anonymized, the row stays because the orders refer to it
+ALTER TABLE "users" ADD COLUMN "deleted_at" TIMESTAMP;
+
+COMMIT;
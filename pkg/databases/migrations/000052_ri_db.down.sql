@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "inventory_forecasts_sales_velocity_idx";
+DROP INDEX IF EXISTS "products_published_created_at_idx";
+DROP INDEX IF EXISTS "products_published_price_idx";
+DROP INDEX IF EXISTS "products_published_title_idx";
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+--Sort options of the storefront listing, a store reads its published products in the order of ?sort= with the id last
+CREATE INDEX "products_published_title_idx" ON "products" (COALESCE("store_id", ''), "title", "id") WHERE "status" = 'published';
+CREATE INDEX "products_published_price_idx" ON "products" (COALESCE("store_id", ''), "price_minor", "id") WHERE "status" = 'published';
+CREATE INDEX "products_published_created_at_idx" ON "products" (COALESCE("store_id", ''), "created_at" DESC, "id") WHERE "status" = 'published';
+
+--best_selling and the best seller badge rank by the sales velocity
+CREATE INDEX "inventory_forecasts_sales_velocity_idx" ON "inventory_forecasts" ("sales_velocity" DESC);
+
+COMMIT;
@@ -0,0 +1,59 @@
+BEGIN;
+
+--Decrement stock of a paid order and drop its holds, returns the first product without enough stock or NULL
+CREATE OR REPLACE FUNCTION convert_reservations(oid VARCHAR)
+RETURNS VARCHAR AS $$
+DECLARE
+    "item" RECORD;
+    "available" INT;
+BEGIN
+    FOR "item" IN
+        SELECT
+            "po"."product"->>'id' AS "product_id",
+            SUM("po"."qty")::INT AS "qty"
+        FROM "products_orders" "po"
+        WHERE "po"."order_id" = oid
+        GROUP BY 1
+        ORDER BY 1
+    LOOP
+        --hold ของ order นี้เองไม่นับ เพราะเป็น stock ที่กันไว้ให้ order นี้
+        SELECT
+            "p"."stock" - COALESCE((
+                SELECT
+                    SUM("r"."qty")
+                FROM "stock_reservations" "r"
+                WHERE "r"."product_id" = "p"."id"
+                AND "r"."expires_at" > now()
+                AND "r"."reference" <> oid
+            ), 0)
+        INTO "available"
+        FROM "products" "p"
+        WHERE "p"."id" = "item"."product_id"
+        FOR UPDATE;
+
+        IF NOT FOUND THEN
+            CONTINUE;
+        END IF;
+        IF "available" < "item"."qty" THEN
+            RETURN "item"."product_id";
+        END IF;
+
+        UPDATE "products" SET
+            "stock" = "stock" - "item"."qty"
+        WHERE "id" = "item"."product_id";
+    END LOOP;
+
+    DELETE FROM "stock_reservations" WHERE "reference" = oid;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql VOLATILE;
+
+DROP TRIGGER IF EXISTS record_reservation_movement_stock_reservations_table ON "stock_reservations";
+DROP TRIGGER IF EXISTS record_stock_movement_products_table ON "products";
+DROP FUNCTION IF EXISTS record_reservation_movement();
+DROP FUNCTION IF EXISTS record_stock_movement();
+
+DROP TABLE IF EXISTS "inventory_movements";
+DROP TYPE IF EXISTS "movement_kind";
+
+COMMIT;
@@ -0,0 +1,166 @@
+BEGIN;
+
+--Every change of the stock of a product, qty is negative when the stock goes out. A reservation hold or
+--release does not change the stock, its stock_after is null and the reconcile does not count it
+CREATE TYPE "movement_kind" AS ENUM (
+  'sale',
+  'restock',
+  'adjustment',
+  'reservation',
+  'refund'
+);
+
+CREATE TABLE "inventory_movements" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "product_id" VARCHAR NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+  "kind" movement_kind NOT NULL,
+  "qty" INT NOT NULL,
+  "stock_after" INT,
+  "reason" VARCHAR NOT NULL DEFAULT '',
+  "actor_id" VARCHAR NOT NULL DEFAULT '',
+  "order_id" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "inventory_movements_product_id_idx" ON "inventory_movements" ("product_id", "id" DESC);
+CREATE INDEX "inventory_movements_order_id_idx" ON "inventory_movements" ("order_id") WHERE "order_id" IS NOT NULL;
+CREATE INDEX "inventory_movements_created_at_idx" ON "inventory_movements" ("created_at");
+
+--The code which change the stock tell what the change is with set_config of ri.movement_kind, ri.movement_reason,
+--ri.movement_actor and ri.movement_order for the rest of its transaction, a change without them is an adjustment
+CREATE OR REPLACE FUNCTION record_stock_movement()
+RETURNS TRIGGER AS $$
+DECLARE
+    "delta" INT;
+    "kind" VARCHAR;
+    "reason" VARCHAR;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        "delta" := NEW."stock";
+        "kind" := 'restock';
+        "reason" := 'product created';
+    ELSE
+        "delta" := NEW."stock" - OLD."stock";
+        "kind" := 'adjustment';
+        "reason" := '';
+    END IF;
+    IF "delta" = 0 THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO "inventory_movements" (
+        "product_id",
+        "kind",
+        "qty",
+        "stock_after",
+        "reason",
+        "actor_id",
+        "order_id"
+    )
+    VALUES (
+        NEW."id",
+        COALESCE(NULLIF(current_setting('ri.movement_kind', true), ''), "kind")::movement_kind,
+        "delta",
+        NEW."stock",
+        COALESCE(NULLIF(current_setting('ri.movement_reason', true), ''), "reason"),
+        COALESCE(current_setting('ri.movement_actor', true), ''),
+        NULLIF(current_setting('ri.movement_order', true), '')
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_stock_movement_products_table AFTER INSERT OR UPDATE OF "stock" ON "products" FOR EACH ROW EXECUTE PROCEDURE record_stock_movement();
+
+--A hold is a negative reservation and its release a positive one, the product is checked because the holds of a
+--deleted product are removed with it
+CREATE OR REPLACE FUNCTION record_reservation_movement()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO "inventory_movements" ("product_id", "kind", "qty", "reason", "actor_id", "order_id")
+        SELECT
+            NEW."product_id",
+            'reservation',
+            -NEW."qty",
+            'hold ' || NEW."reference",
+            COALESCE(NEW."user_id", ''),
+            (SELECT "o"."id" FROM "orders" "o" WHERE "o"."id" = NEW."reference");
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO "inventory_movements" ("product_id", "kind", "qty", "reason", "actor_id", "order_id")
+    SELECT
+        OLD."product_id",
+        'reservation',
+        OLD."qty",
+        CASE WHEN OLD."expires_at" <= now() THEN 'expired ' ELSE 'release ' END || OLD."reference",
+        COALESCE(current_setting('ri.movement_actor', true), ''),
+        (SELECT "o"."id" FROM "orders" "o" WHERE "o"."id" = OLD."reference")
+    WHERE EXISTS (SELECT 1 FROM "products" "p" WHERE "p"."id" = OLD."product_id");
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_reservation_movement_stock_reservations_table AFTER INSERT OR DELETE ON "stock_reservations" FOR EACH ROW EXECUTE PROCEDURE record_reservation_movement();
+
+--Decrement stock of a paid order and drop its holds, returns the first product without enough stock or NULL
+CREATE OR REPLACE FUNCTION convert_reservations(oid VARCHAR)
+RETURNS VARCHAR AS $$
+DECLARE
+    "item" RECORD;
+    "available" INT;
+BEGIN
+    --the stock changes below are recorded as the sale of the order
+    PERFORM set_config('ri.movement_kind', 'sale', true);
+    PERFORM set_config('ri.movement_order', oid, true);
+    PERFORM set_config('ri.movement_reason', 'order paid', true);
+
+    FOR "item" IN
+        SELECT
+            "po"."product"->>'id' AS "product_id",
+            SUM("po"."qty")::INT AS "qty"
+        FROM "products_orders" "po"
+        WHERE "po"."order_id" = oid
+        GROUP BY 1
+        ORDER BY 1
+    LOOP
+        --hold ของ order นี้เองไม่นับ เพราะเป็น stock ที่กันไว้ให้ order นี้
+        SELECT
+            "p"."stock" - COALESCE((
+                SELECT
+                    SUM("r"."qty")
+                FROM "stock_reservations" "r"
+                WHERE "r"."product_id" = "p"."id"
+                AND "r"."expires_at" > now()
+                AND "r"."reference" <> oid
+            ), 0)
+        INTO "available"
+        FROM "products" "p"
+        WHERE "p"."id" = "item"."product_id"
+        FOR UPDATE;
+
+        IF NOT FOUND THEN
+            CONTINUE;
+        END IF;
+        IF "available" < "item"."qty" THEN
+            RETURN "item"."product_id";
+        END IF;
+
+        UPDATE "products" SET
+            "stock" = "stock" - "item"."qty"
+        WHERE "id" = "item"."product_id";
+    END LOOP;
+
+    DELETE FROM "stock_reservations" WHERE "reference" = oid;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql VOLATILE;
+
+--The stock before the ledger is its opening balance
+INSERT INTO "inventory_movements" ("product_id", "kind", "qty", "stock_after", "reason")
+SELECT "id", 'adjustment', "stock", "stock", 'opening balance'
+FROM "products"
+WHERE "stock" <> 0;
+
+COMMIT;
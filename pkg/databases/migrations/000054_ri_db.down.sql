@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "coupon_code";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "discount";
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+--Discount of the promotions and the coupon applied at checkout, total_paid is reduced by it
+ALTER TABLE "orders" ADD COLUMN "discount" FLOAT NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN "coupon_code" VARCHAR;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "suppliers_store_id_title_idx";
+ALTER TABLE "suppliers" ADD CONSTRAINT "suppliers_title_key" UNIQUE ("title");
+
+ALTER TABLE "search_queries" DROP COLUMN IF EXISTS "store_id";
+ALTER TABLE "payout_batches" DROP COLUMN IF EXISTS "store_id";
+ALTER TABLE "pickup_locations" DROP COLUMN IF EXISTS "store_id";
+ALTER TABLE "suppliers" DROP COLUMN IF EXISTS "store_id";
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+--Settings and history of every store, null is the default store
+ALTER TABLE "suppliers" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id");
+ALTER TABLE "pickup_locations" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id");
+ALTER TABLE "payout_batches" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id");
+ALTER TABLE "search_queries" ADD COLUMN "store_id" VARCHAR REFERENCES "stores" ("id") ON DELETE CASCADE;
+
+--ชื่อ supplier ซ้ำกันได้ข้าม store
+ALTER TABLE "suppliers" DROP CONSTRAINT IF EXISTS "suppliers_title_key";
+CREATE UNIQUE INDEX "suppliers_store_id_title_idx" ON "suppliers" (COALESCE("store_id", ''), "title");
+
+COMMIT;
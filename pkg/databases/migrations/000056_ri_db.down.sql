@@ -0,0 +1,7 @@
+BEGIN;
+
+UPDATE "stock_reservations" SET
+    "expires_at" = now() + INTERVAL '10 minutes'
+WHERE "expires_at" = 'infinity';
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+--Holds which were moved to an order wait for its payment
+UPDATE "stock_reservations" SET
+    "expires_at" = 'infinity'
+WHERE "reference" IN (SELECT "id" FROM "orders");
+
+COMMIT;
@@ -0,0 +1,62 @@
+package databases
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// slowQueries is the number of slow queries since startup, read by the db metrics
+var slowQueries atomic.Int64
+
+func SlowQueries() int64 {
+	return slowQueries.Load()
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+// slowQueryTracer log every query which takes longer than threshold. It is set on the pgx connection
+// under sqlx, so the queries of every repository are timed without changing them.
+// The args are not logged because they may have passwords or personal data
+type slowQueryTracer struct {
+	threshold time.Duration
+}
+
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, &queryStart{
+		sql: data.SQL,
+		at:  time.Now(),
+	})
+}
+
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(*queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start.at)
+	if elapsed < t.threshold {
+		return
+	}
+
+	slowQueries.Add(1)
+	if data.Err != nil {
+		log.Printf("slow query %v failed: %v: %s", elapsed.Round(time.Millisecond), data.Err, compact(start.sql))
+		return
+	}
+	log.Printf("slow query %v: %s", elapsed.Round(time.Millisecond), compact(start.sql))
+}
+
+// compact put the query on one line, the repositories write them over many indented lines
+func compact(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,94 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFn load the values of keys in one call, a key which is missing from the map has the zero value
+type BatchFn[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batch the keys of one request. Load only queue a key and return a thunk, the first thunk
+// which is called load every queued key in one call of BatchFn. A value is loaded once per loader,
+// so a loader is made for each request and dropped with it
+type Loader[K comparable, V any] struct {
+	ctx     context.Context
+	batch   BatchFn[K, V]
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	values  map[K]V
+	errs    map[K]error
+}
+
+func New[K comparable, V any](ctx context.Context, batch BatchFn[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		ctx:    ctx,
+		batch:  batch,
+		queued: make(map[K]bool),
+		values: make(map[K]V),
+		errs:   make(map[K]error),
+	}
+}
+
+// Load queue key, the value is read by calling the thunk after every key of the batch is queued
+func (l *Loader[K, V]) Load(key K) func() (V, error) {
+	l.mu.Lock()
+	if !l.loaded(key) && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if !l.loaded(key) {
+			l.dispatch()
+		}
+		return l.values[key], l.errs[key]
+	}
+}
+
+// Prime set the value of key which was read by another query, it is not loaded again
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.loaded(key) {
+		l.values[key] = value
+	}
+}
+
+func (l *Loader[K, V]) loaded(key K) bool {
+	if _, ok := l.values[key]; ok {
+		return true
+	}
+	_, ok := l.errs[key]
+	return ok
+}
+
+// dispatch load the queued keys, the caller hold mu
+func (l *Loader[K, V]) dispatch() {
+	keys := make([]K, 0, len(l.pending))
+	for _, key := range l.pending {
+		if !l.loaded(key) {
+			keys = append(keys, key)
+		}
+	}
+	l.pending = nil
+	l.queued = make(map[K]bool)
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.batch(l.ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.values[key] = values[key]
+	}
+}
@@ -0,0 +1,81 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLoadBatch(t *testing.T) {
+	batches := make([][]string, 0)
+	loader := New(context.Background(), func(ctx context.Context, keys []string) (map[string]int, error) {
+		batches = append(batches, keys)
+		values := make(map[string]int)
+		for _, key := range keys {
+			values[key] = len(key)
+		}
+		return values, nil
+	})
+
+	// the keys which are queued before the first thunk is called are loaded together
+	a := loader.Load("a")
+	bb := loader.Load("bb")
+	again := loader.Load("a")
+	if v, err := bb(); err != nil || v != 2 {
+		t.Errorf("expected: 2, got: %v %v", v, err)
+	}
+	if v, err := a(); err != nil || v != 1 {
+		t.Errorf("expected: 1, got: %v %v", v, err)
+	}
+	if v, _ := again(); v != 1 {
+		t.Errorf("expected: 1, got: %v", v)
+	}
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("expected one batch of [a bb], got: %v", batches)
+	}
+
+	// a loaded key is not loaded again, a new key is the next batch
+	loader.Load("a")()
+	if v, _ := loader.Load("ccc")(); v != 3 {
+		t.Errorf("expected: 3, got: %v", v)
+	}
+	if len(batches) != 2 || len(batches[1]) != 1 || batches[1][0] != "ccc" {
+		t.Errorf("expected a second batch of [ccc], got: %v", batches)
+	}
+}
+
+func TestLoadPrime(t *testing.T) {
+	calls := 0
+	loader := New(context.Background(), func(ctx context.Context, keys []string) (map[string]int, error) {
+		calls++
+		return map[string]int{}, nil
+	})
+
+	loader.Prime("a", 10)
+	if v, _ := loader.Load("a")(); v != 10 {
+		t.Errorf("expected: 10, got: %v", v)
+	}
+	if calls != 0 {
+		t.Errorf("expected a primed key not to be loaded, got %d calls", calls)
+	}
+
+	// a key which is not found has the zero value
+	if v, err := loader.Load("b")(); err != nil || v != 0 {
+		t.Errorf("expected: 0, got: %v %v", v, err)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	loader := New(context.Background(), func(ctx context.Context, keys []string) (map[string]int, error) {
+		return nil, errors.New("select failed")
+	})
+
+	a := loader.Load("a")
+	b := loader.Load("b")
+	if _, err := a(); err == nil {
+		t.Errorf("expected the error of the batch")
+	}
+	if _, err := b(); err == nil {
+		t.Errorf("expected every key of the batch to fail")
+	}
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Event is published in process, subscribers run in their own goroutine so a slow subscriber never
+// blocks the request which published it. They outlive the request, so they get their own context
+type Event struct {
+	Name      string    `json:"name"`
+	Payload   any       `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Handler func(ctx context.Context, e *Event)
+
+var (
+	mu          sync.RWMutex
+	subscribers = make(map[string][]Handler)
+)
+
+// Subscribe register fn for events of name, "*" receive every event
+func Subscribe(name string, fn Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	subscribers[name] = append(subscribers[name], fn)
+}
+
+func Publish(name string, payload any) {
+	e := &Event{
+		Name:      name,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+
+	mu.RLock()
+	handlers := make([]Handler, 0, len(subscribers[name])+len(subscribers["*"]))
+	handlers = append(handlers, subscribers[name]...)
+	handlers = append(handlers, subscribers["*"]...)
+	mu.RUnlock()
+
+	for _, fn := range handlers {
+		go func(fn Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("event %s subscriber panic: %v\n", e.Name, r)
+				}
+			}()
+			fn(context.Background(), e)
+		}(fn)
+	}
+}
@@ -0,0 +1,153 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultLocale is the last locale of every fallback chain
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+type catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string // locale -> key -> message
+}
+
+var defaultCatalog = mustLoadCatalog()
+
+func mustLoadCatalog() *catalog {
+	c := &catalog{
+		messages: make(map[string]map[string]string),
+	}
+
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("read locale files failed: %v", err))
+	}
+	for _, entry := range entries {
+		b, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("read locale file %s failed: %v", entry.Name(), err))
+		}
+
+		messages := make(map[string]string)
+		if err := json.Unmarshal(b, &messages); err != nil {
+			panic(fmt.Sprintf("unmarshal locale file %s failed: %v", entry.Name(), err))
+		}
+		c.messages[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return c
+}
+
+// IsSupported report whether there is a message file for the locale
+func IsSupported(locale string) bool {
+	defaultCatalog.mu.RLock()
+	defer defaultCatalog.mu.RUnlock()
+
+	_, ok := defaultCatalog.messages[normalize(locale)]
+	return ok
+}
+
+// Translate return the message of key in the first locale that has one, e.g. th-TH -> th -> en,
+// the key itself is returned when no locale has it
+func Translate(locales []string, key string) string {
+	defaultCatalog.mu.RLock()
+	defer defaultCatalog.mu.RUnlock()
+
+	for _, locale := range Chain(locales...) {
+		if msg, ok := defaultCatalog.messages[locale][key]; ok {
+			return msg
+		}
+	}
+	return key
+}
+
+// Chain expand locales to the fallback chain, regional locales fall back to their language
+func Chain(locales ...string) []string {
+	chain := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if locale != "" && !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+
+	for _, locale := range locales {
+		locale = normalize(locale)
+		add(locale)
+		if i := strings.Index(locale, "-"); i > 0 {
+			add(locale[:i])
+		}
+	}
+	add(DefaultLocale)
+	return chain
+}
+
+// ParseAcceptLanguage return locales of an Accept-Language header in the order they are sent,
+// quality values are ignored because browsers already send them sorted
+func ParseAcceptLanguage(header string) []string {
+	locales := make([]string, 0)
+	for _, part := range strings.Split(header, ",") {
+		locale := strings.TrimSpace(strings.Split(part, ";")[0])
+		if locale != "" && locale != "*" {
+			locales = append(locales, locale)
+		}
+	}
+	return locales
+}
+
+// Override set a message, an override of an unknown locale add the locale
+func Override(locale, key, message string) {
+	defaultCatalog.mu.Lock()
+	defer defaultCatalog.mu.Unlock()
+
+	locale = normalize(locale)
+	if defaultCatalog.messages[locale] == nil {
+		defaultCatalog.messages[locale] = make(map[string]string)
+	}
+	defaultCatalog.messages[locale][key] = message
+}
+
+// LoadOverrides apply the messages table on top of the embedded message files
+func LoadOverrides(db *sqlx.DB) error {
+	query := `
+	SELECT
+		"locale",
+		"key",
+		"message"
+	FROM "messages";`
+
+	overrides := make([]*struct {
+		Locale  string `db:"locale"`
+		Key     string `db:"key"`
+		Message string `db:"message"`
+	}, 0)
+	if err := db.Select(&overrides, query); err != nil {
+		return fmt.Errorf("select messages failed: %v", err)
+	}
+
+	for _, o := range overrides {
+		Override(o.Locale, o.Key, o.Message)
+	}
+	return nil
+}
+
+// normalize make th_th, TH-th and th-TH the same locale th-TH
+func normalize(locale string) string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	parts := strings.SplitN(locale, "-", 2)
+	if len(parts) == 2 {
+		return strings.ToLower(parts[0]) + "-" + strings.ToUpper(parts[1])
+	}
+	return strings.ToLower(locale)
+}
@@ -0,0 +1,292 @@
+// Package imageclean remove the metadata of uploaded images, e.g. the GPS position and the camera of a
+// photo, and turn the pixels upright from the EXIF orientation since the tag is removed with the rest
+package imageclean
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// JpegQuality is used when a JPEG has to be encoded again to rotate it
+const JpegQuality = 90
+
+var (
+	jpegMagic = []byte{0xFF, 0xD8}
+	pngMagic  = []byte("\x89PNG\r\n\x1a\n")
+	exifMagic = []byte("Exif\x00\x00")
+)
+
+// Sanitize return the image without its metadata. An upright JPEG or PNG is only stripped so its pixels are
+// not encoded again, a rotated one is decoded, turned and encoded. Other formats are returned as they are
+func Sanitize(b []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(b, jpegMagic):
+		return sanitizeJpeg(b)
+	case bytes.HasPrefix(b, pngMagic):
+		return sanitizePng(b)
+	default:
+		return b, nil
+	}
+}
+
+// Orientation return the EXIF orientation of a JPEG or PNG, 1 (upright) when there is none
+func Orientation(b []byte) int {
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(b, jpegMagic):
+		segments, _, err := jpegSegments(b)
+		if err != nil {
+			return 1
+		}
+		for _, s := range segments {
+			if s.marker == 0xE1 && bytes.HasPrefix(s.data, exifMagic) {
+				tiff = s.data[len(exifMagic):]
+				break
+			}
+		}
+	case bytes.HasPrefix(b, pngMagic):
+		chunks, err := pngChunks(b)
+		if err != nil {
+			return 1
+		}
+		for _, c := range chunks {
+			if c.kind == "eXIf" {
+				tiff = c.data
+				break
+			}
+		}
+	}
+	return tiffOrientation(tiff)
+}
+
+// tiffOrientation read tag 0x0112 of IFD0 of an EXIF block, 1 when it is missing or invalid
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) || offset < 8 {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		// SHORT, the value is in the first 2 bytes of the value field
+		if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
+
+type jpegSegment struct {
+	marker byte
+	data   []byte // without the marker and the length
+}
+
+// jpegSegments split the header of a JPEG up to the start of scan, rest is the scan and everything after it
+func jpegSegments(b []byte) ([]*jpegSegment, []byte, error) {
+	segments := make([]*jpegSegment, 0)
+	i := 2
+	for i < len(b) {
+		if b[i] != 0xFF {
+			return nil, nil, fmt.Errorf("invalid jpeg marker at %d", i)
+		}
+		// fill bytes
+		for i < len(b) && b[i] == 0xFF {
+			i++
+		}
+		if i >= len(b) {
+			break
+		}
+		marker := b[i]
+		i++
+		if marker == 0xDA {
+			// start of scan, its header and the compressed data are kept as they are
+			return segments, b[i-2:], nil
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			segments = append(segments, &jpegSegment{marker: marker})
+			continue
+		}
+		if i+2 > len(b) {
+			return nil, nil, fmt.Errorf("jpeg segment is cut")
+		}
+		length := int(binary.BigEndian.Uint16(b[i:]))
+		if length < 2 || i+length > len(b) {
+			return nil, nil, fmt.Errorf("jpeg segment is cut")
+		}
+		segments = append(segments, &jpegSegment{marker: marker, data: b[i+2 : i+length]})
+		i += length
+	}
+	return nil, nil, fmt.Errorf("jpeg has no image data")
+}
+
+// metadataSegment report whether a JPEG segment only hold metadata: EXIF and XMP (APP1), IPTC (APP13)
+// and comments. JFIF, the ICC profile and Adobe are kept, the colors depend on them
+func metadataSegment(marker byte) bool {
+	return marker == 0xE1 || marker == 0xED || marker == 0xFE
+}
+
+func sanitizeJpeg(b []byte) ([]byte, error) {
+	segments, rest, err := jpegSegments(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if orientation := Orientation(b); orientation != 1 {
+		img, err := jpeg.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("decode jpeg failed: %v", err)
+		}
+		buf := new(bytes.Buffer)
+		if err := jpeg.Encode(buf, Orient(img, orientation), &jpeg.Options{Quality: JpegQuality}); err != nil {
+			return nil, fmt.Errorf("encode jpeg failed: %v", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(b)))
+	out.Write(jpegMagic)
+	for _, s := range segments {
+		if metadataSegment(s.marker) {
+			continue
+		}
+		out.Write([]byte{0xFF, s.marker})
+		if s.data != nil {
+			binary.Write(out, binary.BigEndian, uint16(len(s.data)+2))
+			out.Write(s.data)
+		}
+	}
+	out.Write(rest)
+	return out.Bytes(), nil
+}
+
+type pngChunk struct {
+	kind string
+	data []byte
+	raw  []byte // the whole chunk with the length and the crc
+}
+
+func pngChunks(b []byte) ([]*pngChunk, error) {
+	chunks := make([]*pngChunk, 0)
+	for i := len(pngMagic); i < len(b); {
+		if i+8 > len(b) {
+			return nil, fmt.Errorf("png chunk is cut")
+		}
+		length := int(binary.BigEndian.Uint32(b[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(b) {
+			return nil, fmt.Errorf("png chunk is cut")
+		}
+		chunks = append(chunks, &pngChunk{kind: string(b[i+4 : i+8]), data: b[i+8 : i+8+length], raw: b[i:end]})
+		i = end
+	}
+	return chunks, nil
+}
+
+// metadataChunk report whether a PNG chunk only hold metadata, text and the time are as private as EXIF
+func metadataChunk(kind string) bool {
+	switch kind {
+	case "eXIf", "tEXt", "iTXt", "zTXt", "tIME":
+		return true
+	}
+	return false
+}
+
+func sanitizePng(b []byte) ([]byte, error) {
+	chunks, err := pngChunks(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if orientation := Orientation(b); orientation != 1 {
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("decode png failed: %v", err)
+		}
+		buf := new(bytes.Buffer)
+		if err := png.Encode(buf, Orient(img, orientation)); err != nil {
+			return nil, fmt.Errorf("encode png failed: %v", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(b)))
+	out.Write(pngMagic)
+	for _, c := range chunks {
+		if metadataChunk(c.kind) {
+			continue
+		}
+		// a damaged chunk would fail in the browser anyway, it is rejected here with a clear error
+		if crc32.ChecksumIEEE(c.raw[4:len(c.raw)-4]) != binary.BigEndian.Uint32(c.raw[len(c.raw)-4:]) {
+			return nil, fmt.Errorf("png chunk %s has a wrong crc", c.kind)
+		}
+		out.Write(c.raw)
+	}
+	return out.Bytes(), nil
+}
+
+// Orient turn img upright from its EXIF orientation, 2 to 8 are mirrored and or rotated
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // upside down and mirrored
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // turned 90 degrees counterclockwise, rotated clockwise back
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // turned 90 degrees clockwise, rotated counterclockwise back
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
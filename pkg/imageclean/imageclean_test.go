@@ -0,0 +1,169 @@
+package imageclean
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testImage is 4x2 blocks of scale pixels, red at the top left block and blue elsewhere
+func testImage(scale int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 4*scale, 2*scale))
+	for y := 0; y < 2*scale; y++ {
+		for x := 0; x < 4*scale; x++ {
+			if x < scale && y < scale {
+				img.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// exifSegment is an APP1 with the orientation and a GPS like string after IFD0
+func exifSegment(orientation uint16) []byte {
+	tiff := new(bytes.Buffer)
+	tiff.WriteString("MM")
+	binary.Write(tiff, binary.BigEndian, uint16(42))
+	binary.Write(tiff, binary.BigEndian, uint32(8))
+	binary.Write(tiff, binary.BigEndian, uint16(1))
+	binary.Write(tiff, binary.BigEndian, uint16(0x0112))
+	binary.Write(tiff, binary.BigEndian, uint16(3))
+	binary.Write(tiff, binary.BigEndian, uint32(1))
+	binary.Write(tiff, binary.BigEndian, orientation)
+	binary.Write(tiff, binary.BigEndian, uint16(0))
+	binary.Write(tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("GPS 13.7563N 100.5018E")
+
+	data := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(data)+2))
+	return append(segment, data...)
+}
+
+func jpegWithExif(t *testing.T, orientation uint16) []byte {
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, testImage(16), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	return append(append(append([]byte{}, b[:2]...), exifSegment(orientation)...), b[2:]...)
+}
+
+func TestSanitizeJpegUpright(t *testing.T) {
+	b := jpegWithExif(t, 1)
+	if !bytes.Contains(b, []byte("GPS")) {
+		t.Fatalf("test jpeg has no metadata")
+	}
+
+	out, err := Sanitize(b)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if bytes.Contains(out, []byte("GPS")) || bytes.Contains(out, []byte("Exif")) {
+		t.Errorf("expected the exif to be removed")
+	}
+	// only the segment is removed, the pixels are not encoded again
+	if len(b)-len(out) != len(exifSegment(1)) {
+		t.Errorf("expected %d bytes less, got %d", len(exifSegment(1)), len(b)-len(out))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("expected a valid jpeg, got: %v", err)
+	}
+}
+
+func TestSanitizeJpegRotated(t *testing.T) {
+	b := jpegWithExif(t, 6)
+	if o := Orientation(b); o != 6 {
+		t.Fatalf("expected orientation 6, got %d", o)
+	}
+
+	out, err := Sanitize(b)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if bytes.Contains(out, []byte("GPS")) {
+		t.Errorf("expected the exif to be removed")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 32 || size.Y != 64 {
+		t.Errorf("expected 32x64 after the rotation, got %v", size)
+	}
+	// the top left corner of the photo is at the top right once turned clockwise
+	if r, _, b, _ := img.At(24, 8).RGBA(); r < b {
+		t.Errorf("expected red at the top right, got r=%d b=%d", r, b)
+	}
+}
+
+func TestSanitizePng(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, testImage(1)); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	// tEXt after IHDR, 8 bytes signature and 25 bytes IHDR
+	text := []byte("tEXtAuthor\x00Somchai")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)-4))
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(text))
+	b = append(append(append([]byte{}, b[:33]...), chunk...), b[33:]...)
+
+	out, err := Sanitize(b)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if bytes.Contains(out, []byte("Somchai")) {
+		t.Errorf("expected the text chunk to be removed")
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("expected a valid png, got: %v", err)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	tests := []struct {
+		orientation int
+		size        image.Point
+		red         image.Point
+	}{
+		{orientation: 1, size: image.Pt(4, 2), red: image.Pt(0, 0)},
+		{orientation: 2, size: image.Pt(4, 2), red: image.Pt(3, 0)},
+		{orientation: 3, size: image.Pt(4, 2), red: image.Pt(3, 1)},
+		{orientation: 4, size: image.Pt(4, 2), red: image.Pt(0, 1)},
+		{orientation: 5, size: image.Pt(2, 4), red: image.Pt(0, 0)},
+		{orientation: 6, size: image.Pt(2, 4), red: image.Pt(1, 0)},
+		{orientation: 7, size: image.Pt(2, 4), red: image.Pt(1, 3)},
+		{orientation: 8, size: image.Pt(2, 4), red: image.Pt(0, 3)},
+	}
+
+	for _, test := range tests {
+		img := Orient(testImage(1), test.orientation)
+		if size := img.Bounds().Size(); size != test.size {
+			t.Errorf("orientation %d: expected size %v, got %v", test.orientation, test.size, size)
+			continue
+		}
+		if r, _, _, _ := img.At(test.red.X, test.red.Y).RGBA(); r == 0 {
+			t.Errorf("orientation %d: expected red at %v", test.orientation, test.red)
+		}
+	}
+}
+
+func TestSanitizeInvalid(t *testing.T) {
+	if _, err := Sanitize([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00}); err == nil {
+		t.Errorf("expected an error for a cut jpeg")
+	}
+	other := []byte("GIF89a")
+	if out, err := Sanitize(other); err != nil || !bytes.Equal(out, other) {
+		t.Errorf("expected other formats to be kept, got %v %v", out, err)
+	}
+}
@@ -0,0 +1,88 @@
+package imagehash
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+)
+
+const (
+	hashWidth  = 9
+	hashHeight = 8
+	// HashBits is the length of the embedding vector stored in postgres
+	HashBits = (hashWidth - 1) * hashHeight
+)
+
+// DHash compute a 64 bits difference hash, visually similar images produce hashes with a small hamming distance
+func DHash(r io.Reader) (uint64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("decode image failed: %v", err)
+	}
+
+	grid := grayscaleGrid(img)
+
+	var hash uint64
+	for y := 0; y < hashHeight; y++ {
+		for x := 0; x < hashWidth-1; x++ {
+			hash <<= 1
+			if grid[y][x] < grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// grayscaleGrid shrink the image to hashWidth x hashHeight by averaging the luminance of each block
+func grayscaleGrid(img image.Image) [hashHeight][hashWidth]float64 {
+	var grid [hashHeight][hashWidth]float64
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	for gy := 0; gy < hashHeight; gy++ {
+		y0 := bounds.Min.Y + gy*h/hashHeight
+		y1 := bounds.Min.Y + (gy+1)*h/hashHeight
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for gx := 0; gx < hashWidth; gx++ {
+			x0 := bounds.Min.X + gx*w/hashWidth
+			x1 := bounds.Min.X + (gx+1)*w/hashWidth
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var sum float64
+			var count int
+			for y := y0; y < y1 && y < bounds.Max.Y; y++ {
+				for x := x0; x < x1 && x < bounds.Max.X; x++ {
+					r, g, b, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			if count > 0 {
+				grid[gy][gx] = sum / float64(count)
+			}
+		}
+	}
+	return grid
+}
+
+// ToVector format the hash as a pgvector literal, one dimension per bit
+// the euclidean distance between two vectors is the square root of the hamming distance
+func ToVector(hash uint64) string {
+	bits := make([]string, HashBits)
+	for i := 0; i < HashBits; i++ {
+		if hash&(1<<uint(HashBits-1-i)) != 0 {
+			bits[i] = "1"
+		} else {
+			bits[i] = "0"
+		}
+	}
+	return "[" + strings.Join(bits, ",") + "]"
+}
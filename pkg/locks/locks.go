@@ -0,0 +1,134 @@
+// Package locks serialize work between the instances of the server behind a load balancer with Postgres
+// advisory locks, so no other service is needed. A lock is a name, e.g. "files.deletion" or "stock:<product id>"
+package locks
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// unlockTimeout is how long releasing a lock can take, the connection is dropped when it fails
+const unlockTimeout = 5 * time.Second
+
+type ILocker interface {
+	// TryRun run fn when no other instance hold the lock, ran is false when it is held. Used by the jobs
+	// which run on every instance, one of them does the work and the others skip the tick
+	TryRun(name string, fn func() error) (ran bool, err error)
+	// Run wait for the lock until ctx is done, then run fn
+	Run(ctx context.Context, name string, fn func() error) error
+}
+
+type dbLocker struct {
+	db *sqlx.DB
+}
+
+func DbLocker(db *sqlx.DB) ILocker {
+	return &dbLocker{
+		db: db,
+	}
+}
+
+// Key is the advisory lock key of a name, the same on every instance
+func Key(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// StockKey is the lock of the stock of a product
+func StockKey(productId string) string {
+	return "stock:" + productId
+}
+
+func (l *dbLocker) TryRun(name string, fn func() error) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a session lock belong to the connection, the same connection must unlock it
+	conn, err := l.db.Connx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get connection failed: %v", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1);`, Key(name)); err != nil {
+		return false, fmt.Errorf("lock %s failed: %v", name, err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer unlock(conn, name)
+
+	return true, fn()
+}
+
+func (l *dbLocker) Run(ctx context.Context, name string, fn func() error) error {
+	conn, err := l.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection failed: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1);`, Key(name)); err != nil {
+		return fmt.Errorf("lock %s failed: %v", name, err)
+	}
+	defer unlock(conn, name)
+
+	return fn()
+}
+
+// unlock release a session lock, a connection which could not release it is dropped from the pool so
+// Postgres release the lock when the session end
+func unlock(conn *sqlx.Conn, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1);`, Key(name)); err != nil {
+		log.Printf("unlock %s failed: %v\n", name, err)
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
+
+// LockTx take the locks until tx is committed or rolled back. The keys are taken in order so two
+// transactions which lock the same names cannot deadlock
+func LockTx(ctx context.Context, tx *sqlx.Tx, names ...string) error {
+	for _, key := range sortedKeys(names) {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1);`, key); err != nil {
+			return fmt.Errorf("lock failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// LockStock take the stock locks of the products until tx end, every change of the stock take them
+func LockStock(ctx context.Context, tx *sqlx.Tx, productIds ...string) error {
+	names := make([]string, 0, len(productIds))
+	for _, id := range productIds {
+		names = append(names, StockKey(id))
+	}
+	return LockTx(ctx, tx, names...)
+}
+
+// sortedKeys return the keys of the names in order without duplicates
+func sortedKeys(names []string) []int64 {
+	seen := make(map[int64]bool, len(names))
+	keys := make([]int64, 0, len(names))
+	for _, name := range names {
+		key := Key(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,36 @@
+package locks
+
+import "testing"
+
+func TestKey(t *testing.T) {
+	if Key("files.deletion") != Key("files.deletion") {
+		t.Errorf("expected the same key for the same name")
+	}
+	if Key("files.deletion") == Key("inventory.forecast") {
+		t.Errorf("expected different keys for different names")
+	}
+	if Key(StockKey("p1")) == Key(StockKey("p2")) {
+		t.Errorf("expected a lock per product")
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	keys := sortedKeys([]string{StockKey("p3"), StockKey("p1"), StockKey("p3"), StockKey("p2")})
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 keys without the duplicate, got %d", len(keys))
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			t.Errorf("expected the keys in order, got %v", keys)
+		}
+	}
+
+	// the order does not depend on the order of the names
+	other := sortedKeys([]string{StockKey("p2"), StockKey("p1"), StockKey("p3")})
+	for i := range keys {
+		if keys[i] != other[i] {
+			t.Errorf("expected %v, got %v", keys, other)
+			break
+		}
+	}
+}
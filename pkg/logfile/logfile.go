@@ -0,0 +1,208 @@
+// Package logfile append lines to a file from a goroutine and rotate it by size and time, so a request
+// never wait for the disk. Rotated files are renamed next to it, e.g. access-20240515T100000.log, which is
+// what filebeat and logrotate expect
+package logfile
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	Path     string
+	MaxBytes int64         // rotate when the file would be larger, 0 never by size
+	Every    time.Duration // rotate on each multiple of Every in UTC, e.g. 24h at midnight, 0 never by time
+	MaxFiles int           // rotated files which are kept, 0 keep every file
+	Buffer   int           // lines waiting for the disk, a line is dropped when it is full
+}
+
+type Writer struct {
+	cfg   Config
+	lines chan []byte
+	done  chan struct{}
+	now   func() time.Time
+
+	mu     sync.RWMutex
+	closed bool
+
+	dropped atomic.Int64
+
+	// only used by the goroutine
+	file     *os.File
+	size     int64
+	rotateAt time.Time
+}
+
+// New open the file, it is created with its directory when it does not exist
+func New(cfg Config) (*Writer, error) {
+	if cfg.Buffer < 1 {
+		cfg.Buffer = 1024
+	}
+	w := &Writer{
+		cfg:   cfg,
+		lines: make(chan []byte, cfg.Buffer),
+		done:  make(chan struct{}),
+		now:   time.Now,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write queue a copy of p, it never block. A line is dropped when the buffer is full, see Dropped
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, fmt.Errorf("log file is closed")
+	}
+	line := make([]byte, len(p))
+	copy(line, p)
+	select {
+	case w.lines <- line:
+	default:
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Dropped is the number of lines dropped because the disk was slower than the requests
+func (w *Writer) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close write the queued lines and close the file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.lines)
+	w.mu.Unlock()
+
+	<-w.done
+	return w.file.Close()
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	for line := range w.lines {
+		w.write(line)
+	}
+}
+
+func (w *Writer) write(line []byte) {
+	if w.due(len(line)) {
+		if err := w.rotate(); err != nil {
+			log.Printf("rotate %s failed: %v", w.cfg.Path, err)
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		log.Printf("write %s failed: %v", w.cfg.Path, err)
+	}
+}
+
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.cfg.Path), 0755); err != nil {
+		return fmt.Errorf("create log dir failed: %v", err)
+	}
+	file, err := os.OpenFile(w.cfg.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file failed: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open log file failed: %v", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	// a file left by the previous run is rotated on the period of its last write
+	openedAt := w.now()
+	if info.Size() > 0 {
+		openedAt = info.ModTime()
+	}
+	if w.cfg.Every > 0 {
+		w.rotateAt = openedAt.UTC().Truncate(w.cfg.Every).Add(w.cfg.Every)
+	}
+	return nil
+}
+
+// due report whether the file must be rotated before a line of n bytes, an empty file is never rotated
+func (w *Writer) due(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.cfg.MaxBytes > 0 && w.size+int64(n) > w.cfg.MaxBytes {
+		return true
+	}
+	return !w.rotateAt.IsZero() && !w.now().Before(w.rotateAt)
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(w.cfg.Path)
+	base := strings.TrimSuffix(w.cfg.Path, ext)
+	name := fmt.Sprintf("%s-%s%s", base, w.now().UTC().Format("20060102T150405"), ext)
+	// rotated twice in a second
+	for i := 1; exists(name); i++ {
+		name = fmt.Sprintf("%s-%s.%d%s", base, w.now().UTC().Format("20060102T150405"), i, ext)
+	}
+	renameErr := os.Rename(w.cfg.Path, name)
+
+	// the lines must still be written when the rename failed
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	w.size = 0
+	if w.cfg.Every > 0 {
+		w.rotateAt = w.now().UTC().Truncate(w.cfg.Every).Add(w.cfg.Every)
+	}
+	return w.removeOld(base, ext)
+}
+
+// removeOld keep the newest MaxFiles rotated files, their names sort by the time they were rotated
+func (w *Writer) removeOld(base, ext string) error {
+	if w.cfg.MaxFiles < 1 {
+		return nil
+	}
+	rotated, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	sort.Strings(rotated)
+	for len(rotated) > w.cfg.MaxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			return err
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,119 @@
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	w, err := New(Config{Path: path, MaxBytes: 10, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// แต่ละบรรทัด 6 bytes ไฟล์จึงมีได้บรรทัดเดียว
+	for _, line := range []string{"line1\n", "line2\n", "line3\n", "line4\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	names := readDir(t, dir)
+	if len(names) != 3 {
+		t.Fatalf("expected the file and 2 rotated files, got: %v", names)
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "line4\n" {
+		t.Errorf("expected: line4, got: %q", current)
+	}
+	for _, name := range names {
+		if name != "access.log" && !strings.HasPrefix(name, "access-") {
+			t.Errorf("unexpected rotated file: %s", name)
+		}
+	}
+}
+
+func TestRotateByTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	now := time.Date(2024, 5, 15, 23, 59, 0, 0, time.UTC)
+
+	// เขียนตรงโดยไม่มี goroutine เพื่อเลื่อนเวลาระหว่างบรรทัดได้
+	w := &Writer{
+		cfg: Config{Path: path, Every: 24 * time.Hour},
+		now: func() time.Time { return now },
+	}
+	if err := w.open(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	w.write([]byte("before\n"))
+	// ไฟล์เดิมยังไม่ถึงเที่ยงคืน
+	now = now.Add(30 * time.Second)
+	w.write([]byte("same day\n"))
+	now = now.Add(time.Minute)
+	w.write([]byte("next day\n"))
+	if err := w.file.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	rotated, _ := os.ReadFile(filepath.Join(dir, "access-20240516T000030.log"))
+	if string(rotated) != "before\nsame day\n" {
+		t.Errorf("expected the lines of the previous day, got: %q (files %v)", rotated, readDir(t, dir))
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "next day\n" {
+		t.Errorf("expected: next day, got: %q", current)
+	}
+}
+
+func TestDropWhenFull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	// ไม่มี goroutine อ่าน buffer จึงเต็มหลังบรรทัดแรก
+	w := &Writer{
+		cfg:   Config{Path: path},
+		lines: make(chan []byte, 1),
+		done:  make(chan struct{}),
+		now:   time.Now,
+	}
+	w.Write([]byte("kept\n"))
+	w.Write([]byte("dropped\n"))
+	if w.Dropped() != 1 {
+		t.Errorf("expected 1 dropped line, got: %d", w.Dropped())
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	w, err := New(Config{Path: filepath.Join(t.TempDir(), "access.log")})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Error("expected an error after close")
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("expected a second close to do nothing, got: %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+// Package openapi build an OpenAPI 3 document from the routes of the server, the schemas are read from the
+// json and query tags of the request and response types so they follow the code
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenApi    string               `json:"openapi"`
+	Info       *Info                `json:"info"`
+	Tags       []*Tag               `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components"`
+
+	errorSchema *Schema
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem is the operations of a path by method, e.g. "get"
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	OperationId string                `json:"operationId,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path, query or header
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"` // http or apiKey
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// File and Files are the schemas of the file fields of a multipart form
+var (
+	File  = &Schema{Type: "string", Format: "binary"}
+	Files = &Schema{Type: "array", Items: File}
+)
+
+// Route is an operation of the server. Only Method and Path are required, a route which is not documented
+// is still listed with its path parameters
+type Route struct {
+	Method     string // GET, POST, ...
+	Path       string // path of fiber, e.g. /v1/products/:productId
+	Tag        string
+	Id         string // operationId, used as the function name by generated clients
+	Summary    string
+	Security   []string // names given to AddSecurity, any of them is accepted
+	Deprecated bool
+
+	Query    any                // struct whose query tags are the query parameters
+	Body     any                // the json body
+	Form     map[string]*Schema // the fields of a multipart/form-data body
+	Status   int                // status of the success, 200 when not set
+	Response any                // the json of the success, no content when nil
+}
+
+// New return an empty document, errorRes is the json of every error response when it is not nil
+func New(title, version string, errorRes any) *Document {
+	d := &Document{
+		OpenApi: Version,
+		Info:    &Info{Title: title, Version: version},
+		Paths:   make(map[string]*PathItem),
+		Components: &Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+	if errorRes != nil {
+		d.errorSchema = d.Schema(errorRes)
+	}
+	return d
+}
+
+func (d *Document) AddSecurity(name string, scheme *SecurityScheme) {
+	d.Components.SecuritySchemes[name] = scheme
+}
+
+// Add the route to the document, a route which is added again replace the previous one
+func (d *Document) Add(r *Route) {
+	path, params := convertPath(r.Path)
+	op := &Operation{
+		Summary:     r.Summary,
+		OperationId: r.Id,
+		Deprecated:  r.Deprecated,
+		Parameters:  params,
+		Responses:   make(map[string]*Response),
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+		d.addTag(r.Tag)
+	}
+	for _, name := range r.Security {
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+	if r.Query != nil {
+		op.Parameters = append(op.Parameters, d.queryParameters(reflect.TypeOf(r.Query))...)
+	}
+
+	switch {
+	case r.Body != nil:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: d.Schema(r.Body)}},
+		}
+	case len(r.Form) > 0:
+		form := &Schema{Type: "object", Properties: r.Form}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"multipart/form-data": {Schema: form}},
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	res := &Response{Description: http.StatusText(status)}
+	if r.Response != nil && status != http.StatusNoContent {
+		res.Content = map[string]*MediaType{"application/json": {Schema: d.Schema(r.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = res
+	if d.errorSchema != nil {
+		op.Responses["default"] = &Response{
+			Description: "Error",
+			Content:     map[string]*MediaType{"application/json": {Schema: d.errorSchema}},
+		}
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[strings.ToLower(r.Method)] = op
+}
+
+func (d *Document) addTag(name string) {
+	for _, tag := range d.Tags {
+		if tag.Name == name {
+			return
+		}
+	}
+	d.Tags = append(d.Tags, &Tag{Name: name})
+	sort.Slice(d.Tags, func(i, j int) bool { return d.Tags[i].Name < d.Tags[j].Name })
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)\??|\*|\+`)
+
+// convertPath turn the parameters of fiber into the parameters of OpenAPI, :id and :id? are {id} and a
+// wildcard * or + is {path}
+func convertPath(path string) (string, []*Parameter) {
+	params := make([]*Parameter, 0)
+	converted := pathParam.ReplaceAllStringFunc(path, func(match string) string {
+		name := "path"
+		if strings.HasPrefix(match, ":") {
+			name = strings.TrimSuffix(strings.TrimPrefix(match, ":"), "?")
+		}
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+		return "{" + name + "}"
+	})
+	return converted, params
+}
+
+func (d *Document) queryParameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	params := make([]*Parameter, 0)
+	if t.Kind() != reflect.Struct {
+		return params
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("query"), ",")[0]
+		if f.Anonymous && name == "" {
+			params = append(params, d.queryParameters(f.Type)...)
+			continue
+		}
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		params = append(params, &Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: d.schemaOf(f.Type),
+		})
+	}
+	return params
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Schema return the schema of the json of v, a named struct is added to the components and referenced
+func (d *Document) Schema(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// the json is up to its MarshalJSON
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.object(t)
+		}
+		name := componentName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// set first so a type which contains itself refers to the component
+			d.Components.Schemas[name] = &Schema{Type: "object"}
+			d.Components.Schemas[name] = d.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		// any
+		return &Schema{}
+	}
+}
+
+func (d *Document) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.properties(t, s.Properties)
+	return s
+}
+
+func (d *Document) properties(t reflect.Type, props map[string]*Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" && len(tag) == 1 {
+			continue
+		}
+		// an embedded struct without a name is flattened like encoding/json
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.properties(ft, props)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := d.schemaOf(f.Type)
+		for _, opt := range tag[1:] {
+			if opt == "string" {
+				schema = &Schema{Type: "string"}
+			}
+		}
+		props[name] = schema
+	}
+}
+
+var invalidName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// componentName is the package and the name of the type, e.g. products.Products
+func componentName(t reflect.Type) string {
+	return strings.Trim(invalidName.ReplaceAllString(t.String(), "_"), "_")
+}
@@ -0,0 +1,112 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type page struct {
+	Page  int `json:"page" query:"page"`
+	Limit int `json:"limit" query:"limit"`
+}
+
+type item struct {
+	Id        string            `json:"id"`
+	Price     float64           `json:"price"`
+	Stock     *int              `json:"stock,omitempty"`
+	Tags      []string          `json:"tags"`
+	Attrs     map[string]string `json:"attributes"`
+	Parent    *item             `json:"parent,omitempty"`
+	Secret    string            `json:"-"`
+	Version   int64             `json:"version,string"`
+	CreatedAt time.Time         `json:"created_at"`
+	Extra     json.RawMessage   `json:"extra"`
+	internal  string
+}
+
+type itemFilter struct {
+	*page
+	Search string `query:"search"`
+	Ignore string
+}
+
+type errorRes struct {
+	Msg string `json:"message"`
+}
+
+func TestAdd(t *testing.T) {
+	d := New("ri-shop", "v1", &errorRes{})
+	d.Add(&Route{
+		Method:   "GET",
+		Path:     "/v1/items/:itemId/:variant?",
+		Tag:      "items",
+		Security: []string{"bearerAuth"},
+		Query:    &itemFilter{},
+		Response: &item{},
+	})
+	d.Add(&Route{Method: "POST", Path: "/v1/files/*", Form: map[string]*Schema{"files": Files}, Status: 201})
+
+	op := (*d.Paths["/v1/items/{itemId}/{variant}"])["get"]
+	if op == nil {
+		t.Fatalf("expected the path to be converted, got: %v", d.Paths)
+	}
+	names := make([]string, 0)
+	for _, param := range op.Parameters {
+		names = append(names, param.In+":"+param.Name)
+	}
+	expect := []string{"path:itemId", "path:variant", "query:page", "query:limit", "query:search"}
+	if len(names) != len(expect) {
+		t.Fatalf("expected: %v, got: %v", expect, names)
+	}
+	for i := range expect {
+		if names[i] != expect[i] {
+			t.Errorf("expected: %v, got: %v", expect, names)
+		}
+	}
+	if op.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/openapi.item" {
+		t.Errorf("expected a reference to the item")
+	}
+	if op.Responses["default"].Content["application/json"].Schema.Ref != "#/components/schemas/openapi.errorRes" {
+		t.Errorf("expected the error response")
+	}
+
+	upload := (*d.Paths["/v1/files/{path}"])["post"]
+	if upload == nil || upload.RequestBody.Content["multipart/form-data"] == nil || upload.Responses["201"] == nil {
+		t.Errorf("expected a multipart upload which return 201, got: %+v", upload)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	d := New("ri-shop", "v1", nil)
+	d.Schema([]*item{})
+
+	props := d.Components.Schemas["openapi.item"].Properties
+	tests := map[string]string{
+		"id":         "string",
+		"price":      "number",
+		"stock":      "integer",
+		"tags":       "array",
+		"attributes": "object",
+		"version":    "string",
+		"created_at": "string",
+		"extra":      "",
+	}
+	for name, expect := range tests {
+		if props[name] == nil || props[name].Type != expect {
+			t.Errorf("%s: expected: %q, got: %+v", name, expect, props[name])
+		}
+	}
+	if props["parent"] == nil || props["parent"].Ref != "#/components/schemas/openapi.item" {
+		t.Errorf("expected parent to refer to the item, got: %+v", props["parent"])
+	}
+	for _, name := range []string{"Secret", "-", "internal"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("expected %s to be skipped", name)
+		}
+	}
+
+	if _, err := json.Marshal(d); err != nil {
+		t.Errorf("expected the document to marshal, got: %v", err)
+	}
+}
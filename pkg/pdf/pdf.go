@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page in points
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+// Document is a text only PDF with the standard Courier fonts, which every reader has so nothing is embedded.
+// Courier is monospaced, columns padded with spaces stay aligned. Only Latin-1 can be written, other
+// characters are printed as "?"
+type Document struct {
+	pages []*bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{
+		pages: make([]*bytes.Buffer, 0),
+	}
+}
+
+// AddPage start a new page, Text and Line draw on the last page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text write s with its baseline at y, x and y are from the top left corner of the page
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PageHeight-y, escape(s))
+}
+
+// Line draw a thin line, x and y are from the top left corner of the page
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// margin of the page and line height of FromText
+const (
+	Margin      = 40.0
+	LineSpacing = 1.3
+)
+
+// FromText lay out text line by line from the top of the page and start a new page when it is full,
+// a line starting with "\f" start a new page too
+func FromText(text string, size float64) *Document {
+	d := New()
+	d.AddPage()
+	y := Margin + size
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if strings.HasPrefix(line, "\f") || y > PageHeight-Margin {
+			d.AddPage()
+			y = Margin + size
+			line = strings.TrimPrefix(line, "\f")
+		}
+		d.Text(Margin, y, size, false, line)
+		y += size * LineSpacing
+	}
+	return d
+}
+
+// Bytes return the whole file
+func (d *Document) Bytes() []byte {
+	d.page()
+
+	// 1 catalog, 2 pages, 3 and 4 fonts, then a page and its content for every page
+	objects := []string{
+		"",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	kids := make([]string, 0, len(d.pages))
+	for _, content := range d.pages {
+		pageId := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageId))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", PageWidth, PageHeight, pageId+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	out := new(bytes.Buffer)
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// escape turn s into the bytes of a PDF string
+func escape(s string) string {
+	b := new(strings.Builder)
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in     string
+		expect string
+	}{
+		{in: "Total (THB)", expect: `Total \(THB\)`},
+		{in: `C:\invoices`, expect: `C:\\invoices`},
+		{in: "café", expect: "caf\xe9"},
+		{in: "ภาษี 7%", expect: "???? 7%"},
+	}
+
+	for _, test := range tests {
+		if out := escape(test.in); out != test.expect {
+			t.Errorf("escape(%q): expected: %q, got: %q", test.in, test.expect, out)
+		}
+	}
+}
+
+func TestFromTextPages(t *testing.T) {
+	lines := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	lines = append(lines, "\fterms")
+
+	b := FromText(strings.Join(lines, "\n"), 10).Bytes()
+	count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(b)
+	if count == nil {
+		t.Fatalf("pages object not found")
+	}
+	// 10pt lines are 13pt apart, 59 lines fit on a page
+	if n, _ := strconv.Atoi(string(count[1])); n != 3 {
+		t.Errorf("expected 3 pages, got: %d", n)
+	}
+	if !bytes.Contains(b, []byte("(line 99) Tj")) || !bytes.Contains(b, []byte("(terms) Tj")) {
+		t.Errorf("text is missing")
+	}
+}
+
+func TestBytesXref(t *testing.T) {
+	b := New().Bytes()
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatalf("not a pdf: %q", b)
+	}
+
+	// every xref entry must point at the start of its object
+	start := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(b)
+	xref, _ := strconv.Atoi(string(start[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(b[xref:], -1)
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 objects, got: %d", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if prefix := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(b[offset:], []byte(prefix)) {
+			t.Errorf("object %d: offset %d does not start with %q", i+1, offset, prefix)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package resilience retry the calls to an external dependency (the bucket, the email providers) and stop
+// calling it for a while after it failed several times in a row, so an outage fail the requests at once
+// instead of holding their goroutines until the timeout
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Settings struct {
+	Attempts  int           // tries of a call, 1 does not retry
+	BaseDelay time.Duration // the largest delay before the first retry, doubled for each further retry
+	MaxDelay  time.Duration
+	Failures  int           // failed calls in a row which open the breaker, 0 never opens
+	OpenFor   time.Duration // an open breaker fail the calls for this long, then one call is tried
+}
+
+var DefaultSettings = Settings{
+	Attempts:  3,
+	BaseDelay: 100 * time.Millisecond,
+	MaxDelay:  2 * time.Second,
+	Failures:  5,
+	OpenFor:   30 * time.Second,
+}
+
+var settings atomic.Pointer[Settings]
+
+func init() {
+	settings.Store(&DefaultSettings)
+}
+
+// Configure set the settings of every breaker, it is called once at startup
+func Configure(s Settings) {
+	if s.Attempts < 1 {
+		s.Attempts = 1
+	}
+	settings.Store(&s)
+}
+
+type State string
+
+const (
+	Closed   State = "closed"
+	Open     State = "open"
+	HalfOpen State = "half_open" // a call is trying whether the dependency is back
+)
+
+// OpenError is returned without calling the dependency while its breaker is open
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable, retry after %s", e.Name, e.RetryAfter.Round(time.Second))
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent mark an answer of the dependency which is not an outage, e.g. an object which does not exist.
+// It is returned without a retry and does not count as a failure of the breaker
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type Breaker struct {
+	name string
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trying   bool // the call of the half open breaker is running
+	lastErr  string
+
+	opened atomic.Int64
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*Breaker)
+)
+
+// Get return the breaker of a dependency, it is created on the first call
+func Get(name string) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	b, ok := breakers[name]
+	if !ok {
+		b = &Breaker{name: name, now: time.Now, state: Closed}
+		breakers[name] = b
+	}
+	return b
+}
+
+// Do call fn until it succeeds or the attempts are used. Every try get ctx, a retry wait with jitter and
+// stop when ctx is done
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	s := settings.Load()
+
+	if err := b.allow(s); err != nil {
+		return err
+	}
+
+	var err error
+	for attempt := 1; attempt <= s.Attempts; attempt++ {
+		err = fn(ctx)
+		var permanent *permanentError
+		if err == nil || errors.As(err, &permanent) {
+			b.done(s, nil)
+			if permanent != nil {
+				return permanent.err
+			}
+			return nil
+		}
+		if attempt == s.Attempts || ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-time.After(backoff(s, attempt)):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	// the caller gave up, that says nothing about the dependency
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		b.release()
+		return err
+	}
+	b.done(s, err)
+	return err
+}
+
+// backoff is a random delay up to BaseDelay doubled for each retry (full jitter), so the instances do not
+// retry at the same time
+func backoff(s *Settings, attempt int) time.Duration {
+	limit := s.BaseDelay << (attempt - 1)
+	if limit <= 0 || limit > s.MaxDelay {
+		limit = s.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+func (b *Breaker) allow(s *Settings) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if wait := b.openedAt.Add(s.OpenFor).Sub(b.now()); wait > 0 {
+			return &OpenError{Name: b.name, RetryAfter: wait}
+		}
+		b.state = HalfOpen
+		b.trying = true
+		return nil
+	case HalfOpen:
+		// มีคนลองอยู่แล้ว คนอื่นรอผล
+		if b.trying {
+			return &OpenError{Name: b.name, RetryAfter: time.Second}
+		}
+		b.trying = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// release free the half-open try without a result, the next call try again
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trying = false
+}
+
+func (b *Breaker) done(s *Settings, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trying = false
+	if err == nil {
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	b.lastErr = err.Error()
+	if b.state == HalfOpen || (s.Failures > 0 && b.failures >= s.Failures) {
+		if b.state != Open {
+			b.opened.Add(1)
+		}
+		b.state = Open
+		b.openedAt = b.now()
+	}
+}
+
+// Status is a breaker at the time it is read, for the metrics
+type Status struct {
+	Name      string `json:"name"`
+	State     State  `json:"state"`
+	Failures  int    `json:"failures"` // failed calls in a row
+	Opened    int64  `json:"opened"`   // times the breaker opened since startup
+	OpenedAt  string `json:"opened_at,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+func (b *Breaker) Status() *Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := &Status{
+		Name:      b.name,
+		State:     b.state,
+		Failures:  b.failures,
+		Opened:    b.opened.Load(),
+		LastError: b.lastErr,
+	}
+	if !b.openedAt.IsZero() {
+		status.OpenedAt = b.openedAt.UTC().Format(time.RFC3339)
+	}
+	return status
+}
+
+// Statuses return every breaker sorted by name
+func Statuses() []*Status {
+	breakersMu.Lock()
+	list := make([]*Breaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersMu.Unlock()
+
+	statuses := make([]*Status, 0, len(list))
+	for _, b := range list {
+		statuses = append(statuses, b.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,150 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("connection refused")
+
+func newBreaker(now *time.Time) *Breaker {
+	return &Breaker{name: "test", now: func() time.Time { return *now }, state: Closed}
+}
+
+func TestRetry(t *testing.T) {
+	Configure(Settings{Attempts: 3, Failures: 5, OpenFor: time.Minute})
+	defer Configure(DefaultSettings)
+
+	now := time.Now()
+	b := newBreaker(&now)
+	calls := 0
+	err := b.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errDown
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("expected a success on the third try, got: %v after %d calls", err, calls)
+	}
+
+	// ไม่ retry error ที่ไม่ใช่ outage
+	calls = 0
+	notFound := errors.New("object not found")
+	err = b.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return Permanent(notFound)
+	})
+	if err != notFound || calls != 1 {
+		t.Errorf("expected the permanent error once, got: %v after %d calls", err, calls)
+	}
+	if b.Status().Failures != 0 {
+		t.Errorf("expected a permanent error not to count, got: %d failures", b.Status().Failures)
+	}
+}
+
+func TestBreakerOpen(t *testing.T) {
+	Configure(Settings{Attempts: 1, Failures: 2, OpenFor: time.Minute})
+	defer Configure(DefaultSettings)
+
+	now := time.Now()
+	b := newBreaker(&now)
+	fail := func(ctx context.Context) error { return errDown }
+	for i := 0; i < 2; i++ {
+		if err := b.Do(context.Background(), fail); err != errDown {
+			t.Fatalf("expected: %v, got: %v", errDown, err)
+		}
+	}
+	if b.Status().State != Open {
+		t.Fatalf("expected the breaker to open, got: %s", b.Status().State)
+	}
+
+	called := false
+	err := b.Do(context.Background(), func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	var openErr *OpenError
+	if !errors.As(err, &openErr) || called {
+		t.Fatalf("expected an open error without a call, got: %v (called %v)", err, called)
+	}
+	if openErr.RetryAfter != time.Minute {
+		t.Errorf("expected retry after: %s, got: %s", time.Minute, openErr.RetryAfter)
+	}
+
+	// หลัง OpenFor ลองได้หนึ่งครั้ง ถ้าผ่านก็ปิด
+	now = now.Add(time.Minute)
+	if err := b.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected the trial call to run, got: %v", err)
+	}
+	if status := b.Status(); status.State != Closed || status.Opened != 1 {
+		t.Errorf("expected closed after opening once, got: %s, opened %d", status.State, status.Opened)
+	}
+}
+
+func TestBreakerHalfOpenFail(t *testing.T) {
+	Configure(Settings{Attempts: 1, Failures: 1, OpenFor: time.Minute})
+	defer Configure(DefaultSettings)
+
+	now := time.Now()
+	b := newBreaker(&now)
+	b.Do(context.Background(), func(ctx context.Context) error { return errDown })
+
+	now = now.Add(time.Minute)
+	b.Do(context.Background(), func(ctx context.Context) error { return errDown })
+	if status := b.Status(); status.State != Open || status.LastError != errDown.Error() {
+		t.Errorf("expected a failed trial to open again, got: %s, %s", status.State, status.LastError)
+	}
+}
+
+func TestBreakerHalfOpenCanceled(t *testing.T) {
+	Configure(Settings{Attempts: 1, Failures: 1, OpenFor: time.Minute})
+	defer Configure(DefaultSettings)
+
+	now := time.Now()
+	b := newBreaker(&now)
+	b.Do(context.Background(), func(ctx context.Context) error { return errDown })
+
+	// the caller left during the trial, the slot is free again and the breaker does not open
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	err := b.Do(ctx, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected: %v, got: %v", context.Canceled, err)
+	}
+	if status := b.Status(); status.State != HalfOpen || status.Failures != 1 {
+		t.Fatalf("expected half open with 1 failure, got: %s, %d", status.State, status.Failures)
+	}
+
+	if err := b.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected the next trial to run, got: %v", err)
+	}
+	if b.Status().State != Closed {
+		t.Errorf("expected closed, got: %s", b.Status().State)
+	}
+}
+
+func TestRetryStopOnContext(t *testing.T) {
+	Configure(Settings{Attempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+	defer Configure(DefaultSettings)
+
+	now := time.Now()
+	b := newBreaker(&now)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	err := b.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return errDown
+	})
+	if err != errDown || calls > 2 {
+		t.Errorf("expected to stop waiting when the context is done, got: %v after %d calls", err, calls)
+	}
+}
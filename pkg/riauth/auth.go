@@ -8,9 +8,18 @@ import (
 
 	"github.com/NatthawutSK/ri-shop/config"
 	"github.com/NatthawutSK/ri-shop/modules/users"
+	"github.com/NatthawutSK/ri-shop/pkg/clock"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// clk issue and expire every token, the server set a test clock outside production
+var clk = clock.Real()
+
+// SetClock must be called before any token is signed or parsed
+func SetClock(c clock.Clock) {
+	clk = c
+}
+
 type TokenType string
 
 const (
@@ -69,7 +78,7 @@ func (a *riApiKey) SignToken() string {
 }
 
 func jwtTimeDuration(t int) *jwt.NumericDate {
-	return jwt.NewNumericDate(time.Now().Add(time.Duration(int64(t) * int64(math.Pow10(9)))))
+	return jwt.NewNumericDate(clk.Now().Add(time.Duration(int64(t) * int64(math.Pow10(9)))))
 }
 
 func jwtTimeRepeatAdapter(t int64) *jwt.NumericDate {
@@ -82,7 +91,7 @@ func ParseToken(cfg config.IJwtConfig, tokenString string) (*riMapClaims, error)
 			return nil, fmt.Errorf("signing method is invalid")
 		}
 		return cfg.SecretKey(), nil
-	})
+	}, jwt.WithTimeFunc(clk.Now))
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenMalformed) {
 			return nil, fmt.Errorf("token format is invalid")
@@ -107,7 +116,7 @@ func ParseAdminToken(cfg config.IJwtConfig, tokenString string) (*riMapClaims, e
 			return nil, fmt.Errorf("signing method is invalid")
 		}
 		return cfg.AdminKey(), nil
-	})
+	}, jwt.WithTimeFunc(clk.Now))
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenMalformed) {
 			return nil, fmt.Errorf("token format is invalid")
@@ -132,7 +141,7 @@ func ParseApiKey(cfg config.IJwtConfig, tokenString string) (*riMapClaims, error
 			return nil, fmt.Errorf("signing method is invalid")
 		}
 		return cfg.ApiKey(), nil
-	})
+	}, jwt.WithTimeFunc(clk.Now))
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenMalformed) {
 			return nil, fmt.Errorf("token format is invalid")
@@ -161,8 +170,8 @@ func RepeatToken(cfg config.IJwtConfig, claims *users.UserClaims, exp int64) str
 				Subject:   "refresh-token",
 				Audience:  []string{"customer", "admin"},
 				ExpiresAt: jwtTimeRepeatAdapter(exp),
-				NotBefore: jwt.NewNumericDate(time.Now()),
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				NotBefore: jwt.NewNumericDate(clk.Now()),
+				IssuedAt:  jwt.NewNumericDate(clk.Now()),
 			},
 		},
 	}
@@ -197,8 +206,8 @@ func newAccessToken(cfg config.IJwtConfig, claims *users.UserClaims) IRiAuth {
 				Subject:   "access-token",
 				Audience:  []string{"customer", "admin"},
 				ExpiresAt: jwtTimeDuration(cfg.AccessExpiresAt()),
-				NotBefore: jwt.NewNumericDate(time.Now()),
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				NotBefore: jwt.NewNumericDate(clk.Now()),
+				IssuedAt:  jwt.NewNumericDate(clk.Now()),
 			},
 		},
 	}
@@ -214,8 +223,8 @@ func newRefreshToken(cfg config.IJwtConfig, claims *users.UserClaims) IRiAuth {
 				Subject:   "refresh-token",
 				Audience:  []string{"customer", "admin"},
 				ExpiresAt: jwtTimeDuration(cfg.RefreshExpiresAt()),
-				NotBefore: jwt.NewNumericDate(time.Now()),
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				NotBefore: jwt.NewNumericDate(clk.Now()),
+				IssuedAt:  jwt.NewNumericDate(clk.Now()),
 			},
 		},
 	}
@@ -232,8 +241,8 @@ func newAdminToken(cfg config.IJwtConfig) IRiAuth {
 					Subject:   "admin-token",
 					Audience:  []string{"admin"},
 					ExpiresAt: jwtTimeDuration(300),
-					NotBefore: jwt.NewNumericDate(time.Now()),
-					IssuedAt:  jwt.NewNumericDate(time.Now()),
+					NotBefore: jwt.NewNumericDate(clk.Now()),
+					IssuedAt:  jwt.NewNumericDate(clk.Now()),
 				},
 			},
 		},
@@ -250,11 +259,59 @@ func newApiKey(cfg config.IJwtConfig) IRiAuth {
 					Issuer:    "rishop-api",
 					Subject:   "api-key",
 					Audience:  []string{"admin", "customer"},
-					ExpiresAt: jwt.NewNumericDate(time.Now().AddDate(2, 0, 0)),
-					NotBefore: jwt.NewNumericDate(time.Now()),
-					IssuedAt:  jwt.NewNumericDate(time.Now()),
+					ExpiresAt: jwt.NewNumericDate(clk.Now().AddDate(2, 0, 0)),
+					NotBefore: jwt.NewNumericDate(clk.Now()),
+					IssuedAt:  jwt.NewNumericDate(clk.Now()),
 				},
 			},
 		},
 	}
 }
+
+// riPreviewClaims let the storefront see draft products and the promotions which run at At
+type riPreviewClaims struct {
+	At *jwt.NumericDate `json:"at"`
+	jwt.RegisteredClaims
+}
+
+// NewPreviewToken is signed with the admin key, it is only for reviewing the catalog and never for checkout
+func NewPreviewToken(cfg config.IJwtConfig, at time.Time, expires int) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &riPreviewClaims{
+		At: jwt.NewNumericDate(at),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "rishop-api",
+			Subject:   "preview-token",
+			Audience:  []string{"admin"},
+			ExpiresAt: jwtTimeDuration(expires),
+			NotBefore: jwt.NewNumericDate(clk.Now()),
+			IssuedAt:  jwt.NewNumericDate(clk.Now()),
+		},
+	})
+	ss, _ := token.SignedString(cfg.AdminKey())
+	return ss
+}
+
+func ParsePreviewToken(cfg config.IJwtConfig, tokenString string) (*riPreviewClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &riPreviewClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("signing method is invalid")
+		}
+		return cfg.AdminKey(), nil
+	}, jwt.WithTimeFunc(clk.Now))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenMalformed) {
+			return nil, fmt.Errorf("token format is invalid")
+		} else if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("token is expired")
+		} else {
+			return nil, fmt.Errorf("parse token  failed : %v", err)
+		}
+	}
+
+	// admin token ก็ sign ด้วย admin key
+	claims, ok := token.Claims.(*riPreviewClaims)
+	if !ok || claims.Subject != "preview-token" || claims.At == nil {
+		return nil, fmt.Errorf("claims type is invalid")
+	}
+	return claims, nil
+}
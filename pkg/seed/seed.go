@@ -0,0 +1,320 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password of every seeded user, the seed is only for development
+const Password = "rishop-dev"
+
+type user struct {
+	Username string
+	Email    string
+	RoleId   int
+	ShopName string // the user is an approved seller when set
+}
+
+type product struct {
+	Title       string
+	Description string
+	Price       float64
+	Stock       int
+	Category    string
+	Images      []string
+	Seller      string // email of the seller
+}
+
+type order struct {
+	Key      string // saved in the order tags as seed:<key> so the order is not inserted twice
+	Customer string // email of the customer
+	Status   string
+	Items    map[string]int // product title and qty
+}
+
+var categories = []string{
+	"food & beverage",
+	"fashion",
+	"gadget",
+	"home & living",
+}
+
+var users = []*user{
+	{Username: "seed-customer", Email: "customer@seed.ri-shop.dev", RoleId: 1},
+	{Username: "seed-admin", Email: "admin@seed.ri-shop.dev", RoleId: 2},
+	{Username: "seed-seller", Email: "seller@seed.ri-shop.dev", RoleId: 4, ShopName: "Seed Shop"},
+}
+
+var products = []*product{
+	{
+		Title:       "Cold Brew Coffee",
+		Description: "Sample food & beverage product",
+		Price:       120,
+		Stock:       50,
+		Category:    "food & beverage",
+		Images:      []string{"https://i.pinimg.com/564x/4a/1c/4a/4a1c4a9755e4d3bdfcb45a1c3a58712f.jpg"},
+	},
+	{
+		Title:       "Linen Shirt",
+		Description: "Sample fashion product",
+		Price:       890,
+		Stock:       20,
+		Category:    "fashion",
+		Images:      []string{"https://i.pinimg.com/564x/a0/6b/70/a06b708becbefa5d642392d7bf805429.jpg"},
+	},
+	{
+		Title:       "Wireless Earbuds",
+		Description: "Sample gadget product",
+		Price:       2490,
+		Stock:       15,
+		Category:    "gadget",
+		Images:      []string{"https://i.pinimg.com/564x/d5/95/e4/d595e4530aaa0fcdf4ff8e7bc17f4d86.jpg"},
+	},
+	{
+		Title:       "Ceramic Mug",
+		Description: "Sample marketplace product of the seed seller",
+		Price:       250,
+		Stock:       30,
+		Category:    "home & living",
+		Images:      []string{"https://i.pinimg.com/564x/6d/ba/91/6dba91c1fdb5d4939c7e9d65420cbd4c.jpg"},
+		Seller:      "seller@seed.ri-shop.dev",
+	},
+}
+
+var orders = []*order{
+	{
+		Key:      "waiting",
+		Customer: "customer@seed.ri-shop.dev",
+		Status:   "waiting",
+		Items:    map[string]int{"Cold Brew Coffee": 2, "Ceramic Mug": 1},
+	},
+	{
+		Key:      "completed",
+		Customer: "customer@seed.ri-shop.dev",
+		Status:   "completed",
+		Items:    map[string]int{"Linen Shirt": 1, "Wireless Earbuds": 1},
+	},
+}
+
+// Run upsert categories, users of each role, products with images and demo orders in one transaction.
+// Rows are matched by their title, email or seed tag, so running it again does not duplicate them
+func Run(db *sqlx.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %v", err)
+	}
+
+	steps := []func(ctx context.Context, tx *sqlx.Tx) error{
+		seedCategories,
+		seedUsers,
+		seedProducts,
+		seedOrders,
+	}
+	for _, step := range steps {
+		if err := step(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit seed failed: %v", err)
+	}
+	log.Printf("seeded %d categories, %d users, %d products and %d orders", len(categories), len(users), len(products), len(orders))
+	return nil
+}
+
+func seedCategories(ctx context.Context, tx *sqlx.Tx) error {
+	for _, title := range categories {
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "categories" ("title")
+		VALUES ($1)
+		ON CONFLICT ("title") DO NOTHING;`, title); err != nil {
+			return fmt.Errorf("seed category %s failed: %v", title, err)
+		}
+	}
+	return nil
+}
+
+func seedUsers(ctx context.Context, tx *sqlx.Tx) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(Password), 10)
+	if err != nil {
+		return fmt.Errorf("hashed password failed: %v", err)
+	}
+
+	for _, u := range users {
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "users" (
+			"username",
+			"email",
+			"password",
+			"role_id"
+		)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING;`, u.Username, u.Email, string(hashed), u.RoleId); err != nil {
+			return fmt.Errorf("seed user %s failed: %v", u.Email, err)
+		}
+
+		if u.ShopName == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "sellers" (
+			"user_id",
+			"shop_name",
+			"bank_account",
+			"status",
+			"approved_at"
+		)
+		SELECT
+			"id",
+			$2,
+			'000-0-00000-0',
+			'approved',
+			now()
+		FROM "users"
+		WHERE "email" = $1
+		ON CONFLICT ("user_id") DO NOTHING;`, u.Email, u.ShopName); err != nil {
+			return fmt.Errorf("seed seller %s failed: %v", u.Email, err)
+		}
+	}
+	return nil
+}
+
+func seedProducts(ctx context.Context, tx *sqlx.Tx) error {
+	for _, p := range products {
+		// products ไม่มี unique key ใช้ title ของ seed แทน
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "products" (
+			"title",
+			"description",
+			"price_minor",
+			"stock",
+			"seller_id"
+		)
+		SELECT
+			$1,
+			$2,
+			major_to_minor($3, 'THB'),
+			$4,
+			(SELECT "id" FROM "users" WHERE "email" = NULLIF($5, ''))
+		WHERE NOT EXISTS (
+			SELECT 1 FROM "products" WHERE "title" = $1
+		);`, p.Title, p.Description, p.Price, p.Stock, p.Seller); err != nil {
+			return fmt.Errorf("seed product %s failed: %v", p.Title, err)
+		}
+
+		var productId string
+		if err := tx.GetContext(ctx, &productId, `SELECT "id" FROM "products" WHERE "title" = $1 ORDER BY "id" LIMIT 1;`, p.Title); err != nil {
+			return fmt.Errorf("find seed product %s failed: %v", p.Title, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "products_categories" (
+			"product_id",
+			"category_id"
+		)
+		SELECT
+			$1,
+			"c"."id"
+		FROM "categories" "c"
+		WHERE "c"."title" = $2
+		AND NOT EXISTS (
+			SELECT 1 FROM "products_categories" WHERE "product_id" = $1
+		);`, productId, p.Category); err != nil {
+			return fmt.Errorf("seed category of product %s failed: %v", p.Title, err)
+		}
+
+		for i, url := range p.Images {
+			filename := fmt.Sprintf("seed_%s_%d.jpg", productId, i+1)
+			if _, err := tx.ExecContext(ctx, `
+			INSERT INTO "images" (
+				"filename",
+				"url",
+				"product_id",
+				"position",
+				"is_primary"
+			)
+			SELECT $1, $2, $3, $4, $5
+			WHERE NOT EXISTS (
+				SELECT 1 FROM "images" WHERE "product_id" = $3 AND "filename" = $1
+			);`, filename, url, productId, i, i == 0); err != nil {
+				return fmt.Errorf("seed image of product %s failed: %v", p.Title, err)
+			}
+		}
+	}
+	return nil
+}
+
+func seedOrders(ctx context.Context, tx *sqlx.Tx) error {
+	for _, o := range orders {
+		tag := "seed:" + o.Key
+
+		var orderId string
+		err := tx.GetContext(ctx, &orderId, `
+		INSERT INTO "orders" (
+			"user_id",
+			"contact",
+			"address",
+			"status",
+			"tags"
+		)
+		SELECT
+			"id",
+			'0800000000',
+			'1 Seed Road, Bangkok 10110',
+			$2,
+			ARRAY[$3]::VARCHAR[]
+		FROM "users"
+		WHERE "email" = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM "orders" WHERE $3 = ANY("tags")
+		)
+			RETURNING "id";`, o.Customer, o.Status, tag)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("seed order %s failed: %v", o.Key, err)
+		}
+
+		// snapshot ของ product เหมือนตอนสั่งซื้อจริง
+		for title, qty := range o.Items {
+			if _, err := tx.ExecContext(ctx, `
+			INSERT INTO "products_orders" (
+				"order_id",
+				"qty",
+				"product",
+				"seller_id"
+			)
+			SELECT
+				$1,
+				$2,
+				jsonb_build_object(
+					'id', "p"."id",
+					'title', "p"."title",
+					'description', "p"."description",
+					'price', minor_to_major("p"."price_minor", "p"."currency"),
+					'currency', "p"."currency",
+					'seller_id', COALESCE("p"."seller_id", '')
+				),
+				"p"."seller_id"
+			FROM "products" "p"
+			WHERE "p"."title" = $3
+			ORDER BY "p"."id"
+			LIMIT 1;`, orderId, qty, title); err != nil {
+				return fmt.Errorf("seed items of order %s failed: %v", o.Key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Package serializer is the json policy of every response: keys are snake_case, statuses are string enums,
+// lists are never null and a value which may be missing is a pointer (null) or has omitempty.
+// Marshal is the JSONEncoder of fiber and Check is run on the api types by the golden tests
+package serializer
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Marshal write nil slices and maps as [] and {}, omitempty still omit them
+func Marshal(v any) ([]byte, error) {
+	if v == nil {
+		return json.Marshal(v)
+	}
+	return json.Marshal(normalize(reflect.ValueOf(v)).Interface())
+}
+
+// customized is a type which encode itself, e.g. time.Time, it is left as it is
+func customized(t reflect.Type) bool {
+	return t.Implements(jsonMarshaler) || t.Implements(textMarshaler) ||
+		reflect.PointerTo(t).Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(textMarshaler)
+}
+
+// normalize copy v with the nil lists replaced, the value given by the caller is never changed.
+// Responses are trees so there is no cycle check
+func normalize(v reflect.Value) reflect.Value {
+	if !v.IsValid() || customized(v.Type()) {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(normalize(v.Elem()))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(normalize(v.Elem()))
+		return out
+	case reflect.Slice:
+		// []byte is base64 and json.RawMessage is already json
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		if v.IsNil() {
+			return reflect.MakeSlice(v.Type(), 0, 0)
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(normalize(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(normalize(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.MakeMap(v.Type())
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), normalize(iter.Value()))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				out.Field(i).Set(normalize(v.Field(i)))
+			}
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Check return the fields of t and of the types it contains which break the policy:
+// every exported field has a snake_case json key or "-" and a field named *Status is a string
+func Check(t reflect.Type) []string {
+	violations := make([]string, 0)
+	check(t, make(map[reflect.Type]bool), &violations)
+	return violations
+}
+
+func check(t reflect.Type, visited map[reflect.Type]bool, violations *[]string) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] || customized(t) {
+		return
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		switch {
+		case tag == "" && field.Anonymous:
+			// fields of an embedded struct are promoted
+		case tag == "":
+			*violations = append(*violations, fmt.Sprintf("%s.%s has no json tag", t, field.Name))
+			continue
+		case name == "-":
+			continue
+		case !snakeCase.MatchString(name):
+			*violations = append(*violations, fmt.Sprintf("%s.%s json key %s is not snake_case", t, field.Name, name))
+		}
+
+		if strings.HasSuffix(field.Name, "Status") {
+			status := field.Type
+			for status.Kind() == reflect.Pointer {
+				status = status.Elem()
+			}
+			if status.Kind() != reflect.String {
+				*violations = append(*violations, fmt.Sprintf("%s.%s must be a string enum", t, field.Name))
+			}
+		}
+		check(field.Type, visited, violations)
+	}
+}
@@ -0,0 +1,164 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/NatthawutSK/ri-shop/modules/appinfo"
+	"github.com/NatthawutSK/ri-shop/modules/entities"
+	"github.com/NatthawutSK/ri-shop/modules/orders"
+	"github.com/NatthawutSK/ri-shop/modules/products"
+	"github.com/NatthawutSK/ri-shop/modules/users"
+)
+
+// go test ./pkg/serializer -update rewrite the golden files after an intended change of the api
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// apiTypes is what products, orders and users return
+var apiTypes = []any{
+	products.Products{},
+	products.Availability{},
+	products.ProductUpdateRes{},
+	products.SimilarProduct{},
+	orders.Order{},
+	orders.PackingSlip{},
+	orders.GiftTracking{},
+	orders.OrderEvent{},
+	users.UserPassport{},
+	users.UserImportReport{},
+	entities.ErrorResponse{},
+}
+
+func fixtureProduct() *products.Products {
+	return &products.Products{
+		Id:          "P000001",
+		Title:       "Cold Brew Coffee",
+		Description: "Sample food & beverage product",
+		Category:    &appinfo.Category{Id: 1, Title: "food & beverage"},
+		CreatedAt:   "2024-01-01T00:00:00",
+		UpdatedAt:   "2024-01-02T00:00:00",
+		Price:       120,
+		Currency:    "THB",
+		Stock:       50,
+		Status:      products.StatusPublished,
+		Images: []*entities.Image{
+			{Id: "1", FileName: "coffee.jpg", Url: "https://example.com/coffee.jpg", IsPrimary: true},
+		},
+		Version: 3,
+	}
+}
+
+var fixtures = map[string]any{
+	"product": fixtureProduct(),
+	"order": &orders.Order{
+		Id:     "O000001",
+		UserId: "U000001",
+		Products: []*orders.ProductsOrder{
+			{Id: "1", Qty: 2, Product: fixtureProduct()},
+		},
+		Address:        "1 Seed Road, Bangkok 10110",
+		Contact:        "0800000000",
+		Status:         orders.StatusWaiting,
+		TotalPaid:      290,
+		ShippingMethod: "flat:standard",
+		ShippingFee:    50,
+		Fulfillment:    orders.FulfillmentDelivery,
+		CreatedAt:      "2024-01-01T00:00:00",
+		UpdatedAt:      "2024-01-01T00:00:00",
+	},
+	"passport": &users.UserPassport{
+		User: &users.User{
+			Id:       "U000001",
+			Email:    "customer@seed.ri-shop.dev",
+			Username: "seed-customer",
+			RoleId:   1,
+		},
+		Token: &users.UserToken{
+			Id:           "1",
+			AccessToken:  "access",
+			RefreshToken: "refresh",
+		},
+	},
+}
+
+func TestPolicy(t *testing.T) {
+	for _, v := range apiTypes {
+		for _, violation := range Check(reflect.TypeOf(v)) {
+			t.Error(violation)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	type bad struct {
+		Id          string `json:"id"`
+		CreatedAt   string `json:"createdAt"`
+		OrderStatus int    `json:"order_status"`
+		Untagged    string
+	}
+
+	if got := len(Check(reflect.TypeOf(bad{}))); got != 3 {
+		t.Fatalf("Check() found %d violations, want 3", got)
+	}
+}
+
+func TestMarshalNil(t *testing.T) {
+	type res struct {
+		Items   []string          `json:"items"`
+		Tags    []string          `json:"tags,omitempty"`
+		Attrs   map[string]string `json:"attrs"`
+		Parent  *res              `json:"parent"`
+		Payload any               `json:"payload"`
+		Raw     json.RawMessage   `json:"raw,omitempty"`
+	}
+
+	in := &res{Payload: &res{}}
+	got, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"items":[],"attrs":{},"parent":null,"payload":{"items":[],"attrs":{},"parent":null,"payload":null}}`
+	if string(got) != want {
+		t.Fatalf("Marshal() = %s, want %s", got, want)
+	}
+	if in.Items != nil || in.Payload.(*res).Attrs != nil {
+		t.Fatal("Marshal() changed the value of the caller")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	for name, v := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			raw, err := Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := new(bytes.Buffer)
+			if err := json.Indent(got, raw, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			got.WriteByte('\n')
+
+			path := filepath.Join("testdata", name+".golden.json")
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file failed, run with -update to create it: %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("%s changed the api shape, run with -update if it is intended\ngot:\n%s\nwant:\n%s", name, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package sqlbuilder number the $n placeholders of the queries which are built from parts,
+// e.g. a SET of the fields the client sent or a WHERE of the filters it used. Parts are written
+// with ? and every part adds its values to the same Args, so the numbers always match the values.
+// A ? is always a placeholder, do not use the jsonb ? operators in a part, use jsonb_exists instead
+package sqlbuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Args is the values of one query in the order of their placeholders
+type Args struct {
+	values []any
+}
+
+func NewArgs() *Args {
+	return &Args{
+		values: make([]any, 0),
+	}
+}
+
+// Add append value and return its placeholder, e.g. $3
+func (a *Args) Add(value any) string {
+	a.values = append(a.values, value)
+	return "$" + strconv.Itoa(len(a.values))
+}
+
+// Bind replace each ? of expr with the placeholder of the value at the same position,
+// a different number of ? and values is a bug of the caller so it panics
+func (a *Args) Bind(expr string, values ...any) string {
+	if n := strings.Count(expr, "?"); n != len(values) {
+		panic(fmt.Sprintf("sqlbuilder: %d values for %d placeholders in %q", len(values), n, expr))
+	}
+
+	var sb strings.Builder
+	parts := strings.Split(expr, "?")
+	for i, part := range parts {
+		sb.WriteString(part)
+		if i < len(values) {
+			sb.WriteString(a.Add(values[i]))
+		}
+	}
+	return sb.String()
+}
+
+func (a *Args) Values() []any {
+	return a.values
+}
+
+func (a *Args) Len() int {
+	return len(a.values)
+}
+
+// Set is the assignments of an UPDATE, written after SET
+type Set struct {
+	args   *Args
+	fields []string
+}
+
+func NewSet(args *Args) *Set {
+	return &Set{
+		args:   args,
+		fields: make([]string, 0),
+	}
+}
+
+// Add assign value to column, the name is quoted like the other queries of the repo
+func (s *Set) Add(column string, value any) {
+	s.fields = append(s.fields, fmt.Sprintf(`"%s" = %s`, column, s.args.Add(value)))
+}
+
+// Expr add an assignment with ? for its values, e.g. `"version" = "version" + 1`
+func (s *Set) Expr(expr string, values ...any) {
+	s.fields = append(s.fields, s.args.Bind(expr, values...))
+}
+
+func (s *Set) Len() int {
+	return len(s.fields)
+}
+
+func (s *Set) String() string {
+	if len(s.fields) == 0 {
+		return ""
+	}
+	return "\n\t\t" + strings.Join(s.fields, ",\n\t\t")
+}
+
+// Where is conditions joined by AND, String is written after a WHERE 1 = 1 so the query
+// is valid without any condition
+type Where struct {
+	args       *Args
+	conditions []string
+}
+
+func NewWhere(args *Args) *Where {
+	return &Where{
+		args:       args,
+		conditions: make([]string, 0),
+	}
+}
+
+// And add a condition with ? for its values
+func (w *Where) And(condition string, values ...any) {
+	w.conditions = append(w.conditions, w.args.Bind(condition, values...))
+}
+
+func (w *Where) Len() int {
+	return len(w.conditions)
+}
+
+func (w *Where) String() string {
+	var sb strings.Builder
+	for _, condition := range w.conditions {
+		sb.WriteString("\n\t\tAND ")
+		sb.WriteString(condition)
+	}
+	return sb.String()
+}
+
+// Values build the rows of a bulk INSERT, each row must have the same number of values
+func Values(args *Args, rows [][]any) string {
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != len(rows[0]) {
+			panic(fmt.Sprintf("sqlbuilder: row of %d values, the first row has %d", len(row), len(rows[0])))
+		}
+		placeholders := make([]string, 0, len(row))
+		for _, value := range row {
+			placeholders = append(placeholders, args.Add(value))
+		}
+		lines = append(lines, "("+strings.Join(placeholders, ", ")+")")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n\t\t" + strings.Join(lines, ",\n\t\t")
+}
@@ -0,0 +1,100 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	args := NewArgs()
+	args.Add("P000001")
+
+	got := args.Bind(`(LOWER("p"."title") LIKE ? OR LOWER("p"."description") LIKE ?)`, "%a%", "%b%")
+	want := `(LOWER("p"."title") LIKE $2 OR LOWER("p"."description") LIKE $3)`
+	if got != want {
+		t.Errorf("Bind() = %s, want %s", got, want)
+	}
+	if !reflect.DeepEqual(args.Values(), []any{"P000001", "%a%", "%b%"}) {
+		t.Errorf("Values() = %v", args.Values())
+	}
+}
+
+func TestBindMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Bind() with too few values did not panic")
+		}
+	}()
+	NewArgs().Bind(`"a" = ? AND "b" = ?`, 1)
+}
+
+func TestSet(t *testing.T) {
+	args := NewArgs()
+	set := NewSet(args)
+	if set.String() != "" {
+		t.Errorf("empty String() = %q", set.String())
+	}
+
+	set.Add("title", "Tea")
+	set.Expr(`"price_minor" = major_to_minor(?, "currency")`, 150.5)
+	set.Expr(`"version" = "version" + 1`)
+
+	got := strings.Fields(set.String())
+	want := strings.Fields(`"title" = $1, "price_minor" = major_to_minor($2, "currency"), "version" = "version" + 1`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("String() = %v, want %v", got, want)
+	}
+	if set.Len() != 3 || args.Len() != 2 {
+		t.Errorf("Len() = %d, args.Len() = %d, want 3 and 2", set.Len(), args.Len())
+	}
+}
+
+func TestWhere(t *testing.T) {
+	args := NewArgs()
+	where := NewWhere(args)
+	if where.String() != "" {
+		t.Errorf("empty String() = %q", where.String())
+	}
+
+	where.And(`"p"."status" = 'published'`)
+	where.And(`"p"."id" = ?`, "P000001")
+	where.And(`product_ships_to("p"."id", ?, ?)`, "TH", "")
+
+	got := strings.Fields(where.String())
+	want := strings.Fields(`AND "p"."status" = 'published' AND "p"."id" = $1 AND product_ships_to("p"."id", $2, $3)`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("String() = %v, want %v", got, want)
+	}
+
+	// placeholders after the where continue from its values
+	if got := args.Add(10); got != "$4" {
+		t.Errorf("Add() after where = %s, want $4", got)
+	}
+}
+
+func TestValues(t *testing.T) {
+	args := NewArgs()
+	args.Add("P000001")
+
+	got := strings.Fields(Values(args, [][]any{
+		{"a.jpg", "https://example.com/a.jpg"},
+		{"b.jpg", "https://example.com/b.jpg"},
+	}))
+	want := strings.Fields(`($2, $3), ($4, $5)`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %v, want %v", got, want)
+	}
+	if Values(NewArgs(), nil) != "" {
+		t.Error("Values() of no rows is not empty")
+	}
+}
+
+func TestValuesUneven(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Values() with uneven rows did not panic")
+		}
+	}()
+	Values(NewArgs(), [][]any{{1, 2}, {3}})
+}
@@ -0,0 +1,275 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Message types of RFC 6455
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// Close codes
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseTooBig        = 1009
+)
+
+const (
+	acceptGuid     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxMessageSize = 64 * 1024
+
+	// PingInterval is how often the server pings, the connection is dropped when nothing is read for PongWait
+	PingInterval = 30 * time.Second
+	PongWait     = 60 * time.Second
+	writeWait    = 10 * time.Second
+)
+
+var ErrClosed = errors.New("websocket is closed")
+
+// Conn is a server side websocket connection, WriteMessage is safe to call from many goroutines
+// but ReadMessage must be called from one goroutine only
+type Conn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	mu     sync.Mutex
+	closed bool
+	locals map[string]any
+}
+
+// IsUpgrade report whether the request asks for a websocket
+func IsUpgrade(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") &&
+		strings.Contains(strings.ToLower(c.Get(fiber.HeaderConnection)), "upgrade")
+}
+
+// New upgrade the request and hand the connection to handler, it must be the last handler of the route.
+// Locals set by the middlewares before it, e.g. userId, are copied to the connection.
+func New(handler func(conn *Conn)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("Sec-WebSocket-Key")
+		if !IsUpgrade(c) || key == "" {
+			return fiber.ErrUpgradeRequired
+		}
+		if c.Get("Sec-WebSocket-Version") != "13" {
+			c.Set("Sec-WebSocket-Version", "13")
+			return fiber.ErrUpgradeRequired
+		}
+
+		locals := make(map[string]any)
+		c.Context().VisitUserValues(func(k []byte, v any) {
+			locals[string(k)] = v
+		})
+
+		c.Status(fiber.StatusSwitchingProtocols)
+		c.Set(fiber.HeaderUpgrade, "websocket")
+		c.Set(fiber.HeaderConnection, "Upgrade")
+		c.Set("Sec-WebSocket-Accept", acceptKey(key))
+
+		c.Context().Hijack(func(nc net.Conn) {
+			conn := &Conn{
+				conn:   nc,
+				br:     bufio.NewReader(nc),
+				locals: locals,
+			}
+			defer conn.conn.Close()
+
+			handler(conn)
+		})
+		return nil
+	}
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGuid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (c *Conn) Locals(key string) any {
+	return c.locals[key]
+}
+
+// ReadMessage return the next text or binary message, pings are answered and ErrClosed is returned
+// when the client closes the connection
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	message := make([]byte, 0)
+
+	for {
+		if err := c.conn.SetReadDeadline(time.Now().Add(PongWait)); err != nil {
+			return 0, nil, err
+		}
+
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			c.Close(CloseNormal)
+			return 0, nil, ErrClosed
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				c.Close(CloseProtocolError)
+				return 0, nil, ErrClosed
+			}
+			messageType = opcode
+		case 0:
+			// continuation ของ message ที่ยังไม่จบ
+			if messageType == 0 {
+				c.Close(CloseProtocolError)
+				return 0, nil, ErrClosed
+			}
+		default:
+			c.Close(CloseProtocolError)
+			return 0, nil, ErrClosed
+		}
+
+		if len(message)+len(payload) > maxMessageSize {
+			c.Close(CloseTooBig)
+			return 0, nil, ErrClosed
+		}
+		message = append(message, payload...)
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.br, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		b := make([]byte, 2)
+		if _, err := io.ReadFull(c.br, b); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(b))
+	case 127:
+		b := make([]byte, 8)
+		if _, err := io.ReadFull(c.br, b); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(b)
+	}
+
+	// client ต้อง mask ทุก frame ตาม RFC 6455
+	if !masked {
+		c.Close(CloseProtocolError)
+		return false, 0, nil, ErrClosed
+	}
+	if length > maxMessageSize {
+		c.Close(CloseTooBig)
+		return false, 0, nil, ErrClosed
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(c.br, mask); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	return c.writeFrame(messageType, data)
+}
+
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+	header := []byte{0x80 | byte(opcode)}
+	switch {
+	case len(data) < 126:
+		header = append(header, byte(len(data)))
+	case len(data) <= 0xffff:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(data)))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(data)))
+	}
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(append(header, data...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Close send the close frame, the connection itself is closed when the handler returns
+func (c *Conn) Close(code int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	_ = c.writeFrame(CloseMessage, payload)
+	c.closed = true
+}
+
+// KeepAlive ping the client every PingInterval until done is closed, must be called in a goroutine
+func (c *Conn) KeepAlive(done <-chan struct{}) {
+	ticker := time.NewTicker(PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := c.WriteMessage(PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package workerpool run a batch of jobs on a fixed number of goroutines. Every job of a batch ends with a
+// result or an error before Map return, a job which fails or panics never leave another worker blocked, and
+// the pools keep counters of their batches for the metrics
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Options struct {
+	Workers  int  // goroutines of the batch, at least 1 and never more than the jobs
+	FailFast bool // after the first error the jobs which did not start are skipped and ctx of the running ones is canceled
+}
+
+// ErrSkipped is the error of a job which did not start because ctx was done
+var ErrSkipped = errors.New("job skipped")
+
+// JobError is the error of one job, Index is its position in the batch. Its message is the message of Err so
+// a caller which check the message of a single job keep working
+type JobError struct {
+	Index int
+	Err   error
+}
+
+func (e *JobError) Error() string { return e.Err.Error() }
+func (e *JobError) Unwrap() error { return e.Err }
+
+// Errors is the failed jobs of a batch in the order of the batch, errors.Is and errors.As look into every job
+type Errors []*JobError
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", e[0].Error(), len(e)-1)
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// PanicError is a job which panicked, the worker recover and go on with the next job
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("job panicked: %v", e.Value) }
+
+// Pool is the counters of the batches of one kind of job, e.g. the uploads to the bucket
+type Pool struct {
+	name string
+
+	batches   atomic.Int64
+	running   atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	panics    atomic.Int64
+
+	mu        sync.Mutex
+	lastErr   string
+	lastErrAt time.Time
+}
+
+var (
+	poolsMu sync.Mutex
+	pools   = make(map[string]*Pool)
+)
+
+// Get return the pool of a kind of job, it is created on the first call
+func Get(name string) *Pool {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	p, ok := pools[name]
+	if !ok {
+		p = &Pool{name: name}
+		pools[name] = p
+	}
+	return p
+}
+
+// Map run fn for every job on opts.Workers goroutines and return the results in the order of the jobs. When a
+// job fails its result is the zero value and the error is an Errors, with FailFast it has only the first error
+func Map[T, R any](ctx context.Context, p *Pool, opts Options, jobs []T, fn func(ctx context.Context, job T) (R, error)) ([]R, error) {
+	results := make([]R, len(jobs))
+	if len(jobs) == 0 {
+		return results, nil
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p.batches.Add(1)
+
+	var (
+		next    atomic.Int64
+		stopped atomic.Bool
+		mu      sync.Mutex
+		errs    Errors
+		wg      sync.WaitGroup
+	)
+	fail := func(i int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// งานที่ล้มเพราะ batch ถูกยกเลิกแล้วไม่นับ ได้แค่ error แรก
+		if opts.FailFast && len(errs) > 0 {
+			return
+		}
+		errs = append(errs, &JobError{Index: i, Err: err})
+		if opts.FailFast {
+			stopped.Store(true)
+			cancel()
+		}
+	}
+
+	// งานแจกด้วย index ไม่ใช่ channel worker ที่เจอ error จึงไม่ทิ้งงานค้างหรือผู้ส่งที่รออยู่
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(jobs) || stopped.Load() {
+					return
+				}
+				if ctx.Err() != nil {
+					fail(i, fmt.Errorf("%w: %v", ErrSkipped, ctx.Err()))
+					continue
+				}
+
+				result, err := run(ctx, p, func(ctx context.Context) (R, error) { return fn(ctx, jobs[i]) })
+				if err != nil {
+					fail(i, err)
+					continue
+				}
+				results[i] = result
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Index < errs[j].Index })
+		return results, errs
+	}
+	return results, nil
+}
+
+// Run is Map of jobs without a result
+func Run[T any](ctx context.Context, p *Pool, opts Options, jobs []T, fn func(ctx context.Context, job T) error) error {
+	_, err := Map(ctx, p, opts, jobs, func(ctx context.Context, job T) (struct{}, error) {
+		return struct{}{}, fn(ctx, job)
+	})
+	return err
+}
+
+// run call fn with the counters of the pool, a panic of fn is returned as a *PanicError
+func run[R any](ctx context.Context, p *Pool, fn func(ctx context.Context) (R, error)) (result R, err error) {
+	p.running.Add(1)
+	defer func() {
+		p.running.Add(-1)
+		if v := recover(); v != nil {
+			p.panics.Add(1)
+			panicErr := &PanicError{Value: v, Stack: debug.Stack()}
+			log.Printf("worker pool %s: %v\n%s", p.name, v, panicErr.Stack)
+			err = panicErr
+		}
+		if err != nil {
+			p.failed.Add(1)
+			p.mu.Lock()
+			p.lastErr = err.Error()
+			p.lastErrAt = time.Now()
+			p.mu.Unlock()
+			return
+		}
+		p.completed.Add(1)
+	}()
+	return fn(ctx)
+}
+
+// Status is a pool at the time it is read, for the metrics
+type Status struct {
+	Name        string `json:"name"`
+	Batches     int64  `json:"batches"`
+	Running     int64  `json:"running"` // jobs which are running now
+	Completed   int64  `json:"completed"`
+	Failed      int64  `json:"failed"`
+	Panics      int64  `json:"panics"`
+	LastError   string `json:"last_error,omitempty"`
+	LastErrorAt string `json:"last_error_at,omitempty"`
+}
+
+func (p *Pool) Status() *Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := &Status{
+		Name:      p.name,
+		Batches:   p.batches.Load(),
+		Running:   p.running.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Panics:    p.panics.Load(),
+		LastError: p.lastErr,
+	}
+	if !p.lastErrAt.IsZero() {
+		status.LastErrorAt = p.lastErrAt.UTC().Format(time.RFC3339)
+	}
+	return status
+}
+
+// Statuses return every pool sorted by name
+func Statuses() []*Status {
+	poolsMu.Lock()
+	list := make([]*Pool, 0, len(pools))
+	for _, p := range pools {
+		list = append(list, p)
+	}
+	poolsMu.Unlock()
+
+	statuses := make([]*Status, 0, len(list))
+	for _, p := range list {
+		statuses = append(statuses, p.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
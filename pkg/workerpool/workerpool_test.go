@@ -0,0 +1,169 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMapOrder(t *testing.T) {
+	jobs := []int{5, 1, 4, 2, 3}
+	results, err := Map(context.Background(), Get("test.order"), Options{Workers: 3}, jobs, func(ctx context.Context, job int) (int, error) {
+		time.Sleep(time.Duration(job) * time.Millisecond)
+		return job * 10, nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for i, job := range jobs {
+		if results[i] != job*10 {
+			t.Errorf("expected the results in the order of the jobs, got: %v", results)
+			break
+		}
+	}
+}
+
+func TestMapConcurrency(t *testing.T) {
+	var running, most atomic.Int64
+	jobs := make([]int, 20)
+	err := Run(context.Background(), Get("test.concurrency"), Options{Workers: 4}, jobs, func(ctx context.Context, job int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if most.Load() > 4 {
+		t.Errorf("expected at most 4 jobs at once, got: %d", most.Load())
+	}
+}
+
+type codeError struct{ code int }
+
+func (e *codeError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestMapErrors(t *testing.T) {
+	p := Get("test.errors")
+	before := p.Status()
+	jobs := []int{1, 2, 3, 4, 5, 6}
+	results, err := Map(context.Background(), p, Options{Workers: 2}, jobs, func(ctx context.Context, job int) (int, error) {
+		if job%2 == 0 {
+			return 0, &codeError{code: job}
+		}
+		return job, nil
+	})
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("expected the 3 failed jobs, got: %v", err)
+	}
+	for i, want := range []int{1, 3, 5} {
+		if errs[i].Index != want {
+			t.Errorf("expected the errors in the order of the jobs, got index %d at %d", errs[i].Index, i)
+		}
+	}
+	var code *codeError
+	if !errors.As(err, &code) || code.code != 2 {
+		t.Errorf("expected errors.As to find the error of a job, got: %v", code)
+	}
+	if err.Error() != "code 2 (and 2 more errors)" {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+	if results[0] != 1 || results[1] != 0 || results[4] != 5 {
+		t.Errorf("expected the results of the jobs which did not fail, got: %v", results)
+	}
+	if status := p.Status(); status.Failed-before.Failed != 3 || status.Completed-before.Completed != 3 || status.LastError == "" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestMapFailFast(t *testing.T) {
+	var started atomic.Int64
+	jobs := make([]int, 50)
+	for i := range jobs {
+		jobs[i] = i
+	}
+	err := Run(context.Background(), Get("test.failfast"), Options{Workers: 2, FailFast: true}, jobs, func(ctx context.Context, job int) error {
+		started.Add(1)
+		if job == 0 {
+			return fmt.Errorf("first job failed")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	if err == nil || err.Error() != "first job failed" {
+		t.Fatalf("expected only the first error, got: %v", err)
+	}
+	if started.Load() > 3 {
+		t.Errorf("expected the batch to stop after the error, got %d jobs started", started.Load())
+	}
+}
+
+func TestMapPanic(t *testing.T) {
+	p := Get("test.panic")
+	before := p.Status()
+	jobs := []int{1, 2, 3}
+	results, err := Map(context.Background(), p, Options{Workers: 1}, jobs, func(ctx context.Context, job int) (int, error) {
+		if job == 2 {
+			panic("boom")
+		}
+		return job, nil
+	})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Fatalf("expected a panic error, got: %v", err)
+	}
+	if results[2] != 3 {
+		t.Errorf("expected the worker to go on after the panic, got: %v", results)
+	}
+	if status := p.Status(); status.Panics-before.Panics != 1 || status.Running != 0 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestMapCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := Run(ctx, Get("test.canceled"), Options{Workers: 2}, []int{1, 2, 3}, func(ctx context.Context, job int) error {
+		called = true
+		return nil
+	})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 3 || !errors.Is(err, ErrSkipped) {
+		t.Fatalf("expected every job to be skipped, got: %v", err)
+	}
+	if called {
+		t.Error("expected no job to run")
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	Get("test.b")
+	Get("test.a")
+	statuses := Statuses()
+	for i := 1; i < len(statuses); i++ {
+		if statuses[i-1].Name >= statuses[i].Name {
+			t.Fatalf("expected the pools sorted by name, got %s before %s", statuses[i-1].Name, statuses[i].Name)
+		}
+	}
+	if Get("test.a") != Get("test.a") {
+		t.Error("expected the same pool for the same name")
+	}
+}